@@ -1,5 +1,6 @@
 // Package aerospikekafkaconnector provides a command to read Aerospike CDC data from Kafka
-// and filter/log operations on specific transaction IDs.
+// and filter/log operations on specific transaction IDs. The change data capture of the SQL
+// UTXO store uses the same message format and can be read as well.
 package aerospikekafkaconnector
 
 import (
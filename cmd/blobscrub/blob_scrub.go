@@ -0,0 +1,132 @@
+// Package blobscrub verifies the integrity of a blob store offline, reading the blob and blockchain stores
+// configured in the settings directly rather than through the running services.
+//
+// A scrub runs once, or, with an interval, repeatedly as a background service until it is interrupted.
+package blobscrub
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/services/blockchain"
+	"github.com/bsv-blockchain/teranode/settings"
+	"github.com/bsv-blockchain/teranode/stores/blob"
+	blockchainstore "github.com/bsv-blockchain/teranode/stores/blockchain"
+	"github.com/bsv-blockchain/teranode/ulogger"
+	"github.com/bsv-blockchain/teranode/util/blobscrub"
+)
+
+// BlobScrub scrubs the blob store, once when the interval is 0, otherwise every interval until the context
+// is cancelled.
+//
+// Parameters:
+//   - ctx: Context for cancellation
+//   - logger: Logger for progress messages
+//   - tSettings: Settings with the store URLs
+//   - store: The store to scrub, "subtree" or "block" for the stores configured in the settings, or a blob store URL
+//   - opts: The options of the scrub
+//   - interval: Time between the start of two runs, 0 to run once
+//   - report: Called with the result of every run
+//
+// Returns:
+//   - error: Any error that stopped a run, the context error is not returned when running at an interval
+func BlobScrub(ctx context.Context, logger ulogger.Logger, tSettings *settings.Settings, store string, opts blobscrub.Options, interval time.Duration, report func(result *blobscrub.Result)) error {
+	scrubber, err := newScrubber(logger, tSettings, store, opts.CheckBlocks)
+	if err != nil {
+		return err
+	}
+
+	if interval <= 0 {
+		result, err := scrubber.Scrub(ctx, opts)
+		if err != nil {
+			return err
+		}
+
+		report(result)
+
+		return nil
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		result, err := scrubber.Scrub(ctx, opts)
+
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case err != nil:
+			// a failed run is retried at the next interval
+			logger.Errorf("[BlobScrub] scrub failed: %v", err)
+		default:
+			report(result)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// newScrubber creates the scrubber over the blob store, and the blockchain store when the subtrees of the
+// blocks are checked
+func newScrubber(logger ulogger.Logger, tSettings *settings.Settings, store string, checkBlocks bool) (*blobscrub.Scrubber, error) {
+	storeURL, err := storeURL(tSettings, store)
+	if err != nil {
+		return nil, err
+	}
+
+	blobStore, err := blob.NewStore(logger, storeURL)
+	if err != nil {
+		return nil, errors.NewStorageError("failed to create blob store", err)
+	}
+
+	var blockchainClient blockchain.ClientI
+
+	if checkBlocks {
+		if tSettings.BlockChain.StoreURL == nil {
+			return nil, errors.NewConfigurationError("blockchain store URL not found in config")
+		}
+
+		blockchainStore, err := blockchainstore.NewStore(logger, tSettings.BlockChain.StoreURL, tSettings)
+		if err != nil {
+			return nil, errors.NewStorageError("failed to create blockchain store", err)
+		}
+
+		if blockchainClient, err = blockchain.NewLocalClient(logger, tSettings, blockchainStore, nil, nil); err != nil {
+			return nil, errors.NewServiceError("failed to create blockchain client", err)
+		}
+	}
+
+	return blobscrub.New(logger, blobStore, blockchainClient), nil
+}
+
+// storeURL returns the URL of the store to scrub
+func storeURL(tSettings *settings.Settings, store string) (*url.URL, error) {
+	var storeURL *url.URL
+
+	switch store {
+	case "subtree":
+		storeURL = tSettings.SubtreeValidation.SubtreeStore
+	case "block":
+		storeURL = tSettings.Block.BlockStore
+	default:
+		parsed, err := url.Parse(store)
+		if err != nil || parsed.Scheme == "" {
+			return nil, errors.NewInvalidArgumentError("store must be subtree, block or a blob store URL, got %q", store)
+		}
+
+		return parsed, nil
+	}
+
+	if storeURL == nil {
+		return nil, errors.NewConfigurationError("%s store URL not found in config", store)
+	}
+
+	return storeURL, nil
+}
@@ -2,14 +2,19 @@ package teranodecli
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"sort"
+	"strings"
+	"syscall"
 
 	"github.com/bsv-blockchain/teranode/cmd/aerospikekafkaconnector"
 	"github.com/bsv-blockchain/teranode/cmd/aerospikereader"
 	"github.com/bsv-blockchain/teranode/cmd/bitcointoutxoset"
+	"github.com/bsv-blockchain/teranode/cmd/blobscrub"
 	"github.com/bsv-blockchain/teranode/cmd/checkblock"
 	"github.com/bsv-blockchain/teranode/cmd/checkblocktemplate"
 	"github.com/bsv-blockchain/teranode/cmd/filereader"
@@ -18,13 +23,19 @@ import (
 	"github.com/bsv-blockchain/teranode/cmd/seeder"
 	"github.com/bsv-blockchain/teranode/cmd/setfsmstate"
 	cmdSettings "github.com/bsv-blockchain/teranode/cmd/settings"
+	"github.com/bsv-blockchain/teranode/cmd/utxomigrate"
 	"github.com/bsv-blockchain/teranode/cmd/utxopersister"
+	"github.com/bsv-blockchain/teranode/cmd/utxosnapshot"
 	"github.com/bsv-blockchain/teranode/cmd/utxovalidator"
+	"github.com/bsv-blockchain/teranode/cmd/verifychain"
 	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
 	"github.com/bsv-blockchain/teranode/settings"
 	"github.com/bsv-blockchain/teranode/stores/blockchain/sql"
+	"github.com/bsv-blockchain/teranode/stores/utxo/migrate"
 	"github.com/bsv-blockchain/teranode/ulogger"
 	"github.com/bsv-blockchain/teranode/util"
+	utilblobscrub "github.com/bsv-blockchain/teranode/util/blobscrub"
 )
 
 // commandHelp stores the command descriptions
@@ -45,6 +56,10 @@ var commandHelp = map[string]string{
 	"resetblockassembly":      "Reset block assembly state",
 	"fix-chainwork":           "Fix incorrect chainwork values in blockchain database",
 	"validate-utxo-set":       "Validate UTXO set file",
+	"verify-chain":            "Verify the blocks in the blockchain store and report the problems found",
+	"blob-scrub":              "Verify the integrity of a blob store and repair corrupt blobs from peers",
+	"utxo-migrate":            "Migrate the records of a UTXO store to a UTXO store of another backend",
+	"utxo-snapshot":           "Create the UTXO set files of a block from the UTXO store",
 }
 
 var dangerousCommands = map[string]bool{}
@@ -401,6 +416,252 @@ func Start(args []string, version, commit string) {
 				os.Exit(1)
 			}
 
+			return nil
+		}
+	case "verify-chain":
+		level := cmd.FlagSet.Int("level", 3, "Check level: 0 headers, 1 difficulty, 2 subtrees, 3 merkle roots, 4 full block validation")
+		depth := cmd.FlagSet.Uint("depth", 288, "Number of blocks to verify below and including the tip (0 for the whole chain)")
+		progressFile := cmd.FlagSet.String("progress-file", "", "File to keep the progress in, an interrupted run resumes from the progress in this file")
+		jsonOutput := cmd.FlagSet.Bool("json", false, "Print the result as JSON")
+
+		cmd.Execute = func(args []string) error {
+			// stop on interrupt, the progress is written before returning
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+			result, err := verifychain.VerifyChain(ctx, logger, tSettings, *level, uint32(*depth), *progressFile) //nolint:gosec
+
+			cancel()
+
+			if err != nil {
+				return errors.NewProcessingError("Failed to verify chain", err)
+			}
+
+			if *jsonOutput {
+				data, err := json.MarshalIndent(result, "", "  ")
+				if err != nil {
+					return errors.NewProcessingError("Failed to encode result", err)
+				}
+
+				fmt.Println(string(data))
+			} else {
+				fmt.Printf("\n")
+				fmt.Printf("Chain Verification Results:\n")
+				fmt.Printf("===========================\n")
+				fmt.Printf("Tip:            %s (height %d)\n", result.TipHash, result.TipHeight)
+				fmt.Printf("Start Height:   %d\n", result.StartHeight)
+				fmt.Printf("Blocks Checked: %d\n", result.BlocksChecked)
+				fmt.Printf("Problems:       %d\n", len(result.Problems))
+
+				for _, problem := range result.Problems {
+					fmt.Printf("  height %d %s [%s]: %s\n", problem.Height, problem.Hash, problem.Check, problem.Message)
+				}
+
+				fmt.Printf("\n")
+			}
+
+			// Exit with non-zero code if problems were found
+			if len(result.Problems) > 0 {
+				os.Exit(1)
+			}
+
+			return nil
+		}
+	case "blob-scrub":
+		store := cmd.FlagSet.String("store", "subtree", "Store to scrub: subtree, block or a blob store URL")
+		fileType := cmd.FlagSet.String("file-type", "", "Only scrub blobs of this file type, e.g. subtree (default: all file types)")
+		prefix := cmd.FlagSet.String("prefix", "", "Only scrub blobs whose hash starts with this prefix")
+		checkBlocks := cmd.FlagSet.Bool("check-blocks", false, "Check that the subtrees of the blocks in the blockchain store exist")
+		depth := cmd.FlagSet.Uint("depth", 288, "Number of blocks to check below and including the tip (0 for the whole chain)")
+		repair := cmd.FlagSet.Bool("repair", false, "Fetch missing and corrupt subtrees, subtree data and blocks from the peers")
+		peers := cmd.FlagSet.String("peers", "", "Comma separated asset HTTP API URLs of the peers to repair from, e.g. http://peer:8090/api/v1")
+		interval := cmd.FlagSet.Duration("interval", 0, "Scrub repeatedly at this interval until interrupted (default: run once)")
+		jsonOutput := cmd.FlagSet.Bool("json", false, "Print the result as JSON")
+
+		cmd.Execute = func(args []string) error {
+			opts := utilblobscrub.Options{
+				FileType:    fileformat.FileType(*fileType),
+				Prefix:      *prefix,
+				CheckBlocks: *checkBlocks,
+				Depth:       uint32(*depth), //nolint:gosec
+				Repair:      *repair,
+			}
+
+			for _, peer := range strings.Split(*peers, ",") {
+				if peer = strings.TrimSpace(peer); peer != "" {
+					opts.PeerURLs = append(opts.PeerURLs, peer)
+				}
+			}
+
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer cancel()
+
+			unrepaired := 0
+
+			err := blobscrub.BlobScrub(ctx, logger, tSettings, *store, opts, *interval, func(result *utilblobscrub.Result) {
+				unrepaired = len(result.Problems) - result.Repaired
+
+				if *jsonOutput {
+					data, err := json.MarshalIndent(result, "", "  ")
+					if err != nil {
+						fmt.Printf("Failed to encode result: %v\n", err)
+						return
+					}
+
+					fmt.Println(string(data))
+
+					return
+				}
+
+				fmt.Printf("\n")
+				fmt.Printf("Blob Scrub Results:\n")
+				fmt.Printf("===================\n")
+				fmt.Printf("Blobs Scrubbed: %d\n", result.BlobsScrubbed)
+				fmt.Printf("Unverified:     %d\n", result.Unverified)
+				fmt.Printf("Blocks Checked: %d\n", result.BlocksChecked)
+				fmt.Printf("Problems:       %d\n", len(result.Problems))
+				fmt.Printf("Repaired:       %d\n", result.Repaired)
+
+				for _, problem := range result.Problems {
+					repaired := ""
+					if problem.Repaired {
+						repaired = " (repaired)"
+					}
+
+					fmt.Printf("  %s %s [%s]: %s%s\n", problem.FileType, problem.Key, problem.Check, problem.Message, repaired)
+				}
+
+				fmt.Printf("\n")
+			})
+			if err != nil {
+				return errors.NewProcessingError("Failed to scrub blob store", err)
+			}
+
+			// Exit with non-zero code if problems were left unrepaired by a single run
+			if *interval == 0 && unrepaired > 0 {
+				os.Exit(1)
+			}
+
+			return nil
+		}
+	case "utxo-migrate":
+		source := cmd.FlagSet.String("source", "", "URL of the UTXO store to migrate from (default: the utxostore of the settings)")
+		destination := cmd.FlagSet.String("destination", "", "URL of the UTXO store to migrate to")
+		checkpoint := cmd.FlagSet.String("checkpoint", "utxo-migrate.checkpoint", "File to write the progress to, an interrupted migration resumes from it")
+		batchSize := cmd.FlagSet.Int("batch-size", migrate.DefaultBatchSize, "Number of records to import at once")
+		verifySample := cmd.FlagSet.Int("verify-sample", 1000, "Compare one in every n records of the stores when verifying (0 to only compare the record counts)")
+		verifyOnly := cmd.FlagSet.Bool("verify-only", false, "Only verify a previous migration")
+		skipVerify := cmd.FlagSet.Bool("skip-verify", false, "Do not verify the migration")
+		jsonOutput := cmd.FlagSet.Bool("json", false, "Print the result as JSON")
+
+		cmd.Execute = func(args []string) error {
+			if *verifyOnly && *skipVerify {
+				return errors.NewInvalidArgumentError("--verify-only and --skip-verify cannot be combined")
+			}
+
+			opts := migrate.Options{
+				BatchSize:      *batchSize,
+				CheckpointFile: *checkpoint,
+				VerifySample:   *verifySample,
+			}
+
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer cancel()
+
+			result, verifyResult, err := utxomigrate.UtxoMigrate(ctx, logger, tSettings, *source, *destination, opts, !*verifyOnly, !*skipVerify)
+			if err != nil {
+				return errors.NewProcessingError("Failed to migrate UTXO store", err)
+			}
+
+			if *jsonOutput {
+				data, err := json.MarshalIndent(struct {
+					Migration    *migrate.Result       `json:"migration,omitempty"`
+					Verification *migrate.VerifyResult `json:"verification,omitempty"`
+				}{result, verifyResult}, "", "  ")
+				if err != nil {
+					return errors.NewProcessingError("Failed to encode result", err)
+				}
+
+				fmt.Println(string(data))
+			} else {
+				fmt.Printf("\n")
+				fmt.Printf("UTXO Migration Results:\n")
+				fmt.Printf("=======================\n")
+
+				if result != nil {
+					fmt.Printf("Records Migrated:    %d\n", result.Migrated)
+
+					if result.ResumeCursor != "" {
+						fmt.Printf("Resumed After:       %d\n", result.ResumedAfter)
+					}
+				}
+
+				if verifyResult != nil {
+					fmt.Printf("Source Records:      %d\n", verifyResult.SourceRecords)
+					fmt.Printf("Destination Records: %d\n", verifyResult.DestinationRecords)
+					fmt.Printf("Records Compared:    %d\n", verifyResult.Sampled)
+					fmt.Printf("Mismatches:          %d\n", len(verifyResult.Mismatches))
+
+					for _, mismatch := range verifyResult.Mismatches {
+						fmt.Printf("  %s: %s\n", mismatch.TxID, strings.Join(mismatch.Differences, ", "))
+					}
+				}
+
+				fmt.Printf("\n")
+			}
+
+			// Exit with non-zero code if the stores differ
+			if verifyResult != nil && !verifyResult.OK() {
+				os.Exit(1)
+			}
+
+			return nil
+		}
+	case "utxo-snapshot":
+		block := cmd.FlagSet.String("block", "", "Hash of the block to create the snapshot of (default: the best block)")
+		output := cmd.FlagSet.String("output", "", "URL of the blob store to write the files to (default: the blockstore of the settings)")
+		force := cmd.FlagSet.Bool("force", false, "Replace existing UTXO set files of the block")
+		jsonOutput := cmd.FlagSet.Bool("json", false, "Print the result as JSON")
+
+		cmd.Execute = func(args []string) error {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer cancel()
+
+			commitment, err := utxosnapshot.UtxoSnapshot(ctx, logger, tSettings, *block, *output, *force)
+			if err != nil {
+				return errors.NewProcessingError("Failed to create UTXO set snapshot", err)
+			}
+
+			muHash := commitment.MuHash()
+
+			if *jsonOutput {
+				data, err := json.MarshalIndent(map[string]interface{}{
+					"blockHash":   commitment.BlockHash.String(),
+					"blockHeight": commitment.BlockHeight,
+					"txCount":     commitment.TxCount,
+					"utxoCount":   commitment.UTXOCount,
+					"totalAmount": commitment.TotalAmount,
+					"muHash":      muHash.String(),
+				}, "", "  ")
+				if err != nil {
+					return errors.NewProcessingError("Failed to encode result", err)
+				}
+
+				fmt.Println(string(data))
+
+				return nil
+			}
+
+			fmt.Printf("\n")
+			fmt.Printf("UTXO Set Snapshot:\n")
+			fmt.Printf("==================\n")
+			fmt.Printf("Block Hash:   %s\n", commitment.BlockHash)
+			fmt.Printf("Block Height: %d\n", commitment.BlockHeight)
+			fmt.Printf("Transactions: %d\n", commitment.TxCount)
+			fmt.Printf("UTXOs:        %d\n", commitment.UTXOCount)
+			fmt.Printf("Total Amount: %s satoshis\n", formatSatoshis(commitment.TotalAmount))
+			fmt.Printf("MuHash:       %s\n", muHash)
+			fmt.Printf("\n")
+
 			return nil
 		}
 	default:
@@ -0,0 +1,131 @@
+// Package utxomigrate copies the records of a UTXO store to a UTXO store of another backend, connecting to
+// both stores directly rather than through the running services.
+package utxomigrate
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/settings"
+	"github.com/bsv-blockchain/teranode/stores/utxo"
+	"github.com/bsv-blockchain/teranode/stores/utxo/factory"
+	"github.com/bsv-blockchain/teranode/stores/utxo/migrate"
+	"github.com/bsv-blockchain/teranode/ulogger"
+)
+
+// UtxoMigrate migrates the records of the source store to the destination store and verifies the migration.
+//
+// Parameters:
+//   - ctx: Context for cancellation
+//   - logger: Logger for progress messages
+//   - tSettings: Settings the stores are created with
+//   - source: URL of the store to migrate from, the UTXO store configured in the settings when empty
+//   - destination: URL of the store to migrate to
+//   - opts: The options of the migration, the source and destination are set from the URLs
+//   - migrateRecords: Whether to migrate the records, only the verification runs when false
+//   - verify: Whether to verify the migration
+//
+// Returns:
+//   - *migrate.Result: The result of the migration, nil when not migrating
+//   - *migrate.VerifyResult: The result of the verification, nil when not verifying
+//   - error: Any error that stopped the migration or verification
+func UtxoMigrate(ctx context.Context, logger ulogger.Logger, tSettings *settings.Settings, source, destination string, opts migrate.Options,
+	migrateRecords, verify bool) (*migrate.Result, *migrate.VerifyResult, error) {
+	sourceURL, err := storeURL(tSettings, source)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if destination == "" {
+		return nil, nil, errors.NewInvalidArgumentError("destination UTXO store URL is required")
+	}
+
+	destinationURL, err := storeURL(tSettings, destination)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if sourceURL.String() == destinationURL.String() {
+		return nil, nil, errors.NewInvalidArgumentError("source and destination UTXO store are the same")
+	}
+
+	sourceStore, err := newStore(ctx, logger, tSettings, sourceURL, "source")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	destinationStore, err := newStore(ctx, logger, tSettings, destinationURL, "destination")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opts.Source = sourceURL.Redacted()
+	opts.Destination = destinationURL.Redacted()
+
+	migrator, err := migrate.New(logger, sourceStore, destinationStore, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		result       *migrate.Result
+		verifyResult *migrate.VerifyResult
+	)
+
+	if migrateRecords {
+		if result, err = migrator.Migrate(ctx); err != nil {
+			return result, nil, err
+		}
+	}
+
+	if verify {
+		if verifyResult, err = migrator.Verify(ctx); err != nil {
+			return result, verifyResult, err
+		}
+	}
+
+	return result, verifyResult, nil
+}
+
+// storeURL returns the parsed store URL, or the UTXO store URL of the settings when empty
+func storeURL(tSettings *settings.Settings, store string) (*url.URL, error) {
+	if store == "" {
+		if tSettings.UtxoStore.UtxoStore == nil {
+			return nil, errors.NewConfigurationError("utxostore URL not found in config")
+		}
+
+		return tSettings.UtxoStore.UtxoStore, nil
+	}
+
+	parsed, err := url.Parse(store)
+	if err != nil || parsed.Scheme == "" {
+		return nil, errors.NewInvalidArgumentError("invalid UTXO store URL %q", store, err)
+	}
+
+	return parsed, nil
+}
+
+// newStore creates the UTXO store of the URL, without the blockchain listener as the migration does not
+// depend on the block height.
+func newStore(ctx context.Context, logger ulogger.Logger, tSettings *settings.Settings, storeURL *url.URL, source string) (utxo.Store, error) {
+	// the store logger wraps the store in a type that exports and imports no records
+	if storeURL.Query().Get("logging") == "true" {
+		query := storeURL.Query()
+		query.Del("logging")
+
+		withoutLogging := *storeURL
+		withoutLogging.RawQuery = query.Encode()
+		storeURL = &withoutLogging
+	}
+
+	storeSettings := *tSettings
+	storeSettings.UtxoStore.UtxoStore = storeURL
+
+	store, err := factory.NewStore(ctx, logger, &storeSettings, source, false)
+	if err != nil {
+		return nil, errors.NewStorageError("failed to create %s UTXO store %s", source, storeURL.Redacted(), err)
+	}
+
+	return store, nil
+}
@@ -0,0 +1,81 @@
+// Package utxosnapshot creates the UTXO set files of a block from the UTXO store, reading the UTXO, blockchain
+// and blob stores configured in the settings directly rather than through the running services.
+//
+// The files are the same as the files created by the UTXO persister from the Block Persister files, so a
+// snapshot can be created when those files are missing, and loaded by the seeder.
+package utxosnapshot
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/services/utxopersister"
+	"github.com/bsv-blockchain/teranode/settings"
+	"github.com/bsv-blockchain/teranode/stores/blob"
+	blockchainstore "github.com/bsv-blockchain/teranode/stores/blockchain"
+	"github.com/bsv-blockchain/teranode/stores/utxo/factory"
+	"github.com/bsv-blockchain/teranode/ulogger"
+)
+
+// UtxoSnapshot creates the utxo-set and utxo-headers files of a block from the records of the UTXO store.
+//
+// Parameters:
+//   - ctx: Context for cancellation
+//   - logger: Logger for progress messages
+//   - tSettings: Settings with the store URLs
+//   - block: Hash of the block to create the snapshot of, the best block when empty
+//   - output: URL of the blob store the files are written to, the block store when empty
+//   - overwrite: Whether to replace existing files of the block
+//
+// Returns:
+//   - *utxopersister.UTXOCommitment: The commitment to the UTXO set of the snapshot
+//   - error: Any error that stopped the snapshot
+func UtxoSnapshot(ctx context.Context, logger ulogger.Logger, tSettings *settings.Settings, block string, output string, overwrite bool) (*utxopersister.UTXOCommitment, error) {
+	if tSettings.BlockChain.StoreURL == nil {
+		return nil, errors.NewConfigurationError("blockchain store URL not found in config")
+	}
+
+	blockchainStore, err := blockchainstore.NewStore(logger, tSettings.BlockChain.StoreURL, tSettings)
+	if err != nil {
+		return nil, errors.NewStorageError("failed to create blockchain store", err)
+	}
+
+	var blockHash *chainhash.Hash
+
+	if block == "" {
+		header, _, err := blockchainStore.GetBestBlockHeader(ctx)
+		if err != nil {
+			return nil, errors.NewProcessingError("failed to get best block header", err)
+		}
+
+		blockHash = header.Hash()
+	} else if blockHash, err = chainhash.NewHashFromStr(block); err != nil {
+		return nil, errors.NewInvalidArgumentError("invalid block hash %q", block, err)
+	}
+
+	outputURL := tSettings.Block.BlockStore
+
+	if output != "" {
+		if outputURL, err = url.Parse(output); err != nil || outputURL.Scheme == "" {
+			return nil, errors.NewInvalidArgumentError("invalid output blob store URL %q", output, err)
+		}
+	}
+
+	if outputURL == nil {
+		return nil, errors.NewConfigurationError("blockstore URL not found in config")
+	}
+
+	outputStore, err := blob.NewStore(logger, outputURL)
+	if err != nil {
+		return nil, errors.NewStorageError("failed to create output blob store", err)
+	}
+
+	utxoStore, err := factory.NewStore(ctx, logger, tSettings, "utxo-snapshot", false)
+	if err != nil {
+		return nil, errors.NewStorageError("failed to create UTXO store", err)
+	}
+
+	return utxopersister.CreateUTXOSetSnapshot(ctx, logger, tSettings, outputStore, utxoStore, blockchainStore, blockHash, overwrite)
+}
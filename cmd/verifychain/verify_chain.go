@@ -0,0 +1,210 @@
+// Package verifychain verifies the blocks in the blockchain store offline, reading the blockchain, subtree
+// and UTXO stores configured in the settings directly rather than through the running services.
+//
+// The progress of a run is written to a progress file, so a run that was interrupted continues where it
+// stopped instead of starting over. The progress file is removed when a run completes, so the next run
+// verifies the chain again.
+package verifychain
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/services/blockchain"
+	"github.com/bsv-blockchain/teranode/settings"
+	"github.com/bsv-blockchain/teranode/stores/blob"
+	blockchainstore "github.com/bsv-blockchain/teranode/stores/blockchain"
+	"github.com/bsv-blockchain/teranode/stores/utxo"
+	utxofactory "github.com/bsv-blockchain/teranode/stores/utxo/factory"
+	"github.com/bsv-blockchain/teranode/ulogger"
+	"github.com/bsv-blockchain/teranode/util/chainverifier"
+)
+
+// progressInterval is the number of blocks after which the progress file is written
+const progressInterval = 1000
+
+// Progress is the progress of a run, as stored in the progress file
+type Progress struct {
+	Level      int                     `json:"level"`      // check level of the run
+	NextHeight uint32                  `json:"nextHeight"` // height of the next block to verify
+	Problems   []chainverifier.Problem `json:"problems"`   // problems found so far
+}
+
+// VerifyChain verifies the blocks of the best chain with the verifier of the verifychain RPC.
+//
+// Parameters:
+//   - ctx: Context for cancellation, the progress is written when the run is cancelled and removed when it completes
+//   - logger: Logger for progress messages
+//   - tSettings: Settings with the store URLs
+//   - level: Check level, chainverifier.LevelHeaders to chainverifier.LevelBlock
+//   - depth: Number of blocks below and including the tip to verify, 0 verifies the whole chain
+//   - progressFile: Path of the progress file, empty to not keep progress
+//
+// Returns:
+//   - *chainverifier.Result: The result, including the problems found by the run that was resumed
+//   - error: Any error that stopped the run
+func VerifyChain(ctx context.Context, logger ulogger.Logger, tSettings *settings.Settings, level int, depth uint32, progressFile string) (*chainverifier.Result, error) {
+	progress, err := ReadProgress(progressFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if progress != nil && progress.Level != level {
+		return nil, errors.NewInvalidArgumentError("progress file %s was written at check level %d, remove it to verify at check level %d", progressFile, progress.Level, level)
+	}
+
+	verifier, err := newVerifier(ctx, logger, tSettings, level)
+	if err != nil {
+		return nil, err
+	}
+
+	if progress == nil {
+		progress = &Progress{Level: level, Problems: make([]chainverifier.Problem, 0)}
+	}
+
+	opts := chainverifier.Options{
+		Level: level,
+		Depth: depth,
+	}
+
+	if progressFile != "" {
+		if progress.NextHeight > 0 {
+			logger.Infof("[VerifyChain] resuming at height %d with %d problems found before", progress.NextHeight, len(progress.Problems))
+
+			opts.StartHeight = &progress.NextHeight
+		}
+
+		blocks := 0
+
+		opts.Progress = func(height uint32, problems []chainverifier.Problem) error {
+			progress.NextHeight = height + 1
+			progress.Problems = append(progress.Problems, problems...)
+
+			if blocks++; blocks%progressInterval != 0 {
+				return nil
+			}
+
+			logger.Infof("[VerifyChain] verified up to height %d, %d problems found", height, len(progress.Problems))
+
+			return WriteProgress(progressFile, progress)
+		}
+	}
+
+	result, err := verifier.Verify(ctx, opts)
+	if err != nil {
+		if progressFile != "" {
+			if writeErr := WriteProgress(progressFile, progress); writeErr != nil {
+				logger.Errorf("[VerifyChain] failed to write progress file %s: %v", progressFile, writeErr)
+			}
+		}
+
+		return result, err
+	}
+
+	if progressFile != "" {
+		// include the problems found by the runs that were resumed
+		result.Problems = progress.Problems
+
+		// the run is complete, the next run must not resume from it
+		if err = RemoveProgress(progressFile); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// newVerifier creates the verifier over the stores the check level needs
+func newVerifier(ctx context.Context, logger ulogger.Logger, tSettings *settings.Settings, level int) (*chainverifier.Verifier, error) {
+	if tSettings.BlockChain.StoreURL == nil {
+		return nil, errors.NewConfigurationError("blockchain store URL not found in config")
+	}
+
+	blockchainStore, err := blockchainstore.NewStore(logger, tSettings.BlockChain.StoreURL, tSettings)
+	if err != nil {
+		return nil, errors.NewStorageError("failed to create blockchain store", err)
+	}
+
+	blockchainClient, err := blockchain.NewLocalClient(logger, tSettings, blockchainStore, nil, nil)
+	if err != nil {
+		return nil, errors.NewServiceError("failed to create blockchain client", err)
+	}
+
+	var (
+		subtreeStore blob.Store
+		utxoStore    utxo.Store
+	)
+
+	if level >= chainverifier.LevelSubtrees {
+		if tSettings.SubtreeValidation.SubtreeStore == nil {
+			return nil, errors.NewConfigurationError("subtree store URL not found in config")
+		}
+
+		if subtreeStore, err = blob.NewStore(logger, tSettings.SubtreeValidation.SubtreeStore); err != nil {
+			return nil, errors.NewStorageError("failed to create subtree store", err)
+		}
+	}
+
+	if level >= chainverifier.LevelBlock {
+		if utxoStore, err = utxofactory.NewStore(ctx, logger, tSettings, "verify-chain", false); err != nil {
+			return nil, errors.NewStorageError("failed to create UTXO store", err)
+		}
+	}
+
+	return chainverifier.New(logger, tSettings, blockchainClient, subtreeStore, utxoStore), nil
+}
+
+// ReadProgress reads the progress file, it returns nil when the path is empty or the file does not exist
+func ReadProgress(path string) (*Progress, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, errors.NewStorageError("failed to read progress file %s", path, err)
+	}
+
+	progress := &Progress{}
+	if err = json.Unmarshal(data, progress); err != nil {
+		return nil, errors.NewProcessingError("failed to parse progress file %s", path, err)
+	}
+
+	return progress, nil
+}
+
+// RemoveProgress removes the progress file of a completed run, it is not an error when the file does not exist
+func RemoveProgress(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return errors.NewStorageError("failed to remove progress file %s", path, err)
+	}
+
+	return nil
+}
+
+// WriteProgress writes the progress file, replacing it atomically so an interrupted write does not lose
+// the progress written before
+func WriteProgress(path string, progress *Progress) error {
+	data, err := json.MarshalIndent(progress, "", "  ")
+	if err != nil {
+		return errors.NewProcessingError("failed to encode progress", err)
+	}
+
+	tmpPath := path + ".tmp"
+
+	if err = os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return errors.NewStorageError("failed to write progress file %s", tmpPath, err)
+	}
+
+	if err = os.Rename(tmpPath, path); err != nil {
+		return errors.NewStorageError("failed to rename progress file %s", tmpPath, err)
+	}
+
+	return nil
+}
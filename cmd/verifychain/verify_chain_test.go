@@ -0,0 +1,59 @@
+package verifychain
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bsv-blockchain/teranode/util/chainverifier"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "progress.json")
+
+	// no progress when the file does not exist yet
+	progress, err := ReadProgress(path)
+	require.NoError(t, err)
+	assert.Nil(t, progress)
+
+	progress, err = ReadProgress("")
+	require.NoError(t, err)
+	assert.Nil(t, progress)
+
+	expected := &Progress{
+		Level:      chainverifier.LevelMerkleRoot,
+		NextHeight: 1001,
+		Problems: []chainverifier.Problem{
+			{Height: 10, Hash: "0000000000000000000000000000000000000000000000000000000000000001", Check: chainverifier.CheckMerkleRoot, Message: "merkle root mismatch"},
+		},
+	}
+
+	require.NoError(t, WriteProgress(path, expected))
+
+	progress, err = ReadProgress(path)
+	require.NoError(t, err)
+	assert.Equal(t, expected, progress)
+
+	// the temporary file is renamed
+	_, err = os.Stat(path + ".tmp")
+	assert.True(t, os.IsNotExist(err))
+
+	// a completed run removes the progress, the next run starts over
+	require.NoError(t, RemoveProgress(path))
+
+	progress, err = ReadProgress(path)
+	require.NoError(t, err)
+	assert.Nil(t, progress)
+
+	require.NoError(t, RemoveProgress(path))
+}
+
+func TestProgress_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "progress.json")
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0o600))
+
+	_, err := ReadProgress(path)
+	require.Error(t, err)
+}
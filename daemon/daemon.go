@@ -90,6 +90,10 @@ const (
 	serviceBlockValidation         = "blockvalidation"
 	serviceBlockValidationFormal   = "BlockValidation"
 	serviceBlockchainFormal        = "Blockchain"
+	serviceElectrum                = "electrum"
+	serviceElectrumFormal          = "Electrum"
+	serviceFilterIndex             = "filterindex"
+	serviceFilterIndexFormal       = "FilterIndex"
 	serviceHelp                    = "help"
 	serviceLegacy                  = "legacy"
 	serviceLegacyFormal            = "Legacy"
@@ -100,6 +104,8 @@ const (
 	servicePropagationFormal       = "Propagation"
 	serviceRPC                     = "rpc"
 	serviceRPCFormal               = "RPC"
+	serviceScriptHashIndex         = "scripthashindex"
+	serviceScriptHashIndexFormal   = "ScriptHashIndex"
 	serviceServiceManager          = "ServiceManager"
 	serviceSubtreeValidation       = "subtreevalidation"
 	serviceSubtreeValidationFormal = "SubtreeValidation"
@@ -502,12 +508,21 @@ func printUsage() {
 	fmt.Println("    -utxopersister=<1|0>")
 	fmt.Println("          whether to start the UTXO persister service")
 	fmt.Println("")
+	fmt.Println("    -filterindex=<1|0>")
+	fmt.Println("          whether to start the compact block filter index service")
+	fmt.Println("")
+	fmt.Println("    -scripthashindex=<1|0>")
+	fmt.Println("          whether to start the script hash index service")
+	fmt.Println("")
 	fmt.Println("    -legacy=<1|0>")
 	fmt.Println("          whether to start the legacy service")
 	fmt.Println("")
 	fmt.Println("    -rpc=<1|0>")
 	fmt.Println("          whether to start the RPC service")
 	fmt.Println("")
+	fmt.Println("    -electrum=<1|0>")
+	fmt.Println("          whether to start the Electrum server")
+	fmt.Println("")
 	fmt.Println("    -alert=<1|0>")
 	fmt.Println("          whether to start the alert service")
 	fmt.Println("")
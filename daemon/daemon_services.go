@@ -15,19 +15,24 @@ import (
 	"github.com/bsv-blockchain/teranode/services/blockchain"
 	"github.com/bsv-blockchain/teranode/services/blockpersister"
 	"github.com/bsv-blockchain/teranode/services/blockvalidation"
+	"github.com/bsv-blockchain/teranode/services/electrum"
+	"github.com/bsv-blockchain/teranode/services/filterindex"
 	"github.com/bsv-blockchain/teranode/services/legacy"
 	"github.com/bsv-blockchain/teranode/services/legacy/peer"
 	"github.com/bsv-blockchain/teranode/services/p2p"
 	"github.com/bsv-blockchain/teranode/services/propagation"
 	"github.com/bsv-blockchain/teranode/services/rpc"
+	"github.com/bsv-blockchain/teranode/services/scripthashindex"
 	"github.com/bsv-blockchain/teranode/services/subtreevalidation"
 	"github.com/bsv-blockchain/teranode/services/utxopersister"
 	"github.com/bsv-blockchain/teranode/services/validator"
 	"github.com/bsv-blockchain/teranode/settings"
 	"github.com/bsv-blockchain/teranode/stores/blob"
 	blockchainstore "github.com/bsv-blockchain/teranode/stores/blockchain"
+	"github.com/bsv-blockchain/teranode/stores/scripthash"
 	"github.com/bsv-blockchain/teranode/stores/utxo"
 	"github.com/bsv-blockchain/teranode/ulogger"
+	"github.com/bsv-blockchain/teranode/util/feeestimator"
 	"github.com/bsv-blockchain/teranode/util/kafka"
 	"github.com/bsv-blockchain/teranode/util/servicemanager"
 	"github.com/bsv-blockchain/teranode/util/tracing"
@@ -65,8 +70,11 @@ func (d *Daemon) startServices(ctx context.Context, logger ulogger.Logger, appSe
 	startAsset := d.shouldStart(serviceAssetFormal, args)
 	startBlockPersister := d.shouldStart(serviceBlockPersisterFormal, args)
 	startUTXOPersister := d.shouldStart(serviceUtxoPersisterFormal, args)
+	startFilterIndex := d.shouldStart(serviceFilterIndexFormal, args)
+	startScriptHashIndex := d.shouldStart(serviceScriptHashIndexFormal, args)
 	startLegacy := d.shouldStart(serviceLegacyFormal, args)
 	startRPC := d.shouldStart(serviceRPCFormal, args)
+	startElectrum := d.shouldStart(serviceElectrumFormal, args)
 	startAlert := d.shouldStart(serviceAlertFormal, args)
 
 	// Create the application count based on the services that are going to be started
@@ -81,6 +89,13 @@ func (d *Daemon) startServices(ctx context.Context, logger ulogger.Logger, appSe
 		return nil
 	}
 
+	readsFeeEstimates := startAsset || startRPC
+	validatesTxs := startValidator || (appSettings.Validator.UseLocalValidator && (startPropagation || startSubtreeValidation || startBlockValidation || startLegacy))
+
+	if err := checkFeeEstimatorServices(appSettings, readsFeeEstimates, validatesTxs, startBlockValidation); err != nil {
+		return err
+	}
+
 	// start the profiler if enabled
 	startProfilerAndMetrics(logger, appSettings)
 
@@ -108,9 +123,12 @@ func (d *Daemon) startServices(ctx context.Context, logger ulogger.Logger, appSe
 		{startP2P, func() error { return d.startP2PService(ctx, appSettings, createLogger) }},
 		{startAsset, func() error { return d.startAssetService(ctx, appSettings, createLogger) }},
 		{startRPC, func() error { return d.startRPCService(ctx, appSettings, createLogger) }},
+		{startElectrum, func() error { return d.startElectrumService(ctx, appSettings, createLogger) }},
 		{startAlert, func() error { return d.startAlertService(ctx, appSettings, createLogger) }},
 		{startBlockPersister, func() error { return d.startBlockPersisterService(ctx, appSettings, createLogger) }},
 		{startUTXOPersister, func() error { return d.startUTXOPersisterService(ctx, appSettings, createLogger) }},
+		{startFilterIndex, func() error { return d.startFilterIndexService(ctx, appSettings, createLogger) }},
+		{startScriptHashIndex, func() error { return d.startScriptHashIndexService(ctx, appSettings, createLogger) }},
 		{startBlockAssembly, func() error { return d.startBlockAssemblyService(ctx, appSettings, createLogger) }},
 		{startSubtreeValidation, func() error { return d.startSubtreeValidationService(ctx, appSettings, createLogger) }},
 		{startBlockValidation, func() error { return d.startBlockValidationService(ctx, appSettings, createLogger) }},
@@ -149,6 +167,23 @@ func (d *Daemon) startServices(ctx context.Context, logger ulogger.Logger, appSe
 	return nil
 }
 
+// checkFeeEstimatorServices returns an error when fee estimation is enabled, but the services reading the
+// estimates and the services feeding the estimator do not all run in this process. The estimator is kept in
+// memory and only fed through the UTXO store of this process: with the transactions created by the validator,
+// and the transactions marked as mined by the block validation. Estimates served by a process that does not
+// run both would never become available.
+func checkFeeEstimatorServices(appSettings *settings.Settings, readsFeeEstimates, validatesTxs, validatesBlocks bool) error {
+	if !appSettings.FeeEstimator.Enabled {
+		return nil
+	}
+
+	if !readsFeeEstimates || !validatesTxs || !validatesBlocks {
+		return errors.NewConfigurationError("feeestimator_enabled requires the Asset or RPC service, the Validator (or a local validator) and the Block Validation service to run in the same process")
+	}
+
+	return nil
+}
+
 // startProfilerAndMetrics initializes and starts the profiler if the address is set in the app settings.
 func startProfilerAndMetrics(logger ulogger.Logger, appSettings *settings.Settings) {
 	profilerAddr := appSettings.ProfilerAddr
@@ -411,6 +446,23 @@ func (d *Daemon) startAssetService(ctx context.Context, appSettings *settings.Se
 		return err
 	}
 
+	// Get the script hash index store, when the script hash endpoints are served
+	var scriptHashStore scripthash.Store
+
+	if appSettings.ScriptHashIndex.Enabled {
+		scriptHashStore, err = d.daemonStores.GetScriptHashStore(createLogger(serviceScriptHashIndex), appSettings)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Get the fee estimator, when fee estimates are served
+	var feeEstimator *feeestimator.Estimator
+
+	if appSettings.FeeEstimator.Enabled {
+		feeEstimator = d.daemonStores.GetFeeEstimator(appSettings)
+	}
+
 	// Initialize the Asset service with the necessary parts
 	return d.ServiceManager.AddService(serviceAssetFormal, asset.NewServer(
 		createLogger(serviceAsset),
@@ -422,6 +474,8 @@ func (d *Daemon) startAssetService(ctx context.Context, appSettings *settings.Se
 		blockchainClient,
 		blockvalidationClient,
 		p2pClient,
+		scriptHashStore,
+		feeEstimator,
 	))
 }
 
@@ -483,10 +537,45 @@ func (d *Daemon) startRPCService(ctx context.Context, appSettings *settings.Sett
 		return err
 	}
 
+	// Create subtree store for the RPC service, used to build merkle proofs
+	var subtreeStore blob.Store
+
+	subtreeStore, err = d.daemonStores.GetSubtreeStore(ctx, createLogger(loggerSubtrees), appSettings)
+	if err != nil {
+		return err
+	}
+
+	// Create block store for the RPC service, used to read compact block filters
+	var blockStore blob.Store
+
+	if appSettings.FilterIndex.Enabled {
+		blockStore, err = d.daemonStores.GetBlockStore(ctx, createLogger(loggerBlockPersisterStore), appSettings)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Create script hash index store for the RPC service, used by searchrawtransactions
+	var scriptHashStore scripthash.Store
+
+	if appSettings.ScriptHashIndex.Enabled {
+		scriptHashStore, err = d.daemonStores.GetScriptHashStore(createLogger(serviceScriptHashIndex), appSettings)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Get the fee estimator for the RPC service, used by estimatefee and estimatesmartfee
+	var feeEstimator *feeestimator.Estimator
+
+	if appSettings.FeeEstimator.Enabled {
+		feeEstimator = d.daemonStores.GetFeeEstimator(appSettings)
+	}
+
 	// Create the RPC server with the necessary parts
 	var rpcServer *rpc.RPCServer
 
-	rpcServer, err = rpc.NewServer(createLogger(loggerRPC), appSettings, blockchainClient, blockValidationClient, utxoStore, blockAssemblyClient, peerClient, p2pClient, txStore, validatorClient)
+	rpcServer, err = rpc.NewServer(createLogger(loggerRPC), appSettings, blockchainClient, blockValidationClient, utxoStore, blockAssemblyClient, peerClient, p2pClient, txStore, validatorClient, subtreeStore, blockStore, scriptHashStore, feeEstimator)
 	if err != nil {
 		return err
 	}
@@ -499,6 +588,81 @@ func (d *Daemon) startRPCService(ctx context.Context, appSettings *settings.Sett
 	return nil
 }
 
+// startElectrumService initializes and adds the Electrum service to the ServiceManager.
+func (d *Daemon) startElectrumService(ctx context.Context, appSettings *settings.Settings,
+	createLogger func(string) ulogger.Logger) error {
+	// Get the UTXO store for the Electrum service
+	utxoStore, err := d.daemonStores.GetUtxoStore(ctx, createLogger(loggerUtxos), appSettings)
+	if err != nil {
+		return err
+	}
+
+	// Get the transaction store for the Electrum service, also used to store broadcast transactions
+	var txStore blob.Store
+
+	txStore, err = d.daemonStores.GetTxStore(createLogger(loggerTransactions), appSettings)
+	if err != nil {
+		return err
+	}
+
+	// Get the subtree store for the Electrum service, used to build merkle proofs
+	var subtreeStore blob.Store
+
+	subtreeStore, err = d.daemonStores.GetSubtreeStore(ctx, createLogger(loggerSubtrees), appSettings)
+	if err != nil {
+		return err
+	}
+
+	// Get the block persister store for the Electrum service
+	var blockPersisterStore blob.Store
+
+	blockPersisterStore, err = d.daemonStores.GetBlockPersisterStore(ctx, createLogger(loggerBlockPersisterStore), appSettings)
+	if err != nil {
+		return err
+	}
+
+	// Get the blockchain client for the Electrum service
+	var blockchainClient blockchain.ClientI
+
+	blockchainClient, err = d.daemonStores.GetBlockchainClient(
+		ctx, createLogger(loggerBlockchainClient), appSettings, serviceElectrum,
+	)
+	if err != nil {
+		return err
+	}
+
+	// Get the validator client for the Electrum service, used to broadcast transactions
+	var validatorClient validator.Interface
+
+	validatorClient, err = d.daemonStores.GetValidatorClient(ctx, createLogger(loggerTxValidator), appSettings)
+	if err != nil {
+		return err
+	}
+
+	// Get the script hash index store, when the script hash methods are served
+	var scriptHashStore scripthash.Store
+
+	if appSettings.ScriptHashIndex.Enabled {
+		scriptHashStore, err = d.daemonStores.GetScriptHashStore(createLogger(serviceScriptHashIndex), appSettings)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Add the Electrum service to the ServiceManager
+	return d.ServiceManager.AddService(serviceElectrumFormal, electrum.New(
+		createLogger(serviceElectrum),
+		appSettings,
+		utxoStore,
+		txStore,
+		subtreeStore,
+		blockPersisterStore,
+		blockchainClient,
+		validatorClient,
+		scriptHashStore,
+	))
+}
+
 // startAlertService initializes and adds the Alert service to the ServiceManager.
 func (d *Daemon) startAlertService(ctx context.Context, appSettings *settings.Settings,
 	createLogger func(string) ulogger.Logger) (err error) {
@@ -628,6 +792,89 @@ func (d *Daemon) startUTXOPersisterService(ctx context.Context, appSettings *set
 	))
 }
 
+// startFilterIndexService initializes and adds the FilterIndex service to the ServiceManager.
+func (d *Daemon) startFilterIndexService(ctx context.Context, appSettings *settings.Settings,
+	createLogger func(string) ulogger.Logger) error {
+	// Create the block store the filters are written to
+	blockStore, err := d.daemonStores.GetBlockStore(ctx, createLogger(loggerBlockPersisterStore), appSettings)
+	if err != nil {
+		return err
+	}
+
+	// Create the subtree store holding the subtree data written by the block persister
+	var subtreeStore blob.Store
+
+	subtreeStore, err = d.daemonStores.GetSubtreeStore(ctx, createLogger(loggerSubtrees), appSettings)
+	if err != nil {
+		return err
+	}
+
+	// Create the UTXO store, used for transactions that are not in extended format
+	var utxoStore utxo.Store
+
+	utxoStore, err = d.daemonStores.GetUtxoStore(ctx, createLogger(loggerUtxos), appSettings)
+	if err != nil {
+		return err
+	}
+
+	// Create the blockchain client for the FilterIndex service
+	var blockchainClient blockchain.ClientI
+
+	blockchainClient, err = d.daemonStores.GetBlockchainClient(
+		ctx, createLogger(loggerBlockchainClient), appSettings, serviceFilterIndex,
+	)
+	if err != nil {
+		return err
+	}
+
+	// Add the FilterIndex service to the ServiceManager
+	return d.ServiceManager.AddService(serviceFilterIndexFormal, filterindex.New(ctx,
+		createLogger(serviceFilterIndex),
+		appSettings,
+		blockStore,
+		subtreeStore,
+		utxoStore,
+		blockchainClient,
+	))
+}
+
+// startScriptHashIndexService initializes and adds the ScriptHashIndex service to the ServiceManager.
+func (d *Daemon) startScriptHashIndexService(ctx context.Context, appSettings *settings.Settings,
+	createLogger func(string) ulogger.Logger) error {
+	// Create the subtree store holding the subtree data written by the block persister
+	subtreeStore, err := d.daemonStores.GetSubtreeStore(ctx, createLogger(loggerSubtrees), appSettings)
+	if err != nil {
+		return err
+	}
+
+	// Create the store the index is written to
+	var scriptHashStore scripthash.Store
+
+	scriptHashStore, err = d.daemonStores.GetScriptHashStore(createLogger(serviceScriptHashIndex), appSettings)
+	if err != nil {
+		return err
+	}
+
+	// Create the blockchain client for the ScriptHashIndex service
+	var blockchainClient blockchain.ClientI
+
+	blockchainClient, err = d.daemonStores.GetBlockchainClient(
+		ctx, createLogger(loggerBlockchainClient), appSettings, serviceScriptHashIndex,
+	)
+	if err != nil {
+		return err
+	}
+
+	// Add the ScriptHashIndex service to the ServiceManager
+	return d.ServiceManager.AddService(serviceScriptHashIndexFormal, scripthashindex.New(ctx,
+		createLogger(serviceScriptHashIndex),
+		appSettings,
+		subtreeStore,
+		scriptHashStore,
+		blockchainClient,
+	))
+}
+
 // startBlockAssemblyService initializes and adds the BlockAssembly service to the ServiceManager.
 func (d *Daemon) startBlockAssemblyService(ctx context.Context, appSettings *settings.Settings,
 	createLogger func(string) ulogger.Logger) error {
@@ -1080,6 +1327,16 @@ func (d *Daemon) startLegacyService(
 		return err
 	}
 
+	// Get the block store holding the compact block filters, when they are served to peers
+	var blockStore blob.Store
+
+	if appSettings.FilterIndex.Enabled {
+		blockStore, err = d.daemonStores.GetBlockStore(ctx, createLogger(loggerBlockPersisterStore), appSettings)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Add the Legacy service to the ServiceManager
 	return d.ServiceManager.AddService(serviceLegacyFormal, legacy.New(
 		createLogger(serviceLegacy),
@@ -1092,5 +1349,6 @@ func (d *Daemon) startLegacyService(
 		subtreeValidationClient,
 		blockValidationClient,
 		blockassemblyClient,
+		blockStore,
 	))
 }
@@ -14,10 +14,13 @@ import (
 	"github.com/bsv-blockchain/teranode/settings"
 	"github.com/bsv-blockchain/teranode/stores/blob"
 	"github.com/bsv-blockchain/teranode/stores/blob/options"
+	"github.com/bsv-blockchain/teranode/stores/scripthash"
+	scripthashfactory "github.com/bsv-blockchain/teranode/stores/scripthash/factory"
 	utxostore "github.com/bsv-blockchain/teranode/stores/utxo"
 	"github.com/bsv-blockchain/teranode/stores/utxo/aerospike"
 	utxofactory "github.com/bsv-blockchain/teranode/stores/utxo/factory"
 	"github.com/bsv-blockchain/teranode/ulogger"
+	"github.com/bsv-blockchain/teranode/util/feeestimator"
 	"github.com/bsv-blockchain/teranode/util/kafka"
 )
 
@@ -25,7 +28,9 @@ type Stores struct {
 	mainBlockPersisterStore     blob.Store
 	mainBlockStore              blob.Store
 	mainBlockValidationClient   blockvalidation.Interface
+	mainFeeEstimator            *feeestimator.Estimator
 	mainP2PClient               p2p.ClientI
+	mainScriptHashStore         scripthash.Store
 	mainSubtreeStore            blob.Store
 	mainSubtreeValidationClient subtreevalidation.Interface
 	mainTempStore               blob.Store
@@ -50,9 +55,29 @@ func (d *Stores) GetUtxoStore(ctx context.Context, logger ulogger.Logger,
 		return nil, err
 	}
 
+	// feed the fee estimator with the transactions created and mined in this process, startServices
+	// refuses to start when these services do not run in the same process as the readers of the estimates
+	if appSettings.FeeEstimator.Enabled {
+		d.mainUtxoStore = feeestimator.NewUtxoStore(d.mainUtxoStore, d.GetFeeEstimator(appSettings))
+	}
+
 	return d.mainUtxoStore, nil
 }
 
+// GetFeeEstimator returns the main fee estimator instance. If the estimator hasn't been initialized yet,
+// it creates a new one using the provided settings. The estimator is fed by the main UTXO store, and
+// read by the asset and RPC services.
+func (d *Stores) GetFeeEstimator(appSettings *settings.Settings) *feeestimator.Estimator {
+	if d.mainFeeEstimator != nil {
+		return d.mainFeeEstimator
+	}
+
+	// the minimum mining fee is in BSV per kB, the estimator works in satoshis per kB
+	d.mainFeeEstimator = feeestimator.New(appSettings.FeeEstimator.MaxTrackedTransactions, appSettings.Policy.GetMinMiningTxFee()*1e8)
+
+	return d.mainFeeEstimator
+}
+
 // GetSubtreeValidationClient returns the main subtree validation client instance. If the client
 // hasn't been initialized yet, it creates a new one using the provided settings. This function
 // ensures only one instance of the subtree validation client exists.
@@ -393,12 +418,37 @@ func (d *Stores) GetBlockPersisterStore(ctx context.Context, logger ulogger.Logg
 	return d.mainBlockPersisterStore, nil
 }
 
+// GetScriptHashStore returns the main script hash index store instance. If the store hasn't been
+// initialized yet, it creates a new one using the configured URL from settings. The store is shared
+// by the script hash index service writing the index, and the asset and RPC services reading it.
+func (d *Stores) GetScriptHashStore(logger ulogger.Logger, appSettings *settings.Settings) (scripthash.Store, error) {
+	if d.mainScriptHashStore != nil {
+		return d.mainScriptHashStore, nil
+	}
+
+	storeURL := appSettings.ScriptHashIndex.Store
+
+	if storeURL == nil {
+		return nil, errors.NewConfigurationError("scripthashindex_store config not found")
+	}
+
+	var err error
+
+	d.mainScriptHashStore, err = scripthashfactory.NewStore(logger, storeURL, appSettings)
+	if err != nil {
+		return nil, errors.NewServiceError("could not create script hash store", err)
+	}
+
+	return d.mainScriptHashStore, nil
+}
+
 // Cleanup resets all singleton stores. This is particularly important for tests
 // where stores may persist between test runs.
 func (d *Stores) Cleanup() {
 	d.mainBlockPersisterStore = nil
 	d.mainBlockStore = nil
 	d.mainBlockValidationClient = nil
+	d.mainFeeEstimator = nil
 	d.mainSubtreeStore = nil
 	d.mainSubtreeValidationClient = nil
 	d.mainTempStore = nil
@@ -406,6 +456,7 @@ func (d *Stores) Cleanup() {
 	d.mainUtxoStore = nil
 	d.mainValidatorClient = nil
 	d.mainP2PClient = nil
+	d.mainScriptHashStore = nil
 
 	// Reset the Aerospike cleanup service singleton if it exists
 	// This prevents state leakage between test runs
@@ -121,6 +121,21 @@ func TestShouldStart(t *testing.T) {
 	}
 }
 
+// TestCheckFeeEstimatorServices tests that fee estimation is refused when the services feeding the estimator
+// do not run in the same process as the services reading the estimates.
+func TestCheckFeeEstimatorServices(t *testing.T) {
+	appSettings := settings.NewSettings()
+
+	appSettings.FeeEstimator.Enabled = false
+	require.NoError(t, checkFeeEstimatorServices(appSettings, true, false, false))
+
+	appSettings.FeeEstimator.Enabled = true
+	require.NoError(t, checkFeeEstimatorServices(appSettings, true, true, true))
+	require.Error(t, checkFeeEstimatorServices(appSettings, true, false, true))
+	require.Error(t, checkFeeEstimatorServices(appSettings, true, true, false))
+	require.Error(t, checkFeeEstimatorServices(appSettings, false, true, true))
+}
+
 // TestDaemon_Stop tests the Stop method of the Daemon to ensure it closes the stop channel.
 func TestDaemon_Stop(t *testing.T) {
 	d := New()
@@ -1054,6 +1054,38 @@ func (b *Block) GetSubtrees(ctx context.Context, logger ulogger.Logger, subtreeS
 	return b.SubtreeSlices, nil
 }
 
+// BlockTxHashes returns the transaction hashes of a block, in block order, from its subtrees.
+// The coinbase placeholder of the first subtree is replaced by the hash of the coinbase transaction,
+// as the merkle root of the block is computed over the coinbase transaction. When coinbaseTx is nil
+// the placeholder is returned as is. A block with only a coinbase transaction has no subtrees, its
+// only transaction hash is the hash of the coinbase transaction.
+func BlockTxHashes(coinbaseTx *bt.Tx, subtrees []*subtreepkg.Subtree) []chainhash.Hash {
+	count := 0
+	for _, subtree := range subtrees {
+		count += len(subtree.Nodes)
+	}
+
+	txHashes := make([]chainhash.Hash, 0, max(count, 1))
+
+	for subtreeIdx, subtree := range subtrees {
+		for nodeIdx := range subtree.Nodes {
+			if subtreeIdx == 0 && nodeIdx == 0 && coinbaseTx != nil {
+				// the first node of the first subtree is the coinbase placeholder
+				txHashes = append(txHashes, *coinbaseTx.TxIDChainHash())
+				continue
+			}
+
+			txHashes = append(txHashes, subtree.Nodes[nodeIdx].Hash)
+		}
+	}
+
+	if len(txHashes) == 0 && coinbaseTx != nil {
+		txHashes = append(txHashes, *coinbaseTx.TxIDChainHash())
+	}
+
+	return txHashes
+}
+
 func (b *Block) GetAndValidateSubtrees(ctx context.Context, logger ulogger.Logger, subtreeStore SubtreeStore, getAndValidateSubtreesConcurrency int) error {
 	ctx, _, deferFn := tracing.Tracer("block").Start(ctx, "GetAndValidateSubtrees",
 		tracing.WithHistogram(prometheusBlockGetAndValidateSubtrees),
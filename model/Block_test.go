@@ -4974,3 +4974,36 @@ func TestBlock_Valid_CoinbasePlaceholderCheck(t *testing.T) {
 		// The coinbase placeholder check should be skipped for empty subtrees
 	})
 }
+
+func TestBlockTxHashes(t *testing.T) {
+	coinbaseTx := bt.NewTx()
+	require.NoError(t, coinbaseTx.From("0000000000000000000000000000000000000000000000000000000000000000", 0xffffffff, "", 0))
+
+	txHash1 := chainhash.Hash{1}
+	txHash2 := chainhash.Hash{2}
+	txHash3 := chainhash.Hash{3}
+
+	subtree1, err := subtreepkg.NewTreeByLeafCount(2)
+	require.NoError(t, err)
+	require.NoError(t, subtree1.AddCoinbaseNode())
+	require.NoError(t, subtree1.AddNode(txHash1, 1, 100))
+
+	subtree2, err := subtreepkg.NewTreeByLeafCount(2)
+	require.NoError(t, err)
+	require.NoError(t, subtree2.AddNode(txHash2, 1, 100))
+	require.NoError(t, subtree2.AddNode(txHash3, 1, 100))
+
+	t.Run("coinbase placeholder replaced", func(t *testing.T) {
+		txHashes := BlockTxHashes(coinbaseTx, []*subtreepkg.Subtree{subtree1, subtree2})
+		assert.Equal(t, []chainhash.Hash{*coinbaseTx.TxIDChainHash(), txHash1, txHash2, txHash3}, txHashes)
+	})
+
+	t.Run("without coinbase", func(t *testing.T) {
+		txHashes := BlockTxHashes(nil, []*subtreepkg.Subtree{subtree1, subtree2})
+		assert.Equal(t, []chainhash.Hash{subtreepkg.CoinbasePlaceholderHashValue, txHash1, txHash2, txHash3}, txHashes)
+	})
+
+	t.Run("coinbase only block", func(t *testing.T) {
+		assert.Equal(t, []chainhash.Hash{*coinbaseTx.TxIDChainHash()}, BlockTxHashes(coinbaseTx, nil))
+	})
+}
@@ -0,0 +1,145 @@
+package fileformat
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// Codec is an enum-like type for the compression codecs of compressed file bodies.
+type Codec string
+
+const (
+	CodecNone Codec = ""
+	CodecZstd Codec = "zstd"
+	CodecLZ4  Codec = "lz4"
+)
+
+func (c Codec) String() string {
+	if c == CodecNone {
+		return "none"
+	}
+
+	return string(c)
+}
+
+// ToMagicBytes returns the magic of the compression header of the codec, all zeros for CodecNone.
+func (c Codec) ToMagicBytes() [8]byte {
+	return codecToMagic[c]
+}
+
+// Magic compression header types - exactly 8 ASCII characters (8 bytes), ending in the version of the chunk
+// container format
+var (
+	magicZstd = [8]byte{'Z', 'S', 'T', 'D', 'C', 'H', 'K', '1'} // ZSTDCHK1
+	magicLZ4  = [8]byte{'L', 'Z', '4', '-', 'C', 'H', 'K', '1'} // LZ4-CHK1
+)
+
+var codecToMagic = map[Codec][8]byte{
+	CodecZstd: magicZstd,
+	CodecLZ4:  magicLZ4,
+}
+
+var magicToCodec = map[[8]byte]Codec{
+	magicZstd: CodecZstd,
+	magicLZ4:  CodecLZ4,
+}
+
+// CodecFromString returns the codec with the given name, "none" and "" return CodecNone.
+func CodecFromString(name string) (Codec, error) {
+	if name == "" || name == "none" {
+		return CodecNone, nil
+	}
+
+	if _, ok := codecToMagic[Codec(name)]; !ok {
+		// nolint: forbidigo
+		return CodecNone, fmt.Errorf("unknown codec: %s", name)
+	}
+
+	return Codec(name), nil
+}
+
+// CompressionHeader identifies the codec of a compressed file body. It directly follows the file type Header,
+// so the header of a compressed file is 16 bytes, while files that are not compressed keep the 8 byte header
+// and can be stored next to compressed files of the same type.
+//
+// The compression header is followed by the chunk container, version 1, which is not a standard zstd or lz4
+// stream. The uncompressed body is split into chunks that are compressed independently, every chunk is
+// stored as:
+//
+//	uint32 little endian  uncompressed length of the chunk
+//	uint32 little endian  stored length of the chunk
+//	[stored length]byte   the chunk, a zstd frame or an lz4 block, or the uncompressed chunk when both lengths are equal
+//
+// The chunks follow each other up to the end of the file, there is no index or trailer. The uncompressed
+// length of a chunk is at most 64 MiB. A reader finds the chunk of an offset in the uncompressed body by
+// reading the chunk headers and skipping the stored bytes of the chunks before it.
+type CompressionHeader struct {
+	magic [8]byte
+}
+
+func NewCompressionHeader(codec Codec) CompressionHeader {
+	magic, found := codecToMagic[codec]
+	if !found {
+		panic(fmt.Sprintf("unknown codec %q", codec))
+	}
+
+	return CompressionHeader{
+		magic: magic,
+	}
+}
+
+func (h CompressionHeader) Size() int {
+	return 8
+}
+
+func (h CompressionHeader) Bytes() []byte {
+	buf := new(bytes.Buffer)
+
+	if err := h.Write(buf); err != nil {
+		panic(err)
+	}
+
+	return buf.Bytes()
+}
+
+func (h CompressionHeader) Write(w io.Writer) error {
+	if _, err := w.Write(h.magic[:]); err != nil {
+		// nolint: forbidigo
+		return fmt.Errorf("error writing compression magic: %w", err)
+	}
+
+	return nil
+}
+
+func (h CompressionHeader) Codec() Codec {
+	return magicToCodec[h.magic]
+}
+
+// ReadCompressionHeader reads the compression header at the start of a file body, the part of the file after
+// the file type Header. Bodies that are not compressed do not have a compression header, in which case CodecNone
+// is returned with a reader that still returns the whole body.
+func ReadCompressionHeader(r io.Reader) (Codec, io.Reader, error) {
+	var magic [8]byte
+
+	n, err := io.ReadFull(r, magic[:])
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		// nolint: forbidigo
+		return CodecNone, nil, fmt.Errorf("error reading compression magic: %w", err)
+	}
+
+	if codec, ok := magicToCodec[magic]; ok && n == len(magic) {
+		return codec, r, nil
+	}
+
+	return CodecNone, io.MultiReader(bytes.NewReader(magic[:n]), r), nil
+}
+
+// CodecFromBytes returns the codec of a file body held in memory, CodecNone when it is not compressed.
+func CodecFromBytes(b []byte) Codec {
+	if len(b) < 8 {
+		return CodecNone
+	}
+
+	return magicToCodec[[8]byte(b[:8])]
+}
@@ -0,0 +1,55 @@
+package fileformat
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressionHeader_RoundTrip(t *testing.T) {
+	for _, codec := range []Codec{CodecZstd, CodecLZ4} {
+		buf := &bytes.Buffer{}
+
+		require.NoError(t, NewCompressionHeader(codec).Write(buf))
+		buf.WriteString("body")
+
+		assert.Equal(t, codec, CodecFromBytes(buf.Bytes()))
+
+		readCodec, r, err := ReadCompressionHeader(buf)
+		require.NoError(t, err)
+		assert.Equal(t, codec, readCodec)
+
+		body, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("body"), body)
+	}
+}
+
+func TestReadCompressionHeader_Uncompressed(t *testing.T) {
+	for _, body := range [][]byte{{}, []byte("tx"), []byte("uncompressed body")} {
+		codec, r, err := ReadCompressionHeader(bytes.NewReader(body))
+		require.NoError(t, err)
+		assert.Equal(t, CodecNone, codec)
+
+		// the bytes read to look for the header are returned by the reader
+		read, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, body, read)
+
+		assert.Equal(t, CodecNone, CodecFromBytes(body))
+	}
+}
+
+func TestCodecFromString(t *testing.T) {
+	for name, expected := range map[string]Codec{"": CodecNone, "none": CodecNone, "zstd": CodecZstd, "lz4": CodecLZ4} {
+		codec, err := CodecFromString(name)
+		require.NoError(t, err)
+		assert.Equal(t, expected, codec)
+	}
+
+	_, err := CodecFromString("gzip")
+	require.Error(t, err)
+}
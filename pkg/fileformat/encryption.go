@@ -0,0 +1,121 @@
+package fileformat
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// magicEncryption is the magic of the encryption header - exactly 8 ASCII characters (8 bytes), ending in the
+// version of the encrypted chunk container
+var magicEncryption = [8]byte{'G', 'C', 'M', '-', 'C', 'H', 'K', '2'} // GCM-CHK2
+
+// EncryptionSaltSize is the size of the random salt the key of a file is derived with
+const EncryptionSaltSize = 32
+
+// EncryptionHeaderSize is the size of the encryption header, the magic, the key ID and the salt
+const EncryptionHeaderSize = 8 + 4 + EncryptionSaltSize
+
+// EncryptionHeader identifies the key an encrypted file body is encrypted with. Like the CompressionHeader, it
+// directly follows the file type Header, so encrypted and plain files of the same type can be stored next to
+// each other. When a file is both compressed and encrypted, the compression header is part of the encrypted body.
+//
+// The header is 44 bytes:
+//
+//	[8]byte               magic, GCM-CHK2
+//	uint32 little endian  ID of the key in the keyring the body is encrypted with
+//	[32]byte              random salt of the file
+//
+// The header is followed by the encrypted chunk container, version 2. Every file is encrypted with a key of its
+// own, the 32 byte AES-256 key derived with HKDF-SHA256 from the keyring key and the salt of the file, with the
+// info "teranode-blob-encryption", the little endian key ID and the file type. The plain body is split into
+// chunks of 64 KiB, the last chunk is shorter and may be empty, so a file always ends with a chunk of less than
+// 64 KiB. Every chunk is sealed with AES-GCM and stored as the ciphertext followed by the 16 byte tag, so all
+// chunks but the last are stored in 64 KiB + 16 bytes and the chunk of an offset is found without reading the
+// chunks before it. The nonce of a chunk is 8 zero bytes followed by the big endian uint32 index of the chunk,
+// which is unique as the key is only used for one file. The additional data is the little endian key ID, the
+// file type and a single byte, 1 for the last chunk and 0 otherwise, so truncated files and files moved to
+// another file type fail to decrypt.
+type EncryptionHeader struct {
+	keyID uint32
+	salt  [EncryptionSaltSize]byte
+}
+
+func NewEncryptionHeader(keyID uint32, salt [EncryptionSaltSize]byte) EncryptionHeader {
+	return EncryptionHeader{
+		keyID: keyID,
+		salt:  salt,
+	}
+}
+
+func (h EncryptionHeader) Size() int {
+	return EncryptionHeaderSize
+}
+
+func (h EncryptionHeader) Bytes() []byte {
+	buf := new(bytes.Buffer)
+
+	if err := h.Write(buf); err != nil {
+		panic(err)
+	}
+
+	return buf.Bytes()
+}
+
+func (h EncryptionHeader) Write(w io.Writer) error {
+	var b [EncryptionHeaderSize]byte
+
+	copy(b[0:8], magicEncryption[:])
+	binary.LittleEndian.PutUint32(b[8:12], h.keyID)
+	copy(b[12:], h.salt[:])
+
+	if _, err := w.Write(b[:]); err != nil {
+		// nolint: forbidigo
+		return fmt.Errorf("error writing encryption header: %w", err)
+	}
+
+	return nil
+}
+
+// KeyID returns the ID of the key the body is encrypted with
+func (h EncryptionHeader) KeyID() uint32 {
+	return h.keyID
+}
+
+// Salt returns the salt the key of the body is derived with
+func (h EncryptionHeader) Salt() [EncryptionSaltSize]byte {
+	return h.salt
+}
+
+// ReadEncryptionHeader reads the encryption header at the start of a file body, the part of the file after the
+// file type Header. Bodies that are not encrypted do not have an encryption header, in which case false is
+// returned with a reader that still returns the whole body.
+func ReadEncryptionHeader(r io.Reader) (EncryptionHeader, bool, io.Reader, error) {
+	var b [EncryptionHeaderSize]byte
+
+	n, err := io.ReadFull(r, b[:])
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		// nolint: forbidigo
+		return EncryptionHeader{}, false, nil, fmt.Errorf("error reading encryption header: %w", err)
+	}
+
+	if header, ok := EncryptionHeaderFromBytes(b[:n]); ok {
+		return header, true, r, nil
+	}
+
+	return EncryptionHeader{}, false, io.MultiReader(bytes.NewReader(b[:n]), r), nil
+}
+
+// EncryptionHeaderFromBytes returns the encryption header of a file body held in memory, false when it is not
+// encrypted.
+func EncryptionHeaderFromBytes(b []byte) (EncryptionHeader, bool) {
+	if len(b) < EncryptionHeaderSize || [8]byte(b[0:8]) != magicEncryption {
+		return EncryptionHeader{}, false
+	}
+
+	return EncryptionHeader{
+		keyID: binary.LittleEndian.Uint32(b[8:12]),
+		salt:  [EncryptionSaltSize]byte(b[12:EncryptionHeaderSize]),
+	}, true
+}
@@ -0,0 +1,50 @@
+package fileformat
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptionHeader_RoundTrip(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	salt := [EncryptionSaltSize]byte{1, 2, 3, 4, 5, 6, 7, 8}
+	salt[EncryptionSaltSize-1] = 9
+
+	require.NoError(t, NewEncryptionHeader(7, salt).Write(buf))
+	buf.WriteString("body")
+
+	header, ok := EncryptionHeaderFromBytes(buf.Bytes())
+	require.True(t, ok)
+	assert.Equal(t, uint32(7), header.KeyID())
+
+	header, encrypted, r, err := ReadEncryptionHeader(buf)
+	require.NoError(t, err)
+	require.True(t, encrypted)
+	assert.Equal(t, uint32(7), header.KeyID())
+	assert.Equal(t, salt, header.Salt())
+
+	body, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("body"), body)
+}
+
+func TestReadEncryptionHeader_Plain(t *testing.T) {
+	for _, body := range [][]byte{{}, []byte("tx"), []byte("a plain body that is longer than the header")} {
+		_, encrypted, r, err := ReadEncryptionHeader(bytes.NewReader(body))
+		require.NoError(t, err)
+		assert.False(t, encrypted)
+
+		// the bytes read to look for the header are returned by the reader
+		read, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, body, read)
+
+		_, encrypted = EncryptionHeaderFromBytes(body)
+		assert.False(t, encrypted)
+	}
+}
@@ -28,6 +28,7 @@ const (
 	FileTypeBatchData      FileType = "batch-data"
 	FileTypeBatchKeys      FileType = "batch-keys"
 	FileTypePreserveUntil  FileType = "preserveUntil"
+	FileTypeCFilter        FileType = "cfilter"
 	FileTypeUnknown        FileType = ""
 )
 
@@ -59,6 +60,7 @@ var (
 	magicBatchData      = [8]byte{'B', 'D', '-', '1', '.', '0', ' ', ' '} // BD-1.0
 	magicBatchKeys      = [8]byte{'B', 'K', '-', '1', '.', '0', ' ', ' '} // BK-1.0
 	magicPreserveUntil  = [8]byte{'P', 'U', '-', '1', '.', '0', ' ', ' '} // PU-1.0
+	magicCFilter        = [8]byte{'C', 'F', '-', '1', '.', '0', ' ', ' '} // CF-1.0
 )
 
 var fileTypeToMagic = map[FileType][8]byte{
@@ -80,6 +82,7 @@ var fileTypeToMagic = map[FileType][8]byte{
 	FileTypeBatchData:      magicBatchData,
 	FileTypeBatchKeys:      magicBatchKeys,
 	FileTypePreserveUntil:  magicPreserveUntil,
+	FileTypeCFilter:        magicCFilter,
 }
 
 var magicToFileType = map[[8]byte]FileType{
@@ -101,6 +104,7 @@ var magicToFileType = map[[8]byte]FileType{
 	magicBatchData:      FileTypeBatchData,
 	magicBatchKeys:      FileTypeBatchKeys,
 	magicPreserveUntil:  FileTypePreserveUntil,
+	magicCFilter:        FileTypeCFilter,
 }
 
 type Header struct {
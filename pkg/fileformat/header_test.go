@@ -97,6 +97,7 @@ func TestNewHeader_AllFileTypes(t *testing.T) {
 		FileTypeBatchData,
 		FileTypeBatchKeys,
 		FileTypePreserveUntil,
+		FileTypeCFilter,
 	}
 
 	for _, fileType := range allTypes {
@@ -18,8 +18,10 @@ import (
 	"github.com/bsv-blockchain/teranode/services/p2p"
 	"github.com/bsv-blockchain/teranode/settings"
 	"github.com/bsv-blockchain/teranode/stores/blob"
+	"github.com/bsv-blockchain/teranode/stores/scripthash"
 	"github.com/bsv-blockchain/teranode/stores/utxo"
 	"github.com/bsv-blockchain/teranode/ulogger"
+	"github.com/bsv-blockchain/teranode/util/feeestimator"
 	"github.com/bsv-blockchain/teranode/util/health"
 	"golang.org/x/sync/errgroup"
 )
@@ -58,6 +60,8 @@ type Server struct {
 	blockchainClient      blockchain.ClientI
 	blockvalidationClient blockvalidation.Interface
 	p2pClient             p2p.ClientI
+	scriptHashStore       scripthash.Store
+	feeEstimator          *feeestimator.Estimator
 }
 
 // NewServer creates a new Server instance with the provided dependencies.
@@ -76,12 +80,15 @@ type Server struct {
 //   - subtreeStore: Store for subtree data, enabling efficient block traversal and validation
 //   - blockPersisterStore: Store for block persistence, ensuring durable storage of validated blocks
 //   - blockchainClient: Client interface for blockchain operations, facilitating integration with the blockchain service
+//   - scriptHashStore: Optional script hash index store, nil when the script hash index is disabled
+//   - feeEstimator: Optional fee estimator, nil when fee estimation is disabled
 //
 // Returns:
 //   - *Server: A fully initialized Server instance ready for use
 func NewServer(logger ulogger.Logger, tSettings *settings.Settings, utxoStore utxo.Store, txStore blob.Store,
 	subtreeStore blob.Store, blockPersisterStore blob.Store, blockchainClient blockchain.ClientI,
-	blockvalidationClient blockvalidation.Interface, p2pClient p2p.ClientI) *Server {
+	blockvalidationClient blockvalidation.Interface, p2pClient p2p.ClientI, scriptHashStore scripthash.Store,
+	feeEstimator *feeestimator.Estimator) *Server {
 	s := &Server{
 		logger:                logger,
 		settings:              tSettings,
@@ -92,6 +99,8 @@ func NewServer(logger ulogger.Logger, tSettings *settings.Settings, utxoStore ut
 		blockchainClient:      blockchainClient,
 		blockvalidationClient: blockvalidationClient,
 		p2pClient:             p2pClient,
+		scriptHashStore:       scriptHashStore,
+		feeEstimator:          feeEstimator,
 	}
 
 	return s
@@ -162,6 +171,10 @@ func (v *Server) Health(ctx context.Context, checkLiveness bool) (int, string, e
 		checks = append(checks, health.Check{Name: "BlockPersisterStore", Check: v.blockPersisterStore.Health})
 	}
 
+	if v.scriptHashStore != nil {
+		checks = append(checks, health.Check{Name: "ScriptHashStore", Check: v.scriptHashStore.Health})
+	}
+
 	return health.CheckAll(ctx, checkLiveness, checks)
 }
 
@@ -195,6 +208,9 @@ func (v *Server) Init(ctx context.Context) (err error) {
 		return errors.NewServiceError("error creating repository", err)
 	}
 
+	repo.ScriptHashStore = v.scriptHashStore
+	repo.FeeEstimator = v.feeEstimator
+
 	v.httpServer, err = httpimpl.New(v.logger, v.settings, repo)
 	if err != nil {
 		return errors.NewServiceError("error creating http server", err)
@@ -71,7 +71,7 @@ func testSetup(t *testing.T) *testCtx {
 	blockchainClient, err := blockchain.NewLocalClient(logger, settings, blockchainStore, nil, nil)
 	require.NoError(t, err)
 
-	server := NewServer(logger, settings, utxoStore, txSore, subtreeStore, blockPersisterStore, blockchainClient, nil, nil)
+	server := NewServer(logger, settings, utxoStore, txSore, subtreeStore, blockPersisterStore, blockchainClient, nil, nil, nil, nil)
 
 	return &testCtx{
 		server:           server,
@@ -211,6 +211,8 @@ func TestHealth_LivenessCheck(t *testing.T) {
 		nil,
 		nil,
 		nil,
+		nil,
+		nil,
 	)
 
 	status, msg, err := server.Health(context.Background(), true)
@@ -230,6 +232,8 @@ func TestHealth_ReadinessWithNoDependencies(t *testing.T) {
 		nil,
 		nil,
 		nil,
+		nil,
+		nil,
 	)
 
 	status, msg, err := server.Health(context.Background(), false)
@@ -532,6 +536,8 @@ func TestHealth_ErrorCases(t *testing.T) {
 			nil, // blockchainClient is nil - will cause health check to report error
 			nil, // blockvalidationClient
 			nil, // p2pClient
+			nil, // scriptHashStore
+			nil, // feeEstimator
 		)
 
 		// Readiness check should still return OK status even with nil dependencies
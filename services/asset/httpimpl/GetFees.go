@@ -0,0 +1,72 @@
+// Package httpimpl provides HTTP handlers for blockchain data retrieval and analysis.
+package httpimpl
+
+import (
+	"net/http"
+
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/util/tracing"
+	"github.com/labstack/echo/v4"
+)
+
+// GetFees creates an HTTP handler for retrieving the fee rates estimated for transactions
+// to be mined within a number of blocks, as recorded by the fee estimator.
+//
+// Parameters:
+//   - mode: ReadMode (only JSON mode is supported)
+//
+// Returns:
+//   - func(c echo.Context) error: Echo handler function
+//
+// HTTP Response:
+//
+//	Status: 200 OK
+//	Content-Type: application/json
+//	Body:
+//	  {
+//	    "height": <uint32>,              // Height of the last block seen by the estimator
+//	    "trackedTransactions": <int>,    // Number of transactions waiting to be mined
+//	    "minFeeRate": <float64>,         // Minimum fee rate of the estimates in satoshis per kB
+//	    "estimates": [
+//	      {
+//	        "blocks": <int>,             // Target number of blocks
+//	        "feeRate": <float64>         // Fee rate in satoshis per kB, -1 when no estimate is available
+//	      }
+//	    ]
+//	  }
+//
+// Error Responses:
+//   - 400 Bad Request: Invalid read mode
+//   - 503 Service Unavailable: Fee estimation is not enabled
+//   - 500 Internal Server Error: Estimator errors
+//
+// Monitoring:
+//   - Execution time recorded in "GetFees_http" statistic
+//   - Prometheus metric "asset_http_get_fees" tracks successful responses
+func (h *HTTP) GetFees(mode ReadMode) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		ctx, _, deferFn := tracing.Tracer("asset").Start(c.Request().Context(), "GetFees_http",
+			tracing.WithParentStat(AssetStat),
+			tracing.WithDebugLogMessage(h.logger, "[Asset_http] GetFees in %s for %s", mode, c.Request().RemoteAddr),
+		)
+
+		defer deferFn()
+
+		if mode != JSON {
+			return echo.NewHTTPError(http.StatusBadRequest, errors.NewInvalidArgumentError("bad read mode").Error())
+		}
+
+		summary, err := h.repository.GetFeeEstimates(ctx)
+		if err != nil {
+			if errors.Is(err, errors.ErrServiceUnavailable) {
+				return echo.NewHTTPError(http.StatusServiceUnavailable, err.Error())
+			}
+
+			return echo.NewHTTPError(http.StatusInternalServerError, errors.NewProcessingError("error reading fee estimates", err).Error())
+		}
+
+		prometheusAssetHTTPGetFees.WithLabelValues("OK", "200").Inc()
+
+		return c.JSONPretty(200, summary, "  ")
+	}
+}
@@ -0,0 +1,72 @@
+package httpimpl
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/util/feeestimator"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetFees(t *testing.T) {
+	initPrometheusMetrics()
+
+	t.Run("Estimates", func(t *testing.T) {
+		httpServer, mockRepo, echoContext, responseRecorder := GetMockHTTP(t, nil)
+
+		mockRepo.On("GetFeeEstimates").Return(&feeestimator.Summary{
+			Height:              100,
+			TrackedTransactions: 5,
+			MinFeeRate:          1,
+			Estimates: []feeestimator.Estimate{
+				{Blocks: 1, FeeRate: -1},
+				{Blocks: 2, FeeRate: 50},
+			},
+		}, nil).Once()
+
+		echoContext.SetPath("/fees")
+
+		err := httpServer.GetFees(JSON)(echoContext)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, responseRecorder.Code)
+
+		var response feeestimator.Summary
+
+		require.NoError(t, json.Unmarshal(responseRecorder.Body.Bytes(), &response))
+		assert.Equal(t, uint32(100), response.Height)
+		assert.Equal(t, 5, response.TrackedTransactions)
+		require.Len(t, response.Estimates, 2)
+		assert.Equal(t, feeestimator.Estimate{Blocks: 2, FeeRate: 50}, response.Estimates[1])
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Fee estimation not enabled", func(t *testing.T) {
+		httpServer, mockRepo, echoContext, _ := GetMockHTTP(t, nil)
+
+		mockRepo.On("GetFeeEstimates").Return(nil, errors.NewServiceUnavailableError("fee estimation is not enabled")).Once()
+
+		echoContext.SetPath("/fees")
+
+		err := httpServer.GetFees(JSON)(echoContext)
+		echoErr := &echo.HTTPError{}
+		require.True(t, errors.As(err, &echoErr))
+
+		assert.Equal(t, http.StatusServiceUnavailable, echoErr.Code)
+	})
+
+	t.Run("Invalid read mode", func(t *testing.T) {
+		httpServer, _, echoContext, _ := GetMockHTTP(t, nil)
+
+		err := httpServer.GetFees(BINARY_STREAM)(echoContext)
+		echoErr := &echo.HTTPError{}
+		require.True(t, errors.As(err, &echoErr))
+
+		assert.Equal(t, http.StatusBadRequest, echoErr.Code)
+	})
+}
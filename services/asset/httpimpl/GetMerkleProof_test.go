@@ -17,10 +17,12 @@ import (
 	"github.com/bsv-blockchain/teranode/services/blockvalidation"
 	"github.com/bsv-blockchain/teranode/services/p2p"
 	"github.com/bsv-blockchain/teranode/settings"
+	"github.com/bsv-blockchain/teranode/stores/scripthash"
 	"github.com/bsv-blockchain/teranode/stores/utxo"
 	"github.com/bsv-blockchain/teranode/stores/utxo/meta"
 	"github.com/bsv-blockchain/teranode/ulogger"
 	"github.com/bsv-blockchain/teranode/util/bump"
+	"github.com/bsv-blockchain/teranode/util/feeestimator"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -610,6 +612,22 @@ func (m *MockRepositoryForMerkleProof) GetUtxo(ctx context.Context, spend *utxo.
 	return nil, nil
 }
 
+func (m *MockRepositoryForMerkleProof) GetScriptHashHistory(ctx context.Context, scriptHash *chainhash.Hash, skip, limit int, reverse bool) ([]*scripthash.HistoryItem, error) {
+	return nil, nil
+}
+
+func (m *MockRepositoryForMerkleProof) GetScriptHashUnspent(ctx context.Context, scriptHash *chainhash.Hash) ([]*scripthash.Output, error) {
+	return nil, nil
+}
+
+func (m *MockRepositoryForMerkleProof) GetScriptHashBalance(ctx context.Context, scriptHash *chainhash.Hash) (*scripthash.Balance, error) {
+	return nil, nil
+}
+
+func (m *MockRepositoryForMerkleProof) GetFeeEstimates(ctx context.Context) (*feeestimator.Summary, error) {
+	return nil, nil
+}
+
 func (m *MockRepositoryForMerkleProof) GetBestBlockHeader(ctx context.Context) (*model.BlockHeader, *model.BlockHeaderMeta, error) {
 	return nil, nil, nil
 }
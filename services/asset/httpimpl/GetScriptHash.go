@@ -0,0 +1,325 @@
+// Package httpimpl provides HTTP handlers for blockchain data retrieval and analysis.
+package httpimpl
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/util/tracing"
+	"github.com/labstack/echo/v4"
+)
+
+// ScriptHashHistoryItem represents a confirmed transaction that created or spent an output
+// locked by a script hash.
+type ScriptHashHistoryItem struct {
+	// Txid is the hash of the transaction
+	Txid string `json:"txid"`
+
+	// Height is the height of the block containing the transaction
+	Height uint32 `json:"height"`
+
+	// TxPos is the position of the transaction in the block
+	TxPos uint32 `json:"txPos"`
+}
+
+// ScriptHashUnspentItem represents an unspent output locked by a script hash.
+type ScriptHashUnspentItem struct {
+	// Txid is the hash of the transaction that created the output
+	Txid string `json:"txid"`
+
+	// Vout is the index of the output in the creating transaction
+	Vout uint32 `json:"vout"`
+
+	// Height is the height of the block containing the creating transaction
+	Height uint32 `json:"height"`
+
+	// TxPos is the position of the creating transaction in the block
+	TxPos uint32 `json:"txPos"`
+
+	// Satoshis is the value of the output
+	Satoshis uint64 `json:"satoshis"`
+}
+
+// ScriptHashBalance represents the confirmed balance of a script hash.
+type ScriptHashBalance struct {
+	// Confirmed is the sum of all unspent outputs
+	Confirmed uint64 `json:"confirmed"`
+
+	// Received is the sum of all outputs ever created
+	Received uint64 `json:"received"`
+
+	// Spent is the sum of all spent outputs
+	Spent uint64 `json:"spent"`
+
+	// UnspentCount is the number of unspent outputs
+	UnspentCount uint64 `json:"unspentCount"`
+}
+
+// GetScriptHashHistory creates an HTTP handler for retrieving the confirmed transaction history
+// of a script hash, as maintained by the script hash index service.
+//
+// Parameters:
+//   - mode: ReadMode (only JSON mode is supported)
+//
+// Returns:
+//   - func(c echo.Context) error: Echo handler function
+//
+// URL Parameters:
+//   - hash: Script hash (hex string of the SHA256 of the locking script, byte order reversed)
+//
+// Query Parameters:
+//   - offset: Number of items to skip (default: 0)
+//   - limit: Maximum number of items to return (default: 20, max: 100)
+//   - reverse: Return the newest items first when "true"
+//
+// HTTP Response:
+//
+//	Status: 200 OK
+//	Content-Type: application/json
+//	Body:
+//	  {
+//	    "data": [
+//	      {
+//	        "txid": "<string>",   // Transaction ID
+//	        "height": <uint32>,   // Block height
+//	        "txPos": <uint32>     // Position of the transaction in the block
+//	      }
+//	    ],
+//	    "pagination": {
+//	      "offset": <int>,
+//	      "limit": <int>,
+//	      "totalRecords": 0  // not computed for script hash history
+//	    }
+//	  }
+//
+// Error Responses:
+//   - 400 Bad Request: Invalid script hash, pagination parameters or read mode
+//   - 503 Service Unavailable: Script hash index is not enabled
+//   - 500 Internal Server Error: Index lookup errors
+//
+// Monitoring:
+//   - Execution time recorded in "GetScriptHashHistory_http" statistic
+//   - Prometheus metric "asset_http_get_script_hash" tracks successful responses
+func (h *HTTP) GetScriptHashHistory(mode ReadMode) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		ctx, _, deferFn := tracing.Tracer("asset").Start(c.Request().Context(), "GetScriptHashHistory_http",
+			tracing.WithParentStat(AssetStat),
+			tracing.WithDebugLogMessage(h.logger, "[Asset_http] GetScriptHashHistory in %s for %s: %s", mode, c.Request().RemoteAddr, c.Param("hash")),
+		)
+
+		defer deferFn()
+
+		if mode != JSON {
+			return echo.NewHTTPError(http.StatusBadRequest, errors.NewInvalidArgumentError("bad read mode").Error())
+		}
+
+		scriptHash, err := getScriptHashParam(c)
+		if err != nil {
+			return err
+		}
+
+		offset, limit, err := h.getLimitOffset(c)
+		if err != nil {
+			// err is already an echo.HTTPError
+			return err
+		}
+
+		// a limit of 0 means no limit to the store, which is not allowed over HTTP
+		if offset < 0 || limit < 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, errors.NewInvalidArgumentError("invalid offset or limit").Error())
+		}
+
+		reverse := false
+
+		if reverseStr := c.QueryParam("reverse"); reverseStr != "" {
+			if reverse, err = strconv.ParseBool(reverseStr); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, errors.NewInvalidArgumentError("invalid reverse format", err).Error())
+			}
+		}
+
+		history, err := h.repository.GetScriptHashHistory(ctx, scriptHash, offset, limit, reverse)
+		if err != nil {
+			return scriptHashHTTPError(err)
+		}
+
+		items := make([]*ScriptHashHistoryItem, 0, len(history))
+
+		for _, item := range history {
+			items = append(items, &ScriptHashHistoryItem{
+				Txid:   item.TxID.String(),
+				Height: item.Height,
+				TxPos:  item.TxPos,
+			})
+		}
+
+		prometheusAssetHTTPGetScriptHash.WithLabelValues("OK", "200").Inc()
+
+		return c.JSONPretty(200, ExtendedResponse{
+			Data: items,
+			Pagination: Pagination{
+				Offset: offset,
+				Limit:  limit,
+			},
+		}, "  ")
+	}
+}
+
+// GetScriptHashUnspent creates an HTTP handler for retrieving the confirmed unspent outputs
+// locked by a script hash.
+//
+// Parameters:
+//   - mode: ReadMode (only JSON mode is supported)
+//
+// Returns:
+//   - func(c echo.Context) error: Echo handler function
+//
+// URL Parameters:
+//   - hash: Script hash (hex string of the SHA256 of the locking script, byte order reversed)
+//
+// HTTP Response:
+//
+//	Status: 200 OK
+//	Content-Type: application/json
+//	Body: Array of unspent outputs:
+//	  [
+//	    {
+//	      "txid": "<string>",   // Transaction ID
+//	      "vout": <uint32>,     // Output index
+//	      "height": <uint32>,   // Block height
+//	      "txPos": <uint32>,    // Position of the transaction in the block
+//	      "satoshis": <uint64>  // Output value in satoshis
+//	    }
+//	  ]
+//
+// Error Responses:
+//   - 400 Bad Request: Invalid script hash or read mode
+//   - 503 Service Unavailable: Script hash index is not enabled
+//   - 500 Internal Server Error: Index lookup errors
+func (h *HTTP) GetScriptHashUnspent(mode ReadMode) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		ctx, _, deferFn := tracing.Tracer("asset").Start(c.Request().Context(), "GetScriptHashUnspent_http",
+			tracing.WithParentStat(AssetStat),
+			tracing.WithDebugLogMessage(h.logger, "[Asset_http] GetScriptHashUnspent in %s for %s: %s", mode, c.Request().RemoteAddr, c.Param("hash")),
+		)
+
+		defer deferFn()
+
+		if mode != JSON {
+			return echo.NewHTTPError(http.StatusBadRequest, errors.NewInvalidArgumentError("bad read mode").Error())
+		}
+
+		scriptHash, err := getScriptHashParam(c)
+		if err != nil {
+			return err
+		}
+
+		outputs, err := h.repository.GetScriptHashUnspent(ctx, scriptHash)
+		if err != nil {
+			return scriptHashHTTPError(err)
+		}
+
+		items := make([]*ScriptHashUnspentItem, 0, len(outputs))
+
+		for _, output := range outputs {
+			items = append(items, &ScriptHashUnspentItem{
+				Txid:     output.TxID.String(),
+				Vout:     output.Vout,
+				Height:   output.Height,
+				TxPos:    output.TxPos,
+				Satoshis: output.Satoshis,
+			})
+		}
+
+		prometheusAssetHTTPGetScriptHash.WithLabelValues("OK", "200").Inc()
+
+		return c.JSONPretty(200, items, "  ")
+	}
+}
+
+// GetScriptHashBalance creates an HTTP handler for retrieving the confirmed balance of a script hash.
+//
+// Parameters:
+//   - mode: ReadMode (only JSON mode is supported)
+//
+// Returns:
+//   - func(c echo.Context) error: Echo handler function
+//
+// URL Parameters:
+//   - hash: Script hash (hex string of the SHA256 of the locking script, byte order reversed)
+//
+// HTTP Response:
+//
+//	Status: 200 OK
+//	Content-Type: application/json
+//	Body:
+//	  {
+//	    "confirmed": <uint64>,    // Sum of all unspent outputs
+//	    "received": <uint64>,     // Sum of all outputs ever created
+//	    "spent": <uint64>,        // Sum of all spent outputs
+//	    "unspentCount": <uint64>  // Number of unspent outputs
+//	  }
+//
+// Error Responses:
+//   - 400 Bad Request: Invalid script hash or read mode
+//   - 503 Service Unavailable: Script hash index is not enabled
+//   - 500 Internal Server Error: Index lookup errors
+func (h *HTTP) GetScriptHashBalance(mode ReadMode) func(c echo.Context) error {
+	return func(c echo.Context) error {
+		ctx, _, deferFn := tracing.Tracer("asset").Start(c.Request().Context(), "GetScriptHashBalance_http",
+			tracing.WithParentStat(AssetStat),
+			tracing.WithDebugLogMessage(h.logger, "[Asset_http] GetScriptHashBalance in %s for %s: %s", mode, c.Request().RemoteAddr, c.Param("hash")),
+		)
+
+		defer deferFn()
+
+		if mode != JSON {
+			return echo.NewHTTPError(http.StatusBadRequest, errors.NewInvalidArgumentError("bad read mode").Error())
+		}
+
+		scriptHash, err := getScriptHashParam(c)
+		if err != nil {
+			return err
+		}
+
+		balance, err := h.repository.GetScriptHashBalance(ctx, scriptHash)
+		if err != nil {
+			return scriptHashHTTPError(err)
+		}
+
+		prometheusAssetHTTPGetScriptHash.WithLabelValues("OK", "200").Inc()
+
+		return c.JSONPretty(200, &ScriptHashBalance{
+			Confirmed:    balance.Confirmed,
+			Received:     balance.Received,
+			Spent:        balance.Spent,
+			UnspentCount: balance.UnspentCount,
+		}, "  ")
+	}
+}
+
+// getScriptHashParam parses the script hash from the "hash" URL parameter.
+func getScriptHashParam(c echo.Context) (*chainhash.Hash, error) {
+	hashStr := c.Param("hash")
+
+	if len(hashStr) != 64 {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, errors.NewInvalidArgumentError("invalid script hash length").Error())
+	}
+
+	scriptHash, err := chainhash.NewHashFromStr(hashStr)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, errors.NewInvalidArgumentError("invalid script hash format", err).Error())
+	}
+
+	return scriptHash, nil
+}
+
+// scriptHashHTTPError maps a script hash index error to an HTTP error.
+func scriptHashHTTPError(err error) error {
+	if errors.Is(err, errors.ErrServiceUnavailable) {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, err.Error())
+	}
+
+	return echo.NewHTTPError(http.StatusInternalServerError, errors.NewProcessingError("error reading script hash index", err).Error())
+}
@@ -0,0 +1,199 @@
+package httpimpl
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/stores/scripthash"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetScriptHashHistory(t *testing.T) {
+	initPrometheusMetrics()
+
+	scriptHash := scripthash.NewScriptHash([]byte("script"))
+	txID := chainhash.DoubleHashH([]byte("tx"))
+
+	t.Run("Valid script hash", func(t *testing.T) {
+		httpServer, mockRepo, echoContext, responseRecorder := GetMockHTTP(t, nil)
+
+		mockRepo.On("GetScriptHashHistory", &scriptHash, 0, 20, false).Return([]*scripthash.HistoryItem{
+			{TxID: txID, Height: 10, TxPos: 3},
+		}, nil).Once()
+
+		echoContext.SetPath("/scripthash/:hash/history")
+		echoContext.SetParamNames("hash")
+		echoContext.SetParamValues(scriptHash.String())
+
+		err := httpServer.GetScriptHashHistory(JSON)(echoContext)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, responseRecorder.Code)
+
+		var response struct {
+			Data       []ScriptHashHistoryItem `json:"data"`
+			Pagination Pagination              `json:"pagination"`
+		}
+
+		require.NoError(t, json.Unmarshal(responseRecorder.Body.Bytes(), &response))
+		require.Len(t, response.Data, 1)
+		assert.Equal(t, txID.String(), response.Data[0].Txid)
+		assert.Equal(t, uint32(10), response.Data[0].Height)
+		assert.Equal(t, uint32(3), response.Data[0].TxPos)
+		assert.Equal(t, 20, response.Pagination.Limit)
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Paging and reverse", func(t *testing.T) {
+		httpServer, mockRepo, echoContext, responseRecorder := GetMockHTTP(t, nil)
+
+		mockRepo.On("GetScriptHashHistory", &scriptHash, 5, 10, true).Return([]*scripthash.HistoryItem{}, nil).Once()
+
+		echoContext.SetPath("/scripthash/:hash/history")
+		echoContext.SetParamNames("hash")
+		echoContext.SetParamValues(scriptHash.String())
+		echoContext.QueryParams().Set("offset", "5")
+		echoContext.QueryParams().Set("limit", "10")
+		echoContext.QueryParams().Set("reverse", "true")
+
+		err := httpServer.GetScriptHashHistory(JSON)(echoContext)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, responseRecorder.Code)
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Invalid script hash", func(t *testing.T) {
+		httpServer, _, echoContext, _ := GetMockHTTP(t, nil)
+
+		echoContext.SetPath("/scripthash/:hash/history")
+		echoContext.SetParamNames("hash")
+		echoContext.SetParamValues("short")
+
+		err := httpServer.GetScriptHashHistory(JSON)(echoContext)
+		echoErr := &echo.HTTPError{}
+		require.True(t, errors.As(err, &echoErr))
+
+		assert.Equal(t, http.StatusBadRequest, echoErr.Code)
+		assert.Equal(t, "INVALID_ARGUMENT (1): invalid script hash length", echoErr.Message)
+	})
+
+	t.Run("Index not enabled", func(t *testing.T) {
+		httpServer, mockRepo, echoContext, _ := GetMockHTTP(t, nil)
+
+		mockRepo.On("GetScriptHashHistory", &scriptHash, 0, 20, false).Return(nil, errors.NewServiceUnavailableError("script hash index is not enabled")).Once()
+
+		echoContext.SetPath("/scripthash/:hash/history")
+		echoContext.SetParamNames("hash")
+		echoContext.SetParamValues(scriptHash.String())
+
+		err := httpServer.GetScriptHashHistory(JSON)(echoContext)
+		echoErr := &echo.HTTPError{}
+		require.True(t, errors.As(err, &echoErr))
+
+		assert.Equal(t, http.StatusServiceUnavailable, echoErr.Code)
+	})
+}
+
+func TestGetScriptHashUnspent(t *testing.T) {
+	initPrometheusMetrics()
+
+	scriptHash := scripthash.NewScriptHash([]byte("script"))
+	txID := chainhash.DoubleHashH([]byte("tx"))
+
+	t.Run("Valid script hash", func(t *testing.T) {
+		httpServer, mockRepo, echoContext, responseRecorder := GetMockHTTP(t, nil)
+
+		mockRepo.On("GetScriptHashUnspent", &scriptHash).Return([]*scripthash.Output{
+			{ScriptHash: scriptHash, TxID: txID, Vout: 1, Height: 10, TxPos: 3, Satoshis: 1_000},
+		}, nil).Once()
+
+		echoContext.SetPath("/scripthash/:hash/utxos")
+		echoContext.SetParamNames("hash")
+		echoContext.SetParamValues(scriptHash.String())
+
+		err := httpServer.GetScriptHashUnspent(JSON)(echoContext)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, responseRecorder.Code)
+
+		var response []ScriptHashUnspentItem
+
+		require.NoError(t, json.Unmarshal(responseRecorder.Body.Bytes(), &response))
+		require.Len(t, response, 1)
+		assert.Equal(t, ScriptHashUnspentItem{Txid: txID.String(), Vout: 1, Height: 10, TxPos: 3, Satoshis: 1_000}, response[0])
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Store error", func(t *testing.T) {
+		httpServer, mockRepo, echoContext, _ := GetMockHTTP(t, nil)
+
+		mockRepo.On("GetScriptHashUnspent", &scriptHash).Return(nil, errors.NewStorageError("db error")).Once()
+
+		echoContext.SetPath("/scripthash/:hash/utxos")
+		echoContext.SetParamNames("hash")
+		echoContext.SetParamValues(scriptHash.String())
+
+		err := httpServer.GetScriptHashUnspent(JSON)(echoContext)
+		echoErr := &echo.HTTPError{}
+		require.True(t, errors.As(err, &echoErr))
+
+		assert.Equal(t, http.StatusInternalServerError, echoErr.Code)
+	})
+}
+
+func TestGetScriptHashBalance(t *testing.T) {
+	initPrometheusMetrics()
+
+	scriptHash := scripthash.NewScriptHash([]byte("script"))
+
+	t.Run("Valid script hash", func(t *testing.T) {
+		httpServer, mockRepo, echoContext, responseRecorder := GetMockHTTP(t, nil)
+
+		mockRepo.On("GetScriptHashBalance", &scriptHash).Return(&scripthash.Balance{
+			Confirmed:    1_000,
+			Received:     3_000,
+			Spent:        2_000,
+			UnspentCount: 1,
+		}, nil).Once()
+
+		echoContext.SetPath("/scripthash/:hash/balance")
+		echoContext.SetParamNames("hash")
+		echoContext.SetParamValues(scriptHash.String())
+
+		err := httpServer.GetScriptHashBalance(JSON)(echoContext)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, responseRecorder.Code)
+
+		var response ScriptHashBalance
+
+		require.NoError(t, json.Unmarshal(responseRecorder.Body.Bytes(), &response))
+		assert.Equal(t, ScriptHashBalance{Confirmed: 1_000, Received: 3_000, Spent: 2_000, UnspentCount: 1}, response)
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Invalid read mode", func(t *testing.T) {
+		httpServer, _, echoContext, _ := GetMockHTTP(t, nil)
+
+		echoContext.SetPath("/scripthash/:hash/balance")
+		echoContext.SetParamNames("hash")
+		echoContext.SetParamValues(scriptHash.String())
+
+		err := httpServer.GetScriptHashBalance(BINARY_STREAM)(echoContext)
+		echoErr := &echo.HTTPError{}
+		require.True(t, errors.As(err, &echoErr))
+
+		assert.Equal(t, http.StatusBadRequest, echoErr.Code)
+	})
+}
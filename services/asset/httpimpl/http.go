@@ -68,6 +68,14 @@ type HTTP struct {
 //	- GET /api/v1/utxos/{hash}/json: Get UTXOs by transaction
 //	- GET /api/v1/balance: Get UTXO set balance
 //
+//	Script Hash Index (requires the script hash index service):
+//	- GET /api/v1/scripthash/{hash}/history: Get transaction history of a script hash
+//	- GET /api/v1/scripthash/{hash}/utxos: Get unspent outputs of a script hash
+//	- GET /api/v1/scripthash/{hash}/balance: Get balance of a script hash
+//
+//	Fee Estimation (requires feeestimator_enabled):
+//	- GET /api/v1/fees: Get estimated fee rates
+//
 //	Search and Discovery:
 //	- GET /api/v1/search: Search for blockchain entities
 //
@@ -249,6 +257,12 @@ func New(logger ulogger.Logger, tSettings *settings.Settings, repo *repository.R
 
 	apiGroup.GET("/utxos/:hash/json", h.GetUTXOsByTxID(JSON))
 
+	apiGroup.GET("/scripthash/:hash/history", h.GetScriptHashHistory(JSON))
+	apiGroup.GET("/scripthash/:hash/utxos", h.GetScriptHashUnspent(JSON))
+	apiGroup.GET("/scripthash/:hash/balance", h.GetScriptHashBalance(JSON))
+
+	apiGroup.GET("/fees", h.GetFees(JSON))
+
 	apiGroup.GET("/bestblockheader", h.GetBestBlockHeader(BINARY_STREAM))
 	apiGroup.GET("/bestblockheader/hex", h.GetBestBlockHeader(HEX))
 	apiGroup.GET("/bestblockheader/json", h.GetBestBlockHeader(JSON))
@@ -44,6 +44,12 @@ var (
 
 	// prometheusAssetHTTPGetMerkleProof tracks merkle proof retrievals
 	prometheusAssetHTTPGetMerkleProof *prometheus.CounterVec
+
+	// prometheusAssetHTTPGetScriptHash tracks script hash history, unspent and balance retrievals
+	prometheusAssetHTTPGetScriptHash *prometheus.CounterVec
+
+	// prometheusAssetHTTPGetFees tracks fee estimate retrievals
+	prometheusAssetHTTPGetFees *prometheus.CounterVec
 )
 
 // prometheusMetricsInitOnce ensures metrics are initialized exactly once
@@ -84,6 +90,7 @@ func initPrometheusMetrics() {
 //   - http_get_last_n_blocks: Multiple block retrievals
 //   - http_get_utxo: UTXO retrievals
 //   - http_get_merkle_proof: Merkle proof retrievals
+//   - http_get_script_hash: Script hash history, unspent and balance retrievals
 func _initPrometheusMetrics() {
 	prometheusAssetHTTPGetTransaction = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -227,4 +234,30 @@ func _initPrometheusMetrics() {
 			"operation", // type of operation achieved
 		},
 	)
+
+	prometheusAssetHTTPGetScriptHash = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "teranode",
+			Subsystem: "asset",
+			Name:      "http_get_script_hash",
+			Help:      "Number of Get script hash ops",
+		},
+		[]string{
+			"function",  // function tracking the operation
+			"operation", // type of operation achieved
+		},
+	)
+
+	prometheusAssetHTTPGetFees = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "teranode",
+			Subsystem: "asset",
+			Name:      "http_get_fees",
+			Help:      "Number of Get fees ops",
+		},
+		[]string{
+			"function",  // function tracking the operation
+			"operation", // type of operation achieved
+		},
+	)
 }
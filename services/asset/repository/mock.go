@@ -11,8 +11,10 @@ import (
 	"github.com/bsv-blockchain/teranode/services/blockchain"
 	"github.com/bsv-blockchain/teranode/services/blockvalidation"
 	"github.com/bsv-blockchain/teranode/services/p2p"
+	"github.com/bsv-blockchain/teranode/stores/scripthash"
 	"github.com/bsv-blockchain/teranode/stores/utxo"
 	"github.com/bsv-blockchain/teranode/stores/utxo/meta"
+	"github.com/bsv-blockchain/teranode/util/feeestimator"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -289,6 +291,46 @@ func (m *Mock) GetUtxo(_ context.Context, spend *utxo.Spend) (*utxo.SpendRespons
 	return args.Get(0).(*utxo.SpendResponse), args.Error(1)
 }
 
+func (m *Mock) GetScriptHashHistory(_ context.Context, scriptHash *chainhash.Hash, skip, limit int, reverse bool) ([]*scripthash.HistoryItem, error) {
+	args := m.Called(scriptHash, skip, limit, reverse)
+
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]*scripthash.HistoryItem), args.Error(1)
+}
+
+func (m *Mock) GetScriptHashUnspent(_ context.Context, scriptHash *chainhash.Hash) ([]*scripthash.Output, error) {
+	args := m.Called(scriptHash)
+
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]*scripthash.Output), args.Error(1)
+}
+
+func (m *Mock) GetScriptHashBalance(_ context.Context, scriptHash *chainhash.Hash) (*scripthash.Balance, error) {
+	args := m.Called(scriptHash)
+
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*scripthash.Balance), args.Error(1)
+}
+
+func (m *Mock) GetFeeEstimates(_ context.Context) (*feeestimator.Summary, error) {
+	args := m.Called()
+
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*feeestimator.Summary), args.Error(1)
+}
+
 func (m *Mock) GetBestBlockHeader(_ context.Context) (*model.BlockHeader, *model.BlockHeaderMeta, error) {
 	args := m.Called()
 
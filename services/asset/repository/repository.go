@@ -20,9 +20,11 @@ import (
 	"github.com/bsv-blockchain/teranode/services/p2p"
 	"github.com/bsv-blockchain/teranode/settings"
 	"github.com/bsv-blockchain/teranode/stores/blob"
+	"github.com/bsv-blockchain/teranode/stores/scripthash"
 	"github.com/bsv-blockchain/teranode/stores/utxo"
 	"github.com/bsv-blockchain/teranode/stores/utxo/meta"
 	"github.com/bsv-blockchain/teranode/ulogger"
+	"github.com/bsv-blockchain/teranode/util/feeestimator"
 	"github.com/bsv-blockchain/teranode/util/health"
 	"github.com/bsv-blockchain/teranode/util/tracing"
 )
@@ -56,6 +58,10 @@ type Interface interface {
 	GetSubtreeHead(ctx context.Context, hash *chainhash.Hash) (*subtree.Subtree, int, error)
 	FindBlocksContainingSubtree(ctx context.Context, subtreeHash *chainhash.Hash) ([]uint32, []uint32, []int, error)
 	GetUtxo(ctx context.Context, spend *utxo.Spend) (*utxo.SpendResponse, error)
+	GetScriptHashHistory(ctx context.Context, scriptHash *chainhash.Hash, skip, limit int, reverse bool) ([]*scripthash.HistoryItem, error)
+	GetScriptHashUnspent(ctx context.Context, scriptHash *chainhash.Hash) ([]*scripthash.Output, error)
+	GetScriptHashBalance(ctx context.Context, scriptHash *chainhash.Hash) (*scripthash.Balance, error)
+	GetFeeEstimates(ctx context.Context) (*feeestimator.Summary, error)
 	GetBestBlockHeader(ctx context.Context) (*model.BlockHeader, *model.BlockHeaderMeta, error)
 	GetLegacyBlockReader(ctx context.Context, hash *chainhash.Hash, wireBlock ...bool) (*io.PipeReader, error)
 	GetBlockLocator(ctx context.Context, blockHeaderHash *chainhash.Hash, height uint32) ([]*chainhash.Hash, error)
@@ -76,6 +82,10 @@ type Repository struct {
 	BlockchainClient      blockchain.ClientI
 	BlockvalidationClient blockvalidation.Interface
 	P2PClient             p2p.ClientI
+	// ScriptHashStore is optional and only set when the script hash index is enabled
+	ScriptHashStore scripthash.Store
+	// FeeEstimator is optional and only set when fee estimation is enabled
+	FeeEstimator *feeestimator.Estimator
 }
 
 // NewRepository creates a new Repository instance with the provided dependencies.
@@ -165,6 +175,10 @@ func (repo *Repository) Health(ctx context.Context, checkLiveness bool) (int, st
 		checks = append(checks, health.Check{Name: "BlockPersisterStore", Check: repo.BlockPersisterStore.Health})
 	}
 
+	if repo.ScriptHashStore != nil {
+		checks = append(checks, health.Check{Name: "ScriptHashStore", Check: repo.ScriptHashStore.Health})
+	}
+
 	return health.CheckAll(ctx, checkLiveness, checks)
 }
 
@@ -773,6 +787,78 @@ func (repo *Repository) GetUtxo(ctx context.Context, spend *utxo.Spend) (*utxo.S
 	return resp, nil
 }
 
+// GetScriptHashHistory retrieves the confirmed transactions that created or spent an output
+// locked by the given script hash, ordered by block height and position in the block.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - scriptHash: SHA256 of the locking script
+//   - skip: Number of items to skip
+//   - limit: Maximum number of items to return, 0 for no limit
+//   - reverse: Return the newest items first
+//
+// Returns:
+//   - []*scripthash.HistoryItem: History of the script hash
+//   - error: Service unavailable error if the script hash index is not enabled
+func (repo *Repository) GetScriptHashHistory(ctx context.Context, scriptHash *chainhash.Hash, skip, limit int, reverse bool) ([]*scripthash.HistoryItem, error) {
+	if repo.ScriptHashStore == nil {
+		return nil, errors.NewServiceUnavailableError("script hash index is not enabled")
+	}
+
+	return repo.ScriptHashStore.GetHistory(ctx, scriptHash, skip, limit, reverse)
+}
+
+// GetScriptHashUnspent retrieves the unspent outputs locked by the given script hash.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - scriptHash: SHA256 of the locking script
+//
+// Returns:
+//   - []*scripthash.Output: Unspent outputs of the script hash
+//   - error: Service unavailable error if the script hash index is not enabled
+func (repo *Repository) GetScriptHashUnspent(ctx context.Context, scriptHash *chainhash.Hash) ([]*scripthash.Output, error) {
+	if repo.ScriptHashStore == nil {
+		return nil, errors.NewServiceUnavailableError("script hash index is not enabled")
+	}
+
+	return repo.ScriptHashStore.GetUnspent(ctx, scriptHash)
+}
+
+// GetScriptHashBalance retrieves the confirmed balance of the given script hash.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - scriptHash: SHA256 of the locking script
+//
+// Returns:
+//   - *scripthash.Balance: Balance of the script hash
+//   - error: Service unavailable error if the script hash index is not enabled
+func (repo *Repository) GetScriptHashBalance(ctx context.Context, scriptHash *chainhash.Hash) (*scripthash.Balance, error) {
+	if repo.ScriptHashStore == nil {
+		return nil, errors.NewServiceUnavailableError("script hash index is not enabled")
+	}
+
+	return repo.ScriptHashStore.GetBalance(ctx, scriptHash)
+}
+
+// GetFeeEstimates retrieves the fee rates estimated for transactions to be mined within
+// a number of blocks.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//
+// Returns:
+//   - *feeestimator.Summary: Fee rate estimates in satoshis per kB
+//   - error: Service unavailable error if fee estimation is not enabled
+func (repo *Repository) GetFeeEstimates(_ context.Context) (*feeestimator.Summary, error) {
+	if repo.FeeEstimator == nil {
+		return nil, errors.NewServiceUnavailableError("fee estimation is not enabled")
+	}
+
+	return repo.FeeEstimator.Summary(), nil
+}
+
 // GetBestBlockHeader retrieves the header of the current best block in the blockchain.
 //
 // Parameters:
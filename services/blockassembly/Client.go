@@ -12,6 +12,7 @@ import (
 	"github.com/bsv-blockchain/teranode/errors"
 	"github.com/bsv-blockchain/teranode/model"
 	"github.com/bsv-blockchain/teranode/services/blockassembly/blockassembly_api"
+	"github.com/bsv-blockchain/teranode/services/blockassembly/subtreeprocessor"
 	"github.com/bsv-blockchain/teranode/settings"
 	"github.com/bsv-blockchain/teranode/ulogger"
 	"github.com/bsv-blockchain/teranode/util"
@@ -485,3 +486,64 @@ func (s *Client) GetTransactionHashes(ctx context.Context) ([]string, error) {
 
 	return resp.Txs, nil
 }
+
+// GetTxRelatives retrieves transactions in block assembly with their ancestors and descendants in block
+// assembly. Transactions that are not in block assembly are left out.
+//
+// Parameters:
+//   - ctx: Context for cancellation
+//   - hashes: Hashes of the transactions
+//
+// Returns:
+//   - []subtreeprocessor.TxRelatives: The transactions and their relatives, with their parents and children
+//   - error: Any error encountered during retrieval
+func (s *Client) GetTxRelatives(ctx context.Context, hashes []chainhash.Hash) ([]subtreeprocessor.TxRelatives, error) {
+	txHashes := make([][]byte, 0, len(hashes))
+	for _, hash := range hashes {
+		txHashes = append(txHashes, hash.CloneBytes())
+	}
+
+	resp, err := s.client.GetBlockAssemblyTxRelatives(ctx, &blockassembly_api.GetBlockAssemblyTxRelativesRequest{
+		TxHashes: txHashes,
+	})
+	if err != nil {
+		return nil, errors.UnwrapGRPC(err)
+	}
+
+	relatives := make([]subtreeprocessor.TxRelatives, 0, len(resp.Txs))
+
+	for _, tx := range resp.Txs {
+		txHash, err := chainhash.NewHash(tx.TxHash)
+		if err != nil {
+			return nil, errors.NewServiceError("invalid transaction hash", err)
+		}
+
+		relative := subtreeprocessor.TxRelatives{
+			Hash:     *txHash,
+			Parents:  make([]chainhash.Hash, 0, len(tx.Parents)),
+			Children: make([]chainhash.Hash, 0, len(tx.Children)),
+		}
+
+		for _, parentBytes := range tx.Parents {
+			parent, err := chainhash.NewHash(parentBytes)
+			if err != nil {
+				return nil, errors.NewServiceError("invalid parent hash", err)
+			}
+
+			relative.Parents = append(relative.Parents, *parent)
+		}
+
+		for _, childBytes := range tx.Children {
+			child, err := chainhash.NewHash(childBytes)
+			if err != nil {
+				return nil, errors.NewServiceError("invalid child hash", err)
+			}
+
+			relative.Children = append(relative.Children, *child)
+		}
+
+		relatives = append(relatives, relative)
+	}
+
+	return relatives, nil
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/bsv-blockchain/go-subtree"
 	"github.com/bsv-blockchain/teranode/model"
 	"github.com/bsv-blockchain/teranode/services/blockassembly/blockassembly_api"
+	"github.com/bsv-blockchain/teranode/services/blockassembly/subtreeprocessor"
 	"github.com/bsv-blockchain/teranode/settings"
 	"github.com/bsv-blockchain/teranode/ulogger"
 	"github.com/stretchr/testify/assert"
@@ -659,6 +660,57 @@ func TestClient_GetTransactionHashes(t *testing.T) {
 	})
 }
 
+func TestClient_GetTxRelatives(t *testing.T) {
+	ctx := context.Background()
+	mockClient := &mockBlockAssemblyAPIClient{}
+	client := createTestClient(mockClient, 0)
+
+	parent := chainhash.HashH([]byte("parent"))
+	child := chainhash.HashH([]byte("child"))
+
+	t.Run("successful", func(t *testing.T) {
+		mockClient.ExpectedCalls = nil
+		mockClient.On("GetBlockAssemblyTxRelatives", ctx, &blockassembly_api.GetBlockAssemblyTxRelativesRequest{
+			TxHashes: [][]byte{child.CloneBytes()},
+		}, mock.Anything).Return(&blockassembly_api.GetBlockAssemblyTxRelativesResponse{
+			Txs: []*blockassembly_api.BlockAssemblyTxRelative{
+				{TxHash: child.CloneBytes(), Parents: [][]byte{parent.CloneBytes()}},
+				{TxHash: parent.CloneBytes(), Children: [][]byte{child.CloneBytes()}},
+			},
+		}, nil)
+
+		relatives, err := client.GetTxRelatives(ctx, []chainhash.Hash{child})
+		require.NoError(t, err)
+		assert.Equal(t, []subtreeprocessor.TxRelatives{
+			{Hash: child, Parents: []chainhash.Hash{parent}, Children: []chainhash.Hash{}},
+			{Hash: parent, Parents: []chainhash.Hash{}, Children: []chainhash.Hash{child}},
+		}, relatives)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("invalid hash", func(t *testing.T) {
+		mockClient.ExpectedCalls = nil
+		mockClient.On("GetBlockAssemblyTxRelatives", ctx, mock.Anything, mock.Anything).Return(&blockassembly_api.GetBlockAssemblyTxRelativesResponse{
+			Txs: []*blockassembly_api.BlockAssemblyTxRelative{{TxHash: []byte("short")}},
+		}, nil)
+
+		relatives, err := client.GetTxRelatives(ctx, []chainhash.Hash{child})
+		assert.Nil(t, relatives)
+		assert.Error(t, err)
+	})
+
+	t.Run("grpc error", func(t *testing.T) {
+		mockClient.ExpectedCalls = nil
+		mockClient.On("GetBlockAssemblyTxRelatives", ctx, mock.Anything, mock.Anything).Return(
+			nil, status.Error(codes.Internal, "relatives error"))
+
+		relatives, err := client.GetTxRelatives(ctx, []chainhash.Hash{child})
+		assert.Nil(t, relatives)
+		assert.Error(t, err)
+		mockClient.AssertExpectations(t)
+	})
+}
+
 func TestClient_sendBatchToBlockAssembly(t *testing.T) {
 	ctx := context.Background()
 	mockClient := &mockBlockAssemblyAPIClient{}
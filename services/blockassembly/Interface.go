@@ -15,6 +15,7 @@ import (
 	"github.com/bsv-blockchain/go-subtree"
 	"github.com/bsv-blockchain/teranode/model"
 	"github.com/bsv-blockchain/teranode/services/blockassembly/blockassembly_api"
+	"github.com/bsv-blockchain/teranode/services/blockassembly/subtreeprocessor"
 )
 
 // ClientI defines the interface for block assembly client operations.
@@ -146,6 +147,18 @@ type ClientI interface {
 	//   - []*chainhash.Hash: List of transaction hashes
 	//   - error: Any error encountered during retrieval
 	GetTransactionHashes(ctx context.Context) ([]string, error)
+
+	// GetTxRelatives retrieves transactions in block assembly with their ancestors and descendants in block
+	// assembly. Transactions that are not in block assembly are left out.
+	//
+	// Parameters:
+	//   - ctx: Context for cancellation
+	//   - hashes: Hashes of the transactions
+	//
+	// Returns:
+	//   - []subtreeprocessor.TxRelatives: The transactions and their relatives, with their parents and children
+	//   - error: Any error encountered during retrieval
+	GetTxRelatives(ctx context.Context, hashes []chainhash.Hash) ([]subtreeprocessor.TxRelatives, error)
 }
 
 // Store defines the interface for block assembly storage operations.
@@ -1411,6 +1411,61 @@ func (ba *BlockAssembly) GetBlockAssemblyTxs(ctx context.Context, _ *blockassemb
 	}, nil
 }
 
+// GetBlockAssemblyTxRelatives retrieves transactions in block assembly with their ancestors and descendants
+// in block assembly, read from the in-memory transaction map of the subtree processor.
+//
+// Parameters:
+//   - ctx: Context for cancellation and tracing
+//   - req: Request containing the hashes of the transactions
+//
+// Returns:
+//   - *blockassembly_api.GetBlockAssemblyTxRelativesResponse: The transactions and their relatives, with their parents and children
+//   - error: Any error encountered during retrieval
+func (ba *BlockAssembly) GetBlockAssemblyTxRelatives(ctx context.Context, req *blockassembly_api.GetBlockAssemblyTxRelativesRequest) (*blockassembly_api.GetBlockAssemblyTxRelativesResponse, error) {
+	_, _, deferFn := tracing.Tracer("blockassembly").Start(ctx, "GetBlockAssemblyTxRelatives",
+		tracing.WithParentStat(ba.stats),
+		tracing.WithLogMessage(ba.logger, "[GetBlockAssemblyTxRelatives] called for %d transactions", len(req.TxHashes)),
+	)
+	defer deferFn()
+
+	hashes := make([]chainhash.Hash, 0, len(req.TxHashes))
+
+	for _, txHashBytes := range req.TxHashes {
+		txHash, err := chainhash.NewHash(txHashBytes)
+		if err != nil {
+			return nil, errors.WrapGRPC(errors.NewInvalidArgumentError("invalid transaction hash", err))
+		}
+
+		hashes = append(hashes, *txHash)
+	}
+
+	relatives := ba.blockAssembler.subtreeProcessor.GetTxRelatives(hashes)
+
+	resp := &blockassembly_api.GetBlockAssemblyTxRelativesResponse{
+		Txs: make([]*blockassembly_api.BlockAssemblyTxRelative, 0, len(relatives)),
+	}
+
+	for _, relative := range relatives {
+		tx := &blockassembly_api.BlockAssemblyTxRelative{
+			TxHash:   relative.Hash.CloneBytes(),
+			Parents:  make([][]byte, 0, len(relative.Parents)),
+			Children: make([][]byte, 0, len(relative.Children)),
+		}
+
+		for _, parent := range relative.Parents {
+			tx.Parents = append(tx.Parents, parent.CloneBytes())
+		}
+
+		for _, child := range relative.Children {
+			tx.Children = append(tx.Children, child.CloneBytes())
+		}
+
+		resp.Txs = append(resp.Txs, tx)
+	}
+
+	return resp, nil
+}
+
 // GetCurrentDifficulty retrieves the current mining difficulty target.
 //
 // This method provides access to the current difficulty target required for valid
@@ -13,6 +13,7 @@ import (
 	"github.com/bsv-blockchain/go-subtree"
 	"github.com/bsv-blockchain/teranode/model"
 	"github.com/bsv-blockchain/teranode/services/blockassembly/blockassembly_api"
+	"github.com/bsv-blockchain/teranode/services/blockassembly/subtreeprocessor"
 	"github.com/stretchr/testify/mock"
 	"google.golang.org/grpc"
 )
@@ -166,6 +167,20 @@ func (m *Mock) GetTransactionHashes(ctx context.Context) ([]string, error) {
 	return args.Get(0).([]string), nil
 }
 
+func (m *Mock) GetTxRelatives(ctx context.Context, hashes []chainhash.Hash) ([]subtreeprocessor.TxRelatives, error) {
+	args := m.Called(ctx, hashes)
+
+	if args.Error(1) != nil {
+		return nil, args.Error(1)
+	}
+
+	if args.Get(0) == nil {
+		return nil, nil
+	}
+
+	return args.Get(0).([]subtreeprocessor.TxRelatives), nil
+}
+
 // mockBlockAssemblyAPIClient is a mock implementation of BlockAssemblyAPIClient
 type mockBlockAssemblyAPIClient struct {
 	mock.Mock
@@ -282,3 +297,11 @@ func (m *mockBlockAssemblyAPIClient) GetBlockAssemblyTxs(ctx context.Context, in
 	}
 	return args.Get(0).(*blockassembly_api.GetBlockAssemblyTxsResponse), args.Error(1)
 }
+
+func (m *mockBlockAssemblyAPIClient) GetBlockAssemblyTxRelatives(ctx context.Context, in *blockassembly_api.GetBlockAssemblyTxRelativesRequest, opts ...grpc.CallOption) (*blockassembly_api.GetBlockAssemblyTxRelativesResponse, error) {
+	args := m.Called(ctx, in, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*blockassembly_api.GetBlockAssemblyTxRelativesResponse), args.Error(1)
+}
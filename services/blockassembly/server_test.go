@@ -616,6 +616,29 @@ func TestGetBlockAssemblyTxsCoverage(t *testing.T) {
 	})
 }
 
+// TestGetBlockAssemblyTxRelatives tests the GetBlockAssemblyTxRelatives function
+func TestGetBlockAssemblyTxRelatives(t *testing.T) {
+	server, _, _, _ := setup(t)
+	ctx := t.Context()
+
+	t.Run("transaction not in block assembly", func(t *testing.T) {
+		txHash := chainhash.HashH([]byte("unknown"))
+
+		resp, err := server.GetBlockAssemblyTxRelatives(ctx, &blockassembly_api.GetBlockAssemblyTxRelativesRequest{
+			TxHashes: [][]byte{txHash.CloneBytes()},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, resp.Txs)
+	})
+
+	t.Run("invalid transaction hash", func(t *testing.T) {
+		_, err := server.GetBlockAssemblyTxRelatives(ctx, &blockassembly_api.GetBlockAssemblyTxRelativesRequest{
+			TxHashes: [][]byte{[]byte("invalid")},
+		})
+		require.Error(t, err)
+	})
+}
+
 // TestRetryFunctionsCoverage tests the retry-related functions coverage
 func TestRetryFunctionsCoverage(t *testing.T) {
 	server, _, subtree, _ := setup(t)
@@ -90,6 +90,24 @@ type reorgBlocksRequest struct {
 	errChan chan error
 }
 
+// txRelativesRequest represents a request for the relatives of transactions in the subtree processor.
+type txRelativesRequest struct {
+	hashes     []chainhash.Hash   // The hashes of the transactions
+	responseCh chan []TxRelatives // Channel receiving the transactions with their relatives
+}
+
+// TxRelatives is a transaction in the subtree processor, with its parents and children in the subtree processor.
+type TxRelatives struct {
+	// Hash is the hash of the transaction
+	Hash chainhash.Hash
+
+	// Parents are the transactions in the subtree processor this transaction spends outputs of
+	Parents []chainhash.Hash
+
+	// Children are the transactions in the subtree processor spending outputs of this transaction
+	Children []chainhash.Hash
+}
+
 // resetBlocks encapsulates the data needed for a processor reset operation.
 type resetBlocks struct {
 	// blockHeader represents the new block header to reset to
@@ -179,6 +197,9 @@ type SubtreeProcessor struct {
 	// getTransactionHashesChan handles requests to retrieve transaction hashes
 	getTransactionHashesChan chan chan []chainhash.Hash
 
+	// getTxRelativesChan handles requests to retrieve the relatives of transactions
+	getTxRelativesChan chan txRelativesRequest
+
 	// moveForwardBlockChan receives requests to process new blocks
 	moveForwardBlockChan chan moveBlockRequest
 
@@ -224,6 +245,14 @@ type SubtreeProcessor struct {
 	// currentTxMap tracks transactions currently held in the subtree processor
 	currentTxMap *txmap.SyncedMap[chainhash.Hash, subtreepkg.TxInpoints]
 
+	// txChildren indexes the children of the transactions in txChildrenMap by parent, for the relatives of
+	// transactions. It is built on the first relatives request and kept up to date while txChildrenMap is the
+	// currentTxMap, it is rebuilt on the next request once the currentTxMap is replaced.
+	txChildren map[chainhash.Hash][]chainhash.Hash
+
+	// txChildrenMap is the transaction map txChildren indexes, nil before the first relatives request
+	txChildrenMap *txmap.SyncedMap[chainhash.Hash, subtreepkg.TxInpoints]
+
 	// removeMap tracks transactions marked for removal
 	removeMap *txmap.SwissMap
 
@@ -282,6 +311,9 @@ var (
 
 	// StateCheckSubtreeProcessor indicates the processor is checking its state
 	StateCheckSubtreeProcessor State = 11
+
+	// StateGetTxRelatives indicates the processor is retrieving the relatives of transactions
+	StateGetTxRelatives State = 12
 )
 
 var StateStrings = map[State]string{
@@ -296,6 +328,7 @@ var StateStrings = map[State]string{
 	StateResetBlocks:           "resetBlocks",
 	StateRemoveTx:              "removeTx",
 	StateCheckSubtreeProcessor: "checkSubtreeProcessor",
+	StateGetTxRelatives:        "getTxRelatives",
 }
 
 var (
@@ -358,6 +391,7 @@ func NewSubtreeProcessor(ctx context.Context, logger ulogger.Logger, tSettings *
 		getSubtreesChan:          make(chan chan []*subtreepkg.Subtree),
 		getSubtreeHashesChan:     make(chan chan []chainhash.Hash),
 		getTransactionHashesChan: make(chan chan []chainhash.Hash),
+		getTxRelativesChan:       make(chan txRelativesRequest),
 		moveForwardBlockChan:     make(chan moveBlockRequest),
 		reorgBlockChan:           make(chan reorgBlocksRequest),
 		resetCh:                  make(chan *resetBlocks),
@@ -484,6 +518,15 @@ func NewSubtreeProcessor(ctx context.Context, logger ulogger.Logger, tSettings *
 				logger.Debugf("[SubtreeProcessor] get current transaction hashes DONE")
 				stp.setCurrentRunningState(StateRunning)
 
+			case txRelativesReq := <-stp.getTxRelativesChan:
+				stp.setCurrentRunningState(StateGetTxRelatives)
+				logger.Debugf("[SubtreeProcessor] get relatives of %d transactions", len(txRelativesReq.hashes))
+
+				txRelativesReq.responseCh <- stp.txRelatives(txRelativesReq.hashes)
+
+				logger.Debugf("[SubtreeProcessor] get relatives of %d transactions DONE", len(txRelativesReq.hashes))
+				stp.setCurrentRunningState(StateRunning)
+
 			case reorgReq := <-stp.reorgBlockChan:
 				stp.setCurrentRunningState(StateReorg)
 				logger.Infof("[SubtreeProcessor] reorgReq subtree processor: %d, %d", len(reorgReq.moveBackBlocks), len(reorgReq.moveForwardBlocks))
@@ -699,6 +742,10 @@ func (stp *SubtreeProcessor) reset(blockHeader *model.BlockHeader, moveBackBlock
 	// clear current tx map
 	stp.currentTxMap.Clear()
 
+	if stp.txChildrenMap == stp.currentTxMap {
+		clear(stp.txChildren)
+	}
+
 	// reset tx count
 	stp.setTxCountFromSubtrees()
 
@@ -950,6 +997,147 @@ func (stp *SubtreeProcessor) GetTransactionHashes() []chainhash.Hash {
 	return <-response
 }
 
+// GetTxRelatives returns the transactions in the subtree processor, with their ancestors and descendants in
+// the subtree processor. Transactions that are not in the subtree processor are left out.
+//
+// Parameters:
+//   - hashes: The hashes of the transactions
+//
+// Returns:
+//   - []TxRelatives: The transactions and their relatives, with their parents and children
+func (stp *SubtreeProcessor) GetTxRelatives(hashes []chainhash.Hash) []TxRelatives {
+	response := make(chan []TxRelatives)
+
+	stp.getTxRelativesChan <- txRelativesRequest{hashes: hashes, responseCh: response}
+
+	return <-response
+}
+
+// txRelatives walks the parents and children of the transactions through the transaction map. The parents
+// of a transaction are read from its inpoints, the children from the children index, which is only rebuilt
+// with a pass over the map after the map was replaced.
+// It must be called from the processing goroutine, as the transaction map is replaced on reset.
+func (stp *SubtreeProcessor) txRelatives(hashes []chainhash.Hash) []TxRelatives {
+	txMap := stp.currentTxMap
+
+	if stp.txChildrenMap != txMap {
+		stp.rebuildTxChildren()
+	}
+
+	children := stp.txChildren
+
+	relatives := make(map[chainhash.Hash]*TxRelatives)
+	result := make([]TxRelatives, 0, len(hashes))
+
+	relative := func(hash chainhash.Hash) *TxRelatives {
+		if tx, ok := relatives[hash]; ok {
+			return tx
+		}
+
+		txInpoints, _ := txMap.Get(hash)
+
+		tx := &TxRelatives{
+			Hash:     hash,
+			Parents:  make([]chainhash.Hash, 0, len(txInpoints.ParentTxHashes)),
+			Children: make([]chainhash.Hash, 0, len(children[hash])),
+		}
+
+		for _, parentHash := range txInpoints.ParentTxHashes {
+			if _, ok := txMap.Get(parentHash); ok && !slices.Contains(tx.Parents, parentHash) {
+				tx.Parents = append(tx.Parents, parentHash)
+			}
+		}
+
+		// a child spending several outputs of the transaction can be indexed more than once
+		for _, childHash := range children[hash] {
+			if !slices.Contains(tx.Children, childHash) {
+				tx.Children = append(tx.Children, childHash)
+			}
+		}
+
+		relatives[hash] = tx
+		result = append(result, *tx)
+
+		return tx
+	}
+
+	requested := make([]chainhash.Hash, 0, len(hashes))
+
+	for _, hash := range hashes {
+		if _, ok := txMap.Get(hash); ok {
+			requested = append(requested, hash)
+		}
+	}
+
+	// the ancestors are reached through the parents, the descendants through the children
+	walk := func(next func(tx *TxRelatives) []chainhash.Hash) {
+		queue := slices.Clone(requested)
+		visited := make(map[chainhash.Hash]struct{}, len(queue))
+
+		for i := 0; i < len(queue); i++ {
+			for _, hash := range next(relative(queue[i])) {
+				if _, ok := visited[hash]; !ok {
+					visited[hash] = struct{}{}
+					queue = append(queue, hash)
+				}
+			}
+		}
+	}
+
+	walk(func(tx *TxRelatives) []chainhash.Hash { return tx.Parents })
+	walk(func(tx *TxRelatives) []chainhash.Hash { return tx.Children })
+
+	return result
+}
+
+// rebuildTxChildren builds the children index of the currentTxMap with a single pass over the map
+func (stp *SubtreeProcessor) rebuildTxChildren() {
+	stp.txChildren = make(map[chainhash.Hash][]chainhash.Hash)
+	stp.txChildrenMap = stp.currentTxMap
+
+	stp.currentTxMap.Iterate(func(txHash chainhash.Hash, txInpoints subtreepkg.TxInpoints) bool {
+		for _, parentHash := range txInpoints.ParentTxHashes {
+			stp.txChildren[parentHash] = append(stp.txChildren[parentHash], txHash)
+		}
+
+		return true
+	})
+}
+
+// indexTxChildren adds a transaction added to the currentTxMap to the children of its parents, when the children
+// index is built for the currentTxMap
+func (stp *SubtreeProcessor) indexTxChildren(hash chainhash.Hash, parents subtreepkg.TxInpoints) {
+	if stp.txChildrenMap != stp.currentTxMap {
+		return
+	}
+
+	for _, parentHash := range parents.ParentTxHashes {
+		stp.txChildren[parentHash] = append(stp.txChildren[parentHash], hash)
+	}
+}
+
+// deleteFromCurrentTxMap removes a transaction from the currentTxMap, and from the children of its parents when
+// the children index is built for the currentTxMap
+func (stp *SubtreeProcessor) deleteFromCurrentTxMap(hash chainhash.Hash) {
+	if stp.txChildrenMap == stp.currentTxMap {
+		if parents, ok := stp.currentTxMap.Get(hash); ok {
+			for _, parentHash := range parents.ParentTxHashes {
+				children := slices.DeleteFunc(stp.txChildren[parentHash], func(child chainhash.Hash) bool {
+					return child == hash
+				})
+
+				if len(children) == 0 {
+					delete(stp.txChildren, parentHash)
+				} else {
+					stp.txChildren[parentHash] = children
+				}
+			}
+		}
+	}
+
+	stp.currentTxMap.Delete(hash)
+}
+
 // GetUtxoStore returns the UTXO store instance.
 //
 // Returns:
@@ -1208,6 +1396,8 @@ func (stp *SubtreeProcessor) addNode(node subtreepkg.Node, parents *subtreepkg.T
 
 			return nil
 		}
+
+		stp.indexTxChildren(node.Hash, *parents)
 	}
 
 	if stp.currentSubtree == nil {
@@ -1372,7 +1562,7 @@ func (stp *SubtreeProcessor) removeTxFromSubtrees(ctx context.Context, hash chai
 
 	if foundIndex >= 0 {
 		// remove tx from the currentTxMap
-		stp.currentTxMap.Delete(hash)
+		stp.deleteFromCurrentTxMap(hash)
 
 		// we found the transaction in a subtree
 		if foundSubtreeIndex == -1 {
@@ -1436,7 +1626,7 @@ func (stp *SubtreeProcessor) removeTxsFromSubtrees(ctx context.Context, hashes [
 
 		if foundIndex >= 0 {
 			// remove tx from the currentTxMap
-			stp.currentTxMap.Delete(hash)
+			stp.deleteFromCurrentTxMap(hash)
 
 			// we found the transaction in a subtree
 			if foundSubtreeIndex == -1 {
@@ -1520,7 +1710,7 @@ func (stp *SubtreeProcessor) reChainSubtrees(fromIndex int) error {
 			}
 
 			// Remove from currentTxMap so addNode won't skip it as a duplicate
-			stp.currentTxMap.Delete(node.Hash)
+			stp.deleteFromCurrentTxMap(node.Hash)
 
 			// Immediately re-add the node
 			if err = stp.addNode(node, &parents, true); err != nil {
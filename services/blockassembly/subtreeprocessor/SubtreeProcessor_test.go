@@ -3890,3 +3890,84 @@ func TestSubtreeProcessor_checkMarkNotOnLongestChain(t *testing.T) {
 		mockUtxoStore.AssertExpectations(t)
 	})
 }
+
+func TestSubtreeProcessor_GetTxRelatives(t *testing.T) {
+	settings := test.CreateBaseTestSettings(t)
+
+	stp, err := NewSubtreeProcessor(context.Background(), ulogger.TestLogger{}, settings, nil, nil, nil, make(chan NewSubtreeRequest))
+	require.NoError(t, err)
+
+	hash := func(s string) chainhash.Hash { return chainhash.HashH([]byte(s)) }
+	a, b, c, d, x, unrelated, mined := hash("a"), hash("b"), hash("c"), hash("d"), hash("x"), hash("unrelated"), hash("mined")
+
+	// a -> b -> c, x -> b, b -> d, the mined parent of a is not in the processor
+	stp.currentTxMap.Set(a, subtreepkg.TxInpoints{ParentTxHashes: []chainhash.Hash{mined}})
+	stp.currentTxMap.Set(x, subtreepkg.TxInpoints{})
+	stp.currentTxMap.Set(b, subtreepkg.TxInpoints{ParentTxHashes: []chainhash.Hash{a, x, a}})
+	stp.currentTxMap.Set(c, subtreepkg.TxInpoints{ParentTxHashes: []chainhash.Hash{b}})
+	stp.currentTxMap.Set(d, subtreepkg.TxInpoints{ParentTxHashes: []chainhash.Hash{b}})
+	stp.currentTxMap.Set(unrelated, subtreepkg.TxInpoints{})
+
+	byHash := func(relatives []TxRelatives) map[chainhash.Hash]TxRelatives {
+		m := make(map[chainhash.Hash]TxRelatives, len(relatives))
+		for _, relative := range relatives {
+			m[relative.Hash] = relative
+		}
+
+		return m
+	}
+
+	t.Run("ancestors", func(t *testing.T) {
+		relatives := stp.GetTxRelatives([]chainhash.Hash{c})
+		require.Len(t, relatives, 4)
+		assert.Equal(t, c, relatives[0].Hash)
+
+		m := byHash(relatives)
+		assert.Contains(t, m, a)
+		assert.Contains(t, m, x)
+		assert.ElementsMatch(t, []chainhash.Hash{a, x}, m[b].Parents)
+		assert.ElementsMatch(t, []chainhash.Hash{c, d}, m[b].Children)
+		assert.Empty(t, m[a].Parents)
+
+		// the other child of an ancestor is not a relative
+		assert.NotContains(t, m, d)
+	})
+
+	t.Run("descendants", func(t *testing.T) {
+		m := byHash(stp.GetTxRelatives([]chainhash.Hash{a}))
+		require.Len(t, m, 4)
+		assert.Contains(t, m, b)
+		assert.Contains(t, m, c)
+		assert.Contains(t, m, d)
+		assert.NotContains(t, m, x)
+	})
+
+	t.Run("multiple transactions", func(t *testing.T) {
+		m := byHash(stp.GetTxRelatives([]chainhash.Hash{c, d}))
+		require.Len(t, m, 5)
+		assert.NotContains(t, m, unrelated)
+	})
+
+	t.Run("transaction not in the processor", func(t *testing.T) {
+		assert.Empty(t, stp.GetTxRelatives([]chainhash.Hash{mined}))
+	})
+
+	t.Run("children index follows the transaction map", func(t *testing.T) {
+		e, f := hash("e"), hash("f")
+
+		assert.ElementsMatch(t, []chainhash.Hash{b}, byHash(stp.GetTxRelatives([]chainhash.Hash{a}))[a].Children)
+
+		require.NoError(t, stp.addNode(subtreepkg.Node{Hash: e}, &subtreepkg.TxInpoints{ParentTxHashes: []chainhash.Hash{d}}, true))
+		assert.Equal(t, []chainhash.Hash{e}, byHash(stp.GetTxRelatives([]chainhash.Hash{d}))[d].Children)
+
+		require.NoError(t, stp.removeTxFromSubtrees(context.Background(), e))
+		assert.Empty(t, byHash(stp.GetTxRelatives([]chainhash.Hash{d}))[d].Children)
+
+		// the index is rebuilt once the map is replaced
+		stp.currentTxMap = txmap.NewSyncedMap[chainhash.Hash, subtreepkg.TxInpoints]()
+		stp.currentTxMap.Set(a, subtreepkg.TxInpoints{})
+		stp.currentTxMap.Set(f, subtreepkg.TxInpoints{ParentTxHashes: []chainhash.Hash{a}})
+
+		assert.Equal(t, []chainhash.Hash{f}, byHash(stp.GetTxRelatives([]chainhash.Hash{a}))[a].Children)
+	})
+}
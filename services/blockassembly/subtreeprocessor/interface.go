@@ -201,6 +201,16 @@ type Interface interface {
 	//   - []chainhash.Hash: Array of transaction hashes
 	GetTransactionHashes() []chainhash.Hash
 
+	// GetTxRelatives returns the transactions currently being processed with their ancestors and descendants
+	// in the processor, read from the in-memory transaction map. Unknown transactions are left out.
+	//
+	// Parameters:
+	//   - hashes: Hashes of the transactions
+	//
+	// Returns:
+	//   - []TxRelatives: The transactions and their relatives, with their parents and children
+	GetTxRelatives(hashes []chainhash.Hash) []TxRelatives
+
 	// GetUtxoStore returns the UTXO store used by the processor.
 	// This provides access to the underlying UTXO validation system.
 	//
@@ -100,6 +100,14 @@ func (m *MockSubtreeProcessor) GetTransactionHashes() []chainhash.Hash {
 	return args.Get(0).([]chainhash.Hash)
 }
 
+func (m *MockSubtreeProcessor) GetTxRelatives(hashes []chainhash.Hash) []TxRelatives {
+	args := m.Called(hashes)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).([]TxRelatives)
+}
+
 func (m *MockSubtreeProcessor) GetUtxoStore() utxostore.Store {
 	args := m.Called()
 	return args.Get(0).(utxostore.Store)
@@ -311,8 +311,32 @@ func (u *Server) Start(ctx context.Context, readyCh chan<- struct{}) error {
 			IdleTimeout:  60 * time.Second,
 		}
 
+		if u.settings.Block.PersisterHTTPAccessFile != "" {
+			auth, err := blob.LoadServerAuth(
+				u.settings.Block.PersisterHTTPAccessFile,
+				u.settings.Block.PersisterHTTPTLSCertFile,
+				u.settings.Block.PersisterHTTPTLSKeyFile,
+				u.settings.Block.PersisterHTTPTLSClientCAFile,
+			)
+			if err != nil {
+				return errors.NewConfigurationError("failed to load blob store server authentication", err)
+			}
+
+			blobStoreServer.SetAuth(auth)
+			srv.TLSConfig = auth.TLSConfig()
+		}
+
 		go func() {
-			if err := srv.Serve(listener); err != nil && err != http.ErrServerClosed {
+			var err error
+
+			if srv.TLSConfig != nil {
+				// the certificate is in the TLS configuration
+				err = srv.ServeTLS(listener, "", "")
+			} else {
+				err = srv.Serve(listener)
+			}
+
+			if err != nil && err != http.ErrServerClosed {
 				u.logger.Warnf("blockStoreServer ended: %v", err)
 			}
 
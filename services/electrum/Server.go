@@ -0,0 +1,476 @@
+// Package electrum implements a server for the Electrum protocol, the JSON-RPC protocol spoken by
+// Electrum wallets and many merchant integrations. The server is a frontend on top of the asset service
+// repository: transactions, block headers and merkle proofs are read through the repository, the script
+// hash history, balance and unspent outputs are read from the script hash index, and transactions are
+// broadcast through the validator.
+//
+// Clients connect over TCP, with newline delimited JSON-RPC messages, or over WebSocket, with one
+// JSON-RPC message per WebSocket message. Both transports share the same sessions and methods.
+//
+// Clients can subscribe to new block headers and to changes in the status of script hashes. Header
+// notifications are sent when a block is added to the best chain. The status of the subscribed script
+// hashes is checked when a block is added and on a timer, as the script hash index is built after the
+// block has been persisted.
+//
+// Integration points:
+// - Blockchain service: Source of block notifications and block headers
+// - Asset repository: Transactions, blocks and subtrees
+// - Script Hash Store: History, balance and unspent outputs of script hashes
+// - Validator: Validation of broadcast transactions
+package electrum
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/model"
+	"github.com/bsv-blockchain/teranode/services/asset/repository"
+	"github.com/bsv-blockchain/teranode/services/blockchain"
+	"github.com/bsv-blockchain/teranode/services/validator"
+	"github.com/bsv-blockchain/teranode/settings"
+	"github.com/bsv-blockchain/teranode/stores/blob"
+	"github.com/bsv-blockchain/teranode/stores/scripthash"
+	"github.com/bsv-blockchain/teranode/stores/utxo"
+	"github.com/bsv-blockchain/teranode/ulogger"
+	"github.com/bsv-blockchain/teranode/util/health"
+	"github.com/gorilla/websocket"
+	"github.com/ordishs/gocore"
+)
+
+const (
+	// protocolVersion is the version of the Electrum protocol implemented by the server
+	protocolVersion = "1.4"
+
+	// defaultStatusCheckInterval is used when no status check interval is configured
+	defaultStatusCheckInterval = 10 * time.Second
+)
+
+// Server is the Electrum protocol server.
+// It accepts client connections on TCP and, optionally, WebSocket, and keeps track of the sessions
+// and their subscriptions, to notify them of new blocks and script hash status changes.
+type Server struct {
+	// logger provides logging functionality
+	logger ulogger.Logger
+
+	// settings contains configuration settings
+	settings *settings.Settings
+
+	// utxoStore provides access to the transactions in the UTXO store
+	utxoStore utxo.Store
+
+	// txStore provides access to the transactions in the blob store, and stores broadcast transactions
+	txStore blob.Store
+
+	// subtreeStore provides access to the subtrees of the blocks, used to build merkle proofs
+	subtreeStore blob.Store
+
+	// blockPersisterStore provides access to the persisted blocks
+	blockPersisterStore blob.Store
+
+	// blockchainClient provides access to blockchain operations
+	blockchainClient blockchain.ClientI
+
+	// validatorClient validates broadcast transactions, broadcasting is disabled when nil
+	validatorClient validator.Interface
+
+	// scriptHashStore provides access to the script hash index, the script hash methods are disabled when nil
+	scriptHashStore scripthash.Store
+
+	// repository provides access to the blockchain data
+	repository repository.Interface
+
+	// stats tracks operational statistics
+	stats *gocore.Stat
+
+	// mu protects the sessions, listeners and best header
+	mu sync.Mutex
+
+	// sessions holds the connected client sessions
+	sessions map[*session]struct{}
+
+	// tcpListener accepts the TCP connections
+	tcpListener net.Listener
+
+	// wsServer accepts the WebSocket connections
+	wsServer *http.Server
+
+	// bestHeader is the last block header the sessions were notified of
+	bestHeader *model.BlockHeader
+
+	// wg tracks the connection handling goroutines
+	wg sync.WaitGroup
+}
+
+// New creates a new Server instance with the provided parameters.
+//
+// Parameters:
+// - logger: Logger interface used for recording operational events and errors
+// - tSettings: Configuration settings that control server behavior
+// - utxoStore: UTXO store holding the transactions
+// - txStore: Blob store holding the transactions
+// - subtreeStore: Blob store holding the subtrees of the blocks
+// - blockPersisterStore: Blob store holding the persisted blocks
+// - blockchainClient: Client interface for accessing blockchain data and subscribing to notifications
+// - validatorClient: Validator used for broadcast transactions, broadcasting is disabled when nil
+// - scriptHashStore: Script hash index, the script hash methods are disabled when nil
+//
+// Returns a configured Server instance ready for initialization via the Init method.
+func New(
+	logger ulogger.Logger,
+	tSettings *settings.Settings,
+	utxoStore utxo.Store,
+	txStore blob.Store,
+	subtreeStore blob.Store,
+	blockPersisterStore blob.Store,
+	blockchainClient blockchain.ClientI,
+	validatorClient validator.Interface,
+	scriptHashStore scripthash.Store,
+) *Server {
+	initPrometheusMetrics()
+
+	return &Server{
+		logger:              logger,
+		settings:            tSettings,
+		utxoStore:           utxoStore,
+		txStore:             txStore,
+		subtreeStore:        subtreeStore,
+		blockPersisterStore: blockPersisterStore,
+		blockchainClient:    blockchainClient,
+		validatorClient:     validatorClient,
+		scriptHashStore:     scriptHashStore,
+		stats:               gocore.NewStat("electrum"),
+		sessions:            make(map[*session]struct{}),
+	}
+}
+
+// Health checks the health status of the server and its dependencies.
+// Liveness checks only verify that the service is running, while readiness checks also verify that
+// the blockchain client, FSM, stores and validator are available.
+//
+// Parameters:
+// - ctx: Context for controlling the health check operation
+// - checkLiveness: Boolean flag that determines the check type (true for liveness, false for readiness)
+//
+// Returns:
+// - int: HTTP status code (200 for OK, 503 for Service Unavailable)
+// - string: Status message describing the health state
+// - error: Any error encountered during health checks
+func (s *Server) Health(ctx context.Context, checkLiveness bool) (int, string, error) {
+	if checkLiveness {
+		// Add liveness checks here. Don't include dependency checks.
+		// If the service is stuck return http.StatusServiceUnavailable
+		// to indicate a restart is needed
+		return http.StatusOK, "OK", nil
+	}
+
+	// Add readiness checks here. Include dependency checks.
+	// If any dependency is not ready, return http.StatusServiceUnavailable
+	// If all dependencies are ready, return http.StatusOK
+	// A failed dependency check does not imply the service needs restarting
+	checks := make([]health.Check, 0, 7)
+	if s.blockchainClient != nil {
+		checks = append(checks, health.Check{Name: "BlockchainClient", Check: s.blockchainClient.Health})
+		checks = append(checks, health.Check{Name: "FSM", Check: blockchain.CheckFSM(s.blockchainClient)})
+	}
+
+	if s.utxoStore != nil {
+		checks = append(checks, health.Check{Name: "UTXOStore", Check: s.utxoStore.Health})
+	}
+
+	if s.txStore != nil {
+		checks = append(checks, health.Check{Name: "TxStore", Check: s.txStore.Health})
+	}
+
+	if s.subtreeStore != nil {
+		checks = append(checks, health.Check{Name: "SubtreeStore", Check: s.subtreeStore.Health})
+	}
+
+	if s.validatorClient != nil {
+		checks = append(checks, health.Check{Name: "ValidatorClient", Check: s.validatorClient.Health})
+	}
+
+	if s.scriptHashStore != nil {
+		checks = append(checks, health.Check{Name: "ScriptHashStore", Check: s.scriptHashStore.Health})
+	}
+
+	return health.CheckAll(ctx, checkLiveness, checks)
+}
+
+// Init initializes the server by creating the asset repository the methods read from.
+//
+// Parameters:
+// - ctx: Context for the initialization
+//
+// Returns:
+// - error: Any error encountered while creating the repository
+func (s *Server) Init(_ context.Context) error {
+	repo, err := repository.NewRepository(s.logger, s.settings, s.utxoStore, s.txStore, s.blockchainClient, nil, s.subtreeStore, s.blockPersisterStore, nil)
+	if err != nil {
+		return errors.NewServiceError("[Electrum] error creating repository", err)
+	}
+
+	repo.ScriptHashStore = s.scriptHashStore
+
+	s.repository = repo
+
+	return nil
+}
+
+// Start begins accepting client connections.
+// It waits for the blockchain FSM to leave the IDLE state, subscribes to blockchain notifications,
+// starts the TCP and WebSocket listeners, and notifies the sessions of new blocks and script hash
+// status changes until the context is canceled.
+//
+// Parameters:
+// - ctx: Context for controlling the server's lifecycle
+// - readyCh: Channel closed when the listeners have been started to signal readiness
+//
+// Returns:
+// - error: Any error encountered during startup
+func (s *Server) Start(ctx context.Context, readyCh chan<- struct{}) error {
+	var closeOnce sync.Once
+	defer closeOnce.Do(func() { close(readyCh) })
+
+	// Blocks until the FSM transitions from the IDLE state
+	if err := s.blockchainClient.WaitUntilFSMTransitionFromIdleState(ctx); err != nil {
+		s.logger.Errorf("[Electrum] Failed to wait for FSM transition from IDLE state: %s", err)
+
+		return err
+	}
+
+	ch, err := s.blockchainClient.Subscribe(ctx, "electrum")
+	if err != nil {
+		return errors.NewServiceError("[Electrum] failed to subscribe to blockchain notifications", err)
+	}
+
+	// the sessions are only notified of blocks added after the server started
+	if header, _, err := s.repository.GetBestBlockHeader(ctx); err == nil {
+		s.mu.Lock()
+		s.bestHeader = header
+		s.mu.Unlock()
+	}
+
+	if err = s.startListeners(ctx); err != nil {
+		return err
+	}
+
+	closeOnce.Do(func() { close(readyCh) })
+
+	interval := s.settings.Electrum.StatusCheckInterval
+	if interval <= 0 {
+		interval = defaultStatusCheckInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.closeListeners()
+
+			return ctx.Err()
+
+		case notification := <-ch:
+			if notification != nil && notification.Type == model.NotificationType_Block {
+				s.notifySessions(ctx)
+			}
+
+		case <-ticker.C:
+			s.notifySessions(ctx)
+		}
+	}
+}
+
+// Stop stops accepting connections, closes all sessions and waits for them to finish.
+func (s *Server) Stop(_ context.Context) error {
+	s.closeListeners()
+
+	s.mu.Lock()
+	for sess := range s.sessions {
+		_ = sess.transport.close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+
+	return nil
+}
+
+// startListeners starts the TCP listener and, when configured, the WebSocket listener.
+func (s *Server) startListeners(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.settings.Electrum.ListenAddress)
+	if err != nil {
+		return errors.NewServiceError("[Electrum] failed to listen on %s", s.settings.Electrum.ListenAddress, err)
+	}
+
+	s.mu.Lock()
+	s.tcpListener = listener
+	s.mu.Unlock()
+
+	s.logger.Infof("[Electrum] listening for TCP connections on %s", listener.Addr().String())
+
+	s.wg.Add(1)
+
+	go func() {
+		defer s.wg.Done()
+
+		s.acceptTCP(ctx, listener)
+	}()
+
+	if s.settings.Electrum.WebSocketListenAddress == "" {
+		return nil
+	}
+
+	wsListener, err := net.Listen("tcp", s.settings.Electrum.WebSocketListenAddress)
+	if err != nil {
+		s.closeListeners()
+
+		return errors.NewServiceError("[Electrum] failed to listen on %s", s.settings.Electrum.WebSocketListenAddress, err)
+	}
+
+	wsServer := &http.Server{
+		Handler:           s.webSocketHandler(ctx),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	s.mu.Lock()
+	s.wsServer = wsServer
+	s.mu.Unlock()
+
+	s.logger.Infof("[Electrum] listening for WebSocket connections on %s", wsListener.Addr().String())
+
+	go func() {
+		if err := wsServer.Serve(wsListener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Errorf("[Electrum] WebSocket server error: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// closeListeners stops accepting new connections.
+func (s *Server) closeListeners() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.tcpListener != nil {
+		_ = s.tcpListener.Close()
+		s.tcpListener = nil
+	}
+
+	if s.wsServer != nil {
+		_ = s.wsServer.Close()
+		s.wsServer = nil
+	}
+}
+
+// acceptTCP accepts TCP connections until the listener is closed.
+func (s *Server) acceptTCP(ctx context.Context, listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+
+			s.logger.Warnf("[Electrum] error accepting connection: %v", err)
+
+			continue
+		}
+
+		s.wg.Add(1)
+
+		go func() {
+			defer s.wg.Done()
+
+			s.serveSession(ctx, newTCPTransport(conn))
+		}()
+	}
+}
+
+// webSocketHandler returns the HTTP handler upgrading requests to WebSocket sessions.
+func (s *Server) webSocketHandler(ctx context.Context) http.Handler {
+	upgrader := websocket.Upgrader{
+		// Electrum clients are not browsers, any origin is allowed
+		CheckOrigin: func(_ *http.Request) bool { return true },
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			s.logger.Debugf("[Electrum] WebSocket upgrade failed for %s: %v", r.RemoteAddr, err)
+			return
+		}
+
+		s.wg.Add(1)
+		defer s.wg.Done()
+
+		s.serveSession(ctx, newWebSocketTransport(conn))
+	})
+}
+
+// serveSession handles the requests of a client until the connection is closed.
+func (s *Server) serveSession(ctx context.Context, t transport) {
+	sess := newSession(s, t)
+
+	s.mu.Lock()
+	s.sessions[sess] = struct{}{}
+	s.mu.Unlock()
+
+	prometheusElectrumSessions.Inc()
+
+	s.logger.Debugf("[Electrum] session opened for %s", t.remoteAddr())
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.sessions, sess)
+		s.mu.Unlock()
+
+		prometheusElectrumSessions.Dec()
+
+		_ = t.close()
+
+		s.logger.Debugf("[Electrum] session closed for %s", t.remoteAddr())
+	}()
+
+	sess.run(ctx)
+}
+
+// notifySessions notifies the sessions of a new best block header, and of the script hashes of which
+// the status has changed.
+func (s *Server) notifySessions(ctx context.Context) {
+	header, meta, err := s.repository.GetBestBlockHeader(ctx)
+	if err != nil {
+		s.logger.Warnf("[Electrum] error getting best block header: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+
+	headerChanged := s.bestHeader == nil || !s.bestHeader.Hash().IsEqual(header.Hash())
+	s.bestHeader = header
+
+	sessions := make([]*session, 0, len(s.sessions))
+	for sess := range s.sessions {
+		sessions = append(sessions, sess)
+	}
+
+	s.mu.Unlock()
+
+	// the status of a script hash is only computed once, even when several sessions subscribed to it
+	statuses := make(map[chainhash.Hash]string)
+
+	for _, sess := range sessions {
+		if headerChanged {
+			sess.notifyHeader(header, meta.Height)
+		}
+
+		sess.notifyScriptHashes(ctx, statuses)
+	}
+}
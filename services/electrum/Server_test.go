@@ -0,0 +1,306 @@
+package electrum
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/bsv-blockchain/go-chaincfg"
+	"github.com/bsv-blockchain/teranode/model"
+	"github.com/bsv-blockchain/teranode/services/blockchain"
+	"github.com/bsv-blockchain/teranode/services/validator"
+	"github.com/bsv-blockchain/teranode/stores/blob"
+	"github.com/bsv-blockchain/teranode/stores/blob/memory"
+	"github.com/bsv-blockchain/teranode/stores/scripthash"
+	"github.com/bsv-blockchain/teranode/stores/utxo/sql"
+	"github.com/bsv-blockchain/teranode/ulogger"
+	"github.com/bsv-blockchain/teranode/util/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// testServer holds a server created with in-memory stores, and the stores for the tests to fill.
+type testServer struct {
+	*Server
+	txStore          blob.Store
+	subtreeStore     blob.Store
+	blockchainClient *blockchain.Mock
+	validatorClient  *validator.MockValidatorClient
+}
+
+func newTestServer(t *testing.T, scriptHashStore scripthash.Store) *testServer {
+	ctx := context.Background()
+	logger := ulogger.TestLogger{}
+	tSettings := test.CreateBaseTestSettings(t)
+
+	utxoStoreURL, err := url.Parse("sqlitememory:///test")
+	require.NoError(t, err)
+
+	utxoStore, err := sql.New(ctx, logger, tSettings, utxoStoreURL)
+	require.NoError(t, err)
+
+	txStore := memory.New()
+	subtreeStore := memory.New()
+	blockchainClient := &blockchain.Mock{}
+	validatorClient := &validator.MockValidatorClient{UtxoStore: utxoStore}
+
+	s := New(logger, tSettings, utxoStore, txStore, subtreeStore, memory.New(), blockchainClient, validatorClient, scriptHashStore)
+	require.NoError(t, s.Init(ctx))
+
+	return &testServer{
+		Server:           s,
+		txStore:          txStore,
+		subtreeStore:     subtreeStore,
+		blockchainClient: blockchainClient,
+		validatorClient:  validatorClient,
+	}
+}
+
+// recordingTransport records the messages written to a session.
+type recordingTransport struct {
+	mu       sync.Mutex
+	messages [][]byte
+}
+
+func (t *recordingTransport) readMessage() ([]byte, error) {
+	return nil, io.EOF
+}
+
+func (t *recordingTransport) writeMessage(msg []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.messages = append(t.messages, msg)
+
+	return nil
+}
+
+func (t *recordingTransport) close() error {
+	return nil
+}
+
+func (t *recordingTransport) remoteAddr() string {
+	return "test"
+}
+
+// notifications returns the notifications written to the session.
+func (t *recordingTransport) notifications(tb testing.TB) []*notification {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	notifications := make([]*notification, 0, len(t.messages))
+
+	for _, msg := range t.messages {
+		n := &notification{}
+		require.NoError(tb, json.Unmarshal(msg, n))
+
+		notifications = append(notifications, n)
+	}
+
+	return notifications
+}
+
+// newTestSession returns a session of the server with a recording transport.
+func newTestSession(s *Server) (*session, *recordingTransport) {
+	t := &recordingTransport{}
+
+	return newSession(s, t), t
+}
+
+// call sends a request to the session and returns the response.
+func call(t *testing.T, sess *session, method string, params ...interface{}) *response {
+	if params == nil {
+		params = []interface{}{}
+	}
+
+	msg, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  method,
+		"params":  params,
+	})
+	require.NoError(t, err)
+
+	reply := sess.handleMessage(context.Background(), msg)
+	require.NotNil(t, reply)
+
+	resp := &response{}
+	require.NoError(t, json.Unmarshal(reply, resp))
+
+	return resp
+}
+
+// callResult sends a request to the session, checks that it succeeded and unmarshals the result.
+func callResult(t *testing.T, sess *session, result interface{}, method string, params ...interface{}) {
+	resp := call(t, sess, method, params...)
+	require.Nil(t, resp.Error, "unexpected error: %v", resp.Error)
+	require.NoError(t, json.Unmarshal(resp.Result, result))
+}
+
+func TestHealth_LivenessCheck(t *testing.T) {
+	s := &Server{}
+
+	status, message, err := s.Health(context.Background(), true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "OK", message)
+}
+
+// TestSession_TCP exchanges newline delimited requests and responses over a connection.
+func TestSession_TCP(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	serverConn, clientConn := net.Pipe()
+
+	done := make(chan struct{})
+
+	go func() {
+		s.serveSession(context.Background(), newTCPTransport(serverConn))
+		close(done)
+	}()
+
+	reader := bufio.NewReader(clientConn)
+
+	readResponse := func() json.RawMessage {
+		line, err := reader.ReadBytes('\n')
+		require.NoError(t, err)
+
+		return line
+	}
+
+	t.Run("single request", func(t *testing.T) {
+		_, err := clientConn.Write([]byte(`{"jsonrpc":"2.0","id":1,"method":"server.version","params":["test","1.4"]}` + "\n"))
+		require.NoError(t, err)
+
+		var resp struct {
+			ID     int      `json:"id"`
+			Result []string `json:"result"`
+		}
+
+		require.NoError(t, json.Unmarshal(readResponse(), &resp))
+		assert.Equal(t, 1, resp.ID)
+		assert.Equal(t, []string{s.serverVersion(), protocolVersion}, resp.Result)
+	})
+
+	t.Run("notifications are not answered", func(t *testing.T) {
+		_, err := clientConn.Write([]byte(`{"jsonrpc":"2.0","method":"server.ping"}` + "\n" + `{"jsonrpc":"2.0","id":2,"method":"server.ping"}` + "\n"))
+		require.NoError(t, err)
+
+		var resp struct {
+			ID     int         `json:"id"`
+			Result interface{} `json:"result"`
+		}
+
+		require.NoError(t, json.Unmarshal(readResponse(), &resp))
+		assert.Equal(t, 2, resp.ID)
+		assert.Nil(t, resp.Result)
+	})
+
+	t.Run("batch request", func(t *testing.T) {
+		_, err := clientConn.Write([]byte(`[{"jsonrpc":"2.0","id":3,"method":"server.banner"},{"jsonrpc":"2.0","id":4,"method":"unknown.method"}]` + "\n"))
+		require.NoError(t, err)
+
+		var resps []response
+
+		require.NoError(t, json.Unmarshal(readResponse(), &resps))
+		require.Len(t, resps, 2)
+
+		assert.Equal(t, "3", string(resps[0].ID))
+		assert.Equal(t, `"`+s.settings.Electrum.Banner+`"`, string(resps[0].Result))
+
+		assert.Equal(t, "4", string(resps[1].ID))
+		require.NotNil(t, resps[1].Error)
+		assert.Equal(t, errCodeMethodNotFound, resps[1].Error.Code)
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		_, err := clientConn.Write([]byte(`{"id":5,` + "\n"))
+		require.NoError(t, err)
+
+		var resp response
+
+		require.NoError(t, json.Unmarshal(readResponse(), &resp))
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, errCodeParse, resp.Error.Code)
+	})
+
+	require.NoError(t, clientConn.Close())
+	<-done
+
+	s.mu.Lock()
+	assert.Empty(t, s.sessions)
+	s.mu.Unlock()
+}
+
+// TestNotifySessions notifies subscribed sessions of a new best block header and of a script hash of
+// which the history changed.
+func TestNotifySessions(t *testing.T) {
+	ctx := context.Background()
+
+	genesisBlock, err := model.NewBlockFromMsgBlock(chaincfg.RegressionNetParams.GenesisBlock, nil)
+	require.NoError(t, err)
+
+	nextHeader := &model.BlockHeader{
+		Version:        1,
+		HashPrevBlock:  genesisBlock.Hash(),
+		HashMerkleRoot: genesisBlock.Header.HashMerkleRoot,
+		Timestamp:      genesisBlock.Header.Timestamp + 600,
+		Bits:           genesisBlock.Header.Bits,
+	}
+
+	scriptHash := scripthash.NewScriptHash([]byte("script"))
+	history := []*scripthash.HistoryItem{{TxID: *genesisBlock.CoinbaseTx.TxIDChainHash(), Height: 1}}
+
+	mockStore := &scripthash.Mock{}
+	mockStore.On("GetHistory", mock.Anything, &scriptHash, 0, 10_001, false).Return([]*scripthash.HistoryItem{}, nil).Once()
+	mockStore.On("GetHistory", mock.Anything, &scriptHash, 0, 10_001, false).Return(history, nil)
+
+	s := newTestServer(t, mockStore)
+	s.blockchainClient.On("GetBestBlockHeader", mock.Anything).Return(genesisBlock.Header, &model.BlockHeaderMeta{Height: 0}, nil).Once()
+	s.blockchainClient.On("GetBestBlockHeader", mock.Anything).Return(nextHeader, &model.BlockHeaderMeta{Height: 1}, nil)
+
+	subscribed, subscribedTransport := newTestSession(s.Server)
+	unsubscribed, unsubscribedTransport := newTestSession(s.Server)
+
+	s.sessions[subscribed] = struct{}{}
+	s.sessions[unsubscribed] = struct{}{}
+	s.bestHeader = genesisBlock.Header
+
+	var header headerResult
+
+	callResult(t, subscribed, &header, "blockchain.headers.subscribe")
+	assert.Equal(t, uint32(0), header.Height)
+
+	var status *string
+
+	callResult(t, subscribed, &status, "blockchain.scripthash.subscribe", scriptHash.String())
+	assert.Nil(t, status)
+
+	s.notifySessions(ctx)
+
+	notifications := subscribedTransport.notifications(t)
+	require.Len(t, notifications, 2)
+
+	assert.Equal(t, "blockchain.headers.subscribe", notifications[0].Method)
+	assert.Equal(t, float64(1), notifications[0].Params[0].(map[string]interface{})["height"])
+
+	expectedStatus, err := s.scriptHashStatus(ctx, &scriptHash)
+	require.NoError(t, err)
+
+	assert.Equal(t, "blockchain.scripthash.subscribe", notifications[1].Method)
+	assert.Equal(t, []interface{}{scriptHash.String(), expectedStatus}, notifications[1].Params)
+
+	assert.Empty(t, unsubscribedTransport.notifications(t))
+
+	// nothing changed, nothing is notified
+	s.notifySessions(ctx)
+	assert.Len(t, subscribedTransport.notifications(t), 2)
+}
@@ -0,0 +1,643 @@
+package electrum
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bsv-blockchain/go-bt/v2"
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/model"
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+	"github.com/bsv-blockchain/teranode/stores/scripthash"
+)
+
+// maxHeadersPerRequest is the maximum number of headers returned by blockchain.block.headers
+const maxHeadersPerRequest = 2016
+
+// handlerFunc handles a method called by a client, with the positional parameters of the request.
+type handlerFunc func(ctx context.Context, sess *session, params []json.RawMessage) (interface{}, error)
+
+// handlers maps the methods of the Electrum protocol to their handlers.
+var handlers = map[string]handlerFunc{
+	"server.version":          handleServerVersion,
+	"server.banner":           handleServerBanner,
+	"server.donation_address": handleServerDonationAddress,
+	"server.features":         handleServerFeatures,
+	"server.peers.subscribe":  handleServerPeersSubscribe,
+	"server.ping":             handleServerPing,
+
+	"blockchain.headers.subscribe": handleHeadersSubscribe,
+	"blockchain.block.header":      handleBlockHeader,
+	"blockchain.block.headers":     handleBlockHeaders,
+	"blockchain.estimatefee":       handleEstimateFee,
+	"blockchain.relayfee":          handleRelayFee,
+
+	"blockchain.transaction.get":         handleTransactionGet,
+	"blockchain.transaction.get_merkle":  handleTransactionGetMerkle,
+	"blockchain.transaction.id_from_pos": handleTransactionIDFromPos,
+	"blockchain.transaction.broadcast":   handleTransactionBroadcast,
+
+	"blockchain.scripthash.get_balance": handleScriptHashGetBalance,
+	"blockchain.scripthash.get_history": handleScriptHashGetHistory,
+	"blockchain.scripthash.get_mempool": handleScriptHashGetMempool,
+	"blockchain.scripthash.listunspent": handleScriptHashListUnspent,
+	"blockchain.scripthash.subscribe":   handleScriptHashSubscribe,
+	"blockchain.scripthash.unsubscribe": handleScriptHashUnsubscribe,
+
+	"mempool.get_fee_histogram": handleMempoolGetFeeHistogram,
+}
+
+// headerResult is a block header and its height.
+type headerResult struct {
+	Hex    string `json:"hex"`
+	Height uint32 `json:"height"`
+}
+
+// headersResult is a sequence of concatenated block headers.
+type headersResult struct {
+	Count int    `json:"count"`
+	Hex   string `json:"hex"`
+	Max   int    `json:"max"`
+}
+
+// featuresResult describes the server.
+type featuresResult struct {
+	GenesisHash   string                 `json:"genesis_hash"`
+	Hosts         map[string]interface{} `json:"hosts"`
+	ProtocolMax   string                 `json:"protocol_max"`
+	ProtocolMin   string                 `json:"protocol_min"`
+	Pruning       *int                   `json:"pruning"`
+	ServerVersion string                 `json:"server_version"`
+	HashFunction  string                 `json:"hash_function"`
+}
+
+// merkleResult is the merkle branch of a transaction in a block.
+type merkleResult struct {
+	BlockHeight uint32   `json:"block_height"`
+	Merkle      []string `json:"merkle"`
+	Pos         int      `json:"pos"`
+}
+
+// idFromPosResult is the hash of the transaction at a position in a block, with its merkle branch.
+type idFromPosResult struct {
+	TxHash string   `json:"tx_hash"`
+	Merkle []string `json:"merkle"`
+}
+
+// balanceResult is the balance of a script hash.
+type balanceResult struct {
+	Confirmed   uint64 `json:"confirmed"`
+	Unconfirmed int64  `json:"unconfirmed"`
+}
+
+// historyItem is a transaction in the history of a script hash.
+type historyItem struct {
+	TxHash string `json:"tx_hash"`
+	Height uint32 `json:"height"`
+}
+
+// unspentItem is an unspent output of a script hash.
+type unspentItem struct {
+	TxHash string `json:"tx_hash"`
+	TxPos  uint32 `json:"tx_pos"`
+	Height uint32 `json:"height"`
+	Value  uint64 `json:"value"`
+}
+
+func newHeaderResult(header *model.BlockHeader, height uint32) *headerResult {
+	return &headerResult{
+		Hex:    hex.EncodeToString(header.Bytes()),
+		Height: height,
+	}
+}
+
+// statusResult returns the status of a script hash as sent to the client, null when the script hash
+// has no history.
+func statusResult(status string) interface{} {
+	if status == "" {
+		return nil
+	}
+
+	return status
+}
+
+// serverVersion returns the name and version of the server software.
+func (s *Server) serverVersion() string {
+	return "Teranode " + s.settings.Version
+}
+
+// handleServerVersion negotiates the protocol version. The protocol version is either a single version
+// or an array with the minimum and maximum version supported by the client.
+func handleServerVersion(_ context.Context, sess *session, params []json.RawMessage) (interface{}, error) {
+	minVersion, maxVersion := protocolVersion, protocolVersion
+
+	if len(params) > 1 {
+		var (
+			version  string
+			versions []string
+		)
+
+		switch {
+		case json.Unmarshal(params[1], &version) == nil:
+			minVersion, maxVersion = version, version
+		case json.Unmarshal(params[1], &versions) == nil && len(versions) == 2:
+			minVersion, maxVersion = versions[0], versions[1]
+		default:
+			return nil, newInvalidParamsError("invalid protocol version")
+		}
+	}
+
+	if compareVersions(minVersion, protocolVersion) > 0 || compareVersions(maxVersion, protocolVersion) < 0 {
+		return nil, newBadRequestError("unsupported protocol version: " + maxVersion)
+	}
+
+	return []string{sess.server.serverVersion(), protocolVersion}, nil
+}
+
+// compareVersions compares two dotted version strings, returning -1, 0 or 1.
+func compareVersions(a, b string) int {
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var aNum, bNum int
+
+		if i < len(aParts) {
+			aNum, _ = strconv.Atoi(aParts[i])
+		}
+
+		if i < len(bParts) {
+			bNum, _ = strconv.Atoi(bParts[i])
+		}
+
+		if aNum < bNum {
+			return -1
+		}
+
+		if aNum > bNum {
+			return 1
+		}
+	}
+
+	return 0
+}
+
+func handleServerBanner(_ context.Context, sess *session, _ []json.RawMessage) (interface{}, error) {
+	return sess.server.settings.Electrum.Banner, nil
+}
+
+func handleServerDonationAddress(_ context.Context, _ *session, _ []json.RawMessage) (interface{}, error) {
+	return "", nil
+}
+
+func handleServerFeatures(_ context.Context, sess *session, _ []json.RawMessage) (interface{}, error) {
+	return &featuresResult{
+		GenesisHash:   sess.server.settings.ChainCfgParams.GenesisHash.String(),
+		Hosts:         map[string]interface{}{},
+		ProtocolMax:   protocolVersion,
+		ProtocolMin:   protocolVersion,
+		ServerVersion: sess.server.serverVersion(),
+		HashFunction:  "sha256",
+	}, nil
+}
+
+// handleServerPeersSubscribe returns no peers, peer discovery is not supported.
+func handleServerPeersSubscribe(_ context.Context, _ *session, _ []json.RawMessage) (interface{}, error) {
+	return []interface{}{}, nil
+}
+
+func handleServerPing(_ context.Context, _ *session, _ []json.RawMessage) (interface{}, error) {
+	return nil, nil
+}
+
+// handleHeadersSubscribe subscribes the session to new block headers and returns the best block header.
+func handleHeadersSubscribe(ctx context.Context, sess *session, _ []json.RawMessage) (interface{}, error) {
+	header, meta, err := sess.server.repository.GetBestBlockHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sess.mu.Lock()
+	sess.headersSubscribed = true
+	sess.mu.Unlock()
+
+	return newHeaderResult(header, meta.Height), nil
+}
+
+// handleBlockHeader returns the header of the block at a height of the best chain.
+func handleBlockHeader(ctx context.Context, sess *session, params []json.RawMessage) (interface{}, error) {
+	height, err := paramUint32(params, 0, "height")
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkNoCheckpoint(params, 1); err != nil {
+		return nil, err
+	}
+
+	headers, _, err := sess.server.blockchainClient.GetBlockHeadersByHeight(ctx, height, height)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(headers) == 0 {
+		return nil, newBadRequestError(fmt.Sprintf("height %d out of range", height))
+	}
+
+	return hex.EncodeToString(headers[0].Bytes()), nil
+}
+
+// handleBlockHeaders returns a sequence of block headers of the best chain, starting at a height.
+func handleBlockHeaders(ctx context.Context, sess *session, params []json.RawMessage) (interface{}, error) {
+	startHeight, err := paramUint32(params, 0, "start_height")
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := paramUint32(params, 1, "count")
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkNoCheckpoint(params, 2); err != nil {
+		return nil, err
+	}
+
+	count = min(count, maxHeadersPerRequest)
+
+	result := &headersResult{
+		Max: maxHeadersPerRequest,
+	}
+
+	if count == 0 || startHeight > ^uint32(0)-count {
+		return result, nil
+	}
+
+	headers, _, err := sess.server.blockchainClient.GetBlockHeadersByHeight(ctx, startHeight, startHeight+count-1)
+	if err != nil {
+		return nil, err
+	}
+
+	var sb strings.Builder
+
+	for _, header := range headers {
+		sb.WriteString(hex.EncodeToString(header.Bytes()))
+	}
+
+	result.Count = len(headers)
+	result.Hex = sb.String()
+
+	return result, nil
+}
+
+// checkNoCheckpoint returns an error when a checkpoint height is requested, checkpoints are not supported.
+func checkNoCheckpoint(params []json.RawMessage, idx int) error {
+	cpHeight, err := optionalParamUint32(params, idx, "cp_height", 0)
+	if err != nil {
+		return err
+	}
+
+	if cpHeight != 0 {
+		return newBadRequestError("checkpoint heights are not supported")
+	}
+
+	return nil
+}
+
+// handleEstimateFee returns -1, as the server has no fee estimate.
+func handleEstimateFee(_ context.Context, _ *session, params []json.RawMessage) (interface{}, error) {
+	if _, err := paramUint32(params, 0, "number"); err != nil {
+		return nil, err
+	}
+
+	return -1, nil
+}
+
+// handleRelayFee returns the minimum fee in BSV/kB for a transaction to be mined.
+func handleRelayFee(_ context.Context, sess *session, _ []json.RawMessage) (interface{}, error) {
+	return sess.server.settings.Policy.GetMinMiningTxFee(), nil
+}
+
+// handleTransactionGet returns a transaction in raw hex, verbose transactions are not supported.
+func handleTransactionGet(ctx context.Context, sess *session, params []json.RawMessage) (interface{}, error) {
+	txHash, err := paramHash(params, 0, "tx_hash")
+	if err != nil {
+		return nil, err
+	}
+
+	verbose, err := optionalParamBool(params, 1, "verbose")
+	if err != nil {
+		return nil, err
+	}
+
+	if verbose {
+		return nil, newBadRequestError("verbose transactions are not supported")
+	}
+
+	txBytes, err := sess.server.repository.GetTransaction(ctx, txHash)
+	if err != nil {
+		if errors.Is(err, errors.ErrNotFound) || errors.Is(err, errors.ErrTxNotFound) {
+			return nil, newBadRequestError("transaction " + txHash.String() + " not found")
+		}
+
+		return nil, err
+	}
+
+	// the UTXO store returns transactions in extended format, clients expect the standard format
+	tx, err := bt.NewTxFromBytes(txBytes)
+	if err != nil {
+		return nil, errors.NewProcessingError("error parsing transaction %s", txHash.String(), err)
+	}
+
+	return hex.EncodeToString(tx.Bytes()), nil
+}
+
+// handleTransactionGetMerkle returns the merkle branch of a transaction in the block at a height.
+func handleTransactionGetMerkle(ctx context.Context, sess *session, params []json.RawMessage) (interface{}, error) {
+	txHash, err := paramHash(params, 0, "tx_hash")
+	if err != nil {
+		return nil, err
+	}
+
+	height, err := paramUint32(params, 1, "height")
+	if err != nil {
+		return nil, err
+	}
+
+	proof, err := sess.server.newBlockMerkleProof(ctx, height)
+	if err != nil {
+		return nil, err
+	}
+
+	branch, pos, err := proof.merkleBranch(txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &merkleResult{
+		BlockHeight: height,
+		Merkle:      hashStrings(branch),
+		Pos:         pos,
+	}, nil
+}
+
+// handleTransactionIDFromPos returns the hash of the transaction at a position in the block at a height,
+// and its merkle branch when requested.
+func handleTransactionIDFromPos(ctx context.Context, sess *session, params []json.RawMessage) (interface{}, error) {
+	height, err := paramUint32(params, 0, "height")
+	if err != nil {
+		return nil, err
+	}
+
+	txPos, err := paramUint32(params, 1, "tx_pos")
+	if err != nil {
+		return nil, err
+	}
+
+	withMerkle, err := optionalParamBool(params, 2, "merkle")
+	if err != nil {
+		return nil, err
+	}
+
+	proof, err := sess.server.newBlockMerkleProof(ctx, height)
+	if err != nil {
+		return nil, err
+	}
+
+	txHash, branch, err := proof.txAtPosition(txPos, withMerkle)
+	if err != nil {
+		return nil, err
+	}
+
+	if !withMerkle {
+		return txHash.String(), nil
+	}
+
+	return &idFromPosResult{
+		TxHash: txHash.String(),
+		Merkle: hashStrings(branch),
+	}, nil
+}
+
+// handleTransactionBroadcast validates a raw transaction and returns its hash.
+func handleTransactionBroadcast(ctx context.Context, sess *session, params []json.RawMessage) (interface{}, error) {
+	rawTx, err := paramString(params, 0, "raw_tx")
+	if err != nil {
+		return nil, err
+	}
+
+	if sess.server.validatorClient == nil {
+		return nil, newBadRequestError("broadcasting transactions is not supported")
+	}
+
+	txBytes, err := hex.DecodeString(rawTx)
+	if err != nil {
+		return nil, newBadRequestError("raw_tx is not a valid hex string")
+	}
+
+	tx, err := bt.NewTxFromBytes(txBytes)
+	if err != nil {
+		return nil, newBadRequestError("the transaction could not be decoded: " + err.Error())
+	}
+
+	// Store the transaction in the blob store first, like the propagation service
+	if sess.server.txStore != nil {
+		if err = sess.server.txStore.Set(ctx, tx.TxIDChainHash().CloneBytes(), fileformat.FileTypeTx, tx.SerializeBytes()); err != nil {
+			return nil, errors.NewStorageError("error storing transaction %s", tx.TxID(), err)
+		}
+	}
+
+	if _, err = sess.server.validatorClient.Validate(ctx, tx, 0); err != nil {
+		return nil, newBadRequestError("the transaction was rejected by network rules: " + err.Error())
+	}
+
+	return tx.TxID(), nil
+}
+
+// handleScriptHashGetBalance returns the confirmed balance of a script hash. Unconfirmed balances are
+// not indexed, and are always 0.
+func handleScriptHashGetBalance(ctx context.Context, sess *session, params []json.RawMessage) (interface{}, error) {
+	scriptHash, err := paramHash(params, 0, "scripthash")
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := sess.server.repository.GetScriptHashBalance(ctx, scriptHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &balanceResult{
+		Confirmed: balance.Confirmed,
+	}, nil
+}
+
+// handleScriptHashGetHistory returns the confirmed history of a script hash.
+func handleScriptHashGetHistory(ctx context.Context, sess *session, params []json.RawMessage) (interface{}, error) {
+	scriptHash, err := paramHash(params, 0, "scripthash")
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := sess.server.scriptHashHistory(ctx, scriptHash)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*historyItem, 0, len(history))
+
+	for _, item := range history {
+		items = append(items, &historyItem{
+			TxHash: item.TxID.String(),
+			Height: item.Height,
+		})
+	}
+
+	return items, nil
+}
+
+// handleScriptHashGetMempool returns no transactions, mempool transactions are not indexed.
+func handleScriptHashGetMempool(_ context.Context, _ *session, params []json.RawMessage) (interface{}, error) {
+	if _, err := paramHash(params, 0, "scripthash"); err != nil {
+		return nil, err
+	}
+
+	return []interface{}{}, nil
+}
+
+// handleScriptHashListUnspent returns the confirmed unspent outputs of a script hash.
+func handleScriptHashListUnspent(ctx context.Context, sess *session, params []json.RawMessage) (interface{}, error) {
+	scriptHash, err := paramHash(params, 0, "scripthash")
+	if err != nil {
+		return nil, err
+	}
+
+	outputs, err := sess.server.repository.GetScriptHashUnspent(ctx, scriptHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(outputs) > sess.server.settings.Electrum.MaxHistory {
+		return nil, newBadRequestError("too many unspent outputs")
+	}
+
+	items := make([]*unspentItem, 0, len(outputs))
+
+	for _, output := range outputs {
+		items = append(items, &unspentItem{
+			TxHash: output.TxID.String(),
+			TxPos:  output.Vout,
+			Height: output.Height,
+			Value:  output.Satoshis,
+		})
+	}
+
+	return items, nil
+}
+
+// handleScriptHashSubscribe subscribes the session to status changes of a script hash, and returns its
+// current status.
+func handleScriptHashSubscribe(ctx context.Context, sess *session, params []json.RawMessage) (interface{}, error) {
+	scriptHash, err := paramHash(params, 0, "scripthash")
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := sess.server.scriptHashStatus(ctx, scriptHash)
+	if err != nil {
+		return nil, err
+	}
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	if _, ok := sess.scriptHashes[*scriptHash]; !ok && len(sess.scriptHashes) >= maxScriptHashSubscriptions {
+		return nil, newBadRequestError("too many script hash subscriptions")
+	}
+
+	sess.scriptHashes[*scriptHash] = status
+
+	return statusResult(status), nil
+}
+
+// handleScriptHashUnsubscribe unsubscribes the session from a script hash, and returns whether it was
+// subscribed.
+func handleScriptHashUnsubscribe(_ context.Context, sess *session, params []json.RawMessage) (interface{}, error) {
+	scriptHash, err := paramHash(params, 0, "scripthash")
+	if err != nil {
+		return nil, err
+	}
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	_, ok := sess.scriptHashes[*scriptHash]
+	delete(sess.scriptHashes, *scriptHash)
+
+	return ok, nil
+}
+
+// handleMempoolGetFeeHistogram returns an empty histogram, the fees of mempool transactions are not tracked.
+func handleMempoolGetFeeHistogram(_ context.Context, _ *session, _ []json.RawMessage) (interface{}, error) {
+	return [][2]float64{}, nil
+}
+
+// scriptHashHistory returns the confirmed history of a script hash, or an error when the history is
+// longer than the configured maximum.
+func (s *Server) scriptHashHistory(ctx context.Context, scriptHash *chainhash.Hash) ([]*scripthash.HistoryItem, error) {
+	maxHistory := s.settings.Electrum.MaxHistory
+
+	history, err := s.repository.GetScriptHashHistory(ctx, scriptHash, 0, maxHistory+1, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(history) > maxHistory {
+		return nil, newBadRequestError("history too large")
+	}
+
+	return history, nil
+}
+
+// scriptHashStatus returns the status of a script hash: the hex encoded SHA256 of the concatenated
+// "tx_hash:height:" strings of its history, or an empty string when the script hash has no history.
+func (s *Server) scriptHashStatus(ctx context.Context, scriptHash *chainhash.Hash) (string, error) {
+	history, err := s.scriptHashHistory(ctx, scriptHash)
+	if err != nil {
+		return "", err
+	}
+
+	if len(history) == 0 {
+		return "", nil
+	}
+
+	var sb strings.Builder
+
+	for _, item := range history {
+		sb.WriteString(item.TxID.String())
+		sb.WriteByte(':')
+		sb.WriteString(strconv.FormatUint(uint64(item.Height), 10))
+		sb.WriteByte(':')
+	}
+
+	status := sha256.Sum256([]byte(sb.String()))
+
+	return hex.EncodeToString(status[:]), nil
+}
+
+// hashStrings returns the hashes as hex strings, in reverse byte order.
+func hashStrings(hashes []chainhash.Hash) []string {
+	strs := make([]string, 0, len(hashes))
+
+	for i := range hashes {
+		strs = append(strs, hashes[i].String())
+	}
+
+	return strs
+}
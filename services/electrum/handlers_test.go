@@ -0,0 +1,431 @@
+package electrum
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/bsv-blockchain/go-chaincfg"
+	bec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	subtreepkg "github.com/bsv-blockchain/go-subtree"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/model"
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+	"github.com/bsv-blockchain/teranode/stores/scripthash"
+	"github.com/bsv-blockchain/teranode/stores/utxo"
+	"github.com/bsv-blockchain/teranode/test/utils/transactions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func getGenesisBlock(t *testing.T) *model.Block {
+	genesisBlock, err := model.NewBlockFromMsgBlock(chaincfg.RegressionNetParams.GenesisBlock, nil)
+	require.NoError(t, err)
+
+	return genesisBlock
+}
+
+func TestServerVersion(t *testing.T) {
+	s := newTestServer(t, nil)
+	sess, _ := newTestSession(s.Server)
+
+	t.Run("Version range", func(t *testing.T) {
+		var result []string
+
+		callResult(t, sess, &result, "server.version", "test", []string{"1.2", "1.4.2"})
+		assert.Equal(t, []string{s.serverVersion(), protocolVersion}, result)
+	})
+
+	t.Run("Unsupported version", func(t *testing.T) {
+		resp := call(t, sess, "server.version", "test", "1.5")
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, errCodeBadRequest, resp.Error.Code)
+	})
+
+	t.Run("Named parameters", func(t *testing.T) {
+		reply := sess.handleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"server.version","params":{"client_name":"test"}}`))
+		assert.Contains(t, string(reply), `"code":-32602`)
+	})
+}
+
+func TestBlockHeaders(t *testing.T) {
+	genesisBlock := getGenesisBlock(t)
+	genesisHex := hex.EncodeToString(genesisBlock.Header.Bytes())
+
+	s := newTestServer(t, nil)
+	sess, _ := newTestSession(s.Server)
+
+	s.blockchainClient.On("GetBlockHeadersByHeight", mock.Anything, uint32(0), uint32(0)).Return([]*model.BlockHeader{genesisBlock.Header}, []*model.BlockHeaderMeta{{Height: 0}}, nil)
+	s.blockchainClient.On("GetBlockHeadersByHeight", mock.Anything, uint32(0), uint32(maxHeadersPerRequest-1)).Return([]*model.BlockHeader{genesisBlock.Header}, []*model.BlockHeaderMeta{{Height: 0}}, nil)
+	s.blockchainClient.On("GetBlockHeadersByHeight", mock.Anything, uint32(7), uint32(7)).Return([]*model.BlockHeader{}, []*model.BlockHeaderMeta{}, nil)
+
+	t.Run("Header", func(t *testing.T) {
+		var result string
+
+		callResult(t, sess, &result, "blockchain.block.header", 0)
+		assert.Equal(t, genesisHex, result)
+	})
+
+	t.Run("Height out of range", func(t *testing.T) {
+		resp := call(t, sess, "blockchain.block.header", 7)
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, errCodeBadRequest, resp.Error.Code)
+	})
+
+	t.Run("Checkpoint height", func(t *testing.T) {
+		resp := call(t, sess, "blockchain.block.header", 0, 10)
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, "checkpoint heights are not supported", resp.Error.Message)
+	})
+
+	t.Run("Headers capped at max", func(t *testing.T) {
+		var result headersResult
+
+		callResult(t, sess, &result, "blockchain.block.headers", 0, 5000)
+		assert.Equal(t, headersResult{Count: 1, Hex: genesisHex, Max: maxHeadersPerRequest}, result)
+	})
+}
+
+func TestTransactionGet(t *testing.T) {
+	ctx := context.Background()
+	coinbaseTx := getGenesisBlock(t).CoinbaseTx
+
+	s := newTestServer(t, nil)
+	sess, _ := newTestSession(s.Server)
+
+	require.NoError(t, s.txStore.Set(ctx, coinbaseTx.TxIDChainHash().CloneBytes(), fileformat.FileTypeTx, coinbaseTx.Bytes()))
+
+	t.Run("Transaction", func(t *testing.T) {
+		var result string
+
+		callResult(t, sess, &result, "blockchain.transaction.get", coinbaseTx.TxID())
+		assert.Equal(t, hex.EncodeToString(coinbaseTx.Bytes()), result)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		resp := call(t, sess, "blockchain.transaction.get", chainhash.HashH([]byte("missing")).String())
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, errCodeBadRequest, resp.Error.Code)
+	})
+
+	t.Run("Invalid hash", func(t *testing.T) {
+		resp := call(t, sess, "blockchain.transaction.get", "1234")
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, errCodeInvalidParams, resp.Error.Code)
+	})
+
+	t.Run("Verbose", func(t *testing.T) {
+		resp := call(t, sess, "blockchain.transaction.get", coinbaseTx.TxID(), true)
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, errCodeBadRequest, resp.Error.Code)
+	})
+}
+
+// TestTransactionGetMerkle builds the merkle branch of the transactions of a block with one subtree,
+// of which the first node is the coinbase placeholder.
+func TestTransactionGetMerkle(t *testing.T) {
+	block := getGenesisBlock(t)
+	block.ID = 5
+	coinbaseHash := *block.CoinbaseTx.TxIDChainHash()
+
+	s := newTestServer(t, nil)
+	sess, _ := newTestSession(s.Server)
+
+	tx1 := createMinedTx(t, s, 1, utxo.MinedBlockInfo{BlockID: 5, BlockHeight: 5, SubtreeIdx: 0})
+	tx2 := createMinedTx(t, s, 2, utxo.MinedBlockInfo{BlockID: 5, BlockHeight: 5, SubtreeIdx: 0})
+	otherBlockTx := createMinedTx(t, s, 3, utxo.MinedBlockInfo{BlockID: 6, BlockHeight: 6, SubtreeIdx: 0})
+
+	subtree := storeSubtree(t, s, true, tx1, tx2)
+
+	block.Subtrees = []*chainhash.Hash{subtree.RootHash()}
+	block.TransactionCount = 3
+
+	s.blockchainClient.On("GetBlockByHeight", mock.Anything, uint32(5)).Return(block, nil)
+	s.blockchainClient.On("GetBlockByHeight", mock.Anything, uint32(6)).Return(nil, errors.NewBlockNotFoundError("block not found"))
+
+	t.Run("Merkle branch", func(t *testing.T) {
+		var result merkleResult
+
+		callResult(t, sess, &result, "blockchain.transaction.get_merkle", tx2.String(), 5)
+
+		// the last transaction is paired with itself
+		assert.Equal(t, merkleResult{
+			BlockHeight: 5,
+			Merkle:      []string{tx2.String(), hashPair(&coinbaseHash, tx1).String()},
+			Pos:         2,
+		}, result)
+	})
+
+	t.Run("Coinbase", func(t *testing.T) {
+		var result merkleResult
+
+		callResult(t, sess, &result, "blockchain.transaction.get_merkle", coinbaseHash.String(), 5)
+		assert.Equal(t, 0, result.Pos)
+		assert.Equal(t, tx1.String(), result.Merkle[0])
+	})
+
+	t.Run("Not in block", func(t *testing.T) {
+		resp := call(t, sess, "blockchain.transaction.get_merkle", chainhash.HashH([]byte("missing")).String(), 5)
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, errCodeBadRequest, resp.Error.Code)
+
+		resp = call(t, sess, "blockchain.transaction.get_merkle", otherBlockTx.String(), 5)
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, errCodeBadRequest, resp.Error.Code)
+	})
+
+	t.Run("Height out of range", func(t *testing.T) {
+		resp := call(t, sess, "blockchain.transaction.get_merkle", tx1.String(), 6)
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, errCodeBadRequest, resp.Error.Code)
+	})
+
+	t.Run("ID from position", func(t *testing.T) {
+		var txID string
+
+		callResult(t, sess, &txID, "blockchain.transaction.id_from_pos", 5, 1)
+		assert.Equal(t, tx1.String(), txID)
+
+		var result idFromPosResult
+
+		callResult(t, sess, &result, "blockchain.transaction.id_from_pos", 5, 1, true)
+		assert.Equal(t, tx1.String(), result.TxHash)
+		assert.Equal(t, []string{coinbaseHash.String(), hashPair(tx2, tx2).String()}, result.Merkle)
+
+		resp := call(t, sess, "blockchain.transaction.id_from_pos", 5, 3)
+		require.NotNil(t, resp.Error)
+	})
+}
+
+// TestTransactionGetMerkle_MultipleSubtrees builds the merkle branch of a transaction in the second subtree of
+// a block, which only reads the first subtree and the subtree of the transaction.
+func TestTransactionGetMerkle_MultipleSubtrees(t *testing.T) {
+	block := getGenesisBlock(t)
+	block.ID = 7
+	coinbaseHash := *block.CoinbaseTx.TxIDChainHash()
+
+	s := newTestServer(t, nil)
+	sess, _ := newTestSession(s.Server)
+
+	tx1 := createMinedTx(t, s, 1, utxo.MinedBlockInfo{BlockID: 7, BlockHeight: 7, SubtreeIdx: 0})
+	tx2 := createMinedTx(t, s, 2, utxo.MinedBlockInfo{BlockID: 7, BlockHeight: 7, SubtreeIdx: 1})
+	tx3 := createMinedTx(t, s, 3, utxo.MinedBlockInfo{BlockID: 7, BlockHeight: 7, SubtreeIdx: 1})
+	tx4 := createMinedTx(t, s, 4, utxo.MinedBlockInfo{BlockID: 7, BlockHeight: 7, SubtreeIdx: 2})
+
+	firstSubtree := storeSubtree(t, s, true, tx1)
+	secondSubtree := storeSubtree(t, s, false, tx2, tx3)
+	thirdSubtree := storeSubtree(t, s, false, tx4)
+
+	block.Subtrees = []*chainhash.Hash{firstSubtree.RootHash(), secondSubtree.RootHash(), thirdSubtree.RootHash()}
+	block.TransactionCount = 5
+
+	s.blockchainClient.On("GetBlockByHeight", mock.Anything, uint32(7)).Return(block, nil)
+
+	firstRoot := hashPair(&coinbaseHash, tx1)
+	secondRoot := hashPair(tx2, tx3)
+	thirdRoot := *tx4
+
+	t.Run("Merkle branch", func(t *testing.T) {
+		var result merkleResult
+
+		callResult(t, sess, &result, "blockchain.transaction.get_merkle", tx3.String(), 7)
+
+		thirdPair := hashPair(&thirdRoot, &thirdRoot)
+
+		assert.Equal(t, merkleResult{
+			BlockHeight: 7,
+			Merkle:      []string{tx2.String(), firstRoot.String(), thirdPair.String()},
+			Pos:         3,
+		}, result)
+	})
+
+	t.Run("Last subtree", func(t *testing.T) {
+		var result merkleResult
+
+		callResult(t, sess, &result, "blockchain.transaction.get_merkle", tx4.String(), 7)
+
+		firstPair := hashPair(&firstRoot, &secondRoot)
+
+		assert.Equal(t, merkleResult{
+			BlockHeight: 7,
+			Merkle:      []string{tx4.String(), firstPair.String()},
+			Pos:         4,
+		}, result)
+	})
+
+	t.Run("ID from position", func(t *testing.T) {
+		var result idFromPosResult
+
+		callResult(t, sess, &result, "blockchain.transaction.id_from_pos", 7, 2, true)
+		assert.Equal(t, tx2.String(), result.TxHash)
+		assert.Equal(t, []string{tx3.String(), firstRoot.String(), hashPair(&thirdRoot, &thirdRoot).String()}, result.Merkle)
+
+		resp := call(t, sess, "blockchain.transaction.id_from_pos", 7, 5)
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, errCodeBadRequest, resp.Error.Code)
+	})
+}
+
+// createMinedTx creates a transaction mined in a block in the UTXO store of the server, and returns its hash.
+func createMinedTx(t *testing.T, s *testServer, amount uint64, minedBlockInfo utxo.MinedBlockInfo) *chainhash.Hash {
+	_, publicKey := bec.PrivateKeyFromBytes([]byte("THIS_IS_A_DETERMINISTIC_PRIVATE_KEY"))
+
+	tx := transactions.Create(t,
+		transactions.WithCoinbaseData(minedBlockInfo.BlockHeight, "/Test miner/"),
+		transactions.WithP2PKHOutputs(1, amount, publicKey),
+	)
+
+	_, err := s.utxoStore.Create(context.Background(), tx, minedBlockInfo.BlockHeight, utxo.WithMinedBlockInfo(minedBlockInfo))
+	require.NoError(t, err)
+
+	return tx.TxIDChainHash()
+}
+
+// storeSubtree stores a subtree of the transactions in the subtree store of the server, starting with the
+// coinbase placeholder when requested.
+func storeSubtree(t *testing.T, s *testServer, coinbase bool, txHashes ...*chainhash.Hash) *subtreepkg.Subtree {
+	leaves := len(txHashes)
+	if coinbase {
+		leaves++
+	}
+
+	subtree, err := subtreepkg.NewIncompleteTreeByLeafCount(leaves)
+	require.NoError(t, err)
+
+	if coinbase {
+		require.NoError(t, subtree.AddCoinbaseNode())
+	}
+
+	for _, txHash := range txHashes {
+		require.NoError(t, subtree.AddNode(*txHash, 1, 100))
+	}
+
+	subtreeBytes, err := subtree.Serialize()
+	require.NoError(t, err)
+
+	require.NoError(t, s.subtreeStore.Set(context.Background(), subtree.RootHash().CloneBytes(), fileformat.FileTypeSubtree, subtreeBytes))
+
+	return subtree
+}
+
+// hashPair returns the double SHA256 of the concatenation of two hashes.
+func hashPair(left, right *chainhash.Hash) chainhash.Hash {
+	var concat [2 * chainhash.HashSize]byte
+
+	copy(concat[:chainhash.HashSize], left[:])
+	copy(concat[chainhash.HashSize:], right[:])
+
+	return chainhash.DoubleHashH(concat[:])
+}
+
+func TestTransactionBroadcast(t *testing.T) {
+	ctx := context.Background()
+	coinbaseTx := getGenesisBlock(t).CoinbaseTx
+
+	t.Run("Accepted", func(t *testing.T) {
+		s := newTestServer(t, nil)
+		sess, _ := newTestSession(s.Server)
+
+		var txID string
+
+		callResult(t, sess, &txID, "blockchain.transaction.broadcast", hex.EncodeToString(coinbaseTx.Bytes()))
+		assert.Equal(t, coinbaseTx.TxID(), txID)
+
+		exists, err := s.txStore.Exists(ctx, coinbaseTx.TxIDChainHash().CloneBytes(), fileformat.FileTypeTx)
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		s := newTestServer(t, nil)
+		sess, _ := newTestSession(s.Server)
+
+		s.validatorClient.Errors = []error{errors.NewTxInvalidError("invalid transaction")}
+
+		resp := call(t, sess, "blockchain.transaction.broadcast", hex.EncodeToString(coinbaseTx.Bytes()))
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, errCodeBadRequest, resp.Error.Code)
+		assert.Contains(t, resp.Error.Message, "rejected")
+	})
+
+	t.Run("Invalid hex", func(t *testing.T) {
+		s := newTestServer(t, nil)
+		sess, _ := newTestSession(s.Server)
+
+		resp := call(t, sess, "blockchain.transaction.broadcast", "zz")
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, errCodeBadRequest, resp.Error.Code)
+	})
+}
+
+func TestScriptHashMethods(t *testing.T) {
+	scriptHash := scripthash.NewScriptHash([]byte("script"))
+	txID1 := chainhash.DoubleHashH([]byte("tx1"))
+	txID2 := chainhash.DoubleHashH([]byte("tx2"))
+
+	mockStore := &scripthash.Mock{}
+	mockStore.On("GetHistory", mock.Anything, &scriptHash, 0, 10_001, false).Return([]*scripthash.HistoryItem{
+		{TxID: txID1, Height: 10, TxPos: 1},
+		{TxID: txID2, Height: 12, TxPos: 4},
+	}, nil)
+	mockStore.On("GetUnspent", mock.Anything, &scriptHash).Return([]*scripthash.Output{
+		{ScriptHash: scriptHash, TxID: txID2, Vout: 1, Height: 12, TxPos: 4, Satoshis: 1_000},
+	}, nil)
+	mockStore.On("GetBalance", mock.Anything, &scriptHash).Return(&scripthash.Balance{Confirmed: 1_000, Received: 3_000, Spent: 2_000, UnspentCount: 1}, nil)
+
+	s := newTestServer(t, mockStore)
+	sess, _ := newTestSession(s.Server)
+
+	t.Run("History", func(t *testing.T) {
+		var result []historyItem
+
+		callResult(t, sess, &result, "blockchain.scripthash.get_history", scriptHash.String())
+		assert.Equal(t, []historyItem{{TxHash: txID1.String(), Height: 10}, {TxHash: txID2.String(), Height: 12}}, result)
+	})
+
+	t.Run("Unspent", func(t *testing.T) {
+		var result []unspentItem
+
+		callResult(t, sess, &result, "blockchain.scripthash.listunspent", scriptHash.String())
+		assert.Equal(t, []unspentItem{{TxHash: txID2.String(), TxPos: 1, Height: 12, Value: 1_000}}, result)
+	})
+
+	t.Run("Balance", func(t *testing.T) {
+		var result balanceResult
+
+		callResult(t, sess, &result, "blockchain.scripthash.get_balance", scriptHash.String())
+		assert.Equal(t, balanceResult{Confirmed: 1_000}, result)
+	})
+
+	t.Run("Subscribe and unsubscribe", func(t *testing.T) {
+		var status string
+
+		callResult(t, sess, &status, "blockchain.scripthash.subscribe", scriptHash.String())
+
+		// the status is the SHA256 of "tx_hash:height:" of each transaction in the history
+		expectedStatus := chainhash.HashB([]byte(txID1.String() + ":10:" + txID2.String() + ":12:"))
+		assert.Equal(t, hex.EncodeToString(expectedStatus), status)
+
+		var unsubscribed bool
+
+		callResult(t, sess, &unsubscribed, "blockchain.scripthash.unsubscribe", scriptHash.String())
+		assert.True(t, unsubscribed)
+
+		callResult(t, sess, &unsubscribed, "blockchain.scripthash.unsubscribe", scriptHash.String())
+		assert.False(t, unsubscribed)
+	})
+}
+
+func TestScriptHashMethods_IndexNotEnabled(t *testing.T) {
+	scriptHash := scripthash.NewScriptHash([]byte("script"))
+
+	s := newTestServer(t, nil)
+	sess, _ := newTestSession(s.Server)
+
+	resp := call(t, sess, "blockchain.scripthash.get_history", scriptHash.String())
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, errCodeDaemon, resp.Error.Code)
+	assert.Equal(t, "script hash index is not enabled", resp.Error.Message)
+}
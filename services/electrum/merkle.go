@@ -0,0 +1,248 @@
+package electrum
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	subtreepkg "github.com/bsv-blockchain/go-subtree"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/model"
+	"github.com/bsv-blockchain/teranode/services/asset/repository"
+	"github.com/bsv-blockchain/teranode/util/merkleproof"
+)
+
+// blockMerkleProof implements merkleproof.MerkleProofConstructor for the block at a height of the best chain,
+// so merkle branches are built from the subtree of the transaction and the subtree roots of the block, without
+// loading the other subtrees.
+//
+// The coinbase placeholder of the first subtree is replaced by the hash of the coinbase transaction, as the
+// merkle root of the block is computed over the coinbase transaction: the first subtree is loaded for every
+// block, and its root is replaced in the subtree roots of the block.
+type blockMerkleProof struct {
+	ctx        context.Context
+	repository repository.Interface
+	height     uint32
+
+	// block is the block with the root of the first subtree computed over the coinbase transaction
+	block *model.Block
+
+	// subtrees caches the subtrees loaded for the block, by root hash
+	subtrees map[chainhash.Hash]*subtreepkg.Subtree
+
+	// leavesPerSubtree is the number of leaves of all but the last subtree of the block
+	leavesPerSubtree int
+
+	// subtreeIdx is the index of the subtree of the transaction the proof is constructed for
+	subtreeIdx int
+}
+
+// newBlockMerkleProof loads the block at a height of the best chain and its first subtree.
+func (s *Server) newBlockMerkleProof(ctx context.Context, height uint32) (*blockMerkleProof, error) {
+	block, err := s.repository.GetBlockByHeight(ctx, height)
+	if err != nil {
+		if errors.Is(err, errors.ErrBlockNotFound) || errors.Is(err, errors.ErrNotFound) {
+			return nil, newBadRequestError(fmt.Sprintf("height %d out of range", height))
+		}
+
+		return nil, err
+	}
+
+	p := &blockMerkleProof{
+		ctx:        ctx,
+		repository: s.repository,
+		height:     height,
+		block:      block,
+		subtrees:   make(map[chainhash.Hash]*subtreepkg.Subtree, 2),
+	}
+
+	if len(block.Subtrees) == 0 {
+		return p, nil
+	}
+
+	firstSubtree, err := p.loadSubtree(block.Subtrees[0])
+	if err != nil {
+		return nil, err
+	}
+
+	firstSubtree = firstSubtree.Duplicate()
+	firstRoot := firstSubtree.ReplaceRootNode(block.CoinbaseTx.TxIDChainHash(), 0, uint64(block.CoinbaseTx.Size()))
+
+	subtreeHashes := make([]*chainhash.Hash, len(block.Subtrees))
+	copy(subtreeHashes, block.Subtrees)
+	subtreeHashes[0] = firstRoot
+
+	p.block, err = model.NewBlock(block.Header, block.CoinbaseTx, subtreeHashes, block.TransactionCount, block.SizeInBytes, block.Height, block.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	p.subtrees[*firstRoot] = firstSubtree
+	p.leavesPerSubtree = len(firstSubtree.Nodes)
+
+	return p, nil
+}
+
+// merkleBranch returns the merkle branch of a transaction in the block, with its position in the block.
+// The subtree of the transaction is read from the transaction metadata in the UTXO store.
+func (p *blockMerkleProof) merkleBranch(txHash *chainhash.Hash) ([]chainhash.Hash, int, error) {
+	if len(p.block.Subtrees) == 0 {
+		// a block without subtrees only holds the coinbase transaction, which is the merkle root
+		if p.block.CoinbaseTx.TxIDChainHash().IsEqual(txHash) {
+			return []chainhash.Hash{}, 0, nil
+		}
+
+		return nil, 0, p.notInBlockError(txHash)
+	}
+
+	subtreeIdx, err := p.subtreeIndex(txHash)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return p.construct(txHash, subtreeIdx)
+}
+
+// txAtPosition returns the hash of the transaction at a position in the block and its merkle branch.
+func (p *blockMerkleProof) txAtPosition(pos uint32, withMerkle bool) (*chainhash.Hash, []chainhash.Hash, error) {
+	if len(p.block.Subtrees) == 0 {
+		if pos != 0 {
+			return nil, nil, p.positionError(pos)
+		}
+
+		return p.block.CoinbaseTx.TxIDChainHash(), []chainhash.Hash{}, nil
+	}
+
+	subtreeIdx := int(pos) / p.leavesPerSubtree
+	if subtreeIdx >= len(p.block.Subtrees) {
+		return nil, nil, p.positionError(pos)
+	}
+
+	subtree, err := p.loadSubtree(p.block.Subtrees[subtreeIdx])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	index := int(pos) % p.leavesPerSubtree
+	if index >= len(subtree.Nodes) {
+		return nil, nil, p.positionError(pos)
+	}
+
+	txHash := subtree.Nodes[index].Hash
+
+	if !withMerkle {
+		return &txHash, nil, nil
+	}
+
+	branch, _, err := p.construct(&txHash, subtreeIdx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &txHash, branch, nil
+}
+
+// construct builds the merkle branch of a transaction in a subtree of the block with
+// merkleproof.ConstructMerkleProof, and returns it with the position of the transaction in the block.
+func (p *blockMerkleProof) construct(txHash *chainhash.Hash, subtreeIdx int) ([]chainhash.Hash, int, error) {
+	p.subtreeIdx = subtreeIdx
+
+	proof, err := merkleproof.ConstructMerkleProof(txHash, p)
+	if err != nil {
+		return nil, 0, errors.NewProcessingError("error constructing merkle proof of %s", txHash.String(), err)
+	}
+
+	branch := make([]chainhash.Hash, 0, len(proof.SubtreeProof)+len(proof.BlockProof))
+	branch = append(branch, proof.SubtreeProof...)
+	branch = append(branch, proof.BlockProof...)
+
+	return branch, proof.SubtreeIndex*p.leavesPerSubtree + proof.TxIndexInSubtree, nil
+}
+
+// subtreeIndex returns the index of the subtree of the block holding a transaction.
+func (p *blockMerkleProof) subtreeIndex(txHash *chainhash.Hash) (int, error) {
+	if p.block.CoinbaseTx.TxIDChainHash().IsEqual(txHash) {
+		return 0, nil
+	}
+
+	txMeta, err := p.repository.GetTxMeta(p.ctx, txHash)
+	if err != nil {
+		if errors.Is(err, errors.ErrNotFound) || errors.Is(err, errors.ErrTxNotFound) {
+			return 0, p.notInBlockError(txHash)
+		}
+
+		return 0, err
+	}
+
+	for i, blockID := range txMeta.BlockIDs {
+		if blockID == p.block.ID && i < len(txMeta.SubtreeIdxs) {
+			return txMeta.SubtreeIdxs[i], nil
+		}
+	}
+
+	return 0, p.notInBlockError(txHash)
+}
+
+// loadSubtree returns a subtree of the block, reading it from the repository the first time.
+func (p *blockMerkleProof) loadSubtree(subtreeHash *chainhash.Hash) (*subtreepkg.Subtree, error) {
+	if subtree, ok := p.subtrees[*subtreeHash]; ok {
+		return subtree, nil
+	}
+
+	subtree, err := p.repository.GetSubtree(p.ctx, subtreeHash)
+	if err != nil {
+		return nil, errors.NewProcessingError("error getting subtree %s of block %s", subtreeHash.String(), p.block.Hash().String(), err)
+	}
+
+	p.subtrees[*subtreeHash] = subtree
+
+	return subtree, nil
+}
+
+func (p *blockMerkleProof) notInBlockError(txHash *chainhash.Hash) error {
+	return newBadRequestError(fmt.Sprintf("transaction %s not in block at height %d", txHash.String(), p.height))
+}
+
+func (p *blockMerkleProof) positionError(pos uint32) error {
+	return newBadRequestError(fmt.Sprintf("no transaction at position %d in block at height %d", pos, p.height))
+}
+
+// GetTxMeta returns the block and subtree of the transaction the proof is constructed for.
+func (p *blockMerkleProof) GetTxMeta(_ *chainhash.Hash) (*merkleproof.TxMetaData, error) {
+	return &merkleproof.TxMetaData{
+		BlockIDs:     []uint32{p.block.ID},
+		BlockHeights: []uint32{p.height},
+		SubtreeIdxs:  []int{p.subtreeIdx},
+	}, nil
+}
+
+// GetBlockByID returns the block, with the root of the first subtree computed over the coinbase transaction.
+func (p *blockMerkleProof) GetBlockByID(_ uint64) (*model.Block, error) {
+	return p.block, nil
+}
+
+// GetBlockHeader returns the header of the block.
+func (p *blockMerkleProof) GetBlockHeader(_ *chainhash.Hash) (*model.BlockHeader, error) {
+	return p.block.Header, nil
+}
+
+// GetSubtree returns a subtree of the block. When the subtree has an odd number of leaves, its last leaf is
+// duplicated, as it is paired with itself in the merkle tree.
+func (p *blockMerkleProof) GetSubtree(subtreeHash *chainhash.Hash) (*subtreepkg.Subtree, error) {
+	subtree, err := p.loadSubtree(subtreeHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(subtree.Nodes) > 1 && len(subtree.Nodes)%2 == 1 {
+		subtree = subtree.Duplicate()
+		subtree.Nodes = append(subtree.Nodes, subtree.Nodes[len(subtree.Nodes)-1])
+	}
+
+	return subtree, nil
+}
+
+// FindBlocksContainingSubtree is not used to construct the merkle proof of a transaction.
+func (p *blockMerkleProof) FindBlocksContainingSubtree(_ *chainhash.Hash) ([]uint32, []uint32, []int, error) {
+	return nil, nil, nil, errors.NewProcessingError("finding the blocks of a subtree is not supported")
+}
@@ -0,0 +1,59 @@
+package electrum
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Prometheus metrics variables for monitoring the Electrum server.
+var (
+	// prometheusElectrumRequests counts the requests handled, by method and result
+	prometheusElectrumRequests *prometheus.CounterVec
+
+	// prometheusElectrumNotifications counts the notifications sent to clients, by method
+	prometheusElectrumNotifications *prometheus.CounterVec
+
+	// prometheusElectrumSessions tracks the number of connected clients
+	prometheusElectrumSessions prometheus.Gauge
+)
+
+var (
+	prometheusMetricsInitOnce sync.Once
+)
+
+func initPrometheusMetrics() {
+	prometheusMetricsInitOnce.Do(_initPrometheusMetrics)
+}
+
+func _initPrometheusMetrics() {
+	prometheusElectrumRequests = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "teranode",
+			Subsystem: "electrum",
+			Name:      "requests",
+			Help:      "Number of requests handled by the Electrum server",
+		},
+		[]string{"method", "result"},
+	)
+
+	prometheusElectrumNotifications = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "teranode",
+			Subsystem: "electrum",
+			Name:      "notifications",
+			Help:      "Number of notifications sent by the Electrum server",
+		},
+		[]string{"method"},
+	)
+
+	prometheusElectrumSessions = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "teranode",
+			Subsystem: "electrum",
+			Name:      "sessions",
+			Help:      "Number of clients connected to the Electrum server",
+		},
+	)
+}
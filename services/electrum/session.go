@@ -0,0 +1,552 @@
+package electrum
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/model"
+	"github.com/bsv-blockchain/teranode/util/tracing"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// maxRequestSize is the maximum size of a request, large enough for the broadcast of big transactions
+	maxRequestSize = 32 * 1024 * 1024
+
+	// writeTimeout is the time allowed to write a message to a client
+	writeTimeout = 30 * time.Second
+
+	// maxScriptHashSubscriptions is the maximum number of script hashes a session can subscribe to
+	maxScriptHashSubscriptions = 10_000
+)
+
+// JSON-RPC error codes. Errors of the standard JSON-RPC codes are protocol errors, the Electrum
+// specific codes are returned for requests that are well-formed but cannot be served.
+const (
+	errCodeParse          = -32700
+	errCodeInvalidRequest = -32600
+	errCodeMethodNotFound = -32601
+	errCodeInvalidParams  = -32602
+	errCodeInternal       = -32603
+
+	// errCodeBadRequest is returned for requests with arguments the server cannot serve
+	errCodeBadRequest = 1
+
+	// errCodeDaemon is returned when the node fails to serve a request
+	errCodeDaemon = 2
+)
+
+// rpcError is a JSON-RPC error returned to the client.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error implements the error interface.
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// newBadRequestError returns an error for a request the server cannot serve.
+func newBadRequestError(message string) *rpcError {
+	return &rpcError{Code: errCodeBadRequest, Message: message}
+}
+
+// newInvalidParamsError returns an error for a request with invalid parameters.
+func newInvalidParamsError(message string) *rpcError {
+	return &rpcError{Code: errCodeInvalidParams, Message: message}
+}
+
+// toRPCError converts an error returned by a method to the error returned to the client.
+func toRPCError(err error) *rpcError {
+	var rpcErr *rpcError
+	if errors.As(err, &rpcErr) {
+		return rpcErr
+	}
+
+	if errors.Is(err, errors.ErrServiceUnavailable) {
+		return &rpcError{Code: errCodeDaemon, Message: "script hash index is not enabled"}
+	}
+
+	return &rpcError{Code: errCodeDaemon, Message: err.Error()}
+}
+
+// request is a JSON-RPC request or notification sent by a client.
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+// response is a JSON-RPC response sent to a client.
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// notification is a JSON-RPC notification sent to a client.
+type notification struct {
+	JSONRPC string        `json:"jsonrpc"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+// transport reads and writes the JSON-RPC messages of a session.
+type transport interface {
+	readMessage() ([]byte, error)
+	writeMessage(msg []byte) error
+	close() error
+	remoteAddr() string
+}
+
+// tcpTransport exchanges newline delimited messages over a TCP connection.
+type tcpTransport struct {
+	conn    net.Conn
+	scanner *bufio.Scanner
+	mu      sync.Mutex
+}
+
+func newTCPTransport(conn net.Conn) *tcpTransport {
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRequestSize)
+
+	return &tcpTransport{
+		conn:    conn,
+		scanner: scanner,
+	}
+}
+
+func (t *tcpTransport) readMessage() ([]byte, error) {
+	if !t.scanner.Scan() {
+		if err := t.scanner.Err(); err != nil {
+			return nil, err
+		}
+
+		return nil, io.EOF
+	}
+
+	return bytes.Clone(t.scanner.Bytes()), nil
+}
+
+func (t *tcpTransport) writeMessage(msg []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.conn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+		return err
+	}
+
+	_, err := t.conn.Write(append(msg, '\n'))
+
+	return err
+}
+
+func (t *tcpTransport) close() error {
+	return t.conn.Close()
+}
+
+func (t *tcpTransport) remoteAddr() string {
+	return t.conn.RemoteAddr().String()
+}
+
+// webSocketTransport exchanges one message per WebSocket message.
+type webSocketTransport struct {
+	conn *websocket.Conn
+	mu   sync.Mutex
+}
+
+func newWebSocketTransport(conn *websocket.Conn) *webSocketTransport {
+	conn.SetReadLimit(maxRequestSize)
+
+	return &webSocketTransport{
+		conn: conn,
+	}
+}
+
+func (t *webSocketTransport) readMessage() ([]byte, error) {
+	_, msg, err := t.conn.ReadMessage()
+
+	return msg, err
+}
+
+func (t *webSocketTransport) writeMessage(msg []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.conn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+		return err
+	}
+
+	return t.conn.WriteMessage(websocket.TextMessage, msg)
+}
+
+func (t *webSocketTransport) close() error {
+	return t.conn.Close()
+}
+
+func (t *webSocketTransport) remoteAddr() string {
+	return t.conn.RemoteAddr().String()
+}
+
+// session is the state of a connected client: its transport and its subscriptions.
+type session struct {
+	server    *Server
+	transport transport
+
+	// mu protects the subscriptions
+	mu sync.Mutex
+
+	// headersSubscribed is set when the client subscribed to new block headers
+	headersSubscribed bool
+
+	// scriptHashes holds the subscribed script hashes, with the status the client was last sent,
+	// which is empty when the script hash has no history
+	scriptHashes map[chainhash.Hash]string
+}
+
+func newSession(server *Server, t transport) *session {
+	return &session{
+		server:       server,
+		transport:    t,
+		scriptHashes: make(map[chainhash.Hash]string),
+	}
+}
+
+// run reads and handles the requests of the client until the connection is closed.
+func (sess *session) run(ctx context.Context) {
+	for {
+		msg, err := sess.transport.readMessage()
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				sess.server.logger.Debugf("[Electrum] error reading from %s: %v", sess.transport.remoteAddr(), err)
+			}
+
+			return
+		}
+
+		reply := sess.handleMessage(ctx, msg)
+		if reply == nil {
+			continue
+		}
+
+		if err = sess.transport.writeMessage(reply); err != nil {
+			sess.server.logger.Debugf("[Electrum] error writing to %s: %v", sess.transport.remoteAddr(), err)
+			return
+		}
+	}
+}
+
+// handleMessage handles a single request or a batch of requests, and returns the serialized reply,
+// or nil when there is nothing to reply, as the message only contained notifications.
+func (sess *session) handleMessage(ctx context.Context, msg []byte) []byte {
+	msg = bytes.TrimSpace(msg)
+	if len(msg) == 0 {
+		return nil
+	}
+
+	var reply interface{}
+
+	if msg[0] == '[' {
+		var batch []json.RawMessage
+
+		if err := json.Unmarshal(msg, &batch); err != nil {
+			reply = newErrorResponse(nil, &rpcError{Code: errCodeParse, Message: "invalid JSON: " + err.Error()})
+		} else if len(batch) == 0 {
+			reply = newErrorResponse(nil, &rpcError{Code: errCodeInvalidRequest, Message: "empty batch"})
+		} else {
+			responses := make([]*response, 0, len(batch))
+
+			for _, raw := range batch {
+				if resp := sess.handleRequest(ctx, raw); resp != nil {
+					responses = append(responses, resp)
+				}
+			}
+
+			if len(responses) == 0 {
+				return nil
+			}
+
+			reply = responses
+		}
+	} else {
+		resp := sess.handleRequest(ctx, msg)
+		if resp == nil {
+			return nil
+		}
+
+		reply = resp
+	}
+
+	replyBytes, err := json.Marshal(reply)
+	if err != nil {
+		sess.server.logger.Errorf("[Electrum] error serializing reply: %v", err)
+
+		replyBytes, _ = json.Marshal(newErrorResponse(nil, &rpcError{Code: errCodeInternal, Message: "error serializing reply"}))
+	}
+
+	return replyBytes
+}
+
+// handleRequest handles a single request, and returns its response, or nil when the request is a
+// notification.
+func (sess *session) handleRequest(ctx context.Context, raw json.RawMessage) *response {
+	var req request
+
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return newErrorResponse(nil, &rpcError{Code: errCodeParse, Message: "invalid JSON: " + err.Error()})
+	}
+
+	if req.Method == "" {
+		return newErrorResponse(req.ID, &rpcError{Code: errCodeInvalidRequest, Message: "missing method"})
+	}
+
+	result, err := sess.call(ctx, req.Method, req.Params)
+
+	// requests without an id are notifications, which are not answered
+	if req.ID == nil {
+		return nil
+	}
+
+	if err != nil {
+		return newErrorResponse(req.ID, toRPCError(err))
+	}
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return newErrorResponse(req.ID, &rpcError{Code: errCodeInternal, Message: "error serializing result"})
+	}
+
+	return &response{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result:  resultBytes,
+	}
+}
+
+// call calls the handler of a method with the parameters of the request.
+func (sess *session) call(ctx context.Context, method string, rawParams json.RawMessage) (interface{}, error) {
+	handler, ok := handlers[method]
+	if !ok {
+		prometheusElectrumRequests.WithLabelValues("unknown", "error").Inc()
+
+		return nil, &rpcError{Code: errCodeMethodNotFound, Message: "unknown method \"" + method + "\""}
+	}
+
+	ctx, _, deferFn := tracing.Tracer("electrum").Start(ctx, method,
+		tracing.WithParentStat(sess.server.stats),
+		tracing.WithDebugLogMessage(sess.server.logger, "[Electrum] %s from %s", method, sess.transport.remoteAddr()),
+	)
+
+	params, err := parseParams(rawParams)
+	if err == nil {
+		var result interface{}
+
+		result, err = handler(ctx, sess, params)
+		if err == nil {
+			deferFn()
+			prometheusElectrumRequests.WithLabelValues(method, "ok").Inc()
+
+			return result, nil
+		}
+	}
+
+	deferFn(err)
+	prometheusElectrumRequests.WithLabelValues(method, "error").Inc()
+
+	return nil, err
+}
+
+// newErrorResponse returns the error response to the request with the given id.
+func newErrorResponse(id json.RawMessage, err *rpcError) *response {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+
+	return &response{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error:   err,
+	}
+}
+
+// notify sends a notification to the client.
+func (sess *session) notify(method string, params ...interface{}) {
+	msg, err := json.Marshal(&notification{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		sess.server.logger.Errorf("[Electrum] error serializing %s notification: %v", method, err)
+		return
+	}
+
+	if err = sess.transport.writeMessage(msg); err != nil {
+		sess.server.logger.Debugf("[Electrum] error notifying %s: %v", sess.transport.remoteAddr(), err)
+		return
+	}
+
+	prometheusElectrumNotifications.WithLabelValues(method).Inc()
+}
+
+// notifyHeader notifies the client of a new best block header, when it subscribed to headers.
+func (sess *session) notifyHeader(header *model.BlockHeader, height uint32) {
+	sess.mu.Lock()
+	subscribed := sess.headersSubscribed
+	sess.mu.Unlock()
+
+	if subscribed {
+		sess.notify("blockchain.headers.subscribe", newHeaderResult(header, height))
+	}
+}
+
+// notifyScriptHashes notifies the client of the subscribed script hashes of which the status changed.
+// The statuses map caches the statuses computed for other sessions.
+func (sess *session) notifyScriptHashes(ctx context.Context, statuses map[chainhash.Hash]string) {
+	sess.mu.Lock()
+
+	subscriptions := make(map[chainhash.Hash]string, len(sess.scriptHashes))
+	for scriptHash, status := range sess.scriptHashes {
+		subscriptions[scriptHash] = status
+	}
+
+	sess.mu.Unlock()
+
+	for scriptHash, lastStatus := range subscriptions {
+		status, ok := statuses[scriptHash]
+		if !ok {
+			var err error
+
+			if status, err = sess.server.scriptHashStatus(ctx, &scriptHash); err != nil {
+				sess.server.logger.Warnf("[Electrum] error getting status of script hash %s: %v", scriptHash.String(), err)
+				continue
+			}
+
+			statuses[scriptHash] = status
+		}
+
+		if status == lastStatus {
+			continue
+		}
+
+		// the session may have unsubscribed while the status was computed
+		sess.mu.Lock()
+
+		_, stillSubscribed := sess.scriptHashes[scriptHash]
+		if stillSubscribed {
+			sess.scriptHashes[scriptHash] = status
+		}
+
+		sess.mu.Unlock()
+
+		if stillSubscribed {
+			sess.notify("blockchain.scripthash.subscribe", scriptHash.String(), statusResult(status))
+		}
+	}
+}
+
+// parseParams parses the positional parameters of a request.
+func parseParams(rawParams json.RawMessage) ([]json.RawMessage, error) {
+	rawParams = bytes.TrimSpace(rawParams)
+	if len(rawParams) == 0 || bytes.Equal(rawParams, []byte("null")) {
+		return nil, nil
+	}
+
+	if rawParams[0] != '[' {
+		return nil, newInvalidParamsError("only positional parameters are supported")
+	}
+
+	var params []json.RawMessage
+
+	if err := json.Unmarshal(rawParams, &params); err != nil {
+		return nil, newInvalidParamsError("invalid parameters: " + err.Error())
+	}
+
+	return params, nil
+}
+
+// paramString returns the required string parameter at the given position.
+func paramString(params []json.RawMessage, idx int, name string) (string, error) {
+	if idx >= len(params) {
+		return "", newInvalidParamsError("missing parameter " + name)
+	}
+
+	var value string
+
+	if err := json.Unmarshal(params[idx], &value); err != nil {
+		return "", newInvalidParamsError("parameter " + name + " must be a string")
+	}
+
+	return value, nil
+}
+
+// paramUint32 returns the required unsigned integer parameter at the given position.
+func paramUint32(params []json.RawMessage, idx int, name string) (uint32, error) {
+	if idx >= len(params) {
+		return 0, newInvalidParamsError("missing parameter " + name)
+	}
+
+	var value uint32
+
+	if err := json.Unmarshal(params[idx], &value); err != nil {
+		return 0, newInvalidParamsError("parameter " + name + " must be a non-negative integer")
+	}
+
+	return value, nil
+}
+
+// optionalParamUint32 returns the unsigned integer parameter at the given position, or the default
+// value when the parameter is missing.
+func optionalParamUint32(params []json.RawMessage, idx int, name string, defaultValue uint32) (uint32, error) {
+	if idx >= len(params) || bytes.Equal(params[idx], []byte("null")) {
+		return defaultValue, nil
+	}
+
+	return paramUint32(params, idx, name)
+}
+
+// optionalParamBool returns the boolean parameter at the given position, or false when the parameter
+// is missing.
+func optionalParamBool(params []json.RawMessage, idx int, name string) (bool, error) {
+	if idx >= len(params) || bytes.Equal(params[idx], []byte("null")) {
+		return false, nil
+	}
+
+	var value bool
+
+	if err := json.Unmarshal(params[idx], &value); err != nil {
+		return false, newInvalidParamsError("parameter " + name + " must be a boolean")
+	}
+
+	return value, nil
+}
+
+// paramHash returns the required hash parameter at the given position, in reverse byte order hex.
+func paramHash(params []json.RawMessage, idx int, name string) (*chainhash.Hash, error) {
+	hashStr, err := paramString(params, idx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(hashStr) != 2*chainhash.HashSize {
+		return nil, newInvalidParamsError("parameter " + name + " must be a 64 character hex string")
+	}
+
+	hash, err := chainhash.NewHashFromStr(hashStr)
+	if err != nil {
+		return nil, newInvalidParamsError("parameter " + name + " must be a 64 character hex string")
+	}
+
+	return hash, nil
+}
@@ -0,0 +1,107 @@
+package filterindex
+
+import (
+	"context"
+
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+	"github.com/bsv-blockchain/teranode/stores/blob"
+	"github.com/bsv-blockchain/teranode/stores/blob/options"
+)
+
+// BlockFilter is the basic compact block filter (BIP158) of a block, together with the filter
+// header (BIP157) that commits to the filter and to the filter headers of all previous blocks.
+//
+// A BlockFilter is stored in the block store under the hash of the block, with the
+// fileformat.FileTypeCFilter file type. The stored record consists of the 32 byte filter header
+// followed by the serialized filter.
+type BlockFilter struct {
+	// BlockHash is the hash of the block the filter was built for
+	BlockHash chainhash.Hash
+
+	// Header is the filter header of the block
+	Header chainhash.Hash
+
+	// Filter is the serialized GCS filter, prefixed with the number of items as a compact size
+	Filter []byte
+}
+
+// NewBlockFilterFromBytes creates a BlockFilter for the given block hash from a stored record.
+//
+// Parameters:
+// - blockHash: Hash of the block the record was stored for
+// - b: The stored record, as returned by Bytes
+//
+// Returns:
+// - *BlockFilter: The deserialized block filter
+// - error: Processing error if the record is too short
+func NewBlockFilterFromBytes(blockHash *chainhash.Hash, b []byte) (*BlockFilter, error) {
+	if len(b) < chainhash.HashSize+1 {
+		return nil, errors.NewProcessingError("block filter record for %s is too short: %d bytes", blockHash, len(b))
+	}
+
+	f := &BlockFilter{
+		BlockHash: *blockHash,
+		Filter:    make([]byte, len(b)-chainhash.HashSize),
+	}
+
+	copy(f.Header[:], b[:chainhash.HashSize])
+	copy(f.Filter, b[chainhash.HashSize:])
+
+	return f, nil
+}
+
+// Bytes returns the record of the block filter as it is stored in the block store.
+func (f *BlockFilter) Bytes() []byte {
+	b := make([]byte, 0, chainhash.HashSize+len(f.Filter))
+	b = append(b, f.Header[:]...)
+	b = append(b, f.Filter...)
+
+	return b
+}
+
+// FilterHash returns the double SHA256 hash of the serialized filter, as sent in cfheaders messages.
+func (f *BlockFilter) FilterHash() chainhash.Hash {
+	return chainhash.DoubleHashH(f.Filter)
+}
+
+// GetBlockFilter reads the filter of the block with the given hash from the store.
+//
+// Parameters:
+// - ctx: Context for the storage operation
+// - store: The blob store the filter index writes to
+// - blockHash: Hash of the block to get the filter for
+//
+// Returns:
+// - *BlockFilter: The filter of the block
+// - error: Not found error when the block has not been indexed, or any other storage error
+func GetBlockFilter(ctx context.Context, store blob.Store, blockHash *chainhash.Hash) (*BlockFilter, error) {
+	b, err := store.Get(ctx, blockHash[:], fileformat.FileTypeCFilter)
+	if err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			return nil, errors.NewNotFoundError("filter for block %s not found", blockHash, err)
+		}
+
+		return nil, errors.NewStorageError("failed to get filter for block %s", blockHash, err)
+	}
+
+	return NewBlockFilterFromBytes(blockHash, b)
+}
+
+// PutBlockFilter writes the filter of a block to the store, replacing any existing filter of the block.
+//
+// Parameters:
+// - ctx: Context for the storage operation
+// - store: The blob store the filter index writes to
+// - f: The filter to store
+//
+// Returns:
+// - error: Any error encountered while writing the filter
+func PutBlockFilter(ctx context.Context, store blob.Store, f *BlockFilter) error {
+	if err := store.Set(ctx, f.BlockHash[:], fileformat.FileTypeCFilter, f.Bytes(), options.WithAllowOverwrite(true)); err != nil {
+		return errors.NewStorageError("failed to store filter for block %s", f.BlockHash, err)
+	}
+
+	return nil
+}
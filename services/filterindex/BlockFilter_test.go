@@ -0,0 +1,66 @@
+package filterindex
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/stores/blob/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockFilterBytes(t *testing.T) {
+	blockHash := chainhash.DoubleHashH([]byte("block"))
+
+	f := &BlockFilter{
+		BlockHash: blockHash,
+		Header:    chainhash.DoubleHashH([]byte("header")),
+		Filter:    []byte{0x01, 0x9d, 0xfc, 0xa8},
+	}
+
+	b := f.Bytes()
+	require.Len(t, b, chainhash.HashSize+4)
+
+	f2, err := NewBlockFilterFromBytes(&blockHash, b)
+	require.NoError(t, err)
+
+	assert.Equal(t, f, f2)
+	assert.Equal(t, chainhash.DoubleHashH(f.Filter), f2.FilterHash())
+}
+
+func TestNewBlockFilterFromBytes_TooShort(t *testing.T) {
+	blockHash := chainhash.DoubleHashH([]byte("block"))
+
+	_, err := NewBlockFilterFromBytes(&blockHash, make([]byte, chainhash.HashSize))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "too short")
+}
+
+func TestPutGetBlockFilter(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+
+	blockHash := chainhash.DoubleHashH([]byte("block"))
+
+	_, err := GetBlockFilter(ctx, store, &blockHash)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrNotFound))
+
+	f := &BlockFilter{
+		BlockHash: blockHash,
+		Header:    chainhash.DoubleHashH([]byte("header")),
+		Filter:    []byte{0x00},
+	}
+
+	require.NoError(t, PutBlockFilter(ctx, store, f))
+
+	// storing the filter of a block again replaces the existing filter
+	f.Filter = []byte{0x01, 0x9d, 0xfc, 0xa8}
+	require.NoError(t, PutBlockFilter(ctx, store, f))
+
+	f2, err := GetBlockFilter(ctx, store, &blockHash)
+	require.NoError(t, err)
+	assert.Equal(t, f, f2)
+}
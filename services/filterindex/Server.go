@@ -0,0 +1,396 @@
+// Package filterindex builds and maintains an index of the basic compact block filters (BIP158) and
+// filter headers (BIP157) of the blocks in the Teranode blockchain. The filters allow light clients
+// to find out which blocks are relevant to them, without revealing their addresses to the node.
+//
+// The package implements a server that follows the best chain and builds the filter of each block
+// from the subtree data written by the Block Persister, which holds the transactions of the block in
+// extended format. The filter of each block is stored in the block store, together with the filter
+// header that chains it to the filters of the previous blocks. The filters are served by the legacy
+// peer server (getcfilters, getcfheaders and getcfcheckpt) and by the RPC service (getcfilter and
+// getcfilterheader).
+//
+// Integration points:
+// - Blockchain service: Source of block notifications and blockchain data
+// - Subtree Store: Source of the subtree data of the blocks
+// - UTXO Store: Source of the previous outputs of transactions that are not in extended format
+// - Block Store: Storage for the block filters
+package filterindex
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	safeconversion "github.com/bsv-blockchain/go-safe-conversion"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/model"
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+	"github.com/bsv-blockchain/teranode/services/blockchain"
+	"github.com/bsv-blockchain/teranode/services/legacy/bsvutil/gcs"
+	"github.com/bsv-blockchain/teranode/services/legacy/bsvutil/gcs/builder"
+	"github.com/bsv-blockchain/teranode/settings"
+	"github.com/bsv-blockchain/teranode/stores/blob"
+	"github.com/bsv-blockchain/teranode/stores/blob/options"
+	"github.com/bsv-blockchain/teranode/stores/utxo"
+	"github.com/bsv-blockchain/teranode/ulogger"
+	"github.com/bsv-blockchain/teranode/util/blockfollower"
+	"github.com/bsv-blockchain/teranode/util/health"
+	"github.com/ordishs/gocore"
+)
+
+const (
+	// nextHeightFilename is the name of the file in the block store holding the height of the
+	// next block to index
+	nextHeightFilename = "filterIndexNextHeight"
+
+	// persistWaitDuration is the time to wait before retrying a block of which the subtree data
+	// has not been written by the block persister yet
+	persistWaitDuration = 10 * time.Second
+)
+
+// Server manages the compact block filter index.
+// It subscribes to blockchain notifications to detect new blocks, and indexes the blocks of the best
+// chain one at a time, in order of height. When the chain reorganizes, the server steps back to the
+// last block of the new best chain of which the filter exists, and indexes the new blocks from there.
+type Server struct {
+	// logger provides logging functionality
+	logger ulogger.Logger
+
+	// settings contains configuration settings
+	settings *settings.Settings
+
+	// blockchainClient provides access to blockchain operations
+	blockchainClient blockchain.ClientI
+
+	// blockStore is where the filters are written to
+	blockStore blob.Store
+
+	// subtreeStore provides access to the subtrees and the subtree data of the blocks
+	subtreeStore blob.Store
+
+	// utxoStore is used to decorate transactions that are not in extended format
+	utxoStore utxo.Store
+
+	// stats tracks operational statistics
+	stats *gocore.Stat
+
+	// nextHeight is the height of the next block to index
+	nextHeight uint32
+
+	// follower calls processNextBlock until the index has caught up with the best chain
+	follower *blockfollower.Follower
+}
+
+// New creates a new Server instance with the provided parameters.
+//
+// Parameters:
+// - ctx: Context for controlling the initialization process
+// - logger: Logger interface used for recording operational events and errors
+// - tSettings: Configuration settings that control server behavior
+// - blockStore: Blob store the filters are written to
+// - subtreeStore: Blob store holding the subtrees and the subtree data of the blocks
+// - utxoStore: UTXO store used to decorate transactions that are not in extended format
+// - blockchainClient: Client interface for accessing blockchain data and subscribing to notifications
+//
+// Returns a configured Server instance ready for initialization via the Init method.
+func New(
+	ctx context.Context,
+	logger ulogger.Logger,
+	tSettings *settings.Settings,
+	blockStore blob.Store,
+	subtreeStore blob.Store,
+	utxoStore utxo.Store,
+	blockchainClient blockchain.ClientI,
+) *Server {
+	s := &Server{
+		logger:           logger,
+		settings:         tSettings,
+		blockchainClient: blockchainClient,
+		blockStore:       blockStore,
+		subtreeStore:     subtreeStore,
+		utxoStore:        utxoStore,
+		stats:            gocore.NewStat("filterindex"),
+	}
+
+	s.follower = blockfollower.New(logger, "FilterIndex", "filter-index", blockchainClient, s.processNextBlock)
+
+	return s
+}
+
+// Health checks the health status of the server and its dependencies.
+// Liveness checks only verify that the service is running, while readiness checks also verify that
+// the blockchain client, FSM, block store and subtree store are available.
+//
+// Parameters:
+// - ctx: Context for controlling the health check operation
+// - checkLiveness: Boolean flag that determines the check type (true for liveness, false for readiness)
+//
+// Returns:
+// - int: HTTP status code (200 for OK, 503 for Service Unavailable)
+// - string: Status message describing the health state
+// - error: Any error encountered during health checks
+func (s *Server) Health(ctx context.Context, checkLiveness bool) (int, string, error) {
+	if checkLiveness {
+		// Add liveness checks here. Don't include dependency checks.
+		// If the service is stuck return http.StatusServiceUnavailable
+		// to indicate a restart is needed
+		return http.StatusOK, "OK", nil
+	}
+
+	// Add readiness checks here. Include dependency checks.
+	// If any dependency is not ready, return http.StatusServiceUnavailable
+	// If all dependencies are ready, return http.StatusOK
+	// A failed dependency check does not imply the service needs restarting
+	checks := make([]health.Check, 0, 4)
+	if s.blockchainClient != nil {
+		checks = append(checks, health.Check{Name: "BlockchainClient", Check: s.blockchainClient.Health})
+		checks = append(checks, health.Check{Name: "FSM", Check: blockchain.CheckFSM(s.blockchainClient)})
+	}
+
+	if s.blockStore != nil {
+		checks = append(checks, health.Check{Name: "BlockStore", Check: s.blockStore.Health})
+	}
+
+	if s.subtreeStore != nil {
+		checks = append(checks, health.Check{Name: "SubtreeStore", Check: s.subtreeStore.Health})
+	}
+
+	return health.CheckAll(ctx, checkLiveness, checks)
+}
+
+// Init initializes the server by reading the height of the next block to index.
+// If the height has not been stored before, indexing starts at the genesis block.
+//
+// Parameters:
+// - ctx: Context for controlling the initialization process
+//
+// Returns:
+// - error: Any error encountered during initialization
+func (s *Server) Init(ctx context.Context) (err error) {
+	height, err := s.readNextHeight(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.nextHeight = height
+
+	return nil
+}
+
+// Start begins the server's processing operations.
+// It waits for the blockchain FSM to leave the IDLE state, subscribes to blockchain notifications and
+// indexes blocks as they are received through the notification channel or on a timer.
+// The readyCh is closed when initialization is complete to signal readiness.
+//
+// Parameters:
+// - ctx: Context for controlling the server's lifecycle
+// - readyCh: Channel closed when initialization is complete to signal readiness
+//
+// Returns:
+// - error: Any error encountered during startup or processing
+func (s *Server) Start(ctx context.Context, readyCh chan<- struct{}) error {
+	close(readyCh)
+
+	return s.follower.Start(ctx)
+}
+
+// Stop stops the server's processing operations.
+// No specific cleanup operations are needed, processing stops when the context passed to Start is canceled.
+func (s *Server) Stop(_ context.Context) error {
+	return nil
+}
+
+// processNextBlock indexes the block of the best chain at the next height.
+//
+// The filter header of a block is derived from the filter header of its parent. When the filter of the
+// parent is missing, the chain has reorganized onto blocks that have not been indexed yet, and the next
+// height is stepped back by one block, until a block is reached of which the parent has been indexed.
+//
+// Parameters:
+// - ctx: Context for controlling the processing operation
+//
+// Returns:
+// - time.Duration: Time to wait before processing the next block
+// - error: Not found error when there is no block to index, or any other error encountered
+func (s *Server) processNextBlock(ctx context.Context) (time.Duration, error) {
+	_, bestBlockMeta, err := s.blockchainClient.GetBestBlockHeader(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if s.nextHeight > bestBlockMeta.Height {
+		return 0, errors.NewNotFoundError("[FilterIndex] no block at height %d", s.nextHeight)
+	}
+
+	headers, _, err := s.blockchainClient.GetBlockHeadersByHeight(ctx, s.nextHeight, s.nextHeight)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(headers) != 1 {
+		return 0, errors.NewProcessingError("[FilterIndex] 1 headers should have been returned, got %d", len(headers))
+	}
+
+	blockHash := headers[0].Hash()
+
+	var prevFilterHeader chainhash.Hash
+
+	if s.nextHeight > 0 {
+		prevFilter, err := GetBlockFilter(ctx, s.blockStore, headers[0].HashPrevBlock)
+		if err != nil {
+			if !errors.Is(err, errors.ErrNotFound) {
+				return 0, err
+			}
+
+			s.logger.Warnf("[FilterIndex] Filter of parent %s of block %s at height %d not found, stepping back", headers[0].HashPrevBlock, blockHash, s.nextHeight)
+
+			s.nextHeight--
+
+			return 0, s.writeNextHeight(ctx, s.nextHeight)
+		}
+
+		prevFilterHeader = prevFilter.Header
+	}
+
+	exists, err := s.blockStore.Exists(ctx, blockHash[:], fileformat.FileTypeCFilter)
+	if err != nil {
+		return 0, errors.NewStorageError("[FilterIndex] failed to check filter of block %s", blockHash, err)
+	}
+
+	if !exists {
+		block, err := s.blockchainClient.GetBlock(ctx, blockHash)
+		if err != nil {
+			return 0, err
+		}
+
+		persisted, err := blockfollower.SubtreeDataPersisted(ctx, s.subtreeStore, block)
+		if err != nil {
+			return 0, err
+		}
+
+		if !persisted {
+			s.logger.Infof("[FilterIndex] Waiting for subtree data of block %s at height %d to be persisted", blockHash, s.nextHeight)
+			return persistWaitDuration, nil
+		}
+
+		filter, err := s.buildBlockFilter(ctx, block)
+		if err != nil {
+			return 0, err
+		}
+
+		filterData, err := filter.NBytes()
+		if err != nil {
+			return 0, errors.NewProcessingError("[FilterIndex] failed to serialize filter of block %s", blockHash, err)
+		}
+
+		blockFilter := &BlockFilter{
+			BlockHash: *blockHash,
+			Header:    builder.MakeHeaderForFilterHash(chainhash.DoubleHashH(filterData), prevFilterHeader),
+			Filter:    filterData,
+		}
+
+		if err = PutBlockFilter(ctx, s.blockStore, blockFilter); err != nil {
+			return 0, err
+		}
+
+		s.logger.Infof("[FilterIndex] Indexed block %s at height %d with %d filter items", blockHash, s.nextHeight, filter.N())
+	}
+
+	s.nextHeight++
+
+	return 0, s.writeNextHeight(ctx, s.nextHeight)
+}
+
+// buildBlockFilter builds the basic filter of a block from its coinbase transaction and the subtree data
+// of its subtrees. Transactions that are not in extended format are decorated with their previous outputs
+// from the UTXO store.
+//
+// Parameters:
+// - ctx: Context for controlling the operation
+// - block: The block to build the filter for
+//
+// Returns:
+// - *gcs.Filter: The basic filter of the block
+// - error: Any error encountered while reading the transactions or building the filter
+func (s *Server) buildBlockFilter(ctx context.Context, block *model.Block) (*gcs.Filter, error) {
+	b := builder.NewBasicFilterBuilder(block.Hash())
+
+	if block.CoinbaseTx != nil {
+		if err := b.AddTx(block.CoinbaseTx); err != nil {
+			return nil, errors.NewProcessingError("[FilterIndex] failed to add coinbase of block %s to filter", block.Hash(), err)
+		}
+	}
+
+	for _, subtreeHash := range block.Subtrees {
+		subtreeData, err := blockfollower.ReadSubtreeData(ctx, s.subtreeStore, subtreeHash)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, tx := range subtreeData.Txs {
+			// the coinbase placeholder has no transaction, and the coinbase has been added already
+			if tx == nil || tx.IsCoinbase() {
+				continue
+			}
+
+			if !tx.IsExtended() {
+				if s.utxoStore == nil {
+					return nil, errors.NewProcessingError("[FilterIndex] transaction %s is not extended and no utxo store is available", tx.TxIDChainHash())
+				}
+
+				if err = s.utxoStore.PreviousOutputsDecorate(ctx, tx); err != nil {
+					return nil, errors.NewProcessingError("[FilterIndex] failed to decorate transaction %s", tx.TxIDChainHash(), err)
+				}
+			}
+
+			if err = b.AddTx(tx); err != nil {
+				return nil, errors.NewProcessingError("[FilterIndex] failed to add transaction %s to filter", tx.TxIDChainHash(), err)
+			}
+		}
+	}
+
+	filter, err := b.Build()
+	if err != nil {
+		return nil, errors.NewProcessingError("[FilterIndex] failed to build filter of block %s", block.Hash(), err)
+	}
+
+	return filter, nil
+}
+
+// readNextHeight reads the height of the next block to index from the block store.
+// If the height has not been stored yet, it returns 0, so that indexing starts at the genesis block.
+func (s *Server) readNextHeight(ctx context.Context) (uint32, error) {
+	b, err := s.blockStore.Get(ctx, nil, fileformat.FileTypeDat, options.WithFilename(nextHeightFilename))
+	if err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			s.logger.Warnf("[FilterIndex] %s.dat does not exist, starting from height 0", nextHeightFilename)
+			return 0, nil
+		}
+
+		return 0, err
+	}
+
+	height, err := strconv.ParseUint(strings.TrimSpace(string(b)), 10, 32)
+	if err != nil {
+		return 0, errors.NewProcessingError("failed to parse height from file", err)
+	}
+
+	return safeconversion.Uint64ToUint32(height)
+}
+
+// writeNextHeight writes the height of the next block to index to the block store, so that indexing
+// resumes from the correct block after a restart.
+func (s *Server) writeNextHeight(ctx context.Context, height uint32) error {
+	return s.blockStore.Set(
+		ctx,
+		nil,
+		fileformat.FileTypeDat,
+		[]byte(fmt.Sprintf("%d", height)),
+		options.WithFilename(nextHeightFilename),
+		options.WithAllowOverwrite(true),
+	)
+}
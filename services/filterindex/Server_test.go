@@ -0,0 +1,141 @@
+package filterindex
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/bsv-blockchain/go-chaincfg"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/model"
+	"github.com/bsv-blockchain/teranode/services/blockchain"
+	"github.com/bsv-blockchain/teranode/stores/blob/memory"
+	"github.com/bsv-blockchain/teranode/ulogger"
+	"github.com/bsv-blockchain/teranode/util/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T, blockchainClient blockchain.ClientI) *Server {
+	tSettings := test.CreateBaseTestSettings(t)
+
+	return New(context.Background(), ulogger.TestLogger{}, tSettings, memory.New(), memory.New(), nil, blockchainClient)
+}
+
+func TestReadWriteNextHeight(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t, nil)
+
+	height, err := s.readNextHeight(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), height)
+
+	require.NoError(t, s.writeNextHeight(ctx, 100_000))
+
+	require.NoError(t, s.Init(ctx))
+	assert.Equal(t, uint32(100_000), s.nextHeight)
+}
+
+func TestHealth_LivenessCheck(t *testing.T) {
+	s := &Server{}
+
+	status, message, err := s.Health(context.Background(), true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "OK", message)
+}
+
+// TestProcessNextBlock_Genesis indexes the testnet genesis block and checks the filter and filter
+// header against the BIP158 test vectors.
+func TestProcessNextBlock_Genesis(t *testing.T) {
+	ctx := context.Background()
+
+	genesisBlock, err := model.NewBlockFromMsgBlock(chaincfg.TestNetParams.GenesisBlock, nil)
+	require.NoError(t, err)
+
+	mockBlockchainClient := &blockchain.Mock{}
+	mockBlockchainClient.On("GetBestBlockHeader", mock.Anything).Return(genesisBlock.Header, &model.BlockHeaderMeta{Height: 0}, nil)
+	mockBlockchainClient.On("GetBlockHeadersByHeight", mock.Anything, uint32(0), uint32(0)).Return([]*model.BlockHeader{genesisBlock.Header}, []*model.BlockHeaderMeta{{Height: 0}}, nil)
+	mockBlockchainClient.On("GetBlock", mock.Anything, genesisBlock.Hash()).Return(genesisBlock, nil)
+
+	s := newTestServer(t, mockBlockchainClient)
+
+	delay, err := s.processNextBlock(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), delay)
+	assert.Equal(t, uint32(1), s.nextHeight)
+
+	f, err := GetBlockFilter(ctx, s.blockStore, genesisBlock.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, "019dfca8", hex.EncodeToString(f.Filter))
+	assert.Equal(t, "21584579b7eb08997773e5aeff3a7f932700042d0ed2a6129012b7d7ae81b750", f.Header.String())
+
+	height, err := s.readNextHeight(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), height)
+
+	// the best block has been indexed, so there is nothing left to do
+	_, err = s.processNextBlock(ctx)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrNotFound))
+
+	mockBlockchainClient.AssertExpectations(t)
+}
+
+// TestProcessNextBlock_MissingParentFilter steps back a block when the filter of the parent of the
+// next block does not exist, which is the case after a reorg.
+func TestProcessNextBlock_MissingParentFilter(t *testing.T) {
+	ctx := context.Background()
+
+	prevHash := chainhash.DoubleHashH([]byte("stale-parent"))
+	header := &model.BlockHeader{
+		Version:        1,
+		HashPrevBlock:  &prevHash,
+		HashMerkleRoot: &chainhash.Hash{},
+	}
+
+	mockBlockchainClient := &blockchain.Mock{}
+	mockBlockchainClient.On("GetBestBlockHeader", mock.Anything).Return(header, &model.BlockHeaderMeta{Height: 10}, nil)
+	mockBlockchainClient.On("GetBlockHeadersByHeight", mock.Anything, uint32(5), uint32(5)).Return([]*model.BlockHeader{header}, []*model.BlockHeaderMeta{{Height: 5}}, nil)
+
+	s := newTestServer(t, mockBlockchainClient)
+	s.nextHeight = 5
+
+	delay, err := s.processNextBlock(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), delay)
+	assert.Equal(t, uint32(4), s.nextHeight)
+
+	mockBlockchainClient.AssertExpectations(t)
+}
+
+// TestProcessNextBlock_WaitingForSubtreeData waits for the block persister when the subtree data of a
+// block has not been written yet.
+func TestProcessNextBlock_WaitingForSubtreeData(t *testing.T) {
+	ctx := context.Background()
+
+	genesisBlock, err := model.NewBlockFromMsgBlock(chaincfg.TestNetParams.GenesisBlock, nil)
+	require.NoError(t, err)
+
+	subtreeHash := chainhash.DoubleHashH([]byte("subtree"))
+	genesisBlock.Subtrees = []*chainhash.Hash{&subtreeHash}
+
+	mockBlockchainClient := &blockchain.Mock{}
+	mockBlockchainClient.On("GetBestBlockHeader", mock.Anything).Return(genesisBlock.Header, &model.BlockHeaderMeta{Height: 0}, nil)
+	mockBlockchainClient.On("GetBlockHeadersByHeight", mock.Anything, uint32(0), uint32(0)).Return([]*model.BlockHeader{genesisBlock.Header}, []*model.BlockHeaderMeta{{Height: 0}}, nil)
+	mockBlockchainClient.On("GetBlock", mock.Anything, genesisBlock.Hash()).Return(genesisBlock, nil)
+
+	s := newTestServer(t, mockBlockchainClient)
+
+	delay, err := s.processNextBlock(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, persistWaitDuration, delay)
+	assert.Equal(t, uint32(0), s.nextHeight)
+
+	mockBlockchainClient.AssertExpectations(t)
+}
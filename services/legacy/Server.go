@@ -115,6 +115,10 @@ type Server struct {
 	// blockAssemblyClient handles block assembly operations
 	// Used for mining and block template generation
 	blockAssemblyClient *blockassembly.Client
+
+	// blockStore provides access to the compact block filters written by the filter index
+	// Only used when serving compact block filters is enabled
+	blockStore blob.Store
 }
 
 // New creates and returns a new Server instance with the provided dependencies.
@@ -135,6 +139,7 @@ type Server struct {
 //   - subtreeValidation: Interface to the subtree validation service
 //   - blockValidation: Interface to the block validation service
 //   - blockAssemblyClient: Client for the block assembly service (used for mining)
+//   - blockStore: Blob storage holding the compact block filters, may be nil when filters are not served
 //
 // Returns a properly configured Server instance that is ready to be initialized and started.
 func New(logger ulogger.Logger,
@@ -147,6 +152,7 @@ func New(logger ulogger.Logger,
 	subtreeValidation subtreevalidation.Interface,
 	blockValidation blockvalidation.Interface,
 	blockAssemblyClient *blockassembly.Client,
+	blockStore blob.Store,
 ) *Server {
 	initPrometheusMetrics()
 
@@ -162,6 +168,7 @@ func New(logger ulogger.Logger,
 		subtreeValidation:   subtreeValidation,
 		blockValidation:     blockValidation,
 		blockAssemblyClient: blockAssemblyClient,
+		blockStore:          blockStore,
 	}
 }
 
@@ -322,6 +329,7 @@ func (s *Server) Init(ctx context.Context) error {
 		s.subtreeValidation,
 		s.blockValidation,
 		s.blockAssemblyClient,
+		s.blockStore,
 		listenAddresses,
 		assetHTTPAddress,
 	)
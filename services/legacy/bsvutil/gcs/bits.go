@@ -0,0 +1,90 @@
+package gcs
+
+import (
+	"io"
+)
+
+// bitWriter writes a stream of bits, most significant bit first, as used by
+// the Golomb-Rice encoding of a filter.
+type bitWriter struct {
+	data  []byte
+	nBits uint8
+}
+
+// writeBit appends a single bit to the stream.
+func (w *bitWriter) writeBit(bit bool) {
+	if w.nBits == 0 {
+		w.data = append(w.data, 0)
+		w.nBits = 8
+	}
+
+	w.nBits--
+
+	if bit {
+		w.data[len(w.data)-1] |= 1 << w.nBits
+	}
+}
+
+// writeBits appends the given number of least significant bits of value to
+// the stream, most significant bit first.
+func (w *bitWriter) writeBits(value uint64, count uint8) {
+	for count > 0 {
+		count--
+		w.writeBit(value&(1<<count) != 0)
+	}
+}
+
+// bytes returns the written stream, padded with zero bits to a whole byte.
+func (w *bitWriter) bytes() []byte {
+	return w.data
+}
+
+// bitReader reads a stream of bits, most significant bit first.
+type bitReader struct {
+	data  []byte
+	pos   int
+	nBits uint8
+}
+
+// newBitReader returns a bitReader for the given stream.
+func newBitReader(data []byte) *bitReader {
+	return &bitReader{data: data}
+}
+
+// readBit reads a single bit from the stream, returning io.EOF when the end
+// of the stream is reached.
+func (r *bitReader) readBit() (bool, error) {
+	if r.nBits == 0 {
+		if r.pos >= len(r.data) {
+			return false, io.EOF
+		}
+
+		r.pos++
+		r.nBits = 8
+	}
+
+	r.nBits--
+
+	return r.data[r.pos-1]&(1<<r.nBits) != 0, nil
+}
+
+// readBits reads the given number of bits from the stream and returns them as
+// the least significant bits of the result.
+func (r *bitReader) readBits(count uint8) (uint64, error) {
+	var value uint64
+
+	for ; count > 0; count-- {
+		bit, err := r.readBit()
+		if err != nil {
+			return 0, err
+		}
+
+		value <<= 1
+
+		if bit {
+			value |= 1
+		}
+	}
+
+	return value, nil
+}
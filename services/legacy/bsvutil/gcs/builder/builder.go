@@ -0,0 +1,156 @@
+// Package builder builds the basic compact block filters specified in BIP158
+// from the transactions of a block, and derives the filter headers that chain
+// the filters of consecutive blocks together as specified in BIP157.
+package builder
+
+import (
+	"errors"
+
+	"github.com/bsv-blockchain/go-bt/v2"
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/bsv-blockchain/teranode/services/legacy/bsvutil/gcs"
+)
+
+const (
+	// DefaultP is the default collision probability (2^-19)
+	DefaultP = 19
+
+	// DefaultM is the default value used for the hash range.
+	DefaultM uint64 = 784931
+
+	// opReturn is the opcode of OP_RETURN, output scripts starting with it
+	// are not included in the basic filter.
+	opReturn = 0x6a
+)
+
+// ErrMissingPrevOutScript is returned when a transaction added to the filter
+// does not carry the locking scripts of the outputs it spends.
+var ErrMissingPrevOutScript = errors.New("transaction input is missing the previous output script")
+
+// DeriveKey derives the key of the filter of a block, which is the first
+// KeySize bytes of the block hash.
+func DeriveKey(blockHash *chainhash.Hash) [gcs.KeySize]byte {
+	var key [gcs.KeySize]byte
+
+	copy(key[:], blockHash[:gcs.KeySize])
+
+	return key
+}
+
+// BasicFilterBuilder collects the scripts of the transactions of a block and
+// builds the basic filter of the block.  The basic filter includes the locking
+// script of every output of every transaction, except for outputs starting
+// with OP_RETURN, and the locking script of every output spent by the
+// transactions, except for the coinbase transaction.
+//
+// Only the hashes of the scripts are kept in memory, so the transactions of a
+// large block can be added in batches.  The builder is not safe for
+// concurrent use.
+type BasicFilterBuilder struct {
+	key    [gcs.KeySize]byte
+	hashes map[uint64]struct{}
+}
+
+// NewBasicFilterBuilder returns a builder for the basic filter of the block
+// with the given hash.
+func NewBasicFilterBuilder(blockHash *chainhash.Hash) *BasicFilterBuilder {
+	return &BasicFilterBuilder{
+		key:    DeriveKey(blockHash),
+		hashes: make(map[uint64]struct{}),
+	}
+}
+
+// AddScript adds a script to the filter.  Empty scripts are skipped.
+func (b *BasicFilterBuilder) AddScript(script []byte) {
+	if len(script) == 0 {
+		return
+	}
+
+	b.hashes[gcs.HashItem(b.key, script)] = struct{}{}
+}
+
+// AddTx adds the output scripts and the spent output scripts of a
+// transaction to the filter.  The transaction must be in extended format,
+// unless it is the coinbase transaction.
+func (b *BasicFilterBuilder) AddTx(tx *bt.Tx) error {
+	if !tx.IsCoinbase() {
+		for _, input := range tx.Inputs {
+			if input.PreviousTxScript == nil {
+				return ErrMissingPrevOutScript
+			}
+
+			b.AddScript(*input.PreviousTxScript)
+		}
+	}
+
+	for _, output := range tx.Outputs {
+		if output.LockingScript == nil {
+			continue
+		}
+
+		script := *output.LockingScript
+		if len(script) > 0 && script[0] == opReturn {
+			continue
+		}
+
+		b.AddScript(script)
+	}
+
+	return nil
+}
+
+// Build returns the filter of all the scripts that have been added.
+func (b *BasicFilterBuilder) Build() (*gcs.Filter, error) {
+	hashes := make([]uint64, 0, len(b.hashes))
+	for hash := range b.hashes {
+		hashes = append(hashes, hash)
+	}
+
+	return gcs.BuildGCSFilterFromHashes(DefaultP, DefaultM, hashes)
+}
+
+// BuildBasicFilter builds the basic filter of the block with the given hash
+// and transactions.  All transactions except for the coinbase transaction
+// must be in extended format.
+func BuildBasicFilter(blockHash *chainhash.Hash, txs []*bt.Tx) (*gcs.Filter, error) {
+	b := NewBasicFilterBuilder(blockHash)
+
+	for _, tx := range txs {
+		if err := b.AddTx(tx); err != nil {
+			return nil, err
+		}
+	}
+
+	return b.Build()
+}
+
+// GetFilterHash returns the double-SHA256 of the filter.
+func GetFilterHash(filter *gcs.Filter) (chainhash.Hash, error) {
+	filterData, err := filter.NBytes()
+	if err != nil {
+		return chainhash.Hash{}, err
+	}
+
+	return chainhash.DoubleHashH(filterData), nil
+}
+
+// MakeHeaderForFilter makes a filter chain header for a filter, given the
+// filter and the previous filter chain header.
+func MakeHeaderForFilter(filter *gcs.Filter, prevHeader chainhash.Hash) (chainhash.Hash, error) {
+	filterHash, err := GetFilterHash(filter)
+	if err != nil {
+		return chainhash.Hash{}, err
+	}
+
+	return MakeHeaderForFilterHash(filterHash, prevHeader), nil
+}
+
+// MakeHeaderForFilterHash makes a filter chain header for a filter, given the
+// hash of the filter and the previous filter chain header.
+func MakeHeaderForFilterHash(filterHash, prevHeader chainhash.Hash) chainhash.Hash {
+	filterTip := make([]byte, 2*chainhash.HashSize)
+	copy(filterTip, filterHash[:])
+	copy(filterTip[chainhash.HashSize:], prevHeader[:])
+
+	return chainhash.DoubleHashH(filterTip)
+}
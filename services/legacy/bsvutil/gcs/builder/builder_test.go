@@ -0,0 +1,144 @@
+package builder_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bt/v2"
+	"github.com/bsv-blockchain/go-bt/v2/bscript"
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/bsv-blockchain/teranode/services/legacy/bsvutil/gcs/builder"
+)
+
+// genesisCoinbaseHex is the coinbase transaction of the genesis block
+const genesisCoinbaseHex = "01000000010000000000000000000000000000000000000000000000000000000000000000ffffffff4d04ffff001d0104455468652054696d65732030332f4a616e2f32303039204368616e63656c6c6f72206f6e206272696e6b206f66207365636f6e64206261696c6f757420666f722062616e6b73ffffffff0100f2052a01000000434104678afdb0fe5548271967f1a67130b7105cd6a828e03909a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7ba0b8d578a4c702b6bf11d5fac00000000"
+
+// TestBuildBasicFilterTestnetGenesis ensures the basic filter and filter header
+// of the testnet genesis block match the BIP158 test vectors.
+func TestBuildBasicFilterTestnetGenesis(t *testing.T) {
+	blockHash, err := chainhash.NewHashFromStr("000000000933ea01ad0ee984209779baaec3ced90fa3f408719526f8d77f4943")
+	if err != nil {
+		t.Fatalf("NewHashFromStr: %v", err)
+	}
+
+	coinbaseTx, err := bt.NewTxFromString(genesisCoinbaseHex)
+	if err != nil {
+		t.Fatalf("NewTxFromString: %v", err)
+	}
+
+	filter, err := builder.BuildBasicFilter(blockHash, []*bt.Tx{coinbaseTx})
+	if err != nil {
+		t.Fatalf("BuildBasicFilter: %v", err)
+	}
+
+	nBytes, err := filter.NBytes()
+	if err != nil {
+		t.Fatalf("NBytes: %v", err)
+	}
+
+	if hex.EncodeToString(nBytes) != "019dfca8" {
+		t.Errorf("filter: got %x, want 019dfca8", nBytes)
+	}
+
+	header, err := builder.MakeHeaderForFilter(filter, chainhash.Hash{})
+	if err != nil {
+		t.Fatalf("MakeHeaderForFilter: %v", err)
+	}
+
+	if header.String() != "21584579b7eb08997773e5aeff3a7f932700042d0ed2a6129012b7d7ae81b750" {
+		t.Errorf("header: got %s, want 21584579b7eb08997773e5aeff3a7f932700042d0ed2a6129012b7d7ae81b750", header)
+	}
+
+	filterHash, err := builder.GetFilterHash(filter)
+	if err != nil {
+		t.Fatalf("GetFilterHash: %v", err)
+	}
+
+	if builder.MakeHeaderForFilterHash(filterHash, chainhash.Hash{}) != header {
+		t.Errorf("MakeHeaderForFilterHash does not match MakeHeaderForFilter")
+	}
+}
+
+// TestBasicFilterBuilder ensures the basic filter includes the output scripts
+// and the spent output scripts of the transactions, except for OP_RETURN
+// outputs.
+func TestBasicFilterBuilder(t *testing.T) {
+	blockHash := &chainhash.Hash{0x01, 0x02, 0x03}
+	key := builder.DeriveKey(blockHash)
+
+	if !bytes.Equal(key[:], blockHash[:len(key)]) {
+		t.Fatalf("DeriveKey: got %x, want %x", key, blockHash[:len(key)])
+	}
+
+	prevScriptHex := "76a914f1c075a01882ae0972f95d3a4177c86c852b7d9188ac"
+
+	tx := bt.NewTx()
+	if err := tx.From("1111111111111111111111111111111111111111111111111111111111111111", 0, prevScriptHex, 10_000); err != nil {
+		t.Fatalf("From: %v", err)
+	}
+
+	if err := tx.PayToAddress("1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", 9_000); err != nil {
+		t.Fatalf("PayToAddress: %v", err)
+	}
+
+	tx.AddOutput(&bt.Output{LockingScript: bscript.NewFromBytes([]byte{0x6a, 0x04, 'd', 'a', 't', 'a'})})
+
+	b := builder.NewBasicFilterBuilder(blockHash)
+	if err := b.AddTx(tx); err != nil {
+		t.Fatalf("AddTx: %v", err)
+	}
+
+	filter, err := b.Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	if filter.N() != 2 {
+		t.Errorf("N: got %d, want 2", filter.N())
+	}
+
+	prevScript, err := hex.DecodeString(prevScriptHex)
+	if err != nil {
+		t.Fatalf("DecodeString: %v", err)
+	}
+
+	for _, script := range [][]byte{prevScript, *tx.Outputs[0].LockingScript} {
+		match, err := filter.Match(key, script)
+		if err != nil {
+			t.Fatalf("Match: %v", err)
+		}
+
+		if !match {
+			t.Errorf("script %x does not match", script)
+		}
+	}
+
+	match, err := filter.Match(key, *tx.Outputs[1].LockingScript)
+	if err != nil {
+		t.Fatalf("Match: %v", err)
+	}
+
+	if match {
+		t.Errorf("OP_RETURN output matches")
+	}
+}
+
+// TestBasicFilterBuilderMissingPrevOutScript ensures transactions that are not
+// in extended format are rejected.
+func TestBasicFilterBuilderMissingPrevOutScript(t *testing.T) {
+	tx := bt.NewTx()
+	tx.Inputs = append(tx.Inputs, &bt.Input{
+		PreviousTxOutIndex: 0,
+		UnlockingScript:    bscript.NewFromBytes([]byte{0x51}),
+		SequenceNumber:     0xffffffff,
+	})
+
+	if err := tx.Inputs[0].PreviousTxIDAdd(&chainhash.Hash{0x01}); err != nil {
+		t.Fatalf("PreviousTxIDAdd: %v", err)
+	}
+
+	if _, err := builder.BuildBasicFilter(&chainhash.Hash{}, []*bt.Tx{tx}); err != builder.ErrMissingPrevOutScript {
+		t.Errorf("BuildBasicFilter: got %v, want %v", err, builder.ErrMissingPrevOutScript)
+	}
+}
@@ -0,0 +1,291 @@
+// Package gcs provides an implementation of the Golomb-coded sets used by the
+// compact block filters specified in BIP158.
+//
+// A filter commits to a set of items, such as the scripts of a block, in a
+// probabilistic way: matching an item against a filter never yields a false
+// negative, while the false positive rate is determined by the P and M
+// parameters of the filter.
+package gcs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/bits"
+	"sort"
+
+	"github.com/bsv-blockchain/go-wire"
+)
+
+// KeySize is the size of the SipHash key used to hash the items of a filter.
+const KeySize = 16
+
+var (
+	// ErrNTooBig signifies that the filter can't handle N items.
+	ErrNTooBig = errors.New("N is too big to fit in uint32")
+
+	// ErrPTooBig signifies that the filter can't handle 1/2**P collision
+	// probability.
+	ErrPTooBig = errors.New("P is too big to fit in uint32")
+
+	// ErrMisserialized signifies a filter was misserialized and is missing
+	// the N and/or P parameters of a serialized filter.
+	ErrMisserialized = errors.New("misserialized filter")
+)
+
+// Filter describes an immutable filter that can be built from a set of data
+// elements, serialized, deserialized, and queried in a thread-safe manner.
+// The serialized form is compressed as a Golomb-coded set (GCS), as specified
+// in BIP158.
+type Filter struct {
+	n          uint32
+	p          uint8
+	modulusNM  uint64
+	filterData []byte
+}
+
+// HashItem returns the 64-bit SipHash of an item with the given key, which
+// is reduced to the range of a filter when the filter is built.
+func HashItem(key [KeySize]byte, item []byte) uint64 {
+	k0 := binary.LittleEndian.Uint64(key[0:8])
+	k1 := binary.LittleEndian.Uint64(key[8:16])
+
+	return SipHash(k0, k1, item)
+}
+
+// BuildGCSFilter builds a new GCS filter with the collision probability of
+// 1/(2**P), the M parameter and key given, and including all the items in
+// the data slice.  Duplicate items are only included once.
+func BuildGCSFilter(P uint8, M uint64, key [KeySize]byte, data [][]byte) (*Filter, error) {
+	hashes := make([]uint64, 0, len(data))
+	seen := make(map[string]struct{}, len(data))
+
+	for _, item := range data {
+		if _, ok := seen[string(item)]; ok {
+			continue
+		}
+
+		seen[string(item)] = struct{}{}
+
+		hashes = append(hashes, HashItem(key, item))
+	}
+
+	return BuildGCSFilterFromHashes(P, M, hashes)
+}
+
+// BuildGCSFilterFromHashes builds a new GCS filter from the SipHash values of
+// the items of the filter, as returned by HashItem.  The caller is responsible
+// for removing duplicate items, and the hashes slice is sorted in place.
+func BuildGCSFilterFromHashes(P uint8, M uint64, hashes []uint64) (*Filter, error) {
+	// Some initial parameter checks: make sure we have data from which to
+	// build the filter, and make sure our parameters will fit the hash
+	// function we're using.
+	if uint64(len(hashes)) >= (1 << 32) {
+		return nil, ErrNTooBig
+	}
+
+	if P > 32 {
+		return nil, ErrPTooBig
+	}
+
+	f := &Filter{
+		n: uint32(len(hashes)),
+		p: P,
+	}
+
+	// First we'll compute the value of m, which is the modulus we use
+	// within our finite field.  We want to compute: N * M.
+	f.modulusNM = uint64(f.n) * M
+
+	// Shortcut if the filter is empty.
+	if f.n == 0 {
+		return f, nil
+	}
+
+	// Map the hashes uniformly into the range [0, N*M) and sort them, so
+	// they can be delta encoded.
+	values := make([]uint64, len(hashes))
+	for i, hash := range hashes {
+		values[i] = fastReduction(hash, f.modulusNM)
+	}
+
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	var (
+		w         bitWriter
+		lastValue uint64
+	)
+
+	for _, value := range values {
+		// Write the difference between this value and the last value
+		// as a Golomb-Rice code: the quotient in unary, followed by the
+		// remainder in P bits.
+		delta := value - lastValue
+		lastValue = value
+
+		for quotient := delta >> f.p; quotient > 0; quotient-- {
+			w.writeBit(true)
+		}
+
+		w.writeBit(false)
+		w.writeBits(delta, f.p)
+	}
+
+	f.filterData = w.bytes()
+
+	return f, nil
+}
+
+// FromNBytes deserializes a GCS filter from a known P and M, and serialized
+// filter as returned by NBytes().
+func FromNBytes(P uint8, M uint64, d []byte) (*Filter, error) {
+	if P > 32 {
+		return nil, ErrPTooBig
+	}
+
+	buffer := bytes.NewReader(d)
+
+	n, err := wire.ReadVarInt(buffer, 0)
+	if err != nil {
+		return nil, ErrMisserialized
+	}
+
+	if n >= (1 << 32) {
+		return nil, ErrNTooBig
+	}
+
+	filterData := make([]byte, buffer.Len())
+	copy(filterData, d[len(d)-buffer.Len():])
+
+	return &Filter{
+		n:          uint32(n),
+		p:          P,
+		modulusNM:  n * M,
+		filterData: filterData,
+	}, nil
+}
+
+// NBytes returns the serialized format of the GCS filter, which includes N
+// as a compact size, but not P or M, as specified in BIP158.
+func (f *Filter) NBytes() ([]byte, error) {
+	var buf bytes.Buffer
+
+	buf.Grow(wire.VarIntSerializeSize(uint64(f.n)) + len(f.filterData))
+
+	if err := wire.WriteVarInt(&buf, 0, uint64(f.n)); err != nil {
+		return nil, err
+	}
+
+	if _, err := buf.Write(f.filterData); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// N returns the number of items in the filter.
+func (f *Filter) N() uint32 {
+	return f.n
+}
+
+// P returns the filter's collision probability as a negative power of 2.
+func (f *Filter) P() uint8 {
+	return f.p
+}
+
+// Match checks whether a []byte value is likely (within collision probability)
+// to be a member of the set represented by the filter.
+func (f *Filter) Match(key [KeySize]byte, data []byte) (bool, error) {
+	return f.MatchAny(key, [][]byte{data})
+}
+
+// MatchAny checks whether any []byte value is likely (within collision
+// probability) to be a member of the set represented by the filter.
+func (f *Filter) MatchAny(key [KeySize]byte, data [][]byte) (bool, error) {
+	if f.n == 0 || len(data) == 0 {
+		return false, nil
+	}
+
+	// Map the query items into the range of the filter and sort them, so
+	// the filter only has to be decoded once.
+	values := make([]uint64, len(data))
+	for i, item := range data {
+		values[i] = fastReduction(HashItem(key, item), f.modulusNM)
+	}
+
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	r := newBitReader(f.filterData)
+
+	var (
+		filterValue uint64
+		queryIdx    int
+	)
+
+	for i := uint32(0); i < f.n; i++ {
+		delta, err := f.readFullUint64(r)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return false, ErrMisserialized
+			}
+
+			return false, err
+		}
+
+		filterValue += delta
+
+		// Skip the query values that are lower than the current filter
+		// value, they are not in the filter.
+		for values[queryIdx] < filterValue {
+			queryIdx++
+
+			if queryIdx == len(values) {
+				return false, nil
+			}
+		}
+
+		if values[queryIdx] == filterValue {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// readFullUint64 reads a value represented by the sum of a unary multiple of
+// the filter's P modulus (`2**P`) and a big-endian P-bit remainder.
+func (f *Filter) readFullUint64(r *bitReader) (uint64, error) {
+	var quotient uint64
+
+	// Count the 1s until we reach a 0.
+	for {
+		bit, err := r.readBit()
+		if err != nil {
+			return 0, err
+		}
+
+		if !bit {
+			break
+		}
+
+		quotient++
+	}
+
+	remainder, err := r.readBits(f.p)
+	if err != nil {
+		return 0, err
+	}
+
+	// Add the multiple and the remainder.
+	return quotient<<f.p + remainder, nil
+}
+
+// fastReduction maps a 64-bit hash uniformly into the range [0, n) without
+// using a modulus operation, by taking the high 64 bits of the 128-bit
+// product of the hash and n.
+func fastReduction(hash, n uint64) uint64 {
+	hi, _ := bits.Mul64(hash, n)
+
+	return hi
+}
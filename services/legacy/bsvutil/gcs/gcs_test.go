@@ -0,0 +1,232 @@
+package gcs_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/bsv-blockchain/teranode/services/legacy/bsvutil/gcs"
+)
+
+const (
+	// testP and testM are the parameters of the BIP158 basic filter
+	testP = 19
+	testM = 784931
+)
+
+// testKey returns the filter key of the testnet genesis block.
+func testKey(t *testing.T) [gcs.KeySize]byte {
+	hash, err := chainhash.NewHashFromStr("000000000933ea01ad0ee984209779baaec3ced90fa3f408719526f8d77f4943")
+	if err != nil {
+		t.Fatalf("NewHashFromStr: %v", err)
+	}
+
+	var key [gcs.KeySize]byte
+
+	copy(key[:], hash[:gcs.KeySize])
+
+	return key
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("DecodeString: %v", err)
+	}
+
+	return b
+}
+
+// TestSipHash ensures the SipHash function produces the reference test vector
+// of the SipHash paper.
+func TestSipHash(t *testing.T) {
+	data := make([]byte, 15)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	hash := gcs.SipHash(0x0706050403020100, 0x0f0e0d0c0b0a0908, data)
+	if hash != 0xa129ca6149be45e5 {
+		t.Errorf("SipHash: got %x, want a129ca6149be45e5", hash)
+	}
+}
+
+// TestBuildGCSFilter ensures filters are built and serialized as specified in
+// BIP158.
+func TestBuildGCSFilter(t *testing.T) {
+	genesisScript := "4104678afdb0fe5548271967f1a67130b7105cd6a828e03909a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7ba0b8d578a4c702b6bf11d5fac"
+
+	tests := []struct {
+		name  string
+		items []string
+		want  string
+	}{
+		{
+			name:  "empty",
+			items: nil,
+			want:  "00",
+		},
+		{
+			// the basic filter of the testnet genesis block from the BIP158 test vectors
+			name:  "testnet genesis",
+			items: []string{genesisScript},
+			want:  "019dfca8",
+		},
+		{
+			name: "multiple items with duplicate",
+			items: []string{
+				"76a914f1c075a01882ae0972f95d3a4177c86c852b7d9188ac",
+				"51",
+				genesisScript,
+				"a914e8a4e4c0f5a3aa8b91b6dd8e4e96ac4b7b1e6f4f87",
+				"51",
+			},
+			want: "0435ade4b0a3ede9dcffbd00",
+		},
+	}
+
+	key := testKey(t)
+
+	for _, test := range tests {
+		data := make([][]byte, len(test.items))
+		for i, item := range test.items {
+			data[i] = mustDecodeHex(t, item)
+		}
+
+		filter, err := gcs.BuildGCSFilter(testP, testM, key, data)
+		if err != nil {
+			t.Errorf("%s: BuildGCSFilter: %v", test.name, err)
+			continue
+		}
+
+		nBytes, err := filter.NBytes()
+		if err != nil {
+			t.Errorf("%s: NBytes: %v", test.name, err)
+			continue
+		}
+
+		if hex.EncodeToString(nBytes) != test.want {
+			t.Errorf("%s: got filter %x, want %s", test.name, nBytes, test.want)
+		}
+
+		for _, item := range data {
+			match, err := filter.Match(key, item)
+			if err != nil {
+				t.Errorf("%s: Match: %v", test.name, err)
+			}
+
+			if !match {
+				t.Errorf("%s: item %x does not match", test.name, item)
+			}
+		}
+	}
+}
+
+// TestFilterMatch ensures filters deserialized with FromNBytes match the
+// items they were built from, and do not match other items.
+func TestFilterMatch(t *testing.T) {
+	key := testKey(t)
+
+	data := [][]byte{
+		[]byte("Alice"),
+		[]byte("Bob"),
+		[]byte("Charlie"),
+		[]byte("Dick"),
+		[]byte("Ed"),
+		[]byte("Frank"),
+		[]byte("George"),
+		[]byte("Harry"),
+	}
+
+	filter, err := gcs.BuildGCSFilter(testP, testM, key, data)
+	if err != nil {
+		t.Fatalf("BuildGCSFilter: %v", err)
+	}
+
+	if filter.N() != uint32(len(data)) {
+		t.Fatalf("N: got %d, want %d", filter.N(), len(data))
+	}
+
+	if filter.P() != testP {
+		t.Fatalf("P: got %d, want %d", filter.P(), testP)
+	}
+
+	nBytes, err := filter.NBytes()
+	if err != nil {
+		t.Fatalf("NBytes: %v", err)
+	}
+
+	filter2, err := gcs.FromNBytes(testP, testM, nBytes)
+	if err != nil {
+		t.Fatalf("FromNBytes: %v", err)
+	}
+
+	nBytes2, err := filter2.NBytes()
+	if err != nil {
+		t.Fatalf("NBytes: %v", err)
+	}
+
+	if !bytes.Equal(nBytes, nBytes2) {
+		t.Fatalf("deserialized filter %x does not match %x", nBytes2, nBytes)
+	}
+
+	for _, item := range data {
+		match, err := filter2.Match(key, item)
+		if err != nil {
+			t.Fatalf("Match: %v", err)
+		}
+
+		if !match {
+			t.Errorf("item %s does not match", item)
+		}
+	}
+
+	match, err := filter2.Match(key, []byte("Nate"))
+	if err != nil {
+		t.Fatalf("Match: %v", err)
+	}
+
+	if match {
+		t.Errorf("item Nate matches")
+	}
+
+	match, err = filter2.MatchAny(key, [][]byte{[]byte("Nate"), []byte("Quentin"), []byte("Frank")})
+	if err != nil {
+		t.Fatalf("MatchAny: %v", err)
+	}
+
+	if !match {
+		t.Errorf("MatchAny did not match Frank")
+	}
+
+	match, err = filter2.MatchAny(key, [][]byte{[]byte("Nate"), []byte("Quentin")})
+	if err != nil {
+		t.Fatalf("MatchAny: %v", err)
+	}
+
+	if match {
+		t.Errorf("MatchAny matched items that are not in the filter")
+	}
+}
+
+// TestFromNBytesErrors ensures invalid serialized filters are rejected.
+func TestFromNBytesErrors(t *testing.T) {
+	if _, err := gcs.FromNBytes(testP, testM, nil); err != gcs.ErrMisserialized {
+		t.Errorf("FromNBytes(nil): got %v, want %v", err, gcs.ErrMisserialized)
+	}
+
+	if _, err := gcs.FromNBytes(33, testM, []byte{0x00}); err != gcs.ErrPTooBig {
+		t.Errorf("FromNBytes(P=33): got %v, want %v", err, gcs.ErrPTooBig)
+	}
+
+	// a filter claiming more items than it holds
+	filter, err := gcs.FromNBytes(testP, testM, []byte{0x05, 0x9d})
+	if err != nil {
+		t.Fatalf("FromNBytes: %v", err)
+	}
+
+	if _, err = filter.Match(testKey(t), []byte("Alice")); err != gcs.ErrMisserialized {
+		t.Errorf("Match: got %v, want %v", err, gcs.ErrMisserialized)
+	}
+}
@@ -0,0 +1,82 @@
+package gcs
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// The following constants are the initialization vectors of the SipHash
+// algorithm.
+const (
+	sipC0 = 0x736f6d6570736575
+	sipC1 = 0x646f72616e646f6d
+	sipC2 = 0x6c7967656e657261
+	sipC3 = 0x7465646279746573
+)
+
+// SipHash implements the SipHash-2-4 keyed hash function, which is used to
+// map the items of a filter into a uniformly distributed 64-bit range as
+// specified in BIP158.  The 128-bit key is given as two little-endian 64-bit
+// halves.
+func SipHash(k0, k1 uint64, data []byte) uint64 {
+	v0 := k0 ^ sipC0
+	v1 := k1 ^ sipC1
+	v2 := k0 ^ sipC2
+	v3 := k1 ^ sipC3
+
+	round := func() {
+		v0 += v1
+		v1 = bits.RotateLeft64(v1, 13)
+		v1 ^= v0
+		v0 = bits.RotateLeft64(v0, 32)
+		v2 += v3
+		v3 = bits.RotateLeft64(v3, 16)
+		v3 ^= v2
+		v0 += v3
+		v3 = bits.RotateLeft64(v3, 21)
+		v3 ^= v0
+		v2 += v1
+		v1 = bits.RotateLeft64(v1, 17)
+		v1 ^= v2
+		v2 = bits.RotateLeft64(v2, 32)
+	}
+
+	// Compress the data in 8-byte chunks.
+	dataLen := len(data)
+	numBlocks := dataLen / 8
+
+	for i := 0; i < numBlocks; i++ {
+		m := binary.LittleEndian.Uint64(data[i*8:])
+
+		v3 ^= m
+
+		round()
+		round()
+
+		v0 ^= m
+	}
+
+	// Compress the remaining bytes together with the length of the data.
+	last := uint64(dataLen&0xff) << 56
+
+	for i, b := range data[numBlocks*8:] {
+		last |= uint64(b) << (8 * i)
+	}
+
+	v3 ^= last
+
+	round()
+	round()
+
+	v0 ^= last
+
+	// Finalize.
+	v2 ^= 0xff
+
+	round()
+	round()
+	round()
+	round()
+
+	return v0 ^ v1 ^ v2 ^ v3
+}
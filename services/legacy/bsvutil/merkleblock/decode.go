@@ -6,6 +6,8 @@
 package merkleblock
 
 import (
+	"errors"
+
 	"github.com/bsv-blockchain/go-bt/v2/chainhash"
 	"github.com/bsv-blockchain/go-wire"
 )
@@ -24,6 +26,28 @@ import (
 // size variable
 var MaxTxnCount = wire.MaxBlockPayload() / 61
 
+var (
+	// ErrNoTransactions describes an error where a partial merkle tree claims
+	// to commit to a block without any transactions.
+	ErrNoTransactions = errors.New("partial merkle tree has no transactions")
+
+	// ErrTooManyTransactions describes an error where a partial merkle tree
+	// claims more transactions than could fit in a block.
+	ErrTooManyTransactions = errors.New("partial merkle tree has too many transactions")
+
+	// ErrTooManyHashes describes an error where a partial merkle tree contains
+	// more hashes than the number of transactions it commits to.
+	ErrTooManyHashes = errors.New("partial merkle tree has more hashes than transactions")
+
+	// ErrNotEnoughBits describes an error where a partial merkle tree contains
+	// fewer flag bits than hashes.
+	ErrNotEnoughBits = errors.New("partial merkle tree has fewer flag bits than hashes")
+
+	// ErrBadTree describes an error where the partial merkle tree could not be
+	// traversed, or not all of its bits and hashes were consumed.
+	ErrBadTree = errors.New("partial merkle tree is malformed")
+)
+
 // PartialBlock is used to house intermediate information needed to decode a
 // wire.MsgMerkleBlock
 type PartialBlock struct {
@@ -101,3 +125,122 @@ func (m *PartialBlock) BadTree() bool {
 func (m *PartialBlock) calcTreeWidth(height uint64) uint64 {
 	return (m.numTx + (1 << height) - 1) >> height
 }
+
+// ExtractMatches traverses the partial merkle tree, collecting the matched
+// transaction hashes and their positions in the block, and returns the merkle
+// root the tree commits to.  The caller is responsible for comparing the
+// returned root against the merkle root in the block header.
+//
+// source code based off bitcoin c++ code at
+// https://github.com/bitcoin/bitcoin/blob/master/src/merkleblock.cpp
+func (m *PartialBlock) ExtractMatches() (*chainhash.Hash, error) {
+	m.bad = false
+	m.bitsUsed = 0
+	m.hashesUsed = 0
+	m.matchedHashes = make([]*chainhash.Hash, 0)
+	m.matchedItems = make([]uint64, 0)
+
+	// an empty set will not work
+	if m.numTx == 0 {
+		return nil, ErrNoTransactions
+	}
+
+	// check for excessively high numbers of transactions
+	if m.numTx > uint64(MaxTxnCount) {
+		return nil, ErrTooManyTransactions
+	}
+
+	// there can never be more hashes provided than one for every txid
+	if uint64(len(m.finalHashes)) > m.numTx {
+		return nil, ErrTooManyHashes
+	}
+
+	// there must be at least one bit per node in the partial tree, and at
+	// least one node per hash
+	if len(m.bits) < len(m.finalHashes) {
+		return nil, ErrNotEnoughBits
+	}
+
+	// calculate height of tree
+	height := uint64(0)
+	for m.calcTreeWidth(height) > 1 {
+		height++
+	}
+
+	// traverse the partial tree
+	merkleRoot := m.traverseAndExtract(height, 0)
+	if m.bad {
+		m.matchedHashes = m.matchedHashes[:0]
+		m.matchedItems = m.matchedItems[:0]
+
+		return nil, ErrBadTree
+	}
+
+	// verify that all bits were consumed (except for the padding caused by
+	// serializing it as a byte sequence) and that all hashes were consumed
+	if (int(m.bitsUsed)+7)/8 != len(m.bits)/8 || int(m.hashesUsed) != len(m.finalHashes) {
+		m.bad = true
+		m.matchedHashes = m.matchedHashes[:0]
+		m.matchedItems = m.matchedItems[:0]
+
+		return nil, ErrBadTree
+	}
+
+	return merkleRoot, nil
+}
+
+// traverseAndExtract recursively extracts the matched hashes from the partial
+// merkle tree, consuming bits and hashes in depth-first order, and returns the
+// hash of the node at the given height and position.
+func (m *PartialBlock) traverseAndExtract(height, pos uint64) *chainhash.Hash {
+	if int(m.bitsUsed) >= len(m.bits) {
+		// overflowed the bits array - failure
+		m.bad = true
+		return &chainhash.Hash{}
+	}
+
+	parentOfMatch := m.bits[m.bitsUsed] != 0
+	m.bitsUsed++
+
+	if height == 0 || !parentOfMatch {
+		// if at height 0, or nothing interesting below, use stored hash and
+		// do not descend
+		if int(m.hashesUsed) >= len(m.finalHashes) {
+			// overflowed the hash array - failure
+			m.bad = true
+			return &chainhash.Hash{}
+		}
+
+		hash := m.finalHashes[m.hashesUsed]
+		m.hashesUsed++
+
+		// in case of height 0, we have a matched txid
+		if height == 0 && parentOfMatch {
+			m.matchedHashes = append(m.matchedHashes, hash)
+			m.matchedItems = append(m.matchedItems, pos)
+		}
+
+		return hash
+	}
+
+	// otherwise, descend into the subtrees to extract matched txids and
+	// hashes
+	left := m.traverseAndExtract(height-1, pos*2)
+
+	var right *chainhash.Hash
+
+	if pos*2+1 < m.calcTreeWidth(height-1) {
+		right = m.traverseAndExtract(height-1, pos*2+1)
+		if right.IsEqual(left) {
+			// the left and right branches should never be identical, as
+			// the transaction hashes covered by them must each be unique
+			// (CVE-2012-2459)
+			m.bad = true
+		}
+	} else {
+		right = left
+	}
+
+	// and combine them before returning
+	return hashMerkleBranches(left, right)
+}
@@ -5,7 +5,14 @@
 package merkleblock_test
 
 import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
 	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/bsv-blockchain/go-wire"
+	"github.com/bsv-blockchain/teranode/services/legacy/bsvutil/merkleblock"
 )
 
 // hashFromStr provides function to wrap the primary function without
@@ -20,3 +27,128 @@ func hashFromStr(s string) *chainhash.Hash {
 
 	return hash
 }
+
+// TestExtractMatches tests decoding a merkle proof and extracting the matched
+// transactions and merkle root from the partial merkle tree
+func TestExtractMatches(t *testing.T) {
+	tests := []struct {
+		name   string
+		proof  string
+		txnIds []*chainhash.Hash
+		items  []uint64
+	}{
+		{
+			name:  "Extract 2 transaction proof for Testnet block 1253848",
+			proof: "00000020c2981857b4516c746e24199820dd2309818a058ce5371a27be0000000000000091bfb84d0fce1e261d97c86d84c44ff66aab3a610e68ac1cf09873159ed1ce9a56ae825b1013041a21aebff3060000000411303ed124cfa04609d6728e78ae56ac913a166da35ae1338d268a05004f82741d7140b611624ccfe154a118b9c95b6e665e844a27b55872241f1b47e191c5bf4846033a92b7038518db97dc6a522c7f386084c15109001413daf6ceb4ba4d8fea3c13cb09d08cfa3e43a6a40d3d01602835093c48d414bcce0777267b8190fc013b",
+			txnIds: []*chainhash.Hash{
+				hashFromStr("bfc591e1471b1f247258b5274a845e666e5bc9b918a154e1cf4c6211b640711d"),
+				hashFromStr("8f4dbab4cef6da1314000951c18460387f2c526adc97db188503b7923a034648"),
+			},
+			items: []uint64{2, 3},
+		},
+		{
+			name:  "Extract 1 transaction proof for Testnet block 1268825",
+			proof: "00e0002075dbd04988a32f7fe6346a7908f04d0aca6f3cad22c6c138fe020000000000000679e44eddbcd820b33c4a287f66df403163576c02c05be6bb509aaca224dfc68ff7f25be142031ad3c64058020000000269430520d964b07d7ee8724f8a7c2fe391c38cef14d0c5c9ad5030f9bb04c4bc6b3108681e3c77832bdc6434e87fc3c7ebfe985deef18e132b3c3a75f99403650105",
+			txnIds: []*chainhash.Hash{
+				hashFromStr("650394f9753a3c2b138ef1ee5d98feebc7c37fe83464dc2b83773c1e6808316b"),
+			},
+			items: []uint64{1},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			proofBytes, err := hex.DecodeString(tt.proof)
+			if err != nil {
+				t.Fatalf("proof DecodeString failed: %v", err)
+			}
+
+			var msg wire.MsgMerkleBlock
+			if err = msg.Bsvdecode(bytes.NewReader(proofBytes), wire.ProtocolVersion, wire.LatestEncoding); err != nil {
+				t.Fatalf("msg Bsvdecode failed: %v", err)
+			}
+
+			pBlock := merkleblock.NewMerkleBlockFromMsg(msg)
+
+			merkleRoot, err := pBlock.ExtractMatches()
+			if err != nil {
+				t.Fatalf("ExtractMatches failed: %v", err)
+			}
+
+			if !merkleRoot.IsEqual(&msg.Header.MerkleRoot) {
+				t.Errorf("merkle root mismatch, got %v wanted %v", merkleRoot, msg.Header.MerkleRoot)
+			}
+
+			if pBlock.BadTree() {
+				t.Errorf("unexpected bad tree")
+			}
+
+			matches := pBlock.GetMatches()
+			if len(matches) != len(tt.txnIds) {
+				t.Fatalf("matches mismatch, got %d wanted %d", len(matches), len(tt.txnIds))
+			}
+
+			for i, match := range matches {
+				if !match.IsEqual(tt.txnIds[i]) {
+					t.Errorf("match %d mismatch, got %v wanted %v", i, match, tt.txnIds[i])
+				}
+			}
+
+			items := pBlock.GetItems()
+			for i, item := range items {
+				if item != tt.items[i] {
+					t.Errorf("item %d mismatch, got %d wanted %d", i, item, tt.items[i])
+				}
+			}
+		})
+	}
+}
+
+// TestExtractMatchesBadTree tests that malformed partial merkle trees are rejected
+func TestExtractMatchesBadTree(t *testing.T) {
+	hash := hashFromStr("650394f9753a3c2b138ef1ee5d98feebc7c37fe83464dc2b83773c1e6808316b")
+
+	tests := []struct {
+		name string
+		msg  wire.MsgMerkleBlock
+		err  error
+	}{
+		{
+			name: "no transactions",
+			msg:  wire.MsgMerkleBlock{Transactions: 0, Hashes: []*chainhash.Hash{hash}, Flags: []byte{0x01}},
+			err:  merkleblock.ErrNoTransactions,
+		},
+		{
+			name: "more hashes than transactions",
+			msg:  wire.MsgMerkleBlock{Transactions: 1, Hashes: []*chainhash.Hash{hash, hash}, Flags: []byte{0x03}},
+			err:  merkleblock.ErrTooManyHashes,
+		},
+		{
+			name: "missing hashes",
+			msg:  wire.MsgMerkleBlock{Transactions: 2, Hashes: []*chainhash.Hash{hash}, Flags: []byte{0x07}},
+			err:  merkleblock.ErrBadTree,
+		},
+		{
+			name: "duplicated branches",
+			msg:  wire.MsgMerkleBlock{Transactions: 2, Hashes: []*chainhash.Hash{hash, hash}, Flags: []byte{0x07}},
+			err:  merkleblock.ErrBadTree,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt // capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pBlock := merkleblock.NewMerkleBlockFromMsg(tt.msg)
+
+			_, err := pBlock.ExtractMatches()
+			if !errors.Is(err, tt.err) {
+				t.Errorf("error mismatch, got %v wanted %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -108,34 +108,45 @@ func TxInSet(tx *chainhash.Hash, set []*chainhash.Hash) bool {
 // NewMerkleBlockWithTxnSet returns a new *wire.MsgMerkleBlock containing a
 // partial merkle tree built using the list of transactions provided
 func NewMerkleBlockWithTxnSet(block *bsvutil.Block, txnSet []*chainhash.Hash) (*wire.MsgMerkleBlock, []uint32) {
-	numTx := uint32(len(block.Transactions()))
+	txHashes := make([]*chainhash.Hash, 0, len(block.Transactions()))
+
+	for _, tx := range block.Transactions() {
+		txHashes = append(txHashes, tx.Hash())
+	}
+
+	return NewMerkleBlockWithTxHashes(&block.MsgBlock().Header, txHashes, txnSet)
+}
+
+// NewMerkleBlockWithTxHashes returns a new *wire.MsgMerkleBlock containing a
+// partial merkle tree built using the list of transactions provided. Unlike
+// NewMerkleBlockWithTxnSet, it only needs the block header and the ordered
+// list of all transaction hashes in the block, not the full transactions.
+func NewMerkleBlockWithTxHashes(header *wire.BlockHeader, txHashes []*chainhash.Hash, txnSet []*chainhash.Hash) (*wire.MsgMerkleBlock, []uint32) {
+	numTx := uint32(len(txHashes)) //nolint:gosec
 	mBlock := MerkleBlock{
 		numTx:       numTx,
-		allHashes:   make([]*chainhash.Hash, 0, numTx),
+		allHashes:   txHashes,
 		matchedBits: make([]byte, 0, numTx),
 	}
 
-	// add all block transactions to merkle block and set bits for matching
-	// transactions
+	// set bits for matching transactions
 	var matchedIndices []uint32
 
-	for txIndex, tx := range block.Transactions() {
-		if TxInSet(tx.Hash(), txnSet) {
+	for txIndex, txHash := range txHashes {
+		if TxInSet(txHash, txnSet) {
 			mBlock.matchedBits = append(mBlock.matchedBits, 0x01)
-			matchedIndices = append(matchedIndices, uint32(txIndex))
+			matchedIndices = append(matchedIndices, uint32(txIndex)) //nolint:gosec
 		} else {
 			mBlock.matchedBits = append(mBlock.matchedBits, 0x00)
 		}
-
-		mBlock.allHashes = append(mBlock.allHashes, tx.Hash())
 	}
 
-	return mBlock.calcBlock(block), matchedIndices
+	return mBlock.calcBlock(header), matchedIndices
 }
 
 // calcBlock calculates the merkleBlock when created from either a TxnSet or
 // by a bloom.Filter
-func (m *MerkleBlock) calcBlock(block *bsvutil.Block) *wire.MsgMerkleBlock {
+func (m *MerkleBlock) calcBlock(header *wire.BlockHeader) *wire.MsgMerkleBlock {
 	// Calculate the number of merkle branches (height) in the tree.
 	height := uint32(0)
 	for m.calcTreeWidth(height) > 1 {
@@ -147,7 +158,7 @@ func (m *MerkleBlock) calcBlock(block *bsvutil.Block) *wire.MsgMerkleBlock {
 
 	// Create and return the merkle block.
 	msgMerkleBlock := wire.MsgMerkleBlock{
-		Header:       block.MsgBlock().Header,
+		Header:       *header,
 		Transactions: m.numTx,
 		Hashes:       make([]*chainhash.Hash, 0, len(m.finalHashes)),
 		Flags:        make([]byte, (len(m.bits)+7)/8),
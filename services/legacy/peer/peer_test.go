@@ -1179,5 +1179,5 @@ func NewTestServer(t *testing.T) (*legacy.Server, error) {
 	utxoStore, err := sql.New(ctx, logger, tSettings, utxoStoreURL)
 	require.NoError(t, err)
 
-	return legacy.New(logger, tSettings, blockchainClient, nil, memStore, memStore, utxoStore, nil, nil, nil), nil
+	return legacy.New(logger, tSettings, blockchainClient, nil, memStore, memStore, utxoStore, nil, nil, nil, nil), nil
 }
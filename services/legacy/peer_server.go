@@ -19,6 +19,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -31,10 +32,12 @@ import (
 	safeconversion "github.com/bsv-blockchain/go-safe-conversion"
 	txmap "github.com/bsv-blockchain/go-tx-map"
 	"github.com/bsv-blockchain/go-wire"
+	"github.com/bsv-blockchain/teranode/model"
 	"github.com/bsv-blockchain/teranode/pkg/fileformat"
 	"github.com/bsv-blockchain/teranode/services/blockassembly"
 	"github.com/bsv-blockchain/teranode/services/blockchain"
 	"github.com/bsv-blockchain/teranode/services/blockvalidation"
+	"github.com/bsv-blockchain/teranode/services/filterindex"
 	"github.com/bsv-blockchain/teranode/services/legacy/addrmgr"
 	blockchain2 "github.com/bsv-blockchain/teranode/services/legacy/blockchain"
 	"github.com/bsv-blockchain/teranode/services/legacy/bsvutil"
@@ -267,6 +270,13 @@ type cfHeaderKV struct {
 	filterHeader chainhash.Hash
 }
 
+// cfCheckptBlock is a block at a cfcheckpt interval of the best chain. The struct
+// is used to cache the blocks the cfcheckpt responses are built for.
+type cfCheckptBlock struct {
+	blockHash chainhash.Hash
+	blockID   uint32
+}
+
 // server provides a bitcoin server for handling communications to and from
 // bitcoin peers.
 type server struct {
@@ -307,6 +317,15 @@ type server struct {
 	cfCheckptCaches    map[wire.FilterType][]cfHeaderKV
 	cfCheckptCachesMtx sync.RWMutex
 
+	// cfCheckptBlocks caches the blocks of the best chain at every
+	// cfcheckpt interval, so they are only looked up once.
+	cfCheckptBlocks    []cfCheckptBlock
+	cfCheckptBlocksMtx sync.Mutex
+
+	// cfIndexCurrent is set once the filter index has caught up with the
+	// best chain, until then the compact filter service is not advertised.
+	cfIndexCurrent atomic.Bool
+
 	// teranode additions
 	logger            ulogger.Logger
 	blockchainClient  blockchain.ClientI
@@ -318,6 +337,7 @@ type server struct {
 	blockValidation   blockvalidation.Interface
 	blockAssembly     *blockassembly.Client
 	assetHTTPAddress  string
+	blockStore        blob.Store
 	banList           *p2p.BanList
 	banChan           chan p2p.BanEvent
 }
@@ -978,19 +998,322 @@ func (sp *serverPeer) OnGetHeaders(_ *peer.Peer, msg *wire.MsgGetHeaders) {
 	}
 }
 
+// cfiltersEnabled returns whether compact block filters are served to peers.
+func (s *server) cfiltersEnabled() bool {
+	return s.settings.FilterIndex.Enabled && s.blockStore != nil
+}
+
+// cfilterRangeHeaders returns the headers of the blocks from the given start height up to and
+// including the block with the given stop hash, in order of height.  An error is returned when
+// the stop block is unknown, when the start height is above the height of the stop block, or
+// when the range holds more than maxResults blocks.
+func (s *server) cfilterRangeHeaders(ctx context.Context, startHeight uint32, stopHash *chainhash.Hash,
+	maxResults uint32) ([]*model.BlockHeader, error) {
+	_, stopMeta, err := s.blockchainClient.GetBlockHeader(ctx, stopHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if startHeight > stopMeta.Height {
+		return nil, fmt.Errorf("start height %d is above the height %d of stop hash %s", startHeight, stopMeta.Height, stopHash)
+	}
+
+	count := stopMeta.Height - startHeight + 1
+	if count > maxResults {
+		return nil, fmt.Errorf("range of %d blocks exceeds the maximum of %d", count, maxResults)
+	}
+
+	// the headers are returned from the stop block backwards
+	headers, _, err := s.blockchainClient.GetBlockHeaders(ctx, stopHash, uint64(count))
+	if err != nil {
+		return nil, err
+	}
+
+	if uint32(len(headers)) != count {
+		return nil, fmt.Errorf("expected %d headers, got %d", count, len(headers))
+	}
+
+	slices.Reverse(headers)
+
+	return headers, nil
+}
+
 // OnGetCFilters is invoked when a peer receives a getcfilters bitcoin message.
 func (sp *serverPeer) OnGetCFilters(_ *peer.Peer, msg *wire.MsgGetCFilters) {
-	sp.DisconnectWithWarning("Ignoring getcfilters request from peer")
+	_, _, deferFn := tracing.Tracer("legacy").Start(sp.ctx, "serverPeer.OnGetCFilters",
+		tracing.WithHistogram(peerServerMetrics["OnGetCFilters"]),
+	)
+	defer deferFn()
+
+	if !sp.server.cfiltersEnabled() {
+		sp.DisconnectWithWarning("Ignoring getcfilters request from peer")
+		return
+	}
+
+	// Ignore getcfilters requests if not in sync.
+	if !sp.server.syncManager.IsCurrent() {
+		return
+	}
+
+	// We only serve the basic filter type.
+	if msg.FilterType != wire.GCSFilterRegular {
+		sp.server.logger.Debugf("Filter request for unknown filter type %d from %s", msg.FilterType, sp)
+		return
+	}
+
+	headers, err := sp.server.cfilterRangeHeaders(sp.ctx, msg.StartHeight, &msg.StopHash, wire.MaxGetCFiltersReqRange)
+	if err != nil {
+		sp.server.logger.Debugf("Invalid getcfilters request from %s: %v", sp, err)
+		return
+	}
+
+	for _, header := range headers {
+		blockFilter, err := filterindex.GetBlockFilter(sp.ctx, sp.server.blockStore, header.Hash())
+		if err != nil {
+			sp.server.logger.Warnf("Could not obtain cfilter for %s: %v", header.Hash(), err)
+			return
+		}
+
+		sp.QueueMessage(wire.NewMsgCFilter(msg.FilterType, header.Hash(), blockFilter.Filter), nil)
+	}
 }
 
 // OnGetCFHeaders is invoked when a peer receives a getcfheader bitcoin message.
 func (sp *serverPeer) OnGetCFHeaders(_ *peer.Peer, msg *wire.MsgGetCFHeaders) {
-	sp.DisconnectWithWarning("Ignoring getcfheaders request from peer")
+	_, _, deferFn := tracing.Tracer("legacy").Start(sp.ctx, "serverPeer.OnGetCFHeaders",
+		tracing.WithHistogram(peerServerMetrics["OnGetCFHeaders"]),
+	)
+	defer deferFn()
+
+	if !sp.server.cfiltersEnabled() {
+		sp.DisconnectWithWarning("Ignoring getcfheaders request from peer")
+		return
+	}
+
+	// Ignore getcfheaders requests if not in sync.
+	if !sp.server.syncManager.IsCurrent() {
+		return
+	}
+
+	// We only serve the basic filter type.
+	if msg.FilterType != wire.GCSFilterRegular {
+		sp.server.logger.Debugf("Filter header request for unknown filter type %d from %s", msg.FilterType, sp)
+		return
+	}
+
+	headers, err := sp.server.cfilterRangeHeaders(sp.ctx, msg.StartHeight, &msg.StopHash, wire.MaxCFHeadersPerMsg)
+	if err != nil {
+		sp.server.logger.Debugf("Invalid getcfheaders request from %s: %v", sp, err)
+		return
+	}
+
+	headersMsg := wire.NewMsgCFHeaders()
+	headersMsg.FilterType = msg.FilterType
+	headersMsg.StopHash = msg.StopHash
+
+	// The filter header of the block before the start block is the starting point for the
+	// peer to verify the filter hashes against.  The genesis block has no previous header.
+	if msg.StartHeight > 0 {
+		prevFilter, err := filterindex.GetBlockFilter(sp.ctx, sp.server.blockStore, headers[0].HashPrevBlock)
+		if err != nil {
+			sp.server.logger.Warnf("Could not obtain cfilter header for %s: %v", headers[0].HashPrevBlock, err)
+			return
+		}
+
+		headersMsg.PrevFilterHeader = prevFilter.Header
+	}
+
+	for _, header := range headers {
+		blockFilter, err := filterindex.GetBlockFilter(sp.ctx, sp.server.blockStore, header.Hash())
+		if err != nil {
+			sp.server.logger.Warnf("Could not obtain cfilter for %s: %v", header.Hash(), err)
+			return
+		}
+
+		filterHash := blockFilter.FilterHash()
+
+		if err = headersMsg.AddCFHash(&filterHash); err != nil {
+			sp.server.logger.Warnf("Failed to add cfilter hash for %s: %v", header.Hash(), err)
+			return
+		}
+	}
+
+	sp.QueueMessage(headersMsg, nil)
 }
 
 // OnGetCFCheckpt is invoked when a peer receives a getcfcheckpt bitcoin message.
 func (sp *serverPeer) OnGetCFCheckpt(_ *peer.Peer, msg *wire.MsgGetCFCheckpt) {
-	sp.DisconnectWithWarning("Ignoring getcfcheckpt request from peer")
+	_, _, deferFn := tracing.Tracer("legacy").Start(sp.ctx, "serverPeer.OnGetCFCheckpt",
+		tracing.WithHistogram(peerServerMetrics["OnGetCFCheckpt"]),
+	)
+	defer deferFn()
+
+	if !sp.server.cfiltersEnabled() {
+		sp.DisconnectWithWarning("Ignoring getcfcheckpt request from peer")
+		return
+	}
+
+	// Ignore getcfcheckpt requests if not in sync.
+	if !sp.server.syncManager.IsCurrent() {
+		return
+	}
+
+	// We only serve the basic filter type.
+	if msg.FilterType != wire.GCSFilterRegular {
+		sp.server.logger.Debugf("Filter checkpoint request for unknown filter type %d from %s", msg.FilterType, sp)
+		return
+	}
+
+	blockHashes, err := sp.server.cfCheckptBlockHashes(sp.ctx, &msg.StopHash)
+	if err != nil {
+		sp.server.logger.Debugf("Invalid getcfcheckpt request from %s: %v", sp, err)
+		return
+	}
+
+	// Take a copy of the cached checkpoints, so the lock is not held while the filters are read.
+	sp.server.cfCheckptCachesMtx.RLock()
+	checkptCache := slices.Clone(sp.server.cfCheckptCaches[msg.FilterType])
+	sp.server.cfCheckptCachesMtx.RUnlock()
+
+	checkptMsg := wire.NewMsgCFCheckpt(msg.FilterType, &msg.StopHash, len(blockHashes))
+	updateCache := false
+
+	for i, blockHash := range blockHashes {
+		// Use the cached filter header if the cache holds the same block at this checkpoint,
+		// the cache entries of blocks that are no longer in the best chain are replaced.
+		if i < len(checkptCache) && checkptCache[i].blockHash.IsEqual(blockHash) {
+			filterHeader := checkptCache[i].filterHeader
+
+			if err = checkptMsg.AddCFHeader(&filterHeader); err != nil {
+				sp.server.logger.Warnf("Failed to add cfilter header for %s: %v", blockHash, err)
+				return
+			}
+
+			continue
+		}
+
+		blockFilter, err := filterindex.GetBlockFilter(sp.ctx, sp.server.blockStore, blockHash)
+		if err != nil {
+			sp.server.logger.Warnf("Could not obtain cfilter header for %s: %v", blockHash, err)
+			return
+		}
+
+		if err = checkptMsg.AddCFHeader(&blockFilter.Header); err != nil {
+			sp.server.logger.Warnf("Failed to add cfilter header for %s: %v", blockHash, err)
+			return
+		}
+
+		kv := cfHeaderKV{blockHash: *blockHash, filterHeader: blockFilter.Header}
+		if i < len(checkptCache) {
+			checkptCache[i] = kv
+		} else {
+			checkptCache = append(checkptCache, kv)
+		}
+
+		updateCache = true
+	}
+
+	if updateCache {
+		sp.server.cfCheckptCachesMtx.Lock()
+		sp.server.cfCheckptCaches[msg.FilterType] = checkptCache
+		sp.server.cfCheckptCachesMtx.Unlock()
+	}
+
+	sp.QueueMessage(checkptMsg, nil)
+}
+
+// cfCheckptBlockHashes returns the hashes of the blocks at every wire.CFCheckptInterval blocks
+// up to the block with the given stop hash, which must be in the best chain.
+//
+// The checkpoint blocks are cached, only the checkpoints above the cached ones are looked up.
+// When the chain reorganizes, the cached checkpoints that are no longer in the best chain are
+// dropped. As the checkpoints are ancestors of each other, this only requires checking the
+// last cached checkpoint, which is the one a reorganization affects first.
+func (s *server) cfCheckptBlockHashes(ctx context.Context, stopHash *chainhash.Hash) ([]*chainhash.Hash, error) {
+	_, stopMeta, err := s.blockchainClient.GetBlockHeader(ctx, stopHash)
+	if err != nil {
+		return nil, err
+	}
+
+	inBestChain, err := s.blockchainClient.CheckBlockIsInCurrentChain(ctx, []uint32{stopMeta.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	if !inBestChain {
+		return nil, fmt.Errorf("stop hash %s is not in the best chain", stopHash)
+	}
+
+	count := int(stopMeta.Height / wire.CFCheckptInterval)
+
+	s.cfCheckptBlocksMtx.Lock()
+	defer s.cfCheckptBlocksMtx.Unlock()
+
+	for len(s.cfCheckptBlocks) > 0 {
+		last := s.cfCheckptBlocks[len(s.cfCheckptBlocks)-1]
+
+		inBestChain, err = s.blockchainClient.CheckBlockIsInCurrentChain(ctx, []uint32{last.blockID})
+		if err != nil {
+			return nil, err
+		}
+
+		if inBestChain {
+			break
+		}
+
+		s.cfCheckptBlocks = s.cfCheckptBlocks[:len(s.cfCheckptBlocks)-1]
+	}
+
+	for len(s.cfCheckptBlocks) < count {
+		height := uint32(len(s.cfCheckptBlocks)+1) * wire.CFCheckptInterval
+
+		headers, metas, err := s.blockchainClient.GetBlockHeadersByHeight(ctx, height, height)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(headers) != 1 || len(metas) != 1 {
+			return nil, fmt.Errorf("expected 1 header at height %d, got %d", height, len(headers))
+		}
+
+		s.cfCheckptBlocks = append(s.cfCheckptBlocks, cfCheckptBlock{blockHash: *headers[0].Hash(), blockID: metas[0].ID})
+	}
+
+	blockHashes := make([]*chainhash.Hash, count)
+	for i := range blockHashes {
+		blockHash := s.cfCheckptBlocks[i].blockHash
+		blockHashes[i] = &blockHash
+	}
+
+	return blockHashes, nil
+}
+
+// advertisedServices returns the services advertised to peers. The compact filter service is
+// only advertised once the filter index has caught up with the best chain, as peers expect the
+// filters of all blocks to be served.
+func (s *server) advertisedServices() wire.ServiceFlag {
+	if s.services&wire.SFNodeCF != wire.SFNodeCF || s.cfIndexCurrent.Load() {
+		return s.services
+	}
+
+	bestBlockHeader, _, err := s.blockchainClient.GetBestBlockHeader(s.ctx)
+	if err == nil {
+		var exists bool
+
+		exists, err = s.blockStore.Exists(s.ctx, bestBlockHeader.Hash()[:], fileformat.FileTypeCFilter)
+		if err == nil && exists {
+			s.logger.Infof("Filter index has caught up with the best chain, advertising compact filter service")
+			s.cfIndexCurrent.Store(true)
+
+			return s.services
+		}
+	}
+
+	if err != nil {
+		s.logger.Warnf("Failed to check the filter index is current: %v", err)
+	}
+
+	return s.services &^ wire.SFNodeCF
 }
 
 // enforceNodeBloomFlag disconnects the peer if the server is not configured to
@@ -2081,13 +2404,13 @@ func newPeerConfig(sp *serverPeer) *peer.Config {
 			OnGetData:      sp.OnGetData,
 			OnGetBlocks:    sp.OnGetBlocks,
 			OnGetHeaders:   sp.OnGetHeaders,
-			OnGetCFilters:  sp.OnGetCFilters,  // not implemented, just logs a warning
-			OnGetCFHeaders: sp.OnGetCFHeaders, // not implemented, just logs a warning
-			OnGetCFCheckpt: sp.OnGetCFCheckpt, // not implemented, just logs a warning
-			OnFeeFilter:    sp.OnFeeFilter,    // being set, but not being enforced, could cause peer to disconnect
-			OnFilterAdd:    sp.OnFilterAdd,    // not implemented, just logs a warning
-			OnFilterClear:  sp.OnFilterClear,  // not implemented, just logs a warning
-			OnFilterLoad:   sp.OnFilterLoad,   // not implemented, just logs a warning
+			OnGetCFilters:  sp.OnGetCFilters,
+			OnGetCFHeaders: sp.OnGetCFHeaders,
+			OnGetCFCheckpt: sp.OnGetCFCheckpt,
+			OnFeeFilter:    sp.OnFeeFilter,   // being set, but not being enforced, could cause peer to disconnect
+			OnFilterAdd:    sp.OnFilterAdd,   // not implemented, just logs a warning
+			OnFilterClear:  sp.OnFilterClear, // not implemented, just logs a warning
+			OnFilterLoad:   sp.OnFilterLoad,  // not implemented, just logs a warning
 			OnGetAddr:      sp.OnGetAddr,
 			OnAddr:         sp.OnAddr,
 			OnRead:         sp.OnRead,
@@ -2103,7 +2426,7 @@ func newPeerConfig(sp *serverPeer) *peer.Config {
 		UserAgentVersion:  userAgentVersion,
 		UserAgentComments: cfg.UserAgentComments,
 		ChainParams:       sp.server.settings.ChainCfgParams,
-		Services:          sp.server.services,
+		Services:          sp.server.advertisedServices(),
 		DisableRelayTx:    cfg.BlocksOnly,
 		ProtocolVersion:   peer.MaxProtocolVersion,
 		TrickleInterval:   cfg.TrickleInterval,
@@ -2657,7 +2980,7 @@ out:
 func newServer(ctx context.Context, logger ulogger.Logger, tSettings *settings.Settings, config Config, blockchainClient blockchain.ClientI,
 	validationClient validator.Interface, utxoStore utxostore.Store, subtreeStore blob.Store, tempStore blob.Store,
 	subtreeValidation subtreevalidation.Interface, blockValidation blockvalidation.Interface,
-	blockAssembly *blockassembly.Client,
+	blockAssembly *blockassembly.Client, blockStore blob.Store,
 	listenAddrs []string, assetHTTPAddress string) (*server, error) {
 	// init config
 	c, _, err := loadConfig(logger)
@@ -2709,7 +3032,9 @@ func newServer(ctx context.Context, logger ulogger.Logger, tSettings *settings.S
 	// cfg.NoPeerBloomFilters
 	services &^= wire.SFNodeBloom
 	// cfg.NoCFilters
-	services &^= wire.SFNodeCF
+	if !tSettings.FilterIndex.Enabled || blockStore == nil {
+		services &^= wire.SFNodeCF
+	}
 
 	// Determine node type (full vs pruned) based on block persister status
 	// This uses the same logic as the P2P service to ensure consistent advertising
@@ -2813,6 +3138,7 @@ func newServer(ctx context.Context, logger ulogger.Logger, tSettings *settings.S
 		blockValidation:   blockValidation,
 		blockAssembly:     blockAssembly,
 		assetHTTPAddress:  assetHTTPAddress,
+		blockStore:        blockStore,
 		banList:           banList,
 		banChan:           banChan,
 	}
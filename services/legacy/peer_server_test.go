@@ -1,16 +1,23 @@
 package legacy
 
 import (
+	"context"
 	"net"
 	"testing"
 
 	"github.com/bsv-blockchain/go-bt/v2/chainhash"
 	"github.com/bsv-blockchain/go-chaincfg"
 	"github.com/bsv-blockchain/go-wire"
+	"github.com/bsv-blockchain/teranode/model"
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+	"github.com/bsv-blockchain/teranode/services/blockchain"
 	"github.com/bsv-blockchain/teranode/services/legacy/addrmgr"
 	"github.com/bsv-blockchain/teranode/services/legacy/netsync"
+	"github.com/bsv-blockchain/teranode/stores/blob/memory"
+	"github.com/bsv-blockchain/teranode/ulogger"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // TestAddKnownAddresses tests that the addKnownAddresses function properly adds
@@ -753,3 +760,77 @@ func TestMergeCheckpointsFunc(t *testing.T) {
 	assert.Equal(t, int32(300), merged[2].Height)
 	assert.Equal(t, int32(400), merged[3].Height)
 }
+
+// TestCFCheckptBlockHashes tests the checkpoint blocks are looked up once, and looked up again
+// when they are no longer in the best chain
+func TestCFCheckptBlockHashes(t *testing.T) {
+	ctx := context.Background()
+	stopHash := &chainhash.Hash{9}
+
+	checkptHeader := func(nonce uint32) *model.BlockHeader {
+		return &model.BlockHeader{HashPrevBlock: &chainhash.Hash{}, HashMerkleRoot: &chainhash.Hash{}, Nonce: nonce}
+	}
+
+	mockBlockchainClient := &blockchain.Mock{}
+	mockBlockchainClient.On("GetBlockHeader", mock.Anything, stopHash).Return(checkptHeader(0), &model.BlockHeaderMeta{ID: 100, Height: 2500}, nil)
+	mockBlockchainClient.On("CheckBlockIsInCurrentChain", mock.Anything, []uint32{100}).Return(true, nil)
+	mockBlockchainClient.On("CheckBlockIsInCurrentChain", mock.Anything, []uint32{1}).Return(true, nil)
+	mockBlockchainClient.On("GetBlockHeadersByHeight", mock.Anything, uint32(1000), uint32(1000)).
+		Return([]*model.BlockHeader{checkptHeader(1)}, []*model.BlockHeaderMeta{{ID: 1, Height: 1000}}, nil).Once()
+	mockBlockchainClient.On("GetBlockHeadersByHeight", mock.Anything, uint32(2000), uint32(2000)).
+		Return([]*model.BlockHeader{checkptHeader(2)}, []*model.BlockHeaderMeta{{ID: 2, Height: 2000}}, nil).Once()
+
+	s := &server{blockchainClient: mockBlockchainClient}
+
+	// the second checkpoint is on the best chain for the second request, but no longer for the third
+	mockBlockchainClient.On("CheckBlockIsInCurrentChain", mock.Anything, []uint32{2}).Return(true, nil).Once()
+	mockBlockchainClient.On("CheckBlockIsInCurrentChain", mock.Anything, []uint32{2}).Return(false, nil).Once()
+
+	for i := 0; i < 2; i++ {
+		blockHashes, err := s.cfCheckptBlockHashes(ctx, stopHash)
+		require.NoError(t, err)
+		require.Len(t, blockHashes, 2)
+		assert.Equal(t, checkptHeader(1).Hash(), blockHashes[0])
+		assert.Equal(t, checkptHeader(2).Hash(), blockHashes[1])
+	}
+
+	mockBlockchainClient.On("GetBlockHeadersByHeight", mock.Anything, uint32(2000), uint32(2000)).
+		Return([]*model.BlockHeader{checkptHeader(3)}, []*model.BlockHeaderMeta{{ID: 3, Height: 2000}}, nil).Once()
+
+	blockHashes, err := s.cfCheckptBlockHashes(ctx, stopHash)
+	require.NoError(t, err)
+	require.Len(t, blockHashes, 2)
+	assert.Equal(t, checkptHeader(3).Hash(), blockHashes[1])
+
+	mockBlockchainClient.AssertExpectations(t)
+}
+
+// TestAdvertisedServices tests the compact filter service is only advertised once the filter
+// index has caught up with the best chain
+func TestAdvertisedServices(t *testing.T) {
+	bestHeader := &model.BlockHeader{HashPrevBlock: &chainhash.Hash{}, HashMerkleRoot: &chainhash.Hash{}}
+
+	mockBlockchainClient := &blockchain.Mock{}
+	mockBlockchainClient.On("GetBestBlockHeader", mock.Anything).Return(bestHeader, &model.BlockHeaderMeta{Height: 10}, nil)
+
+	blockStore := memory.New()
+
+	s := &server{
+		ctx:              context.Background(),
+		logger:           ulogger.TestLogger{},
+		services:         wire.SFNodeNetwork | wire.SFNodeCF,
+		blockchainClient: mockBlockchainClient,
+		blockStore:       blockStore,
+	}
+
+	assert.Equal(t, wire.SFNodeNetwork, s.advertisedServices())
+
+	require.NoError(t, blockStore.Set(context.Background(), bestHeader.Hash()[:], fileformat.FileTypeCFilter, []byte("filter")))
+
+	assert.Equal(t, wire.SFNodeNetwork|wire.SFNodeCF, s.advertisedServices())
+
+	// once the index has caught up, the service stays advertised
+	require.NoError(t, blockStore.Del(context.Background(), bestHeader.Hash()[:], fileformat.FileTypeCFilter))
+
+	assert.Equal(t, wire.SFNodeNetwork|wire.SFNodeCF, s.advertisedServices())
+}
@@ -39,6 +39,7 @@ import (
 	"github.com/bsv-blockchain/teranode/services/validator"
 	"github.com/bsv-blockchain/teranode/settings"
 	"github.com/bsv-blockchain/teranode/stores/blob"
+	"github.com/bsv-blockchain/teranode/stores/blob/quota"
 	"github.com/bsv-blockchain/teranode/ulogger"
 	"github.com/bsv-blockchain/teranode/util"
 	"github.com/bsv-blockchain/teranode/util/health"
@@ -482,6 +483,10 @@ func (ps *PropagationServer) handleSingleTx(_ context.Context) echo.HandlerFunc
 		// Process the transaction and return appropriate response
 		err = ps.processTransaction(ctx, &propagation_api.ProcessTransactionRequest{Tx: body})
 		if err != nil {
+			if errors.Is(err, errors.ErrServiceUnavailable) {
+				return c.String(http.StatusServiceUnavailable, "Failed to process transaction: "+err.Error())
+			}
+
 			return c.String(http.StatusInternalServerError, "Failed to process transaction: "+err.Error())
 		}
 
@@ -922,6 +927,12 @@ func (ps *PropagationServer) processTransactionInternal(ctx context.Context, btT
 		return err
 	}
 
+	// reject new transactions before the disk of a blob store is full, instead of failing to store them
+	if err = quota.CheckPressure(); err != nil {
+		prometheusDiskPressureRejections.Inc()
+		return errors.NewServiceUnavailableError("[ProcessTransaction][%s] rejecting transaction under disk pressure", btTx.TxID(), err)
+	}
+
 	// // decouple the tracing context to not cancel the context when the tx is being saved in the background
 	// decoupledCtx, decoupledSpan, decoupledEndSpan := tracing.DecoupleTracingSpan(ctx, "processTransactionInternal", "decoupled")
 	// defer decoupledEndSpan()
@@ -43,6 +43,7 @@ var (
 	prometheusProcessedHandleMultipleTx prometheus.Histogram
 	prometheusTransactionSize           prometheus.Histogram
 	prometheusInvalidTransactions       prometheus.Counter
+	prometheusDiskPressureRejections    prometheus.Counter
 )
 
 // Synchronization primitive for ensuring metrics are initialized exactly once.
@@ -130,4 +131,12 @@ func _initPrometheusMetrics() {
 			Help:      "Number of transactions found invalid by the propagation service",
 		},
 	)
+	prometheusDiskPressureRejections = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "teranode",
+			Subsystem: "propagation",
+			Name:      "disk_pressure_rejections",
+			Help:      "Number of transactions rejected by the propagation service because a blob store is under critical disk pressure",
+		},
+	)
 }
@@ -60,6 +60,7 @@ import (
 	"time"
 
 	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/model"
 	"github.com/bsv-blockchain/teranode/services/blockassembly"
 	"github.com/bsv-blockchain/teranode/services/blockchain"
 	"github.com/bsv-blockchain/teranode/services/blockvalidation"
@@ -69,9 +70,11 @@ import (
 	"github.com/bsv-blockchain/teranode/services/validator"
 	"github.com/bsv-blockchain/teranode/settings"
 	"github.com/bsv-blockchain/teranode/stores/blob"
+	"github.com/bsv-blockchain/teranode/stores/scripthash"
 	"github.com/bsv-blockchain/teranode/stores/utxo"
 	"github.com/bsv-blockchain/teranode/ulogger"
 	"github.com/bsv-blockchain/teranode/util"
+	"github.com/bsv-blockchain/teranode/util/feeestimator"
 	"github.com/bsv-blockchain/teranode/util/health"
 	"github.com/ordishs/gocore"
 	"go.opentelemetry.io/otel"
@@ -99,7 +102,17 @@ const (
 	// gbtNonceRange is two 32-bit big-endian hexadecimal integers which
 	// represent the valid ranges of nonces returned by the getblocktemplate
 	// RPC.
-	// gbtNonceRange = "00000000ffffffff"
+	gbtNonceRange = "00000000ffffffff"
+
+	// gbtCapabilitySubtrees is the getblocktemplate capability a client
+	// requests to receive the subtree hashes of the block template instead
+	// of the full list of transactions.
+	gbtCapabilitySubtrees = "subtrees"
+
+	// gbtLongPollRecheckInterval is the interval at which a getblocktemplate
+	// long-poll request rechecks the best block, in case a block notification
+	// was missed.
+	gbtLongPollRecheckInterval = 10 * time.Second
 
 	// gbtRegenerateSeconds is the number of seconds that must pass before
 	// a new template is generated when the previous block hash has not
@@ -115,19 +128,22 @@ const (
 var RPCStat = gocore.NewStat("RPC")
 
 var (
-// gbtMutableFields are the manipulations the server allows to be made
-// to block templates generated by the getblocktemplate RPC.  It is
-// declared here to avoid the overhead of creating the slice on every
-// invocation for constant data.
-// gbtMutableFields = []string{
-// 	"time", "transactions/add", "prevblock", "coinbase/append",
-// }
+	// gbtMutableFields are the manipulations the server allows to be made
+	// to block templates generated by the getblocktemplate RPC.  It is
+	// declared here to avoid the overhead of creating the slice on every
+	// invocation for constant data.
+	//
+	// The transactions can not be changed, since submitted blocks are
+	// matched to the subtrees of the template they were built from.
+	gbtMutableFields = []string{
+		"time", "coinbase/append",
+	}
 
-// gbtCapabilities describes additional capabilities returned with a
-// block template generated by the getblocktemplate RPC.    It is
-// declared here to avoid the overhead of creating the slice on every
-// invocation for constant data.
-// gbtCapabilities = []string{"proposal"}
+	// gbtCapabilities describes additional capabilities returned with a
+	// block template generated by the getblocktemplate RPC.    It is
+	// declared here to avoid the overhead of creating the slice on every
+	// invocation for constant data.
+	gbtCapabilities = []string{"proposal", gbtCapabilitySubtrees}
 )
 
 // Errors
@@ -157,9 +173,10 @@ var rpcHandlersBeforeInit = map[string]commandHandler{
 	"addnode":               handleUnimplemented,
 	"createrawtransaction":  handleCreateRawTransaction,
 	"debuglevel":            handleUnimplemented,
-	"decoderawtransaction":  handleUnimplemented,
-	"decodescript":          handleUnimplemented,
-	"estimatefee":           handleUnimplemented,
+	"decoderawtransaction":  handleDecodeRawTransaction,
+	"decodescript":          handleDecodeScript,
+	"estimatefee":           handleEstimateFee,
+	"estimatesmartfee":      handleEstimateSmartFee,
 	"generate":              handleGenerate,
 	"generatetoaddress":     handleGenerateToAddress,
 	"getaddednodeinfo":      handleUnimplemented,
@@ -171,9 +188,9 @@ var rpcHandlersBeforeInit = map[string]commandHandler{
 	"getblockcount":         handleUnimplemented,
 	"getblockhash":          handleGetBlockHash,
 	"getblockheader":        handleGetBlockHeader,
-	"getblocktemplate":      handleUnimplemented,
-	"getcfilter":            handleUnimplemented,
-	"getcfilterheader":      handleUnimplemented,
+	"getblocktemplate":      handleGetBlockTemplate,
+	"getcfilter":            handleGetCFilter,
+	"getcfilterheader":      handleGetCFilterHeader,
 	"getchaintips":          handleGetchaintips,
 	"getconnectioncount":    handleUnimplemented,
 	"getcurrentnet":         handleUnimplemented,
@@ -182,15 +199,19 @@ var rpcHandlersBeforeInit = map[string]commandHandler{
 	"gethashespersec":       handleUnimplemented,
 	"getheaders":            handleUnimplemented,
 	"getinfo":               handleGetInfo,
-	"getmempoolinfo":        handleUnimplemented,
+	"getmempoolancestors":   handleGetMempoolAncestors,
+	"getmempooldescendants": handleGetMempoolDescendants,
+	"getmempoolentry":       handleGetMempoolEntry,
+	"getmempoolinfo":        handleGetMempoolInfo,
 	"getmininginfo":         handleGetMiningInfo,
 	"getnettotals":          handleUnimplemented,
 	"getnetworkhashps":      handleUnimplemented,
 	"getpeerinfo":           handleGetpeerinfo,
 	"getrawmempool":         handleGetRawMempool,
 	"getrawtransaction":     handleGetRawTransaction,
-	"gettxout":              handleUnimplemented,
-	"gettxoutproof":         handleUnimplemented,
+	"gettxout":              handleGetTxOut,
+	"gettxoutproof":         handleGetTxOutProof,
+	"gettxoutsetinfo":       handleGetTxOutSetInfo,
 	"help":                  handleHelp,
 	"node":                  handleUnimplemented,
 	"ping":                  handleUnimplemented,
@@ -199,17 +220,17 @@ var rpcHandlersBeforeInit = map[string]commandHandler{
 	"listbanned":            handleListBanned,
 	"clearbanned":           handleClearBanned,
 	"reconsiderblock":       handleReconsiderBlock,
-	"searchrawtransactions": handleUnimplemented,
+	"searchrawtransactions": handleSearchRawTransactions,
 	"sendrawtransaction":    handleSendRawTransaction,
 	"setban":                handleSetBan,
 	"setgenerate":           handleUnimplemented,
 	"stop":                  handleStop,
-	"submitblock":           handleUnimplemented,
+	"submitblock":           handleSubmitBlock,
 	"uptime":                handleUnimplemented,
-	"validateaddress":       handleUnimplemented,
-	"verifychain":           handleUnimplemented,
-	"verifymessage":         handleUnimplemented,
-	"verifytxoutproof":      handleUnimplemented,
+	"validateaddress":       handleValidateAddress,
+	"verifychain":           handleVerifyChain,
+	"verifymessage":         handleVerifyMessage,
+	"verifytxoutproof":      handleVerifyTxOutProof,
 	"version":               handleVersion,
 	// BSV mining methods
 	"getminingcandidate":   handleGetMiningCandidate,
@@ -241,7 +262,6 @@ var rpcAskWallet = map[string]struct{}{
 	"getreceivedbyaccount":   {},
 	"getreceivedbyaddress":   {},
 	"gettransaction":         {},
-	"gettxoutsetinfo":        {},
 	"getunconfirmedbalance":  {},
 	"getwalletinfo":          {},
 	"importprivkey":          {},
@@ -273,7 +293,6 @@ var rpcAskWallet = map[string]struct{}{
 var rpcUnimplemented = map[string]struct{}{
 	"estimatepriority": {},
 	"getchaintips":     {},
-	"getmempoolentry":  {},
 	"getnetworkinfo":   {},
 	"getwork":          {},
 	// "invalidateblock":  {},
@@ -300,6 +319,7 @@ var rpcLimited = map[string]struct{}{
 	"decoderawtransaction":  {},
 	"decodescript":          {},
 	"estimatefee":           {},
+	"estimatesmartfee":      {},
 	"getbestblock":          {},
 	"getbestblockhash":      {},
 	"getblock":              {},
@@ -312,12 +332,17 @@ var rpcLimited = map[string]struct{}{
 	"getdifficulty":         {},
 	"getheaders":            {},
 	"getinfo":               {},
+	"getmempoolancestors":   {},
+	"getmempooldescendants": {},
+	"getmempoolentry":       {},
+	"getmempoolinfo":        {},
 	"getnettotals":          {},
 	"getnetworkhashps":      {},
 	"getrawmempool":         {},
 	"getrawtransaction":     {},
 	"gettxout":              {},
 	"gettxoutproof":         {},
+	"gettxoutsetinfo":       {},
 	"searchrawtransactions": {},
 	"sendrawtransaction":    {},
 	"submitblock":           {},
@@ -695,6 +720,26 @@ type RPCServer struct {
 	// validatorClient provides access to the transaction validator service
 	// Used for synchronous transaction validation in sendrawtransaction RPC
 	validatorClient validator.Interface
+
+	// subtreeStore provides access to the subtree blob store
+	// Used for building merkle proofs of transactions in gettxoutproof RPC
+	subtreeStore blob.Store
+
+	// blockStore provides access to the block blob store
+	// Used for reading the compact block filters written by the filter index in getcfilter RPC
+	blockStore blob.Store
+
+	// scriptHashStore provides access to the script hash index, nil when the index is disabled
+	// Used for looking up the transactions of an address in searchrawtransactions RPC
+	scriptHashStore scripthash.Store
+
+	// feeEstimator estimates fee rates from the transactions seen by this process, nil when disabled
+	// Used for estimatefee and estimatesmartfee RPCs
+	feeEstimator *feeestimator.Estimator
+
+	// blockTemplates tracks the block templates handed out by getblocktemplate
+	// Used for matching blocks in submitblock and for long-poll requests
+	blockTemplates blockTemplateState
 }
 
 // httpStatusLine returns a response Status-Line (RFC 2616 Section 6.1)
@@ -1323,6 +1368,32 @@ func (s *RPCServer) Start(ctx context.Context, readyCh chan<- struct{}) error {
 		s.jsonRPCRead(w, r, isAdmin)
 	})
 
+	if s.blockchainClient != nil {
+		blockchainSubscription, err := s.blockchainClient.Subscribe(ctx, "rpc")
+		if err != nil {
+			return errors.NewServiceError("[RPC] failed to subscribe to blockchain notifications", err)
+		}
+
+		// wake up getblocktemplate long-poll requests when a block is added to the chain
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case notification, ok := <-blockchainSubscription:
+					if !ok {
+						s.logger.Warnf("[RPC] blockchain subscription closed, getblocktemplate long-polls are no longer woken up by new blocks")
+						return
+					}
+
+					if notification != nil && notification.Type == model.NotificationType_Block {
+						s.blockTemplates.notifyBlock()
+					}
+				}
+			}
+		}()
+	}
+
 	for _, listener := range s.listeners {
 		s.wg.Add(1)
 
@@ -1380,11 +1451,20 @@ func (s *RPCServer) Start(ctx context.Context, readyCh chan<- struct{}) error {
 //   - blockchainClient: Interface to the blockchain service for block and chain operations
 //   - blockValidationClient: Interface to the block validation service
 //   - utxoStore: Interface to the UTXO database for transaction validation
+//   - blockAssemblyClient: Interface to the block assembly service
+//   - peerClient: Interface to the legacy peer service
+//   - p2pClient: Interface to the P2P service
+//   - txStore: Blob store for raw transaction data
+//   - validatorClient: Interface to the transaction validator service
+//   - subtreeStore: Blob store for subtree data, used to build merkle proofs
+//   - blockStore: Blob store for block data, used to read compact block filters
+//   - scriptHashStore: Script hash index store, used to search the transactions of an address
+//   - feeEstimator: Fee estimator, used to estimate fee rates, nil when fee estimation is disabled
 //
 // Returns:
 //   - *RPCServer: Configured server instance ready for initialization
 //   - error: Any error encountered during configuration
-func NewServer(logger ulogger.Logger, tSettings *settings.Settings, blockchainClient blockchain.ClientI, blockValidationClient blockvalidation.Interface, utxoStore utxo.Store, blockAssemblyClient blockassembly.ClientI, peerClient peer.ClientI, p2pClient p2p.ClientI, txStore blob.Store, validatorClient validator.Interface) (*RPCServer, error) {
+func NewServer(logger ulogger.Logger, tSettings *settings.Settings, blockchainClient blockchain.ClientI, blockValidationClient blockvalidation.Interface, utxoStore utxo.Store, blockAssemblyClient blockassembly.ClientI, peerClient peer.ClientI, p2pClient p2p.ClientI, txStore blob.Store, validatorClient validator.Interface, subtreeStore blob.Store, blockStore blob.Store, scriptHashStore scripthash.Store, feeEstimator *feeestimator.Estimator) (*RPCServer, error) {
 	initPrometheusMetrics()
 
 	assetHTTPAddress := tSettings.Asset.HTTPAddress
@@ -1413,6 +1493,10 @@ func NewServer(logger ulogger.Logger, tSettings *settings.Settings, blockchainCl
 		p2pClient:              p2pClient,
 		txStore:                txStore,
 		validatorClient:        validatorClient,
+		subtreeStore:           subtreeStore,
+		blockStore:             blockStore,
+		scriptHashStore:        scriptHashStore,
+		feeEstimator:           feeEstimator,
 	}
 
 	rpcUser := tSettings.RPC.RPCUser
@@ -0,0 +1,193 @@
+package rpc
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bsv-blockchain/go-bt/v2"
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	subtreepkg "github.com/bsv-blockchain/go-subtree"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/model"
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+	"github.com/bsv-blockchain/teranode/stores/blob"
+	"github.com/bsv-blockchain/teranode/util"
+	"github.com/ordishs/go-utils"
+)
+
+// maxBlockTemplates is the maximum number of block templates that are kept for matching
+// blocks submitted with submitblock. When the limit is reached, the oldest template is dropped.
+const maxBlockTemplates = 16
+
+// blockTemplate is a block template handed out by getblocktemplate, kept so that a block
+// submitted with submitblock can be matched to the subtrees it was built from.
+type blockTemplate struct {
+	// workID is the id of the mining candidate the template was created from
+	workID string
+
+	// candidate is the mining candidate from block assembly the template was created from
+	candidate *model.MiningCandidate
+
+	// previousHash is the hash of the block the template builds on
+	previousHash *chainhash.Hash
+
+	// subtreeHashes are the hashes of the subtrees holding the transactions of the template,
+	// with the coinbase placeholder as the first node of the first subtree
+	subtreeHashes []*chainhash.Hash
+
+	// subtrees are the subtrees of the template, nil until loaded from the subtree store
+	subtrees []*subtreepkg.Subtree
+
+	// mu protects the lazy loading of the subtrees
+	mu sync.Mutex
+}
+
+// newBlockTemplate creates a block template for the given mining candidate, which must have been
+// requested with the subtree hashes included.
+func newBlockTemplate(candidate *model.MiningCandidate) (*blockTemplate, error) {
+	previousHash, err := chainhash.NewHash(candidate.PreviousHash)
+	if err != nil {
+		return nil, errors.NewProcessingError("invalid previous hash in mining candidate", err)
+	}
+
+	subtreeHashes := make([]*chainhash.Hash, len(candidate.SubtreeHashes))
+
+	for i, hashBytes := range candidate.SubtreeHashes {
+		if subtreeHashes[i], err = chainhash.NewHash(hashBytes); err != nil {
+			return nil, errors.NewProcessingError("invalid subtree hash in mining candidate", err)
+		}
+	}
+
+	return &blockTemplate{
+		workID:        utils.ReverseAndHexEncodeSlice(candidate.Id),
+		candidate:     candidate,
+		previousHash:  previousHash,
+		subtreeHashes: subtreeHashes,
+	}, nil
+}
+
+// loadSubtrees returns the subtrees of the template, reading them from the subtree store the
+// first time they are needed.
+func (t *blockTemplate) loadSubtrees(ctx context.Context, subtreeStore blob.Store) ([]*subtreepkg.Subtree, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.subtrees != nil || len(t.subtreeHashes) == 0 {
+		return t.subtrees, nil
+	}
+
+	if subtreeStore == nil {
+		return nil, errors.NewServiceError("subtree store not available")
+	}
+
+	subtrees := make([]*subtreepkg.Subtree, len(t.subtreeHashes))
+
+	for i, subtreeHash := range t.subtreeHashes {
+		subtreeBytes, err := subtreeStore.Get(ctx, subtreeHash[:], fileformat.FileTypeSubtree)
+		if err != nil {
+			return nil, errors.NewStorageError("failed to get subtree %s", subtreeHash, err)
+		}
+
+		if subtrees[i], err = subtreepkg.NewSubtreeFromBytes(subtreeBytes); err != nil {
+			return nil, errors.NewProcessingError("failed to deserialize subtree %s", subtreeHash, err)
+		}
+	}
+
+	t.subtrees = subtrees
+
+	return subtrees, nil
+}
+
+// matchesCoinbase returns whether a block with the given header and coinbase transaction was
+// built from this template, by checking the previous block hash and calculating the merkle root
+// from the coinbase transaction and the merkle proof of the template.
+func (t *blockTemplate) matchesCoinbase(header *model.BlockHeader, coinbaseTx *bt.Tx) bool {
+	if !header.HashPrevBlock.IsEqual(t.previousHash) {
+		return false
+	}
+
+	merkleRoot, err := chainhash.NewHash(util.BuildMerkleRootFromCoinbase(coinbaseTx.TxIDChainHash().CloneBytes(), t.candidate.MerkleProof))
+	if err != nil {
+		return false
+	}
+
+	return merkleRoot.IsEqual(header.HashMerkleRoot)
+}
+
+// blockTemplateState tracks the block templates handed out by getblocktemplate, and signals
+// long-poll requests when a block has been added to the chain. The zero value is ready to use.
+type blockTemplateState struct {
+	mu sync.Mutex
+
+	// templates holds the most recent block templates, oldest first
+	templates []*blockTemplate
+
+	// blockCh is closed when a block is added to the chain, and replaced on the next call
+	// to blockNotifyChannel
+	blockCh chan struct{}
+}
+
+// add stores a block template, dropping templates that build on another block than the new
+// template and the oldest template when the maximum number of templates is reached.
+func (b *blockTemplateState) add(template *blockTemplate) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	templates := make([]*blockTemplate, 0, len(b.templates)+1)
+
+	for _, t := range b.templates {
+		if t.previousHash.IsEqual(template.previousHash) && t.workID != template.workID {
+			templates = append(templates, t)
+		}
+	}
+
+	if len(templates) >= maxBlockTemplates {
+		templates = templates[len(templates)-maxBlockTemplates+1:]
+	}
+
+	b.templates = append(templates, template)
+}
+
+// find returns the block template that a block with the given header and coinbase was built
+// from, or nil if there is none. When a work id is given, only that template is considered.
+func (b *blockTemplateState) find(header *model.BlockHeader, coinbaseTx *bt.Tx, workID string) *blockTemplate {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i := len(b.templates) - 1; i >= 0; i-- {
+		t := b.templates[i]
+
+		if workID != "" && t.workID != workID {
+			continue
+		}
+
+		if t.matchesCoinbase(header, coinbaseTx) {
+			return t
+		}
+	}
+
+	return nil
+}
+
+// blockNotifyChannel returns a channel that is closed when the next block is added to the chain.
+func (b *blockTemplateState) blockNotifyChannel() <-chan struct{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.blockCh == nil {
+		b.blockCh = make(chan struct{})
+	}
+
+	return b.blockCh
+}
+
+// notifyBlock signals all long-poll requests waiting on a block notification channel.
+func (b *blockTemplateState) notifyBlock() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.blockCh != nil {
+		close(b.blockCh)
+		b.blockCh = nil
+	}
+}
@@ -23,6 +23,8 @@ func TestChainSvrCmds(t *testing.T) {
 	t.Parallel()
 
 	testID := int(1)
+	conservative := bsvjson.EstimateModeConservative
+	economical := bsvjson.EstimateModeEconomical
 	tests := []struct {
 		name         string
 		newCmd       func() (interface{}, error)
@@ -103,6 +105,28 @@ func TestChainSvrCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"decodescript","params":["00"],"id":1}`,
 			unmarshalled: &bsvjson.DecodeScriptCmd{HexScript: "00"},
 		},
+		{
+			name: "estimatesmartfee",
+			newCmd: func() (interface{}, error) {
+				return bsvjson.NewCmd("estimatesmartfee", 6)
+			},
+			staticCmd: func() interface{} {
+				return bsvjson.NewEstimateSmartFeeCmd(6, nil)
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"estimatesmartfee","params":[6],"id":1}`,
+			unmarshalled: &bsvjson.EstimateSmartFeeCmd{ConfTarget: 6, EstimateMode: &conservative},
+		},
+		{
+			name: "estimatesmartfee optional",
+			newCmd: func() (interface{}, error) {
+				return bsvjson.NewCmd("estimatesmartfee", 6, bsvjson.EstimateModeEconomical)
+			},
+			staticCmd: func() interface{} {
+				return bsvjson.NewEstimateSmartFeeCmd(6, &economical)
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"estimatesmartfee","params":[6,"ECONOMICAL"],"id":1}`,
+			unmarshalled: &bsvjson.EstimateSmartFeeCmd{ConfTarget: 6, EstimateMode: &economical},
+		},
 		{
 			name: "getaddednodeinfo",
 			newCmd: func() (interface{}, error) {
@@ -427,6 +451,62 @@ func TestChainSvrCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"getinfo","params":[],"id":1}`,
 			unmarshalled: &bsvjson.GetInfoCmd{},
 		},
+		{
+			name: "getmempoolancestors",
+			newCmd: func() (interface{}, error) {
+				return bsvjson.NewCmd("getmempoolancestors", "txhash")
+			},
+			staticCmd: func() interface{} {
+				return bsvjson.NewGetMempoolAncestorsCmd("txhash", nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getmempoolancestors","params":["txhash"],"id":1}`,
+			unmarshalled: &bsvjson.GetMempoolAncestorsCmd{
+				TxID:    "txhash",
+				Verbose: bsvjson.Bool(false),
+			},
+		},
+		{
+			name: "getmempoolancestors optional",
+			newCmd: func() (interface{}, error) {
+				return bsvjson.NewCmd("getmempoolancestors", "txhash", true)
+			},
+			staticCmd: func() interface{} {
+				return bsvjson.NewGetMempoolAncestorsCmd("txhash", bsvjson.Bool(true))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getmempoolancestors","params":["txhash",true],"id":1}`,
+			unmarshalled: &bsvjson.GetMempoolAncestorsCmd{
+				TxID:    "txhash",
+				Verbose: bsvjson.Bool(true),
+			},
+		},
+		{
+			name: "getmempooldescendants",
+			newCmd: func() (interface{}, error) {
+				return bsvjson.NewCmd("getmempooldescendants", "txhash")
+			},
+			staticCmd: func() interface{} {
+				return bsvjson.NewGetMempoolDescendantsCmd("txhash", nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getmempooldescendants","params":["txhash"],"id":1}`,
+			unmarshalled: &bsvjson.GetMempoolDescendantsCmd{
+				TxID:    "txhash",
+				Verbose: bsvjson.Bool(false),
+			},
+		},
+		{
+			name: "getmempooldescendants optional",
+			newCmd: func() (interface{}, error) {
+				return bsvjson.NewCmd("getmempooldescendants", "txhash", true)
+			},
+			staticCmd: func() interface{} {
+				return bsvjson.NewGetMempoolDescendantsCmd("txhash", bsvjson.Bool(true))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getmempooldescendants","params":["txhash",true],"id":1}`,
+			unmarshalled: &bsvjson.GetMempoolDescendantsCmd{
+				TxID:    "txhash",
+				Verbose: bsvjson.Bool(true),
+			},
+		},
 		{
 			name: "getmempoolentry",
 			newCmd: func() (interface{}, error) {
@@ -71,6 +71,14 @@ type CreateMultiSigResult struct {
 	RedeemScript string `json:"redeemScript"`
 }
 
+// EstimateSmartFeeResult models the data returned from the estimatesmartfee
+// command.
+type EstimateSmartFeeResult struct {
+	FeeRate *float64 `json:"feerate,omitempty"`
+	Errors  []string `json:"errors,omitempty"`
+	Blocks  int64    `json:"blocks"`
+}
+
 // DecodeScriptResult models the data returned from the decodescript command.
 type DecodeScriptResult struct {
 	Asm       string   `json:"asm"`
@@ -185,6 +193,14 @@ type GetBlockTemplateResult struct {
 	// Block proposal from BIP 0023.
 	Capabilities  []string `json:"capabilities,omitempty"`
 	RejectReasion string   `json:"reject-reason,omitempty"`
+
+	// Subtree extension, only provided when the subtrees capability is
+	// requested. The transactions are not listed, instead the template
+	// references the subtrees they are in, along with the merkle proof of
+	// the coinbase transaction needed to calculate the merkle root.
+	SubtreeHashes []string `json:"subtreehashes,omitempty"`
+	MerkleProof   []string `json:"merkleproof,omitempty"`
+	TxCount       uint32   `json:"txcount,omitempty"`
 }
 
 // GetMempoolEntryResult models the data returned from the getmempoolentry
@@ -209,8 +225,10 @@ type GetMempoolEntryResult struct {
 // GetMempoolInfoResult models the data returned from the getmempoolinfo
 // command.
 type GetMempoolInfoResult struct {
-	Size  int64 `json:"size"`
-	Bytes int64 `json:"bytes"`
+	Size          int64   `json:"size"`
+	Bytes         int64   `json:"bytes"`
+	TotalFee      float64 `json:"total_fee"`
+	MempoolMinFee float64 `json:"mempoolminfee"`
 }
 
 // NetworksResult models the networks data from the getnetworkinfo command.
@@ -306,6 +324,19 @@ type GetTxOutResult struct {
 	Value         float64            `json:"value"`
 	ScriptPubKey  ScriptPubKeyResult `json:"scriptPubKey"`
 	Coinbase      bool               `json:"coinbase"`
+	Frozen        bool               `json:"frozen,omitempty"`
+	Conflicting   bool               `json:"conflicting,omitempty"`
+	Locked        bool               `json:"locked,omitempty"`
+}
+
+// GetTxOutSetInfoResult models the data from the gettxoutsetinfo command.
+type GetTxOutSetInfoResult struct {
+	Height       int64   `json:"height"`
+	BestBlock    string  `json:"bestblock"`
+	Transactions uint64  `json:"transactions"`
+	TxOuts       uint64  `json:"txouts"`
+	TotalAmount  float64 `json:"total_amount"`
+	MuHash       string  `json:"muhash"`
 }
 
 // GetNetTotalsResult models the data returned from the getnettotals command.
@@ -507,6 +538,8 @@ type TxRawDecodeResult struct {
 // ValidateAddressChainResult models the data returned by the chain server
 // validateaddress command.
 type ValidateAddressChainResult struct {
-	IsValid bool   `json:"isvalid"`
-	Address string `json:"address,omitempty"`
+	IsValid      bool   `json:"isvalid"`
+	Address      string `json:"address,omitempty"`
+	ScriptPubKey string `json:"scriptPubKey,omitempty"`
+	IsScript     *bool  `json:"isscript,omitempty"`
 }
@@ -29,6 +29,7 @@ package rpc
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/hex"
 	"fmt"
 	"io"
@@ -36,6 +37,7 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
 	"time"
 
@@ -49,12 +51,22 @@ import (
 	"github.com/bsv-blockchain/teranode/services/blockassembly/blockassembly_api"
 	"github.com/bsv-blockchain/teranode/services/blockchain"
 	"github.com/bsv-blockchain/teranode/services/blockvalidation"
+	"github.com/bsv-blockchain/teranode/services/filterindex"
+	"github.com/bsv-blockchain/teranode/services/legacy/bsvec"
 	"github.com/bsv-blockchain/teranode/services/legacy/bsvutil"
+	"github.com/bsv-blockchain/teranode/services/legacy/bsvutil/merkleblock"
 	"github.com/bsv-blockchain/teranode/services/legacy/peer_api"
 	"github.com/bsv-blockchain/teranode/services/legacy/txscript"
 	"github.com/bsv-blockchain/teranode/services/p2p"
 	"github.com/bsv-blockchain/teranode/services/rpc/bsvjson"
+	"github.com/bsv-blockchain/teranode/services/utxopersister"
+	"github.com/bsv-blockchain/teranode/stores/scripthash"
 	"github.com/bsv-blockchain/teranode/stores/utxo"
+	"github.com/bsv-blockchain/teranode/stores/utxo/fields"
+	"github.com/bsv-blockchain/teranode/stores/utxo/meta"
+	"github.com/bsv-blockchain/teranode/util"
+	"github.com/bsv-blockchain/teranode/util/chainverifier"
+	"github.com/bsv-blockchain/teranode/util/feeestimator"
 	"github.com/bsv-blockchain/teranode/util/tracing"
 	"github.com/ordishs/go-utils"
 	cache "github.com/patrickmn/go-cache"
@@ -306,6 +318,117 @@ func handleGetBlockHeader(ctx context.Context, s *RPCServer, cmd interface{}, _
 	return fmt.Sprintf("%x", b.Bytes()), nil
 }
 
+// handleGetCFilter implements the getcfilter command.
+//
+// This command returns the compact block filter (BIP158) of a block, as built by the
+// filter index service. Light clients use the filter to find out whether a block
+// contains transactions relevant to them, without revealing their addresses.
+//
+// The command requires the filter index to be enabled (filterindex_enabled), and
+// only the basic filter type (0) is supported.
+//
+// Parameters:
+//   - ctx: Context for cancellation and tracing
+//   - s: The RPC server instance providing access to service clients
+//   - cmd: The parsed command arguments (bsvjson.GetCFilterCmd)
+//   - _: Unused channel for close notification
+//
+// Returns:
+//   - interface{}: Hex-encoded serialized filter of the block
+//   - error: ErrRPCNoCFIndex if the filter index is not enabled, or an error if the
+//     hash is invalid or the block has not been indexed
+func handleGetCFilter(ctx context.Context, s *RPCServer, cmd interface{}, _ <-chan struct{}) (interface{}, error) {
+	ctx, _, deferFn := tracing.Tracer("rpc").Start(ctx, "handleGetCFilter",
+		tracing.WithParentStat(RPCStat),
+		tracing.WithHistogram(prometheusHandleGetCFilter),
+		tracing.WithLogMessage(s.logger, "[handleGetCFilter] called"),
+	)
+	defer deferFn()
+
+	c := cmd.(*bsvjson.GetCFilterCmd)
+
+	blockFilter, err := s.getBlockFilter(ctx, c.Hash, c.FilterType)
+	if err != nil {
+		return nil, err
+	}
+
+	return hex.EncodeToString(blockFilter.Filter), nil
+}
+
+// handleGetCFilterHeader implements the getcfilterheader command.
+//
+// This command returns the filter header (BIP157) of a block, which commits to the
+// filter of the block and to the filter headers of all previous blocks. Light clients
+// compare the filter headers served by different peers to detect invalid filters.
+//
+// The command requires the filter index to be enabled (filterindex_enabled), and
+// only the basic filter type (0) is supported.
+//
+// Parameters:
+//   - ctx: Context for cancellation and tracing
+//   - s: The RPC server instance providing access to service clients
+//   - cmd: The parsed command arguments (bsvjson.GetCFilterHeaderCmd)
+//   - _: Unused channel for close notification
+//
+// Returns:
+//   - interface{}: String containing the filter header of the block
+//   - error: ErrRPCNoCFIndex if the filter index is not enabled, or an error if the
+//     hash is invalid or the block has not been indexed
+func handleGetCFilterHeader(ctx context.Context, s *RPCServer, cmd interface{}, _ <-chan struct{}) (interface{}, error) {
+	ctx, _, deferFn := tracing.Tracer("rpc").Start(ctx, "handleGetCFilterHeader",
+		tracing.WithParentStat(RPCStat),
+		tracing.WithHistogram(prometheusHandleGetCFilterHeader),
+		tracing.WithLogMessage(s.logger, "[handleGetCFilterHeader] called"),
+	)
+	defer deferFn()
+
+	c := cmd.(*bsvjson.GetCFilterHeaderCmd)
+
+	blockFilter, err := s.getBlockFilter(ctx, c.Hash, c.FilterType)
+	if err != nil {
+		return nil, err
+	}
+
+	return blockFilter.Header.String(), nil
+}
+
+// getBlockFilter reads the filter of the block with the given hash from the block store, for
+// the getcfilter and getcfilterheader commands.
+func (s *RPCServer) getBlockFilter(ctx context.Context, hashStr string, filterType wire.FilterType) (*filterindex.BlockFilter, error) {
+	if !s.settings.FilterIndex.Enabled || s.blockStore == nil {
+		return nil, &bsvjson.RPCError{
+			Code:    bsvjson.ErrRPCNoCFIndex,
+			Message: "The CF index must be enabled for this command",
+		}
+	}
+
+	if filterType != wire.GCSFilterRegular {
+		return nil, &bsvjson.RPCError{
+			Code:    bsvjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Unknown filter type %d", filterType),
+		}
+	}
+
+	hash, err := chainhash.NewHashFromStr(hashStr)
+	if err != nil {
+		return nil, rpcDecodeHexError(hashStr)
+	}
+
+	blockFilter, err := filterindex.GetBlockFilter(ctx, s.blockStore, hash)
+	if err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			return nil, &bsvjson.RPCError{
+				Code:    bsvjson.ErrRPCBlockNotFound,
+				Message: "Filter not found for block " + hashStr,
+			}
+		}
+
+		return nil, s.internalRPCError(err.Error(), "Failed to get filter")
+	}
+
+	return blockFilter, nil
+}
+
 // blockToJSON converts a block to JSON format based on verbosity level.
 func (s *RPCServer) blockToJSON(ctx context.Context, b *model.Block, verbosity uint32) (interface{}, error) {
 	if b == nil {
@@ -602,127 +725,1261 @@ func handleGetRawTransaction(ctx context.Context, s *RPCServer, cmd interface{},
 	}, nil
 }
 
-// handleCreateRawTransaction handles createrawtransaction commands.
-func handleCreateRawTransaction(ctx context.Context, s *RPCServer, cmd interface{}, _ <-chan struct{}) (interface{}, error) {
-	_, _, deferFn := tracing.Tracer("rpc").Start(ctx, "handleCreateRawTransaction",
+// handleSearchRawTransactions implements the searchrawtransactions command, which returns the
+// confirmed transactions that pay to, or spend from, the passed address.
+//
+// The transactions are looked up in the script hash index maintained by the script hash index
+// service, using the single SHA256 of the pay-to-address locking script of the address. Only
+// transactions in blocks on the best chain are returned, the index does not cover the mempool.
+// The transactions themselves are read from the UTXO store.
+//
+// Parameters:
+//   - ctx: Context for cancellation and tracing
+//   - s: The RPC server instance providing access to service clients
+//   - cmd: The parsed command arguments (bsvjson.SearchRawTransactionsCmd)
+//   - _: Unused channel for close notification
+//
+// Returns:
+//   - interface{}: A list of hex encoded transactions, or of bsvjson.SearchRawTransactionsResult when verbose
+//   - error: Any error encountered during processing
+func handleSearchRawTransactions(ctx context.Context, s *RPCServer, cmd interface{}, _ <-chan struct{}) (interface{}, error) {
+	ctx, _, deferFn := tracing.Tracer("rpc").Start(ctx, "handleSearchRawTransactions",
 		tracing.WithParentStat(RPCStat),
-		tracing.WithHistogram(prometheusHandleCreateRawTransaction),
-		tracing.WithLogMessage(s.logger, "[handleCreateRawTransaction] called"),
+		tracing.WithHistogram(prometheusHandleSearchRawTransactions),
+		tracing.WithLogMessage(s.logger, "[handleSearchRawTransactions] called"),
 	)
 	defer deferFn()
 
-	c := cmd.(*bsvjson.CreateRawTransactionCmd)
+	c := cmd.(*bsvjson.SearchRawTransactionsCmd)
 
-	// Validate the locktime, if given.
-	if c.LockTime != nil &&
-		(*c.LockTime < 0 || *c.LockTime > int64(wire.MaxTxInSequenceNum)) {
+	if !s.settings.ScriptHashIndex.Enabled || s.scriptHashStore == nil {
+		return nil, &bsvjson.RPCError{
+			Code:    bsvjson.ErrRPCMisc,
+			Message: "Script hash index must be enabled (scripthashindex_enabled)",
+		}
+	}
+
+	vinExtra := c.VinExtra != nil && *c.VinExtra != 0
+	verbose := c.Verbose == nil || *c.Verbose != 0
+	reverse := c.Reverse != nil && *c.Reverse
+
+	skip := 0
+	if c.Skip != nil && *c.Skip > 0 {
+		skip = *c.Skip
+	}
+
+	count := 100
+	if c.Count != nil {
+		count = *c.Count
+	}
+
+	if count <= 0 {
+		return []interface{}{}, nil
+	}
+
+	if count > maxSearchRawTransactionsCount {
+		count = maxSearchRawTransactionsCount
+	}
+
+	addr, err := s.decodeAddressForNet(c.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	lockingScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		return nil, s.internalRPCError(err.Error(), "searchrawtransactions: failed to generate pay-to-address script")
+	}
+
+	var filterAddrs map[string]struct{}
+
+	if c.FilterAddrs != nil && len(*c.FilterAddrs) > 0 {
+		filterAddrs = make(map[string]struct{}, len(*c.FilterAddrs))
+
+		for _, filterAddr := range *c.FilterAddrs {
+			filterAddrs[filterAddr] = struct{}{}
+		}
+	}
+
+	scriptHash := scripthash.NewScriptHash(lockingScript)
+
+	history, err := s.scriptHashStore.GetHistory(ctx, &scriptHash, skip, count, reverse)
+	if err != nil {
+		return nil, s.internalRPCError(err.Error(), "searchrawtransactions: failed to read script hash index")
+	}
+
+	if len(history) == 0 {
+		return nil, &bsvjson.RPCError{
+			Code:    bsvjson.ErrRPCNoTxInfo,
+			Message: "No Information Available",
+		}
+	}
+
+	var bestHeight uint32
+
+	if verbose {
+		_, bestBlockMeta, err := s.blockchainClient.GetBestBlockHeader(ctx)
+		if err != nil {
+			return nil, s.internalRPCError(err.Error(), "searchrawtransactions: failed to get best block header")
+		}
+
+		bestHeight = bestBlockMeta.Height
+	}
+
+	hexTxs := make([]string, 0, len(history))
+	results := make([]bsvjson.SearchRawTransactionsResult, 0, len(history))
+	blockHeaders := make(map[uint32]*model.BlockHeader)
+
+	for _, item := range history {
+		txMeta, err := s.utxoStore.Get(ctx, &item.TxID, fields.Tx)
+		if err != nil || txMeta.Tx == nil {
+			if err == nil || errors.Is(err, errors.ErrTxNotFound) || errors.Is(err, errors.ErrNotFound) {
+				return nil, &bsvjson.RPCError{
+					Code:    bsvjson.ErrRPCNoTxInfo,
+					Message: "No information available about transaction " + item.TxID.String(),
+				}
+			}
+
+			return nil, s.internalRPCError(err.Error(), "searchrawtransactions: failed to get transaction "+item.TxID.String())
+		}
+
+		tx := txMeta.Tx
+
+		if !verbose {
+			hexTxs = append(hexTxs, hex.EncodeToString(tx.Bytes()))
+			continue
+		}
+
+		blockHeader, ok := blockHeaders[item.Height]
+		if !ok {
+			headers, _, err := s.blockchainClient.GetBlockHeadersByHeight(ctx, item.Height, item.Height)
+			if err != nil {
+				return nil, s.internalRPCError(err.Error(), "searchrawtransactions: failed to get block header")
+			}
+
+			if len(headers) == 0 {
+				return nil, s.internalRPCError("block header not found", "searchrawtransactions: failed to get block header")
+			}
+
+			blockHeader = headers[0]
+			blockHeaders[item.Height] = blockHeader
+		}
+
+		result, err := s.searchRawTransactionResult(ctx, tx, vinExtra, filterAddrs)
+		if err != nil {
+			return nil, err
+		}
+
+		result.BlockHash = blockHeader.Hash().String()
+		result.Time = int64(blockHeader.Timestamp)
+		result.Blocktime = int64(blockHeader.Timestamp)
+
+		if bestHeight >= item.Height {
+			result.Confirmations = uint64(bestHeight-item.Height) + 1
+		}
+
+		results = append(results, *result)
+	}
+
+	if !verbose {
+		return hexTxs, nil
+	}
+
+	return results, nil
+}
+
+// maxSearchRawTransactionsCount is the maximum number of transactions returned by a single
+// searchrawtransactions call.
+const maxSearchRawTransactionsCount = 10_000
+
+// decodeAddressForNet decodes an address and checks that it is for the network the node is running on.
+func (s *RPCServer) decodeAddressForNet(encodedAddr string) (bsvutil.Address, error) {
+	addr, err := bsvutil.DecodeAddress(encodedAddr, s.settings.ChainCfgParams)
+	if err != nil {
+		return nil, &bsvjson.RPCError{
+			Code:    bsvjson.ErrRPCInvalidAddressOrKey,
+			Message: "Invalid address or key: " + err.Error(),
+		}
+	}
+
+	if !addr.IsForNet(s.settings.ChainCfgParams) {
+		return nil, &bsvjson.RPCError{
+			Code:    bsvjson.ErrRPCInvalidAddressOrKey,
+			Message: "Invalid address: " + encodedAddr + " is for the wrong network",
+		}
+	}
+
+	return addr, nil
+}
+
+// searchRawTransactionResult converts a transaction into a searchrawtransactions result, without the
+// block related fields. The previous outputs of the inputs are looked up when vinExtra is set or when
+// the inputs need to be filtered on address. When filterAddrs is not empty, only the inputs and outputs
+// paying to one of the addresses are included.
+func (s *RPCServer) searchRawTransactionResult(ctx context.Context, tx *bt.Tx, vinExtra bool, filterAddrs map[string]struct{}) (*bsvjson.SearchRawTransactionsResult, error) {
+	isCoinbase := tx.IsCoinbase()
+
+	if !isCoinbase && (vinExtra || len(filterAddrs) > 0) && !tx.IsExtended() {
+		if err := s.utxoStore.PreviousOutputsDecorate(ctx, tx); err != nil {
+			return nil, s.internalRPCError(err.Error(), "searchrawtransactions: failed to get previous outputs")
+		}
+	}
+
+	matchesFilter := func(addresses []string) bool {
+		if len(filterAddrs) == 0 {
+			return true
+		}
+
+		for _, addr := range addresses {
+			if _, ok := filterAddrs[addr]; ok {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	vin := make([]bsvjson.VinPrevOut, 0, len(tx.Inputs))
+
+	for _, input := range tx.Inputs {
+		if isCoinbase {
+			vin = append(vin, bsvjson.VinPrevOut{
+				Coinbase: hex.EncodeToString(input.UnlockingScript.Bytes()),
+				Sequence: input.SequenceNumber,
+			})
+
+			continue
+		}
+
+		asm, err := txscript.DisasmString(input.UnlockingScript.Bytes())
+		if err != nil {
+			return nil, s.internalRPCError(err.Error(), "searchrawtransactions: failed to disassemble unlocking script")
+		}
+
+		vinEntry := bsvjson.VinPrevOut{
+			Txid: input.PreviousTxIDStr(),
+			Vout: input.PreviousTxOutIndex,
+			ScriptSig: &bsvjson.ScriptSig{
+				Asm: asm,
+				Hex: hex.EncodeToString(input.UnlockingScript.Bytes()),
+			},
+			Sequence: input.SequenceNumber,
+		}
+
+		var prevOutAddresses []string
+
+		if input.PreviousTxScript != nil {
+			prevScriptPubKey, err := s.scriptPubKeyToJSON(input.PreviousTxScript.Bytes())
+			if err != nil {
+				return nil, s.internalRPCError(err.Error(), "searchrawtransactions: failed to decode previous locking script")
+			}
+
+			prevOutAddresses = prevScriptPubKey.Addresses
+		}
+
+		if !matchesFilter(prevOutAddresses) {
+			continue
+		}
+
+		if vinExtra {
+			vinEntry.PrevOut = &bsvjson.PrevOut{
+				Addresses: prevOutAddresses,
+				Value:     bsvutil.Amount(input.PreviousTxSatoshis).ToBSV(), //nolint:gosec
+			}
+		}
+
+		vin = append(vin, vinEntry)
+	}
+
+	vout := make([]bsvjson.Vout, 0, len(tx.Outputs))
+
+	for i, output := range tx.Outputs {
+		scriptPubKey, err := s.scriptPubKeyToJSON(output.LockingScript.Bytes())
+		if err != nil {
+			return nil, s.internalRPCError(err.Error(), "searchrawtransactions: failed to decode locking script")
+		}
+
+		if !matchesFilter(scriptPubKey.Addresses) {
+			continue
+		}
+
+		vout = append(vout, bsvjson.Vout{
+			Value:        bsvutil.Amount(output.Satoshis).ToBSV(), //nolint:gosec
+			N:            uint32(i),                               //nolint:gosec
+			ScriptPubKey: scriptPubKey,
+		})
+	}
+
+	size := fmt.Sprintf("%d", tx.Size())
+
+	return &bsvjson.SearchRawTransactionsResult{
+		Hex:      hex.EncodeToString(tx.Bytes()),
+		Txid:     tx.TxID(),
+		Hash:     tx.TxID(),
+		Size:     size,
+		Vsize:    size,
+		Version:  int32(tx.Version), //nolint:gosec
+		LockTime: tx.LockTime,
+		Vin:      vin,
+		Vout:     vout,
+	}, nil
+}
+
+// handleGetTxOut implements the gettxout command, which returns details about an unspent
+// transaction output.
+//
+// The outpoint is resolved against the UTXO store: the parent transaction is loaded to obtain
+// the output value and locking script, and the spend status of the output is looked up by its
+// UTXO hash. Confirmations are derived from the blocks the parent transaction was mined in that
+// are still part of the current best chain.
+//
+// The include_mempool argument controls how unmined state is treated:
+//   - true (default): unmined outputs are returned, outputs spent by any transaction are not
+//   - false: unmined outputs are not returned, outputs only spent by unmined transactions are
+//
+// Unlike spent outputs, frozen, conflicting and locked outputs are still returned, with the
+// corresponding flag set in the result, so that callers can distinguish them from spendable outputs.
+//
+// Parameters:
+//   - ctx: Context for cancellation and tracing
+//   - s: The RPC server instance providing access to service clients
+//   - cmd: The parsed command arguments (bsvjson.GetTxOutCmd)
+//   - _: Unused channel for close notification
+//
+// Returns:
+//   - interface{}: A bsvjson.GetTxOutResult, or nil if the output does not exist or is spent
+//   - error: Any error encountered during processing
+func handleGetTxOut(ctx context.Context, s *RPCServer, cmd interface{}, _ <-chan struct{}) (interface{}, error) {
+	ctx, _, deferFn := tracing.Tracer("rpc").Start(ctx, "handleGetTxOut",
+		tracing.WithParentStat(RPCStat),
+		tracing.WithHistogram(prometheusHandleGetTxOut),
+		tracing.WithLogMessage(s.logger, "[handleGetTxOut] called"),
+	)
+	defer deferFn()
+
+	c := cmd.(*bsvjson.GetTxOutCmd)
+
+	txHash, err := chainhash.NewHashFromStr(c.Txid)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.Txid)
+	}
+
+	includeMempool := c.IncludeMempool == nil || *c.IncludeMempool
+
+	txMeta, err := s.utxoStore.Get(ctx, txHash, fields.Tx, fields.BlockIDs, fields.BlockHeights, fields.IsCoinbase, fields.UnminedSince, fields.Conflicting, fields.Locked)
+	if err != nil {
+		if errors.Is(err, errors.ErrTxNotFound) || errors.Is(err, errors.ErrNotFound) {
+			return nil, nil
+		}
+
+		return nil, s.internalRPCError(err.Error(), "gettxout: failed to get transaction "+c.Txid)
+	}
+
+	if txMeta.Tx == nil || int(c.Vout) >= len(txMeta.Tx.Outputs) || txMeta.Tx.Outputs[c.Vout] == nil {
+		return nil, nil
+	}
+
+	output := txMeta.Tx.Outputs[c.Vout]
+
+	bestBlockHeader, bestBlockMeta, err := s.blockchainClient.GetBestBlockHeader(ctx)
+	if err != nil {
+		return nil, s.internalRPCError(err.Error(), "gettxout: failed to get best block header")
+	}
+
+	confirmations, err := s.txConfirmations(ctx, txMeta, bestBlockMeta.Height)
+	if err != nil {
+		return nil, s.internalRPCError(err.Error(), "gettxout: failed to check block inclusion")
+	}
+
+	if confirmations == 0 && !includeMempool {
+		return nil, nil
+	}
+
+	utxoHash, err := util.UTXOHashFromOutput(txHash, output, c.Vout)
+	if err != nil {
+		return nil, s.internalRPCError(err.Error(), "gettxout: failed to calculate utxo hash")
+	}
+
+	spendResponse, err := s.utxoStore.GetSpend(ctx, &utxo.Spend{
+		TxID:     txHash,
+		Vout:     c.Vout,
+		UTXOHash: utxoHash,
+	})
+	if err != nil {
+		if errors.Is(err, errors.ErrNotFound) || errors.Is(err, errors.ErrTxNotFound) {
+			// outputs that are not stored as utxos (e.g. OP_RETURN) are never spendable
+			return nil, nil
+		}
+
+		return nil, s.internalRPCError(err.Error(), "gettxout: failed to get spend status")
+	}
+
+	if utxo.Status(spendResponse.Status) == utxo.Status_NOT_FOUND {
+		// the aerospike store reports missing utxos with a status instead of an error
+		return nil, nil
+	}
+
+	scriptPubKey, err := s.scriptPubKeyToJSON(output.LockingScript.Bytes())
+	if err != nil {
+		return nil, s.internalRPCError(err.Error(), "gettxout: failed to decode locking script")
+	}
+
+	result := &bsvjson.GetTxOutResult{
+		BestBlock:     bestBlockHeader.Hash().String(),
+		Confirmations: confirmations,
+		Value:         bsvutil.Amount(output.Satoshis).ToBSV(), //nolint:gosec
+		ScriptPubKey:  scriptPubKey,
+		Coinbase:      txMeta.IsCoinbase,
+		Conflicting:   txMeta.Conflicting,
+		Locked:        txMeta.Locked,
+	}
+
+	switch utxo.Status(spendResponse.Status) {
+	case utxo.Status_SPENT:
+		if includeMempool || spendResponse.SpendingData == nil {
+			return nil, nil
+		}
+
+		// when the mempool is excluded, an output that is only spent by an unmined transaction
+		// is still unspent as far as the best chain is concerned
+		spendingTxMeta, err := s.utxoStore.Get(ctx, spendResponse.SpendingData.TxID, fields.BlockIDs, fields.BlockHeights, fields.UnminedSince)
+		if err != nil {
+			if errors.Is(err, errors.ErrTxNotFound) || errors.Is(err, errors.ErrNotFound) {
+				return result, nil
+			}
+
+			return nil, s.internalRPCError(err.Error(), "gettxout: failed to get spending transaction")
+		}
+
+		spendingConfirmations, err := s.txConfirmations(ctx, spendingTxMeta, bestBlockMeta.Height)
+		if err != nil {
+			return nil, s.internalRPCError(err.Error(), "gettxout: failed to check block inclusion")
+		}
+
+		if spendingConfirmations > 0 {
+			return nil, nil
+		}
+	case utxo.Status_FROZEN:
+		result.Frozen = true
+	case utxo.Status_CONFLICTING:
+		result.Conflicting = true
+	case utxo.Status_LOCKED:
+		result.Locked = true
+	}
+
+	return result, nil
+}
+
+// txConfirmations returns the number of confirmations of a transaction on the current best chain.
+// Transactions that are unmined, or only mined in blocks that are not on the best chain, have 0
+// confirmations. The txMeta must have been retrieved with the BlockIDs and BlockHeights fields.
+func (s *RPCServer) txConfirmations(ctx context.Context, txMeta *meta.Data, bestHeight uint32) (int64, error) {
+	if txMeta.UnminedSince > 0 {
+		return 0, nil
+	}
+
+	for i, blockID := range txMeta.BlockIDs {
+		if i >= len(txMeta.BlockHeights) || txMeta.BlockHeights[i] > bestHeight {
+			continue
+		}
+
+		onCurrentChain, err := s.blockchainClient.CheckBlockIsInCurrentChain(ctx, []uint32{blockID})
+		if err != nil {
+			return 0, err
+		}
+
+		if onCurrentChain {
+			return int64(bestHeight) - int64(txMeta.BlockHeights[i]) + 1, nil
+		}
+	}
+
+	return 0, nil
+}
+
+// scriptPubKeyToJSON converts a locking script into its JSON-RPC representation, including the
+// disassembly, script class and any addresses encoded for the network the node is running on.
+// For an unparsable script the error is returned together with the representation, whose
+// disassembly contains the opcodes up to the point of failure.
+func (s *RPCServer) scriptPubKeyToJSON(script []byte) (bsvjson.ScriptPubKeyResult, error) {
+	asm, disasmErr := txscript.DisasmString(script)
+
+	// ignore the error here since an error means the script couldn't parse and there is no
+	// additional information about it anyway
+	scriptClass, addrs, reqSigs, _ := txscript.ExtractPkScriptAddrs(script, s.settings.ChainCfgParams)
+
+	var addresses []string
+
+	if len(addrs) > 0 {
+		addresses = make([]string, len(addrs))
+		for i, addr := range addrs {
+			addresses[i] = addr.EncodeAddress()
+		}
+	}
+
+	return bsvjson.ScriptPubKeyResult{
+		Asm:       asm,
+		Hex:       hex.EncodeToString(script),
+		ReqSigs:   int32(reqSigs), //nolint:gosec
+		Type:      scriptClass.String(),
+		Addresses: addresses,
+	}, disasmErr
+}
+
+// handleGetTxOutSetInfo implements the gettxoutsetinfo command, which returns statistics about the
+// UTXO set and its commitment.
+//
+// The UTXO set is the set written by the UTXO persister at the last block it processed, which trails
+// the best block by 100 blocks. The muhash is the elliptic curve multiset hash of all unspent outputs,
+// which is the same on every node holding the same UTXO set at the same block, so it can be used to
+// compare nodes and to verify UTXO snapshots.
+//
+// Parameters:
+//   - ctx: Context for cancellation and tracing
+//   - s: The RPC server instance providing access to service clients
+//   - _: Unused command arguments (bsvjson.GetTxOutSetInfoCmd)
+//   - _: Unused channel for close notification
+//
+// Returns:
+//   - interface{}: A bsvjson.GetTxOutSetInfoResult
+//   - error: ErrRPCNoTxInfo if no UTXO set with a commitment has been written, or any other error
+func handleGetTxOutSetInfo(ctx context.Context, s *RPCServer, _ interface{}, _ <-chan struct{}) (interface{}, error) {
+	ctx, _, deferFn := tracing.Tracer("rpc").Start(ctx, "handleGetTxOutSetInfo",
+		tracing.WithParentStat(RPCStat),
+		tracing.WithHistogram(prometheusHandleGetTxOutSetInfo),
+		tracing.WithLogMessage(s.logger, "[handleGetTxOutSetInfo] called"),
+	)
+	defer deferFn()
+
+	if s.blockStore == nil {
+		return nil, s.internalRPCError("block store is not configured", "Failed to get utxo set info")
+	}
+
+	commitment, err := utxopersister.GetLatestUTXOCommitment(ctx, s.blockStore, s.blockchainClient)
+	if err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			return nil, &bsvjson.RPCError{
+				Code:    bsvjson.ErrRPCNoTxInfo,
+				Message: "No UTXO set commitment available, the UTXO persister has not written one yet",
+			}
+		}
+
+		return nil, s.internalRPCError(err.Error(), "Failed to get utxo set info")
+	}
+
+	muHash := commitment.MuHash()
+
+	return &bsvjson.GetTxOutSetInfoResult{
+		Height:       int64(commitment.BlockHeight),
+		BestBlock:    commitment.BlockHash.String(),
+		Transactions: commitment.TxCount,
+		TxOuts:       commitment.UTXOCount,
+		TotalAmount:  float64(commitment.TotalAmount) / 1e8,
+		MuHash:       muHash.String(),
+	}, nil
+}
+
+// handleGetTxOutProof implements the gettxoutproof command, which returns a hex encoded merkle
+// proof (a serialized merkleblock message) proving the inclusion of one or more transactions in
+// a block.
+//
+// When a block hash is given, the proof is built against that block. Otherwise the block is
+// looked up from the UTXO store using the first transaction id, which must be mined in a block
+// on the current best chain. All transactions must be in the same block.
+//
+// The block's transaction hashes are read from its subtrees in the subtree store, rather than
+// loading the full block, with the coinbase placeholder in the first subtree replaced by the
+// coinbase transaction id. The resulting proof is the standard partial merkle tree over all
+// transactions in the block, so it can be verified by any Bitcoin client.
+//
+// Parameters:
+//   - ctx: Context for cancellation and tracing
+//   - s: The RPC server instance providing access to service clients
+//   - cmd: The parsed command arguments (bsvjson.GetTxOutProofCmd)
+//   - _: Unused channel for close notification
+//
+// Returns:
+//   - interface{}: The hex encoded merkle proof
+//   - error: Any error encountered during processing
+func handleGetTxOutProof(ctx context.Context, s *RPCServer, cmd interface{}, _ <-chan struct{}) (interface{}, error) {
+	ctx, _, deferFn := tracing.Tracer("rpc").Start(ctx, "handleGetTxOutProof",
+		tracing.WithParentStat(RPCStat),
+		tracing.WithHistogram(prometheusHandleGetTxOutProof),
+		tracing.WithLogMessage(s.logger, "[handleGetTxOutProof] called"),
+	)
+	defer deferFn()
+
+	c := cmd.(*bsvjson.GetTxOutProofCmd)
+
+	if len(c.TxIDs) == 0 {
+		return nil, &bsvjson.RPCError{
+			Code:    bsvjson.ErrRPCInvalidParameter,
+			Message: "Invalid parameter, txids must not be empty",
+		}
+	}
+
+	txHashes := make([]*chainhash.Hash, 0, len(c.TxIDs))
+	seen := make(map[chainhash.Hash]struct{}, len(c.TxIDs))
+
+	for _, txID := range c.TxIDs {
+		txHash, err := chainhash.NewHashFromStr(txID)
+		if err != nil {
+			return nil, rpcDecodeHexError(txID)
+		}
+
+		if _, ok := seen[*txHash]; ok {
+			return nil, &bsvjson.RPCError{
+				Code:    bsvjson.ErrRPCInvalidParameter,
+				Message: "Invalid parameter, duplicated txid: " + txID,
+			}
+		}
+
+		seen[*txHash] = struct{}{}
+		txHashes = append(txHashes, txHash)
+	}
+
+	block, err := s.txOutProofBlock(ctx, c.BlockHash, txHashes[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if s.subtreeStore == nil {
+		return nil, s.internalRPCError("subtree store not available", "gettxoutproof")
+	}
+
+	if err = block.GetAndValidateSubtrees(ctx, s.logger, s.subtreeStore, s.settings.Block.GetAndValidateSubtreesConcurrency); err != nil {
+		return nil, s.internalRPCError(err.Error(), "gettxoutproof: failed to get subtrees for block "+block.Hash().String())
+	}
+
+	blockTxHashes := model.BlockTxHashes(block.CoinbaseTx, block.SubtreeSlices)
+
+	leaves := make([]*chainhash.Hash, len(blockTxHashes))
+	for i := range blockTxHashes {
+		leaves[i] = &blockTxHashes[i]
+	}
+
+	msgMerkleBlock, matchedIndices := merkleblock.NewMerkleBlockWithTxHashes(block.Header.ToWireBlockHeader(), leaves, txHashes)
+	if len(matchedIndices) != len(txHashes) {
+		return nil, &bsvjson.RPCError{
+			Code:    bsvjson.ErrRPCInvalidAddressOrKey,
+			Message: "Not all transactions found in specified or retrieved block",
+		}
+	}
+
+	var buf bytes.Buffer
+	if err = msgMerkleBlock.BsvEncode(&buf, wire.ProtocolVersion, wire.BaseEncoding); err != nil {
+		return nil, s.internalRPCError(err.Error(), "gettxoutproof: failed to serialize merkle block")
+	}
+
+	return hex.EncodeToString(buf.Bytes()), nil
+}
+
+// txOutProofBlock returns the block a merkle proof should be built against: the block with the
+// given hash if one was specified, or otherwise the block on the current best chain that the given
+// transaction was mined in.
+func (s *RPCServer) txOutProofBlock(ctx context.Context, blockHashStr *string, txHash *chainhash.Hash) (*model.Block, error) {
+	if blockHashStr != nil && *blockHashStr != "" {
+		blockHash, err := chainhash.NewHashFromStr(*blockHashStr)
+		if err != nil {
+			return nil, rpcDecodeHexError(*blockHashStr)
+		}
+
+		block, err := s.blockchainClient.GetBlock(ctx, blockHash)
+		if err != nil || block == nil {
+			return nil, &bsvjson.RPCError{
+				Code:    bsvjson.ErrRPCBlockNotFound,
+				Message: "Block not found",
+			}
+		}
+
+		return block, nil
+	}
+
+	txMeta, err := s.utxoStore.Get(ctx, txHash, fields.BlockIDs, fields.UnminedSince)
+	if err != nil && !errors.Is(err, errors.ErrTxNotFound) && !errors.Is(err, errors.ErrNotFound) {
+		return nil, s.internalRPCError(err.Error(), "gettxoutproof: failed to get transaction "+txHash.String())
+	}
+
+	if txMeta != nil && txMeta.UnminedSince == 0 {
+		for _, blockID := range txMeta.BlockIDs {
+			onCurrentChain, err := s.blockchainClient.CheckBlockIsInCurrentChain(ctx, []uint32{blockID})
+			if err != nil {
+				return nil, s.internalRPCError(err.Error(), "gettxoutproof: failed to check block inclusion")
+			}
+
+			if !onCurrentChain {
+				continue
+			}
+
+			block, err := s.blockchainClient.GetBlockByID(ctx, uint64(blockID))
+			if err != nil {
+				return nil, s.internalRPCError(err.Error(), "gettxoutproof: failed to get block")
+			}
+
+			if block != nil {
+				return block, nil
+			}
+		}
+	}
+
+	return nil, &bsvjson.RPCError{
+		Code:    bsvjson.ErrRPCInvalidAddressOrKey,
+		Message: "Transaction not yet in block",
+	}
+}
+
+// handleVerifyTxOutProof implements the verifytxoutproof command, which verifies a merkle proof
+// as returned by gettxoutproof and returns the transaction ids it commits to.
+//
+// The partial merkle tree in the proof is traversed to compute the merkle root, which must match
+// the merkle root in the proof's block header. An empty list is returned for an invalid proof.
+// A valid proof for a block that is not part of the current best chain results in an error.
+//
+// Parameters:
+//   - ctx: Context for cancellation and tracing
+//   - s: The RPC server instance providing access to service clients
+//   - cmd: The parsed command arguments (bsvjson.VerifyTxOutProofCmd)
+//   - _: Unused channel for close notification
+//
+// Returns:
+//   - interface{}: A list of the transaction ids the proof commits to
+//   - error: Any error encountered during processing
+func handleVerifyTxOutProof(ctx context.Context, s *RPCServer, cmd interface{}, _ <-chan struct{}) (interface{}, error) {
+	ctx, _, deferFn := tracing.Tracer("rpc").Start(ctx, "handleVerifyTxOutProof",
+		tracing.WithParentStat(RPCStat),
+		tracing.WithHistogram(prometheusHandleVerifyTxOutProof),
+		tracing.WithLogMessage(s.logger, "[handleVerifyTxOutProof] called"),
+	)
+	defer deferFn()
+
+	c := cmd.(*bsvjson.VerifyTxOutProofCmd)
+
+	proofBytes, err := hex.DecodeString(c.Proof)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.Proof)
+	}
+
+	var msgMerkleBlock wire.MsgMerkleBlock
+	if err = msgMerkleBlock.Bsvdecode(bytes.NewReader(proofBytes), wire.ProtocolVersion, wire.BaseEncoding); err != nil {
+		return nil, &bsvjson.RPCError{
+			Code:    bsvjson.ErrRPCDeserialization,
+			Message: "Failed to deserialize merkle proof: " + err.Error(),
+		}
+	}
+
+	partialBlock := merkleblock.NewMerkleBlockFromMsg(msgMerkleBlock)
+
+	merkleRoot, err := partialBlock.ExtractMatches()
+	if err != nil || !merkleRoot.IsEqual(&msgMerkleBlock.Header.MerkleRoot) {
+		return []string{}, nil
+	}
+
+	blockHash := msgMerkleBlock.Header.BlockHash()
+
+	_, blockMeta, err := s.blockchainClient.GetBlockHeader(ctx, &blockHash)
+	if err != nil || blockMeta == nil {
+		return nil, &bsvjson.RPCError{
+			Code:    bsvjson.ErrRPCInvalidAddressOrKey,
+			Message: "Block not found in chain",
+		}
+	}
+
+	onCurrentChain, err := s.blockchainClient.CheckBlockIsInCurrentChain(ctx, []uint32{blockMeta.ID})
+	if err != nil {
+		return nil, s.internalRPCError(err.Error(), "verifytxoutproof: failed to check block inclusion")
+	}
+
+	if !onCurrentChain {
+		return nil, &bsvjson.RPCError{
+			Code:    bsvjson.ErrRPCInvalidAddressOrKey,
+			Message: "Block not found in chain",
+		}
+	}
+
+	matches := partialBlock.GetMatches()
+	txIDs := make([]string, len(matches))
+
+	for i, match := range matches {
+		txIDs[i] = match.String()
+	}
+
+	return txIDs, nil
+}
+
+// handleCreateRawTransaction handles createrawtransaction commands.
+func handleCreateRawTransaction(ctx context.Context, s *RPCServer, cmd interface{}, _ <-chan struct{}) (interface{}, error) {
+	_, _, deferFn := tracing.Tracer("rpc").Start(ctx, "handleCreateRawTransaction",
+		tracing.WithParentStat(RPCStat),
+		tracing.WithHistogram(prometheusHandleCreateRawTransaction),
+		tracing.WithLogMessage(s.logger, "[handleCreateRawTransaction] called"),
+	)
+	defer deferFn()
+
+	c := cmd.(*bsvjson.CreateRawTransactionCmd)
+
+	// Validate the locktime, if given.
+	if c.LockTime != nil &&
+		(*c.LockTime < 0 || *c.LockTime > int64(wire.MaxTxInSequenceNum)) {
+		return nil, &bsvjson.RPCError{
+			Code:    bsvjson.ErrRPCInvalidParameter,
+			Message: "Locktime out of range",
+		}
+	}
+
+	// Add all transaction inputs to a new transaction after performing
+	// some validity checks.
+	mtx := wire.NewMsgTx(wire.TxVersion)
+
+	for _, input := range c.Inputs {
+		txHash, err := chainhash.NewHashFromStr(input.Txid)
+		if err != nil {
+			return nil, rpcDecodeHexError(input.Txid)
+		}
+
+		prevOut := wire.NewOutPoint(txHash, input.Vout)
+		txIn := wire.NewTxIn(prevOut, []byte{})
+
+		if c.LockTime != nil && *c.LockTime != 0 {
+			txIn.Sequence = wire.MaxTxInSequenceNum - 1
+		}
+
+		mtx.AddTxIn(txIn)
+	}
+
+	// Add all transaction outputs to the transaction after performing
+	// some validity checks.
+	// params := s.cfg.ChainParams
+	for encodedAddr, amount := range c.Amounts {
+		// Ensure amount is in the valid range for monetary amounts.
+		if amount <= 0 || amount > bsvutil.MaxSatoshi {
+			return nil, &bsvjson.RPCError{
+				Code:    bsvjson.ErrRPCType,
+				Message: "Invalid amount",
+			}
+		}
+
+		// Decode the provided address.
+		addr, err := bsvutil.DecodeAddress(encodedAddr, s.settings.ChainCfgParams)
+		if err != nil {
+			return nil, &bsvjson.RPCError{
+				Code:    bsvjson.ErrRPCInvalidAddressOrKey,
+				Message: "Invalid address or key: " + err.Error(),
+			}
+		}
+
+		// Ensure the address is one of the supported types and that
+		// the network encoded with the address matches the network the
+		// server is currently on.
+		switch addr.(type) {
+		case *bsvutil.AddressPubKeyHash:
+		case *bsvutil.AddressScriptHash:
+		case *bsvutil.LegacyAddressPubKeyHash: // TODO: support legacy addresses?
+		default:
+			return nil, &bsvjson.RPCError{
+				Code:    bsvjson.ErrRPCInvalidAddressOrKey,
+				Message: `Invalid address or key`,
+			}
+		}
+
+		if !addr.IsForNet(s.settings.ChainCfgParams) {
+			return nil, &bsvjson.RPCError{
+				Code: bsvjson.ErrRPCInvalidAddressOrKey,
+				Message: "Invalid address: " + encodedAddr +
+					" is for the wrong network",
+			}
+		}
+
+		// Create a new script which pays to the provided address.
+		pkScript, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			context := "Failed to generate pay-to-address script"
+			return nil, s.internalRPCError(err.Error(), context)
+		}
+
+		// Convert the amount to satoshi.
+		satoshi, err := bsvutil.NewAmount(amount)
+		if err != nil {
+			context := "Failed to convert amount"
+			return nil, s.internalRPCError(err.Error(), context)
+		}
+
+		txOut := wire.NewTxOut(int64(satoshi), pkScript)
+		mtx.AddTxOut(txOut)
+	}
+
+	// Set the Locktime, if given.
+	if c.LockTime != nil {
+		lockTimeUint32, err := safeconversion.Int64ToUint32(*c.LockTime)
+		if err != nil {
+			return nil, err
+		}
+
+		mtx.LockTime = lockTimeUint32
+	}
+
+	// Return the serialized and hex-encoded transaction.  Note that this
+	// is intentionally not directly returning because the first return
+	// value is a string and it would result in returning an empty string to
+	// the client instead of nothing (nil) in the case of an error.
+	mtxHex, err := s.messageToHex(mtx)
+	if err != nil {
+		return nil, err
+	}
+
+	return mtxHex, nil
+}
+
+// handleDecodeRawTransaction implements the decoderawtransaction command, which decodes a
+// serialized, hex-encoded transaction without looking it up or validating it.
+//
+// The inputs are returned with their disassembled unlocking scripts, and the outputs with their
+// disassembled locking scripts, script class and the addresses they pay to, encoded for the
+// network the node is running on. Extended format transactions are accepted as well.
+//
+// Parameters:
+//   - ctx: Context for cancellation and tracing
+//   - s: The RPC server instance providing access to the chain parameters
+//   - cmd: The parsed command arguments (bsvjson.DecodeRawTransactionCmd)
+//   - _: Unused channel for close notification
+//
+// Returns:
+//   - interface{}: bsvjson.TxRawDecodeResult with the decoded transaction
+//   - error: ErrRPCDecodeHexString or ErrRPCDeserialization when the transaction cannot be decoded
+func handleDecodeRawTransaction(ctx context.Context, s *RPCServer, cmd interface{}, _ <-chan struct{}) (interface{}, error) {
+	_, _, deferFn := tracing.Tracer("rpc").Start(ctx, "handleDecodeRawTransaction",
+		tracing.WithParentStat(RPCStat),
+		tracing.WithHistogram(prometheusHandleDecodeRawTransaction),
+		tracing.WithLogMessage(s.logger, "[handleDecodeRawTransaction] called"),
+	)
+	defer deferFn()
+
+	c := cmd.(*bsvjson.DecodeRawTransactionCmd)
+
+	hexStr := c.HexTx
+	if len(hexStr)%2 != 0 {
+		hexStr = "0" + hexStr
+	}
+
+	txBytes, err := hex.DecodeString(hexStr)
+	if err != nil {
+		return nil, rpcDecodeHexError(hexStr)
+	}
+
+	tx, err := bt.NewTxFromBytes(txBytes)
+	if err != nil {
+		return nil, &bsvjson.RPCError{
+			Code:    bsvjson.ErrRPCDeserialization,
+			Message: "TX decode failed: " + err.Error(),
+		}
+	}
+
+	vout, err := s.createVoutList(tx)
+	if err != nil {
+		return nil, s.internalRPCError(err.Error(), "decoderawtransaction: failed to decode locking script")
+	}
+
+	return bsvjson.TxRawDecodeResult{
+		Txid:     tx.TxID(),
+		Version:  int32(tx.Version), //nolint:gosec
+		Locktime: tx.LockTime,
+		Vin:      createVinList(tx),
+		Vout:     vout,
+	}, nil
+}
+
+// createVinList converts the inputs of a transaction into their JSON-RPC representation. The
+// unlocking scripts are disassembled on a best effort basis, an unparsable script is disassembled
+// up to the point of failure.
+func createVinList(tx *bt.Tx) []bsvjson.Vin {
+	vin := make([]bsvjson.Vin, len(tx.Inputs))
+
+	if tx.IsCoinbase() {
+		vin[0] = bsvjson.Vin{
+			Coinbase: hex.EncodeToString(tx.Inputs[0].UnlockingScript.Bytes()),
+			Sequence: tx.Inputs[0].SequenceNumber,
+		}
+
+		return vin
+	}
+
+	for i, input := range tx.Inputs {
+		asm, _ := txscript.DisasmString(input.UnlockingScript.Bytes())
+
+		vin[i] = bsvjson.Vin{
+			Txid: input.PreviousTxIDStr(),
+			Vout: input.PreviousTxOutIndex,
+			ScriptSig: &bsvjson.ScriptSig{
+				Asm: asm,
+				Hex: hex.EncodeToString(input.UnlockingScript.Bytes()),
+			},
+			Sequence: input.SequenceNumber,
+		}
+	}
+
+	return vin
+}
+
+// createVoutList converts the outputs of a transaction into their JSON-RPC representation, with
+// the values in BSV.
+func (s *RPCServer) createVoutList(tx *bt.Tx) ([]bsvjson.Vout, error) {
+	vout := make([]bsvjson.Vout, len(tx.Outputs))
+
+	for i, output := range tx.Outputs {
+		scriptPubKey, err := s.scriptPubKeyToJSON(output.LockingScript.Bytes())
+		if err != nil {
+			return nil, err
+		}
+
+		vout[i] = bsvjson.Vout{
+			Value:        bsvutil.Amount(output.Satoshis).ToBSV(), //nolint:gosec
+			N:            uint32(i),                               //nolint:gosec
+			ScriptPubKey: scriptPubKey,
+		}
+	}
+
+	return vout, nil
+}
+
+// handleDecodeScript implements the decodescript command, which decodes a hex-encoded script.
+//
+// The script is disassembled and classified with the standard script templates, and the addresses
+// it pays to are encoded for the network the node is running on. Unless the script is already a
+// pay-to-script-hash script, the pay-to-script-hash address of the script is returned as well.
+//
+// Parameters:
+//   - ctx: Context for cancellation and tracing
+//   - s: The RPC server instance providing access to the chain parameters
+//   - cmd: The parsed command arguments (bsvjson.DecodeScriptCmd)
+//   - _: Unused channel for close notification
+//
+// Returns:
+//   - interface{}: bsvjson.DecodeScriptResult with the decoded script
+//   - error: ErrRPCDecodeHexString when the script is not valid hex
+func handleDecodeScript(ctx context.Context, s *RPCServer, cmd interface{}, _ <-chan struct{}) (interface{}, error) {
+	_, _, deferFn := tracing.Tracer("rpc").Start(ctx, "handleDecodeScript",
+		tracing.WithParentStat(RPCStat),
+		tracing.WithHistogram(prometheusHandleDecodeScript),
+		tracing.WithLogMessage(s.logger, "[handleDecodeScript] called"),
+	)
+	defer deferFn()
+
+	c := cmd.(*bsvjson.DecodeScriptCmd)
+
+	hexStr := c.HexScript
+	if len(hexStr)%2 != 0 {
+		hexStr = "0" + hexStr
+	}
+
+	script, err := hex.DecodeString(hexStr)
+	if err != nil {
+		return nil, rpcDecodeHexError(hexStr)
+	}
+
+	// unparsable scripts are decoded as non-standard scripts, with the opcodes up to the point of failure
+	scriptPubKey, _ := s.scriptPubKeyToJSON(script)
+
+	result := bsvjson.DecodeScriptResult{
+		Asm:       scriptPubKey.Asm,
+		ReqSigs:   scriptPubKey.ReqSigs,
+		Type:      scriptPubKey.Type,
+		Addresses: scriptPubKey.Addresses,
+	}
+
+	if scriptPubKey.Type != txscript.ScriptHashTy.String() {
+		p2sh, err := bsvutil.NewAddressScriptHash(script, s.settings.ChainCfgParams)
+		if err != nil {
+			return nil, s.internalRPCError(err.Error(), "decodescript: failed to convert script to pay-to-script-hash")
+		}
+
+		result.P2sh = p2sh.EncodeAddress()
+	}
+
+	return result, nil
+}
+
+// handleValidateAddress implements the validateaddress command, which checks whether an address
+// is valid for the network the node is running on.
+//
+// Both cash addresses and legacy base58 addresses are accepted. An invalid address is not an
+// error, the result then only reports isvalid as false.
+//
+// Parameters:
+//   - ctx: Context for cancellation and tracing
+//   - s: The RPC server instance providing access to the chain parameters
+//   - cmd: The parsed command arguments (bsvjson.ValidateAddressCmd)
+//   - _: Unused channel for close notification
+//
+// Returns:
+//   - interface{}: bsvjson.ValidateAddressChainResult for the address
+//   - error: Always nil
+func handleValidateAddress(ctx context.Context, s *RPCServer, cmd interface{}, _ <-chan struct{}) (interface{}, error) {
+	_, _, deferFn := tracing.Tracer("rpc").Start(ctx, "handleValidateAddress",
+		tracing.WithParentStat(RPCStat),
+		tracing.WithHistogram(prometheusHandleValidateAddress),
+		tracing.WithLogMessage(s.logger, "[handleValidateAddress] called"),
+	)
+	defer deferFn()
+
+	c := cmd.(*bsvjson.ValidateAddressCmd)
+
+	addr, err := bsvutil.DecodeAddress(c.Address, s.settings.ChainCfgParams)
+	if err != nil || !addr.IsForNet(s.settings.ChainCfgParams) {
+		return bsvjson.ValidateAddressChainResult{IsValid: false}, nil
+	}
+
+	result := bsvjson.ValidateAddressChainResult{
+		IsValid: true,
+		Address: addr.EncodeAddress(),
+	}
+
+	if pkScript, err := txscript.PayToAddrScript(addr); err == nil {
+		isScript := txscript.GetScriptClass(pkScript) == txscript.ScriptHashTy
+
+		result.ScriptPubKey = hex.EncodeToString(pkScript)
+		result.IsScript = &isScript
+	}
+
+	return result, nil
+}
+
+// signedMessageMagic is the prefix of the messages signed with the private key of an address,
+// verified by the verifymessage command
+const signedMessageMagic = "Bitcoin Signed Message:\n"
+
+// handleVerifyMessage implements the verifymessage command, which verifies a message signed with
+// the private key of a pay-to-pubkey-hash address.
+//
+// The public key is recovered from the compact signature over the double SHA256 of the message,
+// prefixed with the Bitcoin signed message magic, and its hash is compared with the hash of the
+// address. Like bitcoind, a signature from which no public key can be recovered is reported as
+// not verified rather than as an error.
+//
+// Parameters:
+//   - ctx: Context for cancellation and tracing
+//   - s: The RPC server instance providing access to the chain parameters
+//   - cmd: The parsed command arguments (bsvjson.VerifyMessageCmd)
+//   - _: Unused channel for close notification
+//
+// Returns:
+//   - interface{}: Boolean indicating whether the signature is valid for the address
+//   - error: ErrRPCInvalidAddressOrKey, ErrRPCType or ErrRPCParse for invalid arguments
+func handleVerifyMessage(ctx context.Context, s *RPCServer, cmd interface{}, _ <-chan struct{}) (interface{}, error) {
+	_, _, deferFn := tracing.Tracer("rpc").Start(ctx, "handleVerifyMessage",
+		tracing.WithParentStat(RPCStat),
+		tracing.WithHistogram(prometheusHandleVerifyMessage),
+		tracing.WithLogMessage(s.logger, "[handleVerifyMessage] called"),
+	)
+	defer deferFn()
+
+	c := cmd.(*bsvjson.VerifyMessageCmd)
+
+	addr, err := s.decodeAddressForNet(c.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	// only pay-to-pubkey-hash addresses can be used for signing
+	switch addr.(type) {
+	case *bsvutil.AddressPubKeyHash, *bsvutil.LegacyAddressPubKeyHash:
+	default:
 		return nil, &bsvjson.RPCError{
-			Code:    bsvjson.ErrRPCInvalidParameter,
-			Message: "Locktime out of range",
+			Code:    bsvjson.ErrRPCType,
+			Message: "Address is not a pay-to-pubkey-hash address",
 		}
 	}
 
-	// Add all transaction inputs to a new transaction after performing
-	// some validity checks.
-	mtx := wire.NewMsgTx(wire.TxVersion)
-
-	for _, input := range c.Inputs {
-		txHash, err := chainhash.NewHashFromStr(input.Txid)
-		if err != nil {
-			return nil, rpcDecodeHexError(input.Txid)
+	sig, err := base64.StdEncoding.DecodeString(c.Signature)
+	if err != nil {
+		return nil, &bsvjson.RPCError{
+			Code:    bsvjson.ErrRPCParse.Code,
+			Message: "Malformed base64 encoding: " + err.Error(),
 		}
+	}
 
-		prevOut := wire.NewOutPoint(txHash, input.Vout)
-		txIn := wire.NewTxIn(prevOut, []byte{})
+	var buf bytes.Buffer
 
-		if c.LockTime != nil && *c.LockTime != 0 {
-			txIn.Sequence = wire.MaxTxInSequenceNum - 1
-		}
+	_ = wire.WriteVarString(&buf, 0, signedMessageMagic)
+	_ = wire.WriteVarString(&buf, 0, c.Message)
 
-		mtx.AddTxIn(txIn)
+	pubKey, wasCompressed, err := bsvec.RecoverCompact(bsvec.S256(), sig, chainhash.DoubleHashB(buf.Bytes()))
+	if err != nil {
+		return false, nil
 	}
 
-	// Add all transaction outputs to the transaction after performing
-	// some validity checks.
-	// params := s.cfg.ChainParams
-	for encodedAddr, amount := range c.Amounts {
-		// Ensure amount is in the valid range for monetary amounts.
-		if amount <= 0 || amount > bsvutil.MaxSatoshi {
-			return nil, &bsvjson.RPCError{
-				Code:    bsvjson.ErrRPCType,
-				Message: "Invalid amount",
-			}
-		}
+	serializedPubKey := pubKey.SerializeUncompressed()
+	if wasCompressed {
+		serializedPubKey = pubKey.SerializeCompressed()
+	}
 
-		// Decode the provided address.
-		addr, err := bsvutil.DecodeAddress(encodedAddr, s.settings.ChainCfgParams)
-		if err != nil {
-			return nil, &bsvjson.RPCError{
-				Code:    bsvjson.ErrRPCInvalidAddressOrKey,
-				Message: "Invalid address or key: " + err.Error(),
-			}
-		}
+	return bytes.Equal(bsvutil.Hash160(serializedPubKey), addr.ScriptAddress()), nil
+}
 
-		// Ensure the address is one of the supported types and that
-		// the network encoded with the address matches the network the
-		// server is currently on.
-		switch addr.(type) {
-		case *bsvutil.AddressPubKeyHash:
-		case *bsvutil.AddressScriptHash:
-		case *bsvutil.LegacyAddressPubKeyHash: // TODO: support legacy addresses?
-		default:
-			return nil, &bsvjson.RPCError{
-				Code:    bsvjson.ErrRPCInvalidAddressOrKey,
-				Message: `Invalid address or key`,
-			}
-		}
+// handleVerifyChain implements the verifychain command, which re-audits the blocks of the best
+// chain from the given depth below the tip up to the tip.
+//
+// The check levels are cumulative:
+//   - 0: every block can be loaded, links to its parent and meets its proof of work target
+//   - 1: the difficulty of every block is the difficulty required by the chain
+//   - 2: the subtree and subtree data of every block exist in the subtree store
+//   - 3: the merkle root computed from the stored subtrees matches the block header
+//   - 4: every block is valid against the UTXO store
+//
+// Like bitcoind the result is a single boolean, the problems found are logged. The verify-chain
+// command of teranode-cli runs the same checks offline and reports the problems.
+//
+// Parameters:
+//   - ctx: Context for cancellation and tracing
+//   - s: The RPC server instance providing access to the blockchain client and the stores
+//   - cmd: The parsed command arguments (bsvjson.VerifyChainCmd)
+//   - _: Unused channel for close notification
+//
+// Returns:
+//   - interface{}: Boolean indicating whether no problems were found
+//   - error: ErrRPCInvalidParameter if the check level or depth is invalid
+func handleVerifyChain(ctx context.Context, s *RPCServer, cmd interface{}, _ <-chan struct{}) (interface{}, error) {
+	ctx, _, deferFn := tracing.Tracer("rpc").Start(ctx, "handleVerifyChain",
+		tracing.WithParentStat(RPCStat),
+		tracing.WithHistogram(prometheusHandleVerifyChain),
+		tracing.WithLogMessage(s.logger, "[handleVerifyChain] called"),
+	)
+	defer deferFn()
 
-		if !addr.IsForNet(s.settings.ChainCfgParams) {
-			return nil, &bsvjson.RPCError{
-				Code: bsvjson.ErrRPCInvalidAddressOrKey,
-				Message: "Invalid address: " + encodedAddr +
-					" is for the wrong network",
-			}
-		}
+	c := cmd.(*bsvjson.VerifyChainCmd)
 
-		// Create a new script which pays to the provided address.
-		pkScript, err := txscript.PayToAddrScript(addr)
-		if err != nil {
-			context := "Failed to generate pay-to-address script"
-			return nil, s.internalRPCError(err.Error(), context)
-		}
+	checkLevel := int32(chainverifier.LevelMerkleRoot)
+	if c.CheckLevel != nil {
+		checkLevel = *c.CheckLevel
+	}
 
-		// Convert the amount to satoshi.
-		satoshi, err := bsvutil.NewAmount(amount)
-		if err != nil {
-			context := "Failed to convert amount"
-			return nil, s.internalRPCError(err.Error(), context)
+	if checkLevel < chainverifier.LevelHeaders || checkLevel > chainverifier.LevelBlock {
+		return nil, &bsvjson.RPCError{
+			Code:    bsvjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Invalid checklevel, must be between %d - %d", chainverifier.LevelHeaders, chainverifier.LevelBlock),
 		}
+	}
 
-		txOut := wire.NewTxOut(int64(satoshi), pkScript)
-		mtx.AddTxOut(txOut)
+	var checkDepth int32 = 288
+	if c.CheckDepth != nil {
+		checkDepth = *c.CheckDepth
 	}
 
-	// Set the Locktime, if given.
-	if c.LockTime != nil {
-		lockTimeUint32, err := safeconversion.Int64ToUint32(*c.LockTime)
-		if err != nil {
-			return nil, err
+	if checkDepth < 0 {
+		return nil, &bsvjson.RPCError{
+			Code:    bsvjson.ErrRPCInvalidParameter,
+			Message: "Invalid checkdepth, must not be negative",
 		}
-
-		mtx.LockTime = lockTimeUint32
 	}
 
-	// Return the serialized and hex-encoded transaction.  Note that this
-	// is intentionally not directly returning because the first return
-	// value is a string and it would result in returning an empty string to
-	// the client instead of nothing (nil) in the case of an error.
-	mtxHex, err := s.messageToHex(mtx)
+	verifier := chainverifier.New(s.logger, s.settings, s.blockchainClient, s.subtreeStore, s.utxoStore)
+
+	result, err := verifier.Verify(ctx, chainverifier.Options{
+		Level: int(checkLevel),
+		Depth: uint32(checkDepth), //nolint:gosec
+	})
 	if err != nil {
-		return nil, err
+		return nil, s.internalRPCError(err.Error(), "verifychain: failed to verify the chain")
 	}
 
-	return mtxHex, nil
+	return len(result.Problems) == 0, nil
 }
 
 // handleSendRawTransaction implements the sendrawtransaction command, which submits a
@@ -1238,55 +2495,377 @@ func handleGetpeerinfo(ctx context.Context, s *RPCServer, cmd interface{}, _ <-c
 		}
 	}
 
-	if s.settings.RPC.CacheEnabled {
-		rpcCallCache.Set("getpeerinfo", infos, 10*time.Second)
+	if s.settings.RPC.CacheEnabled {
+		rpcCallCache.Set("getpeerinfo", infos, 10*time.Second)
+	}
+
+	// return peerInfo, nil
+	return infos, nil
+}
+
+// handleGetRawMempool implements the getrawmempool command.
+// Returns transaction IDs currently in the memory pool.
+//
+// Teranode has no mempool of its own, the transactions in block assembly are the transactions
+// waiting to be mined. When verbose is set, the fee, size and parents of every transaction are
+// fetched from the UTXO store, and a JSON object keyed by transaction id is returned. The height
+// and time of an entry are those of the chain tip when the transaction was accepted.
+//
+// Parameters:
+//   - ctx: Context for cancellation and tracing
+//   - s: The RPC server instance providing access to block assembly and the UTXO store
+//   - cmd: The parsed command arguments (bsvjson.GetRawMempoolCmd)
+//   - _: Unused channel for close notification
+//
+// Returns:
+//   - interface{}: Array of transaction ids, or map of transaction id to
+//     bsvjson.GetRawMempoolVerboseResult when verbose is set
+//   - error: Any error encountered while retrieving the transactions
+func handleGetRawMempool(ctx context.Context, s *RPCServer, cmd interface{}, _ <-chan struct{}) (interface{}, error) {
+	ctx, _, deferFn := tracing.Tracer("rpc").Start(ctx, "handleGetRawMempool",
+		tracing.WithParentStat(RPCStat),
+		tracing.WithHistogram(prometheusHandleGetRawmempool),
+		tracing.WithLogMessage(s.logger, "[handleGetRawMempool] called"),
+	)
+	defer deferFn()
+
+	verbose := cmd.(*bsvjson.GetRawMempoolCmd).Verbose
+
+	if verbose != nil && *verbose {
+		m, err := s.loadMempool(ctx)
+		if err != nil {
+			return nil, &bsvjson.RPCError{
+				Code:    bsvjson.ErrRPCInternal.Code,
+				Message: "Error retrieving raw mempool: " + err.Error(),
+			}
+		}
+
+		result := make(map[string]bsvjson.GetRawMempoolVerboseResult, len(m.hashes))
+		for _, hash := range m.hashes {
+			result[hash.String()] = m.entries[hash].verboseResult()
+		}
+
+		return result, nil
+	}
+
+	txs, err := s.blockAssemblyClient.GetTransactionHashes(ctx)
+	if err != nil {
+		return nil, &bsvjson.RPCError{
+			Code:    bsvjson.ErrRPCInternal.Code,
+			Message: "Error retrieving raw mempool: " + err.Error(),
+		}
+	}
+
+	return txs, nil
+}
+
+// handleGetMempoolInfo implements the getmempoolinfo command.
+//
+// This command returns the number of transactions waiting to be mined in block assembly, their
+// total size in bytes and total fee, and the minimum fee rate for a transaction to be accepted
+// (minminingtxfee). The sizes and fees are fetched from the UTXO store.
+//
+// Parameters:
+//   - ctx: Context for cancellation and tracing
+//   - s: The RPC server instance providing access to block assembly and the UTXO store
+//   - _: Unused command arguments (bsvjson.GetMempoolInfoCmd)
+//   - _: Unused channel for close notification
+//
+// Returns:
+//   - interface{}: *bsvjson.GetMempoolInfoResult with the totals of the pending transactions
+//   - error: Any error encountered while retrieving the transactions
+func handleGetMempoolInfo(ctx context.Context, s *RPCServer, _ interface{}, _ <-chan struct{}) (interface{}, error) {
+	ctx, _, deferFn := tracing.Tracer("rpc").Start(ctx, "handleGetMempoolInfo",
+		tracing.WithParentStat(RPCStat),
+		tracing.WithHistogram(prometheusHandleGetMempoolInfo),
+		tracing.WithLogMessage(s.logger, "[handleGetMempoolInfo] called"),
+	)
+	defer deferFn()
+
+	m, err := s.loadMempool(ctx)
+	if err != nil {
+		return nil, &bsvjson.RPCError{
+			Code:    bsvjson.ErrRPCInternal.Code,
+			Message: "Error retrieving mempool: " + err.Error(),
+		}
+	}
+
+	return &bsvjson.GetMempoolInfoResult{
+		Size:          int64(len(m.hashes)),
+		Bytes:         int64(m.bytes), //nolint:gosec
+		TotalFee:      float64(m.fees) / 1e8,
+		MempoolMinFee: s.settings.Policy.GetMinMiningTxFee(),
+	}, nil
+}
+
+// handleGetMempoolEntry implements the getmempoolentry command.
+//
+// This command returns the fee, size and parents of a transaction waiting to be mined in block
+// assembly, together with the number, size and fees of its in-mempool ancestors and descendants.
+// The ancestors and descendants are walked by block assembly through its in-memory transaction map,
+// and only these transactions are fetched from the UTXO store.
+//
+// Parameters:
+//   - ctx: Context for cancellation and tracing
+//   - s: The RPC server instance providing access to block assembly and the UTXO store
+//   - cmd: The parsed command arguments (bsvjson.GetMempoolEntryCmd)
+//   - _: Unused channel for close notification
+//
+// Returns:
+//   - interface{}: *bsvjson.GetMempoolEntryResult for the transaction
+//   - error: ErrRPCNoTxInfo when the transaction is not in the mempool
+func handleGetMempoolEntry(ctx context.Context, s *RPCServer, cmd interface{}, _ <-chan struct{}) (interface{}, error) {
+	ctx, _, deferFn := tracing.Tracer("rpc").Start(ctx, "handleGetMempoolEntry",
+		tracing.WithParentStat(RPCStat),
+		tracing.WithHistogram(prometheusHandleGetMempoolEntry),
+		tracing.WithLogMessage(s.logger, "[handleGetMempoolEntry] called"),
+	)
+	defer deferFn()
+
+	m, entry, err := s.getMempoolEntry(ctx, cmd.(*bsvjson.GetMempoolEntryCmd).TxID)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.entryResult(entry), nil
+}
+
+// handleGetMempoolAncestors implements the getmempoolancestors command.
+//
+// This command returns the in-mempool ancestors of a transaction waiting to be mined in block
+// assembly, walked by block assembly through its in-memory transaction map.
+//
+// Parameters:
+//   - ctx: Context for cancellation and tracing
+//   - s: The RPC server instance providing access to block assembly and the UTXO store
+//   - cmd: The parsed command arguments (bsvjson.GetMempoolAncestorsCmd)
+//   - _: Unused channel for close notification
+//
+// Returns:
+//   - interface{}: Array of transaction ids, or map of transaction id to
+//     bsvjson.GetMempoolEntryResult when verbose is set
+//   - error: ErrRPCNoTxInfo when the transaction is not in the mempool
+func handleGetMempoolAncestors(ctx context.Context, s *RPCServer, cmd interface{}, _ <-chan struct{}) (interface{}, error) {
+	ctx, _, deferFn := tracing.Tracer("rpc").Start(ctx, "handleGetMempoolAncestors",
+		tracing.WithParentStat(RPCStat),
+		tracing.WithHistogram(prometheusHandleGetMempoolAncestors),
+		tracing.WithLogMessage(s.logger, "[handleGetMempoolAncestors] called"),
+	)
+	defer deferFn()
+
+	c := cmd.(*bsvjson.GetMempoolAncestorsCmd)
+
+	m, entry, err := s.getMempoolEntry(ctx, c.TxID)
+	if err != nil {
+		return nil, err
+	}
+
+	verbose := c.Verbose != nil && *c.Verbose
+	hashes := m.ancestors(entry.hash)
+
+	if verbose && len(hashes) > 0 {
+		// the verbose results need the ancestors and descendants of every ancestor
+		if m, err = s.loadMempoolRelatives(ctx, hashes); err != nil {
+			return nil, mempoolError(err)
+		}
+	}
+
+	return m.relativesResult(hashes, verbose), nil
+}
+
+// handleGetMempoolDescendants implements the getmempooldescendants command.
+//
+// This command returns the in-mempool descendants of a transaction waiting to be mined in block
+// assembly, walked by block assembly through its in-memory transaction map.
+//
+// Parameters:
+//   - ctx: Context for cancellation and tracing
+//   - s: The RPC server instance providing access to block assembly and the UTXO store
+//   - cmd: The parsed command arguments (bsvjson.GetMempoolDescendantsCmd)
+//   - _: Unused channel for close notification
+//
+// Returns:
+//   - interface{}: Array of transaction ids, or map of transaction id to
+//     bsvjson.GetMempoolEntryResult when verbose is set
+//   - error: ErrRPCNoTxInfo when the transaction is not in the mempool
+func handleGetMempoolDescendants(ctx context.Context, s *RPCServer, cmd interface{}, _ <-chan struct{}) (interface{}, error) {
+	ctx, _, deferFn := tracing.Tracer("rpc").Start(ctx, "handleGetMempoolDescendants",
+		tracing.WithParentStat(RPCStat),
+		tracing.WithHistogram(prometheusHandleGetMempoolDescendants),
+		tracing.WithLogMessage(s.logger, "[handleGetMempoolDescendants] called"),
+	)
+	defer deferFn()
+
+	c := cmd.(*bsvjson.GetMempoolDescendantsCmd)
+
+	m, entry, err := s.getMempoolEntry(ctx, c.TxID)
+	if err != nil {
+		return nil, err
+	}
+
+	verbose := c.Verbose != nil && *c.Verbose
+	hashes := m.descendants(entry.hash)
+
+	if verbose && len(hashes) > 0 {
+		// the verbose results need the ancestors and descendants of every descendant
+		if m, err = s.loadMempoolRelatives(ctx, hashes); err != nil {
+			return nil, mempoolError(err)
+		}
+	}
+
+	return m.relativesResult(hashes, verbose), nil
+}
+
+// getMempoolEntry loads the transaction with the given id with its in-mempool ancestors and descendants
+// and returns its entry, returning RPC errors when the id is invalid or the transaction is not in the
+// mempool.
+func (s *RPCServer) getMempoolEntry(ctx context.Context, txID string) (*mempool, *mempoolEntry, error) {
+	txHash, err := chainhash.NewHashFromStr(txID)
+	if err != nil {
+		return nil, nil, rpcDecodeHexError(txID)
+	}
+
+	m, err := s.loadMempoolRelatives(ctx, []chainhash.Hash{*txHash})
+	if err != nil {
+		return nil, nil, mempoolError(err)
+	}
+
+	entry, ok := m.entries[*txHash]
+	if !ok {
+		return nil, nil, &bsvjson.RPCError{
+			Code:    bsvjson.ErrRPCNoTxInfo,
+			Message: "Transaction not in mempool",
+		}
+	}
+
+	return m, entry, nil
+}
+
+// mempoolError returns the RPC error for an error loading the mempool
+func mempoolError(err error) *bsvjson.RPCError {
+	return &bsvjson.RPCError{
+		Code:    bsvjson.ErrRPCInternal.Code,
+		Message: "Error retrieving mempool: " + err.Error(),
+	}
+}
+
+// handleEstimateFee implements the estimatefee command.
+//
+// This command estimates the fee rate a transaction needs to be mined within a number
+// of blocks, from the fee rates of the transactions accepted by the validator and the
+// number of blocks it took for them to be mined. The estimate is never lower than the
+// minimum mining fee (minminingtxfee).
+//
+// The number of blocks is limited to between 1 and the maximum target of the estimator.
+// When fee estimation is disabled (feeestimator_enabled), or not enough transactions
+// have been seen for an estimate, -1 is returned, like bitcoind.
+//
+// Parameters:
+//   - ctx: Context for cancellation and tracing
+//   - s: The RPC server instance providing access to the fee estimator
+//   - cmd: The parsed command arguments (bsvjson.EstimateFeeCmd)
+//   - _: Unused channel for close notification
+//
+// Returns:
+//   - interface{}: Float64 containing the estimated fee rate in BSV per kB, or -1
+//   - error: Always nil
+func handleEstimateFee(ctx context.Context, s *RPCServer, cmd interface{}, _ <-chan struct{}) (interface{}, error) {
+	_, _, deferFn := tracing.Tracer("rpc").Start(ctx, "handleEstimateFee",
+		tracing.WithParentStat(RPCStat),
+		tracing.WithHistogram(prometheusHandleEstimateFee),
+		tracing.WithLogMessage(s.logger, "[handleEstimateFee] called"),
+	)
+	defer deferFn()
+
+	c := cmd.(*bsvjson.EstimateFeeCmd)
+
+	if s.feeEstimator == nil {
+		return float64(-1), nil
+	}
+
+	target := int(max(1, min(c.NumBlocks, feeestimator.MaxTarget)))
+
+	feeRate, ok := s.feeEstimator.EstimateFee(target, true)
+	if !ok {
+		return float64(-1), nil
 	}
 
-	// return peerInfo, nil
-	return infos, nil
+	return feeRate / bsvutil.SatoshiPerBitcoin, nil
 }
 
-// handleGetRawMempool implements the getrawmempool command.
-// Returns transaction IDs currently in the memory pool.
-func handleGetRawMempool(ctx context.Context, s *RPCServer, cmd interface{}, _ <-chan struct{}) (interface{}, error) {
-	ctx, _, deferFn := tracing.Tracer("rpc").Start(ctx, "handleGetRawMempool",
+// handleEstimateSmartFee implements the estimatesmartfee command.
+//
+// This command estimates the fee rate a transaction needs to be mined within a number
+// of blocks. When no estimate is available for the requested number of blocks, higher
+// numbers of blocks are tried, and the number of blocks the estimate was made for is
+// returned. The estimate is never lower than the minimum mining fee (minminingtxfee).
+//
+// A conservative estimate requires 95% of the transactions paying the estimated fee
+// rate to have been mined within the number of blocks, an economical estimate 85%.
+//
+// Parameters:
+//   - ctx: Context for cancellation and tracing
+//   - s: The RPC server instance providing access to the fee estimator
+//   - cmd: The parsed command arguments (bsvjson.EstimateSmartFeeCmd)
+//   - _: Unused channel for close notification
+//
+// Returns:
+//   - interface{}: *bsvjson.EstimateSmartFeeResult with the fee rate in BSV per kB, or
+//     the errors when no estimate is available
+//   - error: ErrRPCInvalidParameter if the number of blocks or the estimate mode is invalid
+func handleEstimateSmartFee(ctx context.Context, s *RPCServer, cmd interface{}, _ <-chan struct{}) (interface{}, error) {
+	_, _, deferFn := tracing.Tracer("rpc").Start(ctx, "handleEstimateSmartFee",
 		tracing.WithParentStat(RPCStat),
-		tracing.WithHistogram(prometheusHandleGetRawmempool),
-		tracing.WithLogMessage(s.logger, "[handleGetRawMempool] called"),
+		tracing.WithHistogram(prometheusHandleEstimateSmartFee),
+		tracing.WithLogMessage(s.logger, "[handleEstimateSmartFee] called"),
 	)
 	defer deferFn()
 
-	verbose := cmd.(*bsvjson.GetRawMempoolCmd).Verbose
+	c := cmd.(*bsvjson.EstimateSmartFeeCmd)
 
-	txs, err := s.blockAssemblyClient.GetTransactionHashes(ctx)
-	if err != nil {
+	if c.ConfTarget < 1 || c.ConfTarget > feeestimator.MaxTarget {
 		return nil, &bsvjson.RPCError{
-			Code:    bsvjson.ErrRPCInternal.Code,
-			Message: "Error retrieving raw mempool: " + err.Error(),
+			Code:    bsvjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Invalid conf_target, must be between 1 - %d", feeestimator.MaxTarget),
 		}
 	}
 
-	if verbose != nil && *verbose {
-		miningCandidate, err := s.blockAssemblyClient.GetMiningCandidate(ctx)
-		if err != nil {
+	conservative := true
+
+	if c.EstimateMode != nil {
+		switch bsvjson.EstimateSmartFeeMode(strings.ToUpper(string(*c.EstimateMode))) {
+		case bsvjson.EstimateModeUnset, bsvjson.EstimateModeConservative:
+		case bsvjson.EstimateModeEconomical:
+			conservative = false
+		default:
 			return nil, &bsvjson.RPCError{
-				Code:    bsvjson.ErrRPCInternal.Code,
-				Message: "Error retrieving mining candidate: " + err.Error(),
+				Code:    bsvjson.ErrRPCInvalidParameter,
+				Message: "Invalid estimate_mode parameter",
 			}
 		}
+	}
 
-		result := bsvjson.GetRawMempoolVerboseResult{
-			Size:    int32(len(txs)),                        //nolint:gosec
-			Fee:     float64(miningCandidate.CoinbaseValue), //nolint:gosec
-			Time:    int64(miningCandidate.Time),            //nolint:gosec
-			Height:  int64(miningCandidate.Height),          //nolint:gosec
-			Depends: txs,
-		}
+	if s.feeEstimator == nil {
+		return &bsvjson.EstimateSmartFeeResult{
+			Errors: []string{"Fee estimation is not enabled"},
+			Blocks: 0,
+		}, nil
+	}
 
-		return result, nil
+	feeRate, target, ok := s.feeEstimator.EstimateSmartFee(int(c.ConfTarget), conservative)
+	if !ok {
+		return &bsvjson.EstimateSmartFeeResult{
+			Errors: []string{"Insufficient data or no feerate found"},
+			Blocks: int64(target),
+		}, nil
 	}
 
-	return txs, nil
+	feeRate /= bsvutil.SatoshiPerBitcoin
+
+	return &bsvjson.EstimateSmartFeeResult{
+		FeeRate: &feeRate,
+		Blocks:  int64(target),
+	}, nil
 }
 
 // handleGetDifficulty implements the getdifficulty command, which returns the current
@@ -1709,6 +3288,453 @@ func handleSubmitMiningSolution(ctx context.Context, s *RPCServer, cmd interface
 	return true, nil
 }
 
+// handleGetBlockTemplate implements the getblocktemplate command as specified in BIP22 and
+// BIP23, which provides stock mining software with a block template to mine on.
+//
+// The template is generated from the mining candidate of the block assembly service. By default
+// the template lists all transactions of the block, read from the subtrees of the candidate and
+// the UTXO store. Since blocks can hold far more transactions than is practical to return in a
+// single response, clients can request the 'subtrees' capability, in which case the template
+// contains the subtree hashes and the merkle proof of the coinbase transaction instead, from
+// which the merkle root of the block can be calculated.
+//
+// When a longpollid is given, the request blocks until the best block changes from the one the
+// longpollid refers to, or until the long-poll timeout expires, before returning a new template.
+//
+// In proposal mode, the given block is validated without being added to the chain, and the
+// reason for rejecting it is returned, or nil if it is valid.
+//
+// Parameters:
+//   - ctx: Context for cancellation and tracing
+//   - s: The RPC server instance providing access to service clients
+//   - cmd: The parsed command arguments (bsvjson.GetBlockTemplateCmd)
+//   - closeChan: Channel that is closed when the client disconnects
+//
+// Returns:
+//   - interface{}: A bsvjson.GetBlockTemplateResult, or the proposal result in proposal mode
+//   - error: Any error encountered during template generation
+func handleGetBlockTemplate(ctx context.Context, s *RPCServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	ctx, _, deferFn := tracing.Tracer("rpc").Start(ctx, "handleGetBlockTemplate",
+		tracing.WithParentStat(RPCStat),
+		tracing.WithHistogram(prometheusHandleGetBlockTemplate),
+		tracing.WithDebugLogMessage(s.logger, "[handleGetBlockTemplate] called"),
+	)
+	defer deferFn()
+
+	c := cmd.(*bsvjson.GetBlockTemplateCmd)
+	request := c.Request
+
+	mode := "template"
+	if request != nil && request.Mode != "" {
+		mode = request.Mode
+	}
+
+	switch mode {
+	case "template":
+		return handleGetBlockTemplateRequest(ctx, s, request, closeChan)
+	case "proposal":
+		return handleGetBlockTemplateProposal(ctx, s, request)
+	}
+
+	return nil, &bsvjson.RPCError{
+		Code:    bsvjson.ErrRPCInvalidParameter,
+		Message: "Invalid mode",
+	}
+}
+
+// handleGetBlockTemplateRequest returns a block template for the template mode of the
+// getblocktemplate command, waiting for the best block to change for long-poll requests.
+func handleGetBlockTemplateRequest(ctx context.Context, s *RPCServer, request *bsvjson.TemplateRequest, closeChan <-chan struct{}) (interface{}, error) {
+	includeSubtrees := false
+
+	if request != nil {
+		for _, capability := range request.Capabilities {
+			if capability == gbtCapabilitySubtrees {
+				includeSubtrees = true
+			}
+		}
+
+		if request.LongPollID != "" {
+			prevHashStr, _, _ := strings.Cut(request.LongPollID, "-")
+
+			prevHash, err := chainhash.NewHashFromStr(prevHashStr)
+			if err != nil {
+				return nil, &bsvjson.RPCError{
+					Code:    bsvjson.ErrRPCInvalidParameter,
+					Message: "Invalid longpollid",
+				}
+			}
+
+			if err = s.waitForBestBlockChange(ctx, prevHash, closeChan); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	candidate, err := s.blockAssemblyClient.GetMiningCandidate(ctx, true)
+	if err != nil {
+		return nil, s.internalRPCError(err.Error(), "getblocktemplate: failed to get mining candidate")
+	}
+
+	template, err := newBlockTemplate(candidate)
+	if err != nil {
+		return nil, s.internalRPCError(err.Error(), "getblocktemplate: invalid mining candidate")
+	}
+
+	nBits, err := model.NewNBitFromSlice(candidate.NBits)
+	if err != nil {
+		return nil, s.internalRPCError(err.Error(), "getblocktemplate: invalid bits in mining candidate")
+	}
+
+	version, err := safeconversion.Uint32ToInt32(candidate.Version)
+	if err != nil {
+		return nil, s.internalRPCError(err.Error(), "getblocktemplate: invalid version in mining candidate")
+	}
+
+	coinbaseValue, err := safeconversion.Uint64ToInt64(candidate.CoinbaseValue)
+	if err != nil {
+		return nil, s.internalRPCError(err.Error(), "getblocktemplate: invalid coinbase value in mining candidate")
+	}
+
+	result := &bsvjson.GetBlockTemplateResult{
+		Bits:          nBits.String(),
+		CurTime:       int64(candidate.Time),
+		Height:        int64(candidate.Height),
+		PreviousHash:  template.previousHash.String(),
+		Version:       version,
+		CoinbaseValue: &coinbaseValue,
+		WorkID:        template.workID,
+		LongPollID:    template.previousHash.String() + "-" + template.workID,
+		SubmitOld:     new(bool),
+		Target:        fmt.Sprintf("%064x", nBits.CalculateTarget()),
+		Mutable:       gbtMutableFields,
+		NonceRange:    gbtNonceRange,
+		Capabilities:  gbtCapabilities,
+		Transactions:  []bsvjson.GetBlockTemplateResultTx{},
+	}
+
+	if includeSubtrees {
+		result.SubtreeHashes = make([]string, len(template.subtreeHashes))
+		for i, subtreeHash := range template.subtreeHashes {
+			result.SubtreeHashes[i] = subtreeHash.String()
+		}
+
+		result.MerkleProof = make([]string, len(candidate.MerkleProof))
+		for i, hash := range candidate.MerkleProof {
+			result.MerkleProof[i] = utils.ReverseAndHexEncodeSlice(hash)
+		}
+
+		result.TxCount = candidate.NumTxs
+	} else {
+		maxTransactions := s.settings.RPC.GBTMaxTransactions
+		if maxTransactions > 0 && int64(candidate.NumTxs) > int64(maxTransactions) {
+			return nil, &bsvjson.RPCError{
+				Code:    bsvjson.ErrRPCInvalidParameter,
+				Message: fmt.Sprintf("Block template has %d transactions, more than the maximum of %d, request the %s capability instead", candidate.NumTxs, maxTransactions, gbtCapabilitySubtrees),
+			}
+		}
+
+		if result.Transactions, err = s.blockTemplateTransactions(ctx, template); err != nil {
+			return nil, s.internalRPCError(err.Error(), "getblocktemplate: failed to get transactions")
+		}
+	}
+
+	s.blockTemplates.add(template)
+
+	return result, nil
+}
+
+// blockTemplateTransactions returns the transactions of a block template in block order, excluding
+// the coinbase transaction. The fees and sizes are taken from the subtrees of the template and the
+// transaction data from the UTXO store.
+func (s *RPCServer) blockTemplateTransactions(ctx context.Context, template *blockTemplate) ([]bsvjson.GetBlockTemplateResultTx, error) {
+	subtrees, err := template.loadSubtrees(ctx, s.subtreeStore)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions := make([]bsvjson.GetBlockTemplateResultTx, 0, template.candidate.NumTxs)
+
+	// the 1-based index of every transaction in the template, used for the depends field
+	txIndex := make(map[chainhash.Hash]int64, template.candidate.NumTxs)
+
+	for subtreeIdx, subtree := range subtrees {
+		nodes := subtree.Nodes
+		if subtreeIdx == 0 && len(nodes) > 0 {
+			// skip the coinbase placeholder
+			nodes = nodes[1:]
+		}
+
+		unresolved := make([]*utxo.UnresolvedMetaData, len(nodes))
+		for i, node := range nodes {
+			unresolved[i] = &utxo.UnresolvedMetaData{Hash: node.Hash, Idx: i}
+		}
+
+		if err = s.utxoStore.BatchDecorate(ctx, unresolved, fields.Tx); err != nil {
+			return nil, err
+		}
+
+		for i, node := range nodes {
+			if unresolved[i].Err != nil || unresolved[i].Data == nil || unresolved[i].Data.Tx == nil {
+				return nil, errors.NewTxNotFoundError("transaction %s not found", node.Hash, unresolved[i].Err)
+			}
+
+			tx := unresolved[i].Data.Tx
+
+			depends := make([]int64, 0)
+
+			for _, input := range tx.Inputs {
+				if idx, ok := txIndex[*input.PreviousTxIDChainHash()]; ok && !slices.Contains(depends, idx) {
+					depends = append(depends, idx)
+				}
+			}
+
+			transactions = append(transactions, bsvjson.GetBlockTemplateResultTx{
+				Data:    hex.EncodeToString(tx.Bytes()),
+				Hash:    node.Hash.String(),
+				Depends: depends,
+				Fee:     int64(node.Fee),         //nolint:gosec
+				Size:    int64(node.SizeInBytes), //nolint:gosec
+			})
+
+			txIndex[node.Hash] = int64(len(transactions))
+		}
+	}
+
+	return transactions, nil
+}
+
+// waitForBestBlockChange blocks until the best block is no longer the block with the given hash,
+// the long-poll timeout expires, or the request is cancelled.
+func (s *RPCServer) waitForBestBlockChange(ctx context.Context, prevHash *chainhash.Hash, closeChan <-chan struct{}) error {
+	var timeoutCh <-chan time.Time
+
+	if s.settings.RPC.GBTLongPollTimeout > 0 {
+		timer := time.NewTimer(s.settings.RPC.GBTLongPollTimeout)
+		defer timer.Stop()
+
+		timeoutCh = timer.C
+	}
+
+	for {
+		// get the notification channel before checking the best block, so that a block that is
+		// added in between is not missed
+		blockCh := s.blockTemplates.blockNotifyChannel()
+
+		bestBlockHeader, _, err := s.blockchainClient.GetBestBlockHeader(ctx)
+		if err != nil {
+			return s.internalRPCError(err.Error(), "getblocktemplate: failed to get best block header")
+		}
+
+		if !bestBlockHeader.Hash().IsEqual(prevHash) {
+			return nil
+		}
+
+		select {
+		case <-blockCh:
+		case <-time.After(gbtLongPollRecheckInterval):
+		case <-timeoutCh:
+			return nil
+		case <-closeChan:
+			return &bsvjson.RPCError{
+				Code:    bsvjson.ErrRPCMisc,
+				Message: "Connection closed by client",
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// handleGetBlockTemplateProposal validates a block proposal for the proposal mode of the
+// getblocktemplate command, as specified in BIP23. The block is validated without being added
+// to the chain.
+func handleGetBlockTemplateProposal(ctx context.Context, s *RPCServer, request *bsvjson.TemplateRequest) (interface{}, error) {
+	if request.Data == "" {
+		return nil, &bsvjson.RPCError{
+			Code:    bsvjson.ErrRPCType,
+			Message: "Data must contain the hex-encoded serialized block that is being proposed",
+		}
+	}
+
+	blockBytes, err := hex.DecodeString(request.Data)
+	if err != nil {
+		return nil, rpcDecodeHexError(request.Data)
+	}
+
+	block, reason, err := s.blockFromSubmission(ctx, blockBytes, request.WorkID)
+	if err != nil {
+		return nil, err
+	}
+
+	if reason != "" {
+		return reason, nil
+	}
+
+	bestBlockHeader, _, err := s.blockchainClient.GetBestBlockHeader(ctx)
+	if err != nil {
+		return nil, s.internalRPCError(err.Error(), "getblocktemplate: failed to get best block header")
+	}
+
+	if !block.Header.HashPrevBlock.IsEqual(bestBlockHeader.Hash()) {
+		return "bad-prevblk", nil
+	}
+
+	if err = s.blockValidationClient.ValidateBlock(ctx, block, nil); err != nil {
+		return "rejected: " + err.Error(), nil
+	}
+
+	return nil, nil
+}
+
+// handleSubmitBlock implements the submitblock command as specified in BIP22, which submits a
+// block built from a getblocktemplate template to the block validation service.
+//
+// The block is matched to the template it was built from, using the workid if given, by
+// calculating the merkle root from the coinbase transaction and the merkle proof of the template.
+// The block is then built from the subtrees of the template, so that the subtrees that are
+// already in the subtree store are reused, and routed to block validation for processing.
+//
+// For templates that were requested with the 'subtrees' capability, the block may consist of
+// just the header and the coinbase transaction. Full blocks must hold exactly the transactions
+// of the template, in template order.
+//
+// Parameters:
+//   - ctx: Context for cancellation and tracing
+//   - s: The RPC server instance providing access to service clients
+//   - cmd: The parsed command arguments (bsvjson.SubmitBlockCmd)
+//   - _: Unused channel for close notification
+//
+// Returns:
+//   - interface{}: Nil if the block was accepted, or the reason it was rejected
+//   - error: Any error encountered during processing
+func handleSubmitBlock(ctx context.Context, s *RPCServer, cmd interface{}, _ <-chan struct{}) (interface{}, error) {
+	ctx, _, deferFn := tracing.Tracer("rpc").Start(ctx, "handleSubmitBlock",
+		tracing.WithParentStat(RPCStat),
+		tracing.WithHistogram(prometheusHandleSubmitBlock),
+		tracing.WithLogMessage(s.logger, "[handleSubmitBlock] called"),
+	)
+	defer deferFn()
+
+	c := cmd.(*bsvjson.SubmitBlockCmd)
+
+	blockBytes, err := hex.DecodeString(c.HexBlock)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.HexBlock)
+	}
+
+	workID := ""
+	if c.Options != nil {
+		workID = c.Options.WorkID
+	}
+
+	block, reason, err := s.blockFromSubmission(ctx, blockBytes, workID)
+	if err != nil {
+		return nil, err
+	}
+
+	if reason != "" {
+		return reason, nil
+	}
+
+	baseURL := ""
+	if s.assetHTTPURL != nil {
+		baseURL = s.assetHTTPURL.String()
+	}
+
+	if err = s.blockValidationClient.ProcessBlock(ctx, block, block.Height, "", baseURL); err != nil {
+		if errors.Is(err, errors.ErrBlockExists) {
+			return "duplicate", nil
+		}
+
+		s.logger.Infof("[handleSubmitBlock] block %s rejected: %v", block.Hash(), err)
+
+		return "rejected: " + err.Error(), nil
+	}
+
+	s.logger.Infof("[handleSubmitBlock] accepted block %s at height %d", block.Hash(), block.Height)
+
+	return nil, nil
+}
+
+// blockFromSubmission builds a block from a serialized block that was built from one of the
+// block templates handed out by getblocktemplate. When the block can not be built, the BIP22
+// reason for rejecting it is returned instead.
+func (s *RPCServer) blockFromSubmission(ctx context.Context, blockBytes []byte, workID string) (*model.Block, string, error) {
+	var msgBlock wire.MsgBlock
+	if err := msgBlock.Deserialize(bytes.NewReader(blockBytes)); err != nil {
+		return nil, "", &bsvjson.RPCError{
+			Code:    bsvjson.ErrRPCDeserialization,
+			Message: "Block decode failed: " + err.Error(),
+		}
+	}
+
+	if len(msgBlock.Transactions) == 0 {
+		return nil, "bad-cb-missing", nil
+	}
+
+	var headerBytes bytes.Buffer
+	if err := msgBlock.Header.Serialize(&headerBytes); err != nil {
+		return nil, "", s.internalRPCError(err.Error(), "failed to serialize block header")
+	}
+
+	header, err := model.NewBlockHeaderFromBytes(headerBytes.Bytes())
+	if err != nil {
+		return nil, "", s.internalRPCError(err.Error(), "failed to create block header")
+	}
+
+	var coinbaseBytes bytes.Buffer
+	if err = msgBlock.Transactions[0].Serialize(&coinbaseBytes); err != nil {
+		return nil, "", s.internalRPCError(err.Error(), "failed to serialize coinbase transaction")
+	}
+
+	coinbaseTx, err := bt.NewTxFromBytes(coinbaseBytes.Bytes())
+	if err != nil {
+		return nil, "bad-cb-missing", nil
+	}
+
+	template := s.blockTemplates.find(header, coinbaseTx, workID)
+	if template == nil {
+		return nil, "rejected: block does not match a known block template", nil
+	}
+
+	txCount := uint64(template.candidate.NumTxs) + 1
+
+	if len(msgBlock.Transactions) > 1 {
+		// a full block must hold exactly the transactions of the template
+		if uint64(len(msgBlock.Transactions)) != txCount {
+			return nil, "bad-txnmrklroot", nil
+		}
+
+		subtrees, err := template.loadSubtrees(ctx, s.subtreeStore)
+		if err != nil {
+			return nil, "", s.internalRPCError(err.Error(), "failed to get block template subtrees")
+		}
+
+		txHashes := model.BlockTxHashes(coinbaseTx, subtrees)
+		if len(txHashes) != len(msgBlock.Transactions) {
+			return nil, "bad-txnmrklroot", nil
+		}
+
+		// the coinbase was matched against the template already
+		for txIdx := 1; txIdx < len(txHashes); txIdx++ {
+			if msgBlock.Transactions[txIdx].TxHash() != txHashes[txIdx] {
+				return nil, "bad-txnmrklroot", nil
+			}
+		}
+	}
+
+	// the size of the transactions, the 80 byte header, the varint for the transaction count and the coinbase
+	sizeInBytes := template.candidate.SizeWithoutCoinbase + 80 + util.VarintSize(txCount) + uint64(coinbaseTx.Size()) //nolint:gosec
+
+	block, err := model.NewBlock(header, coinbaseTx, template.subtreeHashes, txCount, sizeInBytes, template.candidate.Height, 0)
+	if err != nil {
+		return nil, "", s.internalRPCError(err.Error(), "failed to create block")
+	}
+
+	return block, "", nil
+}
+
 // handleInvalidateBlock implements the invalidateblock command, which marks a block
 // as invalid, forcing a chain reorganization.
 //
@@ -3,35 +3,54 @@ package rpc
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/bsv-blockchain/go-bt/v2"
 	"github.com/bsv-blockchain/go-bt/v2/chainhash"
 	"github.com/bsv-blockchain/go-chaincfg"
 	"github.com/bsv-blockchain/go-subtree"
 	"github.com/bsv-blockchain/go-wire"
 	"github.com/bsv-blockchain/teranode/errors"
 	"github.com/bsv-blockchain/teranode/model"
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
 	"github.com/bsv-blockchain/teranode/services/blockassembly/blockassembly_api"
+	"github.com/bsv-blockchain/teranode/services/blockassembly/subtreeprocessor"
 	"github.com/bsv-blockchain/teranode/services/blockchain"
 	"github.com/bsv-blockchain/teranode/services/blockchain/blockchain_api"
 	"github.com/bsv-blockchain/teranode/services/blockvalidation"
+	"github.com/bsv-blockchain/teranode/services/filterindex"
+	"github.com/bsv-blockchain/teranode/services/legacy/bsvec"
 	"github.com/bsv-blockchain/teranode/services/legacy/bsvutil"
 	"github.com/bsv-blockchain/teranode/services/legacy/peer_api"
+	"github.com/bsv-blockchain/teranode/services/legacy/txscript"
 	"github.com/bsv-blockchain/teranode/services/p2p"
 	"github.com/bsv-blockchain/teranode/services/rpc/bsvjson"
+	"github.com/bsv-blockchain/teranode/services/utxopersister"
 	"github.com/bsv-blockchain/teranode/settings"
+	"github.com/bsv-blockchain/teranode/stores/blob/memory"
+	bloboptions "github.com/bsv-blockchain/teranode/stores/blob/options"
 	"github.com/bsv-blockchain/teranode/stores/blockchain/options"
+	"github.com/bsv-blockchain/teranode/stores/scripthash"
+	"github.com/bsv-blockchain/teranode/stores/utxo"
+	"github.com/bsv-blockchain/teranode/stores/utxo/meta"
+	"github.com/bsv-blockchain/teranode/stores/utxo/spend"
+	"github.com/bsv-blockchain/teranode/util/feeestimator"
 	"github.com/bsv-blockchain/teranode/util/test/mocklogger"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/ordishs/go-utils"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
@@ -2229,33 +2248,7 @@ func TestHandleGetRawMempoolComprehensive(t *testing.T) {
 	})
 
 	t.Run("successful verbose mempool", func(t *testing.T) {
-		txHashes := []string{
-			"abc123def456",
-			"789ghi012jkl",
-		}
-
-		miningCandidate := &model.MiningCandidate{
-			Time:          1640995200, // Example timestamp
-			Height:        700000,
-			CoinbaseValue: 625000000, // 6.25 BSV in satoshis
-		}
-
-		mockClient := &mockBlockAssemblyClient{
-			getTransactionHashesFunc: func(ctx context.Context) ([]string, error) {
-				return txHashes, nil
-			},
-			getMiningCandidateFunc: func(ctx context.Context, includeSubtreeHashes ...bool) (*model.MiningCandidate, error) {
-				return miningCandidate, nil
-			},
-		}
-
-		s := &RPCServer{
-			logger:              logger,
-			blockAssemblyClient: mockClient,
-			settings: &settings.Settings{
-				ChainCfgParams: &chaincfg.MainNetParams,
-			},
-		}
+		s, hashes := newMempoolTestServer()
 
 		cmd := &bsvjson.GetRawMempoolCmd{
 			Verbose: func() *bool { v := true; return &v }(),
@@ -2263,15 +2256,20 @@ func TestHandleGetRawMempoolComprehensive(t *testing.T) {
 
 		result, err := handleGetRawMempool(context.Background(), s, cmd, nil)
 		require.NoError(t, err)
-		require.NotNil(t, result)
 
-		verboseResult, ok := result.(bsvjson.GetRawMempoolVerboseResult)
+		verboseResult, ok := result.(map[string]bsvjson.GetRawMempoolVerboseResult)
 		require.True(t, ok)
-		assert.Equal(t, int32(2), verboseResult.Size)
-		assert.Equal(t, float64(625000000), verboseResult.Fee)
-		assert.Equal(t, int64(1640995200), verboseResult.Time)
-		assert.Equal(t, int64(700000), verboseResult.Height)
-		assert.Equal(t, txHashes, verboseResult.Depends)
+		require.Len(t, verboseResult, 3)
+
+		assert.Equal(t, bsvjson.GetRawMempoolVerboseResult{
+			Size:    250,
+			Fee:     0.000005,
+			Time:    1700000099,
+			Height:  99,
+			Depends: []string{hashes[0].String()},
+		}, verboseResult[hashes[1].String()])
+
+		assert.Empty(t, verboseResult[hashes[0].String()].Depends)
 	})
 
 	t.Run("nil verbose flag defaults to non-verbose", func(t *testing.T) {
@@ -2334,25 +2332,12 @@ func TestHandleGetRawMempoolComprehensive(t *testing.T) {
 		assert.Contains(t, rpcErr.Message, "failed to get tx hashes")
 	})
 
-	t.Run("verbose mode - get mining candidate error", func(t *testing.T) {
-		txHashes := []string{"abc123def456"}
-
-		mockClient := &mockBlockAssemblyClient{
-			getTransactionHashesFunc: func(ctx context.Context) ([]string, error) {
-				return txHashes, nil
-			},
-			getMiningCandidateFunc: func(ctx context.Context, includeSubtreeHashes ...bool) (*model.MiningCandidate, error) {
-				return nil, errors.New(errors.ERR_ERROR, "failed to get mining candidate")
-			},
-		}
+	t.Run("verbose mode - utxo store error", func(t *testing.T) {
+		s, _ := newMempoolTestServer()
 
-		s := &RPCServer{
-			logger:              logger,
-			blockAssemblyClient: mockClient,
-			settings: &settings.Settings{
-				ChainCfgParams: &chaincfg.MainNetParams,
-			},
-		}
+		utxoStore := &utxo.MockUtxostore{}
+		utxoStore.On("BatchDecorate", mock.Anything, mock.Anything, mock.Anything).Return(errors.NewStorageError("store down"))
+		s.utxoStore = utxoStore
 
 		cmd := &bsvjson.GetRawMempoolCmd{
 			Verbose: func() *bool { v := true; return &v }(),
@@ -2365,8 +2350,8 @@ func TestHandleGetRawMempoolComprehensive(t *testing.T) {
 		rpcErr, ok := err.(*bsvjson.RPCError)
 		require.True(t, ok)
 		assert.Equal(t, bsvjson.ErrRPCInternal.Code, rpcErr.Code)
-		assert.Contains(t, rpcErr.Message, "Error retrieving mining candidate")
-		assert.Contains(t, rpcErr.Message, "failed to get mining candidate")
+		assert.Contains(t, rpcErr.Message, "Error retrieving raw mempool")
+		assert.Contains(t, rpcErr.Message, "store down")
 	})
 
 	t.Run("requires block assembly client", func(t *testing.T) {
@@ -2389,6 +2374,273 @@ func TestHandleGetRawMempoolComprehensive(t *testing.T) {
 	})
 }
 
+// newMempoolTestServer returns an RPC server with a mempool of the chained transactions a <- b <- c,
+// where b also spends an output of a mined transaction. Block assembly also returns the coinbase
+// placeholder and a transaction that was mined in the meantime, which are left out of the mempool.
+func newMempoolTestServer() (*RPCServer, []chainhash.Hash) {
+	hashes := []chainhash.Hash{
+		chainhash.HashH([]byte("a")),
+		chainhash.HashH([]byte("b")),
+		chainhash.HashH([]byte("c")),
+	}
+	minedHash := chainhash.HashH([]byte("mined"))
+
+	data := map[chainhash.Hash]*meta.Data{
+		hashes[0]: {Fee: 100, SizeInBytes: 200, UnminedSince: 100},
+		hashes[1]: {Fee: 500, SizeInBytes: 250, UnminedSince: 100, TxInpoints: subtree.TxInpoints{
+			ParentTxHashes: []chainhash.Hash{hashes[0], minedHash, hashes[0]},
+		}},
+		hashes[2]: {Fee: 1000, SizeInBytes: 300, UnminedSince: 101, TxInpoints: subtree.TxInpoints{
+			ParentTxHashes: []chainhash.Hash{hashes[1]},
+		}},
+		minedHash: {Fee: 10, SizeInBytes: 100},
+	}
+
+	utxoStore := &utxo.MockUtxostore{}
+	utxoStore.On("BatchDecorate", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		for _, unresolved := range args.Get(1).([]*utxo.UnresolvedMetaData) {
+			unresolved.Data = data[unresolved.Hash]
+		}
+	}).Return(nil)
+
+	s := &RPCServer{
+		logger: mocklogger.NewTestLogger(),
+		settings: &settings.Settings{
+			ChainCfgParams: &chaincfg.MainNetParams,
+			Policy:         &settings.PolicySettings{MinMiningTxFee: 0.0000005},
+		},
+		blockAssemblyClient: &mockBlockAssemblyClient{
+			getTransactionHashesFunc: func(ctx context.Context) ([]string, error) {
+				return []string{
+					subtree.CoinbasePlaceholderHashValue.String(),
+					hashes[0].String(),
+					minedHash.String(),
+					hashes[1].String(),
+					hashes[2].String(),
+				}, nil
+			},
+			// all transactions in block assembly are ancestors or descendants of each other
+			getTxRelativesFunc: func(ctx context.Context, txHashes []chainhash.Hash) ([]subtreeprocessor.TxRelatives, error) {
+				relatives := []subtreeprocessor.TxRelatives{
+					{Hash: hashes[0], Children: []chainhash.Hash{hashes[1]}},
+					{Hash: minedHash, Children: []chainhash.Hash{hashes[1]}},
+					{Hash: hashes[1], Parents: []chainhash.Hash{hashes[0], minedHash}, Children: []chainhash.Hash{hashes[2]}},
+					{Hash: hashes[2], Parents: []chainhash.Hash{hashes[1]}},
+				}
+
+				for _, relative := range relatives {
+					if slices.Contains(txHashes, relative.Hash) {
+						return relatives, nil
+					}
+				}
+
+				return nil, nil
+			},
+		},
+		blockchainClient: &mockBlockchainClient{
+			getBlockHeadersByHeightFunc: func(ctx context.Context, startHeight, endHeight uint32) ([]*model.BlockHeader, []*model.BlockHeaderMeta, error) {
+				headers := make([]*model.BlockHeader, 0)
+				metas := make([]*model.BlockHeaderMeta, 0)
+
+				for height := startHeight; height <= endHeight; height++ {
+					headers = append(headers, &model.BlockHeader{Timestamp: 1700000000 + height})
+					metas = append(metas, &model.BlockHeaderMeta{Height: height})
+				}
+
+				return headers, metas, nil
+			},
+		},
+		utxoStore: utxoStore,
+	}
+
+	return s, hashes
+}
+
+// TestHandleGetMempoolInfoComprehensive tests the handleGetMempoolInfo handler
+func TestHandleGetMempoolInfoComprehensive(t *testing.T) {
+	t.Run("successful response", func(t *testing.T) {
+		s, _ := newMempoolTestServer()
+
+		result, err := handleGetMempoolInfo(context.Background(), s, &bsvjson.GetMempoolInfoCmd{}, nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, &bsvjson.GetMempoolInfoResult{
+			Size:          3,
+			Bytes:         750,
+			TotalFee:      0.000016,
+			MempoolMinFee: 0.0000005,
+		}, result)
+	})
+
+	t.Run("empty mempool", func(t *testing.T) {
+		s, _ := newMempoolTestServer()
+		s.blockAssemblyClient = &mockBlockAssemblyClient{
+			getTransactionHashesFunc: func(ctx context.Context) ([]string, error) {
+				return []string{subtree.CoinbasePlaceholderHashValue.String()}, nil
+			},
+		}
+
+		result, err := handleGetMempoolInfo(context.Background(), s, &bsvjson.GetMempoolInfoCmd{}, nil)
+		require.NoError(t, err)
+
+		info, ok := result.(*bsvjson.GetMempoolInfoResult)
+		require.True(t, ok)
+		assert.Equal(t, int64(0), info.Size)
+		assert.Equal(t, int64(0), info.Bytes)
+	})
+
+	t.Run("block assembly error", func(t *testing.T) {
+		s, _ := newMempoolTestServer()
+		s.blockAssemblyClient = &mockBlockAssemblyClient{
+			getTransactionHashesFunc: func(ctx context.Context) ([]string, error) {
+				return nil, errors.NewServiceError("block assembly down")
+			},
+		}
+
+		_, err := handleGetMempoolInfo(context.Background(), s, &bsvjson.GetMempoolInfoCmd{}, nil)
+		require.Error(t, err)
+
+		rpcErr, ok := err.(*bsvjson.RPCError)
+		require.True(t, ok)
+		assert.Equal(t, bsvjson.ErrRPCInternal.Code, rpcErr.Code)
+		assert.Contains(t, rpcErr.Message, "block assembly down")
+	})
+}
+
+// TestHandleGetMempoolEntryComprehensive tests the handleGetMempoolEntry handler
+func TestHandleGetMempoolEntryComprehensive(t *testing.T) {
+	s, hashes := newMempoolTestServer()
+	s.blockAssemblyClient.(*mockBlockAssemblyClient).getTransactionHashesFunc = func(ctx context.Context) ([]string, error) {
+		t.Fatal("the relatives of a transaction are resolved without a snapshot of block assembly")
+		return nil, nil
+	}
+
+	t.Run("transaction with ancestors and descendants", func(t *testing.T) {
+		result, err := handleGetMempoolEntry(context.Background(), s, &bsvjson.GetMempoolEntryCmd{TxID: hashes[1].String()}, nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, &bsvjson.GetMempoolEntryResult{
+			Size:            250,
+			Fee:             0.000005,
+			ModifiedFee:     0.000005,
+			Time:            1700000099,
+			Height:          99,
+			DescendantCount: 2,
+			DescendantSize:  550,
+			DescendantFees:  1500,
+			AncestorCount:   2,
+			AncestorSize:    450,
+			AncestorFees:    600,
+			Depends:         []string{hashes[0].String()},
+		}, result)
+	})
+
+	t.Run("transaction without parents", func(t *testing.T) {
+		result, err := handleGetMempoolEntry(context.Background(), s, &bsvjson.GetMempoolEntryCmd{TxID: hashes[0].String()}, nil)
+		require.NoError(t, err)
+
+		entry, ok := result.(*bsvjson.GetMempoolEntryResult)
+		require.True(t, ok)
+		assert.Equal(t, int64(1), entry.AncestorCount)
+		assert.Equal(t, int64(3), entry.DescendantCount)
+		assert.Equal(t, int64(750), entry.DescendantSize)
+		assert.Empty(t, entry.Depends)
+	})
+
+	t.Run("transaction not in mempool", func(t *testing.T) {
+		minedHash := chainhash.HashH([]byte("mined"))
+
+		_, err := handleGetMempoolEntry(context.Background(), s, &bsvjson.GetMempoolEntryCmd{TxID: minedHash.String()}, nil)
+		require.Error(t, err)
+
+		rpcErr, ok := err.(*bsvjson.RPCError)
+		require.True(t, ok)
+		assert.Equal(t, bsvjson.ErrRPCNoTxInfo, rpcErr.Code)
+	})
+
+	t.Run("invalid transaction id", func(t *testing.T) {
+		_, err := handleGetMempoolEntry(context.Background(), s, &bsvjson.GetMempoolEntryCmd{TxID: "invalid"}, nil)
+		require.Error(t, err)
+
+		rpcErr, ok := err.(*bsvjson.RPCError)
+		require.True(t, ok)
+		assert.Equal(t, bsvjson.ErrRPCDecodeHexString, rpcErr.Code)
+	})
+
+	t.Run("only the relatives are decorated", func(t *testing.T) {
+		s, hashes := newMempoolTestServer()
+		s.blockAssemblyClient.(*mockBlockAssemblyClient).getTxRelativesFunc = func(ctx context.Context, txHashes []chainhash.Hash) ([]subtreeprocessor.TxRelatives, error) {
+			return []subtreeprocessor.TxRelatives{{Hash: hashes[0]}}, nil
+		}
+
+		utxoStore := &utxo.MockUtxostore{}
+		utxoStore.On("BatchDecorate", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			unresolved := args.Get(1).([]*utxo.UnresolvedMetaData)
+			require.Len(t, unresolved, 1)
+			assert.Equal(t, hashes[0], unresolved[0].Hash)
+
+			unresolved[0].Data = &meta.Data{Fee: 100, SizeInBytes: 200, UnminedSince: 100}
+		}).Return(nil)
+		s.utxoStore = utxoStore
+
+		result, err := handleGetMempoolEntry(context.Background(), s, &bsvjson.GetMempoolEntryCmd{TxID: hashes[0].String()}, nil)
+		require.NoError(t, err)
+
+		entry, ok := result.(*bsvjson.GetMempoolEntryResult)
+		require.True(t, ok)
+		assert.Equal(t, int64(1), entry.AncestorCount)
+		assert.Equal(t, int64(1), entry.DescendantCount)
+		utxoStore.AssertNumberOfCalls(t, "BatchDecorate", 1)
+	})
+}
+
+// TestHandleGetMempoolAncestorsAndDescendantsComprehensive tests the handleGetMempoolAncestors and
+// handleGetMempoolDescendants handlers
+func TestHandleGetMempoolAncestorsAndDescendantsComprehensive(t *testing.T) {
+	s, hashes := newMempoolTestServer()
+	verbose := true
+
+	t.Run("ancestors", func(t *testing.T) {
+		result, err := handleGetMempoolAncestors(context.Background(), s, &bsvjson.GetMempoolAncestorsCmd{TxID: hashes[2].String()}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{hashes[1].String(), hashes[0].String()}, result)
+
+		result, err = handleGetMempoolAncestors(context.Background(), s, &bsvjson.GetMempoolAncestorsCmd{TxID: hashes[0].String()}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{}, result)
+	})
+
+	t.Run("verbose ancestors", func(t *testing.T) {
+		result, err := handleGetMempoolAncestors(context.Background(), s, &bsvjson.GetMempoolAncestorsCmd{TxID: hashes[2].String(), Verbose: &verbose}, nil)
+		require.NoError(t, err)
+
+		entries, ok := result.(map[string]*bsvjson.GetMempoolEntryResult)
+		require.True(t, ok)
+		require.Len(t, entries, 2)
+		assert.Equal(t, int32(200), entries[hashes[0].String()].Size)
+		assert.Equal(t, int64(3), entries[hashes[0].String()].DescendantCount)
+	})
+
+	t.Run("descendants", func(t *testing.T) {
+		result, err := handleGetMempoolDescendants(context.Background(), s, &bsvjson.GetMempoolDescendantsCmd{TxID: hashes[0].String()}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{hashes[1].String(), hashes[2].String()}, result)
+
+		result, err = handleGetMempoolDescendants(context.Background(), s, &bsvjson.GetMempoolDescendantsCmd{TxID: hashes[2].String(), Verbose: &verbose}, nil)
+		require.NoError(t, err)
+		assert.Empty(t, result)
+	})
+
+	t.Run("transaction not in mempool", func(t *testing.T) {
+		_, err := handleGetMempoolDescendants(context.Background(), s, &bsvjson.GetMempoolDescendantsCmd{TxID: chainhash.HashH([]byte("unknown")).String()}, nil)
+		require.Error(t, err)
+
+		rpcErr, ok := err.(*bsvjson.RPCError)
+		require.True(t, ok)
+		assert.Equal(t, bsvjson.ErrRPCNoTxInfo, rpcErr.Code)
+	})
+}
+
 // TestHandleGetblockchaininfoComprehensive tests the handleGetblockchaininfo handler
 func TestHandleGetblockchaininfoComprehensive(t *testing.T) {
 	logger := mocklogger.NewTestLogger()
@@ -4390,6 +4642,8 @@ type mockBlockchainClient struct {
 	getBlockStatsFunc               func(context.Context) (*model.BlockStats, error)
 	findBlocksContainingSubtreeFunc func(context.Context, *chainhash.Hash, uint32) ([]*model.Block, error)
 	checkBlockIsInCurrentChainFunc  func(context.Context, []uint32) (bool, error)
+	getBlockByIDFunc                func(context.Context, uint64) (*model.Block, error)
+	getBlockHeadersByHeightFunc     func(context.Context, uint32, uint32) ([]*model.BlockHeader, []*model.BlockHeaderMeta, error)
 }
 
 func (m *mockBlockchainClient) Health(ctx context.Context, checkLiveness bool) (int, string, error) {
@@ -4431,6 +4685,9 @@ func (m *mockBlockchainClient) GetBlocks(ctx context.Context, blockHash *chainha
 	return nil, nil
 }
 func (m *mockBlockchainClient) GetBlockByID(ctx context.Context, id uint64) (*model.Block, error) {
+	if m.getBlockByIDFunc != nil {
+		return m.getBlockByIDFunc(ctx, id)
+	}
 	return nil, nil
 }
 func (m *mockBlockchainClient) GetNextBlockID(ctx context.Context) (uint64, error) {
@@ -4494,6 +4751,9 @@ func (m *mockBlockchainClient) GetBlockHeadersFromHeight(ctx context.Context, he
 	return nil, nil, nil
 }
 func (m *mockBlockchainClient) GetBlockHeadersByHeight(ctx context.Context, startHeight, endHeight uint32) ([]*model.BlockHeader, []*model.BlockHeaderMeta, error) {
+	if m.getBlockHeadersByHeightFunc != nil {
+		return m.getBlockHeadersByHeightFunc(ctx, startHeight, endHeight)
+	}
 	return nil, nil, nil
 }
 func (m *mockBlockchainClient) InvalidateBlock(ctx context.Context, blockHash *chainhash.Hash) ([]chainhash.Hash, error) {
@@ -5645,6 +5905,7 @@ type mockBlockAssemblyClient struct {
 	getCurrentDifficultyFunc func(ctx context.Context) (float64, error)
 	getMiningCandidateFunc   func(ctx context.Context, includeSubtreeHashes ...bool) (*model.MiningCandidate, error)
 	getTransactionHashesFunc func(ctx context.Context) ([]string, error)
+	getTxRelativesFunc       func(ctx context.Context, hashes []chainhash.Hash) ([]subtreeprocessor.TxRelatives, error)
 	healthFunc               func(context.Context, bool) (int, string, error)
 	// Add other methods as needed
 }
@@ -5707,6 +5968,12 @@ func (m *mockBlockAssemblyClient) GetTransactionHashes(ctx context.Context) ([]s
 	}
 	return nil, nil
 }
+func (m *mockBlockAssemblyClient) GetTxRelatives(ctx context.Context, hashes []chainhash.Hash) ([]subtreeprocessor.TxRelatives, error) {
+	if m.getTxRelativesFunc != nil {
+		return m.getTxRelativesFunc(ctx, hashes)
+	}
+	return nil, nil
+}
 
 // TestHandleGetMiningInfoComprehensive tests the complete handleGetMiningInfo functionality
 func TestHandleGetMiningInfoComprehensive(t *testing.T) {
@@ -6284,3 +6551,1694 @@ func (m *mockMessage) Command() string {
 func (m *mockMessage) MaxPayloadLength(pver uint32) uint64 {
 	return 1000
 }
+
+// TestHandleGetTxOutComprehensive tests the handleGetTxOut handler
+func TestHandleGetTxOutComprehensive(t *testing.T) {
+	logger := mocklogger.NewTestLogger()
+
+	tx := bt.NewTx()
+	require.NoError(t, tx.PayToAddress("1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", 1_000_000))
+
+	txHash := tx.TxIDChainHash()
+	bestHash := chainhash.Hash{1, 2, 3}
+
+	newServer := func(utxoStore *utxo.MockUtxostore, onCurrentChain bool) *RPCServer {
+		return &RPCServer{
+			logger:    logger,
+			utxoStore: utxoStore,
+			settings: &settings.Settings{
+				ChainCfgParams: &chaincfg.MainNetParams,
+			},
+			blockchainClient: &mockBlockchainClient{
+				getBestBlockHeaderFunc: func(ctx context.Context) (*model.BlockHeader, *model.BlockHeaderMeta, error) {
+					return &model.BlockHeader{HashPrevBlock: &bestHash, HashMerkleRoot: &bestHash}, &model.BlockHeaderMeta{Height: 110}, nil
+				},
+				checkBlockIsInCurrentChainFunc: func(ctx context.Context, blockIDs []uint32) (bool, error) {
+					return onCurrentChain, nil
+				},
+			},
+		}
+	}
+
+	minedMeta := &meta.Data{Tx: tx, BlockIDs: []uint32{5}, BlockHeights: []uint32{101}}
+	unminedMeta := &meta.Data{Tx: tx, UnminedSince: 105}
+
+	t.Run("invalid txid", func(t *testing.T) {
+		s := newServer(&utxo.MockUtxostore{}, true)
+
+		_, err := handleGetTxOut(context.Background(), s, &bsvjson.GetTxOutCmd{Txid: "invalid"}, nil)
+		require.Error(t, err)
+
+		rpcErr, ok := err.(*bsvjson.RPCError)
+		require.True(t, ok)
+		assert.Equal(t, bsvjson.ErrRPCDecodeHexString, rpcErr.Code)
+	})
+
+	t.Run("transaction not found", func(t *testing.T) {
+		utxoStore := &utxo.MockUtxostore{}
+		utxoStore.On("Get", mock.Anything, txHash, mock.Anything).Return(nil, errors.NewTxNotFoundError("not found"))
+
+		result, err := handleGetTxOut(context.Background(), newServer(utxoStore, true), &bsvjson.GetTxOutCmd{Txid: txHash.String()}, nil)
+		require.NoError(t, err)
+		assert.Nil(t, result)
+	})
+
+	t.Run("output index out of range", func(t *testing.T) {
+		utxoStore := &utxo.MockUtxostore{}
+		utxoStore.On("Get", mock.Anything, txHash, mock.Anything).Return(minedMeta, nil)
+
+		result, err := handleGetTxOut(context.Background(), newServer(utxoStore, true), &bsvjson.GetTxOutCmd{Txid: txHash.String(), Vout: 1}, nil)
+		require.NoError(t, err)
+		assert.Nil(t, result)
+	})
+
+	t.Run("unspent mined output", func(t *testing.T) {
+		utxoStore := &utxo.MockUtxostore{}
+		utxoStore.On("Get", mock.Anything, txHash, mock.Anything).Return(minedMeta, nil)
+		utxoStore.On("GetSpend", mock.Anything, mock.Anything).Return(&utxo.SpendResponse{Status: int(utxo.Status_OK)}, nil)
+
+		result, err := handleGetTxOut(context.Background(), newServer(utxoStore, true), &bsvjson.GetTxOutCmd{Txid: txHash.String()}, nil)
+		require.NoError(t, err)
+
+		txOut, ok := result.(*bsvjson.GetTxOutResult)
+		require.True(t, ok)
+		assert.Equal(t, int64(10), txOut.Confirmations)
+		assert.InDelta(t, 0.01, txOut.Value, 0.000000001)
+		assert.Equal(t, "pubkeyhash", txOut.ScriptPubKey.Type)
+		assert.Equal(t, []string{"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"}, txOut.ScriptPubKey.Addresses)
+		assert.False(t, txOut.Frozen)
+	})
+
+	t.Run("spent output", func(t *testing.T) {
+		utxoStore := &utxo.MockUtxostore{}
+		utxoStore.On("Get", mock.Anything, txHash, mock.Anything).Return(minedMeta, nil)
+		utxoStore.On("GetSpend", mock.Anything, mock.Anything).Return(&utxo.SpendResponse{
+			Status:       int(utxo.Status_SPENT),
+			SpendingData: spend.NewSpendingData(&chainhash.Hash{9}, 0),
+		}, nil)
+
+		result, err := handleGetTxOut(context.Background(), newServer(utxoStore, true), &bsvjson.GetTxOutCmd{Txid: txHash.String()}, nil)
+		require.NoError(t, err)
+		assert.Nil(t, result)
+	})
+
+	t.Run("utxo not found", func(t *testing.T) {
+		utxoStore := &utxo.MockUtxostore{}
+		utxoStore.On("Get", mock.Anything, txHash, mock.Anything).Return(minedMeta, nil)
+		utxoStore.On("GetSpend", mock.Anything, mock.Anything).Return(&utxo.SpendResponse{Status: int(utxo.Status_NOT_FOUND)}, nil)
+
+		result, err := handleGetTxOut(context.Background(), newServer(utxoStore, true), &bsvjson.GetTxOutCmd{Txid: txHash.String()}, nil)
+		require.NoError(t, err)
+		assert.Nil(t, result)
+	})
+
+	t.Run("output spent by unmined transaction without mempool", func(t *testing.T) {
+		spendingTxHash := chainhash.Hash{9}
+		includeMempool := false
+
+		utxoStore := &utxo.MockUtxostore{}
+		utxoStore.On("Get", mock.Anything, txHash, mock.Anything).Return(minedMeta, nil)
+		utxoStore.On("Get", mock.Anything, &spendingTxHash, mock.Anything).Return(&meta.Data{UnminedSince: 108}, nil)
+		utxoStore.On("GetSpend", mock.Anything, mock.Anything).Return(&utxo.SpendResponse{
+			Status:       int(utxo.Status_SPENT),
+			SpendingData: spend.NewSpendingData(&spendingTxHash, 0),
+		}, nil)
+
+		result, err := handleGetTxOut(context.Background(), newServer(utxoStore, true), &bsvjson.GetTxOutCmd{Txid: txHash.String(), IncludeMempool: &includeMempool}, nil)
+		require.NoError(t, err)
+		require.NotNil(t, result)
+	})
+
+	t.Run("unmined output without mempool", func(t *testing.T) {
+		includeMempool := false
+
+		utxoStore := &utxo.MockUtxostore{}
+		utxoStore.On("Get", mock.Anything, txHash, mock.Anything).Return(unminedMeta, nil)
+
+		result, err := handleGetTxOut(context.Background(), newServer(utxoStore, true), &bsvjson.GetTxOutCmd{Txid: txHash.String(), IncludeMempool: &includeMempool}, nil)
+		require.NoError(t, err)
+		assert.Nil(t, result)
+	})
+
+	t.Run("output mined on a fork has no confirmations", func(t *testing.T) {
+		utxoStore := &utxo.MockUtxostore{}
+		utxoStore.On("Get", mock.Anything, txHash, mock.Anything).Return(minedMeta, nil)
+		utxoStore.On("GetSpend", mock.Anything, mock.Anything).Return(&utxo.SpendResponse{Status: int(utxo.Status_OK)}, nil)
+
+		result, err := handleGetTxOut(context.Background(), newServer(utxoStore, false), &bsvjson.GetTxOutCmd{Txid: txHash.String()}, nil)
+		require.NoError(t, err)
+
+		txOut, ok := result.(*bsvjson.GetTxOutResult)
+		require.True(t, ok)
+		assert.Equal(t, int64(0), txOut.Confirmations)
+	})
+
+	t.Run("frozen and conflicting outputs are reported", func(t *testing.T) {
+		for _, status := range []utxo.Status{utxo.Status_FROZEN, utxo.Status_CONFLICTING, utxo.Status_LOCKED} {
+			utxoStore := &utxo.MockUtxostore{}
+			utxoStore.On("Get", mock.Anything, txHash, mock.Anything).Return(unminedMeta, nil)
+			utxoStore.On("GetSpend", mock.Anything, mock.Anything).Return(&utxo.SpendResponse{Status: int(status)}, nil)
+
+			result, err := handleGetTxOut(context.Background(), newServer(utxoStore, true), &bsvjson.GetTxOutCmd{Txid: txHash.String()}, nil)
+			require.NoError(t, err)
+
+			txOut, ok := result.(*bsvjson.GetTxOutResult)
+			require.True(t, ok)
+			assert.Equal(t, status == utxo.Status_FROZEN, txOut.Frozen)
+			assert.Equal(t, status == utxo.Status_CONFLICTING, txOut.Conflicting)
+			assert.Equal(t, status == utxo.Status_LOCKED, txOut.Locked)
+		}
+	})
+}
+
+// TestHandleGetTxOutProofComprehensive tests gettxoutproof and verifytxoutproof against a block
+// whose transactions are stored in a single subtree
+func TestHandleGetTxOutProofComprehensive(t *testing.T) {
+	logger := mocklogger.NewTestLogger()
+	ctx := context.Background()
+
+	coinbaseTx := bt.NewTx()
+	require.NoError(t, coinbaseTx.PayToAddress("1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", 5_000_000_000))
+
+	txHashes := []chainhash.Hash{{0x01}, {0x02}, {0x03}}
+
+	// the stored subtree starts with the coinbase placeholder
+	storedSubtree, err := subtree.NewTreeByLeafCount(4)
+	require.NoError(t, err)
+	require.NoError(t, storedSubtree.AddCoinbaseNode())
+
+	// the merkle root of the block is calculated over the real coinbase txid
+	rootSubtree, err := subtree.NewTreeByLeafCount(4)
+	require.NoError(t, err)
+	require.NoError(t, rootSubtree.AddNode(*coinbaseTx.TxIDChainHash(), 0, 0))
+
+	for _, txHash := range txHashes {
+		require.NoError(t, storedSubtree.AddNode(txHash, 1, 250))
+		require.NoError(t, rootSubtree.AddNode(txHash, 1, 250))
+	}
+
+	subtreeBytes, err := storedSubtree.Serialize()
+	require.NoError(t, err)
+
+	subtreeStore := memory.New()
+	require.NoError(t, subtreeStore.Set(ctx, storedSubtree.RootHash()[:], fileformat.FileTypeSubtree, subtreeBytes))
+
+	newBlock := func() *model.Block {
+		return &model.Block{
+			Header: &model.BlockHeader{
+				Version:        1,
+				HashPrevBlock:  &chainhash.Hash{},
+				HashMerkleRoot: rootSubtree.RootHash(),
+				Timestamp:      1700000000,
+				Bits:           model.NBit{0xff, 0xff, 0x7f, 0x20},
+			},
+			CoinbaseTx:       coinbaseTx,
+			TransactionCount: 4,
+			Subtrees:         []*chainhash.Hash{storedSubtree.RootHash()},
+		}
+	}
+
+	blockHash := newBlock().Hash()
+
+	newServer := func(utxoStore *utxo.MockUtxostore, onCurrentChain bool) *RPCServer {
+		return &RPCServer{
+			logger:       logger,
+			utxoStore:    utxoStore,
+			subtreeStore: subtreeStore,
+			settings: &settings.Settings{
+				ChainCfgParams: &chaincfg.MainNetParams,
+			},
+			blockchainClient: &mockBlockchainClient{
+				getBlockFunc: func(ctx context.Context, hash *chainhash.Hash) (*model.Block, error) {
+					if hash.IsEqual(blockHash) {
+						return newBlock(), nil
+					}
+
+					return nil, errors.NewBlockNotFoundError("block not found")
+				},
+				getBlockByIDFunc: func(ctx context.Context, id uint64) (*model.Block, error) {
+					return newBlock(), nil
+				},
+				getBlockHeaderFunc: func(ctx context.Context, hash *chainhash.Hash) (*model.BlockHeader, *model.BlockHeaderMeta, error) {
+					if hash.IsEqual(blockHash) {
+						return newBlock().Header, &model.BlockHeaderMeta{ID: 5, Height: 101}, nil
+					}
+
+					return nil, nil, errors.NewBlockNotFoundError("block not found")
+				},
+				checkBlockIsInCurrentChainFunc: func(ctx context.Context, blockIDs []uint32) (bool, error) {
+					return onCurrentChain, nil
+				},
+			},
+		}
+	}
+
+	blockHashStr := blockHash.String()
+
+	t.Run("empty txids", func(t *testing.T) {
+		_, err := handleGetTxOutProof(ctx, newServer(&utxo.MockUtxostore{}, true), &bsvjson.GetTxOutProofCmd{}, nil)
+		require.Error(t, err)
+
+		rpcErr, ok := err.(*bsvjson.RPCError)
+		require.True(t, ok)
+		assert.Equal(t, bsvjson.ErrRPCInvalidParameter, rpcErr.Code)
+	})
+
+	t.Run("duplicated txid", func(t *testing.T) {
+		cmd := &bsvjson.GetTxOutProofCmd{TxIDs: []string{txHashes[0].String(), txHashes[0].String()}}
+
+		_, err := handleGetTxOutProof(ctx, newServer(&utxo.MockUtxostore{}, true), cmd, nil)
+		require.Error(t, err)
+
+		rpcErr, ok := err.(*bsvjson.RPCError)
+		require.True(t, ok)
+		assert.Equal(t, bsvjson.ErrRPCInvalidParameter, rpcErr.Code)
+	})
+
+	t.Run("block not found", func(t *testing.T) {
+		unknownBlock := chainhash.Hash{0xff}.String()
+		cmd := &bsvjson.GetTxOutProofCmd{TxIDs: []string{txHashes[0].String()}, BlockHash: &unknownBlock}
+
+		_, err := handleGetTxOutProof(ctx, newServer(&utxo.MockUtxostore{}, true), cmd, nil)
+		require.Error(t, err)
+
+		rpcErr, ok := err.(*bsvjson.RPCError)
+		require.True(t, ok)
+		assert.Equal(t, bsvjson.ErrRPCBlockNotFound, rpcErr.Code)
+	})
+
+	t.Run("transaction not in block", func(t *testing.T) {
+		cmd := &bsvjson.GetTxOutProofCmd{TxIDs: []string{chainhash.Hash{0x04}.String()}, BlockHash: &blockHashStr}
+
+		_, err := handleGetTxOutProof(ctx, newServer(&utxo.MockUtxostore{}, true), cmd, nil)
+		require.Error(t, err)
+
+		rpcErr, ok := err.(*bsvjson.RPCError)
+		require.True(t, ok)
+		assert.Equal(t, bsvjson.ErrRPCInvalidAddressOrKey, rpcErr.Code)
+	})
+
+	t.Run("unmined transaction", func(t *testing.T) {
+		utxoStore := &utxo.MockUtxostore{}
+		utxoStore.On("Get", mock.Anything, &txHashes[0], mock.Anything).Return(&meta.Data{UnminedSince: 100}, nil)
+
+		cmd := &bsvjson.GetTxOutProofCmd{TxIDs: []string{txHashes[0].String()}}
+
+		_, err := handleGetTxOutProof(ctx, newServer(utxoStore, true), cmd, nil)
+		require.Error(t, err)
+
+		rpcErr, ok := err.(*bsvjson.RPCError)
+		require.True(t, ok)
+		assert.Equal(t, bsvjson.ErrRPCInvalidAddressOrKey, rpcErr.Code)
+		assert.Equal(t, "Transaction not yet in block", rpcErr.Message)
+	})
+
+	t.Run("proof round trip with block hash", func(t *testing.T) {
+		s := newServer(&utxo.MockUtxostore{}, true)
+		cmd := &bsvjson.GetTxOutProofCmd{TxIDs: []string{txHashes[2].String(), txHashes[0].String()}, BlockHash: &blockHashStr}
+
+		proof, err := handleGetTxOutProof(ctx, s, cmd, nil)
+		require.NoError(t, err)
+
+		result, err := handleVerifyTxOutProof(ctx, s, &bsvjson.VerifyTxOutProofCmd{Proof: proof.(string)}, nil)
+		require.NoError(t, err)
+
+		// matches are returned in block order
+		assert.Equal(t, []string{txHashes[0].String(), txHashes[2].String()}, result)
+	})
+
+	t.Run("proof round trip for coinbase looked up from utxo store", func(t *testing.T) {
+		coinbaseHash := coinbaseTx.TxIDChainHash()
+
+		utxoStore := &utxo.MockUtxostore{}
+		utxoStore.On("Get", mock.Anything, coinbaseHash, mock.Anything).Return(&meta.Data{BlockIDs: []uint32{5}, BlockHeights: []uint32{101}}, nil)
+
+		s := newServer(utxoStore, true)
+
+		proof, err := handleGetTxOutProof(ctx, s, &bsvjson.GetTxOutProofCmd{TxIDs: []string{coinbaseHash.String()}}, nil)
+		require.NoError(t, err)
+
+		result, err := handleVerifyTxOutProof(ctx, s, &bsvjson.VerifyTxOutProofCmd{Proof: proof.(string)}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{coinbaseHash.String()}, result)
+	})
+
+	t.Run("verify invalid hex", func(t *testing.T) {
+		_, err := handleVerifyTxOutProof(ctx, newServer(&utxo.MockUtxostore{}, true), &bsvjson.VerifyTxOutProofCmd{Proof: "zz"}, nil)
+		require.Error(t, err)
+
+		rpcErr, ok := err.(*bsvjson.RPCError)
+		require.True(t, ok)
+		assert.Equal(t, bsvjson.ErrRPCDecodeHexString, rpcErr.Code)
+	})
+
+	t.Run("verify proof with wrong merkle root", func(t *testing.T) {
+		s := newServer(&utxo.MockUtxostore{}, true)
+		cmd := &bsvjson.GetTxOutProofCmd{TxIDs: []string{txHashes[1].String()}, BlockHash: &blockHashStr}
+
+		proof, err := handleGetTxOutProof(ctx, s, cmd, nil)
+		require.NoError(t, err)
+
+		proofBytes, err := hex.DecodeString(proof.(string))
+		require.NoError(t, err)
+
+		// corrupt the merkle root in the block header
+		proofBytes[36] ^= 0xff
+
+		result, err := handleVerifyTxOutProof(ctx, s, &bsvjson.VerifyTxOutProofCmd{Proof: hex.EncodeToString(proofBytes)}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{}, result)
+	})
+
+	t.Run("verify proof for block not on the best chain", func(t *testing.T) {
+		cmd := &bsvjson.GetTxOutProofCmd{TxIDs: []string{txHashes[1].String()}, BlockHash: &blockHashStr}
+
+		proof, err := handleGetTxOutProof(ctx, newServer(&utxo.MockUtxostore{}, true), cmd, nil)
+		require.NoError(t, err)
+
+		_, err = handleVerifyTxOutProof(ctx, newServer(&utxo.MockUtxostore{}, false), &bsvjson.VerifyTxOutProofCmd{Proof: proof.(string)}, nil)
+		require.Error(t, err)
+
+		rpcErr, ok := err.(*bsvjson.RPCError)
+		require.True(t, ok)
+		assert.Equal(t, bsvjson.ErrRPCInvalidAddressOrKey, rpcErr.Code)
+	})
+}
+
+// TestHandleGetBlockTemplateComprehensive tests the getblocktemplate and submitblock handlers
+func TestHandleGetBlockTemplateComprehensive(t *testing.T) {
+	logger := mocklogger.NewTestLogger()
+	ctx := context.Background()
+
+	coinbaseTx, err := bt.NewTxFromString("01000000010000000000000000000000000000000000000000000000000000000000000000ffffffff580320a107152f5669614254432f48656c6c6f20576f726c64212f2cfabe6d6dbcbb1b0222e1aeebaca2a9c905bb23a3ad0302898ec600a9033a87ec1645a446010000000000000010f829ba0b13a84def80c389cde9840000ffffffff0174fdaf4a000000001976a914f1c075a01882ae0972f95d3a4177c86c852b7d9188ac00000000")
+	require.NoError(t, err)
+
+	lockingScript := "76a914f1c075a01882ae0972f95d3a4177c86c852b7d9188ac"
+
+	// tx2 spends tx1, tx3 is independent
+	tx1 := bt.NewTx()
+	require.NoError(t, tx1.From(strings.Repeat("11", 32), 0, lockingScript, 10_000))
+	require.NoError(t, tx1.PayToAddress("1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", 9_000))
+
+	tx2 := bt.NewTx()
+	require.NoError(t, tx2.From(tx1.TxID(), 0, lockingScript, 9_000))
+	require.NoError(t, tx2.PayToAddress("1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", 8_000))
+
+	tx3 := bt.NewTx()
+	require.NoError(t, tx3.From(strings.Repeat("33", 32), 1, lockingScript, 5_000))
+	require.NoError(t, tx3.PayToAddress("1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", 4_000))
+
+	txs := []*bt.Tx{tx1, tx2, tx3}
+
+	// the stored subtree starts with the coinbase placeholder
+	storedSubtree, err := subtree.NewTreeByLeafCount(4)
+	require.NoError(t, err)
+	require.NoError(t, storedSubtree.AddCoinbaseNode())
+
+	// the merkle root of the block is calculated over the real coinbase txid
+	rootSubtree, err := subtree.NewTreeByLeafCount(4)
+	require.NoError(t, err)
+	require.NoError(t, rootSubtree.AddNode(*coinbaseTx.TxIDChainHash(), 0, 0))
+
+	sizeWithoutCoinbase := uint64(0)
+
+	for i, tx := range txs {
+		require.NoError(t, storedSubtree.AddNode(*tx.TxIDChainHash(), uint64(1000+i), uint64(tx.Size())))
+		require.NoError(t, rootSubtree.AddNode(*tx.TxIDChainHash(), uint64(1000+i), uint64(tx.Size())))
+
+		sizeWithoutCoinbase += uint64(tx.Size())
+	}
+
+	subtreeBytes, err := storedSubtree.Serialize()
+	require.NoError(t, err)
+
+	subtreeStore := memory.New()
+	require.NoError(t, subtreeStore.Set(ctx, storedSubtree.RootHash()[:], fileformat.FileTypeSubtree, subtreeBytes))
+
+	coinbaseProof, err := rootSubtree.GetMerkleProof(0)
+	require.NoError(t, err)
+
+	merkleProof := make([][]byte, len(coinbaseProof))
+	for i, hash := range coinbaseProof {
+		merkleProof[i] = hash.CloneBytes()
+	}
+
+	bestBlockHeader := &model.BlockHeader{
+		Version:        1,
+		HashPrevBlock:  &chainhash.Hash{0x01},
+		HashMerkleRoot: &chainhash.Hash{0x02},
+		Timestamp:      1700000000,
+		Bits:           model.NBit{0xff, 0xff, 0x7f, 0x20},
+	}
+	prevHash := bestBlockHeader.Hash()
+
+	candidate := &model.MiningCandidate{
+		Id:                  []byte{0x01, 0x02, 0x03, 0x04},
+		PreviousHash:        prevHash.CloneBytes(),
+		CoinbaseValue:       5_000_003_003,
+		Version:             0x20000000,
+		NBits:               []byte{0xff, 0xff, 0x7f, 0x20},
+		Time:                1700000600,
+		Height:              102,
+		MerkleProof:         merkleProof,
+		SubtreeCount:        1,
+		NumTxs:              3,
+		SizeWithoutCoinbase: sizeWithoutCoinbase,
+		SubtreeHashes:       [][]byte{storedSubtree.RootHash().CloneBytes()},
+	}
+
+	newUtxoStore := func() *utxo.MockUtxostore {
+		utxoStore := &utxo.MockUtxostore{}
+		utxoStore.On("BatchDecorate", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			for _, unresolved := range args.Get(1).([]*utxo.UnresolvedMetaData) {
+				for _, tx := range txs {
+					if unresolved.Hash.IsEqual(tx.TxIDChainHash()) {
+						unresolved.Data = &meta.Data{Tx: tx}
+					}
+				}
+			}
+		}).Return(nil)
+
+		return utxoStore
+	}
+
+	type processedBlock struct {
+		block  *model.Block
+		height uint32
+	}
+
+	newServer := func(processed chan<- processedBlock, processErr error) *RPCServer {
+		return &RPCServer{
+			logger:       logger,
+			utxoStore:    newUtxoStore(),
+			subtreeStore: subtreeStore,
+			settings: &settings.Settings{
+				ChainCfgParams: &chaincfg.RegressionNetParams,
+				RPC: settings.RPCSettings{
+					GBTLongPollTimeout: 50 * time.Millisecond,
+					GBTMaxTransactions: 100,
+				},
+			},
+			blockchainClient: &mockBlockchainClient{
+				getBestBlockHeaderFunc: func(ctx context.Context) (*model.BlockHeader, *model.BlockHeaderMeta, error) {
+					return bestBlockHeader, &model.BlockHeaderMeta{Height: 101}, nil
+				},
+			},
+			blockAssemblyClient: &mockBlockAssemblyClient{
+				getMiningCandidateFunc: func(ctx context.Context, includeSubtreeHashes ...bool) (*model.MiningCandidate, error) {
+					return candidate, nil
+				},
+			},
+			blockValidationClient: &mockBlockValidationClient{
+				processBlockFunc: func(ctx context.Context, block *model.Block, height uint32) error {
+					if processed != nil {
+						processed <- processedBlock{block: block, height: height}
+					}
+
+					return processErr
+				},
+			},
+		}
+	}
+
+	// serializeBlock returns the wire format of a block with the given header and transactions
+	serializeBlock := func(header *model.BlockHeader, blockTxs ...*bt.Tx) string {
+		blockBytes := header.Bytes()
+		blockBytes = append(blockBytes, bt.VarInt(len(blockTxs)).Bytes()...)
+
+		for _, tx := range blockTxs {
+			blockBytes = append(blockBytes, tx.Bytes()...)
+		}
+
+		return hex.EncodeToString(blockBytes)
+	}
+
+	blockHeader := &model.BlockHeader{
+		Version:        0x20000000,
+		HashPrevBlock:  prevHash,
+		HashMerkleRoot: rootSubtree.RootHash(),
+		Timestamp:      1700000600,
+		Bits:           model.NBit{0xff, 0xff, 0x7f, 0x20},
+		Nonce:          42,
+	}
+
+	workID := utils.ReverseAndHexEncodeSlice(candidate.Id)
+
+	t.Run("full template", func(t *testing.T) {
+		s := newServer(nil, nil)
+
+		result, err := handleGetBlockTemplate(ctx, s, &bsvjson.GetBlockTemplateCmd{}, nil)
+		require.NoError(t, err)
+
+		gbt, ok := result.(*bsvjson.GetBlockTemplateResult)
+		require.True(t, ok)
+
+		assert.Equal(t, "207fffff", gbt.Bits)
+		assert.Equal(t, int64(1700000600), gbt.CurTime)
+		assert.Equal(t, int64(102), gbt.Height)
+		assert.Equal(t, prevHash.String(), gbt.PreviousHash)
+		assert.Equal(t, int32(0x20000000), gbt.Version)
+		assert.Equal(t, int64(5_000_003_003), *gbt.CoinbaseValue)
+		assert.Equal(t, workID, gbt.WorkID)
+		assert.Equal(t, prevHash.String()+"-"+workID, gbt.LongPollID)
+		assert.Equal(t, "7fffff0000000000000000000000000000000000000000000000000000000000", gbt.Target)
+		assert.Equal(t, []string{"proposal", "subtrees"}, gbt.Capabilities)
+		assert.Empty(t, gbt.SubtreeHashes)
+
+		require.Len(t, gbt.Transactions, 3)
+
+		for i, tx := range txs {
+			assert.Equal(t, hex.EncodeToString(tx.Bytes()), gbt.Transactions[i].Data)
+			assert.Equal(t, tx.TxID(), gbt.Transactions[i].Hash)
+			assert.Equal(t, int64(1000+i), gbt.Transactions[i].Fee)
+			assert.Equal(t, int64(tx.Size()), gbt.Transactions[i].Size)
+		}
+
+		assert.Empty(t, gbt.Transactions[0].Depends)
+		assert.Equal(t, []int64{1}, gbt.Transactions[1].Depends)
+		assert.Empty(t, gbt.Transactions[2].Depends)
+	})
+
+	t.Run("subtrees capability", func(t *testing.T) {
+		s := newServer(nil, nil)
+		s.settings.RPC.GBTMaxTransactions = 1
+
+		cmd := &bsvjson.GetBlockTemplateCmd{Request: &bsvjson.TemplateRequest{Capabilities: []string{"subtrees"}}}
+
+		result, err := handleGetBlockTemplate(ctx, s, cmd, nil)
+		require.NoError(t, err)
+
+		gbt := result.(*bsvjson.GetBlockTemplateResult)
+		assert.Equal(t, []string{storedSubtree.RootHash().String()}, gbt.SubtreeHashes)
+		assert.Equal(t, uint32(3), gbt.TxCount)
+		assert.Empty(t, gbt.Transactions)
+
+		require.Len(t, gbt.MerkleProof, len(coinbaseProof))
+
+		for i, hash := range coinbaseProof {
+			assert.Equal(t, hash.String(), gbt.MerkleProof[i])
+		}
+
+		s.utxoStore.(*utxo.MockUtxostore).AssertNotCalled(t, "BatchDecorate", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("too many transactions without subtrees capability", func(t *testing.T) {
+		s := newServer(nil, nil)
+		s.settings.RPC.GBTMaxTransactions = 2
+
+		_, err := handleGetBlockTemplate(ctx, s, &bsvjson.GetBlockTemplateCmd{}, nil)
+		require.Error(t, err)
+
+		rpcErr, ok := err.(*bsvjson.RPCError)
+		require.True(t, ok)
+		assert.Equal(t, bsvjson.ErrRPCInvalidParameter, rpcErr.Code)
+		assert.Contains(t, rpcErr.Message, "subtrees")
+	})
+
+	t.Run("invalid mode", func(t *testing.T) {
+		_, err := handleGetBlockTemplate(ctx, newServer(nil, nil), &bsvjson.GetBlockTemplateCmd{Request: &bsvjson.TemplateRequest{Mode: "invalid"}}, nil)
+		require.Error(t, err)
+
+		rpcErr, ok := err.(*bsvjson.RPCError)
+		require.True(t, ok)
+		assert.Equal(t, bsvjson.ErrRPCInvalidParameter, rpcErr.Code)
+	})
+
+	t.Run("invalid longpollid", func(t *testing.T) {
+		cmd := &bsvjson.GetBlockTemplateCmd{Request: &bsvjson.TemplateRequest{LongPollID: "zz-" + workID}}
+
+		_, err := handleGetBlockTemplate(ctx, newServer(nil, nil), cmd, nil)
+		require.Error(t, err)
+
+		rpcErr, ok := err.(*bsvjson.RPCError)
+		require.True(t, ok)
+		assert.Equal(t, bsvjson.ErrRPCInvalidParameter, rpcErr.Code)
+	})
+
+	t.Run("long poll returns immediately when best block changed", func(t *testing.T) {
+		cmd := &bsvjson.GetBlockTemplateCmd{Request: &bsvjson.TemplateRequest{LongPollID: (&chainhash.Hash{0x09}).String() + "-" + workID}}
+
+		s := newServer(nil, nil)
+		s.settings.RPC.GBTLongPollTimeout = time.Minute
+
+		result, err := handleGetBlockTemplate(ctx, s, cmd, nil)
+		require.NoError(t, err)
+		assert.Equal(t, workID, result.(*bsvjson.GetBlockTemplateResult).WorkID)
+	})
+
+	t.Run("long poll waits for timeout", func(t *testing.T) {
+		cmd := &bsvjson.GetBlockTemplateCmd{Request: &bsvjson.TemplateRequest{LongPollID: prevHash.String() + "-" + workID}}
+
+		start := time.Now()
+
+		_, err := handleGetBlockTemplate(ctx, newServer(nil, nil), cmd, nil)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	})
+
+	t.Run("long poll wakes up on block notification", func(t *testing.T) {
+		cmd := &bsvjson.GetBlockTemplateCmd{Request: &bsvjson.TemplateRequest{LongPollID: prevHash.String() + "-" + workID}}
+
+		s := newServer(nil, nil)
+		s.settings.RPC.GBTLongPollTimeout = time.Minute
+
+		var changed atomic.Bool
+
+		s.blockchainClient.(*mockBlockchainClient).getBestBlockHeaderFunc = func(ctx context.Context) (*model.BlockHeader, *model.BlockHeaderMeta, error) {
+			if changed.Load() {
+				return blockHeader, &model.BlockHeaderMeta{Height: 102}, nil
+			}
+
+			return bestBlockHeader, &model.BlockHeaderMeta{Height: 101}, nil
+		}
+
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			changed.Store(true)
+			s.blockTemplates.notifyBlock()
+		}()
+
+		_, err := handleGetBlockTemplate(ctx, s, cmd, nil)
+		require.NoError(t, err)
+		assert.True(t, changed.Load())
+	})
+
+	t.Run("long poll cancelled by client", func(t *testing.T) {
+		cmd := &bsvjson.GetBlockTemplateCmd{Request: &bsvjson.TemplateRequest{LongPollID: prevHash.String() + "-" + workID}}
+
+		s := newServer(nil, nil)
+		s.settings.RPC.GBTLongPollTimeout = time.Minute
+
+		closeChan := make(chan struct{})
+		close(closeChan)
+
+		_, err := handleGetBlockTemplate(ctx, s, cmd, closeChan)
+		require.Error(t, err)
+	})
+
+	t.Run("submit header and coinbase for subtree template", func(t *testing.T) {
+		processed := make(chan processedBlock, 1)
+		s := newServer(processed, nil)
+
+		_, err := handleGetBlockTemplate(ctx, s, &bsvjson.GetBlockTemplateCmd{Request: &bsvjson.TemplateRequest{Capabilities: []string{"subtrees"}}}, nil)
+		require.NoError(t, err)
+
+		result, err := handleSubmitBlock(ctx, s, &bsvjson.SubmitBlockCmd{HexBlock: serializeBlock(blockHeader, coinbaseTx)}, nil)
+		require.NoError(t, err)
+		assert.Nil(t, result)
+
+		block := (<-processed).block
+		assert.Equal(t, blockHeader.Hash(), block.Hash())
+		assert.Equal(t, []*chainhash.Hash{storedSubtree.RootHash()}, block.Subtrees)
+		assert.Equal(t, uint64(4), block.TransactionCount)
+		assert.Equal(t, uint32(102), block.Height)
+		assert.Equal(t, coinbaseTx.TxID(), block.CoinbaseTx.TxID())
+		assert.Equal(t, uint64(len(serializeBlock(blockHeader, coinbaseTx, tx1, tx2, tx3))/2), block.SizeInBytes)
+	})
+
+	t.Run("submit full block with workid", func(t *testing.T) {
+		processed := make(chan processedBlock, 1)
+		s := newServer(processed, nil)
+
+		_, err := handleGetBlockTemplate(ctx, s, &bsvjson.GetBlockTemplateCmd{}, nil)
+		require.NoError(t, err)
+
+		cmd := &bsvjson.SubmitBlockCmd{
+			HexBlock: serializeBlock(blockHeader, coinbaseTx, tx1, tx2, tx3),
+			Options:  &bsvjson.SubmitBlockOptions{WorkID: workID},
+		}
+
+		result, err := handleSubmitBlock(ctx, s, cmd, nil)
+		require.NoError(t, err)
+		assert.Nil(t, result)
+		assert.Equal(t, blockHeader.Hash(), (<-processed).block.Hash())
+	})
+
+	t.Run("submit full block with wrong transactions", func(t *testing.T) {
+		s := newServer(nil, nil)
+
+		_, err := handleGetBlockTemplate(ctx, s, &bsvjson.GetBlockTemplateCmd{}, nil)
+		require.NoError(t, err)
+
+		result, err := handleSubmitBlock(ctx, s, &bsvjson.SubmitBlockCmd{HexBlock: serializeBlock(blockHeader, coinbaseTx, tx2, tx1, tx3)}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "bad-txnmrklroot", result)
+
+		result, err = handleSubmitBlock(ctx, s, &bsvjson.SubmitBlockCmd{HexBlock: serializeBlock(blockHeader, coinbaseTx, tx1, tx2)}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "bad-txnmrklroot", result)
+	})
+
+	t.Run("submit block without template", func(t *testing.T) {
+		result, err := handleSubmitBlock(ctx, newServer(nil, nil), &bsvjson.SubmitBlockCmd{HexBlock: serializeBlock(blockHeader, coinbaseTx)}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "rejected: block does not match a known block template", result)
+	})
+
+	t.Run("submit block with unknown workid", func(t *testing.T) {
+		s := newServer(nil, nil)
+
+		_, err := handleGetBlockTemplate(ctx, s, &bsvjson.GetBlockTemplateCmd{}, nil)
+		require.NoError(t, err)
+
+		cmd := &bsvjson.SubmitBlockCmd{
+			HexBlock: serializeBlock(blockHeader, coinbaseTx),
+			Options:  &bsvjson.SubmitBlockOptions{WorkID: "deadbeef"},
+		}
+
+		result, err := handleSubmitBlock(ctx, s, cmd, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "rejected: block does not match a known block template", result)
+	})
+
+	t.Run("submit duplicate block", func(t *testing.T) {
+		s := newServer(nil, errors.NewBlockExistsError("block exists"))
+
+		_, err := handleGetBlockTemplate(ctx, s, &bsvjson.GetBlockTemplateCmd{}, nil)
+		require.NoError(t, err)
+
+		result, err := handleSubmitBlock(ctx, s, &bsvjson.SubmitBlockCmd{HexBlock: serializeBlock(blockHeader, coinbaseTx)}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "duplicate", result)
+	})
+
+	t.Run("submit invalid block", func(t *testing.T) {
+		s := newServer(nil, errors.NewBlockInvalidError("bad block"))
+
+		_, err := handleGetBlockTemplate(ctx, s, &bsvjson.GetBlockTemplateCmd{}, nil)
+		require.NoError(t, err)
+
+		result, err := handleSubmitBlock(ctx, s, &bsvjson.SubmitBlockCmd{HexBlock: serializeBlock(blockHeader, coinbaseTx)}, nil)
+		require.NoError(t, err)
+		assert.Contains(t, result, "rejected: ")
+	})
+
+	t.Run("submit invalid hex", func(t *testing.T) {
+		_, err := handleSubmitBlock(ctx, newServer(nil, nil), &bsvjson.SubmitBlockCmd{HexBlock: "zz"}, nil)
+		require.Error(t, err)
+
+		rpcErr, ok := err.(*bsvjson.RPCError)
+		require.True(t, ok)
+		assert.Equal(t, bsvjson.ErrRPCDecodeHexString, rpcErr.Code)
+	})
+
+	t.Run("submit undecodable block", func(t *testing.T) {
+		_, err := handleSubmitBlock(ctx, newServer(nil, nil), &bsvjson.SubmitBlockCmd{HexBlock: "0000"}, nil)
+		require.Error(t, err)
+
+		rpcErr, ok := err.(*bsvjson.RPCError)
+		require.True(t, ok)
+		assert.Equal(t, bsvjson.ErrRPCDeserialization, rpcErr.Code)
+	})
+
+	t.Run("proposal", func(t *testing.T) {
+		s := newServer(nil, nil)
+
+		var validated *model.Block
+
+		s.blockValidationClient.(*mockBlockValidationClient).validateBlockFunc = func(ctx context.Context, block *model.Block, options *blockvalidation.ValidateBlockOptions) error {
+			validated = block
+			return nil
+		}
+
+		_, err := handleGetBlockTemplate(ctx, s, &bsvjson.GetBlockTemplateCmd{}, nil)
+		require.NoError(t, err)
+
+		cmd := &bsvjson.GetBlockTemplateCmd{Request: &bsvjson.TemplateRequest{Mode: "proposal", Data: serializeBlock(blockHeader, coinbaseTx, tx1, tx2, tx3)}}
+
+		result, err := handleGetBlockTemplate(ctx, s, cmd, nil)
+		require.NoError(t, err)
+		assert.Nil(t, result)
+
+		require.NotNil(t, validated)
+		assert.Equal(t, blockHeader.Hash(), validated.Hash())
+	})
+
+	t.Run("proposal rejected", func(t *testing.T) {
+		s := newServer(nil, nil)
+
+		s.blockValidationClient.(*mockBlockValidationClient).validateBlockFunc = func(ctx context.Context, block *model.Block, options *blockvalidation.ValidateBlockOptions) error {
+			return errors.NewBlockInvalidError("bad block")
+		}
+
+		_, err := handleGetBlockTemplate(ctx, s, &bsvjson.GetBlockTemplateCmd{}, nil)
+		require.NoError(t, err)
+
+		cmd := &bsvjson.GetBlockTemplateCmd{Request: &bsvjson.TemplateRequest{Mode: "proposal", Data: serializeBlock(blockHeader, coinbaseTx)}}
+
+		result, err := handleGetBlockTemplate(ctx, s, cmd, nil)
+		require.NoError(t, err)
+		assert.Contains(t, result, "rejected: ")
+	})
+
+	t.Run("proposal without data", func(t *testing.T) {
+		_, err := handleGetBlockTemplate(ctx, newServer(nil, nil), &bsvjson.GetBlockTemplateCmd{Request: &bsvjson.TemplateRequest{Mode: "proposal"}}, nil)
+		require.Error(t, err)
+	})
+}
+
+// TestBlockTemplateState tests the bookkeeping of the block templates handed out by getblocktemplate
+func TestBlockTemplateState(t *testing.T) {
+	newTemplate := func(id byte, prevHash chainhash.Hash) *blockTemplate {
+		template, err := newBlockTemplate(&model.MiningCandidate{Id: []byte{id}, PreviousHash: prevHash[:]})
+		require.NoError(t, err)
+
+		return template
+	}
+
+	t.Run("templates building on an older block are dropped", func(t *testing.T) {
+		var state blockTemplateState
+
+		state.add(newTemplate(1, chainhash.Hash{0x01}))
+		state.add(newTemplate(2, chainhash.Hash{0x01}))
+		assert.Len(t, state.templates, 2)
+
+		state.add(newTemplate(3, chainhash.Hash{0x02}))
+		require.Len(t, state.templates, 1)
+		assert.Equal(t, "03", state.templates[0].workID)
+	})
+
+	t.Run("template with the same work id is replaced", func(t *testing.T) {
+		var state blockTemplateState
+
+		state.add(newTemplate(1, chainhash.Hash{0x01}))
+		state.add(newTemplate(1, chainhash.Hash{0x01}))
+		assert.Len(t, state.templates, 1)
+	})
+
+	t.Run("oldest template is dropped", func(t *testing.T) {
+		var state blockTemplateState
+
+		for i := 0; i < maxBlockTemplates+4; i++ {
+			state.add(newTemplate(byte(i), chainhash.Hash{0x01}))
+		}
+
+		require.Len(t, state.templates, maxBlockTemplates)
+		assert.Equal(t, "04", state.templates[0].workID)
+	})
+
+	t.Run("block notification", func(t *testing.T) {
+		var state blockTemplateState
+
+		ch := state.blockNotifyChannel()
+		assert.Equal(t, ch, state.blockNotifyChannel())
+
+		state.notifyBlock()
+
+		select {
+		case <-ch:
+		default:
+			t.Fatal("block notify channel was not closed")
+		}
+
+		assert.NotEqual(t, ch, state.blockNotifyChannel())
+	})
+}
+
+func TestHandleGetCFilterComprehensive(t *testing.T) {
+	logger := mocklogger.NewTestLogger()
+	ctx := context.Background()
+
+	blockHash := chainhash.Hash{0x01, 0x02, 0x03}
+	blockFilter := &filterindex.BlockFilter{
+		BlockHash: blockHash,
+		Header:    chainhash.DoubleHashH([]byte("filter header")),
+		Filter:    []byte{0x01, 0x9d, 0xfc, 0xa8},
+	}
+
+	blockStore := memory.New()
+	require.NoError(t, filterindex.PutBlockFilter(ctx, blockStore, blockFilter))
+
+	newServer := func(enabled bool) *RPCServer {
+		return &RPCServer{
+			logger:     logger,
+			blockStore: blockStore,
+			settings: &settings.Settings{
+				ChainCfgParams: &chaincfg.MainNetParams,
+				FilterIndex:    settings.FilterIndexSettings{Enabled: enabled},
+			},
+		}
+	}
+
+	assertRPCError := func(t *testing.T, err error, code bsvjson.RPCErrorCode) {
+		require.Error(t, err)
+
+		rpcErr, ok := err.(*bsvjson.RPCError)
+		require.True(t, ok)
+		assert.Equal(t, code, rpcErr.Code)
+	}
+
+	t.Run("filter index disabled", func(t *testing.T) {
+		_, err := handleGetCFilter(ctx, newServer(false), &bsvjson.GetCFilterCmd{Hash: blockHash.String()}, nil)
+		assertRPCError(t, err, bsvjson.ErrRPCNoCFIndex)
+
+		_, err = handleGetCFilterHeader(ctx, newServer(false), &bsvjson.GetCFilterHeaderCmd{Hash: blockHash.String()}, nil)
+		assertRPCError(t, err, bsvjson.ErrRPCNoCFIndex)
+	})
+
+	t.Run("unknown filter type", func(t *testing.T) {
+		_, err := handleGetCFilter(ctx, newServer(true), &bsvjson.GetCFilterCmd{Hash: blockHash.String(), FilterType: 1}, nil)
+		assertRPCError(t, err, bsvjson.ErrRPCInvalidParameter)
+	})
+
+	t.Run("invalid hash", func(t *testing.T) {
+		_, err := handleGetCFilter(ctx, newServer(true), &bsvjson.GetCFilterCmd{Hash: "xyz"}, nil)
+		assertRPCError(t, err, bsvjson.ErrRPCDecodeHexString)
+	})
+
+	t.Run("block not indexed", func(t *testing.T) {
+		_, err := handleGetCFilter(ctx, newServer(true), &bsvjson.GetCFilterCmd{Hash: chainhash.Hash{0xff}.String()}, nil)
+		assertRPCError(t, err, bsvjson.ErrRPCBlockNotFound)
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		result, err := handleGetCFilter(ctx, newServer(true), &bsvjson.GetCFilterCmd{Hash: blockHash.String()}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "019dfca8", result)
+	})
+
+	t.Run("filter header", func(t *testing.T) {
+		result, err := handleGetCFilterHeader(ctx, newServer(true), &bsvjson.GetCFilterHeaderCmd{Hash: blockHash.String()}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, blockFilter.Header.String(), result)
+	})
+}
+
+func TestHandleGetTxOutSetInfoComprehensive(t *testing.T) {
+	logger := mocklogger.NewTestLogger()
+	ctx := context.Background()
+
+	blockHeader := &model.BlockHeader{
+		Version:        1,
+		HashPrevBlock:  &chainhash.Hash{},
+		HashMerkleRoot: &chainhash.Hash{},
+		Timestamp:      1231006505,
+		Bits:           model.NBit{0xff, 0xff, 0x00, 0x1d},
+		Nonce:          2083236893,
+	}
+
+	blockchainClient := &mockBlockchainClient{
+		getBlockHeadersByHeightFunc: func(ctx context.Context, startHeight, endHeight uint32) ([]*model.BlockHeader, []*model.BlockHeaderMeta, error) {
+			return []*model.BlockHeader{blockHeader}, []*model.BlockHeaderMeta{{Height: startHeight}}, nil
+		},
+	}
+
+	newServer := func(blockStore *memory.Memory) *RPCServer {
+		s := &RPCServer{
+			logger:           logger,
+			blockchainClient: blockchainClient,
+			settings: &settings.Settings{
+				ChainCfgParams: &chaincfg.MainNetParams,
+			},
+		}
+
+		if blockStore != nil {
+			s.blockStore = blockStore
+		}
+
+		return s
+	}
+
+	assertRPCError := func(t *testing.T, err error, code bsvjson.RPCErrorCode) {
+		require.Error(t, err)
+
+		rpcErr, ok := err.(*bsvjson.RPCError)
+		require.True(t, ok)
+		assert.Equal(t, code, rpcErr.Code)
+	}
+
+	t.Run("block store not configured", func(t *testing.T) {
+		_, err := handleGetTxOutSetInfo(ctx, newServer(nil), nil, nil)
+		assertRPCError(t, err, bsvjson.ErrRPCInternal.Code)
+	})
+
+	t.Run("no block processed", func(t *testing.T) {
+		_, err := handleGetTxOutSetInfo(ctx, newServer(memory.New()), nil, nil)
+		assertRPCError(t, err, bsvjson.ErrRPCNoTxInfo)
+	})
+
+	t.Run("no commitment written", func(t *testing.T) {
+		blockStore := memory.New()
+		require.NoError(t, blockStore.Set(ctx, nil, fileformat.FileTypeDat, []byte("10"), bloboptions.WithFilename("lastProcessed")))
+
+		_, err := handleGetTxOutSetInfo(ctx, newServer(blockStore), nil, nil)
+		assertRPCError(t, err, bsvjson.ErrRPCNoTxInfo)
+	})
+
+	t.Run("commitment", func(t *testing.T) {
+		blockStore := memory.New()
+		require.NoError(t, blockStore.Set(ctx, nil, fileformat.FileTypeDat, []byte("10"), bloboptions.WithFilename("lastProcessed")))
+
+		commitment := utxopersister.NewUTXOCommitment()
+		commitment.BlockHash = *blockHeader.Hash()
+		commitment.BlockHeight = 10
+		commitment.TxCount = 1
+		commitment.UTXOCount = 2
+		commitment.AddUTXOs(&utxopersister.UTXOWrapper{TxID: chainhash.HashH([]byte("tx"))}, []*utxopersister.UTXO{
+			{Index: 0, Value: 150_000_000, Script: []byte{0x51}},
+			{Index: 1, Value: 50_000_000, Script: []byte{0x52}},
+		})
+
+		require.NoError(t, utxopersister.WriteUTXOCommitment(ctx, blockStore, commitment))
+
+		result, err := handleGetTxOutSetInfo(ctx, newServer(blockStore), nil, nil)
+		require.NoError(t, err)
+
+		info, ok := result.(*bsvjson.GetTxOutSetInfoResult)
+		require.True(t, ok)
+
+		muHash := commitment.MuHash()
+
+		assert.Equal(t, int64(10), info.Height)
+		assert.Equal(t, blockHeader.Hash().String(), info.BestBlock)
+		assert.Equal(t, uint64(1), info.Transactions)
+		assert.Equal(t, uint64(2), info.TxOuts)
+		assert.InDelta(t, 2.0, info.TotalAmount, 1e-9)
+		assert.Equal(t, muHash.String(), info.MuHash)
+	})
+}
+
+func TestHandleSearchRawTransactionsComprehensive(t *testing.T) {
+	logger := mocklogger.NewTestLogger()
+	ctx := context.Background()
+
+	const address = "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"
+
+	tx := bt.NewTx()
+	require.NoError(t, tx.From("3c8edde27cb9a9132c22038dac4391496be9db16fd21351565cc1006966fdad5", 0, "76a914eb0bd5edba389198e73f8efabddfc61666969ff788ac", 2_000_000))
+	require.NoError(t, tx.PayToAddress(address, 1_000_000))
+	require.NoError(t, tx.PayToAddress("1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", 900_000))
+
+	txHash := tx.TxIDChainHash()
+	blockHeader := &model.BlockHeader{
+		Version:        1,
+		HashPrevBlock:  &chainhash.Hash{},
+		HashMerkleRoot: &chainhash.Hash{},
+		Timestamp:      1_700_000_000,
+	}
+
+	addr, err := bsvutil.DecodeAddress(address, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+
+	lockingScript, err := txscript.PayToAddrScript(addr)
+	require.NoError(t, err)
+
+	scriptHash := scripthash.NewScriptHash(lockingScript)
+
+	newServer := func(store scripthash.Store, utxoStore utxo.Store) *RPCServer {
+		return &RPCServer{
+			logger:          logger,
+			utxoStore:       utxoStore,
+			scriptHashStore: store,
+			settings: &settings.Settings{
+				ChainCfgParams:  &chaincfg.MainNetParams,
+				ScriptHashIndex: settings.ScriptHashIndexSettings{Enabled: store != nil},
+			},
+			blockchainClient: &mockBlockchainClient{
+				getBestBlockHeaderFunc: func(ctx context.Context) (*model.BlockHeader, *model.BlockHeaderMeta, error) {
+					return blockHeader, &model.BlockHeaderMeta{Height: 110}, nil
+				},
+				getBlockHeadersByHeightFunc: func(ctx context.Context, startHeight, endHeight uint32) ([]*model.BlockHeader, []*model.BlockHeaderMeta, error) {
+					return []*model.BlockHeader{blockHeader}, []*model.BlockHeaderMeta{{Height: startHeight}}, nil
+				},
+			},
+		}
+	}
+
+	assertRPCError := func(t *testing.T, err error, code bsvjson.RPCErrorCode) {
+		require.Error(t, err)
+
+		rpcErr, ok := err.(*bsvjson.RPCError)
+		require.True(t, ok)
+		assert.Equal(t, code, rpcErr.Code)
+	}
+
+	t.Run("script hash index disabled", func(t *testing.T) {
+		_, err := handleSearchRawTransactions(ctx, newServer(nil, nil), &bsvjson.SearchRawTransactionsCmd{Address: address}, nil)
+		assertRPCError(t, err, bsvjson.ErrRPCMisc)
+	})
+
+	t.Run("invalid address", func(t *testing.T) {
+		_, err := handleSearchRawTransactions(ctx, newServer(&scripthash.Mock{}, nil), &bsvjson.SearchRawTransactionsCmd{Address: "invalid"}, nil)
+		assertRPCError(t, err, bsvjson.ErrRPCInvalidAddressOrKey)
+	})
+
+	t.Run("no transactions", func(t *testing.T) {
+		store := &scripthash.Mock{}
+		store.On("GetHistory", mock.Anything, &scriptHash, 0, 100, false).Return([]*scripthash.HistoryItem{}, nil)
+
+		_, err := handleSearchRawTransactions(ctx, newServer(store, nil), &bsvjson.SearchRawTransactionsCmd{Address: address}, nil)
+		assertRPCError(t, err, bsvjson.ErrRPCNoTxInfo)
+	})
+
+	t.Run("hex transactions", func(t *testing.T) {
+		verbose := 0
+		skip := 2
+		count := 5
+		reverse := true
+
+		store := &scripthash.Mock{}
+		store.On("GetHistory", mock.Anything, &scriptHash, 2, 5, true).Return([]*scripthash.HistoryItem{{TxID: *txHash, Height: 101, TxPos: 1}}, nil)
+
+		utxoStore := &utxo.MockUtxostore{}
+		utxoStore.On("Get", mock.Anything, txHash, mock.Anything).Return(&meta.Data{Tx: tx}, nil)
+
+		result, err := handleSearchRawTransactions(ctx, newServer(store, utxoStore), &bsvjson.SearchRawTransactionsCmd{
+			Address: address,
+			Verbose: &verbose,
+			Skip:    &skip,
+			Count:   &count,
+			Reverse: &reverse,
+		}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{hex.EncodeToString(tx.Bytes())}, result)
+
+		store.AssertExpectations(t)
+	})
+
+	t.Run("transaction not in utxo store", func(t *testing.T) {
+		store := &scripthash.Mock{}
+		store.On("GetHistory", mock.Anything, &scriptHash, 0, 100, false).Return([]*scripthash.HistoryItem{{TxID: *txHash, Height: 101, TxPos: 1}}, nil)
+
+		utxoStore := &utxo.MockUtxostore{}
+		utxoStore.On("Get", mock.Anything, txHash, mock.Anything).Return(nil, errors.NewTxNotFoundError("not found"))
+
+		_, err := handleSearchRawTransactions(ctx, newServer(store, utxoStore), &bsvjson.SearchRawTransactionsCmd{Address: address}, nil)
+		assertRPCError(t, err, bsvjson.ErrRPCNoTxInfo)
+	})
+
+	t.Run("verbose transactions", func(t *testing.T) {
+		vinExtra := 1
+		filterAddrs := []string{address}
+
+		store := &scripthash.Mock{}
+		store.On("GetHistory", mock.Anything, &scriptHash, 0, 100, false).Return([]*scripthash.HistoryItem{{TxID: *txHash, Height: 101, TxPos: 1}}, nil)
+
+		utxoStore := &utxo.MockUtxostore{}
+		utxoStore.On("Get", mock.Anything, txHash, mock.Anything).Return(&meta.Data{Tx: tx}, nil)
+
+		result, err := handleSearchRawTransactions(ctx, newServer(store, utxoStore), &bsvjson.SearchRawTransactionsCmd{
+			Address:     address,
+			VinExtra:    &vinExtra,
+			FilterAddrs: &filterAddrs,
+		}, nil)
+		require.NoError(t, err)
+
+		results, ok := result.([]bsvjson.SearchRawTransactionsResult)
+		require.True(t, ok)
+		require.Len(t, results, 1)
+
+		assert.Equal(t, txHash.String(), results[0].Txid)
+		assert.Equal(t, blockHeader.Hash().String(), results[0].BlockHash)
+		assert.Equal(t, uint64(10), results[0].Confirmations)
+		assert.Equal(t, int64(1_700_000_000), results[0].Blocktime)
+
+		// the input spends from another address and only the first output pays to the filtered address
+		assert.Empty(t, results[0].Vin)
+		require.Len(t, results[0].Vout, 1)
+		assert.Equal(t, uint32(0), results[0].Vout[0].N)
+		assert.InDelta(t, 0.01, results[0].Vout[0].Value, 0.000000001)
+	})
+}
+
+func TestHandleEstimateFeeComprehensive(t *testing.T) {
+	logger := mocklogger.NewTestLogger()
+	ctx := context.Background()
+
+	// 20 transactions paying 500 satoshis per kB, mined 2 blocks after they were accepted
+	estimator := feeestimator.New(1000, 1)
+	hashes := make([]*chainhash.Hash, 20)
+
+	for i := range hashes {
+		hashes[i] = &chainhash.Hash{byte(i + 1)}
+		estimator.AddTransaction(*hashes[i], 500, 1000, 11)
+	}
+
+	estimator.ProcessMined(hashes, 12)
+
+	newServer := func(feeEstimator *feeestimator.Estimator) *RPCServer {
+		return &RPCServer{
+			logger:       logger,
+			settings:     &settings.Settings{ChainCfgParams: &chaincfg.MainNetParams},
+			feeEstimator: feeEstimator,
+		}
+	}
+
+	t.Run("fee estimation disabled", func(t *testing.T) {
+		result, err := handleEstimateFee(ctx, newServer(nil), &bsvjson.EstimateFeeCmd{NumBlocks: 2}, nil)
+		require.NoError(t, err)
+		assert.InDelta(t, -1, result, 0)
+	})
+
+	t.Run("insufficient data", func(t *testing.T) {
+		result, err := handleEstimateFee(ctx, newServer(estimator), &bsvjson.EstimateFeeCmd{NumBlocks: 1}, nil)
+		require.NoError(t, err)
+		assert.InDelta(t, -1, result, 0)
+	})
+
+	t.Run("estimate", func(t *testing.T) {
+		result, err := handleEstimateFee(ctx, newServer(estimator), &bsvjson.EstimateFeeCmd{NumBlocks: 2}, nil)
+		require.NoError(t, err)
+		assert.InDelta(t, 0.000005, result, 1e-12)
+	})
+
+	t.Run("number of blocks out of range", func(t *testing.T) {
+		result, err := handleEstimateFee(ctx, newServer(estimator), &bsvjson.EstimateFeeCmd{NumBlocks: 1000}, nil)
+		require.NoError(t, err)
+		assert.InDelta(t, 0.000005, result, 1e-12)
+
+		result, err = handleEstimateFee(ctx, newServer(estimator), &bsvjson.EstimateFeeCmd{NumBlocks: 0}, nil)
+		require.NoError(t, err)
+		assert.InDelta(t, -1, result, 0)
+	})
+}
+
+func TestHandleEstimateSmartFeeComprehensive(t *testing.T) {
+	logger := mocklogger.NewTestLogger()
+	ctx := context.Background()
+
+	// 20 transactions paying 500 satoshis per kB, mined 2 blocks after they were accepted
+	estimator := feeestimator.New(1000, 1)
+	hashes := make([]*chainhash.Hash, 20)
+
+	for i := range hashes {
+		hashes[i] = &chainhash.Hash{byte(i + 1)}
+		estimator.AddTransaction(*hashes[i], 500, 1000, 11)
+	}
+
+	estimator.ProcessMined(hashes, 12)
+
+	newServer := func(feeEstimator *feeestimator.Estimator) *RPCServer {
+		return &RPCServer{
+			logger:       logger,
+			settings:     &settings.Settings{ChainCfgParams: &chaincfg.MainNetParams},
+			feeEstimator: feeEstimator,
+		}
+	}
+
+	mode := func(m bsvjson.EstimateSmartFeeMode) *bsvjson.EstimateSmartFeeMode {
+		return &m
+	}
+
+	t.Run("fee estimation disabled", func(t *testing.T) {
+		result, err := handleEstimateSmartFee(ctx, newServer(nil), &bsvjson.EstimateSmartFeeCmd{ConfTarget: 2}, nil)
+		require.NoError(t, err)
+
+		smartFee := result.(*bsvjson.EstimateSmartFeeResult)
+		assert.Nil(t, smartFee.FeeRate)
+		assert.Equal(t, []string{"Fee estimation is not enabled"}, smartFee.Errors)
+	})
+
+	t.Run("invalid target", func(t *testing.T) {
+		for _, target := range []int64{0, feeestimator.MaxTarget + 1} {
+			_, err := handleEstimateSmartFee(ctx, newServer(estimator), &bsvjson.EstimateSmartFeeCmd{ConfTarget: target}, nil)
+			require.Error(t, err)
+
+			rpcErr, ok := err.(*bsvjson.RPCError)
+			require.True(t, ok)
+			assert.Equal(t, bsvjson.ErrRPCInvalidParameter, rpcErr.Code)
+		}
+	})
+
+	t.Run("invalid mode", func(t *testing.T) {
+		_, err := handleEstimateSmartFee(ctx, newServer(estimator), &bsvjson.EstimateSmartFeeCmd{ConfTarget: 2, EstimateMode: mode("FAST")}, nil)
+		require.Error(t, err)
+
+		rpcErr, ok := err.(*bsvjson.RPCError)
+		require.True(t, ok)
+		assert.Equal(t, bsvjson.ErrRPCInvalidParameter, rpcErr.Code)
+	})
+
+	t.Run("higher target", func(t *testing.T) {
+		result, err := handleEstimateSmartFee(ctx, newServer(estimator), &bsvjson.EstimateSmartFeeCmd{ConfTarget: 1, EstimateMode: mode("economical")}, nil)
+		require.NoError(t, err)
+
+		smartFee := result.(*bsvjson.EstimateSmartFeeResult)
+		require.NotNil(t, smartFee.FeeRate)
+		assert.InDelta(t, 0.000005, *smartFee.FeeRate, 1e-12)
+		assert.Equal(t, int64(2), smartFee.Blocks)
+		assert.Empty(t, smartFee.Errors)
+	})
+
+	t.Run("insufficient data", func(t *testing.T) {
+		result, err := handleEstimateSmartFee(ctx, newServer(feeestimator.New(1000, 1)), &bsvjson.EstimateSmartFeeCmd{ConfTarget: 2}, nil)
+		require.NoError(t, err)
+
+		smartFee := result.(*bsvjson.EstimateSmartFeeResult)
+		assert.Nil(t, smartFee.FeeRate)
+		assert.Equal(t, []string{"Insufficient data or no feerate found"}, smartFee.Errors)
+		assert.Equal(t, int64(feeestimator.MaxTarget), smartFee.Blocks)
+	})
+}
+
+// TestHandleDecodeRawTransactionComprehensive tests the handleDecodeRawTransaction handler
+func TestHandleDecodeRawTransactionComprehensive(t *testing.T) {
+	ctx := context.Background()
+	s := &RPCServer{
+		logger: mocklogger.NewTestLogger(),
+		settings: &settings.Settings{
+			ChainCfgParams: &chaincfg.MainNetParams,
+		},
+	}
+
+	assertRPCError := func(t *testing.T, err error, code bsvjson.RPCErrorCode) {
+		require.Error(t, err)
+
+		rpcErr, ok := err.(*bsvjson.RPCError)
+		require.True(t, ok)
+		assert.Equal(t, code, rpcErr.Code)
+	}
+
+	t.Run("transaction", func(t *testing.T) {
+		tx := bt.NewTx()
+		require.NoError(t, tx.From("3c8edde27cb9a9132c22038dac4391496be9db16fd21351565cc1006966fdad5", 1, "76a914eb0bd5edba389198e73f8efabddfc61666969ff788ac", 2_000_000))
+		require.NoError(t, tx.PayToAddress("1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", 1_000_000))
+		tx.LockTime = 500
+
+		result, err := handleDecodeRawTransaction(ctx, s, &bsvjson.DecodeRawTransactionCmd{HexTx: hex.EncodeToString(tx.Bytes())}, nil)
+		require.NoError(t, err)
+
+		decoded, ok := result.(bsvjson.TxRawDecodeResult)
+		require.True(t, ok)
+		assert.Equal(t, tx.TxID(), decoded.Txid)
+		assert.Equal(t, int32(1), decoded.Version)
+		assert.Equal(t, uint32(500), decoded.Locktime)
+
+		require.Len(t, decoded.Vin, 1)
+		assert.Equal(t, "3c8edde27cb9a9132c22038dac4391496be9db16fd21351565cc1006966fdad5", decoded.Vin[0].Txid)
+		assert.Equal(t, uint32(1), decoded.Vin[0].Vout)
+		assert.Empty(t, decoded.Vin[0].Coinbase)
+		require.NotNil(t, decoded.Vin[0].ScriptSig)
+
+		require.Len(t, decoded.Vout, 1)
+		assert.InDelta(t, 0.01, decoded.Vout[0].Value, 0.000000001)
+		assert.Equal(t, "pubkeyhash", decoded.Vout[0].ScriptPubKey.Type)
+		assert.Equal(t, int32(1), decoded.Vout[0].ScriptPubKey.ReqSigs)
+		assert.Equal(t, "76a91462e907b15cbf27d5425399ebf6f0fb50ebb88f1888ac", decoded.Vout[0].ScriptPubKey.Hex)
+		assert.Len(t, decoded.Vout[0].ScriptPubKey.Addresses, 1)
+	})
+
+	t.Run("coinbase transaction", func(t *testing.T) {
+		// the coinbase transaction of the genesis block
+		genesisCoinbase := "01000000010000000000000000000000000000000000000000000000000000000000000000ffffffff4d04ffff001d0104455468652054696d65732030332f4a616e2f32303039204368616e63656c6c6f72206f6e206272696e6b206f66207365636f6e64206261696c6f757420666f722062616e6b73ffffffff0100f2052a01000000434104678afdb0fe5548271967f1a67130b7105cd6a828e03909a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7ba0b8d578a4c702b6bf11d5fac00000000"
+
+		result, err := handleDecodeRawTransaction(ctx, s, &bsvjson.DecodeRawTransactionCmd{HexTx: genesisCoinbase}, nil)
+		require.NoError(t, err)
+
+		decoded, ok := result.(bsvjson.TxRawDecodeResult)
+		require.True(t, ok)
+		assert.Equal(t, "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b", decoded.Txid)
+
+		require.Len(t, decoded.Vin, 1)
+		assert.True(t, decoded.Vin[0].IsCoinBase())
+		assert.Equal(t, uint32(0xffffffff), decoded.Vin[0].Sequence)
+
+		require.Len(t, decoded.Vout, 1)
+		assert.InDelta(t, 50, decoded.Vout[0].Value, 0.000000001)
+		assert.Equal(t, "pubkey", decoded.Vout[0].ScriptPubKey.Type)
+	})
+
+	t.Run("invalid hex", func(t *testing.T) {
+		_, err := handleDecodeRawTransaction(ctx, s, &bsvjson.DecodeRawTransactionCmd{HexTx: "zz"}, nil)
+		assertRPCError(t, err, bsvjson.ErrRPCDecodeHexString)
+	})
+
+	t.Run("invalid transaction", func(t *testing.T) {
+		_, err := handleDecodeRawTransaction(ctx, s, &bsvjson.DecodeRawTransactionCmd{HexTx: "0100"}, nil)
+		assertRPCError(t, err, bsvjson.ErrRPCDeserialization)
+	})
+}
+
+// TestHandleDecodeScriptComprehensive tests the handleDecodeScript handler
+func TestHandleDecodeScriptComprehensive(t *testing.T) {
+	ctx := context.Background()
+	s := &RPCServer{
+		logger: mocklogger.NewTestLogger(),
+		settings: &settings.Settings{
+			ChainCfgParams: &chaincfg.MainNetParams,
+		},
+	}
+
+	t.Run("pay-to-pubkey-hash script", func(t *testing.T) {
+		result, err := handleDecodeScript(ctx, s, &bsvjson.DecodeScriptCmd{HexScript: "76a91462e907b15cbf27d5425399ebf6f0fb50ebb88f1888ac"}, nil)
+		require.NoError(t, err)
+
+		decoded, ok := result.(bsvjson.DecodeScriptResult)
+		require.True(t, ok)
+		assert.Equal(t, "OP_DUP OP_HASH160 62e907b15cbf27d5425399ebf6f0fb50ebb88f18 OP_EQUALVERIFY OP_CHECKSIG", decoded.Asm)
+		assert.Equal(t, "pubkeyhash", decoded.Type)
+		assert.Equal(t, int32(1), decoded.ReqSigs)
+		assert.Len(t, decoded.Addresses, 1)
+		assert.NotEmpty(t, decoded.P2sh)
+	})
+
+	t.Run("pay-to-script-hash script", func(t *testing.T) {
+		result, err := handleDecodeScript(ctx, s, &bsvjson.DecodeScriptCmd{HexScript: "a91462e907b15cbf27d5425399ebf6f0fb50ebb88f1887"}, nil)
+		require.NoError(t, err)
+
+		decoded, ok := result.(bsvjson.DecodeScriptResult)
+		require.True(t, ok)
+		assert.Equal(t, "scripthash", decoded.Type)
+		assert.Empty(t, decoded.P2sh)
+	})
+
+	t.Run("unparsable script", func(t *testing.T) {
+		// odd length hex is padded, 0x01 pushes one byte that is missing
+		result, err := handleDecodeScript(ctx, s, &bsvjson.DecodeScriptCmd{HexScript: "1"}, nil)
+		require.NoError(t, err)
+
+		decoded, ok := result.(bsvjson.DecodeScriptResult)
+		require.True(t, ok)
+		assert.Equal(t, "[error]", decoded.Asm)
+		assert.Equal(t, "nonstandard", decoded.Type)
+	})
+
+	t.Run("invalid hex", func(t *testing.T) {
+		_, err := handleDecodeScript(ctx, s, &bsvjson.DecodeScriptCmd{HexScript: "zz"}, nil)
+		require.Error(t, err)
+
+		rpcErr, ok := err.(*bsvjson.RPCError)
+		require.True(t, ok)
+		assert.Equal(t, bsvjson.ErrRPCDecodeHexString, rpcErr.Code)
+	})
+}
+
+// TestHandleValidateAddressComprehensive tests the handleValidateAddress handler
+func TestHandleValidateAddressComprehensive(t *testing.T) {
+	ctx := context.Background()
+
+	newServer := func(params *chaincfg.Params) *RPCServer {
+		return &RPCServer{
+			logger: mocklogger.NewTestLogger(),
+			settings: &settings.Settings{
+				ChainCfgParams: params,
+			},
+		}
+	}
+
+	t.Run("pay-to-pubkey-hash address", func(t *testing.T) {
+		result, err := handleValidateAddress(ctx, newServer(&chaincfg.MainNetParams), &bsvjson.ValidateAddressCmd{Address: "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"}, nil)
+		require.NoError(t, err)
+
+		validated, ok := result.(bsvjson.ValidateAddressChainResult)
+		require.True(t, ok)
+		assert.True(t, validated.IsValid)
+		assert.Equal(t, "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", validated.Address)
+		assert.Equal(t, "76a91462e907b15cbf27d5425399ebf6f0fb50ebb88f1888ac", validated.ScriptPubKey)
+		require.NotNil(t, validated.IsScript)
+		assert.False(t, *validated.IsScript)
+	})
+
+	t.Run("pay-to-script-hash address", func(t *testing.T) {
+		result, err := handleValidateAddress(ctx, newServer(&chaincfg.MainNetParams), &bsvjson.ValidateAddressCmd{Address: "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy"}, nil)
+		require.NoError(t, err)
+
+		validated, ok := result.(bsvjson.ValidateAddressChainResult)
+		require.True(t, ok)
+		assert.True(t, validated.IsValid)
+		require.NotNil(t, validated.IsScript)
+		assert.True(t, *validated.IsScript)
+	})
+
+	t.Run("address for another network", func(t *testing.T) {
+		result, err := handleValidateAddress(ctx, newServer(&chaincfg.TestNetParams), &bsvjson.ValidateAddressCmd{Address: "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, bsvjson.ValidateAddressChainResult{IsValid: false}, result)
+	})
+
+	t.Run("invalid address", func(t *testing.T) {
+		result, err := handleValidateAddress(ctx, newServer(&chaincfg.MainNetParams), &bsvjson.ValidateAddressCmd{Address: "invalid"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, bsvjson.ValidateAddressChainResult{IsValid: false}, result)
+	})
+}
+
+// TestHandleVerifyMessageComprehensive tests the handleVerifyMessage handler
+func TestHandleVerifyMessageComprehensive(t *testing.T) {
+	ctx := context.Background()
+	s := &RPCServer{
+		logger: mocklogger.NewTestLogger(),
+		settings: &settings.Settings{
+			ChainCfgParams: &chaincfg.MainNetParams,
+		},
+	}
+
+	privKey, pubKey := bsvec.PrivKeyFromBytes(bsvec.S256(), bytes.Repeat([]byte{0x01}, 32))
+
+	signMessage := func(t *testing.T, message string, compressed bool) string {
+		var buf bytes.Buffer
+
+		require.NoError(t, wire.WriteVarString(&buf, 0, signedMessageMagic))
+		require.NoError(t, wire.WriteVarString(&buf, 0, message))
+
+		sig, err := bsvec.SignCompact(bsvec.S256(), privKey, chainhash.DoubleHashB(buf.Bytes()), compressed)
+		require.NoError(t, err)
+
+		return base64.StdEncoding.EncodeToString(sig)
+	}
+
+	addr, err := bsvutil.NewLegacyAddressPubKeyHash(bsvutil.Hash160(pubKey.SerializeCompressed()), &chaincfg.MainNetParams)
+	require.NoError(t, err)
+
+	uncompressedAddr, err := bsvutil.NewLegacyAddressPubKeyHash(bsvutil.Hash160(pubKey.SerializeUncompressed()), &chaincfg.MainNetParams)
+	require.NoError(t, err)
+
+	verify := func(address, signature, message string) (interface{}, error) {
+		return handleVerifyMessage(ctx, s, &bsvjson.VerifyMessageCmd{Address: address, Signature: signature, Message: message}, nil)
+	}
+
+	assertRPCError := func(t *testing.T, err error, code bsvjson.RPCErrorCode) {
+		require.Error(t, err)
+
+		rpcErr, ok := err.(*bsvjson.RPCError)
+		require.True(t, ok)
+		assert.Equal(t, code, rpcErr.Code)
+	}
+
+	t.Run("valid signature", func(t *testing.T) {
+		result, err := verify(addr.EncodeAddress(), signMessage(t, "hello", true), "hello")
+		require.NoError(t, err)
+		assert.Equal(t, true, result)
+	})
+
+	t.Run("valid signature with uncompressed key", func(t *testing.T) {
+		result, err := verify(uncompressedAddr.EncodeAddress(), signMessage(t, "hello", false), "hello")
+		require.NoError(t, err)
+		assert.Equal(t, true, result)
+
+		// the signature commits to the uncompressed key, so it does not verify for the compressed key address
+		result, err = verify(addr.EncodeAddress(), signMessage(t, "hello", false), "hello")
+		require.NoError(t, err)
+		assert.Equal(t, false, result)
+	})
+
+	t.Run("different message", func(t *testing.T) {
+		result, err := verify(addr.EncodeAddress(), signMessage(t, "hello", true), "goodbye")
+		require.NoError(t, err)
+		assert.Equal(t, false, result)
+	})
+
+	t.Run("unrecoverable signature", func(t *testing.T) {
+		result, err := verify(addr.EncodeAddress(), base64.StdEncoding.EncodeToString([]byte("signature")), "hello")
+		require.NoError(t, err)
+		assert.Equal(t, false, result)
+	})
+
+	t.Run("malformed signature", func(t *testing.T) {
+		_, err := verify(addr.EncodeAddress(), "not base64!", "hello")
+		assertRPCError(t, err, bsvjson.ErrRPCParse.Code)
+	})
+
+	t.Run("pay-to-script-hash address", func(t *testing.T) {
+		_, err := verify("3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", signMessage(t, "hello", true), "hello")
+		assertRPCError(t, err, bsvjson.ErrRPCType)
+	})
+
+	t.Run("invalid address", func(t *testing.T) {
+		_, err := verify("invalid", signMessage(t, "hello", true), "hello")
+		assertRPCError(t, err, bsvjson.ErrRPCInvalidAddressOrKey)
+	})
+}
+
+// TestHandleVerifyChainComprehensive tests the handleVerifyChain handler
+func TestHandleVerifyChainComprehensive(t *testing.T) {
+	ctx := context.Background()
+
+	genesis, err := model.NewBlockFromMsgBlock(chaincfg.RegressionNetParams.GenesisBlock, nil)
+	require.NoError(t, err)
+
+	// block 1 does not meet its proof of work target
+	block1 := &model.Block{
+		Header: &model.BlockHeader{
+			Version:        1,
+			HashPrevBlock:  genesis.Hash(),
+			HashMerkleRoot: genesis.CoinbaseTx.TxIDChainHash(),
+			Timestamp:      genesis.Header.Timestamp + 600,
+			Bits:           model.NBit{0xff, 0xff, 0x00, 0x1d},
+		},
+		CoinbaseTx: genesis.CoinbaseTx,
+		Height:     1,
+	}
+
+	newServer := func(blocks ...*model.Block) *RPCServer {
+		tip := blocks[len(blocks)-1]
+
+		return &RPCServer{
+			logger: mocklogger.NewTestLogger(),
+			settings: &settings.Settings{
+				ChainCfgParams: &chaincfg.RegressionNetParams,
+			},
+			blockchainClient: &mockBlockchainClient{
+				getBestBlockHeaderFunc: func(ctx context.Context) (*model.BlockHeader, *model.BlockHeaderMeta, error) {
+					return tip.Header, &model.BlockHeaderMeta{Height: tip.Height}, nil
+				},
+				getBlockByHeightFunc: func(ctx context.Context, height uint32) (*model.Block, error) {
+					return blocks[height], nil
+				},
+			},
+			subtreeStore: memory.New(),
+		}
+	}
+
+	assertRPCError := func(t *testing.T, err error, code bsvjson.RPCErrorCode) {
+		require.Error(t, err)
+
+		rpcErr, ok := err.(*bsvjson.RPCError)
+		require.True(t, ok)
+		assert.Equal(t, code, rpcErr.Code)
+	}
+
+	t.Run("valid chain", func(t *testing.T) {
+		result, err := handleVerifyChain(ctx, newServer(genesis), &bsvjson.VerifyChainCmd{}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, true, result)
+	})
+
+	t.Run("invalid block", func(t *testing.T) {
+		result, err := handleVerifyChain(ctx, newServer(genesis, block1), &bsvjson.VerifyChainCmd{CheckLevel: bsvjson.Int32(0), CheckDepth: bsvjson.Int32(0)}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, false, result)
+	})
+
+	t.Run("depth of one block", func(t *testing.T) {
+		// the depth counts from the tip, so the invalid tip is verified
+		result, err := handleVerifyChain(ctx, newServer(genesis, block1), &bsvjson.VerifyChainCmd{CheckDepth: bsvjson.Int32(1)}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, false, result)
+	})
+
+	t.Run("invalid check level", func(t *testing.T) {
+		_, err := handleVerifyChain(ctx, newServer(genesis), &bsvjson.VerifyChainCmd{CheckLevel: bsvjson.Int32(5)}, nil)
+		assertRPCError(t, err, bsvjson.ErrRPCInvalidParameter)
+	})
+
+	t.Run("negative check depth", func(t *testing.T) {
+		_, err := handleVerifyChain(ctx, newServer(genesis), &bsvjson.VerifyChainCmd{CheckDepth: bsvjson.Int32(-1)}, nil)
+		assertRPCError(t, err, bsvjson.ErrRPCInvalidParameter)
+	})
+
+	t.Run("UTXO store required for level 4", func(t *testing.T) {
+		_, err := handleVerifyChain(ctx, newServer(genesis), &bsvjson.VerifyChainCmd{CheckLevel: bsvjson.Int32(4)}, nil)
+		assertRPCError(t, err, bsvjson.ErrRPCInternal.Code)
+	})
+}
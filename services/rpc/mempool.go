@@ -0,0 +1,347 @@
+package rpc
+
+import (
+	"context"
+	"slices"
+
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	subtreepkg "github.com/bsv-blockchain/go-subtree"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/services/rpc/bsvjson"
+	"github.com/bsv-blockchain/teranode/stores/utxo"
+	"github.com/bsv-blockchain/teranode/stores/utxo/fields"
+)
+
+// mempoolBatchSize is the number of transactions the metadata is fetched for in one call to the UTXO store
+const mempoolBatchSize = 1024
+
+// mempoolEntry is an unmined transaction in block assembly, with its metadata from the UTXO store
+type mempoolEntry struct {
+	hash chainhash.Hash
+
+	// fee is the fee of the transaction in satoshis
+	fee uint64
+
+	// size is the size of the transaction in bytes
+	size uint64
+
+	// height is the height of the chain tip when the transaction was accepted
+	height uint32
+
+	// time is the timestamp of the chain tip when the transaction was accepted, 0 when unknown
+	time int64
+
+	// parents are the unmined transactions in block assembly this transaction spends outputs of
+	parents []chainhash.Hash
+
+	// children are the unmined transactions in block assembly spending outputs of this transaction
+	children []chainhash.Hash
+}
+
+// mempool is a snapshot of the unmined transactions in block assembly. Block assembly has no mempool
+// of its own, the transactions it holds are the transactions waiting to be mined.
+type mempool struct {
+	// hashes holds the transaction hashes in block assembly order
+	hashes []chainhash.Hash
+
+	// entries holds the transactions by hash
+	entries map[chainhash.Hash]*mempoolEntry
+
+	// bytes is the total size of the transactions in bytes
+	bytes uint64
+
+	// fees is the total fee of the transactions in satoshis
+	fees uint64
+}
+
+// loadMempool takes a snapshot of the transactions in block assembly and fetches the fee, size, parents
+// and the height the transaction was accepted at from the UTXO store. Transactions that were mined or
+// removed from the UTXO store while the snapshot was taken are left out.
+func (s *RPCServer) loadMempool(ctx context.Context) (*mempool, error) {
+	txHashes, err := s.blockAssemblyClient.GetTransactionHashes(ctx)
+	if err != nil {
+		return nil, errors.NewServiceError("error retrieving transactions from block assembly", err)
+	}
+
+	hashes := make([]chainhash.Hash, 0, len(txHashes))
+
+	for _, txHashStr := range txHashes {
+		txHash, err := chainhash.NewHashFromStr(txHashStr)
+		if err != nil {
+			return nil, errors.NewProcessingError("invalid transaction hash %s from block assembly", txHashStr, err)
+		}
+
+		if txHash.Equal(subtreepkg.CoinbasePlaceholderHashValue) {
+			continue
+		}
+
+		hashes = append(hashes, *txHash)
+	}
+
+	m, err := s.decorateMempool(ctx, hashes)
+	if err != nil {
+		return nil, err
+	}
+
+	// only keep the parents that are in the mempool, and link the children
+	for _, entry := range m.entries {
+		parents := make([]chainhash.Hash, 0, len(entry.parents))
+
+		for _, parentHash := range entry.parents {
+			parent, ok := m.entries[parentHash]
+			if !ok || slices.Contains(parents, parentHash) {
+				continue
+			}
+
+			parents = append(parents, parentHash)
+			parent.children = append(parent.children, entry.hash)
+		}
+
+		entry.parents = parents
+	}
+
+	if err = s.setMempoolTimes(ctx, m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// loadMempoolRelatives loads the transactions in block assembly with their in-mempool ancestors and
+// descendants, without a snapshot of block assembly. The relatives are walked by block assembly through
+// the in-memory transaction map of the subtree processor, and only these transactions are fetched from
+// the UTXO store. Transactions that are not in block assembly are left out.
+func (s *RPCServer) loadMempoolRelatives(ctx context.Context, hashes []chainhash.Hash) (*mempool, error) {
+	relatives, err := s.blockAssemblyClient.GetTxRelatives(ctx, hashes)
+	if err != nil {
+		return nil, errors.NewServiceError("error retrieving transactions from block assembly", err)
+	}
+
+	relativeHashes := make([]chainhash.Hash, 0, len(relatives))
+
+	for _, relative := range relatives {
+		relativeHashes = append(relativeHashes, relative.Hash)
+	}
+
+	m, err := s.decorateMempool(ctx, relativeHashes)
+	if err != nil {
+		return nil, err
+	}
+
+	// the links are taken from block assembly, leaving out the transactions mined in the meantime
+	inMempool := func(hashes []chainhash.Hash) []chainhash.Hash {
+		return slices.DeleteFunc(slices.Clone(hashes), func(hash chainhash.Hash) bool {
+			_, ok := m.entries[hash]
+			return !ok
+		})
+	}
+
+	for _, relative := range relatives {
+		if entry, ok := m.entries[relative.Hash]; ok {
+			entry.parents = inMempool(relative.Parents)
+			entry.children = inMempool(relative.Children)
+		}
+	}
+
+	if err = s.setMempoolTimes(ctx, m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// decorateMempool fetches the fee, size, parents and the height the transaction was accepted at from
+// the UTXO store, in batches. Transactions that were mined or removed from the UTXO store are left out.
+func (s *RPCServer) decorateMempool(ctx context.Context, hashes []chainhash.Hash) (*mempool, error) {
+	m := &mempool{
+		hashes:  make([]chainhash.Hash, 0, len(hashes)),
+		entries: make(map[chainhash.Hash]*mempoolEntry, len(hashes)),
+	}
+
+	for batch := range slices.Chunk(hashes, mempoolBatchSize) {
+		unresolved := make([]*utxo.UnresolvedMetaData, len(batch))
+		for i, hash := range batch {
+			unresolved[i] = &utxo.UnresolvedMetaData{Hash: hash, Idx: i}
+		}
+
+		if err := s.utxoStore.BatchDecorate(ctx, unresolved, fields.Fee, fields.SizeInBytes, fields.TxInpoints, fields.UnminedSince); err != nil {
+			return nil, errors.NewProcessingError("error retrieving transaction metadata", err)
+		}
+
+		for _, item := range unresolved {
+			if item.Err != nil || item.Data == nil || item.Data.UnminedSince == 0 {
+				continue
+			}
+
+			m.hashes = append(m.hashes, item.Hash)
+			m.entries[item.Hash] = &mempoolEntry{
+				hash:    item.Hash,
+				fee:     item.Data.Fee,
+				size:    item.Data.SizeInBytes,
+				height:  item.Data.UnminedSince - 1,
+				parents: item.Data.TxInpoints.ParentTxHashes,
+			}
+
+			m.bytes += item.Data.SizeInBytes
+			m.fees += item.Data.Fee
+		}
+	}
+
+	return m, nil
+}
+
+// setMempoolTimes sets the time of every entry to the timestamp of the block at the height the
+// transaction was accepted at, fetching the block headers in a single call.
+func (s *RPCServer) setMempoolTimes(ctx context.Context, m *mempool) error {
+	if len(m.entries) == 0 {
+		return nil
+	}
+
+	minHeight, maxHeight := ^uint32(0), uint32(0)
+
+	for _, entry := range m.entries {
+		minHeight = min(minHeight, entry.height)
+		maxHeight = max(maxHeight, entry.height)
+	}
+
+	headers, metas, err := s.blockchainClient.GetBlockHeadersByHeight(ctx, minHeight, maxHeight)
+	if err != nil {
+		return errors.NewServiceError("error retrieving block headers", err)
+	}
+
+	times := make(map[uint32]int64, len(headers))
+
+	for i, header := range headers {
+		if i < len(metas) {
+			times[metas[i].Height] = int64(header.Timestamp)
+		}
+	}
+
+	for _, entry := range m.entries {
+		entry.time = times[entry.height]
+	}
+
+	return nil
+}
+
+// ancestors returns the in-mempool ancestors of the transaction, in the order they were found
+func (m *mempool) ancestors(hash chainhash.Hash) []chainhash.Hash {
+	return m.walk(hash, func(entry *mempoolEntry) []chainhash.Hash { return entry.parents })
+}
+
+// descendants returns the in-mempool descendants of the transaction, in the order they were found
+func (m *mempool) descendants(hash chainhash.Hash) []chainhash.Hash {
+	return m.walk(hash, func(entry *mempoolEntry) []chainhash.Hash { return entry.children })
+}
+
+// walk returns all transactions reachable from the transaction through the links returned by next,
+// excluding the transaction itself.
+func (m *mempool) walk(hash chainhash.Hash, next func(entry *mempoolEntry) []chainhash.Hash) []chainhash.Hash {
+	entry, ok := m.entries[hash]
+	if !ok {
+		return nil
+	}
+
+	seen := map[chainhash.Hash]struct{}{hash: {}}
+	result := make([]chainhash.Hash, 0)
+	queue := slices.Clone(next(entry))
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		if _, ok := seen[current]; ok {
+			continue
+		}
+
+		seen[current] = struct{}{}
+		result = append(result, current)
+
+		queue = append(queue, next(m.entries[current])...)
+	}
+
+	return result
+}
+
+// depends returns the hashes of the in-mempool parents of the entry as strings
+func (e *mempoolEntry) depends() []string {
+	depends := make([]string, len(e.parents))
+	for i, parent := range e.parents {
+		depends[i] = parent.String()
+	}
+
+	return depends
+}
+
+// verboseResult returns the getrawmempool verbose result for the entry
+func (e *mempoolEntry) verboseResult() bsvjson.GetRawMempoolVerboseResult {
+	return bsvjson.GetRawMempoolVerboseResult{
+		Size:    int32(e.size), //nolint:gosec
+		Fee:     float64(e.fee) / 1e8,
+		Time:    e.time,
+		Height:  int64(e.height),
+		Depends: e.depends(),
+	}
+}
+
+// entryResult returns the getmempoolentry result for the entry, including the totals of its
+// in-mempool ancestors and descendants. Like bitcoind, the counts, sizes and fees include the
+// transaction itself.
+func (m *mempool) entryResult(e *mempoolEntry) *bsvjson.GetMempoolEntryResult {
+	result := &bsvjson.GetMempoolEntryResult{
+		Size:            int32(e.size), //nolint:gosec
+		Fee:             float64(e.fee) / 1e8,
+		ModifiedFee:     float64(e.fee) / 1e8,
+		Time:            e.time,
+		Height:          int64(e.height),
+		DescendantCount: 1,
+		DescendantSize:  int64(e.size), //nolint:gosec
+		DescendantFees:  float64(e.fee),
+		AncestorCount:   1,
+		AncestorSize:    int64(e.size), //nolint:gosec
+		AncestorFees:    float64(e.fee),
+		Depends:         e.depends(),
+	}
+
+	for _, hash := range m.ancestors(e.hash) {
+		ancestor := m.entries[hash]
+
+		result.AncestorCount++
+		result.AncestorSize += int64(ancestor.size) //nolint:gosec
+		result.AncestorFees += float64(ancestor.fee)
+	}
+
+	for _, hash := range m.descendants(e.hash) {
+		descendant := m.entries[hash]
+
+		result.DescendantCount++
+		result.DescendantSize += int64(descendant.size) //nolint:gosec
+		result.DescendantFees += float64(descendant.fee)
+	}
+
+	return result
+}
+
+// relativesResult returns the ids of the given transactions, or a map of transaction id to
+// getmempoolentry result when verbose is set. For the verbose result, the mempool must hold the
+// ancestors and descendants of the given transactions.
+func (m *mempool) relativesResult(hashes []chainhash.Hash, verbose bool) interface{} {
+	if !verbose {
+		result := make([]string, len(hashes))
+		for i, hash := range hashes {
+			result[i] = hash.String()
+		}
+
+		return result
+	}
+
+	result := make(map[string]*bsvjson.GetMempoolEntryResult, len(hashes))
+	for _, hash := range hashes {
+		// a transaction mined since its relatives were walked is left out
+		if entry, ok := m.entries[hash]; ok {
+			result[hash.String()] = m.entryResult(entry)
+		}
+	}
+
+	return result
+}
@@ -32,12 +32,15 @@ import (
 // enabling detailed performance analysis and identification of bottlenecks.
 //
 // The metrics cover all major RPC command categories:
-//   - Block operations: GetBlock, GetBlockByHeight, GetBlockHash, GetBlockHeader, GetBestBlockHash
-//   - Transaction operations: GetRawTransaction, CreateRawTransaction, SendRawTransaction
-//   - Mining operations: Generate, GenerateToAddress, GetMiningCandidate, SubmitMiningSolution, GetMiningInfo
+//   - Block operations: GetBlock, GetBlockByHeight, GetBlockHash, GetBlockHeader, GetBestBlockHash, GetCFilter, GetCFilterHeader
+//   - Transaction operations: GetRawTransaction, GetTxOut, GetTxOutProof, GetTxOutSetInfo, VerifyTxOutProof, CreateRawTransaction, SendRawTransaction, SearchRawTransactions
+//   - Mining operations: Generate, GenerateToAddress, GetMiningCandidate, SubmitMiningSolution, GetBlockTemplate, SubmitBlock, GetMiningInfo
 //   - Network operations: GetPeerInfo, SetBan, IsBanned, ListBanned, ClearBanned
 //   - Blockchain info: GetBlockchainInfo, GetInfo, GetDifficulty
-//   - Block management: InvalidateBlock, ReconsiderBlock
+//   - Decoding and validation: DecodeRawTransaction, DecodeScript, ValidateAddress, VerifyMessage
+//   - Mempool operations: GetRawMempool, GetMempoolInfo, GetMempoolEntry, GetMempoolAncestors, GetMempoolDescendants
+//   - Fee estimation: EstimateFee, EstimateSmartFee
+//   - Block management: InvalidateBlock, ReconsiderBlock, VerifyChain
 //   - UTXO operations: Freeze, Unfreeze, Reassign
 //   - Help system: Help command
 //
@@ -45,35 +48,55 @@ import (
 // typically ranging from sub-millisecond to several seconds depending on command complexity.
 var (
 	// prometheusHealth                     prometheus.Counter
-	prometheusHandleGetBlock             prometheus.Histogram
-	prometheusHandleGetBlockByHeight     prometheus.Histogram
-	prometheusHandleGetBlockHash         prometheus.Histogram
-	prometheusHandleGetBlockHeader       prometheus.Histogram
-	prometheusHandleGetBestBlockHash     prometheus.Histogram
-	prometheusHandleGetRawTransaction    prometheus.Histogram
-	prometheusHandleCreateRawTransaction prometheus.Histogram
-	prometheusHandleSendRawTransaction   prometheus.Histogram
-	prometheusHandleGenerate             prometheus.Histogram
-	prometheusHandleGenerateToAddress    prometheus.Histogram
-	prometheusHandleGetMiningCandidate   prometheus.Histogram
-	prometheusHandleSubmitMiningSolution prometheus.Histogram
-	prometheusHandleGetpeerinfo          prometheus.Histogram
-	prometheusHandleGetRawmempool        prometheus.Histogram
-	prometheusHandleGetblockchaininfo    prometheus.Histogram
-	prometheusHandleGetinfo              prometheus.Histogram
-	prometheusHandleGetDifficulty        prometheus.Histogram
-	prometheusHandleInvalidateBlock      prometheus.Histogram
-	prometheusHandleReconsiderBlock      prometheus.Histogram
-	prometheusHandleHelp                 prometheus.Histogram
-	prometheusHandleSetBan               prometheus.Histogram
-	prometheusHandleIsBanned             prometheus.Histogram
-	prometheusHandleListBanned           prometheus.Histogram
-	prometheusHandleClearBanned          prometheus.Histogram
-	prometheusHandleGetMiningInfo        prometheus.Histogram
-	prometheusHandleFreeze               prometheus.Histogram
-	prometheusHandleUnfreeze             prometheus.Histogram
-	prometheusHandleReassign             prometheus.Histogram
-	prometheusHandleGetchaintips         prometheus.Histogram
+	prometheusHandleGetBlock              prometheus.Histogram
+	prometheusHandleGetBlockByHeight      prometheus.Histogram
+	prometheusHandleGetBlockHash          prometheus.Histogram
+	prometheusHandleGetBlockHeader        prometheus.Histogram
+	prometheusHandleGetBestBlockHash      prometheus.Histogram
+	prometheusHandleGetRawTransaction     prometheus.Histogram
+	prometheusHandleGetTxOut              prometheus.Histogram
+	prometheusHandleCreateRawTransaction  prometheus.Histogram
+	prometheusHandleSendRawTransaction    prometheus.Histogram
+	prometheusHandleGenerate              prometheus.Histogram
+	prometheusHandleGenerateToAddress     prometheus.Histogram
+	prometheusHandleGetMiningCandidate    prometheus.Histogram
+	prometheusHandleSubmitMiningSolution  prometheus.Histogram
+	prometheusHandleGetpeerinfo           prometheus.Histogram
+	prometheusHandleGetRawmempool         prometheus.Histogram
+	prometheusHandleGetblockchaininfo     prometheus.Histogram
+	prometheusHandleGetinfo               prometheus.Histogram
+	prometheusHandleGetDifficulty         prometheus.Histogram
+	prometheusHandleInvalidateBlock       prometheus.Histogram
+	prometheusHandleReconsiderBlock       prometheus.Histogram
+	prometheusHandleHelp                  prometheus.Histogram
+	prometheusHandleSetBan                prometheus.Histogram
+	prometheusHandleIsBanned              prometheus.Histogram
+	prometheusHandleListBanned            prometheus.Histogram
+	prometheusHandleClearBanned           prometheus.Histogram
+	prometheusHandleGetMiningInfo         prometheus.Histogram
+	prometheusHandleFreeze                prometheus.Histogram
+	prometheusHandleUnfreeze              prometheus.Histogram
+	prometheusHandleReassign              prometheus.Histogram
+	prometheusHandleGetchaintips          prometheus.Histogram
+	prometheusHandleGetTxOutProof         prometheus.Histogram
+	prometheusHandleGetTxOutSetInfo       prometheus.Histogram
+	prometheusHandleVerifyTxOutProof      prometheus.Histogram
+	prometheusHandleGetBlockTemplate      prometheus.Histogram
+	prometheusHandleSubmitBlock           prometheus.Histogram
+	prometheusHandleGetCFilter            prometheus.Histogram
+	prometheusHandleGetCFilterHeader      prometheus.Histogram
+	prometheusHandleSearchRawTransactions prometheus.Histogram
+	prometheusHandleEstimateFee           prometheus.Histogram
+	prometheusHandleEstimateSmartFee      prometheus.Histogram
+	prometheusHandleGetMempoolInfo        prometheus.Histogram
+	prometheusHandleGetMempoolEntry       prometheus.Histogram
+	prometheusHandleGetMempoolAncestors   prometheus.Histogram
+	prometheusHandleGetMempoolDescendants prometheus.Histogram
+	prometheusHandleDecodeRawTransaction  prometheus.Histogram
+	prometheusHandleDecodeScript          prometheus.Histogram
+	prometheusHandleValidateAddress       prometheus.Histogram
+	prometheusHandleVerifyMessage         prometheus.Histogram
+	prometheusHandleVerifyChain           prometheus.Histogram
 )
 
 var (
@@ -346,4 +369,184 @@ func _initPrometheusMetrics() {
 			Buckets:   util.MetricsBucketsMilliSeconds,
 		},
 	)
+	prometheusHandleGetTxOut = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "teranode",
+			Subsystem: "rpc",
+			Name:      "get_tx_out",
+			Help:      "Histogram of calls to handleGetTxOut in the rpc service",
+			Buckets:   util.MetricsBucketsMilliSeconds,
+		},
+	)
+	prometheusHandleGetTxOutProof = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "teranode",
+			Subsystem: "rpc",
+			Name:      "get_tx_out_proof",
+			Help:      "Histogram of calls to handleGetTxOutProof in the rpc service",
+			Buckets:   util.MetricsBucketsMilliSeconds,
+		},
+	)
+	prometheusHandleGetTxOutSetInfo = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "teranode",
+			Subsystem: "rpc",
+			Name:      "get_tx_out_set_info",
+			Help:      "Histogram of calls to handleGetTxOutSetInfo in the rpc service",
+			Buckets:   util.MetricsBucketsMilliSeconds,
+		},
+	)
+	prometheusHandleVerifyTxOutProof = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "teranode",
+			Subsystem: "rpc",
+			Name:      "verify_tx_out_proof",
+			Help:      "Histogram of calls to handleVerifyTxOutProof in the rpc service",
+			Buckets:   util.MetricsBucketsMilliSeconds,
+		},
+	)
+	prometheusHandleGetBlockTemplate = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "teranode",
+			Subsystem: "rpc",
+			Name:      "get_block_template",
+			Help:      "Histogram of calls to handleGetBlockTemplate in the rpc service",
+			Buckets:   util.MetricsBucketsMilliSeconds,
+		},
+	)
+	prometheusHandleSubmitBlock = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "teranode",
+			Subsystem: "rpc",
+			Name:      "submit_block",
+			Help:      "Histogram of calls to handleSubmitBlock in the rpc service",
+			Buckets:   util.MetricsBucketsMilliSeconds,
+		},
+	)
+	prometheusHandleGetCFilter = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "teranode",
+			Subsystem: "rpc",
+			Name:      "get_cfilter",
+			Help:      "Histogram of calls to handleGetCFilter in the rpc service",
+			Buckets:   util.MetricsBucketsMilliSeconds,
+		},
+	)
+	prometheusHandleGetCFilterHeader = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "teranode",
+			Subsystem: "rpc",
+			Name:      "get_cfilter_header",
+			Help:      "Histogram of calls to handleGetCFilterHeader in the rpc service",
+			Buckets:   util.MetricsBucketsMilliSeconds,
+		},
+	)
+	prometheusHandleSearchRawTransactions = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "teranode",
+			Subsystem: "rpc",
+			Name:      "search_raw_transactions",
+			Help:      "Histogram of calls to handleSearchRawTransactions in the rpc service",
+			Buckets:   util.MetricsBucketsMilliSeconds,
+		},
+	)
+	prometheusHandleEstimateFee = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "teranode",
+			Subsystem: "rpc",
+			Name:      "estimate_fee",
+			Help:      "Histogram of calls to handleEstimateFee in the rpc service",
+			Buckets:   util.MetricsBucketsMilliSeconds,
+		},
+	)
+	prometheusHandleEstimateSmartFee = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "teranode",
+			Subsystem: "rpc",
+			Name:      "estimate_smart_fee",
+			Help:      "Histogram of calls to handleEstimateSmartFee in the rpc service",
+			Buckets:   util.MetricsBucketsMilliSeconds,
+		},
+	)
+	prometheusHandleGetMempoolInfo = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "teranode",
+			Subsystem: "rpc",
+			Name:      "get_mempool_info",
+			Help:      "Histogram of calls to handleGetMempoolInfo in the rpc service",
+			Buckets:   util.MetricsBucketsMilliSeconds,
+		},
+	)
+	prometheusHandleGetMempoolEntry = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "teranode",
+			Subsystem: "rpc",
+			Name:      "get_mempool_entry",
+			Help:      "Histogram of calls to handleGetMempoolEntry in the rpc service",
+			Buckets:   util.MetricsBucketsMilliSeconds,
+		},
+	)
+	prometheusHandleGetMempoolAncestors = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "teranode",
+			Subsystem: "rpc",
+			Name:      "get_mempool_ancestors",
+			Help:      "Histogram of calls to handleGetMempoolAncestors in the rpc service",
+			Buckets:   util.MetricsBucketsMilliSeconds,
+		},
+	)
+	prometheusHandleGetMempoolDescendants = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "teranode",
+			Subsystem: "rpc",
+			Name:      "get_mempool_descendants",
+			Help:      "Histogram of calls to handleGetMempoolDescendants in the rpc service",
+			Buckets:   util.MetricsBucketsMilliSeconds,
+		},
+	)
+	prometheusHandleDecodeRawTransaction = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "teranode",
+			Subsystem: "rpc",
+			Name:      "decode_raw_transaction",
+			Help:      "Histogram of calls to handleDecodeRawTransaction in the rpc service",
+			Buckets:   util.MetricsBucketsMilliSeconds,
+		},
+	)
+	prometheusHandleDecodeScript = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "teranode",
+			Subsystem: "rpc",
+			Name:      "decode_script",
+			Help:      "Histogram of calls to handleDecodeScript in the rpc service",
+			Buckets:   util.MetricsBucketsMilliSeconds,
+		},
+	)
+	prometheusHandleValidateAddress = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "teranode",
+			Subsystem: "rpc",
+			Name:      "validate_address",
+			Help:      "Histogram of calls to handleValidateAddress in the rpc service",
+			Buckets:   util.MetricsBucketsMilliSeconds,
+		},
+	)
+	prometheusHandleVerifyMessage = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "teranode",
+			Subsystem: "rpc",
+			Name:      "verify_message",
+			Help:      "Histogram of calls to handleVerifyMessage in the rpc service",
+			Buckets:   util.MetricsBucketsMilliSeconds,
+		},
+	)
+	prometheusHandleVerifyChain = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "teranode",
+			Subsystem: "rpc",
+			Name:      "verify_chain",
+			Help:      "Histogram of calls to handleVerifyChain in the rpc service",
+			Buckets:   util.MetricsBucketsMilliSeconds,
+		},
+	)
 }
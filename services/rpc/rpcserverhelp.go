@@ -138,13 +138,25 @@ var helpDescsEnUS = map[string]string{
 	"decodescript-hexscript": "Hex-encoded script",
 
 	// EstimateFeeCmd help.
-	"estimatefee--synopsis": "Estimate the fee per kilobyte in satoshis " +
+	"estimatefee--synopsis": "Estimate the fee per kilobyte in BSV " +
 		"required for a transaction to be mined before a certain number of " +
 		"blocks have been generated.",
 	"estimatefee-numblocks": "The maximum number of blocks which can be " +
-		"generated before the transaction is mined.",
-	"estimatefee--result0": "Estimated fee per kilobyte in satoshis for a block to " +
-		"be mined in the next NumBlocks blocks.",
+		"generated before the transaction is mined (1 - 24).",
+	"estimatefee--result0": "Estimated fee per kilobyte in BSV for a transaction to " +
+		"be mined in the next NumBlocks blocks, or -1 if no estimate is available.",
+
+	// EstimateSmartFeeCmd help.
+	"estimatesmartfee--synopsis": "Estimate the fee per kilobyte in BSV " +
+		"required for a transaction to be mined within conf_target blocks, " +
+		"trying higher targets when no estimate is available.",
+	"estimatesmartfee-conftarget":   "The number of blocks within which the transaction should be mined (1 - 24)",
+	"estimatesmartfee-estimatemode": "The estimate mode, UNSET, ECONOMICAL or CONSERVATIVE",
+
+	// EstimateSmartFeeResult help.
+	"estimatesmartfeeresult-feerate": "Estimated fee per kilobyte in BSV, omitted if no estimate is available",
+	"estimatesmartfeeresult-errors":  "Errors encountered during the estimation, if any",
+	"estimatesmartfeeresult-blocks":  "The number of blocks the estimate was made for",
 
 	// GenerateCmd help
 	"generate--synopsis": "Generates a set number of blocks (regtest only) and returns a JSON\n" +
@@ -303,7 +315,7 @@ var helpDescsEnUS = map[string]string{
 
 	// TemplateRequest help.
 	"templaterequest-mode":         "This is 'template', 'proposal', or omitted",
-	"templaterequest-capabilities": "List of capabilities",
+	"templaterequest-capabilities": "List of capabilities, include 'subtrees' to receive the subtree hashes of the block instead of the full transaction list",
 	"templaterequest-longpollid":   "The long poll ID of a job to monitor for expiration; required and valid only for long poll requests ",
 	"templaterequest-sigoplimit":   "Number of signature operations allowed in blocks (this parameter is ignored)",
 	"templaterequest-sizelimit":    "Number of bytes allowed in blocks (this parameter is ignored)",
@@ -348,6 +360,9 @@ var helpDescsEnUS = map[string]string{
 	"getblocktemplateresult-capabilities":               "List of server capabilities including 'proposal' to indicate support for block proposals",
 	"getblocktemplateresult-reject-reason":              "Reason the proposal was invalid as-is (only applies to proposal responses)",
 	"getblocktemplateresult-default_witness_commitment": "The witness commitment itself. Will be populated if the block has witness data",
+	"getblocktemplateresult-subtreehashes":              "Hex-encoded hashes of the subtrees holding the transactions of the block (only with the subtrees capability)",
+	"getblocktemplateresult-merkleproof":                "Hex-encoded merkle branches needed to calculate the merkle root from the coinbase transaction (only with the subtrees capability)",
+	"getblocktemplateresult-txcount":                    "Number of transactions in the block, excluding the coinbase (only with the subtrees capability)",
 
 	// GetBlockTemplateCmd help.
 	"getblocktemplate--synopsis": "Returns a JSON object with information necessary to construct a block to mine or accepts a proposal to validate.\n" +
@@ -429,12 +444,56 @@ var helpDescsEnUS = map[string]string{
 	// GetInfoCmd help.
 	"getinfo--synopsis": "Returns a JSON object containing various state info.",
 
+	// GetMempoolAncestorsCmd help.
+	"getmempoolancestors--synopsis":       "Returns the in-mempool ancestors of a transaction in the memory pool.",
+	"getmempoolancestors-txid":            "The hash of the transaction",
+	"getmempoolancestors-verbose":         "Returns JSON object when true or an array of transaction hashes when false",
+	"getmempoolancestors--condition0":     "verbose=false",
+	"getmempoolancestors--condition1":     "verbose=true",
+	"getmempoolancestors--result0":        "Array of transaction hashes of the ancestors",
+	"getmempoolancestors--result1--desc":  "Mempool entries of the ancestors keyed by transaction hash",
+	"getmempoolancestors--result1--key":   "Transaction hash",
+	"getmempoolancestors--result1--value": "Object in the format of getmempoolentry",
+
+	// GetMempoolDescendantsCmd help.
+	"getmempooldescendants--synopsis":       "Returns the in-mempool descendants of a transaction in the memory pool.",
+	"getmempooldescendants-txid":            "The hash of the transaction",
+	"getmempooldescendants-verbose":         "Returns JSON object when true or an array of transaction hashes when false",
+	"getmempooldescendants--condition0":     "verbose=false",
+	"getmempooldescendants--condition1":     "verbose=true",
+	"getmempooldescendants--result0":        "Array of transaction hashes of the descendants",
+	"getmempooldescendants--result1--desc":  "Mempool entries of the descendants keyed by transaction hash",
+	"getmempooldescendants--result1--key":   "Transaction hash",
+	"getmempooldescendants--result1--value": "Object in the format of getmempoolentry",
+
+	// GetMempoolEntryCmd help.
+	"getmempoolentry--synopsis": "Returns mempool data for a transaction in the memory pool.",
+	"getmempoolentry-txid":      "The hash of the transaction",
+
+	// GetMempoolEntryResult help.
+	"getmempoolentryresult-size":             "Transaction size in bytes",
+	"getmempoolentryresult-fee":              "Transaction fee in bitcoins",
+	"getmempoolentryresult-modifiedfee":      "Transaction fee in bitcoins, there is no fee prioritisation so this equals the fee",
+	"getmempoolentryresult-time":             "Time of the chain tip when the transaction entered the pool in seconds since 1 Jan 1970 GMT",
+	"getmempoolentryresult-height":           "Block height when the transaction entered the pool",
+	"getmempoolentryresult-startingpriority": "Priority when the transaction entered the pool, always 0",
+	"getmempoolentryresult-currentpriority":  "Current priority, always 0",
+	"getmempoolentryresult-descendantcount":  "Number of in-mempool descendant transactions, including this one",
+	"getmempoolentryresult-descendantsize":   "Size in bytes of in-mempool descendants, including this one",
+	"getmempoolentryresult-descendantfees":   "Fees in satoshis of in-mempool descendants, including this one",
+	"getmempoolentryresult-ancestorcount":    "Number of in-mempool ancestor transactions, including this one",
+	"getmempoolentryresult-ancestorsize":     "Size in bytes of in-mempool ancestors, including this one",
+	"getmempoolentryresult-ancestorfees":     "Fees in satoshis of in-mempool ancestors, including this one",
+	"getmempoolentryresult-depends":          "Unconfirmed transactions used as inputs for this transaction",
+
 	// GetMempoolInfoCmd help.
 	"getmempoolinfo--synopsis": "Returns memory pool information",
 
 	// GetMempoolInfoResult help.
-	"getmempoolinforesult-bytes": "Size in bytes of the mempool",
-	"getmempoolinforesult-size":  "Number of transactions in the mempool",
+	"getmempoolinforesult-bytes":         "Size in bytes of the mempool",
+	"getmempoolinforesult-size":          "Number of transactions in the mempool",
+	"getmempoolinforesult-total_fee":     "Total fees of the transactions in the mempool in bitcoins",
+	"getmempoolinforesult-mempoolminfee": "Minimum fee rate in bitcoins per kB for a transaction to be accepted",
 
 	// GetMiningInfoResult help.
 	"getmininginforesult-blocks":           "Height of the latest best block",
@@ -505,11 +564,14 @@ var helpDescsEnUS = map[string]string{
 	"getrawmempoolverboseresult-vsize":            "The virtual size of a transaction",
 
 	// GetRawMempoolCmd help.
-	"getrawmempool--synopsis":   "Returns information about all of the transactions currently in the memory pool.",
-	"getrawmempool-verbose":     "Returns JSON object when true or an array of transaction hashes when false",
-	"getrawmempool--condition0": "verbose=false",
-	"getrawmempool--condition1": "verbose=true",
-	"getrawmempool--result0":    "Array of transaction hashes",
+	"getrawmempool--synopsis":       "Returns information about all of the transactions currently in the memory pool.",
+	"getrawmempool-verbose":         "Returns JSON object when true or an array of transaction hashes when false",
+	"getrawmempool--condition0":     "verbose=false",
+	"getrawmempool--condition1":     "verbose=true",
+	"getrawmempool--result0":        "Array of transaction hashes",
+	"getrawmempool--result1--desc":  "Mempool entries keyed by transaction hash",
+	"getrawmempool--result1--key":   "Transaction hash",
+	"getrawmempool--result1--value": "Object containing the size, fee, time, height and depends of the transaction",
 
 	// GetRawTransactionCmd help.
 	"getrawtransaction--synopsis":   "Returns information about a transaction given its hash.",
@@ -526,6 +588,9 @@ var helpDescsEnUS = map[string]string{
 	"gettxoutresult-scriptPubKey":  "The public key script used to pay coins as a JSON object",
 	"gettxoutresult-version":       "The transaction version",
 	"gettxoutresult-coinbase":      "Whether or not the transaction is a coinbase",
+	"gettxoutresult-frozen":        "Whether or not the output has been frozen by the alert system",
+	"gettxoutresult-conflicting":   "Whether or not the transaction is conflicting with another transaction",
+	"gettxoutresult-locked":        "Whether or not the transaction outputs are locked and not yet spendable",
 
 	// GetTxOutCmd help.
 	"gettxout--synopsis":      "Returns information about an unspent transaction output..",
@@ -539,6 +604,17 @@ var helpDescsEnUS = map[string]string{
 	"gettxoutproof-blockhash": "The block hash the transactions are in",
 	"gettxoutproof--result0":  "Hex encoded merkle proof",
 
+	// GetTxOutSetInfoCmd help.
+	"gettxoutsetinfo--synopsis": "Returns statistics about the unspent transaction output set at the last block processed by the UTXO persister, including its multiset hash.",
+
+	// GetTxOutSetInfoResult help.
+	"gettxoutsetinforesult-height":       "The height of the block of the UTXO set",
+	"gettxoutsetinforesult-bestblock":    "The hash of the block of the UTXO set",
+	"gettxoutsetinforesult-transactions": "The number of transactions with unspent outputs",
+	"gettxoutsetinforesult-txouts":       "The number of unspent transaction outputs",
+	"gettxoutsetinforesult-total_amount": "The total amount of the unspent outputs in bitcoins",
+	"gettxoutsetinforesult-muhash":       "The elliptic curve multiset hash of the unspent outputs",
+
 	// VerifyTxOutProofCmd help.
 	"verifytxoutproof--synopsis": "Verifies that a proof points to a transaction in a block, returning the transaction it commits to and throwing an RPC error if the block is not in our best chain",
 	"verifytxoutproof-proof":     "The hex-encoded proof generated by gettxoutproof",
@@ -558,10 +634,9 @@ var helpDescsEnUS = map[string]string{
 
 	// SearchRawTransactionsCmd help.
 	"searchrawtransactions--synopsis": "Returns raw data for transactions involving the passed address.\n" +
-		"Returned transactions are pulled from both the database, and transactions currently in the mempool.\n" +
-		"Transactions pulled from the mempool will have the 'confirmations' field set to 0.\n" +
-		"Usage of this RPC requires the optional --addrindex flag to be activated, otherwise all responses will simply return with an error stating the address index has not yet been built.\n" +
-		"Similarly, until the address index has caught up with the current best height, all requests will return an error response in order to avoid serving stale data.",
+		"Returned transactions are pulled from the script hash index, only transactions mined in blocks on the best chain are returned.\n" +
+		"Usage of this RPC requires the optional script hash index to be enabled (scripthashindex_enabled), otherwise all responses will simply return with an error stating the index must be enabled.\n" +
+		"Until the script hash index has caught up with the current best height, the most recent transactions will be missing from the response.",
 	"searchrawtransactions-address":     "The Bitcoin address to search for",
 	"searchrawtransactions-verbose":     "Specifies the transaction is returned as a JSON object instead of hex-encoded string",
 	"searchrawtransactions--condition0": "verbose=0",
@@ -593,19 +668,21 @@ var helpDescsEnUS = map[string]string{
 	"stop--result0":  "The string 'bsvd stopping.'",
 
 	// SubmitBlockOptions help.
-	"submitblockoptions-workid": "This parameter is currently ignored",
+	"submitblockoptions-workid": "The workid of the block template the block was built from",
 
 	// SubmitBlockCmd help.
 	"submitblock--synopsis":   "Attempts to submit a new serialized, hex-encoded block to the network.",
 	"submitblock-hexblock":    "Serialized, hex-encoded block",
-	"submitblock-options":     "This parameter is currently ignored",
+	"submitblock-options":     "Options, including the workid of the block template the block was built from",
 	"submitblock--condition0": "Block successfully submitted",
 	"submitblock--condition1": "Block rejected",
 	"submitblock--result1":    "The reason the block was rejected",
 
 	// ValidateAddressResult help.
-	"validateaddresschainresult-isvalid": "Whether or not the address is valid",
-	"validateaddresschainresult-address": "The bitcoin address (only when isvalid is true)",
+	"validateaddresschainresult-isvalid":      "Whether or not the address is valid",
+	"validateaddresschainresult-address":      "The bitcoin address (only when isvalid is true)",
+	"validateaddresschainresult-scriptPubKey": "The hex-encoded locking script paying to the address (only when isvalid is true)",
+	"validateaddresschainresult-isscript":     "Whether or not the address is a pay-to-script-hash address (only when isvalid is true)",
 
 	// ValidateAddressCmd help.
 	"validateaddress--synopsis": "Verify an address is valid.",
@@ -614,11 +691,14 @@ var helpDescsEnUS = map[string]string{
 	// VerifyChainCmd help.
 	"verifychain--synopsis": "Verifies the block chain database.\n" +
 		"The actual checks performed by the checklevel parameter are implementation specific.\n" +
-		"For bsvd this is:\n" +
-		"checklevel=0 - Look up each block and ensure it can be loaded from the database.\n" +
-		"checklevel=1 - Perform basic context-free sanity checks on each block.",
-	"verifychain-checklevel": "How thorough the block verification is",
-	"verifychain-checkdepth": "The number of blocks to check",
+		"For Teranode this is:\n" +
+		"checklevel=0 - Look up each block, ensure it links to its parent and meets its proof of work target.\n" +
+		"checklevel=1 - Also check that the difficulty of each block is the difficulty required by the chain.\n" +
+		"checklevel=2 - Also check that the subtrees and subtree data of each block exist.\n" +
+		"checklevel=3 - Also recompute the merkle root of each block from its stored subtrees.\n" +
+		"checklevel=4 - Also validate each block against the UTXO store.",
+	"verifychain-checklevel": "How thorough the block verification is (0-4)",
+	"verifychain-checkdepth": "The number of blocks to check, 0 checks the whole chain",
 	"verifychain--result0":   "Whether or not the chain verified",
 
 	// VerifyMessageCmd help.
@@ -748,6 +828,7 @@ var rpcResultTypes = map[string][]interface{}{
 	"decoderawtransaction":  {(*bsvjson.TxRawDecodeResult)(nil)},
 	"decodescript":          {(*bsvjson.DecodeScriptResult)(nil)},
 	"estimatefee":           {(*float64)(nil)},
+	"estimatesmartfee":      {(*bsvjson.EstimateSmartFeeResult)(nil)},
 	"generate":              {(*[]string)(nil)},
 	"getaddednodeinfo":      {(*[]string)(nil), (*[]bsvjson.GetAddedNodeInfoResult)(nil)},
 	"getbestblock":          {(*bsvjson.GetBestBlockResult)(nil)},
@@ -767,15 +848,19 @@ var rpcResultTypes = map[string][]interface{}{
 	"gethashespersec":       {(*float64)(nil)},
 	"getheaders":            {(*[]string)(nil)},
 	"getinfo":               {(*bsvjson.InfoChainResult)(nil)},
+	"getmempoolancestors":   {(*[]string)(nil), (*map[string]bsvjson.GetMempoolEntryResult)(nil)},
+	"getmempooldescendants": {(*[]string)(nil), (*map[string]bsvjson.GetMempoolEntryResult)(nil)},
+	"getmempoolentry":       {(*bsvjson.GetMempoolEntryResult)(nil)},
 	"getmempoolinfo":        {(*bsvjson.GetMempoolInfoResult)(nil)},
 	"getmininginfo":         {(*bsvjson.GetMiningInfoResult)(nil)},
 	"getnettotals":          {(*bsvjson.GetNetTotalsResult)(nil)},
 	"getnetworkhashps":      {(*float64)(nil)},
 	"getpeerinfo":           {(*[]bsvjson.GetPeerInfoResult)(nil)},
-	"getrawmempool":         {(*[]string)(nil), (*bsvjson.GetRawMempoolVerboseResult)(nil)},
+	"getrawmempool":         {(*[]string)(nil), (*map[string]bsvjson.GetRawMempoolVerboseResult)(nil)},
 	"getrawtransaction":     {(*string)(nil), (*bsvjson.TxRawResult)(nil)},
 	"gettxout":              {(*bsvjson.GetTxOutResult)(nil)},
 	"gettxoutproof":         {(*string)(nil)},
+	"gettxoutsetinfo":       {(*bsvjson.GetTxOutSetInfoResult)(nil)},
 	"node":                  nil,
 	"help":                  {(*string)(nil), (*string)(nil)},
 	"ping":                  nil,
@@ -0,0 +1,358 @@
+// Package scripthashindex builds and maintains an index of the transaction outputs locked to each script
+// hash, and of the transactions spending them. The index answers the question which transactions touched
+// a script, and is used for the script hash history, unspent outputs and balance queries of the asset
+// service, and for the searchrawtransactions command of the RPC service.
+//
+// The package implements a server that follows the best chain and indexes each block from the subtree
+// data written by the Block Persister. The index is held in a scripthash.Store, which only holds the
+// blocks of the best chain: when the chain reorganizes, the blocks that are no longer on the best chain
+// are unwound from the tip of the index, which marks the transactions of these blocks as no longer on
+// the longest chain, before the blocks of the new best chain are indexed.
+//
+// Integration points:
+// - Blockchain service: Source of block notifications and blockchain data
+// - Subtree Store: Source of the subtree data of the blocks
+// - Script Hash Store: Storage for the index
+package scripthashindex
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/bsv-blockchain/go-bt/v2"
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/model"
+	"github.com/bsv-blockchain/teranode/services/blockchain"
+	"github.com/bsv-blockchain/teranode/settings"
+	"github.com/bsv-blockchain/teranode/stores/blob"
+	"github.com/bsv-blockchain/teranode/stores/scripthash"
+	"github.com/bsv-blockchain/teranode/ulogger"
+	"github.com/bsv-blockchain/teranode/util/blockfollower"
+	"github.com/bsv-blockchain/teranode/util/health"
+	"github.com/ordishs/gocore"
+)
+
+const (
+	// persistWaitDuration is the time to wait before retrying a block of which the subtree data
+	// has not been written by the block persister yet
+	persistWaitDuration = 10 * time.Second
+)
+
+// Server manages the script hash index.
+// It subscribes to blockchain notifications to detect new blocks, and indexes the blocks of the best
+// chain one at a time, in order of height. When the tip of the index is no longer on the best chain,
+// the server unwinds it, one block at a time, until the tip is on the best chain again.
+type Server struct {
+	// logger provides logging functionality
+	logger ulogger.Logger
+
+	// settings contains configuration settings
+	settings *settings.Settings
+
+	// blockchainClient provides access to blockchain operations
+	blockchainClient blockchain.ClientI
+
+	// subtreeStore provides access to the subtrees and the subtree data of the blocks
+	subtreeStore blob.Store
+
+	// store is where the index is written to
+	store scripthash.Store
+
+	// stats tracks operational statistics
+	stats *gocore.Stat
+
+	// follower calls processNextBlock until the index has caught up with the best chain
+	follower *blockfollower.Follower
+}
+
+// New creates a new Server instance with the provided parameters.
+//
+// Parameters:
+// - ctx: Context for controlling the initialization process
+// - logger: Logger interface used for recording operational events and errors
+// - tSettings: Configuration settings that control server behavior
+// - subtreeStore: Blob store holding the subtrees and the subtree data of the blocks
+// - store: Script hash store the index is written to
+// - blockchainClient: Client interface for accessing blockchain data and subscribing to notifications
+//
+// Returns a configured Server instance ready for initialization via the Init method.
+func New(
+	ctx context.Context,
+	logger ulogger.Logger,
+	tSettings *settings.Settings,
+	subtreeStore blob.Store,
+	store scripthash.Store,
+	blockchainClient blockchain.ClientI,
+) *Server {
+	s := &Server{
+		logger:           logger,
+		settings:         tSettings,
+		blockchainClient: blockchainClient,
+		subtreeStore:     subtreeStore,
+		store:            store,
+		stats:            gocore.NewStat("scripthashindex"),
+	}
+
+	s.follower = blockfollower.New(logger, "ScriptHashIndex", "scripthash-index", blockchainClient, s.processNextBlock)
+
+	return s
+}
+
+// Health checks the health status of the server and its dependencies.
+// Liveness checks only verify that the service is running, while readiness checks also verify that
+// the blockchain client, FSM, subtree store and script hash store are available.
+//
+// Parameters:
+// - ctx: Context for controlling the health check operation
+// - checkLiveness: Boolean flag that determines the check type (true for liveness, false for readiness)
+//
+// Returns:
+// - int: HTTP status code (200 for OK, 503 for Service Unavailable)
+// - string: Status message describing the health state
+// - error: Any error encountered during health checks
+func (s *Server) Health(ctx context.Context, checkLiveness bool) (int, string, error) {
+	if checkLiveness {
+		// Add liveness checks here. Don't include dependency checks.
+		// If the service is stuck return http.StatusServiceUnavailable
+		// to indicate a restart is needed
+		return http.StatusOK, "OK", nil
+	}
+
+	// Add readiness checks here. Include dependency checks.
+	// If any dependency is not ready, return http.StatusServiceUnavailable
+	// If all dependencies are ready, return http.StatusOK
+	// A failed dependency check does not imply the service needs restarting
+	checks := make([]health.Check, 0, 4)
+	if s.blockchainClient != nil {
+		checks = append(checks, health.Check{Name: "BlockchainClient", Check: s.blockchainClient.Health})
+		checks = append(checks, health.Check{Name: "FSM", Check: blockchain.CheckFSM(s.blockchainClient)})
+	}
+
+	if s.subtreeStore != nil {
+		checks = append(checks, health.Check{Name: "SubtreeStore", Check: s.subtreeStore.Health})
+	}
+
+	if s.store != nil {
+		checks = append(checks, health.Check{Name: "ScriptHashStore", Check: s.store.Health})
+	}
+
+	return health.CheckAll(ctx, checkLiveness, checks)
+}
+
+// Init initializes the server.
+// No specific initialization is needed, the tip of the index is read from the store for every block.
+func (s *Server) Init(_ context.Context) error {
+	return nil
+}
+
+// Start begins the server's processing operations.
+// It waits for the blockchain FSM to leave the IDLE state, subscribes to blockchain notifications and
+// indexes blocks as they are received through the notification channel or on a timer.
+// The readyCh is closed when initialization is complete to signal readiness.
+//
+// Parameters:
+// - ctx: Context for controlling the server's lifecycle
+// - readyCh: Channel closed when initialization is complete to signal readiness
+//
+// Returns:
+// - error: Any error encountered during startup or processing
+func (s *Server) Start(ctx context.Context, readyCh chan<- struct{}) error {
+	close(readyCh)
+
+	return s.follower.Start(ctx)
+}
+
+// Stop stops the server's processing operations.
+// No specific cleanup operations are needed, processing stops when the context passed to Start is canceled.
+// The store is not closed, as it may be shared with the asset and RPC services.
+func (s *Server) Stop(_ context.Context) error {
+	return nil
+}
+
+// processNextBlock brings the index one block closer to the best chain.
+//
+// When the tip of the index is no longer on the best chain, the tip is unwound. Otherwise, the block of
+// the best chain after the tip is indexed.
+//
+// Parameters:
+// - ctx: Context for controlling the processing operation
+//
+// Returns:
+// - time.Duration: Time to wait before processing the next block
+// - error: Not found error when there is no block to index, or any other error encountered
+func (s *Server) processNextBlock(ctx context.Context) (time.Duration, error) {
+	_, bestBlockMeta, err := s.blockchainClient.GetBestBlockHeader(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var nextHeight uint32
+
+	// tipHash is nil when no block has been indexed yet, indexing starts at the genesis block
+	tipHash, tipHeight, err := s.store.GetTip(ctx)
+	if err != nil {
+		if !errors.Is(err, errors.ErrNotFound) {
+			return 0, err
+		}
+	} else {
+		onBestChain, err := s.onBestChain(ctx, tipHash, tipHeight, bestBlockMeta.Height)
+		if err != nil {
+			return 0, err
+		}
+
+		if !onBestChain {
+			s.logger.Warnf("[ScriptHashIndex] Block %s at height %d is no longer on the best chain, unwinding", tipHash, tipHeight)
+
+			return 0, s.store.UnwindBlock(ctx, tipHash)
+		}
+
+		nextHeight = tipHeight + 1
+	}
+
+	if nextHeight > bestBlockMeta.Height {
+		return 0, errors.NewNotFoundError("[ScriptHashIndex] no block at height %d", nextHeight)
+	}
+
+	headers, _, err := s.blockchainClient.GetBlockHeadersByHeight(ctx, nextHeight, nextHeight)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(headers) != 1 {
+		return 0, errors.NewProcessingError("[ScriptHashIndex] 1 headers should have been returned, got %d", len(headers))
+	}
+
+	blockHash := headers[0].Hash()
+
+	if tipHash != nil && !headers[0].HashPrevBlock.IsEqual(tipHash) {
+		// the chain reorganized since the tip was checked, the tip is unwound on the next iteration
+		return 0, nil
+	}
+
+	block, err := s.blockchainClient.GetBlock(ctx, blockHash)
+	if err != nil {
+		return 0, err
+	}
+
+	persisted, err := blockfollower.SubtreeDataPersisted(ctx, s.subtreeStore, block)
+	if err != nil {
+		return 0, err
+	}
+
+	if !persisted {
+		s.logger.Infof("[ScriptHashIndex] Waiting for subtree data of block %s at height %d to be persisted", blockHash, nextHeight)
+		return persistWaitDuration, nil
+	}
+
+	indexBlock, err := s.buildIndexBlock(ctx, block, nextHeight)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = s.store.IndexBlock(ctx, indexBlock); err != nil {
+		return 0, err
+	}
+
+	s.logger.Infof("[ScriptHashIndex] Indexed block %s at height %d with %d outputs and %d spends", blockHash, nextHeight, len(indexBlock.Outputs), len(indexBlock.Spends))
+
+	return 0, nil
+}
+
+// onBestChain returns whether the block at the tip of the index is still on the best chain.
+func (s *Server) onBestChain(ctx context.Context, tipHash *chainhash.Hash, tipHeight uint32, bestHeight uint32) (bool, error) {
+	if tipHeight > bestHeight {
+		return false, nil
+	}
+
+	headers, _, err := s.blockchainClient.GetBlockHeadersByHeight(ctx, tipHeight, tipHeight)
+	if err != nil {
+		return false, err
+	}
+
+	return len(headers) == 1 && headers[0].Hash().IsEqual(tipHash), nil
+}
+
+// buildIndexBlock collects the outputs created and the outputs spent by the transactions of a block, from
+// its coinbase transaction and the subtree data of its subtrees.
+//
+// Outputs that are provably unspendable (OP_RETURN data outputs) are not indexed. The position of each
+// transaction in the block is recorded, so that the history of a script hash is returned in blockchain order.
+//
+// Parameters:
+// - ctx: Context for controlling the operation
+// - block: The block to index
+// - height: The height of the block
+//
+// Returns:
+// - *scripthash.Block: The outputs and spends of the block
+// - error: Any error encountered while reading the transactions
+func (s *Server) buildIndexBlock(ctx context.Context, block *model.Block, height uint32) (*scripthash.Block, error) {
+	indexBlock := &scripthash.Block{
+		Hash:   *block.Hash(),
+		Height: height,
+	}
+
+	if block.CoinbaseTx != nil {
+		addTx(indexBlock, block.CoinbaseTx, 0)
+	}
+
+	// the first transaction of the first subtree is the coinbase placeholder, at position 0
+	var txPos uint32
+
+	for _, subtreeHash := range block.Subtrees {
+		subtreeData, err := blockfollower.ReadSubtreeData(ctx, s.subtreeStore, subtreeHash)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, tx := range subtreeData.Txs {
+			pos := txPos
+			txPos++
+
+			// the coinbase placeholder has no transaction, and the coinbase has been added already
+			if tx == nil || tx.IsCoinbase() {
+				continue
+			}
+
+			addTx(indexBlock, tx, pos)
+		}
+	}
+
+	return indexBlock, nil
+}
+
+// addTx adds the outputs and, for non-coinbase transactions, the inputs of a transaction to the block.
+func addTx(indexBlock *scripthash.Block, tx *bt.Tx, txPos uint32) {
+	txID := *tx.TxIDChainHash()
+
+	for vout, output := range tx.Outputs {
+		if output.LockingScript == nil || output.LockingScript.IsData() {
+			continue
+		}
+
+		indexBlock.Outputs = append(indexBlock.Outputs, &scripthash.Output{
+			ScriptHash: scripthash.NewScriptHash(output.LockingScript.Bytes()),
+			TxID:       txID,
+			Vout:       uint32(vout), //nolint:gosec // number of outputs is bounded by the transaction size
+			Height:     indexBlock.Height,
+			TxPos:      txPos,
+			Satoshis:   output.Satoshis,
+		})
+	}
+
+	if tx.IsCoinbase() {
+		return
+	}
+
+	for vin, input := range tx.Inputs {
+		indexBlock.Spends = append(indexBlock.Spends, &scripthash.Spend{
+			TxID:          *input.PreviousTxIDChainHash(),
+			Vout:          input.PreviousTxOutIndex,
+			SpendingTxID:  txID,
+			SpendingVin:   uint32(vin), //nolint:gosec // number of inputs is bounded by the transaction size
+			SpendingTxPos: txPos,
+		})
+	}
+}
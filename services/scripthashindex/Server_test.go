@@ -0,0 +1,157 @@
+package scripthashindex
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/bsv-blockchain/go-chaincfg"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/model"
+	"github.com/bsv-blockchain/teranode/services/blockchain"
+	"github.com/bsv-blockchain/teranode/stores/blob/memory"
+	"github.com/bsv-blockchain/teranode/stores/scripthash"
+	"github.com/bsv-blockchain/teranode/ulogger"
+	"github.com/bsv-blockchain/teranode/util/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T, store scripthash.Store, blockchainClient blockchain.ClientI) *Server {
+	tSettings := test.CreateBaseTestSettings(t)
+
+	return New(context.Background(), ulogger.TestLogger{}, tSettings, memory.New(), store, blockchainClient)
+}
+
+func TestHealth_LivenessCheck(t *testing.T) {
+	s := &Server{}
+
+	status, message, err := s.Health(context.Background(), true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "OK", message)
+}
+
+// TestProcessNextBlock_Genesis indexes the genesis block when the index is empty.
+func TestProcessNextBlock_Genesis(t *testing.T) {
+	ctx := context.Background()
+
+	genesisBlock, err := model.NewBlockFromMsgBlock(chaincfg.RegressionNetParams.GenesisBlock, nil)
+	require.NoError(t, err)
+
+	mockBlockchainClient := &blockchain.Mock{}
+	mockBlockchainClient.On("GetBestBlockHeader", mock.Anything).Return(genesisBlock.Header, &model.BlockHeaderMeta{Height: 0}, nil)
+	mockBlockchainClient.On("GetBlockHeadersByHeight", mock.Anything, uint32(0), uint32(0)).Return([]*model.BlockHeader{genesisBlock.Header}, []*model.BlockHeaderMeta{{Height: 0}}, nil)
+	mockBlockchainClient.On("GetBlock", mock.Anything, genesisBlock.Hash()).Return(genesisBlock, nil)
+
+	coinbaseOutput := genesisBlock.CoinbaseTx.Outputs[0]
+
+	mockStore := &scripthash.Mock{}
+	mockStore.On("GetTip", mock.Anything).Return(nil, uint32(0), errors.NewNotFoundError("no block has been indexed"))
+	mockStore.On("IndexBlock", mock.Anything, mock.MatchedBy(func(block *scripthash.Block) bool {
+		return block.Hash.IsEqual(genesisBlock.Hash()) &&
+			block.Height == 0 &&
+			len(block.Outputs) == 1 &&
+			block.Outputs[0].ScriptHash == scripthash.NewScriptHash(coinbaseOutput.LockingScript.Bytes()) &&
+			block.Outputs[0].Satoshis == coinbaseOutput.Satoshis &&
+			len(block.Spends) == 0
+	})).Return(nil)
+
+	s := newTestServer(t, mockStore, mockBlockchainClient)
+
+	delay, err := s.processNextBlock(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), delay)
+
+	mockBlockchainClient.AssertExpectations(t)
+	mockStore.AssertExpectations(t)
+}
+
+// TestProcessNextBlock_UpToDate returns a not found error when the tip of the index is the best block.
+func TestProcessNextBlock_UpToDate(t *testing.T) {
+	ctx := context.Background()
+
+	header := &model.BlockHeader{
+		Version:        1,
+		HashPrevBlock:  &chainhash.Hash{},
+		HashMerkleRoot: &chainhash.Hash{},
+	}
+
+	mockBlockchainClient := &blockchain.Mock{}
+	mockBlockchainClient.On("GetBestBlockHeader", mock.Anything).Return(header, &model.BlockHeaderMeta{Height: 10}, nil)
+	mockBlockchainClient.On("GetBlockHeadersByHeight", mock.Anything, uint32(10), uint32(10)).Return([]*model.BlockHeader{header}, []*model.BlockHeaderMeta{{Height: 10}}, nil)
+
+	mockStore := &scripthash.Mock{}
+	mockStore.On("GetTip", mock.Anything).Return(header.Hash(), uint32(10), nil)
+
+	s := newTestServer(t, mockStore, mockBlockchainClient)
+
+	_, err := s.processNextBlock(ctx)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrNotFound))
+
+	mockBlockchainClient.AssertExpectations(t)
+	mockStore.AssertExpectations(t)
+}
+
+// TestProcessNextBlock_Reorg unwinds the tip of the index when it is no longer on the best chain.
+func TestProcessNextBlock_Reorg(t *testing.T) {
+	ctx := context.Background()
+
+	staleTipHash := chainhash.DoubleHashH([]byte("stale-tip"))
+	header := &model.BlockHeader{
+		Version:        1,
+		HashPrevBlock:  &chainhash.Hash{},
+		HashMerkleRoot: &chainhash.Hash{},
+	}
+
+	mockBlockchainClient := &blockchain.Mock{}
+	mockBlockchainClient.On("GetBestBlockHeader", mock.Anything).Return(header, &model.BlockHeaderMeta{Height: 10}, nil)
+	mockBlockchainClient.On("GetBlockHeadersByHeight", mock.Anything, uint32(5), uint32(5)).Return([]*model.BlockHeader{header}, []*model.BlockHeaderMeta{{Height: 5}}, nil)
+
+	mockStore := &scripthash.Mock{}
+	mockStore.On("GetTip", mock.Anything).Return(&staleTipHash, uint32(5), nil)
+	mockStore.On("UnwindBlock", mock.Anything, &staleTipHash).Return(nil)
+
+	s := newTestServer(t, mockStore, mockBlockchainClient)
+
+	delay, err := s.processNextBlock(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), delay)
+
+	mockBlockchainClient.AssertExpectations(t)
+	mockStore.AssertExpectations(t)
+}
+
+// TestProcessNextBlock_WaitingForSubtreeData waits for the block persister when the subtree data of a
+// block has not been written yet.
+func TestProcessNextBlock_WaitingForSubtreeData(t *testing.T) {
+	ctx := context.Background()
+
+	genesisBlock, err := model.NewBlockFromMsgBlock(chaincfg.RegressionNetParams.GenesisBlock, nil)
+	require.NoError(t, err)
+
+	subtreeHash := chainhash.DoubleHashH([]byte("subtree"))
+	genesisBlock.Subtrees = []*chainhash.Hash{&subtreeHash}
+
+	mockBlockchainClient := &blockchain.Mock{}
+	mockBlockchainClient.On("GetBestBlockHeader", mock.Anything).Return(genesisBlock.Header, &model.BlockHeaderMeta{Height: 0}, nil)
+	mockBlockchainClient.On("GetBlockHeadersByHeight", mock.Anything, uint32(0), uint32(0)).Return([]*model.BlockHeader{genesisBlock.Header}, []*model.BlockHeaderMeta{{Height: 0}}, nil)
+	mockBlockchainClient.On("GetBlock", mock.Anything, genesisBlock.Hash()).Return(genesisBlock, nil)
+
+	mockStore := &scripthash.Mock{}
+	mockStore.On("GetTip", mock.Anything).Return(nil, uint32(0), errors.NewNotFoundError("no block has been indexed"))
+
+	s := newTestServer(t, mockStore, mockBlockchainClient)
+
+	delay, err := s.processNextBlock(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, persistWaitDuration, delay)
+
+	mockBlockchainClient.AssertExpectations(t)
+	mockStore.AssertExpectations(t)
+}
@@ -0,0 +1,330 @@
+// Package utxopersister creates and maintains up-to-date Unspent Transaction Output (UTXO) file sets
+// for each block in the Teranode blockchain. Its primary function is to process the output of the
+// Block Persister service (utxo-additions and utxo-deletions) and generate complete UTXO set files.
+// The resulting UTXO set files can be exported and used to initialize the UTXO store in new Teranode instances.
+//
+// Commitment.go implements the rolling commitment to the UTXO set. The commitment is an elliptic curve
+// multiset hash (ECMH) of all unspent outputs, which does not depend on the order of the outputs and can be
+// updated with the outputs added and spent by each block, without rehashing the whole set. Two nodes holding
+// the same UTXO set at the same block have the same commitment, which makes UTXO snapshots verifiable.
+//
+// The commitment is stored in the utxo-headers file of the block, after an EOF marker that ends the block
+// index records, so readers of the block index records are not affected.
+package utxopersister
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"math/big"
+
+	"github.com/bsv-blockchain/go-bt/v2"
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+	"github.com/bsv-blockchain/teranode/services/blockchain"
+	"github.com/bsv-blockchain/teranode/services/legacy/bsvec"
+	"github.com/bsv-blockchain/teranode/stores/blob"
+	"github.com/bsv-blockchain/teranode/stores/blob/options"
+)
+
+// UTXOCommitment is the rolling commitment to the UTXO set at a block.
+// It holds the elliptic curve multiset of the unspent outputs, together with the statistics
+// reported by the gettxoutsetinfo RPC.
+//
+// The serialization format is as follows:
+// - Bytes 0-31: Block hash (32 bytes)
+// - Bytes 32-35: Block height (4 bytes, little-endian)
+// - Bytes 36-43: Number of transactions with unspent outputs (8 bytes, little-endian)
+// - Bytes 44-51: Number of unspent outputs (8 bytes, little-endian)
+// - Bytes 52-59: Total amount of the unspent outputs in satoshis (8 bytes, little-endian)
+// - Bytes 60-91: X coordinate of the multiset (32 bytes, big-endian)
+// - Bytes 92-123: Y coordinate of the multiset (32 bytes, big-endian)
+type UTXOCommitment struct {
+	// BlockHash contains the hash of the block the commitment is for
+	BlockHash chainhash.Hash
+
+	// BlockHeight represents the height of the block the commitment is for
+	BlockHeight uint32
+
+	// TxCount is the number of transactions with unspent outputs
+	TxCount uint64
+
+	// UTXOCount is the number of unspent outputs
+	UTXOCount uint64
+
+	// TotalAmount is the total amount of the unspent outputs in satoshis
+	TotalAmount uint64
+
+	// multiset is the elliptic curve multiset of the unspent outputs
+	multiset *bsvec.Multiset
+}
+
+// NewUTXOCommitment creates the commitment to an empty UTXO set.
+//
+// Returns:
+// - *UTXOCommitment: The commitment, with a MuHash of 32 zero bytes
+func NewUTXOCommitment() *UTXOCommitment {
+	return &UTXOCommitment{
+		multiset: bsvec.NewMultiset(bsvec.S256()),
+	}
+}
+
+// AddUTXOs adds the unspent outputs of a transaction to the commitment.
+//
+// Parameters:
+// - uw: The transaction the outputs belong to, only its ID, height and coinbase flag are used
+// - utxos: The outputs to add
+func (c *UTXOCommitment) AddUTXOs(uw *UTXOWrapper, utxos []*UTXO) {
+	for _, u := range utxos {
+		c.multiset.Add(commitmentElement(uw, u))
+		c.TotalAmount += u.Value
+	}
+}
+
+// RemoveUTXOs removes the spent outputs of a transaction from the commitment.
+//
+// Parameters:
+// - uw: The transaction the outputs belong to, only its ID, height and coinbase flag are used
+// - utxos: The outputs to remove, they must have been added before
+func (c *UTXOCommitment) RemoveUTXOs(uw *UTXOWrapper, utxos []*UTXO) {
+	for _, u := range utxos {
+		c.multiset.Remove(commitmentElement(uw, u))
+		c.TotalAmount -= u.Value
+	}
+}
+
+// MuHash returns the hash of the multiset, which is the same for equal UTXO sets.
+//
+// Returns:
+// - chainhash.Hash: The hash of the multiset, 32 zero bytes for an empty set
+func (c *UTXOCommitment) MuHash() chainhash.Hash {
+	return c.multiset.Hash()
+}
+
+// Serialise writes the commitment to the provided writer, in the format described on UTXOCommitment.
+//
+// Parameters:
+// - writer: io.Writer to which the serialized commitment will be written
+//
+// Returns:
+// - error: Any error encountered during the write operations
+func (c *UTXOCommitment) Serialise(writer io.Writer) error {
+	b := make([]byte, 0, 124)
+
+	b = append(b, c.BlockHash[:]...)
+	b = binary.LittleEndian.AppendUint32(b, c.BlockHeight)
+	b = binary.LittleEndian.AppendUint64(b, c.TxCount)
+	b = binary.LittleEndian.AppendUint64(b, c.UTXOCount)
+	b = binary.LittleEndian.AppendUint64(b, c.TotalAmount)
+
+	x, y := c.multiset.Point()
+
+	var coordinates [64]byte
+
+	x.FillBytes(coordinates[:32])
+	y.FillBytes(coordinates[32:])
+
+	b = append(b, coordinates[:]...)
+
+	_, err := writer.Write(b)
+
+	return err
+}
+
+// NewUTXOCommitmentFromReader reads a commitment in the format described on UTXOCommitment.
+//
+// Parameters:
+// - reader: io.Reader from which to read the serialized commitment
+//
+// Returns:
+// - *UTXOCommitment: The deserialized commitment
+// - error: io.EOF if the reader is at its end, or any error during deserialization
+func NewUTXOCommitmentFromReader(reader io.Reader) (*UTXOCommitment, error) {
+	var b [124]byte
+
+	if n, err := io.ReadFull(reader, b[:]); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+
+		return nil, errors.NewProcessingError("Expected 124 bytes, got %d", n, err)
+	}
+
+	c := &UTXOCommitment{
+		BlockHeight: binary.LittleEndian.Uint32(b[32:36]),
+		TxCount:     binary.LittleEndian.Uint64(b[36:44]),
+		UTXOCount:   binary.LittleEndian.Uint64(b[44:52]),
+		TotalAmount: binary.LittleEndian.Uint64(b[52:60]),
+		multiset:    bsvec.NewMultisetFromPoint(bsvec.S256(), new(big.Int).SetBytes(b[60:92]), new(big.Int).SetBytes(b[92:124])),
+	}
+
+	copy(c.BlockHash[:], b[:32])
+
+	return c, nil
+}
+
+// commitmentElement returns the serialized output that is added to the multiset:
+// - 32 bytes - txID
+// - 4 bytes - output index
+// - 4 bytes - encoded height and coinbase flag
+// - 8 bytes - value
+// - varint - length of script
+// - n bytes - script
+func commitmentElement(uw *UTXOWrapper, u *UTXO) []byte {
+	var flag uint32
+	if uw.Coinbase {
+		flag = 1
+	}
+
+	scriptLength := bt.VarInt(uint64(len(u.Script)))
+
+	b := make([]byte, 0, 48+scriptLength.Length()+len(u.Script))
+
+	b = append(b, uw.TxID[:]...)
+	b = binary.LittleEndian.AppendUint32(b, u.Index)
+	b = binary.LittleEndian.AppendUint32(b, (uw.Height<<1)|flag)
+	b = binary.LittleEndian.AppendUint64(b, u.Value)
+	b = append(b, scriptLength.Bytes()...)
+	b = append(b, u.Script...)
+
+	return b
+}
+
+// WriteUTXOCommitment writes the utxo-headers file of the block of the commitment, holding the block hash
+// and height, no block index records, the EOF marker and the commitment.
+//
+// Parameters:
+// - ctx: Context for controlling the storage operation
+// - store: Blob store the utxo-headers file is written to
+// - c: The commitment to write
+//
+// Returns:
+// - error: Any error encountered during the write operation
+func WriteUTXOCommitment(ctx context.Context, store blob.Store, c *UTXOCommitment) error {
+	var buf bytes.Buffer
+
+	header := fileformat.NewHeader(fileformat.FileTypeUtxoHeaders)
+
+	if err := header.Write(&buf); err != nil {
+		return errors.NewProcessingError("error writing utxo-headers header", err)
+	}
+
+	buf.Write(c.BlockHash[:])
+
+	if err := binary.Write(&buf, binary.LittleEndian, c.BlockHeight); err != nil {
+		return errors.NewProcessingError("error writing block height", err)
+	}
+
+	// the EOF marker ends the block index records
+	buf.Write(make([]byte, 32))
+
+	if err := c.Serialise(&buf); err != nil {
+		return errors.NewProcessingError("error serialising utxo commitment", err)
+	}
+
+	if err := store.Set(ctx, c.BlockHash[:], fileformat.FileTypeUtxoHeaders, buf.Bytes(), options.WithAllowOverwrite(true)); err != nil {
+		return errors.NewStorageError("error writing utxo commitment for block %s", c.BlockHash.String(), err)
+	}
+
+	return nil
+}
+
+// GetUTXOCommitment reads the commitment from the utxo-headers file of a block.
+//
+// Parameters:
+// - ctx: Context for controlling the storage operation
+// - store: Blob store the utxo-headers file is read from
+// - blockHash: Hash of the block
+//
+// Returns:
+// - *UTXOCommitment: The commitment to the UTXO set at the block
+// - error: A not found error if the file does not exist or holds no commitment, or any other error
+func GetUTXOCommitment(ctx context.Context, store blob.Store, blockHash *chainhash.Hash) (*UTXOCommitment, error) {
+	r, err := store.GetIoReader(ctx, blockHash[:], fileformat.FileTypeUtxoHeaders)
+	if err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			return nil, errors.NewNotFoundError("utxo-headers of block %s not found", blockHash.String(), err)
+		}
+
+		return nil, errors.NewStorageError("error getting utxo-headers reader of block %s", blockHash.String(), err)
+	}
+
+	defer r.Close()
+
+	reader := bufio.NewReader(r)
+
+	header, err := fileformat.ReadHeader(reader)
+	if err != nil {
+		return nil, errors.NewProcessingError("error reading utxo-headers header", err)
+	}
+
+	if header.FileType() != fileformat.FileTypeUtxoHeaders {
+		return nil, errors.NewProcessingError("invalid file type: %s", header.FileType())
+	}
+
+	// skip the last block hash and height, and the block index records
+	if _, err = reader.Discard(36); err != nil {
+		return nil, errors.NewProcessingError("error reading last block hash and height", err)
+	}
+
+	for {
+		if _, err = NewUTXOHeaderFromReader(reader); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return nil, errors.NewProcessingError("error reading utxo-headers", err)
+		}
+	}
+
+	c, err := NewUTXOCommitmentFromReader(reader)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.NewNotFoundError("utxo-headers of block %s hold no utxo commitment", blockHash.String())
+		}
+
+		return nil, err
+	}
+
+	if !c.BlockHash.IsEqual(blockHash) {
+		return nil, errors.NewProcessingError("utxo commitment is for block %s, expected %s", c.BlockHash.String(), blockHash.String())
+	}
+
+	return c, nil
+}
+
+// GetLatestUTXOCommitment reads the commitment to the UTXO set at the last block processed by the
+// UTXO persister.
+//
+// Parameters:
+// - ctx: Context for controlling the operations
+// - store: Blob store the UTXO persister writes to, the block store
+// - blockchainClient: Client used to look up the hash of the last processed block
+//
+// Returns:
+// - *UTXOCommitment: The commitment to the UTXO set at the last processed block
+// - error: A not found error if no block has been processed or it holds no commitment, or any other error
+func GetLatestUTXOCommitment(ctx context.Context, store blob.Store, blockchainClient blockchain.ClientI) (*UTXOCommitment, error) {
+	height, err := readLastHeight(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+
+	if height == 0 {
+		return nil, errors.NewNotFoundError("the utxo persister has not processed any blocks")
+	}
+
+	headers, _, err := blockchainClient.GetBlockHeadersByHeight(ctx, height, height)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(headers) != 1 {
+		return nil, errors.NewProcessingError("1 headers should have been returned, got %d", len(headers))
+	}
+
+	return GetUTXOCommitment(ctx, store, headers[0].Hash())
+}
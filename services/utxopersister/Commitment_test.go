@@ -0,0 +1,181 @@
+// Package utxopersister provides functionality for managing UTXO (Unspent Transaction Output) persistence.
+package utxopersister
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+	"github.com/bsv-blockchain/teranode/stores/blob/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testCommitmentWrappers() (*UTXOWrapper, *UTXOWrapper) {
+	uw1 := &UTXOWrapper{
+		TxID:     chainhash.HashH([]byte("tx1")),
+		Height:   10,
+		Coinbase: true,
+		UTXOs: []*UTXO{
+			{Index: 0, Value: 5000000000, Script: []byte{0x76, 0xa9}},
+		},
+	}
+
+	uw2 := &UTXOWrapper{
+		TxID:   chainhash.HashH([]byte("tx2")),
+		Height: 11,
+		UTXOs: []*UTXO{
+			{Index: 0, Value: 1000, Script: []byte{0x51}},
+			{Index: 2, Value: 2000, Script: []byte{0x52}},
+		},
+	}
+
+	return uw1, uw2
+}
+
+func TestUTXOCommitment(t *testing.T) {
+	uw1, uw2 := testCommitmentWrappers()
+
+	t.Run("empty set", func(t *testing.T) {
+		assert.Equal(t, chainhash.Hash{}, NewUTXOCommitment().MuHash())
+	})
+
+	t.Run("order independent", func(t *testing.T) {
+		c1 := NewUTXOCommitment()
+		c1.AddUTXOs(uw1, uw1.UTXOs)
+		c1.AddUTXOs(uw2, uw2.UTXOs)
+
+		c2 := NewUTXOCommitment()
+		c2.AddUTXOs(uw2, []*UTXO{uw2.UTXOs[1]})
+		c2.AddUTXOs(uw1, uw1.UTXOs)
+		c2.AddUTXOs(uw2, []*UTXO{uw2.UTXOs[0]})
+
+		assert.Equal(t, c1.MuHash(), c2.MuHash())
+		assert.Equal(t, uint64(5000003000), c1.TotalAmount)
+		assert.NotEqual(t, chainhash.Hash{}, c1.MuHash())
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		c1 := NewUTXOCommitment()
+		c1.AddUTXOs(uw1, uw1.UTXOs)
+
+		c2 := NewUTXOCommitment()
+		c2.AddUTXOs(uw1, uw1.UTXOs)
+		c2.AddUTXOs(uw2, uw2.UTXOs)
+		c2.RemoveUTXOs(uw2, uw2.UTXOs)
+
+		assert.Equal(t, c1.MuHash(), c2.MuHash())
+		assert.Equal(t, c1.TotalAmount, c2.TotalAmount)
+
+		// removing all outputs returns to the empty set
+		c2.RemoveUTXOs(uw1, uw1.UTXOs)
+
+		assert.Equal(t, chainhash.Hash{}, c2.MuHash())
+		assert.Equal(t, uint64(0), c2.TotalAmount)
+	})
+
+	t.Run("height and coinbase are committed", func(t *testing.T) {
+		c1 := NewUTXOCommitment()
+		c1.AddUTXOs(uw1, uw1.UTXOs)
+
+		c2 := NewUTXOCommitment()
+		c2.AddUTXOs(&UTXOWrapper{TxID: uw1.TxID, Height: uw1.Height}, uw1.UTXOs)
+
+		assert.NotEqual(t, c1.MuHash(), c2.MuHash())
+	})
+
+	t.Run("serialise", func(t *testing.T) {
+		c1 := NewUTXOCommitment()
+		c1.BlockHash = chainhash.HashH([]byte("block"))
+		c1.BlockHeight = 11
+		c1.TxCount = 2
+		c1.UTXOCount = 3
+		c1.AddUTXOs(uw1, uw1.UTXOs)
+		c1.AddUTXOs(uw2, uw2.UTXOs)
+
+		var buf bytes.Buffer
+
+		require.NoError(t, c1.Serialise(&buf))
+		assert.Equal(t, 124, buf.Len())
+
+		c2, err := NewUTXOCommitmentFromReader(&buf)
+		require.NoError(t, err)
+
+		assert.Equal(t, c1.BlockHash, c2.BlockHash)
+		assert.Equal(t, c1.BlockHeight, c2.BlockHeight)
+		assert.Equal(t, c1.TxCount, c2.TxCount)
+		assert.Equal(t, c1.UTXOCount, c2.UTXOCount)
+		assert.Equal(t, c1.TotalAmount, c2.TotalAmount)
+		assert.Equal(t, c1.MuHash(), c2.MuHash())
+
+		// the deserialized commitment can be rolled forward
+		c1.RemoveUTXOs(uw1, uw1.UTXOs)
+		c2.RemoveUTXOs(uw1, uw1.UTXOs)
+
+		assert.Equal(t, c1.MuHash(), c2.MuHash())
+
+		_, err = NewUTXOCommitmentFromReader(&buf)
+		assert.ErrorIs(t, err, io.EOF)
+	})
+}
+
+func TestWriteAndGetUTXOCommitment(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+
+	uw1, _ := testCommitmentWrappers()
+
+	c := NewUTXOCommitment()
+	c.BlockHash = chainhash.HashH([]byte("block"))
+	c.BlockHeight = 10
+	c.TxCount = 1
+	c.UTXOCount = 1
+	c.AddUTXOs(uw1, uw1.UTXOs)
+
+	_, err := GetUTXOCommitment(ctx, store, &c.BlockHash)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrNotFound))
+
+	require.NoError(t, WriteUTXOCommitment(ctx, store, c))
+
+	read, err := GetUTXOCommitment(ctx, store, &c.BlockHash)
+	require.NoError(t, err)
+
+	assert.Equal(t, c.BlockHeight, read.BlockHeight)
+	assert.Equal(t, c.TotalAmount, read.TotalAmount)
+	assert.Equal(t, c.MuHash(), read.MuHash())
+
+	// readers of the block index records stop at the EOF marker
+	b, err := store.Get(ctx, c.BlockHash[:], fileformat.FileTypeUtxoHeaders)
+	require.NoError(t, err)
+
+	r := bytes.NewReader(b)
+
+	_, err = fileformat.ReadHeader(r)
+	require.NoError(t, err)
+
+	_, err = r.Seek(36, io.SeekCurrent)
+	require.NoError(t, err)
+
+	_, err = NewUTXOHeaderFromReader(r)
+	assert.ErrorIs(t, err, io.EOF)
+
+	// a utxo-headers file without a commitment
+	other := chainhash.HashH([]byte("other"))
+
+	var buf bytes.Buffer
+
+	require.NoError(t, fileformat.NewHeader(fileformat.FileTypeUtxoHeaders).Write(&buf))
+	buf.Write(other[:])
+	buf.Write([]byte{1, 0, 0, 0})
+
+	require.NoError(t, store.Set(ctx, other[:], fileformat.FileTypeUtxoHeaders, buf.Bytes()))
+
+	_, err = GetUTXOCommitment(ctx, store, &other)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrNotFound))
+}
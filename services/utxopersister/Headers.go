@@ -112,6 +112,11 @@ func NewUTXOHeaderFromReader(reader io.Reader) (*BlockIndex, error) {
 		return nil, errors.NewProcessingError("Expected 32 bytes, got %d", n, err)
 	}
 
+	if hash.IsEqual(&chainhash.Hash{}) {
+		// EOF marker encountered
+		return &BlockIndex{}, io.EOF
+	}
+
 	var heightBytes [4]byte
 	if n, err := io.ReadFull(reader, heightBytes[:]); err != nil || n != 4 {
 		return nil, errors.NewProcessingError("Expected 4 bytes, got %d", n, err)
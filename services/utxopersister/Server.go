@@ -505,6 +505,11 @@ func (s *Server) processNextBlock(ctx context.Context) (time.Duration, error) {
 		if err := s.blockStore.Del(ctx, lastWrittenUTXOSetHash[:], fileformat.FileTypeUtxoSet+".sha256"); err != nil {
 			return 0, errors.NewProcessingError("[UTXOPersister] Error deleting UTXOSet for block %s height %d", lastWrittenUTXOSetHash, c.firstBlockHeight, err)
 		}
+
+		// sets written before the utxo commitment was introduced have no utxo-headers
+		if err := s.blockStore.Del(ctx, lastWrittenUTXOSetHash[:], fileformat.FileTypeUtxoHeaders); err != nil && !errors.Is(err, errors.ErrNotFound) {
+			return 0, errors.NewProcessingError("[UTXOPersister] Error deleting UTXO commitment for block %s height %d", lastWrittenUTXOSetHash, c.firstBlockHeight, err)
+		}
 	}
 
 	return 0, s.writeLastHeight(ctx, s.lastHeight)
@@ -528,11 +533,25 @@ func (s *Server) processNextBlock(ctx context.Context) (time.Duration, error) {
 // indicating that processing should start from the genesis block.
 // Other errors during reading or parsing are returned to the caller.
 func (s *Server) readLastHeight(ctx context.Context) (uint32, error) {
+	height, err := readLastHeight(ctx, s.blockStore)
+	if err != nil {
+		return 0, err
+	}
+
+	if height == 0 {
+		s.logger.Warnf("lastProcessed.dat does not exist, starting from height 0")
+	}
+
+	return height, nil
+}
+
+// readLastHeight reads the last processed block height from the lastProcessed.dat file in the store,
+// it returns 0 if the file does not exist.
+func readLastHeight(ctx context.Context, store blob.Store) (uint32, error) {
 	// Read the file content as a byte slice
-	b, err := s.blockStore.Get(ctx, nil, fileformat.FileTypeDat, options.WithFilename("lastProcessed"))
+	b, err := store.Get(ctx, nil, fileformat.FileTypeDat, options.WithFilename("lastProcessed"))
 	if err != nil {
 		if errors.Is(err, errors.ErrNotFound) {
-			s.logger.Warnf("lastProcessed.dat does not exist, starting from height 0")
 			return 0, nil
 		}
 
@@ -0,0 +1,407 @@
+// Package utxopersister creates and maintains up-to-date Unspent Transaction Output (UTXO) file sets
+// for each block in the Teranode blockchain. Its primary function is to process the output of the
+// Block Persister service (utxo-additions and utxo-deletions) and generate complete UTXO set files.
+// The resulting UTXO set files can be exported and used to initialize the UTXO store in new Teranode instances.
+//
+// Snapshot.go creates the UTXO set of a block from the records of a UTXO store instead of from the files of
+// the Block Persister, for when those files are missing. The UTXO store holds the current state, which is
+// rolled back to the block: transactions that are unmined or only mined in blocks that are not on the longest
+// chain up to the block are left out, and outputs spent by transactions mined after the block are kept.
+package utxopersister
+
+import (
+	"context"
+	"encoding/binary"
+
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	safeconversion "github.com/bsv-blockchain/go-safe-conversion"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/model"
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+	"github.com/bsv-blockchain/teranode/services/utxopersister/filestorer"
+	"github.com/bsv-blockchain/teranode/settings"
+	"github.com/bsv-blockchain/teranode/stores/blob"
+	"github.com/bsv-blockchain/teranode/stores/utxo"
+	"github.com/bsv-blockchain/teranode/stores/utxo/fields"
+	"github.com/bsv-blockchain/teranode/ulogger"
+)
+
+// snapshotHeadersBatchSize is the number of block headers read from the blockchain at once
+const snapshotHeadersBatchSize = 10_000
+
+// snapshotSpenderCacheSize is the maximum number of spending transactions whose state is cached
+const snapshotSpenderCacheSize = 100_000
+
+// snapshotChain abstracts the access to the blockchain needed to create a snapshot, it is implemented by
+// both the blockchain client and the blockchain store.
+type snapshotChain interface {
+	GetBestBlockHeader(ctx context.Context) (*model.BlockHeader, *model.BlockHeaderMeta, error)
+	GetBlockHeader(ctx context.Context, blockHash *chainhash.Hash) (*model.BlockHeader, *model.BlockHeaderMeta, error)
+	GetBlockHeadersByHeight(ctx context.Context, startHeight, endHeight uint32) ([]*model.BlockHeader, []*model.BlockHeaderMeta, error)
+}
+
+// snapshot holds the state of the creation of a UTXO set snapshot.
+type snapshot struct {
+	utxoStore utxo.Store
+
+	// blockHeights maps the IDs of the blocks on the longest chain up to the snapshot block to their height
+	blockHeights map[uint32]uint32
+
+	// blockIndexes are the blocks on the longest chain up to the snapshot block, in height order
+	blockIndexes []*BlockIndex
+
+	// spent caches whether a spending transaction was mined on the longest chain up to the snapshot block
+	spent map[chainhash.Hash]bool
+}
+
+// CreateUTXOSetSnapshot creates the utxo-set and utxo-headers files of a block from the records of a UTXO store.
+// The files have the same format as the files created by the UTXO persister, so they can be loaded by the seeder
+// and the UTXO persister can continue from them.
+//
+// The records of the UTXO store are read as of the block: transactions that are unmined or not mined on the
+// longest chain up to the block are left out, as are outputs spent by transactions that were. Records are
+// deleted from the UTXO store a retention period after all their outputs were spent, so the block must be
+// within the UTXO store block height retention of the best block.
+//
+// Besides the commitment to the UTXO set, the utxo-headers file holds the block index records of all the blocks
+// up to the block, which the seeder stores in the blockchain store.
+//
+// Parameters:
+// - ctx: Context for controlling the operation
+// - logger: Logger for progress messages
+// - tSettings: Configuration settings, for the retention of the UTXO store and the file storer buffer size
+// - store: Blob store the files are written to
+// - utxoStore: UTXO store the records are read from, it must implement utxo.RecordExporter
+// - chain: Blockchain client or store the longest chain is read from
+// - blockHash: Hash of the block to create the snapshot of, it must be on the longest chain
+// - overwrite: Whether to replace existing files of the block
+//
+// Returns:
+// - *UTXOCommitment: The commitment to the UTXO set of the snapshot, including its statistics
+// - error: Any error encountered while creating the snapshot
+func CreateUTXOSetSnapshot(ctx context.Context, logger ulogger.Logger, tSettings *settings.Settings, store blob.Store, utxoStore utxo.Store,
+	chain snapshotChain, blockHash *chainhash.Hash, overwrite bool) (*UTXOCommitment, error) {
+	exporter, ok := utxoStore.(utxo.RecordExporter)
+	if !ok {
+		return nil, errors.NewInvalidArgumentError("UTXO store %T does not support exporting records", utxoStore)
+	}
+
+	blockHeader, blockMeta, err := chain.GetBlockHeader(ctx, blockHash)
+	if err != nil {
+		return nil, errors.NewProcessingError("error getting block header %s", blockHash, err)
+	}
+
+	s := &snapshot{
+		utxoStore: utxoStore,
+		spent:     make(map[chainhash.Hash]bool),
+	}
+
+	bestHeight, err := s.readChain(ctx, chain, blockHash, blockMeta.Height)
+	if err != nil {
+		return nil, err
+	}
+
+	if retention := tSettings.GetUtxoStoreBlockHeightRetention(); bestHeight-blockMeta.Height > retention {
+		return nil, errors.NewInvalidArgumentError("block %s at height %d is more than the UTXO store block height retention of %d blocks below the best block at height %d, spent records may have been deleted",
+			blockHash, blockMeta.Height, retention, bestHeight)
+	}
+
+	for _, fileType := range []fileformat.FileType{fileformat.FileTypeUtxoSet, fileformat.FileTypeUtxoHeaders} {
+		exists, err := store.Exists(ctx, blockHash[:], fileType)
+		if err != nil {
+			return nil, errors.NewStorageError("error checking if %s.%s exists", blockHash, fileType, err)
+		}
+
+		if !exists {
+			continue
+		}
+
+		if !overwrite {
+			return nil, errors.NewBlobAlreadyExistsError("%s.%s already exists", blockHash, fileType)
+		}
+
+		if err = store.Del(ctx, blockHash[:], fileType); err != nil {
+			return nil, errors.NewStorageError("error deleting %s.%s", blockHash, fileType, err)
+		}
+	}
+
+	logger.Infof("[CreateUTXOSetSnapshot] Creating UTXOSet snapshot for block %s height %d", blockHash, blockMeta.Height)
+
+	commitment, err := s.writeUTXOSet(ctx, logger, tSettings, store, exporter, blockHash, blockMeta.Height, blockHeader.HashPrevBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.writeHeaders(ctx, logger, tSettings, store, commitment); err != nil {
+		return nil, err
+	}
+
+	logger.Infof("[CreateUTXOSetSnapshot] UTXO commitment for block %s height %d: %s (%d utxos, %d satoshis)", blockHash, blockMeta.Height, commitment.MuHash(), commitment.UTXOCount, commitment.TotalAmount)
+
+	return commitment, nil
+}
+
+// readChain reads the blocks of the longest chain up to the snapshot block and returns the height of the best
+// block. It fails if the snapshot block is not on the longest chain.
+func (s *snapshot) readChain(ctx context.Context, chain snapshotChain, blockHash *chainhash.Hash, blockHeight uint32) (uint32, error) {
+	s.blockHeights = make(map[uint32]uint32, blockHeight+1)
+	s.blockIndexes = make([]*BlockIndex, 0, blockHeight+1)
+
+	for start := uint32(0); start <= blockHeight; start += snapshotHeadersBatchSize {
+		end := min(start+snapshotHeadersBatchSize-1, blockHeight)
+
+		headers, metas, err := chain.GetBlockHeadersByHeight(ctx, start, end)
+		if err != nil {
+			return 0, errors.NewProcessingError("error getting block headers from height %d to %d", start, end, err)
+		}
+
+		if len(headers) != int(end-start+1) || len(metas) != len(headers) {
+			return 0, errors.NewProcessingError("%d headers should have been returned from height %d to %d, got %d", end-start+1, start, end, len(headers))
+		}
+
+		for i, header := range headers {
+			s.blockHeights[metas[i].ID] = metas[i].Height
+
+			s.blockIndexes = append(s.blockIndexes, &BlockIndex{
+				Hash:        header.Hash(),
+				Height:      metas[i].Height,
+				TxCount:     metas[i].TxCount,
+				BlockHeader: header,
+			})
+		}
+	}
+
+	if last := s.blockIndexes[len(s.blockIndexes)-1]; !last.Hash.IsEqual(blockHash) {
+		return 0, errors.NewInvalidArgumentError("block %s is not on the longest chain, block %s is at height %d", blockHash, last.Hash, blockHeight)
+	}
+
+	_, bestMeta, err := chain.GetBestBlockHeader(ctx)
+	if err != nil {
+		return 0, errors.NewProcessingError("error getting best block header", err)
+	}
+
+	return max(bestMeta.Height, blockHeight), nil
+}
+
+// writeUTXOSet writes the utxo-set file of the snapshot block, in the format of UTXOSet.CreateUTXOSet, and
+// returns the commitment to the set.
+func (s *snapshot) writeUTXOSet(ctx context.Context, logger ulogger.Logger, tSettings *settings.Settings, store blob.Store, exporter utxo.RecordExporter,
+	blockHash *chainhash.Hash, blockHeight uint32, previousBlockHash *chainhash.Hash) (*UTXOCommitment, error) {
+	storer, err := filestorer.NewFileStorer(ctx, logger, tSettings, store, blockHash[:], fileformat.FileTypeUtxoSet)
+	if err != nil {
+		return nil, errors.NewStorageError("error creating utxo-set file", err)
+	}
+
+	if _, err = storer.Write(blockHash[:]); err != nil {
+		return nil, errors.NewProcessingError("Couldn't write block hash to file", err)
+	}
+
+	if err = binary.Write(storer, binary.LittleEndian, blockHeight); err != nil {
+		return nil, errors.NewProcessingError("Couldn't write block height to file", err)
+	}
+
+	if _, err = storer.Write(previousBlockHash[:]); err != nil {
+		return nil, errors.NewProcessingError("Couldn't write previous block hash to file", err)
+	}
+
+	it, err := exporter.ExportRecords(ctx, "")
+	if err != nil {
+		return nil, errors.NewStorageError("error exporting records from the UTXO store", err)
+	}
+
+	defer func() {
+		_ = it.Close()
+	}()
+
+	commitment := NewUTXOCommitment()
+	records := 0
+
+	for {
+		record, err := it.Next(ctx)
+		if err != nil {
+			return nil, errors.NewStorageError("error exporting records from the UTXO store", err)
+		}
+
+		if record == nil {
+			break
+		}
+
+		records++
+
+		if records%1_000_000 == 0 {
+			logger.Infof("[CreateUTXOSetSnapshot] Read %d records, %d transactions with unspent outputs", records, commitment.TxCount)
+		}
+
+		utxoWrapper, err := s.utxoWrapper(ctx, record)
+		if err != nil {
+			return nil, err
+		}
+
+		// Only write the UTXOWrapper if the transaction has unspent outputs at the block
+		if utxoWrapper == nil || len(utxoWrapper.UTXOs) == 0 {
+			continue
+		}
+
+		if _, err = storer.Write(utxoWrapper.Bytes()); err != nil {
+			return nil, errors.NewStorageError("error writing utxo wrapper", err)
+		}
+
+		commitment.AddUTXOs(utxoWrapper, utxoWrapper.UTXOs)
+		commitment.TxCount++
+		commitment.UTXOCount += uint64(len(utxoWrapper.UTXOs))
+	}
+
+	// Write the number of transactions and utxos
+	b := make([]byte, 16)
+	binary.LittleEndian.PutUint64(b[:8], commitment.TxCount)
+	binary.LittleEndian.PutUint64(b[8:], commitment.UTXOCount)
+
+	if _, err = storer.Write(b); err != nil {
+		return nil, errors.NewStorageError("error writing number of transactions and UTXOs", err)
+	}
+
+	if err = storer.Close(ctx); err != nil {
+		return nil, errors.NewStorageError("error flushing utxoset writer", err)
+	}
+
+	commitment.BlockHash = *blockHash
+	commitment.BlockHeight = blockHeight
+
+	return commitment, nil
+}
+
+// utxoWrapper returns the outputs of the transaction of the record that are unspent at the snapshot block,
+// or nil when the transaction is not mined on the longest chain up to the block.
+func (s *snapshot) utxoWrapper(ctx context.Context, record *utxo.Record) (*UTXOWrapper, error) {
+	height, mined := s.minedHeight(record.BlockIDs)
+	if !mined {
+		return nil, nil
+	}
+
+	if record.Tx == nil {
+		return nil, errors.NewProcessingError("record of transaction %s has no transaction", record.TxID)
+	}
+
+	utxoWrapper := &UTXOWrapper{
+		TxID:     record.TxID,
+		Height:   height,
+		Coinbase: record.IsCoinbase,
+		UTXOs:    make([]*UTXO, 0),
+	}
+
+	for i, output := range record.Tx.Outputs {
+		if output == nil || !utxo.ShouldStoreOutputAsUTXO(record.IsCoinbase, output, height) {
+			continue
+		}
+
+		if i < len(record.Utxos) && record.Utxos[i] != nil && record.Utxos[i].SpendingData != nil {
+			spent, err := s.isSpent(ctx, record.Utxos[i].SpendingData.TxID)
+			if err != nil {
+				return nil, err
+			}
+
+			if spent {
+				continue
+			}
+		}
+
+		iUint32, err := safeconversion.IntToUint32(i)
+		if err != nil {
+			return nil, err
+		}
+
+		utxoWrapper.UTXOs = append(utxoWrapper.UTXOs, &UTXO{
+			iUint32,
+			output.Satoshis,
+			*output.LockingScript,
+		})
+	}
+
+	return utxoWrapper, nil
+}
+
+// minedHeight returns the height of the block on the longest chain up to the snapshot block the transaction
+// was mined in, and whether there is one.
+func (s *snapshot) minedHeight(blockIDs []uint32) (uint32, bool) {
+	for _, blockID := range blockIDs {
+		if height, ok := s.blockHeights[blockID]; ok {
+			return height, true
+		}
+	}
+
+	return 0, false
+}
+
+// isSpent returns whether the spending transaction was mined on the longest chain up to the snapshot block.
+// A spending transaction that is no longer in the UTXO store was deleted a retention period after it was
+// mined, which, as the snapshot block is within the retention, was before the block.
+func (s *snapshot) isSpent(ctx context.Context, spendingTxID *chainhash.Hash) (bool, error) {
+	if spent, ok := s.spent[*spendingTxID]; ok {
+		return spent, nil
+	}
+
+	var spent bool
+
+	txMeta, err := s.utxoStore.Get(ctx, spendingTxID, fields.BlockIDs)
+	if err != nil {
+		if !errors.Is(err, errors.ErrTxNotFound) {
+			return false, errors.NewStorageError("error getting spending transaction %s", spendingTxID, err)
+		}
+
+		spent = true
+	} else {
+		_, spent = s.minedHeight(txMeta.BlockIDs)
+	}
+
+	if len(s.spent) >= snapshotSpenderCacheSize {
+		clear(s.spent)
+	}
+
+	s.spent[*spendingTxID] = spent
+
+	return spent, nil
+}
+
+// writeHeaders writes the utxo-headers file of the snapshot block, in the format of WriteUTXOCommitment with
+// the block index records of the longest chain up to the block before the EOF marker.
+func (s *snapshot) writeHeaders(ctx context.Context, logger ulogger.Logger, tSettings *settings.Settings, store blob.Store, c *UTXOCommitment) error {
+	storer, err := filestorer.NewFileStorer(ctx, logger, tSettings, store, c.BlockHash[:], fileformat.FileTypeUtxoHeaders)
+	if err != nil {
+		return errors.NewStorageError("error creating utxo-headers file", err)
+	}
+
+	header := fileformat.NewHeader(fileformat.FileTypeUtxoHeaders)
+
+	if err = header.Write(storer); err != nil {
+		return errors.NewProcessingError("error writing utxo-headers header", err)
+	}
+
+	if _, err = storer.Write(c.BlockHash[:]); err != nil {
+		return errors.NewProcessingError("error writing block hash", err)
+	}
+
+	if err = binary.Write(storer, binary.LittleEndian, c.BlockHeight); err != nil {
+		return errors.NewProcessingError("error writing block height", err)
+	}
+
+	for _, blockIndex := range s.blockIndexes {
+		if err = blockIndex.Serialise(storer); err != nil {
+			return errors.NewProcessingError("error writing block index of block %s", blockIndex.Hash, err)
+		}
+	}
+
+	// the EOF marker ends the block index records
+	if _, err = storer.Write(make([]byte, 32)); err != nil {
+		return errors.NewProcessingError("error writing EOF marker", err)
+	}
+
+	if err = c.Serialise(storer); err != nil {
+		return errors.NewProcessingError("error serialising utxo commitment", err)
+	}
+
+	if err = storer.Close(ctx); err != nil {
+		return errors.NewStorageError("error flushing utxo-headers writer", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,237 @@
+package utxopersister
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/url"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bt/v2"
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/model"
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+	"github.com/bsv-blockchain/teranode/stores/blob/memory"
+	"github.com/bsv-blockchain/teranode/stores/utxo"
+	"github.com/bsv-blockchain/teranode/stores/utxo/leveldb"
+	"github.com/bsv-blockchain/teranode/ulogger"
+	"github.com/bsv-blockchain/teranode/util/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// snapshotTestChain is a blockchain of headers by height, with blocks that are not on the longest chain
+type snapshotTestChain struct {
+	headers []*model.BlockHeader
+	metas   []*model.BlockHeaderMeta
+	forks   map[chainhash.Hash]*model.BlockHeaderMeta
+}
+
+func newSnapshotTestChain(length int) *snapshotTestChain {
+	c := &snapshotTestChain{
+		forks: make(map[chainhash.Hash]*model.BlockHeaderMeta),
+	}
+
+	previousHash := &chainhash.Hash{}
+
+	for i := 0; i < length; i++ {
+		header := createTestBlockHeader()
+		header.HashPrevBlock = previousHash
+		header.Nonce = uint32(i) // nolint:gosec
+
+		meta := createTestBlockHeaderMeta(uint32(i)) // nolint:gosec
+		meta.ID = uint32(i)                          // nolint:gosec
+
+		c.headers = append(c.headers, header)
+		c.metas = append(c.metas, meta)
+
+		previousHash = header.Hash()
+	}
+
+	return c
+}
+
+func (c *snapshotTestChain) GetBestBlockHeader(_ context.Context) (*model.BlockHeader, *model.BlockHeaderMeta, error) {
+	return c.headers[len(c.headers)-1], c.metas[len(c.metas)-1], nil
+}
+
+func (c *snapshotTestChain) GetBlockHeader(_ context.Context, blockHash *chainhash.Hash) (*model.BlockHeader, *model.BlockHeaderMeta, error) {
+	for i, header := range c.headers {
+		if header.Hash().IsEqual(blockHash) {
+			return header, c.metas[i], nil
+		}
+	}
+
+	return nil, nil, errors.NewBlockNotFoundError("block %s not found", blockHash)
+}
+
+func (c *snapshotTestChain) GetBlockHeadersByHeight(_ context.Context, startHeight, endHeight uint32) ([]*model.BlockHeader, []*model.BlockHeaderMeta, error) {
+	if int(startHeight) >= len(c.headers) {
+		return []*model.BlockHeader{}, []*model.BlockHeaderMeta{}, nil
+	}
+
+	end := min(int(endHeight)+1, len(c.headers))
+
+	return c.headers[startHeight:end], c.metas[startHeight:end], nil
+}
+
+// newSnapshotSpendingTx returns a transaction spending the output of the parent to a single output
+func newSnapshotSpendingTx(t *testing.T, parent *bt.Tx, vout uint32) *bt.Tx {
+	spendingTx := bt.NewTx()
+
+	require.NoError(t, spendingTx.From(parent.TxIDChainHash().String(), vout, parent.Outputs[vout].LockingScript.String(), parent.Outputs[vout].Satoshis))
+	require.NoError(t, spendingTx.PayToAddress("1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", parent.Outputs[vout].Satoshis/2))
+
+	return spendingTx
+}
+
+func TestCreateUTXOSetSnapshot(t *testing.T) {
+	ctx := context.Background()
+
+	tSettings := test.CreateBaseTestSettings(t)
+	tSettings.GlobalBlockHeightRetention = 10
+	tSettings.UtxoStore.BlockHeightRetentionAdjustment = 0
+
+	utxoStore, err := leveldb.New(ctx, ulogger.TestLogger{}, tSettings, &url.URL{Scheme: "leveldb", Path: t.TempDir()})
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = utxoStore.Close()
+	})
+
+	// blocks 0 to 3 are on the longest chain, the snapshot is of block 2, block 9 is not on the longest chain
+	chain := newSnapshotTestChain(4)
+	blockHash := chain.headers[2].Hash()
+
+	parentTx := tx.Clone()
+	spentBefore := newSnapshotSpendingTx(t, parentTx, 0)
+	spentAfter := newSnapshotSpendingTx(t, parentTx, 1)
+	spentUnmined := newSnapshotSpendingTx(t, parentTx, 2)
+	spentOnFork := newSnapshotSpendingTx(t, parentTx, 3)
+
+	_, err = utxoStore.Create(ctx, parentTx, 1, utxo.WithMinedBlockInfo(utxo.MinedBlockInfo{BlockID: 1, BlockHeight: 1}))
+	require.NoError(t, err)
+
+	for _, spendingTx := range []struct {
+		tx        *bt.Tx
+		blockInfo []utxo.MinedBlockInfo
+	}{
+		{spentBefore, []utxo.MinedBlockInfo{{BlockID: 2, BlockHeight: 2}}},
+		{spentAfter, []utxo.MinedBlockInfo{{BlockID: 3, BlockHeight: 3}}},
+		{spentUnmined, nil},
+		{spentOnFork, []utxo.MinedBlockInfo{{BlockID: 9, BlockHeight: 2}}},
+	} {
+		_, err = utxoStore.Create(ctx, spendingTx.tx, 2, utxo.WithMinedBlockInfo(spendingTx.blockInfo...))
+		require.NoError(t, err)
+
+		_, err = utxoStore.Spend(ctx, spendingTx.tx, 2)
+		require.NoError(t, err)
+	}
+
+	store := memory.New()
+
+	commitment, err := CreateUTXOSetSnapshot(ctx, ulogger.TestLogger{}, tSettings, store, utxoStore, chain, blockHash, false)
+	require.NoError(t, err)
+
+	// the parent keeps the outputs spent after the block, by an unmined transaction and on a fork
+	expected := map[chainhash.Hash]*UTXOWrapper{
+		*parentTx.TxIDChainHash():    {TxID: *parentTx.TxIDChainHash(), Height: 1},
+		*spentBefore.TxIDChainHash(): {TxID: *spentBefore.TxIDChainHash(), Height: 2},
+	}
+
+	for i, output := range parentTx.Outputs[1:] {
+		expected[*parentTx.TxIDChainHash()].UTXOs = append(expected[*parentTx.TxIDChainHash()].UTXOs, &UTXO{uint32(i + 1), output.Satoshis, *output.LockingScript}) // nolint:gosec
+	}
+
+	expected[*spentBefore.TxIDChainHash()].UTXOs = []*UTXO{{0, spentBefore.Outputs[0].Satoshis, *spentBefore.Outputs[0].LockingScript}}
+
+	expectedCommitment := NewUTXOCommitment()
+	for _, uw := range expected {
+		expectedCommitment.AddUTXOs(uw, uw.UTXOs)
+	}
+
+	assert.Equal(t, *blockHash, commitment.BlockHash)
+	assert.Equal(t, uint32(2), commitment.BlockHeight)
+	assert.Equal(t, uint64(2), commitment.TxCount)
+	assert.Equal(t, uint64(10), commitment.UTXOCount)
+	assert.Equal(t, expectedCommitment.TotalAmount, commitment.TotalAmount)
+	assert.Equal(t, expectedCommitment.MuHash(), commitment.MuHash())
+
+	t.Run("utxo-set", func(t *testing.T) {
+		b, err := store.Get(ctx, blockHash[:], fileformat.FileTypeUtxoSet)
+		require.NoError(t, err)
+
+		r := bytes.NewReader(b)
+
+		var hash, previousHash chainhash.Hash
+
+		_, err = io.ReadFull(r, hash[:])
+		require.NoError(t, err)
+		assert.Equal(t, *blockHash, hash)
+
+		heightBytes := make([]byte, 4)
+		_, err = io.ReadFull(r, heightBytes)
+		require.NoError(t, err)
+		assert.Equal(t, []byte{2, 0, 0, 0}, heightBytes)
+
+		_, err = io.ReadFull(r, previousHash[:])
+		require.NoError(t, err)
+		assert.Equal(t, *chain.headers[1].Hash(), previousHash)
+
+		for range expected {
+			uw, err := NewUTXOWrapperFromReader(ctx, r)
+			require.NoError(t, err)
+
+			require.Contains(t, expected, uw.TxID)
+			assert.Equal(t, expected[uw.TxID].Height, uw.Height)
+			assert.False(t, uw.Coinbase)
+			assert.Equal(t, expected[uw.TxID].UTXOs, uw.UTXOs)
+		}
+
+		// the footer holds the number of transactions and utxos
+		assert.Equal(t, 16, r.Len())
+	})
+
+	t.Run("utxo-headers", func(t *testing.T) {
+		storedCommitment, err := GetUTXOCommitment(ctx, store, blockHash)
+		require.NoError(t, err)
+		assert.Equal(t, commitment.MuHash(), storedCommitment.MuHash())
+		assert.Equal(t, commitment.UTXOCount, storedCommitment.UTXOCount)
+
+		r, err := store.GetIoReader(ctx, blockHash[:], fileformat.FileTypeUtxoHeaders)
+		require.NoError(t, err)
+
+		_, err = fileformat.ReadHeader(r)
+		require.NoError(t, err)
+
+		_, err = io.ReadFull(r, make([]byte, 36))
+		require.NoError(t, err)
+
+		for i := 0; i <= 2; i++ {
+			blockIndex, err := NewUTXOHeaderFromReader(r)
+			require.NoError(t, err)
+			assert.Equal(t, chain.headers[i].Hash(), blockIndex.Hash)
+			assert.Equal(t, uint32(i), blockIndex.Height) // nolint:gosec
+		}
+
+		_, err = NewUTXOHeaderFromReader(r)
+		assert.ErrorIs(t, err, io.EOF)
+	})
+
+	t.Run("existing files", func(t *testing.T) {
+		_, err := CreateUTXOSetSnapshot(ctx, ulogger.TestLogger{}, tSettings, store, utxoStore, chain, blockHash, false)
+		require.ErrorIs(t, err, errors.ErrBlobAlreadyExists)
+
+		overwritten, err := CreateUTXOSetSnapshot(ctx, ulogger.TestLogger{}, tSettings, store, utxoStore, chain, blockHash, true)
+		require.NoError(t, err)
+		assert.Equal(t, commitment.MuHash(), overwritten.MuHash())
+	})
+
+	t.Run("beyond retention", func(t *testing.T) {
+		tSettings.GlobalBlockHeightRetention = 0
+
+		_, err := CreateUTXOSetSnapshot(ctx, ulogger.TestLogger{}, tSettings, memory.New(), utxoStore, chain, blockHash, false)
+		require.ErrorIs(t, err, errors.ErrInvalidArgument)
+	})
+}
@@ -463,6 +463,7 @@ func (us *UTXOSet) GetUTXODeletionsReader(ctx context.Context) (io.ReadCloser, e
 // 4. Adds new unspent outputs from the current block
 // 5. Writes all remaining UTXOs to the UTXO set file
 // 6. Finalizes the file with footer information and counts
+// 7. Rolls the UTXO commitment of the previous block forward and writes it to the utxo-headers file
 //
 // The method uses error groups to process UTXOs in parallel for better performance,
 // with coordinated error handling to ensure data integrity. Tracing is used for
@@ -528,6 +529,26 @@ func (us *UTXOSet) CreateUTXOSet(ctx context.Context, c *consolidator) (err erro
 		utxoCount  uint64
 	)
 
+	// The commitment is rolled forward from the commitment of the previous block, by removing the spent outputs
+	// and adding the new ones. Without a previous commitment, it is built from all outputs of the set instead.
+	commitment := NewUTXOCommitment()
+	rebuildCommitment := false
+
+	if c.firstPreviousBlockHash.String() != c.settings.ChainCfgParams.GenesisHash.String() {
+		previousCommitment, err := GetUTXOCommitment(ctx, us.store, c.firstPreviousBlockHash)
+		if err != nil {
+			if !errors.Is(err, errors.ErrNotFound) {
+				return errors.NewStorageError("error getting utxo commitment for previous block %s", c.firstPreviousBlockHash, err)
+			}
+
+			us.logger.Infof("[CreateUTXOSet] No utxo commitment for previous block %s, building it from the utxo-set", c.firstPreviousBlockHash)
+
+			rebuildCommitment = true
+		} else {
+			commitment = previousCommitment
+		}
+	}
+
 	if c.firstPreviousBlockHash.String() != c.settings.ChainCfgParams.GenesisHash.String() {
 		// Open the previous UTXOSet for the previous block
 		previousUTXOSetReader, err := us.store.GetIoReader(ctx, c.firstPreviousBlockHash[:], fileformat.FileTypeUtxoSet)
@@ -580,9 +601,17 @@ func (us *UTXOSet) CreateUTXOSet(ctx context.Context, c *consolidator) (err erro
 
 				ts = readStat.AddTime(ts)
 
+				previousUTXOs := utxoWrapper.UTXOs
+
 				// Filter UTXOs based on the deletions map
 				utxoWrapper.UTXOs = filterUTXOs(utxoWrapper.UTXOs, c.deletions, &utxoWrapper.TxID)
 
+				if rebuildCommitment {
+					commitment.AddUTXOs(utxoWrapper, utxoWrapper.UTXOs)
+				} else if len(utxoWrapper.UTXOs) != len(previousUTXOs) {
+					commitment.RemoveUTXOs(utxoWrapper, spentUTXOs(previousUTXOs, c.deletions, &utxoWrapper.TxID))
+				}
+
 				ts = filterStat.AddTime(ts)
 
 				// Only write the UTXOWrapper if there are remaining UTXOs after deletions
@@ -609,6 +638,8 @@ func (us *UTXOSet) CreateUTXOSet(ctx context.Context, c *consolidator) (err erro
 		// Filter UTXOs based on the deletions map
 		utxoWrapper.UTXOs = filterUTXOs(utxoWrapper.UTXOs, c.deletions, &utxoWrapper.TxID)
 
+		commitment.AddUTXOs(utxoWrapper, utxoWrapper.UTXOs)
+
 		ts = filterStat.AddTime(ts)
 
 		// Only write the UTXOWrapper if there are remaining UTXOs after deletions
@@ -644,6 +675,17 @@ func (us *UTXOSet) CreateUTXOSet(ctx context.Context, c *consolidator) (err erro
 		return errors.NewStorageError("error flushing utxoset writer", err)
 	}
 
+	commitment.BlockHash = *c.lastBlockHash
+	commitment.BlockHeight = c.lastBlockHeight
+	commitment.TxCount = txCount
+	commitment.UTXOCount = utxoCount
+
+	if err = WriteUTXOCommitment(ctx, us.store, commitment); err != nil {
+		return err
+	}
+
+	us.logger.Infof("[CreateUTXOSet] UTXO commitment for block %s height %d: %s (%d utxos, %d satoshis)", c.lastBlockHash, c.lastBlockHeight, commitment.MuHash(), utxoCount, commitment.TotalAmount)
+
 	return nil
 }
 
@@ -682,6 +724,19 @@ func filterUTXOs(utxos []*UTXO, deletions map[UTXODeletion]struct{}, txID *chain
 	return filteredUTXOs
 }
 
+// spentUTXOs returns the UTXOs that are present in the deletions map, the UTXOs that are removed by filterUTXOs.
+func spentUTXOs(utxos []*UTXO, deletions map[UTXODeletion]struct{}, txID *chainhash.Hash) []*UTXO {
+	spent := make([]*UTXO, 0, len(utxos))
+
+	for _, utxo := range utxos {
+		if _, found := deletions[UTXODeletion{TxID: *txID, Index: utxo.Index}]; found {
+			spent = append(spent, utxo)
+		}
+	}
+
+	return spent
+}
+
 // PadUTXOsWithNil pads a slice of UTXOs with nil values to match their indices.
 // It creates a new slice with nil values at positions where no UTXO exists,
 // ensuring that UTXOs are at positions matching their output index.
@@ -22,6 +22,7 @@ import (
 	"github.com/bsv-blockchain/teranode/services/blockchain"
 	"github.com/bsv-blockchain/teranode/services/blockchain/blockchain_api"
 	"github.com/bsv-blockchain/teranode/settings"
+	"github.com/bsv-blockchain/teranode/stores/blob/quota"
 	"github.com/bsv-blockchain/teranode/stores/utxo"
 	"github.com/bsv-blockchain/teranode/stores/utxo/fields"
 	"github.com/bsv-blockchain/teranode/stores/utxo/meta"
@@ -283,7 +284,7 @@ func (v *Validator) ValidateWithOptions(ctx context.Context, tx *bt.Tx, blockHei
 	if txMetaData, err = v.validateInternal(ctx, tx, blockHeight, validationOptions); err != nil {
 		if v.rejectedTxKafkaProducerClient != nil { // tests may not set this
 			// TODO which errors should we be sending here?
-			if !errors.Is(err, errors.ErrStorageError) && !errors.Is(err, errors.ErrServiceError) && !errors.Is(err, errors.ErrTxMissingParent) {
+			if !errors.Is(err, errors.ErrStorageError) && !errors.Is(err, errors.ErrServiceError) && !errors.Is(err, errors.ErrServiceUnavailable) && !errors.Is(err, errors.ErrTxMissingParent) {
 				if v.blockchainClient != nil {
 					var (
 						state *blockchain.FSMStateType
@@ -423,6 +424,16 @@ func (v *Validator) validateInternal(ctx context.Context, tx *bt.Tx, blockHeight
 		return nil, err
 	}
 
+	// new transactions are rejected before the disk of a blob store is full, transactions of mined blocks are not
+	if !validationOptions.SkipPolicyChecks {
+		if err = quota.CheckPressure(); err != nil {
+			err = errors.NewServiceUnavailableError("[Validate][%s] rejecting transaction under disk pressure", txID, err)
+			span.RecordError(err)
+
+			return nil, err
+		}
+	}
+
 	var utxoHeights []uint32
 
 	// check whether the transaction is extended, extend it if not
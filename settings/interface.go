@@ -67,6 +67,10 @@ type Settings struct {
 	RPC                          RPCSettings
 	Faucet                       FaucetSettings
 	Dashboard                    DashboardSettings
+	FilterIndex                  FilterIndexSettings
+	ScriptHashIndex              ScriptHashIndexSettings
+	Electrum                     ElectrumSettings
+	FeeEstimator                 FeeEstimatorSettings
 	GlobalBlockHeightRetention   uint32
 }
 
@@ -123,6 +127,7 @@ type KafkaSettings struct {
 	InvalidSubtreesConfig *url.URL
 	SubtreesConfig        *url.URL
 	BlocksConfig          *url.URL
+	UtxoCDCConfig         *url.URL // Change data capture of the SQL UTXO store, disabled when not set
 	// TLS settings
 	EnableTLS     bool
 	TLSSkipVerify bool
@@ -173,6 +178,10 @@ type BlockSettings struct {
 	MinedCacheMaxMB                       int
 	PersisterStore                        *url.URL
 	PersisterHTTPListenAddress            string
+	PersisterHTTPAccessFile               string
+	PersisterHTTPTLSCertFile              string
+	PersisterHTTPTLSKeyFile               string
+	PersisterHTTPTLSClientCAFile          string
 	StateFile                             string
 	CheckDuplicateTransactionsConcurrency int
 	GetAndValidateSubtreesConcurrency     int
@@ -535,18 +544,42 @@ type PropagationSettings struct {
 }
 
 type RPCSettings struct {
-	RPCUser           string
-	RPCPass           string
-	RPCLimitUser      string
-	RPCLimitPass      string
-	RPCMaxClients     int
-	RPCQuirks         bool
-	RPCListenerURL    *url.URL
-	CacheEnabled      bool
-	RPCTimeout        time.Duration
-	ClientCallTimeout time.Duration
+	RPCUser            string
+	RPCPass            string
+	RPCLimitUser       string
+	RPCLimitPass       string
+	RPCMaxClients      int
+	RPCQuirks          bool
+	RPCListenerURL     *url.URL
+	CacheEnabled       bool
+	RPCTimeout         time.Duration
+	ClientCallTimeout  time.Duration
+	GBTLongPollTimeout time.Duration
+	GBTMaxTransactions int
 }
 
 type FaucetSettings struct {
 	HTTPListenAddress string
 }
+
+type FilterIndexSettings struct {
+	Enabled bool // Serve compact block filters (BIP157) to peers and RPC clients, requires the filter index service
+}
+
+type ScriptHashIndexSettings struct {
+	Enabled bool     // Serve script hash history queries from the asset and RPC services, requires the script hash index service
+	Store   *url.URL // URL of the SQL store holding the index
+}
+
+type ElectrumSettings struct {
+	ListenAddress          string        // TCP address the Electrum server listens on
+	WebSocketListenAddress string        // Address the Electrum WebSocket server listens on, disabled when empty
+	Banner                 string        // Banner returned by server.banner
+	MaxHistory             int           // Maximum number of history items of a script hash served to clients
+	StatusCheckInterval    time.Duration // Interval at which subscribed script hashes are checked for changes
+}
+
+type FeeEstimatorSettings struct {
+	Enabled                bool // Record the fee rates of accepted transactions and serve fee estimates from the asset and RPC services
+	MaxTrackedTransactions int  // Maximum number of unmined transactions tracked by the fee estimator
+}
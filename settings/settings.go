@@ -121,6 +121,7 @@ func NewSettings(alternativeContext ...string) *Settings {
 			InvalidSubtreesConfig: getURL("kafka_invalidSubtreesConfig", "", alternativeContext...),
 			SubtreesConfig:        getURL("kafka_subtreesConfig", "", alternativeContext...),
 			BlocksConfig:          getURL("kafka_blocksConfig", "", alternativeContext...),
+			UtxoCDCConfig:         getURL("kafka_utxoCDCConfig", "", alternativeContext...),
 			// TLS settings
 			EnableTLS:     getBool("KAFKA_ENABLE_TLS", false, alternativeContext...),
 			TLSSkipVerify: getBool("KAFKA_TLS_SKIP_VERIFY", false, alternativeContext...),
@@ -168,6 +169,10 @@ func NewSettings(alternativeContext ...string) *Settings {
 			PersisterStore:                        getURL("blockPersisterStore", "file://./data/blockstore", alternativeContext...),
 			StateFile:                             getString("blockPersister_stateFile", "file://./data/blockpersister_state.txt", alternativeContext...),
 			PersisterHTTPListenAddress:            getString("blockPersister_httpListenAddress", ":8083", alternativeContext...),
+			PersisterHTTPAccessFile:               getString("blockPersister_httpAccessFile", "", alternativeContext...),
+			PersisterHTTPTLSCertFile:              getString("blockPersister_httpTLSCertFile", "", alternativeContext...),
+			PersisterHTTPTLSKeyFile:               getString("blockPersister_httpTLSKeyFile", "", alternativeContext...),
+			PersisterHTTPTLSClientCAFile:          getString("blockPersister_httpTLSClientCAFile", "", alternativeContext...),
 			CheckDuplicateTransactionsConcurrency: getInt("block_checkDuplicateTransactionsConcurrency", -1, alternativeContext...),
 			GetAndValidateSubtreesConcurrency:     getInt("block_getAndValidateSubtreesConcurrency", -1, alternativeContext...),
 			KafkaWorkers:                          getInt("block_kafkaWorkers", 0, alternativeContext...),
@@ -477,16 +482,18 @@ func NewSettings(alternativeContext ...string) *Settings {
 			GRPCListenAddress:    getString("propagation_grpcListenAddress", "", alternativeContext...),
 		},
 		RPC: RPCSettings{
-			RPCUser:           getString("rpc_user", "", alternativeContext...),
-			RPCPass:           getString("rpc_pass", "", alternativeContext...),
-			RPCLimitUser:      getString("rpc_limit_user", "", alternativeContext...),
-			RPCLimitPass:      getString("rpc_limit_pass", "", alternativeContext...),
-			RPCMaxClients:     getInt("rpc_max_clients", 1, alternativeContext...),
-			RPCQuirks:         getBool("rpc_quirks", true, alternativeContext...),
-			RPCListenerURL:    getURL("rpc_listener_url", "", alternativeContext...),
-			CacheEnabled:      getBool("rpc_cache_enabled", true, alternativeContext...),
-			RPCTimeout:        getDuration("rpc_timeout", 30*time.Second, alternativeContext...),
-			ClientCallTimeout: getDuration("rpc_client_call_timeout", 5*time.Second, alternativeContext...),
+			RPCUser:            getString("rpc_user", "", alternativeContext...),
+			RPCPass:            getString("rpc_pass", "", alternativeContext...),
+			RPCLimitUser:       getString("rpc_limit_user", "", alternativeContext...),
+			RPCLimitPass:       getString("rpc_limit_pass", "", alternativeContext...),
+			RPCMaxClients:      getInt("rpc_max_clients", 1, alternativeContext...),
+			RPCQuirks:          getBool("rpc_quirks", true, alternativeContext...),
+			RPCListenerURL:     getURL("rpc_listener_url", "", alternativeContext...),
+			CacheEnabled:       getBool("rpc_cache_enabled", true, alternativeContext...),
+			RPCTimeout:         getDuration("rpc_timeout", 30*time.Second, alternativeContext...),
+			ClientCallTimeout:  getDuration("rpc_client_call_timeout", 5*time.Second, alternativeContext...),
+			GBTLongPollTimeout: getDuration("rpc_gbt_long_poll_timeout", 25*time.Second, alternativeContext...),
+			GBTMaxTransactions: getInt("rpc_gbt_max_transactions", 100_000, alternativeContext...),
 		},
 		Faucet: FaucetSettings{
 			HTTPListenAddress: getString("faucet_httpListenAddress", "", alternativeContext...),
@@ -497,6 +504,24 @@ func NewSettings(alternativeContext ...string) *Settings {
 			WebSocketPort:  getString("dashboard_websocketPort", "8090", alternativeContext...),
 			WebSocketPath:  getString("dashboard_websocketPath", "/connection/websocket", alternativeContext...),
 		},
+		FilterIndex: FilterIndexSettings{
+			Enabled: getBool("filterindex_enabled", false, alternativeContext...),
+		},
+		ScriptHashIndex: ScriptHashIndexSettings{
+			Enabled: getBool("scripthashindex_enabled", false, alternativeContext...),
+			Store:   getURL("scripthashindex_store", "sqlite:///scripthashindex", alternativeContext...),
+		},
+		Electrum: ElectrumSettings{
+			ListenAddress:          getString("electrum_listenAddress", ":50001", alternativeContext...),
+			WebSocketListenAddress: getString("electrum_wsListenAddress", "", alternativeContext...),
+			Banner:                 getString("electrum_banner", "Welcome to Teranode", alternativeContext...),
+			MaxHistory:             getInt("electrum_maxHistory", 10_000, alternativeContext...),
+			StatusCheckInterval:    getDuration("electrum_statusCheckInterval", 10*time.Second, alternativeContext...),
+		},
+		FeeEstimator: FeeEstimatorSettings{
+			Enabled:                getBool("feeestimator_enabled", false, alternativeContext...),
+			MaxTrackedTransactions: getInt("feeestimator_maxTrackedTransactions", 100_000, alternativeContext...),
+		},
 	}
 }
 
@@ -79,6 +79,12 @@ type blobStoreSetter interface {
 	Set(ctx context.Context, key []byte, fileType fileformat.FileType, value []byte, opts ...options.FileOption) error
 }
 
+// blobStoreLister is implemented by underlying blob stores that can list the blobs they hold.
+type blobStoreLister interface {
+	// List returns a page of the blobs in the underlying store
+	List(ctx context.Context, listOpts options.ListOptions, opts ...options.FileOption) (*options.ListResult, error)
+}
+
 // New creates a new Batcher instance that wraps the provided blob store.
 //
 // The batcher improves performance by aggregating multiple small blob operations into larger batches,
@@ -455,6 +461,27 @@ func (b *Batcher) Del(_ context.Context, _ []byte, _ fileformat.FileType, _ ...o
 	return errors.NewStorageError("Del not supported by batcher")
 }
 
+// List lists the blobs in the underlying store.
+// The batched blobs are stored as batches under their own keys, so the listing returns the
+// batches written to the underlying store, not the individual blobs queued in the batcher.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - listOpts: The prefix, file type, cursor and limit of the listing
+//   - opts: Optional file options
+//
+// Returns:
+//   - *options.ListResult: The blobs in the page and the cursor of the next page
+//   - error: go-errors.NewStorageError when the underlying store does not support listing, or any error of the listing
+func (b *Batcher) List(ctx context.Context, listOpts options.ListOptions, opts ...options.FileOption) (*options.ListResult, error) {
+	lister, ok := b.blobStore.(blobStoreLister)
+	if !ok {
+		return nil, errors.NewStorageError("List not supported by the underlying store of the batcher")
+	}
+
+	return lister.List(ctx, listOpts, opts...)
+}
+
 // SetCurrentBlockHeight is a no-op in the batcher implementation.
 // The batcher does not implement Delete-At-Height functionality, so it ignores
 // block height updates.
@@ -0,0 +1,631 @@
+// Package cache provides a read-through cache for blob stores, keeping recently read blobs on local disk.
+//
+// The Cache wrapper serves Get, GetIoReader, Exists and GetDAH from a size-bounded least recently used cache on
+// local disk, optionally keeping the most recently read blobs in memory as well, and reads the blobs that are not
+// cached from the wrapped store, typically an s3 or http store. Blobs read repeatedly, like the subtrees that are
+// validated again during catchup, are then only read over the network once.
+//
+// The DAH of a blob is cached with it, and a cached blob is no longer served once the current block height reaches
+// its DAH. Writes, deletes and DAH changes go to the wrapped store and invalidate or update the cached blob, so the
+// cache never serves a blob that was changed through it. Changes made to the wrapped store by other processes are
+// only seen once the blob has been evicted.
+//
+// Readers of cached blobs implement io.Seeker, so range reads of the HTTP blob server only read the requested range
+// from local disk. The hit rate of the cache is exposed through the teranode_blob_cache_* Prometheus metrics.
+package cache
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+	"github.com/bsv-blockchain/teranode/stores/blob/options"
+	"github.com/bsv-blockchain/teranode/ulogger"
+)
+
+const (
+	// fileSuffix is the suffix of the files of cached blobs, files with it are removed when the cache is created
+	fileSuffix = ".blob"
+	// tempSuffix is the suffix of the files blobs are written to before they are added to the cache
+	tempSuffix = ".tmp"
+	// writeStripes is the number of stripes the writes to blobs are counted in
+	writeStripes = 256
+
+	tierDisk   = "disk"
+	tierMemory = "memory"
+)
+
+// blobStore defines the interface of the wrapped blob store, it mirrors the blob.Store interface
+type blobStore interface {
+	Health(ctx context.Context, checkLiveness bool) (int, string, error)
+	Exists(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (bool, error)
+	Get(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) ([]byte, error)
+	GetIoReader(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (io.ReadCloser, error)
+	Set(ctx context.Context, key []byte, fileType fileformat.FileType, value []byte, opts ...options.FileOption) error
+	SetFromReader(ctx context.Context, key []byte, fileType fileformat.FileType, value io.ReadCloser, opts ...options.FileOption) error
+	SetDAH(ctx context.Context, key []byte, fileType fileformat.FileType, newDAH uint32, opts ...options.FileOption) error
+	GetDAH(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (uint32, error)
+	Del(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) error
+	Close(ctx context.Context) error
+	SetCurrentBlockHeight(height uint32)
+}
+
+// blobStoreLister is implemented by wrapped blob stores that can list the blobs they hold
+type blobStoreLister interface {
+	List(ctx context.Context, listOpts options.ListOptions, opts ...options.FileOption) (*options.ListResult, error)
+}
+
+// entry is a blob in the cache, it is always on disk and in the memory tier when data is set
+type entry struct {
+	id       string
+	path     string
+	size     int64
+	dah      uint32
+	diskElem *list.Element
+	data     []byte
+	memElem  *list.Element
+}
+
+// Cache is a blob store wrapper that caches the blobs read from the wrapped store on local disk.
+type Cache struct {
+	logger         ulogger.Logger
+	store          blobStore
+	dir            string
+	maxDiskBytes   int64
+	maxMemoryBytes int64
+
+	currentBlockHeight atomic.Uint32
+
+	mu          sync.Mutex
+	entries     map[string]*entry
+	diskLRU     *list.List
+	memoryLRU   *list.List
+	diskBytes   int64
+	memoryBytes int64
+	// writes counts the writes per stripe of blob ids, a blob read from the wrapped store is only cached when no
+	// write to its stripe happened while it was read, so a blob changed during the read is never cached
+	writes [writeStripes]uint64
+}
+
+// New creates a new Cache wrapper around the blob store. Blob files left in the cache directory by a previous
+// run are removed, as their DAHs are not known.
+//
+// Parameters:
+//   - logger: Logger instance for cache operations
+//   - store: The blob store to wrap, typically a remote store
+//   - dir: The local directory the cached blobs are stored in
+//   - maxDiskBytes: The maximum number of bytes of the blobs cached on disk
+//   - maxMemoryBytes: The maximum number of bytes of the blobs kept in memory as well, 0 to only cache on disk
+//
+// Returns:
+//   - *Cache: The cache wrapper
+//   - error: Any error in the configuration or creating the cache directory
+func New(logger ulogger.Logger, store blobStore, dir string, maxDiskBytes, maxMemoryBytes int64) (*Cache, error) {
+	if store == nil {
+		return nil, errors.NewConfigurationError("[Cache] store is required")
+	}
+
+	if dir == "" {
+		return nil, errors.NewConfigurationError("[Cache] cache directory is required")
+	}
+
+	if maxDiskBytes <= 0 {
+		return nil, errors.NewConfigurationError("[Cache] cache size must be positive")
+	}
+
+	if maxMemoryBytes < 0 {
+		return nil, errors.NewConfigurationError("[Cache] memory cache size must not be negative")
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.NewStorageError("[Cache] failed to create cache directory %s", dir, err)
+	}
+
+	if err := clearDir(dir); err != nil {
+		return nil, err
+	}
+
+	initPrometheusMetrics()
+
+	return &Cache{
+		logger:         logger,
+		store:          store,
+		dir:            dir,
+		maxDiskBytes:   maxDiskBytes,
+		maxMemoryBytes: maxMemoryBytes,
+		entries:        make(map[string]*entry),
+		diskLRU:        list.New(),
+		memoryLRU:      list.New(),
+	}, nil
+}
+
+// clearDir removes the blob and temporary files left in the cache directory, other files are left alone
+func clearDir(dir string) error {
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return errors.NewStorageError("[Cache] failed to read cache directory %s", dir, err)
+	}
+
+	for _, dirEntry := range dirEntries {
+		name := dirEntry.Name()
+
+		if dirEntry.IsDir() || (!strings.HasSuffix(name, fileSuffix) && !strings.HasSuffix(name, tempSuffix)) {
+			continue
+		}
+
+		if err = os.Remove(filepath.Join(dir, name)); err != nil && !os.IsNotExist(err) {
+			return errors.NewStorageError("[Cache] failed to remove cached blob %s", name, err)
+		}
+	}
+
+	return nil
+}
+
+// cacheID returns the id of a blob in the cache, blobs stored under another filename or sub directory are
+// different blobs
+func cacheID(key []byte, fileType fileformat.FileType, opts []options.FileOption) string {
+	fileOptions := options.NewFileOptions(opts...)
+
+	return fileOptions.SubDirectory + "/" + fileOptions.Filename + "/" + hex.EncodeToString(key) + "." + fileType.String()
+}
+
+// stripe returns the stripe the writes to a blob are counted in
+func stripe(id string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(id))
+
+	return int(h.Sum32() % writeStripes)
+}
+
+// expired returns whether a blob with a DAH is deleted at the block height
+func expired(dah, height uint32) bool {
+	return dah > 0 && dah <= height
+}
+
+// filePath returns the path of the file a blob is cached in
+func (c *Cache) filePath(id string) string {
+	hash := sha256.Sum256([]byte(id))
+
+	return filepath.Join(c.dir, hex.EncodeToString(hash[:])+fileSuffix)
+}
+
+// lookup returns the entry of a cached blob, nil when the blob is not cached or its DAH has been reached.
+// c.mu must be held.
+func (c *Cache) lookup(id string) *entry {
+	e, ok := c.entries[id]
+	if !ok {
+		return nil
+	}
+
+	if expired(e.dah, c.currentBlockHeight.Load()) {
+		c.remove(e)
+		return nil
+	}
+
+	return e
+}
+
+// hit looks up a cached blob and marks it as recently used, it returns the data of the blob when it is in the
+// memory tier and the path of its file otherwise
+func (c *Cache) hit(id string) ([]byte, string, *entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := c.lookup(id)
+	if e == nil {
+		return nil, "", nil
+	}
+
+	c.diskLRU.MoveToFront(e.diskElem)
+
+	if e.memElem != nil {
+		c.memoryLRU.MoveToFront(e.memElem)
+		return e.data, e.path, e
+	}
+
+	return nil, e.path, e
+}
+
+// remove removes an entry from the cache and deletes its file. c.mu must be held.
+func (c *Cache) remove(e *entry) {
+	c.unlink(e)
+	c.removeFile(e.path)
+}
+
+// unlink removes an entry from the cache without deleting its file. c.mu must be held.
+func (c *Cache) unlink(e *entry) {
+	delete(c.entries, e.id)
+	c.diskLRU.Remove(e.diskElem)
+	c.diskBytes -= e.size
+	prometheusCacheSize.WithLabelValues(tierDisk).Sub(float64(e.size))
+
+	c.dropFromMemory(e)
+}
+
+// dropFromMemory removes an entry from the memory tier, it stays cached on disk. c.mu must be held.
+func (c *Cache) dropFromMemory(e *entry) {
+	if e.memElem == nil {
+		return
+	}
+
+	c.memoryLRU.Remove(e.memElem)
+	c.memoryBytes -= e.size
+	prometheusCacheSize.WithLabelValues(tierMemory).Sub(float64(e.size))
+
+	e.memElem = nil
+	e.data = nil
+}
+
+// keepInMemory adds the data of an entry to the memory tier, evicting the least recently used blobs from memory
+// when it is full. c.mu must be held.
+func (c *Cache) keepInMemory(e *entry, data []byte) {
+	if e.memElem != nil {
+		c.memoryLRU.MoveToFront(e.memElem)
+		return
+	}
+
+	if c.maxMemoryBytes == 0 || e.size > c.maxMemoryBytes {
+		return
+	}
+
+	for c.memoryBytes+e.size > c.maxMemoryBytes {
+		c.dropFromMemory(c.memoryLRU.Back().Value.(*entry))
+		prometheusCacheEvictions.WithLabelValues(tierMemory).Inc()
+	}
+
+	e.data = data
+	e.memElem = c.memoryLRU.PushFront(e)
+	c.memoryBytes += e.size
+	prometheusCacheSize.WithLabelValues(tierMemory).Add(float64(e.size))
+}
+
+// promote adds the data read from the file of an entry to the memory tier, unless the entry has been replaced
+// or removed since it was looked up
+func (c *Cache) promote(e *entry, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries[e.id] == e {
+		c.keepInMemory(e, data)
+	}
+}
+
+// removeFile deletes a file of the cache directory, logging failures
+func (c *Cache) removeFile(path string) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		c.logger.Warnf("[Cache] failed to remove %s: %v", path, err)
+	}
+}
+
+// writeCount returns the number of writes to the stripe of a blob, to be passed to add
+func (c *Cache) writeCount(id string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.writes[stripe(id)]
+}
+
+// invalidate counts a write to a blob and removes it from the cache
+func (c *Cache) invalidate(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.writes[stripe(id)]++
+
+	if e, ok := c.entries[id]; ok {
+		c.remove(e)
+	}
+}
+
+// writeTemp creates a temporary file in the cache directory, to write a blob to before it is added to the cache
+func (c *Cache) writeTemp() (*os.File, error) {
+	f, err := os.CreateTemp(c.dir, "*"+tempSuffix)
+	if err != nil {
+		return nil, errors.NewStorageError("[Cache] failed to create temporary file", err)
+	}
+
+	return f, nil
+}
+
+// add adds a blob written to the file at tempPath to the cache, evicting the least recently used blobs when the
+// cache is full. The blob is not added when a write to its stripe happened since writes was read by writeCount,
+// when it does not fit in the cache or when its DAH has been reached. The file is moved into the cache when the
+// blob is added and removed otherwise, open handles of it can still be read from.
+func (c *Cache) add(id string, tempPath string, size int64, dah uint32, data []byte, writes uint64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.writes[stripe(id)] != writes || size > c.maxDiskBytes || expired(dah, c.currentBlockHeight.Load()) {
+		c.removeFile(tempPath)
+		return false
+	}
+
+	path := c.filePath(id)
+
+	if e, ok := c.entries[id]; ok {
+		// the file of the entry is replaced by the rename
+		c.unlink(e)
+	}
+
+	if err := os.Rename(tempPath, path); err != nil {
+		c.logger.Warnf("[Cache] failed to move %s into the cache: %v", tempPath, err)
+		c.removeFile(tempPath)
+		c.removeFile(path)
+
+		return false
+	}
+
+	for c.diskBytes+size > c.maxDiskBytes {
+		c.remove(c.diskLRU.Back().Value.(*entry))
+		prometheusCacheEvictions.WithLabelValues(tierDisk).Inc()
+	}
+
+	e := &entry{
+		id:   id,
+		path: path,
+		size: size,
+		dah:  dah,
+	}
+
+	e.diskElem = c.diskLRU.PushFront(e)
+	c.entries[id] = e
+	c.diskBytes += size
+	prometheusCacheSize.WithLabelValues(tierDisk).Add(float64(size))
+
+	if data != nil {
+		c.keepInMemory(e, data)
+	}
+
+	return true
+}
+
+func (c *Cache) Health(ctx context.Context, checkLiveness bool) (int, string, error) {
+	return c.store.Health(ctx, checkLiveness)
+}
+
+// Exists returns true for cached blobs without checking the wrapped store.
+func (c *Cache) Exists(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (bool, error) {
+	if _, _, e := c.hit(cacheID(key, fileType, opts)); e != nil {
+		return true, nil
+	}
+
+	return c.store.Exists(ctx, key, fileType, opts...)
+}
+
+func (c *Cache) Set(ctx context.Context, key []byte, fileType fileformat.FileType, value []byte, opts ...options.FileOption) error {
+	id := cacheID(key, fileType, opts)
+
+	// invalidate before and after the write, so a read of the blob that overlaps the write is not cached
+	c.invalidate(id)
+	defer c.invalidate(id)
+
+	return c.store.Set(ctx, key, fileType, value, opts...)
+}
+
+func (c *Cache) SetFromReader(ctx context.Context, key []byte, fileType fileformat.FileType, value io.ReadCloser, opts ...options.FileOption) error {
+	id := cacheID(key, fileType, opts)
+
+	c.invalidate(id)
+	defer c.invalidate(id)
+
+	return c.store.SetFromReader(ctx, key, fileType, value, opts...)
+}
+
+// SetDAH sets the DAH of the blob in the wrapped store and of the cached blob, the cached blob is removed when
+// the current block height has reached the DAH.
+func (c *Cache) SetDAH(ctx context.Context, key []byte, fileType fileformat.FileType, newDAH uint32, opts ...options.FileOption) error {
+	if err := c.store.SetDAH(ctx, key, fileType, newDAH, opts...); err != nil {
+		return err
+	}
+
+	id := cacheID(key, fileType, opts)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// a read of the blob that overlaps the change may have read the old DAH
+	c.writes[stripe(id)]++
+
+	if e, ok := c.entries[id]; ok {
+		e.dah = newDAH
+
+		if expired(newDAH, c.currentBlockHeight.Load()) {
+			c.remove(e)
+		}
+	}
+
+	return nil
+}
+
+// GetDAH returns the DAH of cached blobs without reading it from the wrapped store.
+func (c *Cache) GetDAH(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (uint32, error) {
+	id := cacheID(key, fileType, opts)
+
+	c.mu.Lock()
+
+	if e := c.lookup(id); e != nil {
+		dah := e.dah
+		c.mu.Unlock()
+
+		return dah, nil
+	}
+
+	c.mu.Unlock()
+
+	return c.store.GetDAH(ctx, key, fileType, opts...)
+}
+
+// GetIoReader returns a reader of the blob, which implements io.Seeker. A blob that is not cached is read from
+// the wrapped store into the cache before the reader is returned.
+func (c *Cache) GetIoReader(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (io.ReadCloser, error) {
+	id := cacheID(key, fileType, opts)
+
+	if data, path, e := c.hit(id); e != nil {
+		if data != nil {
+			prometheusCacheHits.WithLabelValues(fileType.String(), tierMemory).Inc()
+			return &bytesReadCloser{Reader: bytes.NewReader(data)}, nil
+		}
+
+		if f, err := os.Open(path); err == nil {
+			prometheusCacheHits.WithLabelValues(fileType.String(), tierDisk).Inc()
+			return f, nil
+		}
+
+		// the blob was evicted after it was looked up, read it from the wrapped store
+	}
+
+	prometheusCacheMisses.WithLabelValues(fileType.String()).Inc()
+
+	writes := c.writeCount(id)
+
+	rc, err := c.store.GetIoReader(ctx, key, fileType, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := c.writeTemp()
+	if err != nil {
+		c.logger.Warnf("[Cache][GetIoReader] not caching %s: %v", fileType, err)
+		return rc, nil
+	}
+
+	size, err := io.Copy(f, rc)
+	_ = rc.Close()
+
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+
+	if err != nil {
+		_ = f.Close()
+		c.removeFile(f.Name())
+
+		return nil, errors.NewStorageError("[Cache][GetIoReader] failed to read %s", fileType, err)
+	}
+
+	dah, err := c.store.GetDAH(ctx, key, fileType, opts...)
+	if err != nil {
+		c.logger.Debugf("[Cache][GetIoReader] not caching %s, failed to get its DAH: %v", fileType, err)
+		c.removeFile(f.Name())
+
+		return f, nil
+	}
+
+	c.add(id, f.Name(), size, dah, nil, writes)
+
+	return f, nil
+}
+
+func (c *Cache) Get(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) ([]byte, error) {
+	id := cacheID(key, fileType, opts)
+
+	if data, path, e := c.hit(id); e != nil {
+		if data != nil {
+			prometheusCacheHits.WithLabelValues(fileType.String(), tierMemory).Inc()
+			return data, nil
+		}
+
+		if value, err := os.ReadFile(path); err == nil {
+			prometheusCacheHits.WithLabelValues(fileType.String(), tierDisk).Inc()
+			c.promote(e, value)
+
+			return value, nil
+		}
+
+		// the blob was evicted after it was looked up, read it from the wrapped store
+	}
+
+	prometheusCacheMisses.WithLabelValues(fileType.String()).Inc()
+
+	writes := c.writeCount(id)
+
+	value, err := c.store.Get(ctx, key, fileType, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	dah, err := c.store.GetDAH(ctx, key, fileType, opts...)
+	if err != nil {
+		c.logger.Debugf("[Cache][Get] not caching %s, failed to get its DAH: %v", fileType, err)
+		return value, nil
+	}
+
+	f, err := c.writeTemp()
+	if err != nil {
+		c.logger.Warnf("[Cache][Get] not caching %s: %v", fileType, err)
+		return value, nil
+	}
+
+	_, err = f.Write(value)
+	_ = f.Close()
+
+	if err != nil {
+		c.logger.Warnf("[Cache][Get] not caching %s: %v", fileType, err)
+		c.removeFile(f.Name())
+
+		return value, nil
+	}
+
+	c.add(id, f.Name(), int64(len(value)), dah, value, writes)
+
+	return value, nil
+}
+
+func (c *Cache) Del(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) error {
+	id := cacheID(key, fileType, opts)
+
+	c.invalidate(id)
+	defer c.invalidate(id)
+
+	return c.store.Del(ctx, key, fileType, opts...)
+}
+
+// List lists the blobs of the wrapped store, the cache does not hold blobs of its own.
+func (c *Cache) List(ctx context.Context, listOpts options.ListOptions, opts ...options.FileOption) (*options.ListResult, error) {
+	lister, ok := c.store.(blobStoreLister)
+	if !ok {
+		return nil, errors.NewStorageError("[Cache] blob store %T does not support listing", c.store)
+	}
+
+	return lister.List(ctx, listOpts, opts...)
+}
+
+func (c *Cache) Close(ctx context.Context) error {
+	return c.store.Close(ctx)
+}
+
+// SetCurrentBlockHeight passes the block height to the wrapped store and removes the cached blobs whose DAH has
+// been reached.
+func (c *Cache) SetCurrentBlockHeight(height uint32) {
+	c.currentBlockHeight.Store(height)
+	c.store.SetCurrentBlockHeight(height)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, e := range c.entries {
+		if expired(e.dah, height) {
+			c.remove(e)
+		}
+	}
+}
+
+// bytesReadCloser is a seekable reader of a blob in the memory tier
+type bytesReadCloser struct {
+	*bytes.Reader
+}
+
+func (b *bytesReadCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,294 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+	"github.com/bsv-blockchain/teranode/stores/blob/memory"
+	"github.com/bsv-blockchain/teranode/stores/blob/options"
+	"github.com/bsv-blockchain/teranode/ulogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCache(t *testing.T, maxDiskBytes, maxMemoryBytes int64) (*Cache, *memory.Memory, string) {
+	underlying := memory.New()
+	dir := t.TempDir()
+
+	c, err := New(ulogger.TestLogger{}, underlying, dir, maxDiskBytes, maxMemoryBytes)
+	require.NoError(t, err)
+
+	return c, underlying, dir
+}
+
+// cachedFiles returns the number of blob files in the cache directory
+func cachedFiles(t *testing.T, dir string) int {
+	matches, err := filepath.Glob(filepath.Join(dir, "*"+fileSuffix))
+	require.NoError(t, err)
+
+	return len(matches)
+}
+
+func TestCache_ReadThrough(t *testing.T) {
+	ctx := context.Background()
+	c, underlying, dir := newTestCache(t, 1024, 0)
+
+	key := []byte("key")
+	require.NoError(t, c.Set(ctx, key, fileformat.FileTypeSubtree, []byte("value")))
+
+	for i := 0; i < 3; i++ {
+		value, err := c.Get(ctx, key, fileformat.FileTypeSubtree)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("value"), value)
+
+		reader, err := c.GetIoReader(ctx, key, fileformat.FileTypeSubtree)
+		require.NoError(t, err)
+
+		value, err = io.ReadAll(reader)
+		require.NoError(t, err)
+		require.NoError(t, reader.Close())
+		assert.Equal(t, []byte("value"), value)
+	}
+
+	// only the first read went to the wrapped store
+	assert.Equal(t, 1, underlying.Counters["get"])
+	assert.Equal(t, 1, cachedFiles(t, dir))
+
+	existsCount := underlying.Counters["exists"]
+
+	exists, err := c.Exists(ctx, key, fileformat.FileTypeSubtree)
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, existsCount, underlying.Counters["exists"])
+
+	// other file types and sub directories are different blobs
+	_, err = c.Get(ctx, key, fileformat.FileTypeBlock)
+	require.Error(t, err)
+
+	// the memory store ignores sub directories, the blob read from another sub directory is cached separately
+	_, err = c.Get(ctx, key, fileformat.FileTypeSubtree, options.WithSubDirectory("other"))
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, underlying.Counters["get"])
+	assert.Equal(t, 2, cachedFiles(t, dir))
+}
+
+func TestCache_Invalidation(t *testing.T) {
+	ctx := context.Background()
+	c, _, dir := newTestCache(t, 1024, 1024)
+
+	key := []byte("key")
+	require.NoError(t, c.Set(ctx, key, fileformat.FileTypeSubtree, []byte("old")))
+
+	value, err := c.Get(ctx, key, fileformat.FileTypeSubtree)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("old"), value)
+
+	require.NoError(t, c.SetFromReader(ctx, key, fileformat.FileTypeSubtree, io.NopCloser(bytes.NewReader([]byte("new"))), options.WithAllowOverwrite(true)))
+
+	value, err = c.Get(ctx, key, fileformat.FileTypeSubtree)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("new"), value)
+
+	require.NoError(t, c.Del(ctx, key, fileformat.FileTypeSubtree))
+	assert.Zero(t, cachedFiles(t, dir))
+
+	_, err = c.Get(ctx, key, fileformat.FileTypeSubtree)
+	require.Error(t, err)
+
+	// a write that happens while a blob is read from the wrapped store keeps it from being cached
+	require.NoError(t, c.Set(ctx, key, fileformat.FileTypeSubtree, []byte("value")))
+
+	writes := c.writeCount(cacheID(key, fileformat.FileTypeSubtree, nil))
+	c.invalidate(cacheID(key, fileformat.FileTypeSubtree, nil))
+
+	f, err := c.writeTemp()
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	assert.False(t, c.add(cacheID(key, fileformat.FileTypeSubtree, nil), f.Name(), 0, 0, nil, writes))
+
+	_, err = os.Stat(f.Name())
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestCache_DAH(t *testing.T) {
+	ctx := context.Background()
+	c, underlying, dir := newTestCache(t, 1024, 0)
+
+	key := []byte("key")
+	require.NoError(t, c.Set(ctx, key, fileformat.FileTypeSubtree, []byte("value"), options.WithDeleteAt(10)))
+
+	_, err := c.Get(ctx, key, fileformat.FileTypeSubtree)
+	require.NoError(t, err)
+
+	dah, err := c.GetDAH(ctx, key, fileformat.FileTypeSubtree)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(10), dah)
+
+	// the DAH of the cached blob follows the changes made through the cache
+	require.NoError(t, c.SetDAH(ctx, key, fileformat.FileTypeSubtree, 20))
+
+	dah, err = c.GetDAH(ctx, key, fileformat.FileTypeSubtree)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(20), dah)
+
+	c.SetCurrentBlockHeight(19)
+	assert.Equal(t, 1, cachedFiles(t, dir))
+
+	_, err = c.Get(ctx, key, fileformat.FileTypeSubtree)
+	require.NoError(t, err)
+	assert.Equal(t, 1, underlying.Counters["get"])
+
+	// the cached blob is removed once the DAH is reached
+	c.SetCurrentBlockHeight(20)
+	assert.Zero(t, cachedFiles(t, dir))
+
+	// a blob without a DAH stays cached
+	require.NoError(t, c.SetDAH(ctx, key, fileformat.FileTypeSubtree, 0))
+
+	_, err = c.Get(ctx, key, fileformat.FileTypeSubtree)
+	require.NoError(t, err)
+
+	c.SetCurrentBlockHeight(1000)
+	assert.Equal(t, 1, cachedFiles(t, dir))
+}
+
+func TestCache_Eviction(t *testing.T) {
+	ctx := context.Background()
+	c, underlying, dir := newTestCache(t, 100, 0)
+
+	keys := [][]byte{[]byte("key1"), []byte("key2"), []byte("key3")}
+
+	for _, key := range keys {
+		require.NoError(t, c.Set(ctx, key, fileformat.FileTypeSubtree, bytes.Repeat(key[3:], 40)))
+	}
+
+	for _, key := range keys[:2] {
+		_, err := c.Get(ctx, key, fileformat.FileTypeSubtree)
+		require.NoError(t, err)
+	}
+
+	// key1 is the least recently used blob
+	_, err := c.Get(ctx, keys[1], fileformat.FileTypeSubtree)
+	require.NoError(t, err)
+
+	_, err = c.Get(ctx, keys[2], fileformat.FileTypeSubtree)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, cachedFiles(t, dir))
+	assert.Equal(t, int64(80), c.diskBytes)
+	assert.Equal(t, 3, underlying.Counters["get"])
+
+	_, err = c.Get(ctx, keys[1], fileformat.FileTypeSubtree)
+	require.NoError(t, err)
+	assert.Equal(t, 3, underlying.Counters["get"])
+
+	value, err := c.Get(ctx, keys[0], fileformat.FileTypeSubtree)
+	require.NoError(t, err)
+	assert.Equal(t, bytes.Repeat([]byte("1"), 40), value)
+	assert.Equal(t, 4, underlying.Counters["get"])
+
+	// blobs larger than the cache are not cached
+	require.NoError(t, c.Set(ctx, []byte("large"), fileformat.FileTypeSubtree, make([]byte, 101)))
+
+	reader, err := c.GetIoReader(ctx, []byte("large"), fileformat.FileTypeSubtree)
+	require.NoError(t, err)
+
+	value, err = io.ReadAll(reader)
+	require.NoError(t, err)
+	require.NoError(t, reader.Close())
+	assert.Len(t, value, 101)
+	assert.Equal(t, 2, cachedFiles(t, dir))
+}
+
+func TestCache_MemoryTier(t *testing.T) {
+	ctx := context.Background()
+	c, _, dir := newTestCache(t, 1024, 10)
+
+	require.NoError(t, c.Set(ctx, []byte("small"), fileformat.FileTypeSubtree, []byte("small")))
+	require.NoError(t, c.Set(ctx, []byte("large"), fileformat.FileTypeSubtree, []byte("too large for memory")))
+
+	for _, key := range [][]byte{[]byte("small"), []byte("large")} {
+		_, err := c.Get(ctx, key, fileformat.FileTypeSubtree)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, int64(5), c.memoryBytes)
+
+	// the small blob is served from memory without its file
+	require.NoError(t, os.Remove(c.filePath(cacheID([]byte("small"), fileformat.FileTypeSubtree, nil))))
+
+	reader, err := c.GetIoReader(ctx, []byte("small"), fileformat.FileTypeSubtree)
+	require.NoError(t, err)
+
+	value, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("small"), value)
+	assert.Equal(t, 1, cachedFiles(t, dir))
+}
+
+func TestCache_Seek(t *testing.T) {
+	ctx := context.Background()
+
+	for _, maxMemoryBytes := range []int64{0, 1024} {
+		c, _, _ := newTestCache(t, 1024, maxMemoryBytes)
+
+		key := []byte("key")
+		require.NoError(t, c.Set(ctx, key, fileformat.FileTypeSubtree, []byte("0123456789")))
+
+		// the reader of the blob read from the wrapped store and of the cached blob can seek
+		for i := 0; i < 2; i++ {
+			if i == 1 {
+				_, err := c.Get(ctx, key, fileformat.FileTypeSubtree)
+				require.NoError(t, err)
+			}
+
+			reader, err := c.GetIoReader(ctx, key, fileformat.FileTypeSubtree)
+			require.NoError(t, err)
+
+			seeker, ok := reader.(io.Seeker)
+			require.True(t, ok)
+
+			_, err = seeker.Seek(6, io.SeekStart)
+			require.NoError(t, err)
+
+			value := make([]byte, 3)
+			_, err = io.ReadFull(reader, value)
+			require.NoError(t, err)
+			assert.Equal(t, []byte("678"), value)
+
+			_, err = seeker.Seek(-2, io.SeekEnd)
+			require.NoError(t, err)
+
+			value, err = io.ReadAll(reader)
+			require.NoError(t, err)
+			assert.Equal(t, []byte("89"), value)
+
+			require.NoError(t, reader.Close())
+		}
+	}
+}
+
+func TestNew_ClearsDir(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "stale"+fileSuffix), []byte("stale"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "partial"+tempSuffix), []byte("partial"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other"), []byte("other"), 0o600))
+
+	_, err := New(ulogger.TestLogger{}, memory.New(), dir, 1024, 0)
+	require.NoError(t, err)
+
+	dirEntries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, dirEntries, 1)
+	assert.Equal(t, "other", dirEntries[0].Name())
+
+	_, err = New(ulogger.TestLogger{}, memory.New(), dir, 0, 0)
+	require.Error(t, err)
+}
@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	prometheusCacheHits      *prometheus.CounterVec
+	prometheusCacheMisses    *prometheus.CounterVec
+	prometheusCacheEvictions *prometheus.CounterVec
+	prometheusCacheSize      *prometheus.GaugeVec
+
+	// only init the metrics once
+	prometheusMetricsInitOnce sync.Once
+)
+
+func initPrometheusMetrics() {
+	prometheusMetricsInitOnce.Do(_initPrometheusMetrics)
+}
+
+func _initPrometheusMetrics() {
+	prometheusCacheHits = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "teranode",
+			Subsystem: "blob_cache",
+			Name:      "hits",
+			Help:      "Number of blob reads served from the cache, by the tier they were served from",
+		},
+		[]string{"file_type", "tier"},
+	)
+	prometheusCacheMisses = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "teranode",
+			Subsystem: "blob_cache",
+			Name:      "misses",
+			Help:      "Number of blob reads not found in the cache, which are read from the wrapped store",
+		},
+		[]string{"file_type"},
+	)
+	prometheusCacheEvictions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "teranode",
+			Subsystem: "blob_cache",
+			Name:      "evictions",
+			Help:      "Number of blobs evicted from a tier of the cache to stay within its size",
+		},
+		[]string{"tier"},
+	)
+	prometheusCacheSize = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "teranode",
+			Subsystem: "blob_cache",
+			Name:      "size_bytes",
+			Help:      "Number of bytes held by a tier of the cache",
+		},
+		[]string{"tier"},
+	)
+}
@@ -0,0 +1,385 @@
+package compression
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+const (
+	// chunkSize is the number of uncompressed bytes compressed into a single chunk
+	chunkSize = 1024 * 1024
+	// maxChunkSize is the largest chunk accepted when reading, guarding against allocating for corrupt chunk headers
+	maxChunkSize = 64 * 1024 * 1024
+	// chunkHeaderSize is the size of the header of a chunk, the uncompressed and stored length as uint32
+	chunkHeaderSize = 8
+)
+
+// blockCodec compresses and decompresses the chunks of a compressed blob. Every chunk is compressed
+// independently, which is what allows a reader to skip chunks when seeking.
+type blockCodec interface {
+	// compress returns the compressed chunk, or nil when the chunk could not be compressed
+	compress(src []byte) ([]byte, error)
+	// decompress returns the uncompressed chunk of rawLen bytes
+	decompress(src []byte, rawLen int) ([]byte, error)
+}
+
+// newBlockCodecs returns the block codecs of all supported codecs, blobs are read with the codec recorded in
+// their compression header, whatever codec the store is configured to write with
+func newBlockCodecs() (map[fileformat.Codec]blockCodec, error) {
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		return nil, errors.NewStorageError("failed to create zstd encoder", err)
+	}
+
+	decoder, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(0))
+	if err != nil {
+		return nil, errors.NewStorageError("failed to create zstd decoder", err)
+	}
+
+	return map[fileformat.Codec]blockCodec{
+		fileformat.CodecZstd: &zstdCodec{encoder: encoder, decoder: decoder},
+		fileformat.CodecLZ4:  &lz4Codec{},
+	}, nil
+}
+
+// zstdCodec compresses every chunk into an independent zstd frame. The chunks are stored in the chunk
+// container described by fileformat.CompressionHeader, which is not a seekable zstd stream.
+type zstdCodec struct {
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+}
+
+func (c *zstdCodec) compress(src []byte) ([]byte, error) {
+	return c.encoder.EncodeAll(src, make([]byte, 0, len(src))), nil
+}
+
+func (c *zstdCodec) decompress(src []byte, rawLen int) ([]byte, error) {
+	return c.decoder.DecodeAll(src, make([]byte, 0, rawLen))
+}
+
+// lz4Codec compresses every chunk into an lz4 block
+type lz4Codec struct{}
+
+func (c *lz4Codec) compress(src []byte) ([]byte, error) {
+	dst := make([]byte, lz4.CompressBlockBound(len(src)))
+
+	n, err := lz4.CompressBlock(src, dst, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// 0 bytes means the chunk is not compressible
+	return dst[:n], nil
+}
+
+func (c *lz4Codec) decompress(src []byte, rawLen int) ([]byte, error) {
+	dst := make([]byte, rawLen)
+
+	n, err := lz4.UncompressBlock(src, dst)
+	if err != nil {
+		return nil, err
+	}
+
+	return dst[:n], nil
+}
+
+// writer compresses the data written to it in chunks of chunkSize bytes, in the chunk container described by
+// fileformat.CompressionHeader. Every chunk is written as a chunk header, the uncompressed and stored length as
+// little endian uint32, followed by the stored bytes. Chunks that do not get smaller when compressed are stored
+// uncompressed, which is recorded by equal lengths.
+type writer struct {
+	w      io.Writer
+	codec  blockCodec
+	chunk  []byte
+	header [chunkHeaderSize]byte
+}
+
+func newWriter(w io.Writer, codec blockCodec) *writer {
+	return &writer{
+		w:     w,
+		codec: codec,
+	}
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	written := 0
+
+	for len(p) > 0 {
+		if w.chunk == nil {
+			w.chunk = make([]byte, 0, chunkSize)
+		}
+
+		n := min(len(p), chunkSize-len(w.chunk))
+		w.chunk = append(w.chunk, p[:n]...)
+		p = p[n:]
+		written += n
+
+		if len(w.chunk) == chunkSize {
+			if err := w.flush(); err != nil {
+				return written, err
+			}
+		}
+	}
+
+	return written, nil
+}
+
+// Close writes the last chunk, it does not close the underlying writer
+func (w *writer) Close() error {
+	return w.flush()
+}
+
+func (w *writer) flush() error {
+	if len(w.chunk) == 0 {
+		return nil
+	}
+
+	stored, err := w.codec.compress(w.chunk)
+	if err != nil {
+		return errors.NewProcessingError("failed to compress chunk", err)
+	}
+
+	if len(stored) == 0 || len(stored) >= len(w.chunk) {
+		stored = w.chunk
+	}
+
+	binary.LittleEndian.PutUint32(w.header[0:4], uint32(len(w.chunk))) //nolint:gosec // chunks are at most chunkSize bytes
+	binary.LittleEndian.PutUint32(w.header[4:8], uint32(len(stored)))  //nolint:gosec // stored chunks are never larger than the chunk
+
+	if _, err = w.w.Write(w.header[:]); err != nil {
+		return err
+	}
+
+	if _, err = w.w.Write(stored); err != nil {
+		return err
+	}
+
+	w.chunk = w.chunk[:0]
+
+	return nil
+}
+
+// reader decompresses the chunks written by writer. It implements io.Seeker on the uncompressed data: seeking
+// forward skips the chunks before the new position without decompressing them, seeking backward and relative
+// to the end is only possible when the underlying reader can seek.
+type reader struct {
+	r      io.Reader
+	closer io.Closer
+	codec  blockCodec
+	// dataStart is the offset of the first chunk in r, -1 when r cannot seek
+	dataStart int64
+	// chunk is the uncompressed data of the current chunk
+	chunk []byte
+	// chunkStart is the offset of the current chunk in the uncompressed data
+	chunkStart int64
+	// pos is the read position in the uncompressed data
+	pos int64
+	// size is the length of the uncompressed data, -1 until it is read for a seek relative to the end
+	size   int64
+	header [chunkHeaderSize]byte
+}
+
+func newReader(r io.Reader, closer io.Closer, codec blockCodec, dataStart int64) *reader {
+	return &reader{
+		r:         r,
+		closer:    closer,
+		codec:     codec,
+		dataStart: dataStart,
+		size:      -1,
+	}
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	for r.pos >= r.chunkStart+int64(len(r.chunk)) {
+		if err := r.nextChunk(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, r.chunk[r.pos-r.chunkStart:])
+	r.pos += int64(n)
+
+	return n, nil
+}
+
+// nextChunk moves to the chunk following the current chunk. Chunks that end before the read position are
+// skipped without decompressing them, it returns io.EOF after the last chunk.
+func (r *reader) nextChunk() error {
+	if _, err := io.ReadFull(r.r, r.header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return errors.NewStorageError("truncated compressed chunk header", err)
+		}
+
+		return err
+	}
+
+	rawLen := int(binary.LittleEndian.Uint32(r.header[0:4]))
+	storedLen := int(binary.LittleEndian.Uint32(r.header[4:8]))
+
+	if rawLen > maxChunkSize || storedLen > maxChunkSize {
+		return errors.NewStorageError("invalid compressed chunk of %d bytes, stored in %d bytes", rawLen, storedLen)
+	}
+
+	start := r.chunkStart + int64(len(r.chunk))
+
+	if r.pos >= start+int64(rawLen) {
+		if err := r.skip(int64(storedLen)); err != nil {
+			return err
+		}
+
+		r.chunk = r.chunk[:0]
+		r.chunkStart = start + int64(rawLen)
+
+		return nil
+	}
+
+	stored := make([]byte, storedLen)
+	if _, err := io.ReadFull(r.r, stored); err != nil {
+		return errors.NewStorageError("truncated compressed chunk", err)
+	}
+
+	chunk := stored
+
+	if storedLen != rawLen {
+		var err error
+
+		if chunk, err = r.codec.decompress(stored, rawLen); err != nil {
+			return errors.NewStorageError("failed to decompress chunk", err)
+		}
+
+		if len(chunk) != rawLen {
+			return errors.NewStorageError("decompressed chunk is %d bytes, expected %d", len(chunk), rawLen)
+		}
+	}
+
+	r.chunk = chunk
+	r.chunkStart = start
+
+	return nil
+}
+
+// skip skips n bytes of the underlying reader
+func (r *reader) skip(n int64) error {
+	if seeker, ok := r.r.(io.Seeker); ok && r.dataStart >= 0 {
+		_, err := seeker.Seek(n, io.SeekCurrent)
+		return err
+	}
+
+	if _, err := io.CopyN(io.Discard, r.r, n); err != nil {
+		return errors.NewStorageError("truncated compressed chunk", err)
+	}
+
+	return nil
+}
+
+func (r *reader) Seek(offset int64, whence int) (int64, error) {
+	var target int64
+
+	switch whence {
+	case io.SeekStart:
+		target = offset
+	case io.SeekCurrent:
+		target = r.pos + offset
+	case io.SeekEnd:
+		size, err := r.uncompressedSize()
+		if err != nil {
+			return r.pos, err
+		}
+
+		target = size + offset
+	default:
+		return r.pos, errors.NewStorageError("invalid whence %d", whence)
+	}
+
+	if target < 0 {
+		return r.pos, errors.NewStorageError("invalid seek to negative offset %d", target)
+	}
+
+	if target < r.chunkStart {
+		seeker, ok := r.r.(io.Seeker)
+		if !ok || r.dataStart < 0 {
+			return r.pos, errors.NewStorageError("seeking backward is not supported by the underlying store")
+		}
+
+		if _, err := seeker.Seek(r.dataStart, io.SeekStart); err != nil {
+			return r.pos, err
+		}
+
+		r.chunk = r.chunk[:0]
+		r.chunkStart = 0
+	}
+
+	// the chunks up to the new position are skipped on the next read
+	r.pos = target
+
+	return target, nil
+}
+
+// uncompressedSize returns the length of the uncompressed data. The container does not record it, so it is
+// summed from the chunk headers, seeking over the stored chunks, after which the underlying reader is returned
+// to its position.
+func (r *reader) uncompressedSize() (int64, error) {
+	if r.size >= 0 {
+		return r.size, nil
+	}
+
+	seeker, ok := r.r.(io.Seeker)
+	if !ok || r.dataStart < 0 {
+		return 0, errors.NewStorageError("seeking relative to the end is not supported by the underlying store")
+	}
+
+	current, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err = seeker.Seek(r.dataStart, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	var (
+		size   int64
+		header [chunkHeaderSize]byte
+	)
+
+	for {
+		if _, err = io.ReadFull(r.r, header[:]); err != nil {
+			if err == io.EOF {
+				break
+			}
+
+			return 0, errors.NewStorageError("truncated compressed chunk header", err)
+		}
+
+		size += int64(binary.LittleEndian.Uint32(header[0:4]))
+
+		if _, err = seeker.Seek(int64(binary.LittleEndian.Uint32(header[4:8])), io.SeekCurrent); err != nil {
+			return 0, err
+		}
+	}
+
+	if _, err = seeker.Seek(current, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	r.size = size
+
+	return size, nil
+}
+
+func (r *reader) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+
+	return r.closer.Close()
+}
@@ -0,0 +1,300 @@
+// Package compression provides a transparent compression wrapper for blob stores.
+//
+// The Compression wrapper compresses blobs with zstd or lz4 before they are written to the wrapped store
+// and decompresses them when they are read. The codec is recorded in a fileformat.CompressionHeader at the
+// start of the blob body, directly after the file type header written by the store, so blobs written before
+// compression was enabled, or with another codec, are still read as they were stored.
+//
+// Blobs are compressed in independent chunks of 1 MiB. Writing and reading stream chunk by chunk, without
+// holding the whole blob in memory, and readers returned by GetIoReader can seek in the uncompressed data,
+// skipping the chunks before the position without decompressing them. This is what keeps range reads of the
+// HTTP blob server working for compressed blobs.
+//
+// Compression can be enabled or disabled per file type, small blobs like transactions gain little from it.
+package compression
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+	"github.com/bsv-blockchain/teranode/stores/blob/options"
+	"github.com/bsv-blockchain/teranode/ulogger"
+)
+
+// blobStore defines the interface of the wrapped blob store, it mirrors the blob.Store interface
+type blobStore interface {
+	Health(ctx context.Context, checkLiveness bool) (int, string, error)
+	Exists(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (bool, error)
+	Get(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) ([]byte, error)
+	GetIoReader(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (io.ReadCloser, error)
+	Set(ctx context.Context, key []byte, fileType fileformat.FileType, value []byte, opts ...options.FileOption) error
+	SetFromReader(ctx context.Context, key []byte, fileType fileformat.FileType, value io.ReadCloser, opts ...options.FileOption) error
+	SetDAH(ctx context.Context, key []byte, fileType fileformat.FileType, newDAH uint32, opts ...options.FileOption) error
+	GetDAH(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (uint32, error)
+	Del(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) error
+	Close(ctx context.Context) error
+	SetCurrentBlockHeight(height uint32)
+}
+
+// blobStoreLister is implemented by wrapped blob stores that can list the blobs they hold
+type blobStoreLister interface {
+	List(ctx context.Context, listOpts options.ListOptions, opts ...options.FileOption) (*options.ListResult, error)
+}
+
+// Compression is a blob store wrapper that compresses blobs before they are stored in the wrapped store.
+type Compression struct {
+	logger ulogger.Logger
+	store  blobStore
+	// codec is the codec new blobs are compressed with, CodecNone only decompresses blobs
+	codec fileformat.Codec
+	// codecs are the block codecs of all supported codecs, used to read blobs written with any codec
+	codecs map[fileformat.Codec]blockCodec
+	// fileTypes are the only file types that are compressed, all file types when empty
+	fileTypes map[fileformat.FileType]struct{}
+	// skipFileTypes are the file types that are never compressed
+	skipFileTypes map[fileformat.FileType]struct{}
+}
+
+// New creates a new Compression wrapper around the blob store.
+//
+// Parameters:
+//   - logger: Logger instance for compression operations
+//   - store: The blob store to wrap
+//   - codec: The codec to compress new blobs with, fileformat.CodecNone to store new blobs uncompressed while
+//     still reading compressed blobs
+//   - fileTypes: The only file types to compress, all file types when empty
+//   - skipFileTypes: The file types to never compress
+//
+// Returns:
+//   - *Compression: The compression wrapper
+//   - error: Any error that occurred creating the codecs
+func New(logger ulogger.Logger, store blobStore, codec fileformat.Codec, fileTypes, skipFileTypes []fileformat.FileType) (*Compression, error) {
+	codecs, err := newBlockCodecs()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := codecs[codec]; !ok && codec != fileformat.CodecNone {
+		return nil, errors.NewConfigurationError("unsupported compression codec %s", codec)
+	}
+
+	c := &Compression{
+		logger:        logger,
+		store:         store,
+		codec:         codec,
+		codecs:        codecs,
+		skipFileTypes: make(map[fileformat.FileType]struct{}, len(skipFileTypes)),
+	}
+
+	if len(fileTypes) > 0 {
+		c.fileTypes = make(map[fileformat.FileType]struct{}, len(fileTypes))
+
+		for _, fileType := range fileTypes {
+			c.fileTypes[fileType] = struct{}{}
+		}
+	}
+
+	for _, fileType := range skipFileTypes {
+		c.skipFileTypes[fileType] = struct{}{}
+	}
+
+	return c, nil
+}
+
+// compresses returns whether new blobs of the file type are compressed. Blobs stored without a header, for
+// readability outside of Teranode, are not compressed either.
+func (c *Compression) compresses(fileType fileformat.FileType, opts []options.FileOption) bool {
+	if c.codec == fileformat.CodecNone {
+		return false
+	}
+
+	if c.fileTypes != nil {
+		if _, ok := c.fileTypes[fileType]; !ok {
+			return false
+		}
+	}
+
+	if _, ok := c.skipFileTypes[fileType]; ok {
+		return false
+	}
+
+	return !options.NewFileOptions(opts...).SkipHeader
+}
+
+// compress writes the compression header and the compressed data of src to w
+func (c *Compression) compress(w io.Writer, src io.Reader) error {
+	if err := fileformat.NewCompressionHeader(c.codec).Write(w); err != nil {
+		return err
+	}
+
+	cw := newWriter(w, c.codecs[c.codec])
+
+	if _, err := io.Copy(cw, src); err != nil {
+		return err
+	}
+
+	return cw.Close()
+}
+
+func (c *Compression) Health(ctx context.Context, checkLiveness bool) (int, string, error) {
+	return c.store.Health(ctx, checkLiveness)
+}
+
+func (c *Compression) Exists(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (bool, error) {
+	return c.store.Exists(ctx, key, fileType, opts...)
+}
+
+func (c *Compression) Set(ctx context.Context, key []byte, fileType fileformat.FileType, value []byte, opts ...options.FileOption) error {
+	if !c.compresses(fileType, opts) {
+		return c.store.Set(ctx, key, fileType, value, opts...)
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, len(value)/2))
+
+	if err := c.compress(buf, bytes.NewReader(value)); err != nil {
+		return errors.NewStorageError("[Compression][Set] failed to compress %s", fileType, err)
+	}
+
+	return c.store.Set(ctx, key, fileType, buf.Bytes(), opts...)
+}
+
+// SetFromReader compresses the reader while it is streamed to the wrapped store.
+func (c *Compression) SetFromReader(ctx context.Context, key []byte, fileType fileformat.FileType, reader io.ReadCloser, opts ...options.FileOption) error {
+	if !c.compresses(fileType, opts) {
+		return c.store.SetFromReader(ctx, key, fileType, reader, opts...)
+	}
+
+	pr, pw := io.Pipe()
+
+	go func() {
+		defer reader.Close()
+
+		_ = pw.CloseWithError(c.compress(pw, reader))
+	}()
+
+	err := c.store.SetFromReader(ctx, key, fileType, pr, opts...)
+
+	// unblock the compression when the store stopped reading before the end
+	_ = pr.CloseWithError(io.ErrClosedPipe)
+
+	return err
+}
+
+func (c *Compression) SetDAH(ctx context.Context, key []byte, fileType fileformat.FileType, newDAH uint32, opts ...options.FileOption) error {
+	return c.store.SetDAH(ctx, key, fileType, newDAH, opts...)
+}
+
+func (c *Compression) GetDAH(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (uint32, error) {
+	return c.store.GetDAH(ctx, key, fileType, opts...)
+}
+
+// GetIoReader returns a reader of the uncompressed blob. The reader implements io.Seeker when the blob is
+// compressed, or when it is not compressed and the reader of the wrapped store implements io.Seeker.
+// For a compressed blob, seeking backward or relative to the end fails when the reader of the wrapped store
+// does not implement io.Seeker. A seek relative to the end reads all chunk headers, as the uncompressed
+// length of a blob is not stored.
+func (c *Compression) GetIoReader(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (io.ReadCloser, error) {
+	rc, err := c.store.GetIoReader(ctx, key, fileType, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	seeker, canSeek := rc.(io.Seeker)
+
+	var start int64
+
+	if canSeek {
+		if start, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			canSeek = false
+		}
+	}
+
+	codec, body, err := fileformat.ReadCompressionHeader(rc)
+	if err != nil {
+		_ = rc.Close()
+		return nil, errors.NewStorageError("[Compression][GetIoReader] failed to read compression header of %s", fileType, err)
+	}
+
+	if codec == fileformat.CodecNone {
+		if canSeek {
+			// rewind, so the seekable reader of the store can be returned as is
+			if _, err = seeker.Seek(start, io.SeekStart); err == nil {
+				return rc, nil
+			}
+
+			c.logger.Warnf("[Compression][GetIoReader] failed to rewind reader of %s, returning a reader that cannot seek: %v", fileType, err)
+		}
+
+		return &readCloser{Reader: body, Closer: rc}, nil
+	}
+
+	decoder, ok := c.codecs[codec]
+	if !ok {
+		_ = rc.Close()
+		return nil, errors.NewStorageError("[Compression][GetIoReader] unsupported compression codec %s", codec)
+	}
+
+	dataStart := int64(-1)
+	if canSeek {
+		dataStart = start + int64(fileformat.CompressionHeader{}.Size())
+	}
+
+	return newReader(rc, rc, decoder, dataStart), nil
+}
+
+func (c *Compression) Get(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) ([]byte, error) {
+	value, err := c.store.Get(ctx, key, fileType, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	codec := fileformat.CodecFromBytes(value)
+	if codec == fileformat.CodecNone {
+		return value, nil
+	}
+
+	decoder, ok := c.codecs[codec]
+	if !ok {
+		return nil, errors.NewStorageError("[Compression][Get] unsupported compression codec %s", codec)
+	}
+
+	body := bytes.NewReader(value[fileformat.CompressionHeader{}.Size():])
+
+	uncompressed, err := io.ReadAll(newReader(body, nil, decoder, -1))
+	if err != nil {
+		return nil, errors.NewStorageError("[Compression][Get] failed to decompress %s", fileType, err)
+	}
+
+	return uncompressed, nil
+}
+
+func (c *Compression) Del(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) error {
+	return c.store.Del(ctx, key, fileType, opts...)
+}
+
+// List lists the blobs of the wrapped store, compression does not change the blobs that are listed.
+func (c *Compression) List(ctx context.Context, listOpts options.ListOptions, opts ...options.FileOption) (*options.ListResult, error) {
+	lister, ok := c.store.(blobStoreLister)
+	if !ok {
+		return nil, errors.NewStorageError("[Compression] blob store %T does not support listing", c.store)
+	}
+
+	return lister.List(ctx, listOpts, opts...)
+}
+
+func (c *Compression) Close(ctx context.Context) error {
+	return c.store.Close(ctx)
+}
+
+func (c *Compression) SetCurrentBlockHeight(height uint32) {
+	c.store.SetCurrentBlockHeight(height)
+}
+
+// readCloser closes the reader of the wrapped store after reading from another reader over it
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
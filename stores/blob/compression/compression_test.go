@@ -0,0 +1,248 @@
+package compression
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"net/url"
+	"testing"
+
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+	"github.com/bsv-blockchain/teranode/stores/blob/file"
+	"github.com/bsv-blockchain/teranode/stores/blob/memory"
+	"github.com/bsv-blockchain/teranode/stores/blob/options"
+	"github.com/bsv-blockchain/teranode/ulogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testData returns compressible data spanning several chunks, ending in an incomplete chunk
+func testData() []byte {
+	rnd := rand.New(rand.NewSource(1)) //nolint:gosec // test data
+
+	data := make([]byte, 3*chunkSize+12345)
+	for i := range data {
+		if i%16 == 0 {
+			data[i] = byte(rnd.Intn(256))
+		} else {
+			data[i] = byte(i % 7)
+		}
+	}
+
+	return data
+}
+
+func TestCompression_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	data := testData()
+
+	for _, codec := range []fileformat.Codec{fileformat.CodecZstd, fileformat.CodecLZ4} {
+		t.Run(codec.String(), func(t *testing.T) {
+			underlying := memory.New()
+
+			store, err := New(ulogger.TestLogger{}, underlying, codec, nil, nil)
+			require.NoError(t, err)
+
+			key := []byte("set")
+			require.NoError(t, store.Set(ctx, key, fileformat.FileTypeSubtreeData, data))
+
+			stored, err := underlying.Get(ctx, key, fileformat.FileTypeSubtreeData)
+			require.NoError(t, err)
+			assert.Equal(t, codec, fileformat.CodecFromBytes(stored))
+			assert.Less(t, len(stored), len(data))
+
+			value, err := store.Get(ctx, key, fileformat.FileTypeSubtreeData)
+			require.NoError(t, err)
+			assert.Equal(t, data, value)
+
+			key = []byte("setFromReader")
+			require.NoError(t, store.SetFromReader(ctx, key, fileformat.FileTypeSubtreeData, io.NopCloser(bytes.NewReader(data))))
+
+			reader, err := store.GetIoReader(ctx, key, fileformat.FileTypeSubtreeData)
+			require.NoError(t, err)
+
+			value, err = io.ReadAll(reader)
+			require.NoError(t, err)
+			require.NoError(t, reader.Close())
+			assert.Equal(t, data, value)
+		})
+	}
+}
+
+func TestCompression_IncompressibleData(t *testing.T) {
+	ctx := context.Background()
+
+	data := make([]byte, chunkSize+100)
+	_, _ = rand.New(rand.NewSource(2)).Read(data) //nolint:gosec // test data
+
+	for _, codec := range []fileformat.Codec{fileformat.CodecZstd, fileformat.CodecLZ4} {
+		store, err := New(ulogger.TestLogger{}, memory.New(), codec, nil, nil)
+		require.NoError(t, err)
+
+		require.NoError(t, store.Set(ctx, []byte("key"), fileformat.FileTypeSubtreeData, data))
+
+		value, err := store.Get(ctx, []byte("key"), fileformat.FileTypeSubtreeData)
+		require.NoError(t, err)
+		assert.Equal(t, data, value, codec.String())
+	}
+}
+
+func TestCompression_MixedBlobs(t *testing.T) {
+	ctx := context.Background()
+	data := testData()
+	underlying := memory.New()
+
+	// a blob written before compression was enabled, and one written with another codec
+	require.NoError(t, underlying.Set(ctx, []byte("plain"), fileformat.FileTypeSubtreeData, data))
+
+	lz4Store, err := New(ulogger.TestLogger{}, underlying, fileformat.CodecLZ4, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, lz4Store.Set(ctx, []byte("lz4"), fileformat.FileTypeSubtreeData, data))
+
+	store, err := New(ulogger.TestLogger{}, underlying, fileformat.CodecZstd, nil, nil)
+	require.NoError(t, err)
+
+	for _, key := range []string{"plain", "lz4"} {
+		value, err := store.Get(ctx, []byte(key), fileformat.FileTypeSubtreeData)
+		require.NoError(t, err)
+		assert.Equal(t, data, value, key)
+
+		reader, err := store.GetIoReader(ctx, []byte(key), fileformat.FileTypeSubtreeData)
+		require.NoError(t, err)
+
+		value, err = io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, data, value, key)
+	}
+
+	// blobs shorter than the compression header are not compressed
+	require.NoError(t, underlying.Set(ctx, []byte("short"), fileformat.FileTypeTx, []byte("tx")))
+
+	reader, err := store.GetIoReader(ctx, []byte("short"), fileformat.FileTypeTx)
+	require.NoError(t, err)
+
+	value, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("tx"), value)
+}
+
+func TestCompression_FileTypes(t *testing.T) {
+	ctx := context.Background()
+	data := testData()
+
+	tests := []struct {
+		name          string
+		fileTypes     []fileformat.FileType
+		skipFileTypes []fileformat.FileType
+		fileType      fileformat.FileType
+		opts          []options.FileOption
+		compressed    bool
+	}{
+		{name: "all file types", fileType: fileformat.FileTypeTx, compressed: true},
+		{name: "skipped", skipFileTypes: []fileformat.FileType{fileformat.FileTypeTx}, fileType: fileformat.FileTypeTx},
+		{name: "not skipped", skipFileTypes: []fileformat.FileType{fileformat.FileTypeTx}, fileType: fileformat.FileTypeBlock, compressed: true},
+		{name: "included", fileTypes: []fileformat.FileType{fileformat.FileTypeBlock}, fileType: fileformat.FileTypeBlock, compressed: true},
+		{name: "not included", fileTypes: []fileformat.FileType{fileformat.FileTypeBlock}, fileType: fileformat.FileTypeTx},
+		{name: "skip header", fileType: fileformat.FileTypeBlock, opts: []options.FileOption{options.WithSkipHeader(true)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			underlying := memory.New()
+
+			store, err := New(ulogger.TestLogger{}, underlying, fileformat.CodecZstd, tt.fileTypes, tt.skipFileTypes)
+			require.NoError(t, err)
+
+			require.NoError(t, store.Set(ctx, []byte("key"), tt.fileType, data, tt.opts...))
+
+			stored, err := underlying.Get(ctx, []byte("key"), tt.fileType, tt.opts...)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.compressed, fileformat.CodecFromBytes(stored) == fileformat.CodecZstd)
+		})
+	}
+}
+
+func TestCompression_Seek(t *testing.T) {
+	ctx := context.Background()
+	data := testData()
+
+	u, err := url.Parse("file://" + t.TempDir())
+	require.NoError(t, err)
+
+	fileStore, err := file.New(ulogger.TestLogger{}, u)
+	require.NoError(t, err)
+
+	for name, underlying := range map[string]blobStore{"memory": memory.New(), "file": fileStore} {
+		t.Run(name, func(t *testing.T) {
+			store, err := New(ulogger.TestLogger{}, underlying, fileformat.CodecZstd, nil, nil)
+			require.NoError(t, err)
+
+			require.NoError(t, store.Set(ctx, []byte("key"), fileformat.FileTypeSubtreeData, data))
+
+			reader, err := store.GetIoReader(ctx, []byte("key"), fileformat.FileTypeSubtreeData)
+			require.NoError(t, err)
+
+			defer reader.Close()
+
+			seeker, ok := reader.(io.Seeker)
+			require.True(t, ok)
+
+			buf := make([]byte, 100)
+
+			// seek forward into the third chunk
+			offset := int64(2*chunkSize + 500)
+
+			pos, err := seeker.Seek(offset, io.SeekStart)
+			require.NoError(t, err)
+			assert.Equal(t, offset, pos)
+
+			_, err = io.ReadFull(reader, buf)
+			require.NoError(t, err)
+			assert.Equal(t, data[offset:offset+100], buf)
+
+			// seek within the current chunk
+			pos, err = seeker.Seek(-50, io.SeekCurrent)
+			require.NoError(t, err)
+			assert.Equal(t, offset+50, pos)
+
+			_, err = io.ReadFull(reader, buf)
+			require.NoError(t, err)
+			assert.Equal(t, data[offset+50:offset+150], buf)
+
+			// seeking back into an earlier chunk, or relative to the end, needs a seekable store reader
+			_, err = seeker.Seek(10, io.SeekStart)
+			if name == "memory" {
+				require.Error(t, err)
+
+				_, err = seeker.Seek(-100, io.SeekEnd)
+				require.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			_, err = io.ReadFull(reader, buf)
+			require.NoError(t, err)
+			assert.Equal(t, data[10:110], buf)
+
+			pos, err = seeker.Seek(-100, io.SeekEnd)
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(data)-100), pos)
+
+			_, err = io.ReadFull(reader, buf)
+			require.NoError(t, err)
+			assert.Equal(t, data[len(data)-100:], buf)
+
+			_, err = reader.Read(buf)
+			assert.ErrorIs(t, err, io.EOF)
+		})
+	}
+}
+
+func TestCompression_InvalidCodec(t *testing.T) {
+	_, err := New(ulogger.TestLogger{}, memory.New(), fileformat.Codec("gzip"), nil, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,351 @@
+package encryption
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"io"
+
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+)
+
+const (
+	// chunkSize is the number of plain bytes sealed into a single chunk
+	chunkSize = 64 * 1024
+	// tagSize is the size of the AES-GCM tag stored after the ciphertext of every chunk
+	tagSize = 16
+	// storedChunkSize is the stored size of every chunk but the last
+	storedChunkSize = chunkSize + tagSize
+)
+
+// blobAAD returns the additional data of the chunks of a blob, the key ID and the file type, followed by a byte
+// for the last flag of the chunk, which chunkAAD sets
+func blobAAD(keyID uint32, fileType fileformat.FileType) []byte {
+	aad := binary.LittleEndian.AppendUint32(nil, keyID)
+	aad = append(aad, fileType...)
+
+	return append(aad, 0)
+}
+
+// chunkAAD sets the last flag of the additional data of a blob, 1 for the last chunk and 0 for the other chunks
+func chunkAAD(aad []byte, last bool) []byte {
+	aad[len(aad)-1] = 0
+	if last {
+		aad[len(aad)-1] = 1
+	}
+
+	return aad
+}
+
+// chunkNonce returns the nonce of a chunk, zeros followed by the index of the chunk. The nonces are only unique
+// per blob, which is safe as every blob is encrypted with a key of its own.
+func chunkNonce(index uint32) []byte {
+	nonce := make([]byte, 12)
+	binary.BigEndian.PutUint32(nonce[8:], index)
+
+	return nonce
+}
+
+// writer seals the data written to it in chunks of chunkSize bytes, in the encrypted chunk container described
+// by fileformat.EncryptionHeader. Close seals the last chunk, which is shorter than chunkSize and may be empty.
+type writer struct {
+	w     io.Writer
+	aead  cipher.AEAD
+	aad   []byte
+	index uint32
+	chunk []byte
+}
+
+func newWriter(w io.Writer, aead cipher.AEAD, aad []byte) *writer {
+	return &writer{
+		w:     w,
+		aead:  aead,
+		aad:   aad,
+		chunk: make([]byte, 0, chunkSize),
+	}
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	written := 0
+
+	for len(p) > 0 {
+		// a full chunk is only sealed once more data follows, as the last chunk must be shorter than chunkSize
+		if len(w.chunk) == chunkSize {
+			if err := w.seal(false); err != nil {
+				return written, err
+			}
+		}
+
+		n := min(len(p), chunkSize-len(w.chunk))
+		w.chunk = append(w.chunk, p[:n]...)
+		p = p[n:]
+		written += n
+	}
+
+	return written, nil
+}
+
+// Close seals the last chunk, it does not close the underlying writer
+func (w *writer) Close() error {
+	if len(w.chunk) == chunkSize {
+		if err := w.seal(false); err != nil {
+			return err
+		}
+	}
+
+	return w.seal(true)
+}
+
+func (w *writer) seal(last bool) error {
+	sealed := w.aead.Seal(nil, chunkNonce(w.index), w.chunk, chunkAAD(w.aad, last))
+
+	if _, err := w.w.Write(sealed); err != nil {
+		return err
+	}
+
+	w.index++
+	w.chunk = w.chunk[:0]
+
+	return nil
+}
+
+// reader opens the chunks written by writer. It implements io.Seeker on the plain data: as all chunks but the
+// last have the same stored size, the chunk of a position is read without opening the chunks before it, they
+// are skipped, or seeked over when the underlying reader can seek. Seeking backward and relative to the end is
+// only possible when the underlying reader can seek.
+type reader struct {
+	r      io.Reader
+	closer io.Closer
+	aead   cipher.AEAD
+	aad    []byte
+	// dataStart is the offset of the first chunk in r, -1 when r cannot seek
+	dataStart int64
+	// next is the index of the chunk at the position of r
+	next int64
+	// chunk is the plain data of the current chunk
+	chunk []byte
+	// chunkIndex is the index of the current chunk, -1 before a chunk is read
+	chunkIndex int64
+	// lastIndex is the index of the last chunk, -1 until the last chunk is read
+	lastIndex int64
+	// pos is the read position in the plain data
+	pos int64
+	// size is the length of the plain data, -1 until it is computed for a seek relative to the end
+	size int64
+	// buf holds the stored chunk while it is opened
+	buf []byte
+}
+
+func newReader(r io.Reader, closer io.Closer, aead cipher.AEAD, aad []byte, dataStart int64) *reader {
+	return &reader{
+		r:          r,
+		closer:     closer,
+		aead:       aead,
+		aad:        aad,
+		dataStart:  dataStart,
+		chunkIndex: -1,
+		lastIndex:  -1,
+		size:       -1,
+	}
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	index := r.pos / chunkSize
+
+	if r.lastIndex >= 0 && index > r.lastIndex {
+		return 0, io.EOF
+	}
+
+	if index != r.chunkIndex {
+		if err := r.readChunk(index); err != nil {
+			return 0, err
+		}
+	}
+
+	offset := r.pos - index*chunkSize
+	if offset >= int64(len(r.chunk)) {
+		// only the last chunk is shorter than chunkSize
+		return 0, io.EOF
+	}
+
+	n := copy(p, r.chunk[offset:])
+	r.pos += int64(n)
+
+	return n, nil
+}
+
+// readChunk reads and opens the chunk of the index. A position after the last chunk reads as the empty last chunk.
+func (r *reader) readChunk(index int64) error {
+	if index < r.next {
+		if err := r.seekChunk(index); err != nil {
+			return err
+		}
+	}
+
+	skipped := false
+
+	if index > r.next {
+		if err := r.skip((index - r.next) * storedChunkSize); err != nil {
+			return err
+		}
+
+		skipped = true
+		r.next = index
+	}
+
+	if r.buf == nil {
+		r.buf = make([]byte, storedChunkSize)
+	}
+
+	n, err := io.ReadFull(r.r, r.buf)
+
+	switch {
+	case err == io.EOF && skipped:
+		// the position is after the end of the blob
+		r.chunk = r.chunk[:0]
+		r.chunkIndex = index
+
+		return nil
+	case err == io.EOF:
+		return errors.NewStorageError("encrypted blob is truncated, the last chunk is missing")
+	case err != nil && err != io.ErrUnexpectedEOF:
+		return err
+	case n < tagSize:
+		return errors.NewStorageError("encrypted chunk %d is truncated", index)
+	}
+
+	// only the last chunk is shorter than a full chunk
+	aad := chunkAAD(r.aad, n < storedChunkSize)
+
+	// the buffer of the current chunk is reused, it is invalid when opening fails
+	r.chunkIndex = -1
+
+	chunk, err := r.aead.Open(r.chunk[:0], chunkNonce(uint32(index)), r.buf[:n], aad) //nolint:gosec // blobs have fewer than 2^32 chunks
+	if err != nil {
+		return errors.NewStorageError("failed to decrypt chunk %d", index, err)
+	}
+
+	r.chunk = chunk
+	r.chunkIndex = index
+	r.next = index + 1
+
+	if n < storedChunkSize {
+		r.lastIndex = index
+	}
+
+	return nil
+}
+
+// seekChunk moves the underlying reader back to the start of a chunk
+func (r *reader) seekChunk(index int64) error {
+	seeker, ok := r.r.(io.Seeker)
+	if !ok || r.dataStart < 0 {
+		return errors.NewStorageError("seeking backward is not supported by the underlying store")
+	}
+
+	if _, err := seeker.Seek(r.dataStart+index*storedChunkSize, io.SeekStart); err != nil {
+		return err
+	}
+
+	r.next = index
+
+	return nil
+}
+
+// skip skips n bytes of the underlying reader
+func (r *reader) skip(n int64) error {
+	if seeker, ok := r.r.(io.Seeker); ok && r.dataStart >= 0 {
+		_, err := seeker.Seek(n, io.SeekCurrent)
+		return err
+	}
+
+	if _, err := io.CopyN(io.Discard, r.r, n); err != nil && err != io.EOF {
+		return err
+	}
+
+	return nil
+}
+
+func (r *reader) Seek(offset int64, whence int) (int64, error) {
+	var target int64
+
+	switch whence {
+	case io.SeekStart:
+		target = offset
+	case io.SeekCurrent:
+		target = r.pos + offset
+	case io.SeekEnd:
+		size, err := r.plainSize()
+		if err != nil {
+			return r.pos, err
+		}
+
+		target = size + offset
+	default:
+		return r.pos, errors.NewStorageError("invalid whence %d", whence)
+	}
+
+	if target < 0 {
+		return r.pos, errors.NewStorageError("invalid seek to negative offset %d", target)
+	}
+
+	if index := target / chunkSize; index < r.next && index != r.chunkIndex {
+		if _, ok := r.r.(io.Seeker); !ok || r.dataStart < 0 {
+			return r.pos, errors.NewStorageError("seeking backward is not supported by the underlying store")
+		}
+	}
+
+	// the chunk of the new position is read on the next read
+	r.pos = target
+
+	return target, nil
+}
+
+// plainSize returns the length of the plain data, computed from the stored length of the blob, as every chunk
+// adds a tag and the last chunk is always present
+func (r *reader) plainSize() (int64, error) {
+	if r.size >= 0 {
+		return r.size, nil
+	}
+
+	seeker, ok := r.r.(io.Seeker)
+	if !ok || r.dataStart < 0 {
+		return 0, errors.NewStorageError("seeking relative to the end is not supported by the underlying store")
+	}
+
+	current, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+
+	end, err := seeker.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err = seeker.Seek(current, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	stored := end - r.dataStart
+	if stored < tagSize {
+		return 0, errors.NewStorageError("encrypted blob is truncated")
+	}
+
+	chunks := (stored + storedChunkSize - 1) / storedChunkSize
+	r.size = stored - chunks*tagSize
+
+	return r.size, nil
+}
+
+func (r *reader) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+
+	return r.closer.Close()
+}
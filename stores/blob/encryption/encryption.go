@@ -0,0 +1,273 @@
+// Package encryption provides a transparent encryption-at-rest wrapper for blob stores.
+//
+// The Encryption wrapper encrypts blobs with AES-GCM before they are written to the wrapped store and decrypts
+// them when they are read, so block and subtree data can be kept on shared object storage that must only hold
+// client-side encrypted data. The ID of the key a blob is encrypted with is recorded in a
+// fileformat.EncryptionHeader at the start of the blob body, directly after the file type header written by the
+// store. Keys are rotated by adding a key to the Keyring: new blobs are encrypted with the current key, while
+// blobs encrypted with older keys are still read. Blobs without an encryption header, written before encryption
+// was enabled, are read as they were stored.
+//
+// Every blob is encrypted with a key of its own, derived from the keyring key and a random salt stored in the
+// encryption header, and sealed in chunks of 64 KiB with the index of the chunk as nonce. The key ID and the file
+// type are part of the additional data of every chunk, so a blob stored under another file type fails to decrypt.
+// Writing and reading stream chunk by chunk, and readers returned by GetIoReader can seek in the plain
+// data, reading only the chunks of the requested range, which keeps range reads of the HTTP blob server working.
+//
+// The wrapper only changes the blob bodies, so DAHs, batching and listing are handled by the wrapped store as
+// before. When compression is enabled as well, blobs are compressed before they are encrypted.
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+	"github.com/bsv-blockchain/teranode/stores/blob/options"
+	"github.com/bsv-blockchain/teranode/ulogger"
+)
+
+// blobStore defines the interface of the wrapped blob store, it mirrors the blob.Store interface
+type blobStore interface {
+	Health(ctx context.Context, checkLiveness bool) (int, string, error)
+	Exists(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (bool, error)
+	Get(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) ([]byte, error)
+	GetIoReader(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (io.ReadCloser, error)
+	Set(ctx context.Context, key []byte, fileType fileformat.FileType, value []byte, opts ...options.FileOption) error
+	SetFromReader(ctx context.Context, key []byte, fileType fileformat.FileType, value io.ReadCloser, opts ...options.FileOption) error
+	SetDAH(ctx context.Context, key []byte, fileType fileformat.FileType, newDAH uint32, opts ...options.FileOption) error
+	GetDAH(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (uint32, error)
+	Del(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) error
+	Close(ctx context.Context) error
+	SetCurrentBlockHeight(height uint32)
+}
+
+// blobStoreLister is implemented by wrapped blob stores that can list the blobs they hold
+type blobStoreLister interface {
+	List(ctx context.Context, listOpts options.ListOptions, opts ...options.FileOption) (*options.ListResult, error)
+}
+
+// Encryption is a blob store wrapper that encrypts blobs before they are stored in the wrapped store.
+type Encryption struct {
+	logger  ulogger.Logger
+	store   blobStore
+	keyring *Keyring
+	// encrypt is set when new blobs are encrypted, when not set blobs are only decrypted
+	encrypt bool
+}
+
+// New creates a new Encryption wrapper around the blob store.
+//
+// Parameters:
+//   - logger: Logger instance for encryption operations
+//   - store: The blob store to wrap
+//   - keyring: The keys blobs are encrypted and decrypted with
+//   - encrypt: Whether new blobs are encrypted, false to store new blobs in plain while still reading encrypted blobs
+//
+// Returns:
+//   - *Encryption: The encryption wrapper
+//   - error: Any error in the configuration
+func New(logger ulogger.Logger, store blobStore, keyring *Keyring, encrypt bool) (*Encryption, error) {
+	if keyring == nil {
+		return nil, errors.NewConfigurationError("[Encryption] keyring is required")
+	}
+
+	return &Encryption{
+		logger:  logger,
+		store:   store,
+		keyring: keyring,
+		encrypt: encrypt,
+	}, nil
+}
+
+// encryptTo writes the encryption header and the encrypted data of src to w, with a key derived from the current
+// key and a new random salt
+func (e *Encryption) encryptTo(w io.Writer, src io.Reader, fileType fileformat.FileType) error {
+	keyID := e.keyring.current
+
+	var salt [fileformat.EncryptionSaltSize]byte
+
+	if _, err := rand.Read(salt[:]); err != nil {
+		return errors.NewProcessingError("[Encryption] failed to generate salt", err)
+	}
+
+	aead, err := e.keyring.blobAEAD(keyID, salt, fileType)
+	if err != nil {
+		return err
+	}
+
+	if err = fileformat.NewEncryptionHeader(keyID, salt).Write(w); err != nil {
+		return err
+	}
+
+	ew := newWriter(w, aead, blobAAD(keyID, fileType))
+
+	if _, err = io.Copy(ew, src); err != nil {
+		return err
+	}
+
+	return ew.Close()
+}
+
+func (e *Encryption) Health(ctx context.Context, checkLiveness bool) (int, string, error) {
+	return e.store.Health(ctx, checkLiveness)
+}
+
+func (e *Encryption) Exists(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (bool, error) {
+	return e.store.Exists(ctx, key, fileType, opts...)
+}
+
+func (e *Encryption) Set(ctx context.Context, key []byte, fileType fileformat.FileType, value []byte, opts ...options.FileOption) error {
+	if !e.encrypt {
+		return e.store.Set(ctx, key, fileType, value, opts...)
+	}
+
+	sealedSize := fileformat.EncryptionHeaderSize + len(value) + (len(value)/chunkSize+1)*tagSize
+	buf := bytes.NewBuffer(make([]byte, 0, sealedSize))
+
+	if err := e.encryptTo(buf, bytes.NewReader(value), fileType); err != nil {
+		return errors.NewStorageError("[Encryption][Set] failed to encrypt %s", fileType, err)
+	}
+
+	return e.store.Set(ctx, key, fileType, buf.Bytes(), opts...)
+}
+
+// SetFromReader encrypts the reader while it is streamed to the wrapped store.
+func (e *Encryption) SetFromReader(ctx context.Context, key []byte, fileType fileformat.FileType, reader io.ReadCloser, opts ...options.FileOption) error {
+	if !e.encrypt {
+		return e.store.SetFromReader(ctx, key, fileType, reader, opts...)
+	}
+
+	pr, pw := io.Pipe()
+
+	go func() {
+		defer reader.Close()
+
+		_ = pw.CloseWithError(e.encryptTo(pw, reader, fileType))
+	}()
+
+	err := e.store.SetFromReader(ctx, key, fileType, pr, opts...)
+
+	// unblock the encryption when the store stopped reading before the end
+	_ = pr.CloseWithError(io.ErrClosedPipe)
+
+	return err
+}
+
+func (e *Encryption) SetDAH(ctx context.Context, key []byte, fileType fileformat.FileType, newDAH uint32, opts ...options.FileOption) error {
+	return e.store.SetDAH(ctx, key, fileType, newDAH, opts...)
+}
+
+func (e *Encryption) GetDAH(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (uint32, error) {
+	return e.store.GetDAH(ctx, key, fileType, opts...)
+}
+
+// GetIoReader returns a reader of the decrypted blob. The reader implements io.Seeker when the blob is
+// encrypted, or when it is not encrypted and the reader of the wrapped store implements io.Seeker.
+// For an encrypted blob, seeking backward or relative to the end fails when the reader of the wrapped store
+// does not implement io.Seeker.
+func (e *Encryption) GetIoReader(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (io.ReadCloser, error) {
+	rc, err := e.store.GetIoReader(ctx, key, fileType, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	seeker, canSeek := rc.(io.Seeker)
+
+	var start int64
+
+	if canSeek {
+		if start, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			canSeek = false
+		}
+	}
+
+	header, encrypted, body, err := fileformat.ReadEncryptionHeader(rc)
+	if err != nil {
+		_ = rc.Close()
+		return nil, errors.NewStorageError("[Encryption][GetIoReader] failed to read encryption header of %s", fileType, err)
+	}
+
+	if !encrypted {
+		if canSeek {
+			// rewind, so the seekable reader of the store can be returned as is
+			if _, err = seeker.Seek(start, io.SeekStart); err == nil {
+				return rc, nil
+			}
+
+			e.logger.Warnf("[Encryption][GetIoReader] failed to rewind reader of %s, returning a reader that cannot seek: %v", fileType, err)
+		}
+
+		return &readCloser{Reader: body, Closer: rc}, nil
+	}
+
+	aead, err := e.keyring.blobAEAD(header.KeyID(), header.Salt(), fileType)
+	if err != nil {
+		_ = rc.Close()
+		return nil, errors.NewStorageError("[Encryption][GetIoReader] failed to decrypt %s", fileType, err)
+	}
+
+	dataStart := int64(-1)
+	if canSeek {
+		dataStart = start + int64(fileformat.EncryptionHeaderSize)
+	}
+
+	return newReader(rc, rc, aead, blobAAD(header.KeyID(), fileType), dataStart), nil
+}
+
+func (e *Encryption) Get(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) ([]byte, error) {
+	value, err := e.store.Get(ctx, key, fileType, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	header, encrypted := fileformat.EncryptionHeaderFromBytes(value)
+	if !encrypted {
+		return value, nil
+	}
+
+	aead, err := e.keyring.blobAEAD(header.KeyID(), header.Salt(), fileType)
+	if err != nil {
+		return nil, errors.NewStorageError("[Encryption][Get] failed to decrypt %s", fileType, err)
+	}
+
+	body := bytes.NewReader(value[fileformat.EncryptionHeaderSize:])
+
+	plain, err := io.ReadAll(newReader(body, nil, aead, blobAAD(header.KeyID(), fileType), -1))
+	if err != nil {
+		return nil, errors.NewStorageError("[Encryption][Get] failed to decrypt %s", fileType, err)
+	}
+
+	return plain, nil
+}
+
+func (e *Encryption) Del(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) error {
+	return e.store.Del(ctx, key, fileType, opts...)
+}
+
+// List lists the blobs of the wrapped store, encryption does not change the blobs that are listed.
+func (e *Encryption) List(ctx context.Context, listOpts options.ListOptions, opts ...options.FileOption) (*options.ListResult, error) {
+	lister, ok := e.store.(blobStoreLister)
+	if !ok {
+		return nil, errors.NewStorageError("[Encryption] blob store %T does not support listing", e.store)
+	}
+
+	return lister.List(ctx, listOpts, opts...)
+}
+
+func (e *Encryption) Close(ctx context.Context) error {
+	return e.store.Close(ctx)
+}
+
+func (e *Encryption) SetCurrentBlockHeight(height uint32) {
+	e.store.SetCurrentBlockHeight(height)
+}
+
+// readCloser closes the reader of the wrapped store after reading from another reader over it
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,383 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+	"github.com/bsv-blockchain/teranode/stores/blob/file"
+	"github.com/bsv-blockchain/teranode/stores/blob/memory"
+	"github.com/bsv-blockchain/teranode/stores/blob/options"
+	"github.com/bsv-blockchain/teranode/ulogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testKey1 = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+	testKey2 = "1f1e1d1c1b1a191817161514131211100f0e0d0c0b0a09080706050403020100"
+)
+
+// testData returns data spanning several chunks, ending in an incomplete chunk
+func testData() []byte {
+	data := make([]byte, 3*chunkSize+12345)
+	_, _ = rand.New(rand.NewSource(1)).Read(data) //nolint:gosec // test data
+
+	return data
+}
+
+func testKeyring(t *testing.T, text string) *Keyring {
+	keyring, err := ParseKeyring(text, nil)
+	require.NoError(t, err)
+
+	return keyring
+}
+
+func TestEncryption_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+
+	for _, size := range []int{0, 10, chunkSize, 3*chunkSize + 12345} {
+		data := testData()[:size]
+		underlying := memory.New()
+
+		store, err := New(ulogger.TestLogger{}, underlying, testKeyring(t, "1:"+testKey1), true)
+		require.NoError(t, err)
+
+		key := []byte("set")
+		require.NoError(t, store.Set(ctx, key, fileformat.FileTypeSubtreeData, data))
+
+		stored, err := underlying.Get(ctx, key, fileformat.FileTypeSubtreeData)
+		require.NoError(t, err)
+
+		header, encrypted := fileformat.EncryptionHeaderFromBytes(stored)
+		require.True(t, encrypted)
+		assert.Equal(t, uint32(1), header.KeyID())
+
+		if size > 0 {
+			assert.False(t, bytes.Contains(stored, data))
+		}
+
+		value, err := store.Get(ctx, key, fileformat.FileTypeSubtreeData)
+		require.NoError(t, err)
+		assert.Equal(t, data, value, size)
+
+		key = []byte("setFromReader")
+		require.NoError(t, store.SetFromReader(ctx, key, fileformat.FileTypeSubtreeData, io.NopCloser(bytes.NewReader(data))))
+
+		reader, err := store.GetIoReader(ctx, key, fileformat.FileTypeSubtreeData)
+		require.NoError(t, err)
+
+		value, err = io.ReadAll(reader)
+		require.NoError(t, err)
+		require.NoError(t, reader.Close())
+		assert.Equal(t, data, value, size)
+	}
+}
+
+func TestEncryption_KeyRotation(t *testing.T) {
+	ctx := context.Background()
+	data := testData()
+	underlying := memory.New()
+
+	oldStore, err := New(ulogger.TestLogger{}, underlying, testKeyring(t, "1:"+testKey1), true)
+	require.NoError(t, err)
+	require.NoError(t, oldStore.Set(ctx, []byte("old"), fileformat.FileTypeBlock, data))
+
+	store, err := New(ulogger.TestLogger{}, underlying, testKeyring(t, "1:"+testKey1+"\n2:"+testKey2), true)
+	require.NoError(t, err)
+	require.NoError(t, store.Set(ctx, []byte("new"), fileformat.FileTypeBlock, data))
+
+	stored, err := underlying.Get(ctx, []byte("new"), fileformat.FileTypeBlock)
+	require.NoError(t, err)
+
+	header, _ := fileformat.EncryptionHeaderFromBytes(stored)
+	assert.Equal(t, uint32(2), header.KeyID())
+
+	for _, key := range []string{"old", "new"} {
+		value, err := store.Get(ctx, []byte(key), fileformat.FileTypeBlock)
+		require.NoError(t, err)
+		assert.Equal(t, data, value, key)
+	}
+
+	// blobs encrypted with a key that was removed from the keyring cannot be read
+	newOnly, err := New(ulogger.TestLogger{}, underlying, testKeyring(t, "2:"+testKey2), true)
+	require.NoError(t, err)
+
+	_, err = newOnly.Get(ctx, []byte("old"), fileformat.FileTypeBlock)
+	require.Error(t, err)
+}
+
+func TestEncryption_PlainBlobs(t *testing.T) {
+	ctx := context.Background()
+	data := testData()
+	underlying := memory.New()
+
+	// blobs written before encryption was enabled, including blobs shorter than the encryption header
+	require.NoError(t, underlying.Set(ctx, []byte("plain"), fileformat.FileTypeSubtreeData, data))
+	require.NoError(t, underlying.Set(ctx, []byte("short"), fileformat.FileTypeTx, []byte("tx")))
+
+	store, err := New(ulogger.TestLogger{}, underlying, testKeyring(t, "1:"+testKey1), true)
+	require.NoError(t, err)
+
+	for key, expected := range map[string][]byte{"plain": data, "short": []byte("tx")} {
+		fileType := fileformat.FileTypeSubtreeData
+		if key == "short" {
+			fileType = fileformat.FileTypeTx
+		}
+
+		value, err := store.Get(ctx, []byte(key), fileType)
+		require.NoError(t, err)
+		assert.Equal(t, expected, value, key)
+
+		reader, err := store.GetIoReader(ctx, []byte(key), fileType)
+		require.NoError(t, err)
+
+		value, err = io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, expected, value, key)
+	}
+
+	// with encryption disabled new blobs are stored in plain, encrypted blobs are still read
+	require.NoError(t, store.Set(ctx, []byte("encrypted"), fileformat.FileTypeBlock, data))
+
+	decryptOnly, err := New(ulogger.TestLogger{}, underlying, testKeyring(t, "1:"+testKey1), false)
+	require.NoError(t, err)
+	require.NoError(t, decryptOnly.Set(ctx, []byte("new"), fileformat.FileTypeBlock, data))
+
+	stored, err := underlying.Get(ctx, []byte("new"), fileformat.FileTypeBlock)
+	require.NoError(t, err)
+	assert.Equal(t, data, stored)
+
+	value, err := decryptOnly.Get(ctx, []byte("encrypted"), fileformat.FileTypeBlock)
+	require.NoError(t, err)
+	assert.Equal(t, data, value)
+}
+
+func TestEncryption_Tampering(t *testing.T) {
+	ctx := context.Background()
+	data := testData()
+	underlying := memory.New()
+
+	store, err := New(ulogger.TestLogger{}, underlying, testKeyring(t, "1:"+testKey1), true)
+	require.NoError(t, err)
+	require.NoError(t, store.Set(ctx, []byte("key"), fileformat.FileTypeBlock, data))
+
+	stored, err := underlying.Get(ctx, []byte("key"), fileformat.FileTypeBlock)
+	require.NoError(t, err)
+
+	flipped := bytes.Clone(stored)
+	flipped[fileformat.EncryptionHeaderSize+chunkSize+100] ^= 0x01
+
+	truncated := stored[:fileformat.EncryptionHeaderSize+2*storedChunkSize]
+
+	for name, value := range map[string][]byte{"flipped": flipped, "truncated": truncated} {
+		require.NoError(t, underlying.Set(ctx, []byte(name), fileformat.FileTypeBlock, value))
+
+		_, err = store.Get(ctx, []byte(name), fileformat.FileTypeBlock)
+		require.Error(t, err, name)
+
+		reader, err := store.GetIoReader(ctx, []byte(name), fileformat.FileTypeBlock)
+		require.NoError(t, err)
+
+		_, err = io.ReadAll(reader)
+		require.Error(t, err, name)
+	}
+
+	// the file type is part of the additional data, a blob stored under another file type fails to decrypt
+	require.NoError(t, underlying.Set(ctx, []byte("moved"), fileformat.FileTypeSubtree, stored))
+
+	_, err = store.Get(ctx, []byte("moved"), fileformat.FileTypeSubtree)
+	require.Error(t, err)
+}
+
+func TestEncryption_BlobKeys(t *testing.T) {
+	ctx := context.Background()
+	data := testData()
+	underlying := memory.New()
+
+	store, err := New(ulogger.TestLogger{}, underlying, testKeyring(t, "1:"+testKey1), true)
+	require.NoError(t, err)
+	require.NoError(t, store.Set(ctx, []byte("a"), fileformat.FileTypeBlock, data))
+	require.NoError(t, store.Set(ctx, []byte("b"), fileformat.FileTypeBlock, data))
+
+	storedA, err := underlying.Get(ctx, []byte("a"), fileformat.FileTypeBlock)
+	require.NoError(t, err)
+
+	storedB, err := underlying.Get(ctx, []byte("b"), fileformat.FileTypeBlock)
+	require.NoError(t, err)
+
+	headerA, _ := fileformat.EncryptionHeaderFromBytes(storedA)
+	headerB, _ := fileformat.EncryptionHeaderFromBytes(storedB)
+
+	// every blob has a salt and so a key of its own, the same data is encrypted differently
+	assert.NotEqual(t, headerA.Salt(), headerB.Salt())
+	assert.NotEqual(t, storedA[fileformat.EncryptionHeaderSize:], storedB[fileformat.EncryptionHeaderSize:])
+
+	// the salt is part of the key, a blob with the salt of another blob fails to decrypt
+	swapped := bytes.Clone(storedA)
+	copy(swapped[:fileformat.EncryptionHeaderSize], storedB[:fileformat.EncryptionHeaderSize])
+	require.NoError(t, underlying.Set(ctx, []byte("swapped"), fileformat.FileTypeBlock, swapped))
+
+	_, err = store.Get(ctx, []byte("swapped"), fileformat.FileTypeBlock)
+	require.Error(t, err)
+}
+
+func TestEncryption_Seek(t *testing.T) {
+	ctx := context.Background()
+	data := testData()
+
+	u, err := url.Parse("file://" + t.TempDir())
+	require.NoError(t, err)
+
+	fileStore, err := file.New(ulogger.TestLogger{}, u)
+	require.NoError(t, err)
+
+	for name, underlying := range map[string]blobStore{"memory": memory.New(), "file": fileStore} {
+		t.Run(name, func(t *testing.T) {
+			store, err := New(ulogger.TestLogger{}, underlying, testKeyring(t, "1:"+testKey1), true)
+			require.NoError(t, err)
+
+			require.NoError(t, store.Set(ctx, []byte("key"), fileformat.FileTypeSubtreeData, data))
+
+			reader, err := store.GetIoReader(ctx, []byte("key"), fileformat.FileTypeSubtreeData)
+			require.NoError(t, err)
+
+			defer reader.Close()
+
+			seeker, ok := reader.(io.Seeker)
+			require.True(t, ok)
+
+			buf := make([]byte, 100)
+
+			// seek forward into the third chunk
+			offset := int64(2*chunkSize + 500)
+
+			pos, err := seeker.Seek(offset, io.SeekStart)
+			require.NoError(t, err)
+			assert.Equal(t, offset, pos)
+
+			_, err = io.ReadFull(reader, buf)
+			require.NoError(t, err)
+			assert.Equal(t, data[offset:offset+100], buf)
+
+			// seek within the current chunk
+			pos, err = seeker.Seek(-50, io.SeekCurrent)
+			require.NoError(t, err)
+			assert.Equal(t, offset+50, pos)
+
+			_, err = io.ReadFull(reader, buf)
+			require.NoError(t, err)
+			assert.Equal(t, data[offset+50:offset+150], buf)
+
+			// seeking back into an earlier chunk, or relative to the end, needs a seekable store reader
+			_, err = seeker.Seek(10, io.SeekStart)
+			if name == "memory" {
+				require.Error(t, err)
+
+				_, err = seeker.Seek(-100, io.SeekEnd)
+				require.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			_, err = io.ReadFull(reader, buf)
+			require.NoError(t, err)
+			assert.Equal(t, data[10:110], buf)
+
+			pos, err = seeker.Seek(-100, io.SeekEnd)
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(data)-100), pos)
+
+			_, err = io.ReadFull(reader, buf)
+			require.NoError(t, err)
+			assert.Equal(t, data[len(data)-100:], buf)
+
+			_, err = reader.Read(buf)
+			assert.ErrorIs(t, err, io.EOF)
+
+			// seeking past the end reads nothing
+			_, err = seeker.Seek(int64(len(data)+chunkSize), io.SeekStart)
+			require.NoError(t, err)
+
+			_, err = reader.Read(buf)
+			assert.ErrorIs(t, err, io.EOF)
+		})
+	}
+}
+
+func TestEncryption_DAH(t *testing.T) {
+	ctx := context.Background()
+
+	store, err := New(ulogger.TestLogger{}, memory.New(), testKeyring(t, "1:"+testKey1), true)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Set(ctx, []byte("key"), fileformat.FileTypeTx, []byte("tx"), options.WithDeleteAt(10)))
+
+	dah, err := store.GetDAH(ctx, []byte("key"), fileformat.FileTypeTx)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(10), dah)
+
+	require.NoError(t, store.SetDAH(ctx, []byte("key"), fileformat.FileTypeTx, 0))
+
+	dah, err = store.GetDAH(ctx, []byte("key"), fileformat.FileTypeTx)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), dah)
+}
+
+func TestParseKeyring(t *testing.T) {
+	keyring, err := ParseKeyring("# keys\n1:"+testKey1+"\n\n2:"+testKey2+"\n", nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(2), keyring.current)
+
+	current := uint32(1)
+
+	keyring, err = ParseKeyring("1:"+testKey1+",2:"+testKey2, &current)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), keyring.current)
+
+	for name, text := range map[string]string{
+		"empty":          "# no keys",
+		"no id":          testKey1,
+		"invalid id":     "x:" + testKey1,
+		"not hex":        "1:not-hex",
+		"invalid length": "1:0001",
+		"duplicate":      "1:" + testKey1 + ",1:" + testKey2,
+	} {
+		_, err = ParseKeyring(text, nil)
+		require.Error(t, err, name)
+	}
+
+	current = 3
+
+	_, err = ParseKeyring("1:"+testKey1, &current)
+	require.Error(t, err)
+}
+
+func TestLoadKeyring(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "keyring")
+	require.NoError(t, os.WriteFile(keyFile, []byte("1:"+testKey1+"\n"), 0o600))
+
+	keyring, err := LoadKeyring(keyFile, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), keyring.current)
+
+	t.Setenv("TEST_BLOB_KEYRING", "5:"+testKey2)
+
+	keyring, err = LoadKeyring("", "TEST_BLOB_KEYRING", nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(5), keyring.current)
+
+	_, err = LoadKeyring("", "TEST_BLOB_KEYRING_MISSING", nil)
+	require.Error(t, err)
+
+	_, err = LoadKeyring("", "", nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,181 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+)
+
+// blobKeyInfo is the start of the HKDF info the key of a blob is derived with
+const blobKeyInfo = "teranode-blob-encryption"
+
+// blobKeySize is the size of the key of a blob, AES-256
+const blobKeySize = 32
+
+// Keyring holds the keys blobs are encrypted with, by key ID. Every blob is encrypted with a key of its own,
+// derived from a keyring key and the random salt of the blob. New blobs are encrypted with a key derived from the
+// current key, blobs are decrypted with a key derived from the key whose ID is recorded in their encryption header,
+// so keys are rotated by adding a key with a higher ID and keeping the old keys in the keyring for as long as blobs
+// encrypted with them exist.
+type Keyring struct {
+	keys    map[uint32][]byte
+	current uint32
+}
+
+// NewKeyring creates a keyring from 16, 24 or 32 byte keys by ID.
+//
+// Parameters:
+//   - keys: The keys by ID
+//   - current: The ID of the key new blobs are encrypted with
+//
+// Returns:
+//   - *Keyring: The keyring
+//   - error: Any error for invalid keys or a current key that is not in the keyring
+func NewKeyring(keys map[uint32][]byte, current uint32) (*Keyring, error) {
+	k := &Keyring{
+		keys:    make(map[uint32][]byte, len(keys)),
+		current: current,
+	}
+
+	for id, key := range keys {
+		switch len(key) {
+		case 16, 24, 32:
+		default:
+			return nil, errors.NewConfigurationError("[Encryption] invalid key %d, expected 16, 24 or 32 bytes, got %d", id, len(key))
+		}
+
+		k.keys[id] = append([]byte(nil), key...)
+	}
+
+	if _, ok := k.keys[current]; !ok {
+		return nil, errors.NewConfigurationError("[Encryption] current key %d is not in the keyring", current)
+	}
+
+	return k, nil
+}
+
+// ParseKeyring parses a keyring of hex encoded keys, one "id:key" pair per line or separated by commas, e.g.
+// "1:6b6579...,2:6e6577...". Empty entries and lines starting with # are ignored.
+//
+// Parameters:
+//   - text: The keyring
+//   - current: The ID of the key new blobs are encrypted with, the highest ID in the keyring when nil
+//
+// Returns:
+//   - *Keyring: The keyring
+//   - error: Any error parsing the keyring
+func ParseKeyring(text string, current *uint32) (*Keyring, error) {
+	keys := make(map[uint32][]byte)
+
+	var highest uint32
+
+	for _, entry := range strings.FieldsFunc(text, func(r rune) bool { return r == '\n' || r == ',' }) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+
+		idString, keyString, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, errors.NewConfigurationError("[Encryption] invalid keyring entry, expected id:key")
+		}
+
+		parsedID, err := strconv.ParseUint(strings.TrimSpace(idString), 10, 32)
+		if err != nil {
+			return nil, errors.NewConfigurationError("[Encryption] invalid key ID %q", idString, err)
+		}
+
+		id := uint32(parsedID)
+
+		if _, exists := keys[id]; exists {
+			return nil, errors.NewConfigurationError("[Encryption] duplicate key ID %d", id)
+		}
+
+		key, err := hex.DecodeString(strings.TrimSpace(keyString))
+		if err != nil {
+			return nil, errors.NewConfigurationError("[Encryption] key %d is not hex encoded", id, err)
+		}
+
+		keys[id] = key
+		highest = max(highest, id)
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.NewConfigurationError("[Encryption] keyring is empty")
+	}
+
+	if current == nil {
+		current = &highest
+	}
+
+	return NewKeyring(keys, *current)
+}
+
+// LoadKeyring loads a keyring from a file or from an environment variable, see ParseKeyring for the format.
+//
+// Parameters:
+//   - file: The path of the keyring file, empty to read the environment variable
+//   - env: The name of the environment variable holding the keyring
+//   - current: The ID of the key new blobs are encrypted with, the highest ID in the keyring when nil
+//
+// Returns:
+//   - *Keyring: The keyring
+//   - error: Any error reading or parsing the keyring
+func LoadKeyring(file, env string, current *uint32) (*Keyring, error) {
+	switch {
+	case file != "":
+		text, err := os.ReadFile(file)
+		if err != nil {
+			return nil, errors.NewConfigurationError("[Encryption] failed to read keyring file %s", file, err)
+		}
+
+		return ParseKeyring(string(text), current)
+	case env != "":
+		text, ok := os.LookupEnv(env)
+		if !ok {
+			return nil, errors.NewConfigurationError("[Encryption] keyring environment variable %s is not set", env)
+		}
+
+		return ParseKeyring(text, current)
+	default:
+		return nil, errors.NewConfigurationError("[Encryption] a keyring file or environment variable is required")
+	}
+}
+
+// blobAEAD returns the AES-GCM of a blob, with the AES-256 key derived with HKDF-SHA256 from the key of the ID and
+// the salt of the blob. The key ID and the file type are part of the info, so every blob has a key of its own.
+func (k *Keyring) blobAEAD(id uint32, salt [fileformat.EncryptionSaltSize]byte, fileType fileformat.FileType) (cipher.AEAD, error) {
+	key, ok := k.keys[id]
+	if !ok {
+		return nil, errors.NewStorageError("[Encryption] key %d is not in the keyring", id)
+	}
+
+	info := binary.LittleEndian.AppendUint32([]byte(blobKeyInfo), id)
+	info = append(info, fileType...)
+
+	blobKey, err := hkdf.Key(sha256.New, key, salt[:], string(info), blobKeySize)
+	if err != nil {
+		return nil, errors.NewProcessingError("[Encryption] failed to derive the blob key of key %d", id, err)
+	}
+
+	block, err := aes.NewCipher(blobKey)
+	if err != nil {
+		return nil, errors.NewProcessingError("[Encryption] failed to create the blob cipher of key %d", id, err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.NewProcessingError("[Encryption] failed to create AES-GCM of key %d", id, err)
+	}
+
+	return aead, nil
+}
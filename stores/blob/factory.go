@@ -11,31 +11,63 @@ import (
 	"strings"
 
 	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
 	"github.com/bsv-blockchain/teranode/stores/blob/batcher"
+	"github.com/bsv-blockchain/teranode/stores/blob/cache"
+	"github.com/bsv-blockchain/teranode/stores/blob/compression"
+	"github.com/bsv-blockchain/teranode/stores/blob/encryption"
 	"github.com/bsv-blockchain/teranode/stores/blob/file"
 	"github.com/bsv-blockchain/teranode/stores/blob/http"
+	"github.com/bsv-blockchain/teranode/stores/blob/leveldb"
 	"github.com/bsv-blockchain/teranode/stores/blob/localdah"
 	storelogger "github.com/bsv-blockchain/teranode/stores/blob/logger"
 	"github.com/bsv-blockchain/teranode/stores/blob/memory"
+	"github.com/bsv-blockchain/teranode/stores/blob/mirror"
 	"github.com/bsv-blockchain/teranode/stores/blob/null"
 	"github.com/bsv-blockchain/teranode/stores/blob/options"
+	"github.com/bsv-blockchain/teranode/stores/blob/quota"
 	"github.com/bsv-blockchain/teranode/stores/blob/s3"
+	"github.com/bsv-blockchain/teranode/stores/blob/tiered"
 	"github.com/bsv-blockchain/teranode/ulogger"
 )
 
 var (
 	_ Store = (*batcher.Batcher)(nil)
+	_ Store = (*cache.Cache)(nil)
+	_ Store = (*compression.Compression)(nil)
+	_ Store = (*encryption.Encryption)(nil)
 	_ Store = (*file.File)(nil)
 	_ Store = (*http.HTTPStore)(nil)
+	_ Store = (*leveldb.LevelDB)(nil)
 	_ Store = (*localdah.LocalDAH)(nil)
 	_ Store = (*memory.Memory)(nil)
+	_ Store = (*mirror.Mirror)(nil)
 	_ Store = (*null.Null)(nil)
+	_ Store = (*quota.Quota)(nil)
 	_ Store = (*s3.S3)(nil)
 	_ Store = (*storelogger.Logger)(nil)
+	_ Store = (*tiered.Tiered)(nil)
+
+	_ Lister = (*batcher.Batcher)(nil)
+	_ Lister = (*cache.Cache)(nil)
+	_ Lister = (*compression.Compression)(nil)
+	_ Lister = (*encryption.Encryption)(nil)
+	_ Lister = (*file.File)(nil)
+	_ Lister = (*http.HTTPStore)(nil)
+	_ Lister = (*leveldb.LevelDB)(nil)
+	_ Lister = (*localdah.LocalDAH)(nil)
+	_ Lister = (*memory.Memory)(nil)
+	_ Lister = (*mirror.Mirror)(nil)
+	_ Lister = (*quota.Quota)(nil)
+	_ Lister = (*s3.S3)(nil)
+	_ Lister = (*storelogger.Logger)(nil)
+	_ Lister = (*tiered.Tiered)(nil)
 )
 
 // NewStore creates a new blob store based on the provided URL scheme and options.
-// It supports various storage backends including null, memory, file, http, and s3.
+// It supports various storage backends including null, memory, file, leveldb, http, and s3,
+// optionally with storage quotas and disk-pressure monitoring, behind a read-through cache on local disk, the mirror store that mirrors the blobs to several of these backends, and the tiered store that
+// migrates aged blobs from one of these backends to another.
 // Parameters:
 //   - logger: Logger instance for store operations
 //   - storeURL: URL containing the store configuration
@@ -64,20 +96,49 @@ func NewStore(logger ulogger.Logger, storeURL *url.URL, opts ...options.StoreOpt
 		if err != nil {
 			return nil, errors.NewStorageError("error creating file blob store", err)
 		}
-	case "http":
+	case "http", "https":
 		store, err = http.New(logger, storeURL, opts...)
 		if err != nil {
 			return nil, errors.NewStorageError("error creating http blob store", err)
 		}
+	case "leveldb":
+		store, err = leveldb.New(logger, storeURL, opts...)
+		if err != nil {
+			return nil, errors.NewStorageError("error creating leveldb blob store", err)
+		}
 	case "s3":
 		store, err = s3.New(logger, storeURL, opts...)
 		if err != nil {
 			return nil, errors.NewStorageError("error creating s3 blob store", err)
 		}
+	case "mirror":
+		store, err = createMirrorStore(storeURL, logger, opts)
+		if err != nil {
+			return nil, errors.NewStorageError("error creating mirror blob store", err)
+		}
+	case "tiered":
+		store, err = createTieredStore(storeURL, logger, opts)
+		if err != nil {
+			return nil, errors.NewStorageError("error creating tiered blob store", err)
+		}
 	default:
 		return nil, errors.NewStorageError("unknown store type: %s", storeURL.Scheme)
 	}
 
+	if hasQuotaParams(storeURL) {
+		store, err = createQuotaStore(storeURL, store, logger)
+		if err != nil {
+			return nil, errors.NewStorageError("error creating quota blob store", err)
+		}
+	}
+
+	if storeURL.Query().Get("cache") != "" {
+		store, err = createCachedStore(storeURL, store, logger)
+		if err != nil {
+			return nil, errors.NewStorageError("error creating cached blob store", err)
+		}
+	}
+
 	if storeURL.Query().Get("batch") == "true" {
 		store, err = createBatchedStore(storeURL, store, logger)
 		if err != nil {
@@ -92,6 +153,20 @@ func NewStore(logger ulogger.Logger, storeURL *url.URL, opts ...options.StoreOpt
 		}
 	}
 
+	if storeURL.Query().Get("encrypt") != "" {
+		store, err = createEncryptedStore(storeURL, store, logger)
+		if err != nil {
+			return nil, errors.NewStorageError("error creating encrypted blob store", err)
+		}
+	}
+
+	if storeURL.Query().Get("compress") != "" {
+		store, err = createCompressedStore(storeURL, store, logger)
+		if err != nil {
+			return nil, errors.NewStorageError("error creating compressed blob store", err)
+		}
+	}
+
 	if storeURL.Query().Get("logger") == "true" {
 		logger.Infof("enabling blob store logging at DEBUG level")
 		store = storelogger.New(logger, store)
@@ -147,6 +222,451 @@ func createDAHStore(storeURL *url.URL, logger ulogger.Logger, opts []options.Sto
 	return store, nil
 }
 
+// createMirrorStore creates a store that mirrors the blobs to several replica stores.
+// Each replica is configured through a URL encoded store query parameter, in the order the
+// replicas are read from, e.g. mirror://?store=file%3A%2F%2F%2Fdata%2Fsubtrees&store=s3%3A%2F%2F...
+// The replicas are created with NewStore, so they can use the wrappers of the other stores.
+//
+// The mirror is configured through URL query parameters:
+//   - store: The URL of a replica, repeated for each replica
+//   - writeQuorum: The number of replicas a write must succeed on (default: all replicas)
+//
+// Parameters:
+//   - storeURL: URL containing the replica URLs and mirror parameters
+//   - logger: Logger instance for mirror operations
+//   - opts: Store options to be passed to the replica stores
+//
+// Returns:
+//   - Store: The mirror store instance
+//   - error: Any error that occurred during creation, particularly if a replica cannot be created
+func createMirrorStore(storeURL *url.URL, logger ulogger.Logger, opts []options.StoreOption) (Store, error) {
+	replicaURLs := storeURL.Query()["store"]
+	if len(replicaURLs) == 0 {
+		return nil, errors.NewConfigurationError("mirror store requires at least one store parameter")
+	}
+
+	writeQuorum := 0
+
+	if quorumString := storeURL.Query().Get("writeQuorum"); quorumString != "" {
+		var err error
+
+		writeQuorum, err = strconv.Atoi(quorumString)
+		if err != nil {
+			return nil, errors.NewConfigurationError("error parsing writeQuorum", err)
+		}
+	}
+
+	replicas := make([]*mirror.Replica, 0, len(replicaURLs))
+
+	for _, replicaURLString := range replicaURLs {
+		replicaURL, err := url.Parse(replicaURLString)
+		if err != nil {
+			return nil, errors.NewConfigurationError("error parsing mirror store URL %s", replicaURLString, err)
+		}
+
+		if replicaURL.Scheme == "mirror" {
+			return nil, errors.NewConfigurationError("mirror store cannot mirror to another mirror store")
+		}
+
+		replicaStore, err := NewStore(logger, replicaURL, opts...)
+		if err != nil {
+			return nil, errors.NewStorageError("error creating mirror replica %s://%s", replicaURL.Scheme, replicaURL.Host, err)
+		}
+
+		replicas = append(replicas, &mirror.Replica{
+			Name:  replicaURL.Scheme + "://" + replicaURL.Host + replicaURL.Path,
+			Store: replicaStore,
+		})
+	}
+
+	mirrorStore, err := mirror.New(logger.New("mirror"), replicas, writeQuorum)
+	if err != nil {
+		return nil, err
+	}
+
+	return mirrorStore, nil
+}
+
+// createTieredStore creates a store that writes blobs to a hot store and migrates them to a cold store
+// once they are older than the depth of their file type, e.g.
+// tiered://?hot=file%3A%2F%2F%2Fdata%2Fblockstore&cold=s3%3A%2F%2F...&tierDepths=block:1000,subtree:100
+// The hot and cold stores are created with NewStore, so they can use the wrappers of the other stores.
+//
+// The tiered store is configured through URL query parameters:
+//   - hot: The URL of the store blobs are written to, it must support listing
+//   - cold: The URL of the store aged blobs are migrated to
+//   - tierDepths: Comma separated file type and depth in blocks pairs, the file types that are migrated
+//
+// Parameters:
+//   - storeURL: URL containing the hot and cold store URLs and the tiering policy
+//   - logger: Logger instance for tiering operations
+//   - opts: Store options to be passed to the hot and cold stores, the block height channel is only used by
+//     the tiered store, which passes the block heights on
+//
+// Returns:
+//   - Store: The tiered store instance
+//   - error: Any error that occurred during creation, particularly for an invalid policy
+func createTieredStore(storeURL *url.URL, logger ulogger.Logger, opts []options.StoreOption) (Store, error) {
+	policy, err := parseTierDepths(storeURL.Query().Get("tierDepths"))
+	if err != nil {
+		return nil, errors.NewConfigurationError("error parsing tierDepths", err)
+	}
+
+	storeOpts := append(append(make([]options.StoreOption, 0, len(opts)+1), opts...), options.WithBlockHeightCh(nil))
+
+	tierStores := make([]Store, 0, 2)
+
+	for _, tier := range []string{"hot", "cold"} {
+		tierURLString := storeURL.Query().Get(tier)
+		if tierURLString == "" {
+			return nil, errors.NewConfigurationError("tiered store requires a %s parameter", tier)
+		}
+
+		var tierURL *url.URL
+
+		tierURL, err = url.Parse(tierURLString)
+		if err != nil {
+			return nil, errors.NewConfigurationError("error parsing %s store URL %s", tier, tierURLString, err)
+		}
+
+		var tierStore Store
+
+		tierStore, err = NewStore(logger, tierURL, storeOpts...)
+		if err != nil {
+			return nil, errors.NewStorageError("error creating %s store %s://%s", tier, tierURL.Scheme, tierURL.Host, err)
+		}
+
+		tierStores = append(tierStores, tierStore)
+	}
+
+	tieredStore, err := tiered.New(logger.New("tiered"), tierStores[0], tierStores[1], policy, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return tieredStore, nil
+}
+
+// parseTierDepths parses a comma separated list of file type extension and depth pairs, e.g. block:1000
+func parseTierDepths(value string) (tiered.Policy, error) {
+	policy := make(tiered.Policy)
+
+	if value == "" {
+		return policy, nil
+	}
+
+	for _, part := range strings.Split(value, ",") {
+		extension, depthString, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok {
+			return nil, errors.NewConfigurationError("invalid tier depth %q, expected fileType:depth", part)
+		}
+
+		fileType, err := fileformat.FileTypeFromExtension(extension)
+		if err != nil {
+			return nil, err
+		}
+
+		depth, err := strconv.ParseUint(depthString, 10, 32)
+		if err != nil {
+			return nil, errors.NewConfigurationError("invalid depth of file type %s", extension, err)
+		}
+
+		policy[fileType] = uint32(depth)
+	}
+
+	return policy, nil
+}
+
+// createEncryptedStore wraps a store with transparent AES-GCM encryption of the blobs.
+// The encryption wraps the store after the batcher and the local DAH store, so the blobs
+// written to the DAH store are encrypted as well, and before the compression, so blobs
+// are compressed before they are encrypted.
+//
+// The encryption is configured through URL query parameters:
+//   - encrypt: aes-gcm to encrypt new blobs, or none to only decrypt blobs that are encrypted
+//   - encryptKeyFile: Path of the keyring file
+//   - encryptKeyEnv: Environment variable holding the keyring, used when encryptKeyFile is not set
+//   - encryptKeyID: ID of the key new blobs are encrypted with, the highest ID in the keyring when not set
+//
+// Parameters:
+//   - storeURL: URL containing encryption configuration parameters
+//   - store: The store to wrap with encryption
+//   - logger: Logger instance for encryption operations
+//
+// Returns:
+//   - Store: The encrypted store instance
+//   - error: Any error that occurred during creation, particularly for an invalid mode or keyring
+func createEncryptedStore(storeURL *url.URL, store Store, logger ulogger.Logger) (Store, error) {
+	var encrypt bool
+
+	switch mode := storeURL.Query().Get("encrypt"); mode {
+	case "aes-gcm":
+		encrypt = true
+	case "none":
+		encrypt = false
+	default:
+		return nil, errors.NewConfigurationError("unknown encrypt mode %q, expected aes-gcm or none", mode)
+	}
+
+	var currentKeyID *uint32
+
+	if value := storeURL.Query().Get("encryptKeyID"); value != "" {
+		keyID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return nil, errors.NewConfigurationError("error parsing encryptKeyID", err)
+		}
+
+		id := uint32(keyID)
+		currentKeyID = &id
+	}
+
+	keyring, err := encryption.LoadKeyring(storeURL.Query().Get("encryptKeyFile"), storeURL.Query().Get("encryptKeyEnv"), currentKeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	encryptedStore, err := encryption.New(logger, store, keyring, encrypt)
+	if err != nil {
+		return nil, err
+	}
+
+	return encryptedStore, nil
+}
+
+// createCompressedStore wraps a store with transparent compression of the blobs.
+// The compression wraps the store after the batcher, the local DAH store and the encryption,
+// so the blobs written to the DAH store are compressed as well.
+//
+// The compression is configured through URL query parameters:
+//   - compress: The codec to compress new blobs with, zstd, lz4 or none to only read compressed blobs
+//   - compressFileTypes: Comma separated file types to compress, all file types when not set
+//   - compressSkipFileTypes: Comma separated file types to never compress, e.g. tx
+//
+// Parameters:
+//   - storeURL: URL containing compression configuration parameters
+//   - store: The store to wrap with compression
+//   - logger: Logger instance for compression operations
+//
+// Returns:
+//   - Store: The compressed store instance
+//   - error: Any error that occurred during creation, particularly for unknown codecs or file types
+func createCompressedStore(storeURL *url.URL, store Store, logger ulogger.Logger) (Store, error) {
+	codec, err := fileformat.CodecFromString(storeURL.Query().Get("compress"))
+	if err != nil {
+		return nil, errors.NewConfigurationError("error parsing compress", err)
+	}
+
+	fileTypes, err := parseFileTypes(storeURL.Query().Get("compressFileTypes"))
+	if err != nil {
+		return nil, errors.NewConfigurationError("error parsing compressFileTypes", err)
+	}
+
+	skipFileTypes, err := parseFileTypes(storeURL.Query().Get("compressSkipFileTypes"))
+	if err != nil {
+		return nil, errors.NewConfigurationError("error parsing compressSkipFileTypes", err)
+	}
+
+	compressedStore, err := compression.New(logger, store, codec, fileTypes, skipFileTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	return compressedStore, nil
+}
+
+// parseFileTypes parses a comma separated list of file type extensions
+func parseFileTypes(value string) ([]fileformat.FileType, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(value, ",")
+	fileTypes := make([]fileformat.FileType, 0, len(parts))
+
+	for _, part := range parts {
+		fileType, err := fileformat.FileTypeFromExtension(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+
+		fileTypes = append(fileTypes, fileType)
+	}
+
+	return fileTypes, nil
+}
+
+// createCachedStore wraps a store with a read-through cache of the blobs on local disk.
+// The cache wraps the store directly, so it holds the blobs as they are stored, encrypted and
+// compressed when encryption or compression is enabled.
+//
+// The cache is configured through URL query parameters:
+//   - cache: The local directory the cached blobs are stored in
+//   - cacheSize: Maximum number of bytes of the blobs cached on disk (default: 1 GiB)
+//   - cacheMemorySize: Maximum number of bytes of the blobs kept in memory as well (default: 0, disk only)
+//
+// Parameters:
+//   - storeURL: URL containing cache configuration parameters
+//   - store: The store to wrap with the cache
+//   - logger: Logger instance for cache operations
+//
+// Returns:
+//   - Store: The cached store instance
+//   - error: Any error that occurred during creation, particularly for invalid sizes or an unusable directory
+func createCachedStore(storeURL *url.URL, store Store, logger ulogger.Logger) (Store, error) {
+	maxDiskBytes := int64(1024 * 1024 * 1024)
+
+	if sizeString := storeURL.Query().Get("cacheSize"); sizeString != "" {
+		var err error
+
+		maxDiskBytes, err = strconv.ParseInt(sizeString, 10, 64)
+		if err != nil {
+			return nil, errors.NewConfigurationError("error parsing cacheSize", err)
+		}
+	}
+
+	var maxMemoryBytes int64
+
+	if sizeString := storeURL.Query().Get("cacheMemorySize"); sizeString != "" {
+		var err error
+
+		maxMemoryBytes, err = strconv.ParseInt(sizeString, 10, 64)
+		if err != nil {
+			return nil, errors.NewConfigurationError("error parsing cacheMemorySize", err)
+		}
+	}
+
+	cachedStore, err := cache.New(logger.New("cache"), store, storeURL.Query().Get("cache"), maxDiskBytes, maxMemoryBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return cachedStore, nil
+}
+
+// hasQuotaParams returns whether the store URL configures a quota or a disk watermark
+func hasQuotaParams(storeURL *url.URL) bool {
+	for _, param := range []string{"quota", "quotaFileTypes", "diskLowWatermark", "diskCriticalWatermark"} {
+		if storeURL.Query().Get(param) != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// createQuotaStore wraps a store with storage quotas and disk-pressure monitoring.
+// The quota wraps the store directly, so the bytes counted against the quotas are the bytes on disk,
+// and the early cleanup below the low watermark reaches the DAH cleanup of the file store.
+//
+// The quota is configured through URL query parameters:
+//   - quota: Maximum number of bytes of all blobs in the store (default: 0, no quota)
+//   - quotaFileTypes: Comma separated file type and maximum number of bytes pairs, e.g. subtree:107374182400
+//   - diskLowWatermark: Percentage of free disk space below which the store is degraded (default: 0, disabled)
+//   - diskCriticalWatermark: Percentage of free disk space below which writes are rejected (default: 0, disabled)
+//   - earlyCleanupBlocks: Number of blocks ahead of their DAH blobs are removed below the low watermark (default: 10)
+//   - quotaDir: The directory to count and monitor, the path of the store for file stores
+//
+// Parameters:
+//   - storeURL: URL containing quota configuration parameters
+//   - store: The store to wrap with the quota
+//   - logger: Logger instance for quota operations
+//
+// Returns:
+//   - Store: The quota store instance
+//   - error: Any error that occurred during creation, particularly for invalid quotas or watermarks
+func createQuotaStore(storeURL *url.URL, store Store, logger ulogger.Logger) (Store, error) {
+	opts := quota.Options{
+		Dir:                storeURL.Query().Get("quotaDir"),
+		EarlyCleanupBlocks: 10,
+	}
+
+	if opts.Dir == "" {
+		if storeURL.Scheme != "file" {
+			return nil, errors.NewConfigurationError("quota of a %s store requires a quotaDir parameter", storeURL.Scheme)
+		}
+
+		if storeURL.Host == "." {
+			opts.Dir = storeURL.Path[1:] // relative path
+		} else {
+			opts.Dir = storeURL.Path // absolute path
+		}
+	}
+
+	var err error
+
+	if value := storeURL.Query().Get("quota"); value != "" {
+		opts.MaxBytes, err = strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, errors.NewConfigurationError("error parsing quota", err)
+		}
+	}
+
+	opts.FileTypeMaxBytes, err = parseFileTypeQuotas(storeURL.Query().Get("quotaFileTypes"))
+	if err != nil {
+		return nil, errors.NewConfigurationError("error parsing quotaFileTypes", err)
+	}
+
+	if value := storeURL.Query().Get("diskLowWatermark"); value != "" {
+		opts.LowWatermark, err = strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, errors.NewConfigurationError("error parsing diskLowWatermark", err)
+		}
+	}
+
+	if value := storeURL.Query().Get("diskCriticalWatermark"); value != "" {
+		opts.CriticalWatermark, err = strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, errors.NewConfigurationError("error parsing diskCriticalWatermark", err)
+		}
+	}
+
+	if value := storeURL.Query().Get("earlyCleanupBlocks"); value != "" {
+		blocks, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return nil, errors.NewConfigurationError("error parsing earlyCleanupBlocks", err)
+		}
+
+		opts.EarlyCleanupBlocks = uint32(blocks)
+	}
+
+	quotaStore, err := quota.New(logger.New("quota"), store, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return quotaStore, nil
+}
+
+// parseFileTypeQuotas parses a comma separated list of file type extension and bytes pairs, e.g. subtree:1000000
+func parseFileTypeQuotas(value string) (map[fileformat.FileType]int64, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	quotas := make(map[fileformat.FileType]int64)
+
+	for _, part := range strings.Split(value, ",") {
+		extension, bytesString, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok {
+			return nil, errors.NewConfigurationError("invalid file type quota %q, expected fileType:bytes", part)
+		}
+
+		fileType, err := fileformat.FileTypeFromExtension(extension)
+		if err != nil {
+			return nil, err
+		}
+
+		maxBytes, err := strconv.ParseInt(bytesString, 10, 64)
+		if err != nil {
+			return nil, errors.NewConfigurationError("invalid quota of file type %s", extension, err)
+		}
+
+		quotas[fileType] = maxBytes
+	}
+
+	return quotas, nil
+}
+
 // createBatchedStore wraps a store with batching capabilities for improved performance.
 // Batching allows multiple blob operations to be processed as a group, which can
 // significantly improve throughput and reduce overhead, especially for storage backends
@@ -34,6 +34,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -663,6 +664,52 @@ func (s *File) cleanupExpiredFiles() {
 	}
 }
 
+// CleanupAhead removes the blobs that expire within the given number of blocks above the current block
+// height, ahead of their DAH, to free disk space when the disk is running full. Blobs without a DAH are kept.
+//
+// Parameters:
+//   - blocks: Number of blocks above the current block height
+//
+// Returns:
+//   - int: The number of blobs removed
+func (s *File) CleanupAhead(blocks uint32) int {
+	currentBlockHeight := s.currentBlockHeight.Load()
+	if currentBlockHeight == 0 {
+		// the DAHs cannot be compared before the block height is known
+		return 0
+	}
+
+	height := currentBlockHeight + blocks
+
+	s.fileDAHsMu.Lock()
+	filesToRemove := make([]string, 0)
+
+	for fileName, dah := range s.fileDAHs {
+		if dah <= height {
+			filesToRemove = append(filesToRemove, fileName)
+		}
+	}
+	s.fileDAHsMu.Unlock()
+
+	removed := 0
+
+	for _, fileName := range filesToRemove {
+		// the DAH file might have been updated since the map was
+		dah, err := s.readDAHFromFile(fileName + ".dah")
+		if err != nil || dah == 0 || dah > height {
+			continue
+		}
+
+		s.logger.Debugf("[File] removing file expiring at %d ahead of its DAH: %s", dah, fileName)
+		s.removeFiles(fileName)
+		s.removeDAHFromMap(fileName)
+
+		removed++
+	}
+
+	return removed
+}
+
 func (s *File) getExpiredFiles() []string {
 	s.fileDAHsMu.Lock()
 	filesToRemove := make([]string, 0, len(s.fileDAHs))
@@ -1021,6 +1068,68 @@ func (s *File) writeHashFile(hasher hash.Hash, filename string) error {
 	return nil
 }
 
+// VerifyChecksum verifies a blob against the SHA256 checksum file written next to it.
+// The checksum covers the whole file, including the file header.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - key: The key identifying the blob
+//   - fileType: The type of the file
+//   - opts: Optional file options
+//
+// Returns:
+//   - error: nil when the checksum matches, a not found error when the blob or its checksum file does not
+//     exist, or a storage error when the checksum does not match
+func (s *File) VerifyChecksum(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) error {
+	merged := options.MergeOptions(s.options, opts)
+
+	fileName, err := merged.ConstructFilename(s.path, key, fileType)
+	if err != nil {
+		return err
+	}
+
+	checksumData, err := os.ReadFile(fileName + checksumExtension)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return errors.NewNotFoundError("[File][VerifyChecksum] [%s] checksum file not found", fileName)
+		}
+
+		return errors.NewStorageError("[File][VerifyChecksum] [%s] failed to read checksum file", fileName, err)
+	}
+
+	fields := strings.Fields(string(checksumData))
+	if len(fields) == 0 {
+		return errors.NewStorageError("[File][VerifyChecksum] [%s] checksum file is empty", fileName)
+	}
+
+	if err = acquireReadPermit(ctx); err != nil {
+		return errors.NewStorageError("[File][VerifyChecksum] failed to acquire read permit", err)
+	}
+	defer releaseReadPermit()
+
+	f, err := os.Open(fileName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return errors.NewNotFoundError("[File][VerifyChecksum] [%s] file not found", fileName)
+		}
+
+		return errors.NewStorageError("[File][VerifyChecksum] [%s] failed to open file", fileName, err)
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+
+	if _, err = io.Copy(hasher, f); err != nil {
+		return errors.NewStorageError("[File][VerifyChecksum] [%s] failed to read file", fileName, err)
+	}
+
+	if actual := fmt.Sprintf("%x", hasher.Sum(nil)); actual != fields[0] {
+		return errors.NewStorageError("[File][VerifyChecksum] [%s] checksum mismatch: got %s, want %s", fileName, actual, fields[0])
+	}
+
+	return nil
+}
+
 // Set stores a blob in the file store.
 // This method is a convenience wrapper around SetFromReader that converts the byte slice
 // to a reader before delegating to SetFromReader for the actual storage operation.
@@ -1431,6 +1540,153 @@ func (s *File) Del(ctx context.Context, key []byte, fileType fileformat.FileType
 	return nil
 }
 
+// List returns a page of the blobs in the file store, in ascending order of their name.
+// The blobs are read from the directory of the store, or the sub directory in the options, including the
+// hash prefix directories when the store uses them. Blobs that are only available in the persistent sub
+// directory or the longterm store are not listed. The DAH of a blob is read from its DAH file.
+//
+// With a hash prefix, the prefix directories sort like the blobs in them, so a page is read from the
+// directory of the cursor onwards and the scan stops once the page is full. With a hash suffix the
+// directories do not follow the order of the blobs, and every directory is read for every page.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - listOpts: The prefix, file type, cursor and limit of the listing
+//   - opts: Optional file options, the sub directory to list
+//
+// Returns:
+//   - *options.ListResult: The blobs in the page and the cursor of the next page
+//   - error: Any error that occurred while reading the directories
+func (s *File) List(ctx context.Context, listOpts options.ListOptions, opts ...options.FileOption) (*options.ListResult, error) {
+	if err := acquireReadPermit(ctx); err != nil {
+		return nil, errors.NewStorageError("[File][List] failed to acquire read permit", err)
+	}
+	defer releaseReadPermit()
+
+	merged := options.MergeOptions(s.options, opts)
+
+	entries, err := s.listFolder(ctx, filepath.Join(s.path, merged.SubDirectory), merged, listOpts, true)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Cursor() < entries[j].Cursor()
+	})
+
+	return options.NewListResult(entries, listOpts), nil
+}
+
+// listFolder returns the blobs in the folder matching the list options that come after the cursor, at most
+// one more than the limit, as the directory entries are sorted by name. When descend is set, the hash prefix
+// directories in the folder are listed as well, at most one more than the limit of blobs in total when
+// the directories sort like the blobs in them.
+func (s *File) listFolder(ctx context.Context, folder string, merged *options.Options, listOpts options.ListOptions, descend bool) ([]options.ListEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	dirEntries, err := os.ReadDir(folder)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, errors.NewStorageError("[File][List] failed to read directory %s", folder, err)
+	}
+
+	names := make(map[string]struct{}, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		names[dirEntry.Name()] = struct{}{}
+	}
+
+	limit := listOpts.GetLimit()
+	entries := make([]options.ListEntry, 0)
+	folderEntries := 0
+	prefixDirEntries := 0
+
+	// the blobs in the hash prefix directories before the one of the cursor all come before the cursor
+	cursorDir := ""
+	if merged.HashPrefix > 0 {
+		cursorDir = listOpts.Cursor[:min(merged.HashPrefix, len(listOpts.Cursor))]
+	}
+
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() {
+			if !descend || !s.isHashPrefixDir(dirEntry.Name(), merged, listOpts) {
+				continue
+			}
+
+			// the hash prefix directories after a full page only hold blobs after the page
+			if merged.HashPrefix > 0 && (dirEntry.Name() < cursorDir || prefixDirEntries > limit) {
+				continue
+			}
+
+			subEntries, err := s.listFolder(ctx, filepath.Join(folder, dirEntry.Name()), merged, listOpts, false)
+			if err != nil {
+				return nil, err
+			}
+
+			entries = append(entries, subEntries...)
+			prefixDirEntries += len(subEntries)
+
+			continue
+		}
+
+		if folderEntries > limit {
+			continue
+		}
+
+		entry, ok := options.ParseListEntry(dirEntry.Name())
+		if !ok || !listOpts.Matches(entry.Name, entry.FileType) || (listOpts.Cursor != "" && entry.Cursor() <= listOpts.Cursor) {
+			continue
+		}
+
+		if _, ok = names[dirEntry.Name()+".dah"]; ok {
+			entry.DAH = s.listDAH(filepath.Join(folder, dirEntry.Name()))
+		}
+
+		entries = append(entries, entry)
+		folderEntries++
+	}
+
+	return entries, nil
+}
+
+// isHashPrefixDir returns whether the directory is a hash prefix directory that can hold blobs matching
+// the prefix of the list options
+func (s *File) isHashPrefixDir(name string, merged *options.Options, listOpts options.ListOptions) bool {
+	switch {
+	case merged.HashPrefix > 0:
+		return len(name) == merged.HashPrefix && (strings.HasPrefix(name, listOpts.Prefix) || strings.HasPrefix(listOpts.Prefix, name))
+	case merged.HashPrefix < 0:
+		return len(name) == -merged.HashPrefix
+	default:
+		return false
+	}
+}
+
+// listDAH returns the DAH of the blob from the DAH map, or from its DAH file when it was written by another
+// process, 0 when it cannot be read
+func (s *File) listDAH(fileName string) uint32 {
+	s.fileDAHsMu.Lock()
+	dah, ok := s.fileDAHs[fileName]
+	s.fileDAHsMu.Unlock()
+
+	if ok {
+		return dah
+	}
+
+	// Use _internal variant since List already holds a readSemaphore permit
+	dah, err := s.readDAHFromFile_internal(fileName + ".dah")
+	if err != nil {
+		s.logger.Debugf("[File][List] failed to read DAH file %s: %v", fileName+".dah", err)
+		return 0
+	}
+
+	return dah
+}
+
 // findFilesByExtension performs directory traversal to find files by extension.
 // NOTE: This is intentionally not semaphore-protected since it's a bulk scanning
 // operation that doesn't open many file descriptors simultaneously. It's called
@@ -10,6 +10,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -2075,3 +2076,206 @@ func TestDAHZeroHandling(t *testing.T) {
 		require.True(t, os.IsNotExist(err), "Temp file should be cleaned up")
 	})
 }
+
+func TestFileList(t *testing.T) {
+	ctx := context.Background()
+
+	for _, query := range []string{"", "?hashPrefix=2", "?hashSuffix=1"} {
+		t.Run("store"+query, func(t *testing.T) {
+			u, err := url.Parse("file://" + t.TempDir() + query)
+			require.NoError(t, err)
+
+			f, err := New(ulogger.TestLogger{}, u, options.WithDefaultSubDirectory("listing"))
+			require.NoError(t, err)
+
+			hashes := []chainhash.Hash{
+				chainhash.HashH([]byte("1")),
+				chainhash.HashH([]byte("2")),
+				chainhash.HashH([]byte("3")),
+			}
+
+			for _, hash := range hashes {
+				require.NoError(t, f.Set(ctx, hash[:], fileformat.FileTypeSubtree, []byte("subtree")))
+			}
+
+			require.NoError(t, f.Set(ctx, hashes[0][:], fileformat.FileTypeSubtreeData, []byte("data"), options.WithDeleteAt(100)))
+
+			// blobs in another sub directory are not listed
+			require.NoError(t, f.Set(ctx, hashes[0][:], fileformat.FileTypeTx, []byte("tx"), options.WithSubDirectory("other")))
+
+			result, err := f.List(ctx, options.ListOptions{})
+			require.NoError(t, err)
+			require.Len(t, result.Entries, 4)
+			assert.Empty(t, result.NextCursor)
+
+			for i := 1; i < len(result.Entries); i++ {
+				assert.Less(t, result.Entries[i-1].Cursor(), result.Entries[i].Cursor())
+			}
+
+			for _, entry := range result.Entries {
+				hash, err := chainhash.NewHash(entry.Key)
+				require.NoError(t, err)
+				assert.Equal(t, hash.String(), entry.Name)
+
+				if entry.FileType == fileformat.FileTypeSubtreeData {
+					assert.Equal(t, uint32(100), entry.DAH)
+				} else {
+					assert.Equal(t, uint32(0), entry.DAH)
+				}
+			}
+
+			// file type filter and prefix
+			result, err = f.List(ctx, options.ListOptions{FileType: fileformat.FileTypeSubtreeData})
+			require.NoError(t, err)
+			require.Len(t, result.Entries, 1)
+			assert.Equal(t, hashes[0][:], result.Entries[0].Key)
+
+			result, err = f.List(ctx, options.ListOptions{Prefix: hashes[1].String()[:6]})
+			require.NoError(t, err)
+			require.Len(t, result.Entries, 1)
+			assert.Equal(t, hashes[1].String(), result.Entries[0].Name)
+
+			// other sub directory
+			result, err = f.List(ctx, options.ListOptions{}, options.WithSubDirectory("other"))
+			require.NoError(t, err)
+			require.Len(t, result.Entries, 1)
+			assert.Equal(t, fileformat.FileTypeTx, result.Entries[0].FileType)
+
+			// pagination
+			var cursors []string
+
+			listOpts := options.ListOptions{Limit: 3}
+
+			for {
+				result, err = f.List(ctx, listOpts)
+				require.NoError(t, err)
+
+				for _, entry := range result.Entries {
+					cursors = append(cursors, entry.Cursor())
+				}
+
+				if result.NextCursor == "" {
+					break
+				}
+
+				listOpts.Cursor = result.NextCursor
+			}
+
+			assert.Len(t, cursors, 4)
+		})
+	}
+}
+
+func TestFileList_PagesAcrossHashPrefixDirectories(t *testing.T) {
+	ctx := context.Background()
+
+	for _, query := range []string{"?hashPrefix=2", "?hashSuffix=1"} {
+		t.Run("store"+query, func(t *testing.T) {
+			u, err := url.Parse("file://" + t.TempDir() + query)
+			require.NoError(t, err)
+
+			f, err := New(ulogger.TestLogger{}, u)
+			require.NoError(t, err)
+
+			expected := make([]string, 0, 50)
+
+			for i := 0; i < 50; i++ {
+				hash := chainhash.HashH([]byte(fmt.Sprintf("blob-%d", i)))
+				require.NoError(t, f.Set(ctx, hash[:], fileformat.FileTypeTx, []byte("tx")))
+
+				expected = append(expected, hash.String()+"."+fileformat.FileTypeTx.String())
+			}
+
+			sort.Strings(expected)
+
+			var cursors []string
+
+			listOpts := options.ListOptions{Limit: 7}
+
+			for {
+				result, err := f.List(ctx, listOpts)
+				require.NoError(t, err)
+				require.LessOrEqual(t, len(result.Entries), 7)
+
+				for _, entry := range result.Entries {
+					cursors = append(cursors, entry.Cursor())
+				}
+
+				if result.NextCursor == "" {
+					break
+				}
+
+				listOpts.Cursor = result.NextCursor
+			}
+
+			assert.Equal(t, expected, cursors)
+		})
+	}
+}
+
+func TestFileVerifyChecksum(t *testing.T) {
+	tempDir := t.TempDir()
+
+	u, err := url.Parse("file://" + tempDir)
+	require.NoError(t, err)
+
+	f, err := New(ulogger.TestLogger{}, u)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	key := []byte("test-key-verify-checksum")
+
+	err = f.VerifyChecksum(ctx, key, fileformat.FileTypeTesting)
+	require.True(t, errors.Is(err, errors.ErrNotFound), "missing blob should not be found")
+
+	require.NoError(t, f.Set(ctx, key, fileformat.FileTypeTesting, []byte("test content")))
+	require.NoError(t, f.VerifyChecksum(ctx, key, fileformat.FileTypeTesting))
+
+	filename, err := f.options.ConstructFilename(tempDir, key, fileformat.FileTypeTesting)
+	require.NoError(t, err)
+
+	// flip a byte of the body, the checksum no longer matches
+	data, err := os.ReadFile(filename)
+	require.NoError(t, err)
+
+	data[len(data)-1] ^= 0xff
+	require.NoError(t, os.WriteFile(filename, data, 0o600))
+
+	err = f.VerifyChecksum(ctx, key, fileformat.FileTypeTesting)
+	require.Error(t, err)
+	require.False(t, errors.Is(err, errors.ErrNotFound))
+	require.Contains(t, err.Error(), "checksum mismatch")
+
+	// a blob without a checksum file cannot be verified
+	require.NoError(t, os.Remove(filename+checksumExtension))
+
+	err = f.VerifyChecksum(ctx, key, fileformat.FileTypeTesting)
+	require.True(t, errors.Is(err, errors.ErrNotFound))
+}
+
+func TestFileCleanupAhead(t *testing.T) {
+	ctx := context.Background()
+
+	u, err := url.Parse("file://" + t.TempDir())
+	require.NoError(t, err)
+
+	f, err := New(ulogger.TestLogger{}, u)
+	require.NoError(t, err)
+
+	require.NoError(t, f.Set(ctx, []byte("soon"), fileformat.FileTypeTesting, []byte("soon"), options.WithDeleteAt(105)))
+	require.NoError(t, f.Set(ctx, []byte("later"), fileformat.FileTypeTesting, []byte("later"), options.WithDeleteAt(120)))
+	require.NoError(t, f.Set(ctx, []byte("kept"), fileformat.FileTypeTesting, []byte("kept")))
+
+	// nothing is removed before the block height is known
+	assert.Zero(t, f.CleanupAhead(10))
+
+	f.currentBlockHeight.Store(100)
+
+	assert.Equal(t, 1, f.CleanupAhead(10))
+
+	for key, expected := range map[string]bool{"soon": false, "later": true, "kept": true} {
+		exists, err := f.Exists(ctx, []byte(key), fileformat.FileTypeTesting)
+		require.NoError(t, err)
+		assert.Equal(t, expected, exists, key)
+	}
+}
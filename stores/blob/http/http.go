@@ -19,12 +19,17 @@ package http
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bsv-blockchain/teranode/errors"
@@ -37,6 +42,7 @@ const (
 	blobURLFormat        = "%s/blob/%s?%s"
 	blobURLFormatWithDAH = blobURLFormat + "&dah=%d"
 	blobURLFormatGetDAH  = blobURLFormat + "&getDAH=1"
+	listURLFormat        = "%s/list?%s"
 )
 
 // HTTPStore implements the blob.Store interface by making HTTP requests to a remote
@@ -47,6 +53,8 @@ type HTTPStore struct {
 	baseURL string
 	// httpClient is the HTTP client used for making requests with configurable timeout
 	httpClient *http.Client
+	// multiClient is the HTTP client used for POST /multi requests, without a timeout for the whole response
+	multiClient *http.Client
 	// logger provides structured logging for HTTP operations and errors
 	logger ulogger.Logger
 	// options contains configuration options for the HTTP blob store
@@ -59,6 +67,12 @@ type HTTPStore struct {
 // server URL. It handles serialization, error handling, and connection management to
 // provide a seamless blob.Store interface implementation.
 //
+// The client authenticates to the server with the URL query parameters:
+//   - authTokenFile: File holding the bearer token sent with every request
+//   - authTokenEnv: Environment variable holding the bearer token, used when authTokenFile is not set
+//   - tlsCAFile: CA certificate the https server certificate is verified with, the system roots when not set
+//   - tlsCertFile, tlsKeyFile: Client certificate and key for mutual TLS
+//
 // Parameters:
 //   - logger: Logger for recording operations and errors
 //   - storeURL: URL of the remote blob server (e.g., "http://localhost:8080")
@@ -66,7 +80,7 @@ type HTTPStore struct {
 //
 // Returns:
 //   - *HTTPStore: Configured HTTP blob store client
-//   - error: Configuration error if storeURL is nil
+//   - error: Configuration error if storeURL is nil or the authentication settings are invalid
 func New(logger ulogger.Logger, storeURL *url.URL, opts ...options.StoreOption) (*HTTPStore, error) {
 	logger = logger.New("http")
 
@@ -76,14 +90,100 @@ func New(logger ulogger.Logger, storeURL *url.URL, opts ...options.StoreOption)
 
 	options := options.NewStoreOptions(opts...)
 
+	transport, err := newTransport(storeURL.Query())
+	if err != nil {
+		return nil, err
+	}
+
+	// the query parameters configure the client, they are not part of the URLs of the requests
+	baseURL := *storeURL
+	baseURL.RawQuery = ""
+	baseURL.ForceQuery = false
+
 	return &HTTPStore{
-		baseURL:    storeURL.String(),
-		httpClient: &http.Client{Timeout: 30 * time.Second},
-		logger:     logger,
-		options:    options,
+		baseURL:     strings.TrimSuffix(baseURL.String(), "/"),
+		httpClient:  &http.Client{Timeout: 30 * time.Second, Transport: transport},
+		multiClient: &http.Client{Transport: transport},
+		logger:      logger,
+		options:     options,
 	}, nil
 }
 
+// newTransport creates the transport of the HTTP clients, with the TLS configuration and bearer token
+// configured in the query parameters of the store URL
+func newTransport(query url.Values) (http.RoundTripper, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	caFile, certFile, keyFile := query.Get("tlsCAFile"), query.Get("tlsCertFile"), query.Get("tlsKeyFile")
+
+	if caFile != "" || certFile != "" || keyFile != "" {
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+		if caFile != "" {
+			caCert, err := os.ReadFile(caFile)
+			if err != nil {
+				return nil, errors.NewConfigurationError("[HTTPStore] failed to read TLS CA file %s", caFile, err)
+			}
+
+			tlsConfig.RootCAs = x509.NewCertPool()
+
+			if !tlsConfig.RootCAs.AppendCertsFromPEM(caCert) {
+				return nil, errors.NewConfigurationError("[HTTPStore] no CA certificate found in %s", caFile)
+			}
+		}
+
+		if (certFile != "") != (keyFile != "") {
+			return nil, errors.NewConfigurationError("[HTTPStore] tlsCertFile and tlsKeyFile must be set together")
+		}
+
+		if certFile != "" {
+			cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+			if err != nil {
+				return nil, errors.NewConfigurationError("[HTTPStore] failed to load TLS client certificate", err)
+			}
+
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	var token string
+
+	switch {
+	case query.Get("authTokenFile") != "":
+		b, err := os.ReadFile(query.Get("authTokenFile"))
+		if err != nil {
+			return nil, errors.NewConfigurationError("[HTTPStore] failed to read authTokenFile", err)
+		}
+
+		token = strings.TrimSpace(string(b))
+	case query.Get("authTokenEnv") != "":
+		token = strings.TrimSpace(os.Getenv(query.Get("authTokenEnv")))
+		if token == "" {
+			return nil, errors.NewConfigurationError("[HTTPStore] environment variable %s of authTokenEnv is not set", query.Get("authTokenEnv"))
+		}
+	default:
+		return transport, nil
+	}
+
+	return &bearerTransport{token: token, next: transport}, nil
+}
+
+// bearerTransport adds the bearer token to every request
+type bearerTransport struct {
+	token string
+	next  http.RoundTripper
+}
+
+func (t *bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// a RoundTripper must not modify the request
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.token)
+
+	return t.next.RoundTrip(req)
+}
+
 // Health checks the health status of the remote blob server.
 // It makes an HTTP GET request to the /health endpoint of the remote server
 // and returns the status code, message, and any error encountered.
@@ -380,6 +480,64 @@ func (s *HTTPStore) Del(ctx context.Context, key []byte, fileType fileformat.Fil
 	return nil
 }
 
+// List returns a page of the blobs in the remote blob store.
+// It makes an HTTP GET request to the list endpoint with the listing options as query parameters,
+// and decodes the JSON page returned by the server.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - listOpts: The prefix, file type, cursor and limit of the listing
+//   - opts: Optional file options, the sub directory to list
+//
+// Returns:
+//   - *options.ListResult: The blobs in the page and the cursor of the next page
+//   - error: Any error that occurred during the operation
+func (s *HTTPStore) List(ctx context.Context, listOpts options.ListOptions, opts ...options.FileOption) (*options.ListResult, error) {
+	query := url.Values{}
+
+	if listOpts.Prefix != "" {
+		query.Set("prefix", listOpts.Prefix)
+	}
+
+	if listOpts.FileType != fileformat.FileTypeUnknown {
+		query.Set("fileType", listOpts.FileType.String())
+	}
+
+	if listOpts.Cursor != "" {
+		query.Set("cursor", listOpts.Cursor)
+	}
+
+	if listOpts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(listOpts.Limit))
+	}
+
+	if merged := options.MergeOptions(s.options, opts); merged.SubDirectory != "" {
+		query.Set("subDirectory", merged.SubDirectory)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf(listURLFormat, s.baseURL, query.Encode()), nil)
+	if err != nil {
+		return nil, errors.NewStorageError("[HTTPStore] List failed to create request", err)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.NewStorageError("[HTTPStore] List failed", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.NewStorageError(fmt.Sprintf("[HTTPStore] List failed with status code %d", resp.StatusCode), nil)
+	}
+
+	result := &options.ListResult{}
+	if err = json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return nil, errors.NewStorageError("[HTTPStore] List failed to decode response body", err)
+	}
+
+	return result, nil
+}
+
 // Close performs any necessary cleanup for the HTTP blob store.
 // In the current implementation, this is a no-op as HTTP connections are managed by the HTTP client.
 //
@@ -0,0 +1,307 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+	"github.com/bsv-blockchain/teranode/stores/blob/options"
+)
+
+const multiURLFormat = "%s/multi?%s"
+
+// MaxMultiKeys is the maximum number of keys in a single POST /multi request
+const MaxMultiKeys = 1000
+
+// Status of a blob in the response of a POST /multi request
+const (
+	// MultiStatusFound is followed by the blob in chunks, each an uint32 little endian length and the data,
+	// ended by a chunk of length 0
+	MultiStatusFound byte = iota
+	// MultiStatusNotFound is not followed by any data
+	MultiStatusNotFound
+	// MultiStatusError is followed by the error message, an uint32 little endian length and the message
+	MultiStatusError
+)
+
+// multiChunkAbort is the chunk length marking that reading a found blob failed after the first chunks were
+// sent, it is followed by the error message, like MultiStatusError
+const multiChunkAbort = ^uint32(0)
+
+// MultiRequest is the JSON body of a POST /multi request, the file type and file options are passed as query
+// parameters, like for the other blob endpoints.
+type MultiRequest struct {
+	// Keys are the base64 URL encoded keys of the blobs, the response holds the blobs in the same order
+	Keys []string `json:"keys"`
+}
+
+// MultiWriter writes the response of a POST /multi request, the blobs are written one after the other in the
+// order of the requested keys.
+type MultiWriter struct {
+	w io.Writer
+}
+
+// NewMultiWriter creates a writer of the response of a POST /multi request
+func NewMultiWriter(w io.Writer) *MultiWriter {
+	return &MultiWriter{w: w}
+}
+
+// WriteBlob streams a found blob from the reader, in chunks of at most the size of buf
+func (m *MultiWriter) WriteBlob(r io.Reader, buf []byte) error {
+	if _, err := m.w.Write([]byte{MultiStatusFound}); err != nil {
+		return err
+	}
+
+	var length [4]byte
+
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			binary.LittleEndian.PutUint32(length[:], uint32(n)) //nolint:gosec // n is at most the size of buf
+
+			if _, writeErr := m.w.Write(length[:]); writeErr != nil {
+				return writeErr
+			}
+
+			if _, writeErr := m.w.Write(buf[:n]); writeErr != nil {
+				return writeErr
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			// the chunks sent so far do not tell the client that the blob is incomplete
+			binary.LittleEndian.PutUint32(length[:], multiChunkAbort)
+
+			if _, writeErr := m.w.Write(length[:]); writeErr != nil {
+				return writeErr
+			}
+
+			return m.writeMessage(err.Error())
+		}
+	}
+
+	binary.LittleEndian.PutUint32(length[:], 0)
+
+	_, err := m.w.Write(length[:])
+
+	return err
+}
+
+// WriteNotFound writes a blob that was not found
+func (m *MultiWriter) WriteNotFound() error {
+	_, err := m.w.Write([]byte{MultiStatusNotFound})
+	return err
+}
+
+// WriteError writes a blob that could not be read
+func (m *MultiWriter) WriteError(err error) error {
+	if _, writeErr := m.w.Write([]byte{MultiStatusError}); writeErr != nil {
+		return writeErr
+	}
+
+	return m.writeMessage(err.Error())
+}
+
+// writeMessage writes an error message, an uint32 little endian length and the message
+func (m *MultiWriter) writeMessage(msg string) error {
+	var length [4]byte
+
+	binary.LittleEndian.PutUint32(length[:], uint32(len(msg))) //nolint:gosec // error messages are short
+
+	if _, err := m.w.Write(length[:]); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(m.w, msg)
+
+	return err
+}
+
+// multiBlobReader reads the chunks of a found blob from the response of a POST /multi request
+type multiBlobReader struct {
+	r         io.Reader
+	remaining uint32
+	done      bool
+	err       error
+}
+
+func (m *multiBlobReader) Read(p []byte) (int, error) {
+	for m.remaining == 0 {
+		if m.done {
+			return 0, io.EOF
+		}
+
+		if m.err != nil {
+			return 0, m.err
+		}
+
+		length, err := readUint32(m.r)
+		if err != nil {
+			m.err = err
+			return 0, err
+		}
+
+		switch length {
+		case 0:
+			m.done = true
+		case multiChunkAbort:
+			m.err = readMessage(m.r)
+		default:
+			m.remaining = length
+		}
+	}
+
+	if uint32(len(p)) > m.remaining { //nolint:gosec // only compared
+		p = p[:m.remaining]
+	}
+
+	n, err := m.r.Read(p)
+	m.remaining -= uint32(n) //nolint:gosec // n is at most remaining
+
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
+	if err != nil {
+		m.err = err
+	}
+
+	return n, err
+}
+
+// drain reads the rest of the blob, so the response is at the start of the next blob
+func (m *multiBlobReader) drain() error {
+	_, err := io.Copy(io.Discard, m)
+	return err
+}
+
+func readUint32(r io.Reader) (uint32, error) {
+	var b [4]byte
+
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+
+		return 0, err
+	}
+
+	return binary.LittleEndian.Uint32(b[:]), nil
+}
+
+// readMessage reads the error message sent for a blob that could not be read
+func readMessage(r io.Reader) error {
+	length, err := readUint32(r)
+	if err != nil {
+		return err
+	}
+
+	msg := make([]byte, length)
+	if _, err = io.ReadFull(r, msg); err != nil {
+		return err
+	}
+
+	return errors.NewStorageError("[HTTPStore] remote error: %s", string(msg))
+}
+
+// GetMulti reads many blobs of a file type in a single POST /multi request, in batches of MaxMultiKeys keys.
+// The blobs are streamed from the response, fn is called for every key in the order of the keys, with a reader
+// of the blob that is only valid until fn returns, or with errors.ErrNotFound when the blob does not exist.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - keys: The keys identifying the blobs
+//   - fileType: The type of the files
+//   - fn: Function called for every blob, reading stops at the first error it returns
+//   - opts: Optional file options
+//
+// Returns:
+//   - error: Any error that occurred during the request, or returned by fn
+func (s *HTTPStore) GetMulti(ctx context.Context, keys [][]byte, fileType fileformat.FileType, fn func(index int, reader io.Reader, err error) error, opts ...options.FileOption) error {
+	for start := 0; start < len(keys); start += MaxMultiKeys {
+		end := min(start+MaxMultiKeys, len(keys))
+
+		if err := s.getMultiBatch(ctx, keys[start:end], start, fileType, fn, opts...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *HTTPStore) getMultiBatch(ctx context.Context, keys [][]byte, offset int, fileType fileformat.FileType, fn func(index int, reader io.Reader, err error) error, opts ...options.FileOption) error {
+	request := MultiRequest{Keys: make([]string, len(keys))}
+
+	for i, key := range keys {
+		request.Keys[i] = base64.URLEncoding.EncodeToString(key)
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return errors.NewStorageError("[HTTPStore] GetMulti failed to encode request", err)
+	}
+
+	query := options.FileOptionsToQuery(fileType, opts...)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf(multiURLFormat, s.baseURL, query.Encode()), bytes.NewReader(body))
+	if err != nil {
+		return errors.NewStorageError("[HTTPStore] GetMulti failed to create request", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	// the response of many large blobs can take longer than the timeout of the client
+	resp, err := s.multiClient.Do(req)
+	if err != nil {
+		return errors.NewStorageError("[HTTPStore] GetMulti failed", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.NewStorageError(fmt.Sprintf("[HTTPStore] GetMulti failed with status code %d", resp.StatusCode), nil)
+	}
+
+	var status [1]byte
+
+	for i := range keys {
+		if _, err = io.ReadFull(resp.Body, status[:]); err != nil {
+			return errors.NewStorageError("[HTTPStore] GetMulti failed to read blob %d", offset+i, err)
+		}
+
+		switch status[0] {
+		case MultiStatusFound:
+			reader := &multiBlobReader{r: resp.Body}
+
+			if err = fn(offset+i, reader, nil); err != nil {
+				return err
+			}
+
+			if err = reader.drain(); err != nil {
+				return errors.NewStorageError("[HTTPStore] GetMulti failed to read blob %d", offset+i, err)
+			}
+		case MultiStatusNotFound:
+			if err = fn(offset+i, nil, errors.ErrNotFound); err != nil {
+				return err
+			}
+		case MultiStatusError:
+			if err = fn(offset+i, nil, readMessage(resp.Body)); err != nil {
+				return err
+			}
+		default:
+			return errors.NewStorageError("[HTTPStore] GetMulti received invalid status %d for blob %d", status[0], offset+i)
+		}
+	}
+
+	return nil
+}
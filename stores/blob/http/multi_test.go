@@ -0,0 +1,74 @@
+package http
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingReader returns its data and then an error
+type failingReader struct {
+	data []byte
+}
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	if len(f.data) == 0 {
+		return 0, errors.NewStorageError("disk failure")
+	}
+
+	n := copy(p, f.data)
+	f.data = f.data[n:]
+
+	return n, nil
+}
+
+func TestMultiWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	multiWriter := NewMultiWriter(&buf)
+	chunk := make([]byte, 4)
+
+	require.NoError(t, multiWriter.WriteBlob(bytes.NewReader([]byte("0123456789")), chunk))
+	require.NoError(t, multiWriter.WriteNotFound())
+	require.NoError(t, multiWriter.WriteError(errors.NewStorageError("failed")))
+	require.NoError(t, multiWriter.WriteBlob(&failingReader{data: []byte("partial")}, chunk))
+	require.NoError(t, multiWriter.WriteBlob(bytes.NewReader(nil), chunk))
+
+	status := make([]byte, 1)
+
+	readStatus := func() byte {
+		_, err := io.ReadFull(&buf, status)
+		require.NoError(t, err)
+
+		return status[0]
+	}
+
+	require.Equal(t, MultiStatusFound, readStatus())
+
+	value, err := io.ReadAll(&multiBlobReader{r: &buf})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("0123456789"), value)
+
+	require.Equal(t, MultiStatusNotFound, readStatus())
+
+	require.Equal(t, MultiStatusError, readStatus())
+	assert.ErrorContains(t, readMessage(&buf), "failed")
+
+	// a blob failing after the first chunks is not read as complete
+	require.Equal(t, MultiStatusFound, readStatus())
+
+	_, err = io.ReadAll(&multiBlobReader{r: &buf})
+	assert.ErrorContains(t, err, "disk failure")
+
+	require.Equal(t, MultiStatusFound, readStatus())
+
+	value, err = io.ReadAll(&multiBlobReader{r: &buf})
+	require.NoError(t, err)
+	assert.Empty(t, value)
+
+	assert.Zero(t, buf.Len())
+}
@@ -0,0 +1,799 @@
+// Package leveldb provides an embedded key-value implementation of the blob.Store interface, backed by LevelDB.
+//
+// The file store writes one file, plus .sha256 and .dah side files, for every blob, which does not scale to the
+// millions of small tx, outputs and subtreeMeta blobs Teranode writes. This store keeps all blobs in a single
+// LevelDB database instead, so small-object workloads do not depend on the inode behaviour of the filesystem.
+//
+// Features:
+//   - Native Delete-At-Height support: every DAH is indexed under a key ordered by height, so the blobs that
+//     expire at a block height are found by a range scan, without loading the DAHs of all blobs in memory
+//   - Batched writes: concurrent writes are committed together in a single LevelDB batch
+//   - Compaction-friendly deletes: expired blobs are deleted in batches, after which the deleted index range
+//     is compacted so the tombstones do not slow down later scans
+//   - Listing in ascending name order, straight from the ordered key space
+//
+// The store is configured with a URL like leveldb:///data/txstore?sync=true&batchSize=1000, where the path is
+// the directory of the database. A database can only be opened by one store at a time.
+package leveldb
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+	"github.com/bsv-blockchain/teranode/stores/blob/options"
+	"github.com/bsv-blockchain/teranode/ulogger"
+	goleveldb "github.com/btcsuite/goleveldb/leveldb"
+	"github.com/btcsuite/goleveldb/leveldb/opt"
+	"github.com/btcsuite/goleveldb/leveldb/util"
+	"github.com/ordishs/go-utils"
+)
+
+const (
+	// prefixBlob prefixes the keys of the blobs, followed by the sub directory, "/" and the name of the blob
+	prefixBlob = 'b'
+	// prefixDAH prefixes the keys holding the DAH of a blob, followed by the blob key
+	prefixDAH = 'd'
+	// prefixHeight prefixes the DAH index, followed by the big endian DAH and the blob key
+	prefixHeight = 'h'
+
+	// defaultBatchSize is the maximum number of writes committed in a single batch
+	defaultBatchSize = 1000
+	// cleanupBatchSize is the number of expired blobs deleted in a single batch
+	cleanupBatchSize = 1000
+	// keyLockStripes is the number of mutexes the blob keys are striped over, to serialize the read-modify-write
+	// of the DAH of a blob
+	keyLockStripes = 256
+)
+
+// write is a single write of a batch, a put or a delete
+type write struct {
+	key    []byte
+	value  []byte
+	delete bool
+}
+
+// writeRequest is a group of writes that is committed atomically, in a batch with other write requests
+type writeRequest struct {
+	writes []write
+	done   chan error
+}
+
+// LevelDB implements the blob.Store interface on an embedded LevelDB database.
+type LevelDB struct {
+	logger             ulogger.Logger
+	db                 *goleveldb.DB
+	options            *options.Options
+	writeOptions       *opt.WriteOptions
+	batchSize          int
+	writeCh            chan *writeRequest
+	writerDone         chan struct{}
+	closeMu            sync.RWMutex
+	closed             bool
+	currentBlockHeight atomic.Uint32
+	cleanupCh          chan struct{}
+	cleanupCancel      context.CancelFunc
+
+	// keyLocks serialize the writes that read the DAH of a blob before changing it, so a concurrent write or
+	// cleanup cannot leave the blob, its DAH and its index entry out of step
+	keyLocks [keyLockStripes]sync.Mutex
+}
+
+// New creates a new LevelDB blob store, opening or creating the database in the directory of the URL path.
+//
+// Parameters:
+//   - logger: Logger instance for store operations
+//   - storeURL: URL with the database directory and the optional query parameters sync, to sync every batch to
+//     disk, and batchSize, the maximum number of writes committed in a single batch
+//   - opts: Optional store configuration options
+//
+// Returns:
+//   - *LevelDB: The configured LevelDB blob store
+//   - error: Any error that occurred opening the database
+func New(logger ulogger.Logger, storeURL *url.URL, opts ...options.StoreOption) (*LevelDB, error) {
+	if storeURL == nil {
+		return nil, errors.NewConfigurationError("storeURL is nil")
+	}
+
+	logger = logger.New("leveldb")
+
+	var path string
+	if storeURL.Host == "." {
+		path = storeURL.Path[1:] // relative path
+	} else {
+		path = storeURL.Path // absolute path
+	}
+
+	if len(path) == 0 {
+		return nil, errors.NewConfigurationError("[LevelDB] database path is not set in %s", storeURL)
+	}
+
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, errors.NewStorageError("[LevelDB] failed to create directory", err)
+	}
+
+	batchSize := defaultBatchSize
+
+	if value := storeURL.Query().Get("batchSize"); len(value) > 0 {
+		val, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, errors.NewConfigurationError("[LevelDB] failed to parse batchSize", err)
+		}
+
+		if val <= 0 {
+			return nil, errors.NewConfigurationError("[LevelDB] batchSize must be positive, got %d", val)
+		}
+
+		batchSize = val
+	}
+
+	db, err := goleveldb.OpenFile(path, &opt.Options{
+		// most small blobs are hashes and transactions, which do not compress
+		Compression: opt.NoCompression,
+	})
+	if err != nil {
+		return nil, errors.NewStorageError("[LevelDB] failed to open database %s", path, err)
+	}
+
+	storeOptions := options.NewStoreOptions(opts...)
+
+	cleanupCtx, cleanupCancel := context.WithCancel(context.Background())
+
+	s := &LevelDB{
+		logger:        logger,
+		db:            db,
+		options:       storeOptions,
+		writeOptions:  &opt.WriteOptions{Sync: storeURL.Query().Get("sync") == "true"},
+		batchSize:     batchSize,
+		writeCh:       make(chan *writeRequest, batchSize),
+		writerDone:    make(chan struct{}),
+		cleanupCh:     make(chan struct{}, 1),
+		cleanupCancel: cleanupCancel,
+	}
+
+	go s.writer()
+	go s.dahCleaner(cleanupCtx)
+
+	if storeOptions.BlockHeightCh != nil {
+		go func() {
+			for {
+				select {
+				case <-cleanupCtx.Done():
+					return
+				case blockHeight := <-storeOptions.BlockHeightCh:
+					s.SetCurrentBlockHeight(blockHeight)
+				}
+			}
+		}()
+	}
+
+	return s, nil
+}
+
+// blobKey returns the key of a blob, "b{subDirectory}/{name}.{fileType}", the name is the reversed hex encoded
+// key, the way the file store names its files, or the custom filename
+func blobKey(key []byte, fileType fileformat.FileType, merged *options.Options) []byte {
+	name := merged.Filename
+	if len(name) == 0 {
+		name = utils.ReverseAndHexEncodeSlice(key)
+	}
+
+	return []byte(string(prefixBlob) + merged.SubDirectory + "/" + name + "." + fileType.String())
+}
+
+// dahKey returns the key holding the DAH of the blob
+func dahKey(blobKey []byte) []byte {
+	return append([]byte{prefixDAH}, blobKey...)
+}
+
+// heightKey returns the key of the blob in the DAH index, ordered by DAH
+func heightKey(dah uint32, blobKey []byte) []byte {
+	key := make([]byte, 5, 5+len(blobKey))
+	key[0] = prefixHeight
+	binary.BigEndian.PutUint32(key[1:], dah)
+
+	return append(key, blobKey...)
+}
+
+// keyStripe returns the index of the key lock of the blob key
+func keyStripe(blobKey []byte) int {
+	h := fnv.New32a()
+	_, _ = h.Write(blobKey)
+
+	return int(h.Sum32() % keyLockStripes)
+}
+
+// lockKey locks the key lock of the blob key, and returns the function unlocking it
+func (s *LevelDB) lockKey(blobKey []byte) func() {
+	mu := &s.keyLocks[keyStripe(blobKey)]
+	mu.Lock()
+
+	return mu.Unlock
+}
+
+// lockKeys locks the key locks of all blob keys, in ascending stripe order so concurrent callers cannot
+// deadlock, and returns the function unlocking them
+func (s *LevelDB) lockKeys(blobKeys [][]byte) func() {
+	stripes := make([]int, 0, len(blobKeys))
+	for _, bKey := range blobKeys {
+		stripes = append(stripes, keyStripe(bKey))
+	}
+
+	slices.Sort(stripes)
+	stripes = slices.Compact(stripes)
+
+	for _, stripe := range stripes {
+		s.keyLocks[stripe].Lock()
+	}
+
+	return func() {
+		for _, stripe := range stripes {
+			s.keyLocks[stripe].Unlock()
+		}
+	}
+}
+
+// write commits the writes atomically, in a batch with the writes of concurrent calls
+func (s *LevelDB) write(ctx context.Context, writes []write) error {
+	req := &writeRequest{
+		writes: writes,
+		done:   make(chan error, 1),
+	}
+
+	s.closeMu.RLock()
+
+	if s.closed {
+		s.closeMu.RUnlock()
+		return errors.NewStorageError("[LevelDB] store is closed")
+	}
+
+	select {
+	case s.writeCh <- req:
+		s.closeMu.RUnlock()
+	case <-ctx.Done():
+		s.closeMu.RUnlock()
+		return errors.NewStorageError("[LevelDB] context done before write", ctx.Err())
+	}
+
+	// the request is committed, or not, even when the context is done while waiting
+	return <-req.done
+}
+
+// writer commits the write requests, combining the requests that are waiting into a single batch
+func (s *LevelDB) writer() {
+	defer close(s.writerDone)
+
+	requests := make([]*writeRequest, 0, s.batchSize)
+
+	for req := range s.writeCh {
+		requests = append(requests[:0], req)
+
+	drain:
+		for len(requests) < s.batchSize {
+			select {
+			case next, ok := <-s.writeCh:
+				if !ok {
+					break drain
+				}
+
+				requests = append(requests, next)
+			default:
+				break drain
+			}
+		}
+
+		batch := new(goleveldb.Batch)
+
+		for _, request := range requests {
+			for _, w := range request.writes {
+				if w.delete {
+					batch.Delete(w.key)
+				} else {
+					batch.Put(w.key, w.value)
+				}
+			}
+		}
+
+		var err error
+		if writeErr := s.db.Write(batch, s.writeOptions); writeErr != nil {
+			err = errors.NewStorageError("[LevelDB] failed to write batch", writeErr)
+		}
+
+		for _, request := range requests {
+			request.done <- err
+		}
+	}
+}
+
+// getDAH returns the DAH of the blob, 0 when the blob does not expire
+func (s *LevelDB) getDAH(blobKey []byte) (uint32, error) {
+	value, err := s.db.Get(dahKey(blobKey), nil)
+	if err != nil {
+		if errors.Is(err, goleveldb.ErrNotFound) {
+			return 0, nil
+		}
+
+		return 0, errors.NewStorageError("[LevelDB] failed to get DAH", err)
+	}
+
+	if len(value) != 4 {
+		return 0, errors.NewStorageError("[LevelDB] invalid DAH of %d bytes", len(value))
+	}
+
+	return binary.BigEndian.Uint32(value), nil
+}
+
+// dahWrites returns the writes that change the DAH of the blob from oldDAH to newDAH
+func dahWrites(blobKey []byte, oldDAH, newDAH uint32) []write {
+	if oldDAH == newDAH {
+		return nil
+	}
+
+	writes := make([]write, 0, 3)
+
+	if oldDAH > 0 {
+		writes = append(writes, write{key: heightKey(oldDAH, blobKey), delete: true})
+	}
+
+	if newDAH > 0 {
+		value := make([]byte, 4)
+		binary.BigEndian.PutUint32(value, newDAH)
+
+		writes = append(writes,
+			write{key: dahKey(blobKey), value: value},
+			write{key: heightKey(newDAH, blobKey), value: []byte{}},
+		)
+	} else {
+		writes = append(writes, write{key: dahKey(blobKey), delete: true})
+	}
+
+	return writes
+}
+
+// Health checks whether the database can be read.
+//
+// Parameters:
+//   - ctx: Context for the operation (unused)
+//   - checkLiveness: Whether to perform a liveness check (ignored)
+//
+// Returns:
+//   - int: HTTP status code indicating health status
+//   - string: Status message
+//   - error: Any error that occurred during the health check
+func (s *LevelDB) Health(_ context.Context, _ bool) (int, string, error) {
+	if _, err := s.db.Has([]byte{prefixBlob}, nil); err != nil {
+		return http.StatusServiceUnavailable, "LevelDB Store: unable to read database", err
+	}
+
+	return http.StatusOK, "LevelDB Store", nil
+}
+
+// Exists checks whether the blob exists in the store.
+func (s *LevelDB) Exists(_ context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (bool, error) {
+	merged := options.MergeOptions(s.options, opts)
+
+	found, err := s.db.Has(blobKey(key, fileType, merged), nil)
+	if err != nil {
+		return false, errors.NewStorageError("[LevelDB][Exists] [%s] failed to check blob", utils.ReverseAndHexEncodeSlice(key), err)
+	}
+
+	return found, nil
+}
+
+// Get retrieves the blob from the store.
+//
+// Parameters:
+//   - ctx: Context for the operation (unused)
+//   - key: The key of the blob
+//   - fileType: The type of the blob
+//   - opts: Optional file options
+//
+// Returns:
+//   - []byte: The blob data
+//   - error: errors.ErrNotFound when the blob does not exist, or any other error that occurred
+func (s *LevelDB) Get(_ context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) ([]byte, error) {
+	merged := options.MergeOptions(s.options, opts)
+
+	value, err := s.db.Get(blobKey(key, fileType, merged), nil)
+	if err != nil {
+		if errors.Is(err, goleveldb.ErrNotFound) {
+			return nil, errors.ErrNotFound
+		}
+
+		return nil, errors.NewStorageError("[LevelDB][Get] [%s] failed to get blob", utils.ReverseAndHexEncodeSlice(key), err)
+	}
+
+	header, err := fileformat.ReadHeaderFromBytes(value)
+	if err != nil {
+		return nil, errors.NewStorageError("[LevelDB][Get] [%s] missing or invalid header", utils.ReverseAndHexEncodeSlice(key), err)
+	}
+
+	if header.FileType() != fileType {
+		return nil, errors.NewStorageError("[LevelDB][Get] [%s] header filetype mismatch: got %s, want %s", utils.ReverseAndHexEncodeSlice(key), header.FileType(), fileType)
+	}
+
+	return value[header.Size():], nil
+}
+
+// GetIoReader retrieves the blob from the store as a reader. The blob is held in memory, so the reader
+// implements io.Seeker, which the HTTP blob server uses for range requests.
+func (s *LevelDB) GetIoReader(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (io.ReadCloser, error) {
+	value, err := s.Get(ctx, key, fileType, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &readSeekCloser{Reader: bytes.NewReader(value)}, nil
+}
+
+// Set stores the blob, with the DAH of the options, or the block height retention of the store when no DAH is
+// given. The blob, its DAH and its DAH index entry are written atomically.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - key: The key of the blob
+//   - fileType: The type of the blob
+//   - value: The blob data
+//   - opts: Optional file options
+//
+// Returns:
+//   - error: errors.NewBlobAlreadyExistsError when the blob exists and overwriting is not allowed, or any other
+//     error that occurred
+func (s *LevelDB) Set(ctx context.Context, key []byte, fileType fileformat.FileType, value []byte, opts ...options.FileOption) error {
+	merged := options.MergeOptions(s.options, opts)
+	bKey := blobKey(key, fileType, merged)
+
+	unlock := s.lockKey(bKey)
+	defer unlock()
+
+	if !merged.AllowOverwrite {
+		found, err := s.db.Has(bKey, nil)
+		if err != nil {
+			return errors.NewStorageError("[LevelDB][Set] [%s] failed to check blob", utils.ReverseAndHexEncodeSlice(key), err)
+		}
+
+		if found {
+			return errors.NewBlobAlreadyExistsError("[LevelDB][Set] [%s] blob already exists", utils.ReverseAndHexEncodeSlice(key))
+		}
+	}
+
+	dah := merged.DAH
+	if dah == 0 && merged.BlockHeightRetention > 0 {
+		dah = s.currentBlockHeight.Load() + merged.BlockHeightRetention
+	}
+
+	oldDAH, err := s.getDAH(bKey)
+	if err != nil {
+		return err
+	}
+
+	header := fileformat.NewHeader(fileType)
+
+	data := make([]byte, 0, header.Size()+len(value))
+	data = append(data, header.Bytes()...)
+	data = append(data, value...)
+
+	writes := append([]write{{key: bKey, value: data}}, dahWrites(bKey, oldDAH, dah)...)
+
+	return s.write(ctx, writes)
+}
+
+// SetFromReader stores the blob read from the reader. The store is meant for small blobs, which are read
+// into memory before they are written.
+func (s *LevelDB) SetFromReader(ctx context.Context, key []byte, fileType fileformat.FileType, reader io.ReadCloser, opts ...options.FileOption) error {
+	defer reader.Close()
+
+	value, err := io.ReadAll(reader)
+	if err != nil {
+		return errors.NewStorageError("[LevelDB][SetFromReader] [%s] failed to read data from reader", utils.ReverseAndHexEncodeSlice(key), err)
+	}
+
+	return s.Set(ctx, key, fileType, value, opts...)
+}
+
+// SetDAH sets the DAH of the blob, a DAH of 0 keeps the blob forever.
+func (s *LevelDB) SetDAH(ctx context.Context, key []byte, fileType fileformat.FileType, newDAH uint32, opts ...options.FileOption) error {
+	merged := options.MergeOptions(s.options, opts)
+	bKey := blobKey(key, fileType, merged)
+
+	unlock := s.lockKey(bKey)
+	defer unlock()
+
+	found, err := s.db.Has(bKey, nil)
+	if err != nil {
+		return errors.NewStorageError("[LevelDB][SetDAH] [%s] failed to check blob", utils.ReverseAndHexEncodeSlice(key), err)
+	}
+
+	if !found {
+		return errors.ErrNotFound
+	}
+
+	oldDAH, err := s.getDAH(bKey)
+	if err != nil {
+		return err
+	}
+
+	writes := dahWrites(bKey, oldDAH, newDAH)
+	if len(writes) == 0 {
+		return nil
+	}
+
+	return s.write(ctx, writes)
+}
+
+// GetDAH returns the DAH of the blob, 0 when the blob does not expire.
+func (s *LevelDB) GetDAH(_ context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (uint32, error) {
+	merged := options.MergeOptions(s.options, opts)
+	bKey := blobKey(key, fileType, merged)
+
+	found, err := s.db.Has(bKey, nil)
+	if err != nil {
+		return 0, errors.NewStorageError("[LevelDB][GetDAH] [%s] failed to check blob", utils.ReverseAndHexEncodeSlice(key), err)
+	}
+
+	if !found {
+		return 0, errors.ErrNotFound
+	}
+
+	return s.getDAH(bKey)
+}
+
+// Del deletes the blob and its DAH, deleting a blob that does not exist is not an error.
+func (s *LevelDB) Del(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) error {
+	merged := options.MergeOptions(s.options, opts)
+	bKey := blobKey(key, fileType, merged)
+
+	unlock := s.lockKey(bKey)
+	defer unlock()
+
+	dah, err := s.getDAH(bKey)
+	if err != nil {
+		return err
+	}
+
+	writes := []write{{key: bKey, delete: true}}
+
+	if dah > 0 {
+		writes = append(writes, write{key: dahKey(bKey), delete: true}, write{key: heightKey(dah, bKey), delete: true})
+	}
+
+	return s.write(ctx, writes)
+}
+
+// List returns a page of the blobs in the sub directory of the options, in ascending order of their name.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - listOpts: The prefix, file type, cursor and limit of the listing
+//   - opts: Optional file options, the sub directory to list
+//
+// Returns:
+//   - *options.ListResult: The blobs in the page and the cursor of the next page
+//   - error: Any error that occurred during the listing
+func (s *LevelDB) List(ctx context.Context, listOpts options.ListOptions, opts ...options.FileOption) (*options.ListResult, error) {
+	merged := options.MergeOptions(s.options, opts)
+	dirPrefix := string(prefixBlob) + merged.SubDirectory + "/"
+	limit := listOpts.GetLimit()
+
+	iter := s.db.NewIterator(util.BytesPrefix([]byte(dirPrefix+listOpts.Prefix)), nil)
+	defer iter.Release()
+
+	var ok bool
+	if listOpts.Cursor != "" {
+		ok = iter.Seek([]byte(dirPrefix + listOpts.Cursor))
+	} else {
+		ok = iter.First()
+	}
+
+	entries := make([]options.ListEntry, 0, min(limit+1, options.DefaultListLimit))
+
+	for ; ok && len(entries) <= limit; ok = iter.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		entry, valid := options.ParseListEntry(strings.TrimPrefix(string(iter.Key()), dirPrefix))
+		if !valid || !listOpts.Matches(entry.Name, entry.FileType) {
+			continue
+		}
+
+		if listOpts.Cursor != "" && entry.Cursor() <= listOpts.Cursor {
+			continue
+		}
+
+		dah, err := s.getDAH(iter.Key())
+		if err != nil {
+			return nil, err
+		}
+
+		entry.DAH = dah
+		entries = append(entries, entry)
+	}
+
+	if err := iter.Error(); err != nil {
+		return nil, errors.NewStorageError("[LevelDB][List] failed to iterate blobs", err)
+	}
+
+	return options.NewListResult(entries, listOpts), nil
+}
+
+// SetCurrentBlockHeight sets the current block height and triggers the deletion of the blobs that expired.
+func (s *LevelDB) SetCurrentBlockHeight(height uint32) {
+	s.currentBlockHeight.Store(height)
+
+	select {
+	case s.cleanupCh <- struct{}{}:
+	default: // Channel is full; we are already cleaning up.
+	}
+}
+
+// dahCleaner deletes the expired blobs whenever the block height changes
+func (s *LevelDB) dahCleaner(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.cleanupCh:
+			if err := s.cleanupExpired(ctx, s.currentBlockHeight.Load()); err != nil {
+				s.logger.Errorf("[LevelDB] failed to clean up expired blobs: %v", err)
+			}
+		}
+	}
+}
+
+// cleanupExpired deletes the blobs with a DAH at or below the block height, scanning the DAH index from the
+// lowest height. The blobs are deleted in batches, after which the deleted part of the index is compacted.
+func (s *LevelDB) cleanupExpired(ctx context.Context, blockHeight uint32) error {
+	if blockHeight == 0 {
+		return nil
+	}
+
+	expired := util.Range{
+		Start: heightKey(0, nil),
+		Limit: heightKey(blockHeight, []byte{0xff}),
+	}
+
+	deleted := 0
+
+	for {
+		if ctx.Err() != nil {
+			// the store is closing
+			return nil
+		}
+
+		writes, blobs, unlock, err := s.expiredWrites(expired, blockHeight)
+		if err != nil {
+			return err
+		}
+
+		if len(writes) == 0 {
+			unlock()
+			break
+		}
+
+		err = s.write(ctx, writes)
+
+		unlock()
+
+		if err != nil {
+			return err
+		}
+
+		deleted += blobs
+	}
+
+	if deleted == 0 {
+		return nil
+	}
+
+	s.logger.Debugf("[LevelDB] deleted %d blobs expired at height %d", deleted, blockHeight)
+
+	if err := s.db.CompactRange(expired); err != nil {
+		return errors.NewStorageError("[LevelDB] failed to compact DAH index", err)
+	}
+
+	return nil
+}
+
+// expiredWrites returns the writes deleting the next batch of expired blobs, with their DAH and index entries,
+// and the number of blobs deleted by the writes. The key locks of the blobs are held until the returned unlock
+// function is called, which must be after the writes are committed, so a DAH changed in the meantime is not lost.
+func (s *LevelDB) expiredWrites(expired util.Range, blockHeight uint32) ([]write, int, func(), error) {
+	indexKeys, blobKeys, dahs, err := s.expiredEntries(expired, blockHeight)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	unlock := s.lockKeys(blobKeys)
+
+	writes := make([]write, 0, 3*len(indexKeys))
+	blobs := 0
+
+	for i, bKey := range blobKeys {
+		// the DAH of the blob may have been changed since the index entry was read
+		currentDAH, err := s.getDAH(bKey)
+		if err != nil {
+			unlock()
+			return nil, 0, nil, err
+		}
+
+		writes = append(writes, write{key: indexKeys[i], delete: true})
+
+		if currentDAH == dahs[i] {
+			writes = append(writes, write{key: bKey, delete: true}, write{key: dahKey(bKey), delete: true})
+			blobs++
+		}
+	}
+
+	return writes, blobs, unlock, nil
+}
+
+// expiredEntries returns the next batch of entries of the DAH index at or below the block height, with the keys
+// and DAHs of their blobs
+func (s *LevelDB) expiredEntries(expired util.Range, blockHeight uint32) (indexKeys, blobKeys [][]byte, dahs []uint32, err error) {
+	iter := s.db.NewIterator(&expired, nil)
+	defer iter.Release()
+
+	for len(indexKeys) < cleanupBatchSize && iter.Next() {
+		key := iter.Key()
+
+		dah := binary.BigEndian.Uint32(key[1:5])
+		if dah > blockHeight {
+			break
+		}
+
+		indexKeys = append(indexKeys, append([]byte(nil), key...))
+		blobKeys = append(blobKeys, append([]byte(nil), key[5:]...))
+		dahs = append(dahs, dah)
+	}
+
+	if err = iter.Error(); err != nil {
+		return nil, nil, nil, errors.NewStorageError("[LevelDB] failed to iterate DAH index", err)
+	}
+
+	return indexKeys, blobKeys, dahs, nil
+}
+
+// Close stops the background processes and closes the database, waiting for the pending writes.
+func (s *LevelDB) Close(_ context.Context) error {
+	s.closeMu.Lock()
+
+	if s.closed {
+		s.closeMu.Unlock()
+		return nil
+	}
+
+	s.closed = true
+	s.cleanupCancel()
+	close(s.writeCh)
+	s.closeMu.Unlock()
+
+	<-s.writerDone
+
+	if err := s.db.Close(); err != nil {
+		return errors.NewStorageError("[LevelDB] failed to close database", err)
+	}
+
+	return nil
+}
+
+// readSeekCloser is a seekable reader over a blob held in memory
+type readSeekCloser struct {
+	*bytes.Reader
+}
+
+func (r *readSeekCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,424 @@
+package leveldb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+	"github.com/bsv-blockchain/teranode/stores/blob/options"
+	"github.com/bsv-blockchain/teranode/ulogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(t *testing.T, path string, opts ...options.StoreOption) *LevelDB {
+	u, err := url.Parse("leveldb://" + path)
+	require.NoError(t, err)
+
+	store, err := New(ulogger.TestLogger{}, u, opts...)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = store.Close(context.Background())
+	})
+
+	return store
+}
+
+func TestLevelDB_New(t *testing.T) {
+	u, err := url.Parse("leveldb://" + t.TempDir() + "?batchSize=abc")
+	require.NoError(t, err)
+
+	_, err = New(ulogger.TestLogger{}, u)
+	require.Error(t, err)
+
+	_, err = New(ulogger.TestLogger{}, nil)
+	require.Error(t, err)
+}
+
+func TestLevelDB_Health(t *testing.T) {
+	store := newTestStore(t, t.TempDir())
+
+	status, _, err := store.Health(context.Background(), true)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+
+	require.NoError(t, store.Close(context.Background()))
+
+	status, _, err = store.Health(context.Background(), true)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+}
+
+func TestLevelDB_SetAndGet(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t, t.TempDir())
+
+	key := []byte("test-key")
+	value := []byte("test-value")
+
+	require.NoError(t, store.Set(ctx, key, fileformat.FileTypeTesting, value))
+
+	got, err := store.Get(ctx, key, fileformat.FileTypeTesting)
+	require.NoError(t, err)
+	assert.Equal(t, value, got)
+
+	// the file type is part of the key
+	_, err = store.Get(ctx, key, fileformat.FileTypeTx)
+	require.ErrorIs(t, err, errors.ErrNotFound)
+
+	// blobs are not overwritten unless allowed
+	err = store.Set(ctx, key, fileformat.FileTypeTesting, []byte("other"))
+	require.ErrorIs(t, err, errors.ErrBlobAlreadyExists)
+
+	require.NoError(t, store.Set(ctx, key, fileformat.FileTypeTesting, []byte("other"), options.WithAllowOverwrite(true)))
+
+	got, err = store.Get(ctx, key, fileformat.FileTypeTesting)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("other"), got)
+}
+
+func TestLevelDB_SetFromReader(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t, t.TempDir())
+
+	key := []byte("test-key")
+	value := []byte("test-value")
+
+	require.NoError(t, store.SetFromReader(ctx, key, fileformat.FileTypeTesting, io.NopCloser(bytes.NewReader(value))))
+
+	got, err := store.Get(ctx, key, fileformat.FileTypeTesting)
+	require.NoError(t, err)
+	assert.Equal(t, value, got)
+}
+
+func TestLevelDB_GetIoReader(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t, t.TempDir())
+
+	key := []byte("test-key")
+	value := []byte("test-value")
+
+	require.NoError(t, store.Set(ctx, key, fileformat.FileTypeTesting, value))
+
+	reader, err := store.GetIoReader(ctx, key, fileformat.FileTypeTesting)
+	require.NoError(t, err)
+
+	defer reader.Close()
+
+	// the reader is seekable, for range requests
+	seeker, ok := reader.(io.Seeker)
+	require.True(t, ok)
+
+	_, err = seeker.Seek(5, io.SeekStart)
+	require.NoError(t, err)
+
+	got, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, value[5:], got)
+
+	_, err = store.GetIoReader(ctx, []byte("missing"), fileformat.FileTypeTesting)
+	require.ErrorIs(t, err, errors.ErrNotFound)
+}
+
+func TestLevelDB_ExistsAndDel(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t, t.TempDir())
+
+	key := []byte("test-key")
+
+	exists, err := store.Exists(ctx, key, fileformat.FileTypeTesting)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	require.NoError(t, store.Set(ctx, key, fileformat.FileTypeTesting, []byte("value"), options.WithDeleteAt(10)))
+
+	exists, err = store.Exists(ctx, key, fileformat.FileTypeTesting)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	require.NoError(t, store.Del(ctx, key, fileformat.FileTypeTesting))
+
+	exists, err = store.Exists(ctx, key, fileformat.FileTypeTesting)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	// the DAH and its index entry are deleted with the blob
+	assert.Empty(t, dbKeys(t, store, prefixDAH))
+	assert.Empty(t, dbKeys(t, store, prefixHeight))
+
+	// deleting a missing blob is not an error
+	require.NoError(t, store.Del(ctx, key, fileformat.FileTypeTesting))
+}
+
+func TestLevelDB_DAHOperations(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t, t.TempDir())
+
+	key := []byte("test-key")
+
+	_, err := store.GetDAH(ctx, key, fileformat.FileTypeTesting)
+	require.ErrorIs(t, err, errors.ErrNotFound)
+
+	err = store.SetDAH(ctx, key, fileformat.FileTypeTesting, 10)
+	require.ErrorIs(t, err, errors.ErrNotFound)
+
+	require.NoError(t, store.Set(ctx, key, fileformat.FileTypeTesting, []byte("value"), options.WithDeleteAt(5)))
+
+	dah, err := store.GetDAH(ctx, key, fileformat.FileTypeTesting)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(5), dah)
+
+	require.NoError(t, store.SetDAH(ctx, key, fileformat.FileTypeTesting, 10))
+
+	dah, err = store.GetDAH(ctx, key, fileformat.FileTypeTesting)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(10), dah)
+
+	// the index only holds the current DAH
+	assert.Len(t, dbKeys(t, store, prefixHeight), 1)
+
+	require.NoError(t, store.SetDAH(ctx, key, fileformat.FileTypeTesting, 0))
+
+	dah, err = store.GetDAH(ctx, key, fileformat.FileTypeTesting)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), dah)
+	assert.Empty(t, dbKeys(t, store, prefixHeight))
+}
+
+func TestLevelDB_BlockHeightRetention(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t, t.TempDir(), options.WithDefaultBlockHeightRetention(10))
+
+	store.currentBlockHeight.Store(100)
+
+	require.NoError(t, store.Set(ctx, []byte("key"), fileformat.FileTypeTesting, []byte("value")))
+
+	dah, err := store.GetDAH(ctx, []byte("key"), fileformat.FileTypeTesting)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(110), dah)
+}
+
+func TestLevelDB_CleanupExpired(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t, t.TempDir())
+
+	blobs := map[string]uint32{
+		"expired":       1,
+		"expired-now":   2,
+		"fresh":         3,
+		"no-dah":        0,
+		"dah-increased": 1,
+	}
+
+	for key, dah := range blobs {
+		require.NoError(t, store.Set(ctx, []byte(key), fileformat.FileTypeTesting, []byte("value"), options.WithDeleteAt(dah)))
+	}
+
+	require.NoError(t, store.SetDAH(ctx, []byte("dah-increased"), fileformat.FileTypeTesting, 5))
+
+	require.NoError(t, store.cleanupExpired(ctx, 2))
+
+	for key, expected := range map[string]bool{"expired": false, "expired-now": false, "fresh": true, "no-dah": true, "dah-increased": true} {
+		exists, err := store.Exists(ctx, []byte(key), fileformat.FileTypeTesting)
+		require.NoError(t, err)
+		assert.Equal(t, expected, exists, key)
+	}
+
+	assert.Len(t, dbKeys(t, store, prefixHeight), 2)
+
+	// the cleanup runs in the background when the block height changes
+	store.SetCurrentBlockHeight(5)
+
+	require.Eventually(t, func() bool {
+		exists, err := store.Exists(ctx, []byte("dah-increased"), fileformat.FileTypeTesting)
+		return err == nil && !exists
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestLevelDB_SetDAHRacingCleanup(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t, t.TempDir())
+
+	const blobs = 500
+
+	keys := make([][]byte, blobs)
+
+	for i := range keys {
+		key := chainhash.HashH([]byte(fmt.Sprintf("key-%d", i)))
+		keys[i] = key[:]
+
+		require.NoError(t, store.Set(ctx, keys[i], fileformat.FileTypeTx, []byte("value"), options.WithDeleteAt(10)))
+	}
+
+	results := make([]error, blobs)
+
+	var wg sync.WaitGroup
+
+	for i := range keys {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			results[i] = store.SetDAH(ctx, keys[i], fileformat.FileTypeTx, 0)
+		}(i)
+	}
+
+	require.NoError(t, store.cleanupExpired(ctx, 10))
+
+	wg.Wait()
+
+	kept := 0
+
+	for i, key := range keys {
+		exists, err := store.Exists(ctx, key, fileformat.FileTypeTx)
+		require.NoError(t, err)
+
+		if results[i] == nil {
+			// the DAH was removed before the cleanup deleted the blob, so the blob is kept forever
+			require.True(t, exists, "blob %d", i)
+
+			dah, err := store.GetDAH(ctx, key, fileformat.FileTypeTx)
+			require.NoError(t, err)
+			assert.Equal(t, uint32(0), dah)
+
+			kept++
+		} else {
+			require.ErrorIs(t, results[i], errors.ErrNotFound)
+			require.False(t, exists, "blob %d", i)
+		}
+	}
+
+	// no DAH or index entries are left behind for the kept or the deleted blobs
+	assert.Empty(t, dbKeys(t, store, prefixDAH))
+	assert.Empty(t, dbKeys(t, store, prefixHeight))
+	assert.Len(t, dbKeys(t, store, prefixBlob), kept)
+}
+
+func TestLevelDB_ConcurrentWrites(t *testing.T) {
+	ctx := context.Background()
+
+	u, err := url.Parse("leveldb://" + t.TempDir() + "?batchSize=10")
+	require.NoError(t, err)
+
+	store, err := New(ulogger.TestLogger{}, u)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			key := chainhash.HashH([]byte(fmt.Sprintf("key-%d", i)))
+			assert.NoError(t, store.Set(ctx, key[:], fileformat.FileTypeTx, []byte(fmt.Sprintf("value-%d", i)), options.WithDeleteAt(uint32(i+1)))) //nolint:gosec
+		}(i)
+	}
+
+	wg.Wait()
+
+	result, err := store.List(ctx, options.ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, result.Entries, 100)
+
+	// the blobs survive closing and reopening the database
+	require.NoError(t, store.Close(ctx))
+
+	reopened := newTestStore(t, u.Path)
+
+	key := chainhash.HashH([]byte("key-42"))
+
+	value, err := reopened.Get(ctx, key[:], fileformat.FileTypeTx)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value-42"), value)
+
+	dah, err := reopened.GetDAH(ctx, key[:], fileformat.FileTypeTx)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(43), dah)
+
+	err = store.Set(ctx, key[:], fileformat.FileTypeTx, []byte("closed"), options.WithAllowOverwrite(true))
+	require.Error(t, err)
+}
+
+func TestLevelDB_List(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t, t.TempDir())
+
+	keys := [][]byte{{0x01, 0xaa}, {0x02, 0xaa}, {0x03, 0xbb}}
+
+	for _, key := range keys {
+		require.NoError(t, store.Set(ctx, key, fileformat.FileTypeSubtree, []byte("subtree")))
+	}
+
+	require.NoError(t, store.Set(ctx, keys[0], fileformat.FileTypeSubtreeData, []byte("data"), options.WithDeleteAt(10)))
+	require.NoError(t, store.Set(ctx, keys[0], fileformat.FileTypeTx, []byte("tx"), options.WithSubDirectory("other")))
+	require.NoError(t, store.Set(ctx, nil, fileformat.FileTypeDat, []byte("dat"), options.WithFilename("custom")))
+
+	result, err := store.List(ctx, options.ListOptions{})
+	require.NoError(t, err)
+
+	cursors := make([]string, 0, len(result.Entries))
+	for _, entry := range result.Entries {
+		cursors = append(cursors, entry.Cursor())
+	}
+
+	assert.Equal(t, []string{"aa01.subtree", "aa01.subtreeData", "aa02.subtree", "bb03.subtree", "custom.dat"}, cursors)
+	assert.Equal(t, keys[0], result.Entries[0].Key)
+	assert.Equal(t, uint32(10), result.Entries[1].DAH)
+	assert.Nil(t, result.Entries[4].Key)
+	assert.Empty(t, result.NextCursor)
+
+	// prefix and file type filter
+	result, err = store.List(ctx, options.ListOptions{Prefix: "aa", FileType: fileformat.FileTypeSubtree})
+	require.NoError(t, err)
+	require.Len(t, result.Entries, 2)
+	assert.Equal(t, "aa02", result.Entries[1].Name)
+
+	// pagination
+	result, err = store.List(ctx, options.ListOptions{Limit: 3})
+	require.NoError(t, err)
+	require.Len(t, result.Entries, 3)
+	assert.Equal(t, "aa02.subtree", result.NextCursor)
+
+	result, err = store.List(ctx, options.ListOptions{Limit: 3, Cursor: result.NextCursor})
+	require.NoError(t, err)
+	require.Len(t, result.Entries, 2)
+	assert.Equal(t, "bb03.subtree", result.Entries[0].Cursor())
+	assert.Empty(t, result.NextCursor)
+
+	// sub directory
+	result, err = store.List(ctx, options.ListOptions{}, options.WithSubDirectory("other"))
+	require.NoError(t, err)
+	require.Len(t, result.Entries, 1)
+	assert.Equal(t, fileformat.FileTypeTx, result.Entries[0].FileType)
+}
+
+// dbKeys returns the keys in the database with the prefix
+func dbKeys(t *testing.T, store *LevelDB, prefix byte) [][]byte {
+	iter := store.db.NewIterator(nil, nil)
+	defer iter.Release()
+
+	var keys [][]byte
+
+	for iter.Next() {
+		if iter.Key()[0] == prefix {
+			keys = append(keys, append([]byte(nil), iter.Key()...))
+		}
+	}
+
+	require.NoError(t, iter.Error())
+
+	return keys
+}
@@ -0,0 +1,82 @@
+package blob
+
+import (
+	"context"
+
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/stores/blob/options"
+)
+
+// Lister is implemented by stores that can enumerate the blobs they hold. It is kept separate from the
+// Store interface, as not every store can list its blobs, see List and Walk for using it on any Store.
+//
+// Implementations include the memory, file, s3 and http stores, and the batcher, localdah and logger
+// wrappers when the store they wrap is a Lister.
+type Lister interface {
+	// List returns a page of the blobs in the store.
+	// Parameters:
+	//   - ctx: The context for the operation
+	//   - listOpts: The prefix, file type, cursor and limit of the listing
+	//   - opts: Optional file options, the sub directory to list
+	// Returns:
+	//   - *options.ListResult: The blobs in the page and the cursor of the next page
+	//   - error: Any error that occurred during the listing
+	List(ctx context.Context, listOpts options.ListOptions, opts ...options.FileOption) (*options.ListResult, error)
+}
+
+// List returns a page of the blobs in the store, or an error when the store does not support listing.
+//
+// Parameters:
+//   - ctx: The context for the operation
+//   - store: The store to list
+//   - listOpts: The prefix, file type, cursor and limit of the listing
+//   - opts: Optional file options
+//
+// Returns:
+//   - *options.ListResult: The blobs in the page and the cursor of the next page
+//   - error: Any error that occurred during the listing
+func List(ctx context.Context, store Store, listOpts options.ListOptions, opts ...options.FileOption) (*options.ListResult, error) {
+	lister, ok := store.(Lister)
+	if !ok {
+		return nil, errors.NewStorageError("blob store %T does not support listing", store)
+	}
+
+	return lister.List(ctx, listOpts, opts...)
+}
+
+// Walk calls fn for every blob in the store matching the list options, fetching the blobs page by page.
+// The walk starts after listOpts.Cursor when set and stops at the first error returned by fn.
+//
+// Parameters:
+//   - ctx: The context for the operation
+//   - store: The store to walk
+//   - listOpts: The prefix, file type, start cursor and page size of the walk
+//   - fn: Function called for every blob
+//   - opts: Optional file options
+//
+// Returns:
+//   - error: Any error that occurred during the listing, or returned by fn
+func Walk(ctx context.Context, store Store, listOpts options.ListOptions, fn func(entry options.ListEntry) error, opts ...options.FileOption) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		result, err := List(ctx, store, listOpts, opts...)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range result.Entries {
+			if err = fn(entry); err != nil {
+				return err
+			}
+		}
+
+		if result.NextCursor == "" {
+			return nil
+		}
+
+		listOpts.Cursor = result.NextCursor
+	}
+}
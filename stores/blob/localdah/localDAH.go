@@ -19,6 +19,8 @@ package localdah
 import (
 	"context"
 	"io"
+	"slices"
+	"strings"
 
 	"github.com/bsv-blockchain/teranode/errors"
 	"github.com/bsv-blockchain/teranode/pkg/fileformat"
@@ -44,6 +46,11 @@ type blobStore interface {
 	SetCurrentBlockHeight(height uint32)
 }
 
+// blobStoreLister is implemented by the underlying blob stores that can list the blobs they hold.
+type blobStoreLister interface {
+	List(ctx context.Context, listOpts options.ListOptions, opts ...options.FileOption) (*options.ListResult, error)
+}
+
 // LocalDAH implements Delete-At-Height functionality as a wrapper around blob stores.
 // It maintains DAH metadata in a separate store and coordinates automatic cleanup
 // of expired blobs when the blockchain reaches specified heights.
@@ -236,6 +243,55 @@ func (l *LocalDAH) Del(ctx context.Context, key []byte, fileType fileformat.File
 	return l.blobStore.Del(ctx, key, fileType, opts...)
 }
 
+// List lists the blobs of both the DAH store and the blob store, merged into a single listing ordered by name.
+// A blob that is in both stores, which can happen while it is moved between them, is listed once with the
+// entry of the DAH store.
+func (l *LocalDAH) List(ctx context.Context, listOpts options.ListOptions, opts ...options.FileOption) (*options.ListResult, error) {
+	dahLister, ok := l.dahStore.(blobStoreLister)
+	if !ok {
+		return nil, errors.NewStorageError("[localDAH] DAH store %T does not support listing", l.dahStore)
+	}
+
+	blobLister, ok := l.blobStore.(blobStoreLister)
+	if !ok {
+		return nil, errors.NewStorageError("[localDAH] blob store %T does not support listing", l.blobStore)
+	}
+
+	dahResult, err := dahLister.List(ctx, listOpts, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	blobResult, err := blobLister.List(ctx, listOpts, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]options.ListEntry, 0, len(dahResult.Entries)+len(blobResult.Entries))
+	entries = append(entries, dahResult.Entries...)
+
+	for _, entry := range blobResult.Entries {
+		if !slices.ContainsFunc(dahResult.Entries, func(dahEntry options.ListEntry) bool {
+			return dahEntry.Cursor() == entry.Cursor()
+		}) {
+			entries = append(entries, entry)
+		}
+	}
+
+	slices.SortFunc(entries, func(a, b options.ListEntry) int {
+		return strings.Compare(a.Cursor(), b.Cursor())
+	})
+
+	// both pages start after the cursor, the first entries up to the limit of the merged pages are therefore
+	// never beyond the last entry of a page that was cut off at the limit
+	result := options.NewListResult(entries, options.ListOptions{Limit: listOpts.Limit})
+	if result.NextCursor == "" && len(result.Entries) > 0 && (dahResult.NextCursor != "" || blobResult.NextCursor != "") {
+		result.NextCursor = result.Entries[len(result.Entries)-1].Cursor()
+	}
+
+	return result, nil
+}
+
 func (l *LocalDAH) SetCurrentBlockHeight(height uint32) {
 	l.dahStore.SetCurrentBlockHeight(height)
 	l.blobStore.SetCurrentBlockHeight(height)
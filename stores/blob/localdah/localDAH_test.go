@@ -227,3 +227,58 @@ func TestLocalDAH_Health(t *testing.T) {
 		assert.Equal(t, 200, status)
 	})
 }
+
+func TestLocalDAH_List(t *testing.T) {
+	ctx := context.Background()
+	store, dahStore, _ := setupTest(t)
+
+	keys := [][]byte{{0x01}, {0x02}, {0x03}, {0x04}}
+
+	// keys 1 and 3 in the DAH store, keys 2 and 4 in the blob store
+	for i, key := range keys {
+		var opts []options.FileOption
+		if i%2 == 0 {
+			opts = append(opts, options.WithDeleteAt(10))
+		}
+
+		require.NoError(t, store.Set(ctx, key, fileformat.FileTypeTesting, []byte("value"), opts...))
+	}
+
+	// key 2 is also in the DAH store while it is being moved
+	require.NoError(t, dahStore.Set(ctx, keys[1], fileformat.FileTypeTesting, []byte("value"), options.WithDeleteAt(20)))
+
+	result, err := store.List(ctx, options.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, result.Entries, 4)
+	assert.Empty(t, result.NextCursor)
+
+	for i, entry := range result.Entries {
+		assert.Equal(t, keys[i], entry.Key)
+	}
+
+	assert.Equal(t, uint32(10), result.Entries[0].DAH)
+	assert.Equal(t, uint32(20), result.Entries[1].DAH)
+	assert.Equal(t, uint32(0), result.Entries[3].DAH)
+
+	// page through the merged listing
+	var listed [][]byte
+
+	listOpts := options.ListOptions{Limit: 3}
+
+	for {
+		result, err = store.List(ctx, listOpts)
+		require.NoError(t, err)
+
+		for _, entry := range result.Entries {
+			listed = append(listed, entry.Key)
+		}
+
+		if result.NextCursor == "" {
+			break
+		}
+
+		listOpts.Cursor = result.NextCursor
+	}
+
+	assert.Equal(t, keys, listed)
+}
@@ -25,6 +25,7 @@ import (
 	"strings"
 
 	"github.com/bsv-blockchain/go-bt/v2"
+	"github.com/bsv-blockchain/teranode/errors"
 	"github.com/bsv-blockchain/teranode/pkg/fileformat"
 	"github.com/bsv-blockchain/teranode/stores/blob/options"
 	"github.com/bsv-blockchain/teranode/ulogger"
@@ -46,6 +47,11 @@ type blobStore interface {
 	SetCurrentBlockHeight(height uint32)
 }
 
+// blobStoreLister is implemented by blob storage backends that can list the blobs they hold.
+type blobStoreLister interface {
+	List(ctx context.Context, listOpts options.ListOptions, opts ...options.FileOption) (*options.ListResult, error)
+}
+
 // Logger is a debugging wrapper that logs all blob store operations.
 //
 // It implements the blobStore interface by wrapping an underlying store
@@ -197,6 +203,28 @@ func (s *Logger) Del(ctx context.Context, key []byte, fileType fileformat.FileTy
 	return err
 }
 
+func (s *Logger) List(ctx context.Context, listOpts options.ListOptions, opts ...options.FileOption) (*options.ListResult, error) {
+	var (
+		result *options.ListResult
+		err    error
+	)
+
+	if lister, ok := s.store.(blobStoreLister); ok {
+		result, err = lister.List(ctx, listOpts, opts...)
+	} else {
+		err = errors.NewStorageError("blob store %T does not support listing", s.store)
+	}
+
+	entries := 0
+	if result != nil {
+		entries = len(result.Entries)
+	}
+
+	s.logger.Debugf("[BlobStore][logger][List] prefix %s, fileType %s, cursor %s, entries %d, err %v : %s", listOpts.Prefix, listOpts.FileType, listOpts.Cursor, entries, err, caller())
+
+	return result, err
+}
+
 func (s *Logger) Close(ctx context.Context) error {
 	err := s.store.Close(ctx)
 	s.logger.Debugf("[BlobStore][logger][Close] err %v : %s", err, caller())
@@ -21,12 +21,15 @@ import (
 	"encoding/hex"
 	"io"
 	"net/http"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/bsv-blockchain/teranode/errors"
 	"github.com/bsv-blockchain/teranode/pkg/fileformat"
 	"github.com/bsv-blockchain/teranode/stores/blob/options"
+	"github.com/ordishs/go-utils"
 )
 
 // blobData holds the complete blob data (header + payload + footer) and its DAH
@@ -344,6 +347,58 @@ func (m *Memory) ListKeys() [][]byte {
 	return keys
 }
 
+// List returns a page of the blobs in the store, in ascending order of their name.
+// The memory store does not use sub directories, so the file options are ignored.
+//
+// Parameters:
+//   - ctx: Context for the operation (unused in memory implementation)
+//   - listOpts: The prefix, file type, cursor and limit of the listing
+//   - opts: Optional file options (unused in memory implementation)
+//
+// Returns:
+//   - *options.ListResult: The blobs in the page and the cursor of the next page
+//   - error: Any error that occurred (always nil for memory store)
+func (m *Memory) List(_ context.Context, listOpts options.ListOptions, _ ...options.FileOption) (*options.ListResult, error) {
+	m.mu.RLock()
+
+	m.countersMu.Lock()
+	m.Counters["list"]++
+	m.countersMu.Unlock()
+
+	entries := make([]options.ListEntry, 0, len(m.blobs))
+
+	for storeKey, bd := range m.blobs {
+		pos := strings.LastIndex(storeKey, "_")
+		if pos == -1 {
+			continue
+		}
+
+		entry := options.ListEntry{
+			Name:     storeKey[:pos],
+			FileType: fileformat.FileType(storeKey[pos+1:]),
+			DAH:      bd.dah,
+		}
+
+		// blobs stored without a custom filename are stored under the hex encoded key
+		if key := m.keys[storeKey]; entry.Name == hex.EncodeToString(key) {
+			entry.Key = key
+			entry.Name = utils.ReverseAndHexEncodeSlice(key)
+		}
+
+		if listOpts.Matches(entry.Name, entry.FileType) {
+			entries = append(entries, entry)
+		}
+	}
+
+	m.mu.RUnlock()
+
+	slices.SortFunc(entries, func(a, b options.ListEntry) int {
+		return strings.Compare(a.Cursor(), b.Cursor())
+	})
+
+	return options.NewListResult(entries, listOpts), nil
+}
+
 func (m *Memory) SetCurrentBlockHeight(height uint32) {
 	m.currentBlockHeight = height
 }
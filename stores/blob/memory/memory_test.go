@@ -5,6 +5,7 @@ import (
 	"context"
 	"io"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/bsv-blockchain/teranode/errors"
@@ -315,3 +316,73 @@ func TestMemory_Close(t *testing.T) {
 		t.Errorf("expected close counter to be 1, got %d", store.Counters["close"])
 	}
 }
+
+func TestMemory_List(t *testing.T) {
+	ctx := context.Background()
+	store := New()
+
+	keys := [][]byte{{0x01, 0xaa}, {0x02, 0xaa}, {0x03, 0xbb}}
+
+	for _, key := range keys {
+		if err := store.Set(ctx, key, fileformat.FileTypeSubtree, []byte("subtree")); err != nil {
+			t.Fatalf("unexpected error on Set: %v", err)
+		}
+	}
+
+	if err := store.Set(ctx, keys[0], fileformat.FileTypeSubtreeData, []byte("data"), options.WithDeleteAt(10)); err != nil {
+		t.Fatalf("unexpected error on Set: %v", err)
+	}
+
+	// all blobs, in the order of their reversed hex keys
+	result, err := store.List(ctx, options.ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error on List: %v", err)
+	}
+
+	names := make([]string, 0, len(result.Entries))
+	for _, entry := range result.Entries {
+		names = append(names, entry.Cursor())
+	}
+
+	expected := []string{"aa01.subtree", "aa01.subtreeData", "aa02.subtree", "bb03.subtree"}
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected entries %v, got %v", expected, names)
+	}
+
+	if !bytes.Equal(result.Entries[1].Key, keys[0]) || result.Entries[1].DAH != 10 {
+		t.Errorf("expected key %x with DAH 10, got %x with DAH %d", keys[0], result.Entries[1].Key, result.Entries[1].DAH)
+	}
+
+	if result.NextCursor != "" {
+		t.Errorf("expected no next cursor, got %s", result.NextCursor)
+	}
+
+	// prefix and file type filter
+	result, err = store.List(ctx, options.ListOptions{Prefix: "aa", FileType: fileformat.FileTypeSubtree})
+	if err != nil {
+		t.Fatalf("unexpected error on List: %v", err)
+	}
+
+	if len(result.Entries) != 2 || result.Entries[0].Name != "aa01" || result.Entries[1].Name != "aa02" {
+		t.Errorf("expected subtrees aa01 and aa02, got %v", result.Entries)
+	}
+
+	// pagination
+	result, err = store.List(ctx, options.ListOptions{Limit: 3})
+	if err != nil {
+		t.Fatalf("unexpected error on List: %v", err)
+	}
+
+	if len(result.Entries) != 3 || result.NextCursor != "aa02.subtree" {
+		t.Fatalf("expected 3 entries and next cursor aa02.subtree, got %d entries and %q", len(result.Entries), result.NextCursor)
+	}
+
+	result, err = store.List(ctx, options.ListOptions{Limit: 3, Cursor: result.NextCursor})
+	if err != nil {
+		t.Fatalf("unexpected error on List: %v", err)
+	}
+
+	if len(result.Entries) != 1 || result.Entries[0].Name != "bb03" || result.NextCursor != "" {
+		t.Errorf("expected last entry bb03 without next cursor, got %v and %q", result.Entries, result.NextCursor)
+	}
+}
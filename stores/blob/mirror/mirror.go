@@ -0,0 +1,533 @@
+// Package mirror provides a blob store that mirrors every blob to several underlying stores.
+//
+// The Mirror store writes every blob to all of its replicas and succeeds when a configurable write quorum of
+// them succeeded. Reads go to the first healthy replica, in the configured order, and fail over to the next
+// replica when a replica fails or does not have the blob. A copy that is missing from a replica earlier in the
+// order is repaired in the background from the replica the blob was read from.
+//
+// A replica that fails an operation is marked down for a while, during which reads skip it unless all replicas
+// are down. Health reports the health of every replica, and the mirror is healthy while at least the write
+// quorum of replicas is healthy.
+//
+// This allows running, for example, a file store and an S3 store side by side, so a node can keep serving
+// subtree data to its peers when the local disk fails.
+package mirror
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+	"github.com/bsv-blockchain/teranode/stores/blob/options"
+	"github.com/bsv-blockchain/teranode/ulogger"
+	"github.com/ordishs/go-utils"
+)
+
+const (
+	// downDuration is how long a replica that failed an operation is skipped by reads
+	downDuration = 30 * time.Second
+	// repairTimeout is the timeout of the repair of a missing copy
+	repairTimeout = time.Minute
+	// copyBufferSize is the size of the buffer used to stream a blob to the replicas
+	copyBufferSize = 64 * 1024
+)
+
+// blobStore defines the interface of the replicas, it mirrors the blob.Store interface
+type blobStore interface {
+	Health(ctx context.Context, checkLiveness bool) (int, string, error)
+	Exists(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (bool, error)
+	Get(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) ([]byte, error)
+	GetIoReader(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (io.ReadCloser, error)
+	Set(ctx context.Context, key []byte, fileType fileformat.FileType, value []byte, opts ...options.FileOption) error
+	SetFromReader(ctx context.Context, key []byte, fileType fileformat.FileType, value io.ReadCloser, opts ...options.FileOption) error
+	SetDAH(ctx context.Context, key []byte, fileType fileformat.FileType, newDAH uint32, opts ...options.FileOption) error
+	GetDAH(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (uint32, error)
+	Del(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) error
+	Close(ctx context.Context) error
+	SetCurrentBlockHeight(height uint32)
+}
+
+// blobStoreLister is implemented by replicas that can list the blobs they hold
+type blobStoreLister interface {
+	List(ctx context.Context, listOpts options.ListOptions, opts ...options.FileOption) (*options.ListResult, error)
+}
+
+// Replica is a store the blobs are mirrored to
+type Replica struct {
+	// Name identifies the replica in logs and health reports, e.g. the scheme and host of its URL
+	Name string
+	// Store is the underlying store
+	Store blobStore
+
+	// downUntil is the unix nano time until which the replica is skipped by reads
+	downUntil atomic.Int64
+}
+
+func (r *Replica) isDown() bool {
+	return time.Now().UnixNano() < r.downUntil.Load()
+}
+
+func (r *Replica) markDown() {
+	r.downUntil.Store(time.Now().Add(downDuration).UnixNano())
+}
+
+func (r *Replica) markUp() {
+	r.downUntil.Store(0)
+}
+
+// Mirror is a blob store that mirrors every blob to all of its replicas.
+type Mirror struct {
+	logger      ulogger.Logger
+	replicas    []*Replica
+	writeQuorum int
+}
+
+// New creates a new Mirror store over the replicas.
+//
+// Parameters:
+//   - logger: Logger instance for mirror operations
+//   - replicas: The replicas, in the order they are read from
+//   - writeQuorum: The number of replicas a write must succeed on, 0 for all replicas
+//
+// Returns:
+//   - *Mirror: The mirror store
+//   - error: Any error in the configuration
+func New(logger ulogger.Logger, replicas []*Replica, writeQuorum int) (*Mirror, error) {
+	if len(replicas) == 0 {
+		return nil, errors.NewConfigurationError("[Mirror] at least one replica is required")
+	}
+
+	if writeQuorum == 0 {
+		writeQuorum = len(replicas)
+	}
+
+	if writeQuorum < 0 || writeQuorum > len(replicas) {
+		return nil, errors.NewConfigurationError("[Mirror] write quorum %d must be between 1 and the number of replicas %d", writeQuorum, len(replicas))
+	}
+
+	return &Mirror{
+		logger:      logger,
+		replicas:    replicas,
+		writeQuorum: writeQuorum,
+	}, nil
+}
+
+// readOrder returns the replicas in the order they are read from, the replicas that are down last
+func (m *Mirror) readOrder() []*Replica {
+	order := make([]*Replica, 0, len(m.replicas))
+
+	var down []*Replica
+
+	for _, replica := range m.replicas {
+		if replica.isDown() {
+			down = append(down, replica)
+		} else {
+			order = append(order, replica)
+		}
+	}
+
+	return append(order, down...)
+}
+
+// writeAll runs the write on all replicas concurrently, it succeeds when the write quorum succeeded
+func (m *Mirror) writeAll(op string, key []byte, fn func(replica *Replica) error) error {
+	errs := make([]error, len(m.replicas))
+
+	var wg sync.WaitGroup
+
+	for i, replica := range m.replicas {
+		wg.Add(1)
+
+		go func(i int, replica *Replica) {
+			defer wg.Done()
+
+			errs[i] = fn(replica)
+		}(i, replica)
+	}
+
+	wg.Wait()
+
+	return m.quorumResult(op, key, errs)
+}
+
+// quorumResult returns the result of a write from the errors of the replicas. Blobs that already exist on a
+// replica count as written, unless they exist on all replicas.
+func (m *Mirror) quorumResult(op string, key []byte, errs []error) error {
+	succeeded := 0
+	exists := 0
+
+	var firstErr error
+
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+
+			m.replicas[i].markUp()
+		case errors.Is(err, errors.ErrBlobAlreadyExists):
+			exists++
+		default:
+			m.replicas[i].markDown()
+			m.logger.Warnf("[Mirror][%s] [%s] failed on replica %s: %v", op, utils.ReverseAndHexEncodeSlice(key), m.replicas[i].Name, err)
+
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	if exists == len(errs) {
+		return errors.NewBlobAlreadyExistsError("[Mirror][%s] [%s] blob already exists", op, utils.ReverseAndHexEncodeSlice(key))
+	}
+
+	if succeeded+exists < m.writeQuorum {
+		return errors.NewStorageError("[Mirror][%s] [%s] succeeded on %d of %d replicas, write quorum is %d", op, utils.ReverseAndHexEncodeSlice(key), succeeded+exists, len(errs), m.writeQuorum, firstErr)
+	}
+
+	return nil
+}
+
+// Health checks the health of all replicas. The mirror is healthy while the write quorum of replicas is
+// healthy, the message reports the health of every replica.
+//
+// Parameters:
+//   - ctx: Context for the health checks
+//   - checkLiveness: Whether to perform liveness checks on the replicas
+//
+// Returns:
+//   - int: http.StatusOK when the write quorum of replicas is healthy, http.StatusServiceUnavailable otherwise
+//   - string: The health of every replica
+//   - error: Any error of the replicas when the mirror is not healthy
+func (m *Mirror) Health(ctx context.Context, checkLiveness bool) (int, string, error) {
+	messages := make([]string, len(m.replicas))
+	errs := make([]error, len(m.replicas))
+
+	var wg sync.WaitGroup
+
+	for i, replica := range m.replicas {
+		wg.Add(1)
+
+		go func(i int, replica *Replica) {
+			defer wg.Done()
+
+			status, message, err := replica.Store.Health(ctx, checkLiveness)
+			if err == nil && status != http.StatusOK {
+				err = errors.NewServiceUnavailableError("status %d", status)
+			}
+
+			if err != nil {
+				replica.markDown()
+
+				errs[i] = err
+				messages[i] = fmt.Sprintf("%s: %d %s: %v", replica.Name, status, message, err)
+
+				return
+			}
+
+			replica.markUp()
+
+			messages[i] = fmt.Sprintf("%s: %d %s", replica.Name, status, message)
+		}(i, replica)
+	}
+
+	wg.Wait()
+
+	healthy := 0
+
+	var firstErr error
+
+	for _, err := range errs {
+		if err == nil {
+			healthy++
+		} else if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	message := fmt.Sprintf("Mirror Store: %d of %d replicas healthy, write quorum %d; %s", healthy, len(m.replicas), m.writeQuorum, strings.Join(messages, "; "))
+
+	if healthy < m.writeQuorum {
+		return http.StatusServiceUnavailable, message, firstErr
+	}
+
+	return http.StatusOK, message, nil
+}
+
+func (m *Mirror) Set(ctx context.Context, key []byte, fileType fileformat.FileType, value []byte, opts ...options.FileOption) error {
+	return m.writeAll("Set", key, func(replica *Replica) error {
+		return replica.Store.Set(ctx, key, fileType, value, opts...)
+	})
+}
+
+// SetFromReader streams the reader to all replicas at once. A replica that fails stops receiving the data,
+// while the others continue.
+func (m *Mirror) SetFromReader(ctx context.Context, key []byte, fileType fileformat.FileType, reader io.ReadCloser, opts ...options.FileOption) error {
+	defer reader.Close()
+
+	errs := make([]error, len(m.replicas))
+	writers := make([]*io.PipeWriter, len(m.replicas))
+
+	var wg sync.WaitGroup
+
+	for i, replica := range m.replicas {
+		pr, pw := io.Pipe()
+		writers[i] = pw
+
+		wg.Add(1)
+
+		go func(i int, replica *Replica) {
+			defer wg.Done()
+
+			errs[i] = replica.Store.SetFromReader(ctx, key, fileType, pr, opts...)
+
+			// stop the data being written to a replica that returned before reading it all
+			_ = pr.CloseWithError(io.ErrClosedPipe)
+		}(i, replica)
+	}
+
+	buf := make([]byte, copyBufferSize)
+
+	var readErr error
+
+	for {
+		n, err := reader.Read(buf)
+		if n > 0 {
+			for i, pw := range writers {
+				if pw == nil {
+					continue
+				}
+
+				if _, writeErr := pw.Write(buf[:n]); writeErr != nil {
+					writers[i] = nil
+				}
+			}
+		}
+
+		if err != nil {
+			if err != io.EOF {
+				readErr = err
+			}
+
+			break
+		}
+	}
+
+	for _, pw := range writers {
+		if pw != nil {
+			_ = pw.CloseWithError(readErr)
+		}
+	}
+
+	wg.Wait()
+
+	if readErr != nil {
+		return errors.NewStorageError("[Mirror][SetFromReader] [%s] failed to read data", utils.ReverseAndHexEncodeSlice(key), readErr)
+	}
+
+	return m.quorumResult("SetFromReader", key, errs)
+}
+
+func (m *Mirror) SetDAH(ctx context.Context, key []byte, fileType fileformat.FileType, newDAH uint32, opts ...options.FileOption) error {
+	return m.writeAll("SetDAH", key, func(replica *Replica) error {
+		return replica.Store.SetDAH(ctx, key, fileType, newDAH, opts...)
+	})
+}
+
+// Del deletes the blob from all replicas, it only succeeds when it succeeded on all replicas, so a deleted blob
+// is not repaired from a replica that still has it.
+func (m *Mirror) Del(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) error {
+	errs := make([]error, len(m.replicas))
+
+	var wg sync.WaitGroup
+
+	for i, replica := range m.replicas {
+		wg.Add(1)
+
+		go func(i int, replica *Replica) {
+			defer wg.Done()
+
+			errs[i] = replica.Store.Del(ctx, key, fileType, opts...)
+		}(i, replica)
+	}
+
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			m.replicas[i].markDown()
+			return errors.NewStorageError("[Mirror][Del] [%s] failed on replica %s", utils.ReverseAndHexEncodeSlice(key), m.replicas[i].Name, err)
+		}
+	}
+
+	return nil
+}
+
+// read runs the read on the replicas in read order until it succeeds. A replica that does not have the blob is
+// returned in missing, so the copy can be repaired, the other failing replicas are marked down.
+func read[T any](m *Mirror, op string, key []byte, fn func(replica *Replica) (T, error)) (result T, source *Replica, missing []*Replica, err error) {
+	var lastErr error
+
+	for _, replica := range m.readOrder() {
+		result, err = fn(replica)
+		if err == nil {
+			return result, replica, missing, nil
+		}
+
+		if errors.Is(err, errors.ErrNotFound) {
+			missing = append(missing, replica)
+		} else {
+			replica.markDown()
+			m.logger.Warnf("[Mirror][%s] [%s] failed on replica %s, failing over: %v", op, utils.ReverseAndHexEncodeSlice(key), replica.Name, err)
+		}
+
+		lastErr = err
+	}
+
+	if len(missing) > 0 {
+		return result, nil, nil, errors.ErrNotFound
+	}
+
+	return result, nil, nil, lastErr
+}
+
+// repair copies the blob from the source replica to the replicas that do not have it, in the background
+func (m *Mirror) repair(key []byte, fileType fileformat.FileType, source *Replica, missing []*Replica, opts []options.FileOption) {
+	if len(missing) == 0 {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), repairTimeout)
+		defer cancel()
+
+		dah, err := source.Store.GetDAH(ctx, key, fileType, opts...)
+		if err != nil {
+			m.logger.Warnf("[Mirror][repair] [%s] failed to get DAH from replica %s: %v", utils.ReverseAndHexEncodeSlice(key), source.Name, err)
+			return
+		}
+
+		for _, replica := range missing {
+			reader, err := source.Store.GetIoReader(ctx, key, fileType, opts...)
+			if err != nil {
+				m.logger.Warnf("[Mirror][repair] [%s] failed to read from replica %s: %v", utils.ReverseAndHexEncodeSlice(key), source.Name, err)
+				return
+			}
+
+			repairOpts := append(append(make([]options.FileOption, 0, len(opts)+1), opts...), options.WithDeleteAt(dah))
+
+			if err = replica.Store.SetFromReader(ctx, key, fileType, reader, repairOpts...); err != nil && !errors.Is(err, errors.ErrBlobAlreadyExists) {
+				m.logger.Warnf("[Mirror][repair] [%s] failed to repair copy on replica %s: %v", utils.ReverseAndHexEncodeSlice(key), replica.Name, err)
+				continue
+			}
+
+			m.logger.Infof("[Mirror][repair] [%s] repaired copy of %s on replica %s from %s", utils.ReverseAndHexEncodeSlice(key), fileType, replica.Name, source.Name)
+		}
+	}()
+}
+
+func (m *Mirror) Get(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) ([]byte, error) {
+	value, source, missing, err := read(m, "Get", key, func(replica *Replica) ([]byte, error) {
+		return replica.Store.Get(ctx, key, fileType, opts...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	m.repair(key, fileType, source, missing, opts)
+
+	return value, nil
+}
+
+func (m *Mirror) GetIoReader(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (io.ReadCloser, error) {
+	reader, source, missing, err := read(m, "GetIoReader", key, func(replica *Replica) (io.ReadCloser, error) {
+		return replica.Store.GetIoReader(ctx, key, fileType, opts...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	m.repair(key, fileType, source, missing, opts)
+
+	return reader, nil
+}
+
+// Exists returns whether the blob exists on any of the replicas.
+func (m *Mirror) Exists(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (bool, error) {
+	_, _, _, err := read(m, "Exists", key, func(replica *Replica) (bool, error) {
+		found, err := replica.Store.Exists(ctx, key, fileType, opts...)
+		if err == nil && !found {
+			err = errors.ErrNotFound
+		}
+
+		return found, err
+	})
+	if err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (m *Mirror) GetDAH(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (uint32, error) {
+	dah, _, _, err := read(m, "GetDAH", key, func(replica *Replica) (uint32, error) {
+		return replica.Store.GetDAH(ctx, key, fileType, opts...)
+	})
+
+	return dah, err
+}
+
+// List lists the blobs of the first healthy replica that supports listing.
+func (m *Mirror) List(ctx context.Context, listOpts options.ListOptions, opts ...options.FileOption) (*options.ListResult, error) {
+	var lastErr error
+
+	for _, replica := range m.readOrder() {
+		lister, ok := replica.Store.(blobStoreLister)
+		if !ok {
+			continue
+		}
+
+		result, err := lister.List(ctx, listOpts, opts...)
+		if err == nil {
+			return result, nil
+		}
+
+		replica.markDown()
+
+		lastErr = err
+	}
+
+	if lastErr != nil {
+		return nil, lastErr
+	}
+
+	return nil, errors.NewStorageError("[Mirror] none of the replicas supports listing")
+}
+
+func (m *Mirror) Close(ctx context.Context) error {
+	var firstErr error
+
+	for _, replica := range m.replicas {
+		if err := replica.Store.Close(ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+func (m *Mirror) SetCurrentBlockHeight(height uint32) {
+	for _, replica := range m.replicas {
+		replica.Store.SetCurrentBlockHeight(height)
+	}
+}
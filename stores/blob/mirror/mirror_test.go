@@ -0,0 +1,270 @@
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+	"github.com/bsv-blockchain/teranode/stores/blob/memory"
+	"github.com/bsv-blockchain/teranode/stores/blob/options"
+	"github.com/bsv-blockchain/teranode/ulogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingStore is a memory store that fails all operations while failing is set
+type failingStore struct {
+	*memory.Memory
+	failing atomic.Bool
+}
+
+func newFailingStore() *failingStore {
+	return &failingStore{Memory: memory.New()}
+}
+
+func (f *failingStore) err() error {
+	if f.failing.Load() {
+		return errors.NewStorageError("replica unavailable")
+	}
+
+	return nil
+}
+
+func (f *failingStore) Health(ctx context.Context, checkLiveness bool) (int, string, error) {
+	if err := f.err(); err != nil {
+		return http.StatusServiceUnavailable, "failing", err
+	}
+
+	return f.Memory.Health(ctx, checkLiveness)
+}
+
+func (f *failingStore) Get(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) ([]byte, error) {
+	if err := f.err(); err != nil {
+		return nil, err
+	}
+
+	return f.Memory.Get(ctx, key, fileType, opts...)
+}
+
+func (f *failingStore) GetIoReader(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (io.ReadCloser, error) {
+	if err := f.err(); err != nil {
+		return nil, err
+	}
+
+	return f.Memory.GetIoReader(ctx, key, fileType, opts...)
+}
+
+func (f *failingStore) Set(ctx context.Context, key []byte, fileType fileformat.FileType, value []byte, opts ...options.FileOption) error {
+	if err := f.err(); err != nil {
+		return err
+	}
+
+	return f.Memory.Set(ctx, key, fileType, value, opts...)
+}
+
+func (f *failingStore) SetFromReader(ctx context.Context, key []byte, fileType fileformat.FileType, reader io.ReadCloser, opts ...options.FileOption) error {
+	if err := f.err(); err != nil {
+		_ = reader.Close()
+		return err
+	}
+
+	return f.Memory.SetFromReader(ctx, key, fileType, reader, opts...)
+}
+
+func newMirror(t *testing.T, writeQuorum int, stores ...blobStore) *Mirror {
+	replicas := make([]*Replica, 0, len(stores))
+	for i, store := range stores {
+		replicas = append(replicas, &Replica{Name: string(rune('a' + i)), Store: store})
+	}
+
+	m, err := New(ulogger.TestLogger{}, replicas, writeQuorum)
+	require.NoError(t, err)
+
+	return m
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(ulogger.TestLogger{}, nil, 0)
+	require.Error(t, err)
+
+	_, err = New(ulogger.TestLogger{}, []*Replica{{Name: "a", Store: memory.New()}}, 2)
+	require.Error(t, err)
+
+	m, err := New(ulogger.TestLogger{}, []*Replica{{Name: "a", Store: memory.New()}, {Name: "b", Store: memory.New()}}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, m.writeQuorum)
+}
+
+func TestMirror_SetWritesAllReplicas(t *testing.T) {
+	ctx := context.Background()
+	a, b := memory.New(), memory.New()
+	m := newMirror(t, 0, a, b)
+
+	require.NoError(t, m.Set(ctx, []byte("set"), fileformat.FileTypeTx, []byte("data"), options.WithDeleteAt(10)))
+	require.NoError(t, m.SetFromReader(ctx, []byte("reader"), fileformat.FileTypeTx, io.NopCloser(bytes.NewReader([]byte("streamed")))))
+
+	for _, store := range []*memory.Memory{a, b} {
+		value, err := store.Get(ctx, []byte("set"), fileformat.FileTypeTx)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("data"), value)
+
+		dah, err := store.GetDAH(ctx, []byte("set"), fileformat.FileTypeTx)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(10), dah)
+
+		value, err = store.Get(ctx, []byte("reader"), fileformat.FileTypeTx)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("streamed"), value)
+	}
+
+	// a blob that exists on all replicas
+	err := m.Set(ctx, []byte("set"), fileformat.FileTypeTx, []byte("data"))
+	require.ErrorIs(t, err, errors.ErrBlobAlreadyExists)
+
+	require.NoError(t, m.SetDAH(ctx, []byte("set"), fileformat.FileTypeTx, 20))
+
+	dah, err := b.GetDAH(ctx, []byte("set"), fileformat.FileTypeTx)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(20), dah)
+
+	require.NoError(t, m.Del(ctx, []byte("set"), fileformat.FileTypeTx))
+
+	exists, err := m.Exists(ctx, []byte("set"), fileformat.FileTypeTx)
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestMirror_WriteQuorum(t *testing.T) {
+	ctx := context.Background()
+	healthy, failing := memory.New(), newFailingStore()
+	failing.failing.Store(true)
+
+	m := newMirror(t, 1, failing, healthy)
+
+	require.NoError(t, m.Set(ctx, []byte("key"), fileformat.FileTypeTx, []byte("data")))
+	require.NoError(t, m.SetFromReader(ctx, []byte("reader"), fileformat.FileTypeTx, io.NopCloser(bytes.NewReader([]byte("streamed")))))
+
+	value, err := healthy.Get(ctx, []byte("reader"), fileformat.FileTypeTx)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("streamed"), value)
+
+	m = newMirror(t, 0, failing, healthy)
+
+	err = m.Set(ctx, []byte("other"), fileformat.FileTypeTx, []byte("data"))
+	require.Error(t, err)
+	assert.False(t, errors.Is(err, errors.ErrBlobAlreadyExists))
+}
+
+func TestMirror_ReadFailover(t *testing.T) {
+	ctx := context.Background()
+	first, second := newFailingStore(), memory.New()
+	m := newMirror(t, 1, first, second)
+
+	require.NoError(t, m.Set(ctx, []byte("key"), fileformat.FileTypeTx, []byte("data")))
+
+	first.failing.Store(true)
+
+	value, err := m.Get(ctx, []byte("key"), fileformat.FileTypeTx)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data"), value)
+
+	// the failing replica is marked down and read last
+	assert.True(t, m.replicas[0].isDown())
+	assert.Equal(t, m.replicas[1], m.readOrder()[0])
+
+	reader, err := m.GetIoReader(ctx, []byte("key"), fileformat.FileTypeTx)
+	require.NoError(t, err)
+
+	value, err = io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data"), value)
+
+	exists, err := m.Exists(ctx, []byte("key"), fileformat.FileTypeTx)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	// all replicas failing
+	m = newMirror(t, 1, first)
+
+	_, err = m.Get(ctx, []byte("key"), fileformat.FileTypeTx)
+	require.Error(t, err)
+	assert.False(t, errors.Is(err, errors.ErrNotFound))
+}
+
+func TestMirror_RepairOnRead(t *testing.T) {
+	ctx := context.Background()
+	first, second := memory.New(), memory.New()
+
+	require.NoError(t, second.Set(ctx, []byte("key"), fileformat.FileTypeTx, []byte("data"), options.WithDeleteAt(42)))
+
+	m := newMirror(t, 0, first, second)
+
+	value, err := m.Get(ctx, []byte("key"), fileformat.FileTypeTx)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data"), value)
+
+	require.Eventually(t, func() bool {
+		exists, err := first.Exists(ctx, []byte("key"), fileformat.FileTypeTx)
+		return err == nil && exists
+	}, time.Second, 10*time.Millisecond)
+
+	value, err = first.Get(ctx, []byte("key"), fileformat.FileTypeTx)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data"), value)
+
+	dah, err := first.GetDAH(ctx, []byte("key"), fileformat.FileTypeTx)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(42), dah)
+
+	// a blob missing on all replicas
+	_, err = m.Get(ctx, []byte("missing"), fileformat.FileTypeTx)
+	require.ErrorIs(t, err, errors.ErrNotFound)
+}
+
+func TestMirror_Health(t *testing.T) {
+	ctx := context.Background()
+	healthy, failing := memory.New(), newFailingStore()
+	failing.failing.Store(true)
+
+	m := newMirror(t, 1, healthy, failing)
+
+	status, message, err := m.Health(ctx, false)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, message, "1 of 2 replicas healthy")
+	assert.Contains(t, message, "b: 503 failing")
+
+	m = newMirror(t, 2, healthy, failing)
+
+	status, _, err = m.Health(ctx, false)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+}
+
+func TestMirror_List(t *testing.T) {
+	ctx := context.Background()
+	failing, second := newFailingStore(), memory.New()
+	m := newMirror(t, 1, failing, second)
+
+	require.NoError(t, m.Set(ctx, []byte("key"), fileformat.FileTypeTx, []byte("data")))
+
+	result, err := m.List(ctx, options.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, result.Entries, 1)
+
+	failing.failing.Store(true)
+	_, _ = m.Get(ctx, []byte("key"), fileformat.FileTypeTx)
+
+	// the down replica is listed last
+	require.NoError(t, second.Set(ctx, []byte("only-second"), fileformat.FileTypeTx, []byte("data")))
+
+	result, err = m.List(ctx, options.ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, result.Entries, 2)
+}
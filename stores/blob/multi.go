@@ -0,0 +1,73 @@
+package blob
+
+import (
+	"context"
+	"io"
+
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+	"github.com/bsv-blockchain/teranode/stores/blob/options"
+)
+
+// MultiGetter is implemented by stores that can read many blobs in a single request, like the http store
+// reading them with one POST /multi request. See GetMulti for using it on any Store.
+type MultiGetter interface {
+	// GetMulti reads many blobs of a file type.
+	// Parameters:
+	//   - ctx: The context for the operation
+	//   - keys: The keys identifying the blobs
+	//   - fileType: The type of the files
+	//   - fn: Function called for every key in the order of the keys, with a reader of the blob that is only
+	//     valid until fn returns, or with the error reading the blob, errors.ErrNotFound when it does not exist
+	//   - opts: Optional file options
+	// Returns:
+	//   - error: Any error that occurred during the request, or returned by fn
+	GetMulti(ctx context.Context, keys [][]byte, fileType fileformat.FileType, fn func(index int, reader io.Reader, err error) error, opts ...options.FileOption) error
+}
+
+// GetMulti reads many blobs of a file type, in a single request when the store is a MultiGetter, or blob by
+// blob otherwise. Errors reading a single blob are passed to fn, reading stops at the first error fn returns.
+//
+// Parameters:
+//   - ctx: The context for the operation
+//   - store: The store to read the blobs from
+//   - keys: The keys identifying the blobs
+//   - fileType: The type of the files
+//   - fn: Function called for every key in the order of the keys, with a reader of the blob that is only
+//     valid until fn returns, or with the error reading the blob, errors.ErrNotFound when it does not exist
+//   - opts: Optional file options
+//
+// Returns:
+//   - error: Any error that occurred during the request, or returned by fn
+func GetMulti(ctx context.Context, store Store, keys [][]byte, fileType fileformat.FileType, fn func(index int, reader io.Reader, err error) error, opts ...options.FileOption) error {
+	if multiGetter, ok := store.(MultiGetter); ok {
+		return multiGetter.GetMulti(ctx, keys, fileType, fn, opts...)
+	}
+
+	for i, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := getOne(ctx, store, i, key, fileType, fn, opts...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getOne reads a single blob for GetMulti, closing its reader when fn returns
+func getOne(ctx context.Context, store Store, index int, key []byte, fileType fileformat.FileType, fn func(index int, reader io.Reader, err error) error, opts ...options.FileOption) error {
+	rc, err := store.GetIoReader(ctx, key, fileType, opts...)
+	if err != nil {
+		if !errors.Is(err, errors.ErrNotFound) {
+			err = errors.NewStorageError("failed to read blob %d", index, err)
+		}
+
+		return fn(index, nil, err)
+	}
+	defer rc.Close()
+
+	return fn(index, rc, nil)
+}
@@ -0,0 +1,122 @@
+package options
+
+import (
+	"strings"
+
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+	"github.com/ordishs/go-utils"
+)
+
+// DefaultListLimit is the number of entries returned in a page when ListOptions.Limit is not set
+const DefaultListLimit = 1000
+
+// ListOptions configures the listing of the blobs in a store.
+//
+// Blobs are listed in ascending order of their name, the reversed hex encoded key as used in the file
+// names of the file store, followed by the file type extension. A listing is paginated: every page
+// returns a cursor that is passed in the options of the next call to continue after the last entry.
+type ListOptions struct {
+	// Prefix only lists blobs whose name starts with the prefix, the prefix is matched against the
+	// reversed hex encoded key, the way hashes are displayed
+	Prefix string
+	// FileType only lists blobs of the file type, FileTypeUnknown lists blobs of all file types
+	FileType fileformat.FileType
+	// Cursor continues the listing after the entry the cursor was returned for, empty to start at the beginning
+	Cursor string
+	// Limit is the maximum number of entries returned, DefaultListLimit when 0
+	Limit int
+}
+
+// ListEntry is a blob returned by a listing
+type ListEntry struct {
+	// Key is the key the blob was stored under, nil when the blob was stored with a custom filename
+	Key []byte `json:"key"`
+	// Name is the name of the blob in the store, the reversed hex encoded key or the custom filename
+	Name string `json:"name"`
+	// FileType is the file type of the blob
+	FileType fileformat.FileType `json:"fileType"`
+	// DAH is the Delete-At-Height of the blob, 0 when the blob does not expire or the store does not track DAHs
+	DAH uint32 `json:"dah"`
+}
+
+// ListResult is a page of a listing
+type ListResult struct {
+	// Entries are the blobs in the page
+	Entries []ListEntry `json:"entries"`
+	// NextCursor is the cursor to pass to get the next page, empty when this is the last page
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// Cursor returns the cursor that continues a listing after the entry
+func (e ListEntry) Cursor() string {
+	return e.Name + "." + e.FileType.String()
+}
+
+// GetLimit returns the maximum number of entries in a page
+func (o ListOptions) GetLimit() int {
+	if o.Limit <= 0 {
+		return DefaultListLimit
+	}
+
+	return o.Limit
+}
+
+// Matches returns whether a blob with the name and file type is part of the listing, ignoring the cursor
+func (o ListOptions) Matches(name string, fileType fileformat.FileType) bool {
+	if o.FileType != fileformat.FileTypeUnknown && o.FileType != fileType {
+		return false
+	}
+
+	return strings.HasPrefix(name, o.Prefix)
+}
+
+// ParseListEntry parses the name of a blob as stored, "{name}.{fileType}", into a list entry. It returns false
+// when the extension is not a known file type, which is the case for the checksum and DAH files stored next
+// to the blobs.
+func ParseListEntry(storedName string) (ListEntry, bool) {
+	pos := strings.LastIndex(storedName, ".")
+	if pos <= 0 {
+		return ListEntry{}, false
+	}
+
+	fileType, err := fileformat.FileTypeFromExtension(storedName[pos+1:])
+	if err != nil {
+		return ListEntry{}, false
+	}
+
+	entry := ListEntry{
+		Name:     storedName[:pos],
+		FileType: fileType,
+	}
+
+	if key, err := utils.DecodeAndReverseHexString(entry.Name); err == nil {
+		entry.Key = key
+	}
+
+	return entry, true
+}
+
+// NewListResult returns a page of a listing from the entries, which must be sorted by cursor and already
+// filtered by the list options, applying the cursor and limit of the options
+func NewListResult(entries []ListEntry, opts ListOptions) *ListResult {
+	limit := opts.GetLimit()
+
+	result := &ListResult{
+		Entries: make([]ListEntry, 0, min(limit, len(entries))),
+	}
+
+	for _, entry := range entries {
+		if opts.Cursor != "" && entry.Cursor() <= opts.Cursor {
+			continue
+		}
+
+		if len(result.Entries) == limit {
+			result.NextCursor = result.Entries[limit-1].Cursor()
+			break
+		}
+
+		result.Entries = append(result.Entries, entry)
+	}
+
+	return result
+}
@@ -266,6 +266,13 @@ func QueryToFileOptions(query url.Values) []FileOption {
 		}
 	}
 
+	// the DAH as sent by FileOptionsToQuery
+	if dahStr := query.Get("dah"); dahStr != "" {
+		if dah, err := strconv.ParseUint(dahStr, 10, 32); err == nil {
+			opts = append(opts, WithDeleteAt(uint32(dah)))
+		}
+	}
+
 	if filename := query.Get("filename"); filename != "" {
 		opts = append(opts, WithFilename(filename))
 	}
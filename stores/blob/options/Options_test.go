@@ -245,6 +245,12 @@ func TestQueryToFileOptions(t *testing.T) {
 		assert.True(t, options.AllowOverwrite)
 	})
 
+	t.Run("DAH of FileOptionsToQuery", func(t *testing.T) {
+		opts := QueryToFileOptions(FileOptionsToQuery(fileformat.FileTypeTesting, WithDeleteAt(1000)))
+		options := NewFileOptions(opts...)
+		assert.Equal(t, uint32(1000), options.DAH)
+	})
+
 	t.Run("Invalid DAH", func(t *testing.T) {
 		query := url.Values{
 			"dah": []string{"invalid"},
@@ -0,0 +1,18 @@
+package quota
+
+import (
+	"github.com/bsv-blockchain/teranode/errors"
+	"golang.org/x/sys/unix"
+)
+
+// diskSpace returns the free and total bytes of the file system the directory is on, the free bytes are the
+// bytes available to unprivileged users. It is a variable so tests can simulate a full disk.
+var diskSpace = func(dir string) (free uint64, total uint64, err error) {
+	var stat unix.Statfs_t
+
+	if err = unix.Statfs(dir, &stat); err != nil {
+		return 0, 0, errors.NewStorageError("[Quota] failed to get the disk space of %s", dir, err)
+	}
+
+	return stat.Bavail * uint64(stat.Bsize), stat.Blocks * uint64(stat.Bsize), nil //nolint:gosec
+}
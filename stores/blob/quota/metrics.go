@@ -0,0 +1,71 @@
+package quota
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	prometheusQuotaUsage    *prometheus.GaugeVec
+	prometheusQuotaRejected *prometheus.CounterVec
+	prometheusDiskFree      *prometheus.GaugeVec
+	prometheusDiskPressure  *prometheus.GaugeVec
+	prometheusEarlyCleanup  *prometheus.CounterVec
+
+	// only init the metrics once
+	prometheusMetricsInitOnce sync.Once
+)
+
+func initPrometheusMetrics() {
+	prometheusMetricsInitOnce.Do(_initPrometheusMetrics)
+}
+
+func _initPrometheusMetrics() {
+	prometheusQuotaUsage = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "teranode",
+			Subsystem: "blob_quota",
+			Name:      "usage_bytes",
+			Help:      "Number of bytes of the blobs in a store counted against its quotas, by file type",
+		},
+		[]string{"dir", "file_type"},
+	)
+	prometheusQuotaRejected = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "teranode",
+			Subsystem: "blob_quota",
+			Name:      "rejected_writes",
+			Help:      "Number of writes rejected because a quota was exceeded or the disk is below its critical watermark",
+		},
+		[]string{"dir", "file_type", "reason"},
+	)
+	prometheusDiskFree = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "teranode",
+			Subsystem: "blob_quota",
+			Name:      "disk_free_percent",
+			Help:      "Percentage of the disk of a store that is free",
+		},
+		[]string{"dir"},
+	)
+	prometheusDiskPressure = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "teranode",
+			Subsystem: "blob_quota",
+			Name:      "disk_pressure",
+			Help:      "Disk pressure of a store: 0 none, 1 below the low watermark, 2 below the critical watermark",
+		},
+		[]string{"dir"},
+	)
+	prometheusEarlyCleanup = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "teranode",
+			Subsystem: "blob_quota",
+			Name:      "early_cleanup_blobs",
+			Help:      "Number of blobs removed ahead of their DAH to free disk space",
+		},
+		[]string{"dir"},
+	)
+}
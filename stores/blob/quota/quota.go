@@ -0,0 +1,536 @@
+// Package quota provides a blob store wrapper that enforces storage quotas and reacts to disk pressure.
+//
+// The Quota wrapper limits the number of bytes of the blobs in a store, in total and per file type, and monitors
+// the free space of the disk the store is on against two watermarks. Below the low watermark the store reports
+// itself as degraded in Health() and removes the blobs that expire within a number of blocks ahead of their DAH.
+// Below the critical watermark writes are rejected with a storage unavailable error before the disk is actually
+// full, and CheckPressure reports the pressure, so the propagation and validator services can reject new
+// transactions gracefully instead of failing deep inside block assembly and subtree validation.
+//
+// The bytes used by the store are counted by scanning the directory of the store, so the quotas only apply to
+// stores on local disk, like the file store. The scan is repeated periodically, writes in between are added to
+// the count, while deletes are only seen at the next scan, so the count errs on the side of the quota. Quotas are
+// enforced once the first scan has completed.
+//
+// The usage and disk pressure are exposed through the teranode_blob_quota_* Prometheus metrics.
+package quota
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+	"github.com/bsv-blockchain/teranode/stores/blob/options"
+	"github.com/bsv-blockchain/teranode/ulogger"
+)
+
+const (
+	// DefaultCheckInterval is the default interval the free space of the disk is checked at
+	DefaultCheckInterval = 10 * time.Second
+	// DefaultScanInterval is the default interval the directory of the store is scanned at to count its usage
+	DefaultScanInterval = 5 * time.Minute
+)
+
+// Pressure is the disk pressure of a store
+type Pressure int32
+
+const (
+	// PressureNone means the free space of the disk is above the low watermark
+	PressureNone Pressure = iota
+	// PressureLow means the free space of the disk is below the low watermark, the store is degraded
+	PressureLow
+	// PressureCritical means the free space of the disk is below the critical watermark, writes are rejected
+	PressureCritical
+)
+
+func (p Pressure) String() string {
+	switch p {
+	case PressureLow:
+		return "low"
+	case PressureCritical:
+		return "critical"
+	default:
+		return "none"
+	}
+}
+
+// blobStore defines the interface of the wrapped blob store, it mirrors the blob.Store interface
+type blobStore interface {
+	Health(ctx context.Context, checkLiveness bool) (int, string, error)
+	Exists(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (bool, error)
+	Get(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) ([]byte, error)
+	GetIoReader(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (io.ReadCloser, error)
+	Set(ctx context.Context, key []byte, fileType fileformat.FileType, value []byte, opts ...options.FileOption) error
+	SetFromReader(ctx context.Context, key []byte, fileType fileformat.FileType, value io.ReadCloser, opts ...options.FileOption) error
+	SetDAH(ctx context.Context, key []byte, fileType fileformat.FileType, newDAH uint32, opts ...options.FileOption) error
+	GetDAH(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (uint32, error)
+	Del(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) error
+	Close(ctx context.Context) error
+	SetCurrentBlockHeight(height uint32)
+}
+
+// blobStoreLister is implemented by wrapped blob stores that can list the blobs they hold
+type blobStoreLister interface {
+	List(ctx context.Context, listOpts options.ListOptions, opts ...options.FileOption) (*options.ListResult, error)
+}
+
+// earlyCleaner is implemented by wrapped blob stores that can remove blobs ahead of their DAH, like the file store
+type earlyCleaner interface {
+	CleanupAhead(blocks uint32) int
+}
+
+// Options configures the quotas and watermarks of a Quota wrapper
+type Options struct {
+	// Dir is the directory of the store, it is scanned to count the usage and its disk is monitored
+	Dir string
+	// MaxBytes is the maximum number of bytes of all blobs in the store, 0 for no quota
+	MaxBytes int64
+	// FileTypeMaxBytes is the maximum number of bytes of the blobs of a file type in the store
+	FileTypeMaxBytes map[fileformat.FileType]int64
+	// LowWatermark is the percentage of free disk space below which the store is degraded, 0 to disable
+	LowWatermark float64
+	// CriticalWatermark is the percentage of free disk space below which writes are rejected, 0 to disable
+	CriticalWatermark float64
+	// EarlyCleanupBlocks is the number of blocks ahead of their DAH blobs are removed below the low watermark,
+	// 0 to not remove blobs early
+	EarlyCleanupBlocks uint32
+	// CheckInterval is the interval the free disk space is checked at, DefaultCheckInterval when 0
+	CheckInterval time.Duration
+	// ScanInterval is the interval the usage of the store is counted at, DefaultScanInterval when 0
+	ScanInterval time.Duration
+}
+
+// Quota is a blob store wrapper that enforces storage quotas and reacts to disk pressure.
+type Quota struct {
+	logger ulogger.Logger
+	store  blobStore
+	opts   Options
+
+	pressure    atomic.Int32
+	freePercent atomic.Uint64 // in hundredths of a percent
+
+	usageMu sync.Mutex
+	scanned bool
+	usage   map[fileformat.FileType]int64
+	total   int64
+
+	cancel   context.CancelFunc
+	done     chan struct{}
+	closeErr error
+	once     sync.Once
+}
+
+var (
+	// registry holds the quota stores of the process, their pressure is reported by CheckPressure
+	registryMu sync.RWMutex
+	registry   = make(map[*Quota]struct{})
+)
+
+// New creates a new Quota wrapper around the blob store and starts monitoring the disk and counting the usage.
+//
+// Parameters:
+//   - logger: Logger instance for quota operations
+//   - store: The blob store to wrap, a store on local disk like the file store
+//   - opts: The quotas and watermarks
+//
+// Returns:
+//   - *Quota: The quota wrapper
+//   - error: Any error in the configuration
+func New(logger ulogger.Logger, store blobStore, opts Options) (*Quota, error) {
+	if store == nil {
+		return nil, errors.NewConfigurationError("[Quota] store is required")
+	}
+
+	if opts.Dir == "" {
+		return nil, errors.NewConfigurationError("[Quota] directory of the store is required")
+	}
+
+	if opts.MaxBytes < 0 {
+		return nil, errors.NewConfigurationError("[Quota] quota must not be negative")
+	}
+
+	for fileType, maxBytes := range opts.FileTypeMaxBytes {
+		if maxBytes <= 0 {
+			return nil, errors.NewConfigurationError("[Quota] quota of file type %s must be positive", fileType)
+		}
+	}
+
+	if opts.LowWatermark < 0 || opts.LowWatermark >= 100 || opts.CriticalWatermark < 0 || opts.CriticalWatermark >= 100 {
+		return nil, errors.NewConfigurationError("[Quota] watermarks must be percentages between 0 and 100")
+	}
+
+	if opts.LowWatermark > 0 && opts.CriticalWatermark > opts.LowWatermark {
+		return nil, errors.NewConfigurationError("[Quota] critical watermark %.1f%% must not be above the low watermark %.1f%%", opts.CriticalWatermark, opts.LowWatermark)
+	}
+
+	if opts.CheckInterval <= 0 {
+		opts.CheckInterval = DefaultCheckInterval
+	}
+
+	if opts.ScanInterval <= 0 {
+		opts.ScanInterval = DefaultScanInterval
+	}
+
+	initPrometheusMetrics()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	q := &Quota{
+		logger: logger,
+		store:  store,
+		opts:   opts,
+		usage:  make(map[fileformat.FileType]int64),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	// the pressure is known before the first write
+	q.checkDisk()
+
+	go q.monitor(ctx)
+
+	registryMu.Lock()
+	registry[q] = struct{}{}
+	registryMu.Unlock()
+
+	return q, nil
+}
+
+// CheckPressure returns a storage unavailable error when the disk of a quota store created in this process is
+// below its critical watermark, services use it to reject new work before the disk is full.
+//
+// Returns:
+//   - error: nil when no store is under critical disk pressure
+func CheckPressure() error {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	for q := range registry {
+		if err := q.CheckPressure(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CheckPressure returns a storage unavailable error when the disk of the store is below its critical watermark.
+func (q *Quota) CheckPressure() error {
+	if q.Pressure() == PressureCritical {
+		return errors.NewStorageUnavailableError("[Quota] free disk space of %s is %.2f%%, below the critical watermark of %.2f%%", q.opts.Dir, q.FreePercent(), q.opts.CriticalWatermark)
+	}
+
+	return nil
+}
+
+// Pressure returns the disk pressure of the store as of the last check
+func (q *Quota) Pressure() Pressure {
+	return Pressure(q.pressure.Load())
+}
+
+// FreePercent returns the percentage of the disk of the store that was free at the last check
+func (q *Quota) FreePercent() float64 {
+	return float64(q.freePercent.Load()) / 100
+}
+
+// Usage returns the number of bytes counted against the quotas, in total and of the file type
+func (q *Quota) Usage(fileType fileformat.FileType) (total int64, ofFileType int64) {
+	q.usageMu.Lock()
+	defer q.usageMu.Unlock()
+
+	return q.total, q.usage[fileType]
+}
+
+// monitor checks the disk and scans the usage at their intervals until the wrapper is closed
+func (q *Quota) monitor(ctx context.Context) {
+	defer close(q.done)
+
+	checkTicker := time.NewTicker(q.opts.CheckInterval)
+	defer checkTicker.Stop()
+
+	scanTicker := time.NewTicker(q.opts.ScanInterval)
+	defer scanTicker.Stop()
+
+	if q.hasQuotas() {
+		q.scan(ctx)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-checkTicker.C:
+			q.checkDisk()
+		case <-scanTicker.C:
+			if q.hasQuotas() {
+				q.scan(ctx)
+			}
+		}
+	}
+}
+
+// hasQuotas returns whether a quota is configured, the usage is only counted when it is
+func (q *Quota) hasQuotas() bool {
+	return q.opts.MaxBytes > 0 || len(q.opts.FileTypeMaxBytes) > 0
+}
+
+// checkDisk updates the disk pressure from the free space of the disk, and removes blobs ahead of their DAH when
+// the disk is below the low watermark
+func (q *Quota) checkDisk() {
+	if q.opts.LowWatermark == 0 && q.opts.CriticalWatermark == 0 {
+		return
+	}
+
+	free, total, err := diskSpace(q.opts.Dir)
+	if err != nil || total == 0 {
+		q.logger.Warnf("[Quota] failed to check the disk space of %s: %v", q.opts.Dir, err)
+		return
+	}
+
+	freePercent := float64(free) / float64(total) * 100
+
+	pressure := PressureNone
+
+	switch {
+	case q.opts.CriticalWatermark > 0 && freePercent < q.opts.CriticalWatermark:
+		pressure = PressureCritical
+	case q.opts.LowWatermark > 0 && freePercent < q.opts.LowWatermark:
+		pressure = PressureLow
+	}
+
+	q.freePercent.Store(uint64(freePercent * 100))
+
+	if previous := Pressure(q.pressure.Swap(int32(pressure))); previous != pressure { //nolint:gosec
+		q.logger.Warnf("[Quota] disk pressure of %s changed from %s to %s, %.2f%% free", q.opts.Dir, previous, pressure, freePercent)
+	}
+
+	prometheusDiskFree.WithLabelValues(q.opts.Dir).Set(freePercent)
+	prometheusDiskPressure.WithLabelValues(q.opts.Dir).Set(float64(pressure))
+
+	if pressure == PressureNone || q.opts.EarlyCleanupBlocks == 0 {
+		return
+	}
+
+	if cleaner, ok := q.store.(earlyCleaner); ok {
+		if removed := cleaner.CleanupAhead(q.opts.EarlyCleanupBlocks); removed > 0 {
+			q.logger.Infof("[Quota] removed %d blobs of %s expiring within %d blocks to free disk space", removed, q.opts.Dir, q.opts.EarlyCleanupBlocks)
+			prometheusEarlyCleanup.WithLabelValues(q.opts.Dir).Add(float64(removed))
+		}
+	}
+}
+
+// scan counts the bytes of the blob files in the directory of the store by file type. Files that are not blobs,
+// like the DAH and checksum files, are not counted.
+func (q *Quota) scan(ctx context.Context) {
+	usage := make(map[fileformat.FileType]int64)
+
+	var total int64
+
+	err := filepath.WalkDir(q.opts.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// files removed during the scan are skipped
+			return nil
+		}
+
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		fileType, err := fileformat.FileTypeFromExtension(strings.TrimPrefix(filepath.Ext(d.Name()), "."))
+		if err != nil || fileType == fileformat.FileTypeUnknown {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+
+		usage[fileType] += info.Size()
+		total += info.Size()
+
+		return nil
+	})
+	if err != nil {
+		q.logger.Warnf("[Quota] failed to scan %s: %v", q.opts.Dir, err)
+		return
+	}
+
+	for fileType, bytes := range usage {
+		prometheusQuotaUsage.WithLabelValues(q.opts.Dir, fileType.String()).Set(float64(bytes))
+	}
+
+	q.usageMu.Lock()
+	q.usage = usage
+	q.total = total
+	q.scanned = true
+	q.usageMu.Unlock()
+}
+
+// checkWrite returns an error when a write of the number of bytes of the file type is not allowed
+func (q *Quota) checkWrite(fileType fileformat.FileType, size int64) error {
+	if q.Pressure() == PressureCritical {
+		prometheusQuotaRejected.WithLabelValues(q.opts.Dir, fileType.String(), "disk").Inc()
+
+		return errors.NewStorageUnavailableError("[Quota] rejecting %s write, free disk space of %s is %.2f%%, below the critical watermark of %.2f%%", fileType, q.opts.Dir, q.FreePercent(), q.opts.CriticalWatermark)
+	}
+
+	q.usageMu.Lock()
+	defer q.usageMu.Unlock()
+
+	if !q.scanned {
+		return nil
+	}
+
+	if q.opts.MaxBytes > 0 && q.total+size > q.opts.MaxBytes {
+		prometheusQuotaRejected.WithLabelValues(q.opts.Dir, fileType.String(), "quota").Inc()
+
+		return errors.NewThresholdExceededError("[Quota] rejecting %s write, quota of %d bytes of %s is exceeded, %d bytes used", fileType, q.opts.MaxBytes, q.opts.Dir, q.total)
+	}
+
+	if maxBytes, ok := q.opts.FileTypeMaxBytes[fileType]; ok && q.usage[fileType]+size > maxBytes {
+		prometheusQuotaRejected.WithLabelValues(q.opts.Dir, fileType.String(), "file_type_quota").Inc()
+
+		return errors.NewThresholdExceededError("[Quota] rejecting %s write, quota of %d bytes of %s is exceeded, %d bytes used", fileType, maxBytes, q.opts.Dir, q.usage[fileType])
+	}
+
+	return nil
+}
+
+// addUsage adds the bytes written to the usage
+func (q *Quota) addUsage(fileType fileformat.FileType, size int64) {
+	q.usageMu.Lock()
+	q.usage[fileType] += size
+	q.total += size
+	q.usageMu.Unlock()
+}
+
+// Health reports the store as degraded below the low watermark and as unavailable below the critical watermark.
+func (q *Quota) Health(ctx context.Context, checkLiveness bool) (int, string, error) {
+	status, message, err := q.store.Health(ctx, checkLiveness)
+	if checkLiveness || err != nil || status != http.StatusOK {
+		return status, message, err
+	}
+
+	switch q.Pressure() {
+	case PressureCritical:
+		return http.StatusServiceUnavailable, fmt.Sprintf("Quota Store: critical, %.2f%% of the disk is free, writes are rejected; %s", q.FreePercent(), message), q.CheckPressure()
+	case PressureLow:
+		return http.StatusServiceUnavailable, fmt.Sprintf("Quota Store: degraded, %.2f%% of the disk is free, below the low watermark of %.2f%%; %s", q.FreePercent(), q.opts.LowWatermark, message), nil
+	default:
+		return status, message, nil
+	}
+}
+
+func (q *Quota) Exists(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (bool, error) {
+	return q.store.Exists(ctx, key, fileType, opts...)
+}
+
+func (q *Quota) Get(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) ([]byte, error) {
+	return q.store.Get(ctx, key, fileType, opts...)
+}
+
+func (q *Quota) GetIoReader(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (io.ReadCloser, error) {
+	return q.store.GetIoReader(ctx, key, fileType, opts...)
+}
+
+func (q *Quota) Set(ctx context.Context, key []byte, fileType fileformat.FileType, value []byte, opts ...options.FileOption) error {
+	if err := q.checkWrite(fileType, int64(len(value))); err != nil {
+		return err
+	}
+
+	if err := q.store.Set(ctx, key, fileType, value, opts...); err != nil {
+		return err
+	}
+
+	q.addUsage(fileType, int64(len(value)))
+
+	return nil
+}
+
+// SetFromReader checks the quotas before the write, as the size of the blob is not known up front, and counts
+// the bytes read from the reader against the quotas.
+func (q *Quota) SetFromReader(ctx context.Context, key []byte, fileType fileformat.FileType, value io.ReadCloser, opts ...options.FileOption) error {
+	if err := q.checkWrite(fileType, 0); err != nil {
+		_ = value.Close()
+		return err
+	}
+
+	reader := &countingReadCloser{ReadCloser: value}
+
+	if err := q.store.SetFromReader(ctx, key, fileType, reader, opts...); err != nil {
+		return err
+	}
+
+	q.addUsage(fileType, reader.n)
+
+	return nil
+}
+
+func (q *Quota) SetDAH(ctx context.Context, key []byte, fileType fileformat.FileType, newDAH uint32, opts ...options.FileOption) error {
+	return q.store.SetDAH(ctx, key, fileType, newDAH, opts...)
+}
+
+func (q *Quota) GetDAH(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (uint32, error) {
+	return q.store.GetDAH(ctx, key, fileType, opts...)
+}
+
+func (q *Quota) Del(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) error {
+	return q.store.Del(ctx, key, fileType, opts...)
+}
+
+// List lists the blobs of the wrapped store.
+func (q *Quota) List(ctx context.Context, listOpts options.ListOptions, opts ...options.FileOption) (*options.ListResult, error) {
+	lister, ok := q.store.(blobStoreLister)
+	if !ok {
+		return nil, errors.NewStorageError("[Quota] blob store %T does not support listing", q.store)
+	}
+
+	return lister.List(ctx, listOpts, opts...)
+}
+
+// Close stops monitoring the disk and closes the wrapped store.
+func (q *Quota) Close(ctx context.Context) error {
+	q.once.Do(func() {
+		registryMu.Lock()
+		delete(registry, q)
+		registryMu.Unlock()
+
+		q.cancel()
+		<-q.done
+
+		q.closeErr = q.store.Close(ctx)
+	})
+
+	return q.closeErr
+}
+
+func (q *Quota) SetCurrentBlockHeight(height uint32) {
+	q.store.SetCurrentBlockHeight(height)
+}
+
+// countingReadCloser counts the bytes read from the reader
+type countingReadCloser struct {
+	io.ReadCloser
+	n int64
+}
+
+func (c *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n += int64(n)
+
+	return n, err
+}
@@ -0,0 +1,188 @@
+package quota
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+	"github.com/bsv-blockchain/teranode/stores/blob/memory"
+	"github.com/bsv-blockchain/teranode/ulogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cleanerStore is a memory store that records the early cleanups
+type cleanerStore struct {
+	*memory.Memory
+	cleanups []uint32
+}
+
+func (c *cleanerStore) CleanupAhead(blocks uint32) int {
+	c.cleanups = append(c.cleanups, blocks)
+	return 1
+}
+
+// setDiskSpace makes the disk of every store report the percentage of free space
+func setDiskSpace(t *testing.T, freePercent uint64) {
+	original := diskSpace
+
+	diskSpace = func(string) (uint64, uint64, error) {
+		return freePercent, 100, nil
+	}
+
+	t.Cleanup(func() {
+		diskSpace = original
+	})
+}
+
+func TestQuota_Quotas(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	// blobs written before the store was created are counted, other files are not
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "blob.subtree"), make([]byte, 60), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "blob.subtree.dah"), make([]byte, 10), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other"), make([]byte, 10), 0o600))
+
+	q, err := New(ulogger.TestLogger{}, memory.New(), Options{
+		Dir:              dir,
+		MaxBytes:         100,
+		FileTypeMaxBytes: map[fileformat.FileType]int64{fileformat.FileTypeTx: 20},
+		ScanInterval:     time.Hour,
+	})
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, q.Close(ctx))
+	}()
+
+	// the first scan runs in the background
+	require.Eventually(t, func() bool {
+		q.usageMu.Lock()
+		defer q.usageMu.Unlock()
+
+		return q.scanned
+	}, time.Second, 10*time.Millisecond)
+
+	total, subtrees := q.Usage(fileformat.FileTypeSubtree)
+	assert.Equal(t, int64(60), total)
+	assert.Equal(t, int64(60), subtrees)
+
+	require.NoError(t, q.Set(ctx, []byte("key1"), fileformat.FileTypeSubtree, make([]byte, 20)))
+
+	// the quota of the file type is exceeded
+	err = q.Set(ctx, []byte("key2"), fileformat.FileTypeTx, make([]byte, 21))
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrThresholdExceeded))
+
+	require.NoError(t, q.SetFromReader(ctx, []byte("key2"), fileformat.FileTypeTx, io.NopCloser(bytes.NewReader(make([]byte, 15)))))
+
+	total, txs := q.Usage(fileformat.FileTypeTx)
+	assert.Equal(t, int64(95), total)
+	assert.Equal(t, int64(15), txs)
+
+	// the quota of the store is exceeded
+	err = q.Set(ctx, []byte("key3"), fileformat.FileTypeSubtree, make([]byte, 6))
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrThresholdExceeded))
+
+	exists, err := q.Exists(ctx, []byte("key3"), fileformat.FileTypeSubtree)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	// a scan replaces the count, the blobs of the memory store are not in the directory
+	q.scan(ctx)
+
+	total, _ = q.Usage(fileformat.FileTypeSubtree)
+	assert.Equal(t, int64(60), total)
+
+	require.NoError(t, q.Set(ctx, []byte("key3"), fileformat.FileTypeSubtree, make([]byte, 6)))
+}
+
+func TestQuota_DiskPressure(t *testing.T) {
+	ctx := context.Background()
+	setDiskSpace(t, 20)
+
+	store := &cleanerStore{Memory: memory.New()}
+
+	q, err := New(ulogger.TestLogger{}, store, Options{
+		Dir:                t.TempDir(),
+		LowWatermark:       10,
+		CriticalWatermark:  5,
+		EarlyCleanupBlocks: 6,
+		CheckInterval:      time.Hour,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, PressureNone, q.Pressure())
+	assert.Equal(t, float64(20), q.FreePercent())
+
+	status, _, err := q.Health(ctx, false)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, store.cleanups)
+
+	// below the low watermark the store is degraded and blobs are removed early, writes are still allowed
+	setDiskSpace(t, 8)
+	q.checkDisk()
+
+	assert.Equal(t, PressureLow, q.Pressure())
+	assert.Equal(t, []uint32{6}, store.cleanups)
+
+	status, message, err := q.Health(ctx, false)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Contains(t, message, "degraded")
+
+	require.NoError(t, CheckPressure())
+	require.NoError(t, q.Set(ctx, []byte("key"), fileformat.FileTypeTx, []byte("tx")))
+
+	// below the critical watermark writes are rejected and the pressure is reported to the services
+	setDiskSpace(t, 3)
+	q.checkDisk()
+
+	assert.Equal(t, PressureCritical, q.Pressure())
+
+	err = q.Set(ctx, []byte("key"), fileformat.FileTypeTx, []byte("tx"))
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrStorageUnavailable))
+
+	err = CheckPressure()
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrStorageUnavailable))
+
+	// liveness is not affected by the disk pressure
+	status, _, err = q.Health(ctx, true)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+
+	// reads are still served
+	value, err := q.Get(ctx, []byte("key"), fileformat.FileTypeTx)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("tx"), value)
+
+	// a closed store no longer reports its pressure
+	require.NoError(t, q.Close(ctx))
+	require.NoError(t, CheckPressure())
+}
+
+func TestNew_InvalidOptions(t *testing.T) {
+	for name, opts := range map[string]Options{
+		"no directory":           {},
+		"negative quota":         {Dir: "dir", MaxBytes: -1},
+		"zero file type quota":   {Dir: "dir", FileTypeMaxBytes: map[fileformat.FileType]int64{fileformat.FileTypeTx: 0}},
+		"watermark above 100":    {Dir: "dir", LowWatermark: 100},
+		"critical above low":     {Dir: "dir", LowWatermark: 5, CriticalWatermark: 10},
+		"negative critical mark": {Dir: "dir", CriticalWatermark: -1},
+	} {
+		_, err := New(ulogger.TestLogger{}, memory.New(), opts)
+		assert.Error(t, err, name)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"io"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -111,6 +112,45 @@ func (m *mockS3Client) DeleteObject(ctx context.Context, input *s3.DeleteObjectI
 	return &s3.DeleteObjectOutput{}, nil
 }
 
+func (m *mockS3Client) ListObjectsV2(ctx context.Context, input *s3.ListObjectsV2Input) (*s3.ListObjectsV2Output, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	startAfter := aws.ToString(input.StartAfter)
+	if input.ContinuationToken != nil {
+		startAfter = aws.ToString(input.ContinuationToken)
+	}
+
+	keys := make([]string, 0, len(m.store))
+
+	for key := range m.store {
+		if strings.HasPrefix(key, aws.ToString(input.Prefix)) && key > startAfter {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	output := &s3.ListObjectsV2Output{IsTruncated: aws.Bool(false)}
+
+	maxKeys := int(aws.ToInt32(input.MaxKeys))
+	if maxKeys == 0 {
+		maxKeys = 1000
+	}
+
+	if len(keys) > maxKeys {
+		keys = keys[:maxKeys]
+		output.IsTruncated = aws.Bool(true)
+		output.NextContinuationToken = aws.String(keys[maxKeys-1])
+	}
+
+	for _, key := range keys {
+		output.Contents = append(output.Contents, types.Object{Key: aws.String(key)})
+	}
+
+	return output, nil
+}
+
 func (m *mockS3Client) CreateMultipartUpload(ctx context.Context, input *s3.CreateMultipartUploadInput) (*s3.CreateMultipartUploadOutput, error) {
 	return &s3.CreateMultipartUploadOutput{
 		Bucket:   input.Bucket,
@@ -26,6 +26,7 @@ import (
 	"net/http"
 	"net/url"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -518,6 +519,99 @@ func (g *S3) Del(ctx context.Context, key []byte, fileType fileformat.FileType,
 	return nil
 }
 
+// List returns a page of the blobs in the bucket, in ascending order of their name.
+// The objects are listed from the sub directory of the store, or the sub directory in the options,
+// including the hash prefix directories. Objects are listed page by page from S3 until the page of
+// the listing is complete; with hash suffix directories the S3 order differs from the order of the
+// listing, so all objects in the sub directory are listed. The DAH of the entries is always 0, as
+// the S3 store does not keep DAHs.
+//
+// Parameters:
+//   - ctx: Context for the operation
+//   - listOpts: The prefix, file type, cursor and limit of the listing
+//   - opts: Optional file options, the sub directory to list
+//
+// Returns:
+//   - *options.ListResult: The blobs in the page and the cursor of the next page
+//   - error: Any error that occurred while listing the objects
+func (g *S3) List(ctx context.Context, listOpts options.ListOptions, opts ...options.FileOption) (*options.ListResult, error) {
+	ctx, span, endSpan := tracing.Tracer("s3").Start(ctx, "s3:List")
+	defer endSpan()
+
+	merged := options.MergeOptions(g.options, opts)
+
+	dirPrefix := ""
+	if merged.SubDirectory != "" {
+		dirPrefix = strings.TrimSuffix(merged.SubDirectory, "/") + "/"
+	}
+
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(g.bucket),
+		Prefix: aws.String(dirPrefix),
+	}
+
+	// the prefix of the listing can only be passed to S3 when the objects are not in hash prefix directories
+	if merged.HashPrefix == 0 {
+		input.Prefix = aws.String(dirPrefix + listOpts.Prefix)
+	}
+
+	// without hash suffix directories the S3 order is the order of the listing, so S3 can skip to the cursor
+	if listOpts.Cursor != "" && merged.HashPrefix >= 0 {
+		input.StartAfter = aws.String(filepath.Join(merged.SubDirectory, merged.CalculatePrefix(listOpts.Cursor), listOpts.Cursor))
+	}
+
+	limit := listOpts.GetLimit()
+	entries := make([]options.ListEntry, 0)
+
+	for {
+		output, err := g.client.ListObjectsV2(ctx, input)
+		if err != nil {
+			err = errors.NewStorageError("[S3] [%s/%s] failed to list objects", g.bucket, dirPrefix, err)
+			span.RecordError(err)
+
+			return nil, err
+		}
+
+		for _, object := range output.Contents {
+			entry, ok := g.listEntry(aws.ToString(object.Key), dirPrefix, merged)
+			if !ok || !listOpts.Matches(entry.Name, entry.FileType) || (listOpts.Cursor != "" && entry.Cursor() <= listOpts.Cursor) {
+				continue
+			}
+
+			entries = append(entries, entry)
+		}
+
+		if !aws.ToBool(output.IsTruncated) || (merged.HashPrefix >= 0 && len(entries) > limit) {
+			break
+		}
+
+		input.ContinuationToken = output.NextContinuationToken
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Cursor() < entries[j].Cursor()
+	})
+
+	return options.NewListResult(entries, listOpts), nil
+}
+
+// listEntry parses the object key of a blob in the listed sub directory into a list entry. It returns false
+// for objects that are not blobs, or that are in another sub directory.
+func (g *S3) listEntry(objectKey string, dirPrefix string, o *options.Options) (options.ListEntry, bool) {
+	path := strings.TrimPrefix(objectKey, dirPrefix)
+
+	if dir, name, found := strings.Cut(path, "/"); found {
+		// only descend into the hash prefix directories
+		if o.HashPrefix == 0 || strings.Contains(name, "/") || dir != o.CalculatePrefix(name) {
+			return options.ListEntry{}, false
+		}
+
+		path = name
+	}
+
+	return options.ParseListEntry(path)
+}
+
 func (g *S3) SetCurrentBlockHeight(_ uint32) {
 	// This method is intentionally left empty because the S3 backend does not
 	// support or require block height functionality. Block height is not relevant
@@ -364,3 +364,66 @@ func TestS3_GetCacheMiss(t *testing.T) {
 	assert.True(t, ok, "Value should be cached after Get")
 	assert.Equal(t, value, cached)
 }
+
+func TestS3_List(t *testing.T) {
+	ctx := context.Background()
+
+	keys := [][]byte{{0x01, 0xaa}, {0x02, 0xaa}, {0x03, 0xbb}}
+
+	tests := []struct {
+		name       string
+		hashPrefix int
+	}{
+		{name: "without hash prefix"},
+		{name: "with hash prefix", hashPrefix: 2},
+		{name: "with hash suffix", hashPrefix: -2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s3Store, _ := setupTestS3(t)
+			s3Store.options = options.NewStoreOptions(options.WithDefaultSubDirectory("blobs"), options.WithHashPrefix(tt.hashPrefix))
+
+			for _, key := range keys {
+				require.NoError(t, s3Store.Set(ctx, key, fileformat.FileTypeSubtree, []byte("subtree")))
+			}
+
+			require.NoError(t, s3Store.Set(ctx, keys[0], fileformat.FileTypeSubtreeData, []byte("data")))
+			require.NoError(t, s3Store.Set(ctx, keys[2], fileformat.FileTypeTx, []byte("tx"), options.WithSubDirectory("other")))
+
+			result, err := s3Store.List(ctx, options.ListOptions{})
+			require.NoError(t, err)
+
+			cursors := make([]string, 0, len(result.Entries))
+			for _, entry := range result.Entries {
+				cursors = append(cursors, entry.Cursor())
+			}
+
+			assert.Equal(t, []string{"aa01.subtree", "aa01.subtreeData", "aa02.subtree", "bb03.subtree"}, cursors)
+			assert.Equal(t, keys[0], result.Entries[0].Key)
+			assert.Empty(t, result.NextCursor)
+
+			result, err = s3Store.List(ctx, options.ListOptions{Prefix: "aa", FileType: fileformat.FileTypeSubtree})
+			require.NoError(t, err)
+			require.Len(t, result.Entries, 2)
+
+			// pagination
+			result, err = s3Store.List(ctx, options.ListOptions{Limit: 2})
+			require.NoError(t, err)
+			require.Len(t, result.Entries, 2)
+			assert.Equal(t, "aa01.subtreeData", result.NextCursor)
+
+			result, err = s3Store.List(ctx, options.ListOptions{Limit: 2, Cursor: result.NextCursor})
+			require.NoError(t, err)
+			require.Len(t, result.Entries, 2)
+			assert.Equal(t, "aa02", result.Entries[0].Name)
+			assert.Empty(t, result.NextCursor)
+
+			// other sub directory
+			result, err = s3Store.List(ctx, options.ListOptions{}, options.WithSubDirectory("other"))
+			require.NoError(t, err)
+			require.Len(t, result.Entries, 1)
+			assert.Equal(t, fileformat.FileTypeTx, result.Entries[0].FileType)
+		})
+	}
+}
@@ -16,6 +16,7 @@ type S3Client interface {
 	GetObject(ctx context.Context, input *s3.GetObjectInput) (*s3.GetObjectOutput, error)
 	HeadObject(ctx context.Context, input *s3.HeadObjectInput) (*s3.HeadObjectOutput, error)
 	DeleteObject(ctx context.Context, input *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error)
+	ListObjectsV2(ctx context.Context, input *s3.ListObjectsV2Input) (*s3.ListObjectsV2Output, error)
 
 	// Upload operations
 	CreateMultipartUpload(ctx context.Context, input *s3.CreateMultipartUploadInput) (*s3.CreateMultipartUploadOutput, error)
@@ -63,6 +64,10 @@ func (c *realS3Client) DeleteObject(ctx context.Context, input *s3.DeleteObjectI
 	return c.client.DeleteObject(ctx, input)
 }
 
+func (c *realS3Client) ListObjectsV2(ctx context.Context, input *s3.ListObjectsV2Input) (*s3.ListObjectsV2Output, error) {
+	return c.client.ListObjectsV2(ctx, input)
+}
+
 func (c *realS3Client) CreateMultipartUpload(ctx context.Context, input *s3.CreateMultipartUploadInput) (*s3.CreateMultipartUploadOutput, error) {
 	return c.client.CreateMultipartUpload(ctx, input)
 }
@@ -19,12 +19,18 @@
 //   - POST /blob/{key}.{fileType} - Store a new blob
 //   - PATCH /blob/{key}.{fileType} - Update blob's Delete-At-Height value
 //   - DELETE /blob/{key}.{fileType} - Delete a blob
+//   - GET /list - List the blobs in the store
+//   - POST /multi - Retrieve many blobs in a single response
 //   - GET /health - Health check endpoint
+//
+// When the server is configured with a ServerAuth, every endpoint but the health check requires a bearer token
+// or a verified TLS client certificate with a read or read-write scope.
 package blob
 
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -35,12 +41,20 @@ import (
 
 	"github.com/bsv-blockchain/teranode/errors"
 	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+	blobhttp "github.com/bsv-blockchain/teranode/stores/blob/http"
 	"github.com/bsv-blockchain/teranode/stores/blob/options"
 	"github.com/bsv-blockchain/teranode/ulogger"
 )
 
 const NotFoundMsg = "Not found"
 
+const (
+	// maxMultiRequestSize is the maximum size of the JSON body of a POST /multi request
+	maxMultiRequestSize = 1 << 20
+	// multiChunkSize is the maximum size of the chunks blobs are streamed in by POST /multi
+	multiChunkSize = 256 * 1024
+)
+
 // HTTPBlobServer provides an HTTP interface to a blob storage backend.
 // It implements the http.Handler interface and exposes blob operations as RESTful endpoints.
 // The server supports standard CRUD operations plus specialized features like health checks,
@@ -61,6 +75,8 @@ type HTTPBlobServer struct {
 	store Store
 	// logger provides structured logging for server operations
 	logger ulogger.Logger
+	// auth authenticates the clients, nil to allow all requests
+	auth *ServerAuth
 }
 
 // NewHTTPBlobServer creates a new HTTP blob server instance.
@@ -84,6 +100,15 @@ func NewHTTPBlobServer(logger ulogger.Logger, storeURL *url.URL, opts ...options
 	}, nil
 }
 
+// SetAuth requires the clients of the server to authenticate, and starts the server with the TLS
+// configuration of the authentication when it has one. It must be called before the server is started.
+//
+// Parameters:
+//   - auth: The authentication of the clients, nil to allow all requests
+func (s *HTTPBlobServer) SetAuth(auth *ServerAuth) {
+	s.auth = auth
+}
+
 // Start begins serving HTTP requests on the specified address.
 // Parameters:
 //   - ctx: Context for server lifecycle
@@ -102,6 +127,10 @@ func (s *HTTPBlobServer) Start(ctx context.Context, addr string) error {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	if s.auth != nil && s.auth.TLSConfig() != nil {
+		srv.TLSConfig = s.auth.TLSConfig()
+	}
+
 	go func() {
 		<-ctx.Done()
 		s.logger.Infof("Shutting down HTTP blob server")
@@ -114,6 +143,11 @@ func (s *HTTPBlobServer) Start(ctx context.Context, addr string) error {
 		}
 	}()
 
+	if srv.TLSConfig != nil {
+		// the certificate is in the TLS configuration
+		return srv.ListenAndServeTLS("", "")
+	}
+
 	return srv.ListenAndServe()
 }
 
@@ -127,6 +161,12 @@ func (s *HTTPBlobServer) Start(ctx context.Context, addr string) error {
 // - POST /blob/{key}.{fileType}: Store a new blob
 // - PATCH /blob/{key}.{fileType}: Update blob's Delete-At-Height value
 // - DELETE /blob/{key}.{fileType}: Delete a blob
+// - GET /list: List the blobs in the store
+// - POST /multi: Retrieve many blobs in a single response
+//
+// When the server has a ServerAuth, requests other than the health check are rejected with
+// 401 Unauthorized without credentials, and with 403 Forbidden when the scope of the client
+// does not allow the request.
 //
 // Parameters:
 //   - w: HTTP response writer for sending the response
@@ -137,6 +177,20 @@ func (s *HTTPBlobServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !s.authorize(w, r) {
+		return
+	}
+
+	if r.URL.Path == "/list" {
+		s.handleList(w, r)
+		return
+	}
+
+	if r.URL.Path == "/multi" {
+		s.handleMulti(w, r)
+		return
+	}
+
 	opts := options.QueryToFileOptions(r.URL.Query())
 
 	switch r.Method {
@@ -155,6 +209,36 @@ func (s *HTTPBlobServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// authorize checks the scope of the client allows the request, writing the error response when it does not.
+//
+// Parameters:
+//   - w: HTTP response writer for sending the error response
+//   - r: HTTP request to authorize
+//
+// Returns:
+//   - bool: True if the request is allowed
+func (s *HTTPBlobServer) authorize(w http.ResponseWriter, r *http.Request) bool {
+	if s.auth == nil {
+		return true
+	}
+
+	scope := s.auth.scope(r)
+
+	if scope == ScopeNone {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="blob"`)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+
+		return false
+	}
+
+	if scope < requiredScope(r) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return false
+	}
+
+	return true
+}
+
 // setCurrentBlockHeight updates the current block height in the underlying store if it supports this operation.
 // This is used for DAH (Delete-At-Height) functionality to determine when blobs should be deleted.
 //
@@ -195,6 +279,152 @@ func (s *HTTPBlobServer) handleHealth(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write([]byte(msg))
 }
 
+// handleList processes blob listing requests (HTTP GET /list).
+// It returns a page of the blobs in the store as JSON, an options.ListResult. The listing is
+// configured with the query parameters:
+// - prefix: Only list blobs whose name starts with the prefix
+// - fileType: Only list blobs of the file type
+// - cursor: Continue the listing after the cursor returned with the previous page
+// - limit: The maximum number of blobs in the page
+// - subDirectory: The sub directory to list instead of the default sub directory of the store
+//
+// The function returns appropriate HTTP status codes:
+// - 200 OK with the page of the listing
+// - 400 Bad Request if the file type or limit is invalid
+// - 405 Method Not Allowed for other methods than GET
+// - 501 Not Implemented if the underlying store does not support listing
+// - 500 Internal Server Error for other failures
+//
+// Parameters:
+//   - w: HTTP response writer for sending the listing
+//   - r: HTTP request containing the listing options in the query parameters
+func (s *HTTPBlobServer) handleList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+
+	listOpts := options.ListOptions{
+		Prefix: query.Get("prefix"),
+		Cursor: query.Get("cursor"),
+	}
+
+	if fileTypeStr := query.Get("fileType"); fileTypeStr != "" {
+		fileType, err := fileformat.FileTypeFromExtension(fileTypeStr)
+		if err != nil {
+			http.Error(w, "Invalid file type", http.StatusBadRequest)
+			return
+		}
+
+		listOpts.FileType = fileType
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+
+		listOpts.Limit = limit
+	}
+
+	var opts []options.FileOption
+	if subDirectory := query.Get("subDirectory"); subDirectory != "" {
+		opts = append(opts, options.WithSubDirectory(subDirectory))
+	}
+
+	if _, ok := s.store.(Lister); !ok {
+		http.Error(w, "Store does not support listing", http.StatusNotImplemented)
+		return
+	}
+
+	result, err := List(r.Context(), s.store, listOpts, opts...)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+// handleMulti processes requests for many blobs of a file type (HTTP POST /multi).
+// The request body is a JSON http.MultiRequest holding the base64 URL encoded keys, the file type
+// and the file options are passed as query parameters. The blobs are streamed in a single response
+// in the order of the keys, in the format written by http.MultiWriter, so a missing or unreadable
+// blob does not fail the other blobs.
+//
+// The function returns appropriate HTTP status codes:
+// - 200 OK with the blobs
+// - 400 Bad Request if the file type, the body or a key is invalid, or there are too many keys
+// - 405 Method Not Allowed for other methods than POST
+//
+// Parameters:
+//   - w: HTTP response writer for streaming the blobs
+//   - r: HTTP request containing the keys in the body
+func (s *HTTPBlobServer) handleMulti(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	fileType, err := fileformat.FileTypeFromExtension(r.URL.Query().Get("fileType"))
+	if err != nil {
+		http.Error(w, "Invalid file type", http.StatusBadRequest)
+		return
+	}
+
+	var request blobhttp.MultiRequest
+
+	if err = json.NewDecoder(http.MaxBytesReader(w, r.Body, maxMultiRequestSize)).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if len(request.Keys) > blobhttp.MaxMultiKeys {
+		http.Error(w, fmt.Sprintf("Too many keys, at most %d", blobhttp.MaxMultiKeys), http.StatusBadRequest)
+		return
+	}
+
+	keys := make([][]byte, len(request.Keys))
+
+	for i, encodedKey := range request.Keys {
+		if keys[i], err = base64.URLEncoding.DecodeString(encodedKey); err != nil {
+			http.Error(w, "Invalid key format", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// the response of many blobs can take longer than the write timeout of the server
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.WriteHeader(http.StatusOK)
+
+	multiWriter := blobhttp.NewMultiWriter(w)
+	buf := make([]byte, multiChunkSize)
+
+	err = GetMulti(r.Context(), s.store, keys, fileType, func(_ int, reader io.Reader, err error) error {
+		switch {
+		case err == nil:
+			return multiWriter.WriteBlob(reader, buf)
+		case errors.Is(err, errors.ErrNotFound):
+			return multiWriter.WriteNotFound()
+		default:
+			return multiWriter.WriteError(err)
+		}
+	}, options.QueryToFileOptions(r.URL.Query())...)
+	if err != nil {
+		// the status is sent, the client sees the response end early
+		s.logger.Warnf("[HTTPBlobServer] failed to stream %d blobs: %v", len(keys), err)
+	}
+}
+
 // handleExists processes blob existence check requests (HTTP HEAD).
 // It checks if a blob exists in the store without retrieving the actual content,
 // making it an efficient way to verify blob availability. The method extracts the
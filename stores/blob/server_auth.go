@@ -0,0 +1,217 @@
+package blob
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/bsv-blockchain/teranode/errors"
+)
+
+// Scope is the access a client of the HTTPBlobServer is granted.
+type Scope int
+
+const (
+	// ScopeNone grants no access, only the health endpoint can be used
+	ScopeNone Scope = iota
+	// ScopeRead grants reading, checking and listing blobs
+	ScopeRead
+	// ScopeReadWrite grants storing and deleting blobs and setting their DAH as well
+	ScopeReadWrite
+)
+
+// ScopeFromString returns the scope of its name, read or readwrite
+func ScopeFromString(s string) (Scope, error) {
+	switch s {
+	case "read":
+		return ScopeRead, nil
+	case "readwrite":
+		return ScopeReadWrite, nil
+	default:
+		return ScopeNone, errors.NewConfigurationError("unknown scope %q, expected read or readwrite", s)
+	}
+}
+
+// ServerAuth authenticates the clients of an HTTPBlobServer, by bearer token or by the common name of a
+// verified TLS client certificate, and grants them the scope configured for the token or common name.
+// A client presenting both gets the widest of the two scopes.
+type ServerAuth struct {
+	// tokens holds the scopes by the SHA-256 of the token, so tokens are not compared byte by byte
+	tokens map[[sha256.Size]byte]Scope
+	// commonNames holds the scopes by the common name of the client certificate
+	commonNames map[string]Scope
+	// tlsConfig is the TLS configuration of the server, nil to serve plain HTTP
+	tlsConfig *tls.Config
+}
+
+// NewServerAuth creates the authentication of an HTTPBlobServer.
+//
+// Parameters:
+//   - tokens: The scopes by bearer token
+//   - commonNames: The scopes by the common name of verified TLS client certificates
+//   - tlsConfig: The TLS configuration the server is started with, nil to serve plain HTTP
+//
+// Returns:
+//   - *ServerAuth: The authentication of the server
+func NewServerAuth(tokens map[string]Scope, commonNames map[string]Scope, tlsConfig *tls.Config) *ServerAuth {
+	a := &ServerAuth{
+		tokens:      make(map[[sha256.Size]byte]Scope, len(tokens)),
+		commonNames: make(map[string]Scope, len(commonNames)),
+		tlsConfig:   tlsConfig,
+	}
+
+	for token, scope := range tokens {
+		a.tokens[sha256.Sum256([]byte(token))] = scope
+	}
+
+	for commonName, scope := range commonNames {
+		a.commonNames[commonName] = scope
+	}
+
+	return a
+}
+
+// LoadServerAuth loads the authentication of an HTTPBlobServer from an access file and TLS certificate files.
+//
+// The access file grants one scope per line, as the scope, read or readwrite, followed by the bearer token
+// prefixed with token: or the common name of a client certificate prefixed with cn:, e.g.
+//
+//	# subtree validation reads subtree data
+//	read token:2b7e151628aed2a6abf7158809cf4f3c
+//	readwrite cn:blockpersister
+//
+// Parameters:
+//   - accessFile: The path of the access file
+//   - certFile, keyFile: The TLS certificate and key of the server, empty to serve plain HTTP
+//   - clientCAFile: The CA client certificates are verified with, empty to not request client certificates
+//
+// Returns:
+//   - *ServerAuth: The authentication of the server
+//   - error: Any error reading the files
+func LoadServerAuth(accessFile, certFile, keyFile, clientCAFile string) (*ServerAuth, error) {
+	tokens, commonNames, err := readAccessFile(accessFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if certFile == "" {
+		if clientCAFile != "" {
+			return nil, errors.NewConfigurationError("a client CA requires a TLS certificate and key")
+		}
+
+		return NewServerAuth(tokens, commonNames, nil), nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, errors.NewConfigurationError("failed to load TLS certificate and key", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if clientCAFile != "" {
+		caCert, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, errors.NewConfigurationError("failed to read client CA file %s", clientCAFile, err)
+		}
+
+		tlsConfig.ClientCAs = x509.NewCertPool()
+
+		if !tlsConfig.ClientCAs.AppendCertsFromPEM(caCert) {
+			return nil, errors.NewConfigurationError("no CA certificate found in %s", clientCAFile)
+		}
+
+		// clients authenticating with a bearer token do not need a certificate
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return NewServerAuth(tokens, commonNames, tlsConfig), nil
+}
+
+// readAccessFile reads the scopes of the tokens and common names in an access file
+func readAccessFile(accessFile string) (map[string]Scope, map[string]Scope, error) {
+	f, err := os.Open(accessFile)
+	if err != nil {
+		return nil, nil, errors.NewConfigurationError("failed to open access file %s", accessFile, err)
+	}
+	defer f.Close()
+
+	tokens := make(map[string]Scope)
+	commonNames := make(map[string]Scope)
+
+	scanner := bufio.NewScanner(f)
+
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		scopeName, principal, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, nil, errors.NewConfigurationError("invalid line %d in access file, expected scope and principal", lineNumber)
+		}
+
+		scope, err := ScopeFromString(scopeName)
+		if err != nil {
+			return nil, nil, errors.NewConfigurationError("invalid line %d in access file", lineNumber, err)
+		}
+
+		principal = strings.TrimSpace(principal)
+
+		switch {
+		case strings.HasPrefix(principal, "token:") && len(principal) > len("token:"):
+			tokens[strings.TrimPrefix(principal, "token:")] = scope
+		case strings.HasPrefix(principal, "cn:") && len(principal) > len("cn:"):
+			commonNames[strings.TrimPrefix(principal, "cn:")] = scope
+		default:
+			return nil, nil, errors.NewConfigurationError("invalid line %d in access file, expected token: or cn: principal", lineNumber)
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, nil, errors.NewConfigurationError("failed to read access file %s", accessFile, err)
+	}
+
+	return tokens, commonNames, nil
+}
+
+// TLSConfig returns the TLS configuration the server is started with, nil to serve plain HTTP
+func (a *ServerAuth) TLSConfig() *tls.Config {
+	return a.tlsConfig
+}
+
+// scope returns the scope granted to the client of a request
+func (a *ServerAuth) scope(r *http.Request) Scope {
+	scope := ScopeNone
+
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		scope = max(scope, a.tokens[sha256.Sum256([]byte(token))])
+	}
+
+	// only certificates verified against the client CA have verified chains
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+		scope = max(scope, a.commonNames[r.TLS.VerifiedChains[0][0].Subject.CommonName])
+	}
+
+	return scope
+}
+
+// requiredScope returns the scope a request requires, reading requires ScopeRead, changing blobs ScopeReadWrite
+func requiredScope(r *http.Request) Scope {
+	switch {
+	case r.URL.Path == "/multi", r.URL.Path == "/list":
+		return ScopeRead
+	case r.Method == http.MethodGet, r.Method == http.MethodHead:
+		return ScopeRead
+	default:
+		return ScopeReadWrite
+	}
+}
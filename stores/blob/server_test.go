@@ -6,11 +6,14 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http/httptest"
 	"net/url"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/bsv-blockchain/teranode/errors"
 	"github.com/bsv-blockchain/teranode/pkg/fileformat"
 	"github.com/bsv-blockchain/teranode/stores/blob/http"
 	"github.com/bsv-blockchain/teranode/stores/blob/options"
@@ -177,4 +180,210 @@ func TestServerOperations(t *testing.T) {
 		err = client.Del(context.Background(), key, fileformat.FileTypeTesting)
 		require.NoError(t, err)
 	})
+
+	t.Run("List", func(t *testing.T) {
+		keys := [][]byte{[]byte("testKey6"), []byte("testKey7")}
+
+		for _, key := range keys {
+			err := client.Set(context.Background(), key, fileformat.FileTypeSubtree, []byte("subtree"), options.WithDeleteAt(1000))
+			require.NoError(t, err)
+		}
+
+		result, err := client.List(context.Background(), options.ListOptions{FileType: fileformat.FileTypeSubtree, Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, result.Entries, 1)
+		assert.Equal(t, fileformat.FileTypeSubtree, result.Entries[0].FileType)
+		assert.Equal(t, uint32(1000), result.Entries[0].DAH)
+		assert.NotEmpty(t, result.NextCursor)
+
+		// walk all pages
+		var listed [][]byte
+
+		err = Walk(context.Background(), client, options.ListOptions{FileType: fileformat.FileTypeSubtree, Limit: 1}, func(entry options.ListEntry) error {
+			listed = append(listed, entry.Key)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, keys, listed)
+
+		for _, key := range keys {
+			err = client.Del(context.Background(), key, fileformat.FileTypeSubtree)
+			require.NoError(t, err)
+		}
+	})
+}
+
+func newTestServer(t *testing.T, auth *ServerAuth) string {
+	storeURL, err := url.Parse("memory://")
+	require.NoError(t, err)
+
+	blobServer, err := NewHTTPBlobServer(ulogger.TestLogger{}, storeURL)
+	require.NoError(t, err)
+
+	blobServer.SetAuth(auth)
+
+	server := httptest.NewServer(blobServer)
+	t.Cleanup(server.Close)
+
+	return server.URL
+}
+
+func newTestClient(t *testing.T, serverURL, query string) *http.HTTPStore {
+	clientURL, err := url.Parse(serverURL + "?" + query)
+	require.NoError(t, err)
+
+	client, err := http.New(ulogger.TestLogger{}, clientURL)
+	require.NoError(t, err)
+
+	return client
+}
+
+func TestServerAuth(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	accessFile := filepath.Join(dir, "access")
+	require.NoError(t, os.WriteFile(accessFile, []byte("# clients\nread token:reader\nreadwrite token:writer\n"), 0o600))
+
+	writerTokenFile := filepath.Join(dir, "writer")
+	require.NoError(t, os.WriteFile(writerTokenFile, []byte("writer\n"), 0o600))
+
+	t.Setenv("TEST_BLOB_READER_TOKEN", "reader")
+	t.Setenv("TEST_BLOB_INVALID_TOKEN", "invalid")
+
+	auth, err := LoadServerAuth(accessFile, "", "", "")
+	require.NoError(t, err)
+
+	serverURL := newTestServer(t, auth)
+
+	writer := newTestClient(t, serverURL, "authTokenFile="+url.QueryEscape(writerTokenFile))
+	reader := newTestClient(t, serverURL, "authTokenEnv=TEST_BLOB_READER_TOKEN")
+	anonymous := newTestClient(t, serverURL, "")
+
+	key := []byte("key")
+
+	require.NoError(t, writer.Set(ctx, key, fileformat.FileTypeTesting, []byte("value")))
+
+	value, err := reader.Get(ctx, key, fileformat.FileTypeTesting)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), value)
+
+	exists, err := reader.Exists(ctx, key, fileformat.FileTypeTesting)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	_, err = reader.List(ctx, options.ListOptions{})
+	require.NoError(t, err)
+
+	// the read scope does not allow changing blobs
+	require.Error(t, reader.Set(ctx, []byte("other"), fileformat.FileTypeTesting, []byte("value")))
+	require.Error(t, reader.Del(ctx, key, fileformat.FileTypeTesting))
+
+	// clients without a valid token can only check the health
+	_, err = anonymous.Get(ctx, key, fileformat.FileTypeTesting)
+	require.Error(t, err)
+
+	_, err = newTestClient(t, serverURL, "authTokenEnv=TEST_BLOB_INVALID_TOKEN").List(ctx, options.ListOptions{})
+	require.Error(t, err)
+
+	status, _, err := anonymous.Health(ctx, false)
+	require.NoError(t, err)
+	assert.Equal(t, 200, status)
+
+	require.NoError(t, writer.Del(ctx, key, fileformat.FileTypeTesting))
+}
+
+func TestLoadServerAuth_Invalid(t *testing.T) {
+	dir := t.TempDir()
+
+	for name, content := range map[string]string{
+		"unknown scope":     "admin token:secret",
+		"missing principal": "read",
+		"unknown principal": "read user:name",
+		"empty token":       "read token:",
+	} {
+		accessFile := filepath.Join(dir, "access")
+		require.NoError(t, os.WriteFile(accessFile, []byte(content), 0o600))
+
+		_, err := LoadServerAuth(accessFile, "", "", "")
+		require.Error(t, err, name)
+	}
+
+	_, err := LoadServerAuth(filepath.Join(dir, "missing"), "", "", "")
+	require.Error(t, err)
+}
+
+func TestServerMulti(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t, newTestServer(t, nil), "")
+
+	large := make([]byte, 3*multiChunkSize+100)
+	for i := range large {
+		large[i] = byte(i % 251)
+	}
+
+	values := map[string][]byte{"first": large, "second": []byte("second"), "third": {}}
+
+	for key, value := range values {
+		require.NoError(t, client.Set(ctx, []byte(key), fileformat.FileTypeSubtreeData, value))
+	}
+
+	keys := [][]byte{[]byte("first"), []byte("missing"), []byte("second"), []byte("third")}
+
+	read := make([][]byte, len(keys))
+	missing := make([]bool, len(keys))
+
+	err := client.GetMulti(ctx, keys, fileformat.FileTypeSubtreeData, func(index int, reader io.Reader, err error) error {
+		if errors.Is(err, errors.ErrNotFound) {
+			missing[index] = true
+			return nil
+		}
+
+		require.NoError(t, err)
+
+		if index == 0 {
+			// the rest of a blob that is not read is skipped
+			read[index] = make([]byte, 10)
+			_, err = io.ReadFull(reader, read[index])
+
+			return err
+		}
+
+		read[index], err = io.ReadAll(reader)
+
+		return err
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, large[:10], read[0])
+	assert.Equal(t, []bool{false, true, false, false}, missing)
+	assert.Equal(t, []byte("second"), read[2])
+	assert.Empty(t, read[3])
+
+	// the helper reads blob by blob from stores that are not a MultiGetter
+	storeURL, err := url.Parse("memory://")
+	require.NoError(t, err)
+
+	store, err := NewStore(ulogger.TestLogger{}, storeURL)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Set(ctx, []byte("first"), fileformat.FileTypeSubtreeData, large))
+
+	var found []int
+
+	err = GetMulti(ctx, store, keys[:2], fileformat.FileTypeSubtreeData, func(index int, reader io.Reader, err error) error {
+		if err != nil {
+			return err
+		}
+
+		value, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, large, value)
+
+		found = append(found, index)
+
+		return nil
+	})
+	require.ErrorIs(t, err, errors.ErrNotFound)
+	assert.Equal(t, []int{0}, found)
 }
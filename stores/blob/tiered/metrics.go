@@ -0,0 +1,61 @@
+package tiered
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	prometheusTieringMigratedBlobs  *prometheus.CounterVec
+	prometheusTieringMigratedBytes  *prometheus.CounterVec
+	prometheusTieringMigratedHeight *prometheus.GaugeVec
+	prometheusTieringErrors         *prometheus.CounterVec
+
+	// only init the metrics once
+	prometheusMetricsInitOnce sync.Once
+)
+
+func initPrometheusMetrics() {
+	prometheusMetricsInitOnce.Do(_initPrometheusMetrics)
+}
+
+func _initPrometheusMetrics() {
+	prometheusTieringMigratedBlobs = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "teranode",
+			Subsystem: "blob_tiering",
+			Name:      "migrated_blobs",
+			Help:      "Number of blobs migrated from the hot to the cold store",
+		},
+		[]string{"file_type"},
+	)
+	prometheusTieringMigratedBytes = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "teranode",
+			Subsystem: "blob_tiering",
+			Name:      "migrated_bytes",
+			Help:      "Number of bytes migrated from the hot to the cold store",
+		},
+		[]string{"file_type"},
+	)
+	prometheusTieringMigratedHeight = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "teranode",
+			Subsystem: "blob_tiering",
+			Name:      "migrated_height",
+			Help:      "Block height up to which the blobs have been migrated to the cold store",
+		},
+		[]string{"file_type"},
+	)
+	prometheusTieringErrors = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "teranode",
+			Subsystem: "blob_tiering",
+			Name:      "errors",
+			Help:      "Number of failed migration runs, which are retried at the next block",
+		},
+		[]string{"file_type"},
+	)
+}
@@ -0,0 +1,793 @@
+// Package tiered provides a blob store that migrates blobs from a hot store to a cold store as they age.
+//
+// The Tiered store writes every blob to the hot store, e.g. a file store on NVMe, and reads blobs from the hot
+// store first, falling back to the cold store, e.g. an S3 store. A tiering policy sets, per file type, the number
+// of blocks after which a blob is migrated: once the block height passed to SetCurrentBlockHeight is that many
+// blocks above the height the blob was written at, the blob is copied to the cold store, the copy is verified
+// against the hot copy and the hot copy is deleted. Blobs of file types without a policy stay in the hot store.
+//
+// The height a blob was written at is recorded in journals kept in the hot store, one per file type and block
+// height, which are written when the block height changes and deleted once all their blobs are migrated. A
+// migration that fails keeps its journal, so it is retried at the next block. The journal entries of the blobs
+// written since the journals were last written are lost when the process stops without closing the store, so
+// at the first block height after the store is created the hot store is listed, and the blobs that are not in a
+// journal are recorded at that height. Blobs written with a custom
+// filename or sub directory are not migrated, and a blob written again after it was migrated is written to the hot
+// store and migrated again.
+//
+// The progress of the migration is exposed through the teranode_blob_tiering_* Prometheus metrics.
+package tiered
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+	"github.com/bsv-blockchain/teranode/stores/blob/options"
+	"github.com/bsv-blockchain/teranode/ulogger"
+	"github.com/ordishs/go-utils"
+)
+
+// journalPrefix prefixes the filenames of the journals in the hot store, followed by the file type, the zero
+// padded block height and a unique suffix, so the journals of a file type are listed in ascending height order
+const journalPrefix = "tiering-"
+
+// blobStore defines the interface of the hot and cold stores, it mirrors the blob.Store interface
+type blobStore interface {
+	Health(ctx context.Context, checkLiveness bool) (int, string, error)
+	Exists(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (bool, error)
+	Get(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) ([]byte, error)
+	GetIoReader(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (io.ReadCloser, error)
+	Set(ctx context.Context, key []byte, fileType fileformat.FileType, value []byte, opts ...options.FileOption) error
+	SetFromReader(ctx context.Context, key []byte, fileType fileformat.FileType, value io.ReadCloser, opts ...options.FileOption) error
+	SetDAH(ctx context.Context, key []byte, fileType fileformat.FileType, newDAH uint32, opts ...options.FileOption) error
+	GetDAH(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (uint32, error)
+	Del(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) error
+	Close(ctx context.Context) error
+	SetCurrentBlockHeight(height uint32)
+}
+
+// blobStoreLister is implemented by stores that can list the blobs they hold
+type blobStoreLister interface {
+	List(ctx context.Context, listOpts options.ListOptions, opts ...options.FileOption) (*options.ListResult, error)
+}
+
+// Policy is the number of blocks after which the blobs of a file type are migrated to the cold store
+type Policy map[fileformat.FileType]uint32
+
+// written is a blob written to the hot store that is not recorded in a journal yet
+type written struct {
+	key      []byte
+	fileType fileformat.FileType
+	height   uint32
+}
+
+// Tiered is a blob store that migrates aged blobs from a hot store to a cold store.
+type Tiered struct {
+	logger ulogger.Logger
+	hot    blobStore
+	cold   blobStore
+	policy Policy
+
+	currentBlockHeight atomic.Uint32
+
+	// pending are the blobs written since the journals were last written
+	pendingMu sync.Mutex
+	pending   []written
+
+	// recovered is set once the blobs in the hot store without a journal entry are recorded
+	recovered bool
+
+	migrateCh     chan struct{}
+	migrateCancel context.CancelFunc
+	migratorDone  chan struct{}
+	closeOnce     sync.Once
+}
+
+// New creates a new Tiered store and starts the background migration.
+//
+// Parameters:
+//   - logger: Logger instance for tiering operations
+//   - hot: The store blobs are written to, it must support listing to find the journals
+//   - cold: The store aged blobs are migrated to
+//   - policy: The number of blocks after which the blobs of a file type are migrated
+//   - opts: Optional store configuration options, the block height channel drives the migration, so it should not
+//     be passed to the hot and cold stores as well
+//
+// Returns:
+//   - *Tiered: The tiered store
+//   - error: Any error in the configuration
+func New(logger ulogger.Logger, hot, cold blobStore, policy Policy, opts ...options.StoreOption) (*Tiered, error) {
+	if hot == nil || cold == nil {
+		return nil, errors.NewConfigurationError("[Tiered] hot and cold stores are required")
+	}
+
+	if _, ok := hot.(blobStoreLister); !ok {
+		return nil, errors.NewConfigurationError("[Tiered] hot store %T does not support listing", hot)
+	}
+
+	for fileType, depth := range policy {
+		if depth == 0 {
+			return nil, errors.NewConfigurationError("[Tiered] depth of file type %s must be positive", fileType)
+		}
+	}
+
+	initPrometheusMetrics()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	t := &Tiered{
+		logger:        logger,
+		hot:           hot,
+		cold:          cold,
+		policy:        policy,
+		migrateCh:     make(chan struct{}, 1),
+		migrateCancel: cancel,
+		migratorDone:  make(chan struct{}),
+	}
+
+	go t.migrator(ctx)
+
+	if blockHeightCh := options.NewStoreOptions(opts...).BlockHeightCh; blockHeightCh != nil {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case blockHeight := <-blockHeightCh:
+					t.SetCurrentBlockHeight(blockHeight)
+				}
+			}
+		}()
+	}
+
+	return t, nil
+}
+
+// journalName returns the filename of a journal of the blobs of a file type written at a block height
+func journalName(fileType fileformat.FileType, height uint32) string {
+	return fmt.Sprintf("%s%s-%010d-%d", journalPrefix, fileType, height, time.Now().UnixNano())
+}
+
+// journalHeight returns the block height of a journal of the file type, false when the name is not the name
+// of such a journal
+func journalHeight(name string, fileType fileformat.FileType) (uint32, bool) {
+	rest, ok := strings.CutPrefix(name, journalPrefix+fileType.String()+"-")
+	if !ok || len(rest) < 10 {
+		return 0, false
+	}
+
+	height, err := strconv.ParseUint(rest[:10], 10, 32)
+	if err != nil {
+		return 0, false
+	}
+
+	return uint32(height), true
+}
+
+// isJournal returns whether a listed blob is a journal
+func isJournal(entry options.ListEntry) bool {
+	return entry.FileType == fileformat.FileTypeDat && strings.HasPrefix(entry.Name, journalPrefix)
+}
+
+// record adds a blob written to the hot store to the pending journal entries, when it is migrated by the policy
+func (t *Tiered) record(key []byte, fileType fileformat.FileType, opts []options.FileOption) {
+	if _, ok := t.policy[fileType]; !ok {
+		return
+	}
+
+	fileOptions := options.NewFileOptions(opts...)
+	if fileOptions.Filename != "" || fileOptions.SubDirectory != "" {
+		return
+	}
+
+	t.pendingMu.Lock()
+	t.pending = append(t.pending, written{
+		key:      append([]byte(nil), key...),
+		fileType: fileType,
+		height:   t.currentBlockHeight.Load(),
+	})
+	t.pendingMu.Unlock()
+}
+
+// writeJournals writes the pending journal entries to the hot store, one journal per file type and height. Blobs
+// written before the block height was known are recorded at the current height. Entries of a journal that fails
+// to be written stay pending.
+func (t *Tiered) writeJournals(ctx context.Context) error {
+	t.pendingMu.Lock()
+	pending := t.pending
+	t.pending = nil
+	t.pendingMu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	type journalID struct {
+		fileType fileformat.FileType
+		height   uint32
+	}
+
+	journals := make(map[journalID][]written)
+	order := make([]journalID, 0, 1)
+
+	for _, w := range pending {
+		if w.height == 0 {
+			w.height = t.currentBlockHeight.Load()
+		}
+
+		id := journalID{fileType: w.fileType, height: w.height}
+		if _, ok := journals[id]; !ok {
+			order = append(order, id)
+		}
+
+		journals[id] = append(journals[id], w)
+	}
+
+	var firstErr error
+
+	for _, id := range order {
+		entries := journals[id]
+
+		var buf bytes.Buffer
+
+		for _, w := range entries {
+			buf.Write(binary.AppendUvarint(nil, uint64(len(w.key))))
+			buf.Write(w.key)
+		}
+
+		err := t.hot.Set(ctx, nil, fileformat.FileTypeDat, buf.Bytes(), options.WithFilename(journalName(id.fileType, id.height)))
+		if err != nil {
+			t.pendingMu.Lock()
+			t.pending = append(t.pending, entries...)
+			t.pendingMu.Unlock()
+
+			if firstErr == nil {
+				firstErr = errors.NewStorageError("[Tiered] failed to write journal of %s at height %d", id.fileType, id.height, err)
+			}
+		}
+	}
+
+	return firstErr
+}
+
+// recoverPending records the blobs of the file types of the policy in the hot store that are neither in a journal
+// nor pending, as their journal entries were lost when the process stopped before the journals were written. The
+// height they were written at is not known, they are recorded at the height the journals are written next.
+func (t *Tiered) recoverPending(ctx context.Context) error {
+	for fileType := range t.policy {
+		journaled := make(map[string]struct{})
+
+		err := t.listAll(ctx, options.ListOptions{Prefix: journalPrefix + fileType.String() + "-", FileType: fileformat.FileTypeDat}, func(entry options.ListEntry) error {
+			if _, ok := journalHeight(entry.Name, fileType); !ok {
+				return nil
+			}
+
+			data, err := t.hot.Get(ctx, nil, fileformat.FileTypeDat, options.WithFilename(entry.Name))
+			if err != nil {
+				return errors.NewStorageError("[Tiered] failed to read journal %s", entry.Name, err)
+			}
+
+			keys, err := decodeJournal(data)
+			if err != nil {
+				return errors.NewProcessingError("[Tiered] failed to decode journal %s", entry.Name, err)
+			}
+
+			for _, key := range keys {
+				journaled[string(key)] = struct{}{}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		var unjournaled [][]byte
+
+		if err = t.listAll(ctx, options.ListOptions{FileType: fileType}, func(entry options.ListEntry) error {
+			// blobs written with a custom filename are not migrated
+			if entry.Key == nil {
+				return nil
+			}
+
+			if _, ok := journaled[string(entry.Key)]; !ok {
+				unjournaled = append(unjournaled, entry.Key)
+			}
+
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		if len(unjournaled) == 0 {
+			continue
+		}
+
+		// blobs are recorded before they are written, so a blob written since the store was created is pending
+		t.pendingMu.Lock()
+
+		for _, w := range t.pending {
+			if w.fileType == fileType {
+				journaled[string(w.key)] = struct{}{}
+			}
+		}
+
+		recovered := 0
+
+		for _, key := range unjournaled {
+			if _, ok := journaled[string(key)]; !ok {
+				t.pending = append(t.pending, written{key: key, fileType: fileType})
+				recovered++
+			}
+		}
+
+		t.pendingMu.Unlock()
+
+		if recovered > 0 {
+			t.logger.Warnf("[Tiered] recorded %d %s blobs without a journal entry for migration", recovered, fileType)
+		}
+	}
+
+	return nil
+}
+
+// listAll calls fn with every blob of the hot store matching the list options
+func (t *Tiered) listAll(ctx context.Context, listOpts options.ListOptions, fn func(entry options.ListEntry) error) error {
+	for {
+		result, err := t.hot.(blobStoreLister).List(ctx, listOpts)
+		if err != nil {
+			return errors.NewStorageError("[Tiered] failed to list %s blobs", listOpts.FileType, err)
+		}
+
+		for _, entry := range result.Entries {
+			if err = fn(entry); err != nil {
+				return err
+			}
+		}
+
+		if result.NextCursor == "" {
+			return nil
+		}
+
+		listOpts.Cursor = result.NextCursor
+	}
+}
+
+// decodeJournal returns the keys recorded in a journal
+func decodeJournal(data []byte) ([][]byte, error) {
+	var keys [][]byte
+
+	for len(data) > 0 {
+		length, n := binary.Uvarint(data)
+		if n <= 0 || uint64(len(data)-n) < length {
+			return nil, errors.NewProcessingError("[Tiered] invalid journal entry")
+		}
+
+		keys = append(keys, data[n:n+int(length)])
+		data = data[n+int(length):]
+	}
+
+	return keys, nil
+}
+
+// migrator writes the journals and migrates the aged blobs whenever the block height changes
+func (t *Tiered) migrator(ctx context.Context) {
+	defer close(t.migratorDone)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.migrateCh:
+			if !t.recovered {
+				if err := t.recoverPending(ctx); err != nil {
+					t.logger.Errorf("[Tiered] failed to record the blobs without a journal entry: %v", err)
+				} else {
+					t.recovered = true
+				}
+			}
+
+			if err := t.writeJournals(ctx); err != nil {
+				t.logger.Errorf("%v", err)
+			}
+
+			if err := t.migrate(ctx, t.currentBlockHeight.Load()); err != nil && ctx.Err() == nil {
+				t.logger.Errorf("[Tiered] failed to migrate blobs at height %d: %v", t.currentBlockHeight.Load(), err)
+			}
+		}
+	}
+}
+
+// migrate migrates the blobs of every file type of the policy that were written at least the depth of the file
+// type below the block height
+func (t *Tiered) migrate(ctx context.Context, blockHeight uint32) error {
+	var firstErr error
+
+	for fileType, depth := range t.policy {
+		if blockHeight < depth {
+			continue
+		}
+
+		if err := t.migrateFileType(ctx, fileType, blockHeight-depth); err != nil {
+			prometheusTieringErrors.WithLabelValues(fileType.String()).Inc()
+
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	return firstErr
+}
+
+// migrateFileType migrates the blobs of the journals of a file type up to the height, in ascending height order
+func (t *Tiered) migrateFileType(ctx context.Context, fileType fileformat.FileType, maxHeight uint32) error {
+	listOpts := options.ListOptions{
+		Prefix:   journalPrefix + fileType.String() + "-",
+		FileType: fileformat.FileTypeDat,
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		result, err := t.hot.(blobStoreLister).List(ctx, listOpts)
+		if err != nil {
+			return errors.NewStorageError("[Tiered] failed to list journals of %s", fileType, err)
+		}
+
+		for _, entry := range result.Entries {
+			height, ok := journalHeight(entry.Name, fileType)
+			if !ok {
+				continue
+			}
+
+			if height > maxHeight {
+				return nil
+			}
+
+			if err = t.migrateJournal(ctx, fileType, entry.Name); err != nil {
+				return err
+			}
+
+			prometheusTieringMigratedHeight.WithLabelValues(fileType.String()).Set(float64(height))
+		}
+
+		if result.NextCursor == "" {
+			return nil
+		}
+
+		listOpts.Cursor = result.NextCursor
+	}
+}
+
+// migrateJournal migrates the blobs of a journal and deletes the journal. The journal is kept when a blob fails
+// to migrate, the blobs migrated already are not found in the hot store when it is retried.
+func (t *Tiered) migrateJournal(ctx context.Context, fileType fileformat.FileType, name string) error {
+	data, err := t.hot.Get(ctx, nil, fileformat.FileTypeDat, options.WithFilename(name))
+	if err != nil {
+		return errors.NewStorageError("[Tiered] failed to read journal %s", name, err)
+	}
+
+	keys, err := decodeJournal(data)
+	if err != nil {
+		return errors.NewProcessingError("[Tiered] failed to decode journal %s", name, err)
+	}
+
+	for _, key := range keys {
+		if err = t.migrateBlob(ctx, key, fileType); err != nil {
+			return err
+		}
+	}
+
+	if err = t.hot.Del(ctx, nil, fileformat.FileTypeDat, options.WithFilename(name)); err != nil {
+		return errors.NewStorageError("[Tiered] failed to delete journal %s", name, err)
+	}
+
+	return nil
+}
+
+// migrateBlob copies a blob with its DAH to the cold store, verifies the copy and deletes the hot copy. A blob
+// that is not in the hot store anymore, or that expires at the current height, is skipped.
+func (t *Tiered) migrateBlob(ctx context.Context, key []byte, fileType fileformat.FileType) error {
+	dah, err := t.hot.GetDAH(ctx, key, fileType)
+	if err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			return nil
+		}
+
+		return errors.NewStorageError("[Tiered] [%s] failed to get DAH of %s", utils.ReverseAndHexEncodeSlice(key), fileType, err)
+	}
+
+	if dah > 0 && dah <= t.currentBlockHeight.Load() {
+		// the hot store deletes the blob
+		return nil
+	}
+
+	reader, err := t.hot.GetIoReader(ctx, key, fileType)
+	if err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			return nil
+		}
+
+		return errors.NewStorageError("[Tiered] [%s] failed to read %s from hot store", utils.ReverseAndHexEncodeSlice(key), fileType, err)
+	}
+
+	hotHash := sha256.New()
+
+	err = t.cold.SetFromReader(ctx, key, fileType, teeReadCloser{Reader: io.TeeReader(reader, hotHash), Closer: reader},
+		options.WithDeleteAt(dah), options.WithAllowOverwrite(true))
+	if err != nil {
+		return errors.NewStorageError("[Tiered] [%s] failed to write %s to cold store", utils.ReverseAndHexEncodeSlice(key), fileType, err)
+	}
+
+	coldReader, err := t.cold.GetIoReader(ctx, key, fileType)
+	if err != nil {
+		return errors.NewStorageError("[Tiered] [%s] failed to read %s back from cold store", utils.ReverseAndHexEncodeSlice(key), fileType, err)
+	}
+
+	coldHash := sha256.New()
+
+	size, err := io.Copy(coldHash, coldReader)
+	_ = coldReader.Close()
+
+	if err != nil {
+		return errors.NewStorageError("[Tiered] [%s] failed to read %s back from cold store", utils.ReverseAndHexEncodeSlice(key), fileType, err)
+	}
+
+	if !bytes.Equal(hotHash.Sum(nil), coldHash.Sum(nil)) {
+		return errors.NewStorageError("[Tiered] [%s] cold copy of %s does not match the hot copy", utils.ReverseAndHexEncodeSlice(key), fileType)
+	}
+
+	if err = t.hot.Del(ctx, key, fileType); err != nil {
+		return errors.NewStorageError("[Tiered] [%s] failed to delete %s from hot store", utils.ReverseAndHexEncodeSlice(key), fileType, err)
+	}
+
+	prometheusTieringMigratedBlobs.WithLabelValues(fileType.String()).Inc()
+	prometheusTieringMigratedBytes.WithLabelValues(fileType.String()).Add(float64(size))
+
+	return nil
+}
+
+// teeReadCloser closes the reader that is read through a tee
+type teeReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// Health checks the health of the hot and cold stores, the tiered store is healthy when both are.
+func (t *Tiered) Health(ctx context.Context, checkLiveness bool) (int, string, error) {
+	status, message, err := t.hot.Health(ctx, checkLiveness)
+	if err != nil || status != http.StatusOK {
+		return status, "Tiered Store: hot store: " + message, err
+	}
+
+	status, message, err = t.cold.Health(ctx, checkLiveness)
+	if err != nil || status != http.StatusOK {
+		return status, "Tiered Store: cold store: " + message, err
+	}
+
+	return http.StatusOK, "Tiered Store: OK", nil
+}
+
+// Exists returns whether the blob exists in the hot or the cold store.
+func (t *Tiered) Exists(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (bool, error) {
+	found, err := t.hot.Exists(ctx, key, fileType, opts...)
+	if err != nil || found {
+		return found, err
+	}
+
+	return t.cold.Exists(ctx, key, fileType, opts...)
+}
+
+func (t *Tiered) Get(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) ([]byte, error) {
+	value, err := t.hot.Get(ctx, key, fileType, opts...)
+	if errors.Is(err, errors.ErrNotFound) {
+		return t.cold.Get(ctx, key, fileType, opts...)
+	}
+
+	return value, err
+}
+
+func (t *Tiered) GetIoReader(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (io.ReadCloser, error) {
+	reader, err := t.hot.GetIoReader(ctx, key, fileType, opts...)
+	if errors.Is(err, errors.ErrNotFound) {
+		return t.cold.GetIoReader(ctx, key, fileType, opts...)
+	}
+
+	return reader, err
+}
+
+// Set records the blob for migration and writes it to the hot store. The blob is recorded first, so it is not
+// taken for a blob without a journal entry when the hot store is listed while it is written.
+func (t *Tiered) Set(ctx context.Context, key []byte, fileType fileformat.FileType, value []byte, opts ...options.FileOption) error {
+	t.record(key, fileType, opts)
+
+	return t.hot.Set(ctx, key, fileType, value, opts...)
+}
+
+// SetFromReader records the blob for migration and writes it to the hot store, see Set.
+func (t *Tiered) SetFromReader(ctx context.Context, key []byte, fileType fileformat.FileType, reader io.ReadCloser, opts ...options.FileOption) error {
+	t.record(key, fileType, opts)
+
+	return t.hot.SetFromReader(ctx, key, fileType, reader, opts...)
+}
+
+// holder returns the store holding the blob, the hot store when it holds the blob, the cold store otherwise.
+// Not every store returns a not found error for the DAH of a missing blob, so the hot store is asked first.
+func (t *Tiered) holder(ctx context.Context, key []byte, fileType fileformat.FileType, opts []options.FileOption) (blobStore, error) {
+	found, err := t.hot.Exists(ctx, key, fileType, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if found {
+		return t.hot, nil
+	}
+
+	return t.cold, nil
+}
+
+// SetDAH sets the DAH of the blob in the store holding it.
+func (t *Tiered) SetDAH(ctx context.Context, key []byte, fileType fileformat.FileType, newDAH uint32, opts ...options.FileOption) error {
+	store, err := t.holder(ctx, key, fileType, opts)
+	if err != nil {
+		return err
+	}
+
+	return store.SetDAH(ctx, key, fileType, newDAH, opts...)
+}
+
+// GetDAH returns the DAH of the blob from the store holding it.
+func (t *Tiered) GetDAH(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) (uint32, error) {
+	store, err := t.holder(ctx, key, fileType, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	return store.GetDAH(ctx, key, fileType, opts...)
+}
+
+// Del deletes the blob from both stores, a blob missing from one of them is not an error.
+func (t *Tiered) Del(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) error {
+	if err := t.hot.Del(ctx, key, fileType, opts...); err != nil && !errors.Is(err, errors.ErrNotFound) {
+		return err
+	}
+
+	if err := t.cold.Del(ctx, key, fileType, opts...); err != nil && !errors.Is(err, errors.ErrNotFound) {
+		return err
+	}
+
+	return nil
+}
+
+// List returns a page of the blobs in the hot and cold stores, merged in ascending order of their name. The
+// journals are not listed.
+func (t *Tiered) List(ctx context.Context, listOpts options.ListOptions, opts ...options.FileOption) (*options.ListResult, error) {
+	coldLister, ok := t.cold.(blobStoreLister)
+	if !ok {
+		return nil, errors.NewStorageError("[Tiered] cold store %T does not support listing", t.cold)
+	}
+
+	pages := make([]*options.ListResult, 0, 2)
+
+	for _, lister := range []blobStoreLister{t.hot.(blobStoreLister), coldLister} {
+		page, err := lister.List(ctx, listOpts, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		pages = append(pages, page)
+	}
+
+	// the entries after the last entry of a page that is not the last page may be missing from the other page
+	var bound string
+
+	for _, page := range pages {
+		if page.NextCursor == "" || len(page.Entries) == 0 {
+			continue
+		}
+
+		if last := page.Entries[len(page.Entries)-1].Cursor(); bound == "" || last < bound {
+			bound = last
+		}
+	}
+
+	entries := mergeEntries(pages[0].Entries, pages[1].Entries, bound)
+	limit := listOpts.GetLimit()
+	more := bound != ""
+
+	if len(entries) > limit {
+		entries = entries[:limit]
+		more = true
+	}
+
+	result := &options.ListResult{Entries: entries}
+
+	if more {
+		if len(entries) > 0 {
+			result.NextCursor = entries[len(entries)-1].Cursor()
+		} else {
+			result.NextCursor = bound
+		}
+	}
+
+	return result, nil
+}
+
+// mergeEntries merges two sorted pages of entries, a blob in both pages once, skipping the journals and the
+// entries after the bound when it is set
+func mergeEntries(a, b []options.ListEntry, bound string) []options.ListEntry {
+	entries := make([]options.ListEntry, 0, len(a)+len(b))
+
+	for len(a) > 0 || len(b) > 0 {
+		var entry options.ListEntry
+
+		switch {
+		case len(b) == 0 || (len(a) > 0 && a[0].Cursor() < b[0].Cursor()):
+			entry, a = a[0], a[1:]
+		case len(a) == 0 || b[0].Cursor() < a[0].Cursor():
+			entry, b = b[0], b[1:]
+		default:
+			entry, a, b = a[0], a[1:], b[1:]
+		}
+
+		if bound != "" && entry.Cursor() > bound {
+			break
+		}
+
+		if !isJournal(entry) {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+// Close stops the migration, writes the pending journals and closes both stores.
+func (t *Tiered) Close(ctx context.Context) error {
+	var err error
+
+	t.closeOnce.Do(func() {
+		t.migrateCancel()
+		<-t.migratorDone
+
+		if journalErr := t.writeJournals(ctx); journalErr != nil {
+			t.logger.Errorf("%v", journalErr)
+		}
+
+		err = t.hot.Close(ctx)
+
+		if coldErr := t.cold.Close(ctx); err == nil {
+			err = coldErr
+		}
+	})
+
+	return err
+}
+
+// SetCurrentBlockHeight sets the block height of both stores and triggers the migration of the aged blobs.
+func (t *Tiered) SetCurrentBlockHeight(height uint32) {
+	t.currentBlockHeight.Store(height)
+
+	t.hot.SetCurrentBlockHeight(height)
+	t.cold.SetCurrentBlockHeight(height)
+
+	select {
+	case t.migrateCh <- struct{}{}:
+	default: // Channel is full; a migration is pending already.
+	}
+}
@@ -0,0 +1,264 @@
+package tiered
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+	"github.com/bsv-blockchain/teranode/stores/blob/memory"
+	"github.com/bsv-blockchain/teranode/stores/blob/options"
+	"github.com/bsv-blockchain/teranode/ulogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// corruptingStore is a memory store that returns different data than it was given
+type corruptingStore struct {
+	*memory.Memory
+}
+
+func (c *corruptingStore) GetIoReader(_ context.Context, _ []byte, _ fileformat.FileType, _ ...options.FileOption) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader([]byte("corrupted"))), nil
+}
+
+func newTiered(t *testing.T, hot, cold blobStore, policy Policy) *Tiered {
+	store, err := New(ulogger.TestLogger{}, hot, cold, policy)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = store.Close(context.Background())
+	})
+
+	return store
+}
+
+func exists(t *testing.T, store blobStore, key string, fileType fileformat.FileType) bool {
+	found, err := store.Exists(context.Background(), []byte(key), fileType)
+	require.NoError(t, err)
+
+	return found
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(ulogger.TestLogger{}, memory.New(), nil, nil)
+	require.Error(t, err)
+
+	_, err = New(ulogger.TestLogger{}, memory.New(), memory.New(), Policy{fileformat.FileTypeBlock: 0})
+	require.Error(t, err)
+}
+
+func TestJournalHeight(t *testing.T) {
+	height, ok := journalHeight(journalName(fileformat.FileTypeSubtree, 42), fileformat.FileTypeSubtree)
+	require.True(t, ok)
+	assert.Equal(t, uint32(42), height)
+
+	_, ok = journalHeight(journalName(fileformat.FileTypeSubtreeData, 42), fileformat.FileTypeSubtree)
+	assert.False(t, ok)
+}
+
+func TestTiered_Migrate(t *testing.T) {
+	ctx := context.Background()
+	hot, cold := memory.New(), memory.New()
+	store := newTiered(t, hot, cold, Policy{fileformat.FileTypeBlock: 10})
+
+	store.currentBlockHeight.Store(100)
+
+	require.NoError(t, store.Set(ctx, []byte("block"), fileformat.FileTypeBlock, []byte("block data"), options.WithDeleteAt(500)))
+	require.NoError(t, store.SetFromReader(ctx, []byte("streamed"), fileformat.FileTypeBlock, io.NopCloser(bytes.NewReader([]byte("streamed data")))))
+	require.NoError(t, store.Set(ctx, []byte("tx"), fileformat.FileTypeTx, []byte("tx data")))
+	require.NoError(t, store.Set(ctx, []byte("custom"), fileformat.FileTypeBlock, []byte("custom data"), options.WithFilename("custom")))
+
+	store.currentBlockHeight.Store(101)
+
+	require.NoError(t, store.writeJournals(ctx))
+
+	store.currentBlockHeight.Store(105)
+
+	require.NoError(t, store.Set(ctx, []byte("recent"), fileformat.FileTypeBlock, []byte("recent data")))
+	require.NoError(t, store.writeJournals(ctx))
+
+	// the blobs written at height 100 are not old enough yet
+	require.NoError(t, store.migrate(ctx, 109))
+	assert.True(t, exists(t, hot, "block", fileformat.FileTypeBlock))
+
+	require.NoError(t, store.migrate(ctx, 110))
+
+	for _, key := range []string{"block", "streamed"} {
+		assert.False(t, exists(t, hot, key, fileformat.FileTypeBlock), key)
+		assert.True(t, exists(t, cold, key, fileformat.FileTypeBlock), key)
+	}
+
+	// blobs of other file types, with a custom filename or written later stay in the hot store
+	assert.True(t, exists(t, hot, "tx", fileformat.FileTypeTx))
+	assert.True(t, exists(t, hot, "recent", fileformat.FileTypeBlock))
+
+	found, err := hot.Exists(ctx, nil, fileformat.FileTypeBlock, options.WithFilename("custom"))
+	require.NoError(t, err)
+	assert.True(t, found)
+
+	// migrated blobs are read from the cold store, with their DAH
+	value, err := store.Get(ctx, []byte("block"), fileformat.FileTypeBlock)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("block data"), value)
+
+	dah, err := store.GetDAH(ctx, []byte("block"), fileformat.FileTypeBlock)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(500), dah)
+
+	require.NoError(t, store.SetDAH(ctx, []byte("block"), fileformat.FileTypeBlock, 600))
+
+	dah, err = cold.GetDAH(ctx, []byte("block"), fileformat.FileTypeBlock)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(600), dah)
+
+	// the journal of height 100 is deleted, the one of height 105 is kept
+	result, err := hot.List(ctx, options.ListOptions{Prefix: journalPrefix})
+	require.NoError(t, err)
+	require.Len(t, result.Entries, 1)
+
+	height, ok := journalHeight(result.Entries[0].Name, fileformat.FileTypeBlock)
+	require.True(t, ok)
+	assert.Equal(t, uint32(105), height)
+
+	require.NoError(t, store.Del(ctx, []byte("block"), fileformat.FileTypeBlock))
+	assert.False(t, exists(t, store, "block", fileformat.FileTypeBlock))
+}
+
+func TestTiered_MigrateVerifiesCopy(t *testing.T) {
+	ctx := context.Background()
+	hot, cold := memory.New(), &corruptingStore{Memory: memory.New()}
+	store := newTiered(t, hot, cold, Policy{fileformat.FileTypeSubtree: 1})
+
+	store.currentBlockHeight.Store(10)
+
+	require.NoError(t, store.Set(ctx, []byte("subtree"), fileformat.FileTypeSubtree, []byte("subtree data")))
+	require.NoError(t, store.writeJournals(ctx))
+
+	err := store.migrate(ctx, 11)
+	require.Error(t, err)
+
+	// the hot copy and the journal are kept, so the migration is retried
+	assert.True(t, exists(t, hot, "subtree", fileformat.FileTypeSubtree))
+
+	result, err := hot.List(ctx, options.ListOptions{Prefix: journalPrefix})
+	require.NoError(t, err)
+	assert.Len(t, result.Entries, 1)
+}
+
+func TestTiered_RecoverPending(t *testing.T) {
+	ctx := context.Background()
+	hot, cold := memory.New(), memory.New()
+	policy := Policy{fileformat.FileTypeSubtree: 2}
+
+	store := newTiered(t, hot, cold, policy)
+	store.currentBlockHeight.Store(10)
+
+	require.NoError(t, store.Set(ctx, []byte("journaled"), fileformat.FileTypeSubtree, []byte("journaled data")))
+	require.NoError(t, store.writeJournals(ctx))
+
+	// the journal entry of a blob is lost when the process stops before the journals are written
+	require.NoError(t, store.Set(ctx, []byte("lost"), fileformat.FileTypeSubtree, []byte("lost data")))
+	require.NoError(t, store.Set(ctx, []byte("custom"), fileformat.FileTypeSubtree, []byte("custom data"), options.WithFilename("custom")))
+
+	store.pendingMu.Lock()
+	store.pending = nil
+	store.pendingMu.Unlock()
+
+	restarted := newTiered(t, hot, cold, policy)
+	restarted.currentBlockHeight.Store(20)
+
+	require.NoError(t, restarted.Set(ctx, []byte("new"), fileformat.FileTypeSubtree, []byte("new data")))
+	require.NoError(t, restarted.recoverPending(ctx))
+
+	// only the blob without a journal entry is recorded, once
+	keys := make([]string, 0, len(restarted.pending))
+	for _, w := range restarted.pending {
+		keys = append(keys, string(w.key))
+	}
+
+	assert.ElementsMatch(t, []string{"new", "lost"}, keys)
+
+	require.NoError(t, restarted.writeJournals(ctx))
+	require.NoError(t, restarted.migrate(ctx, 22))
+
+	for _, key := range []string{"journaled", "lost", "new"} {
+		assert.False(t, exists(t, hot, key, fileformat.FileTypeSubtree), key)
+		assert.True(t, exists(t, cold, key, fileformat.FileTypeSubtree), key)
+	}
+}
+
+func TestTiered_SetCurrentBlockHeight(t *testing.T) {
+	ctx := context.Background()
+	hot, cold := memory.New(), memory.New()
+	store := newTiered(t, hot, cold, Policy{fileformat.FileTypeSubtree: 2})
+
+	store.SetCurrentBlockHeight(1)
+
+	require.NoError(t, store.Set(ctx, []byte("subtree"), fileformat.FileTypeSubtree, []byte("subtree data")))
+
+	store.SetCurrentBlockHeight(2)
+
+	require.Eventually(t, func() bool {
+		result, err := hot.List(ctx, options.ListOptions{Prefix: journalPrefix})
+		return err == nil && len(result.Entries) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	store.SetCurrentBlockHeight(3)
+
+	require.Eventually(t, func() bool {
+		found, err := cold.Exists(ctx, []byte("subtree"), fileformat.FileTypeSubtree)
+		return err == nil && found
+	}, time.Second, 10*time.Millisecond)
+
+	value, err := store.Get(ctx, []byte("subtree"), fileformat.FileTypeSubtree)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("subtree data"), value)
+}
+
+func TestTiered_List(t *testing.T) {
+	ctx := context.Background()
+	hot, cold := memory.New(), memory.New()
+	store := newTiered(t, hot, cold, Policy{fileformat.FileTypeTx: 1})
+
+	for _, key := range [][]byte{{0x01}, {0x03}, {0x05}} {
+		require.NoError(t, hot.Set(ctx, key, fileformat.FileTypeTx, []byte("hot")))
+	}
+
+	for _, key := range [][]byte{{0x02}, {0x03}, {0x04}} {
+		require.NoError(t, cold.Set(ctx, key, fileformat.FileTypeTx, []byte("cold")))
+	}
+
+	require.NoError(t, hot.Set(ctx, nil, fileformat.FileTypeDat, []byte{}, options.WithFilename(journalName(fileformat.FileTypeTx, 1))))
+
+	var names []string
+
+	listOpts := options.ListOptions{Limit: 2}
+
+	for {
+		result, err := store.List(ctx, listOpts)
+		require.NoError(t, err)
+
+		for _, entry := range result.Entries {
+			names = append(names, entry.Name)
+		}
+
+		if result.NextCursor == "" {
+			break
+		}
+
+		listOpts.Cursor = result.NextCursor
+	}
+
+	assert.Equal(t, []string{"01", "02", "03", "04", "05"}, names)
+}
+
+func TestTiered_GetNotFound(t *testing.T) {
+	store := newTiered(t, memory.New(), memory.New(), nil)
+
+	_, err := store.Get(context.Background(), []byte("missing"), fileformat.FileTypeTx)
+	require.ErrorIs(t, err, errors.ErrNotFound)
+}
@@ -0,0 +1,163 @@
+// Package scripthash provides interfaces and implementations for the script hash index, which maps the
+// script hashes of locking scripts to the transaction outputs locked to them, and to the transactions
+// spending these outputs.
+//
+// The script hash of a locking script is the single SHA256 of the script. It is displayed in reverse byte
+// order, which is the format used by the Electrum protocol.
+//
+// The index only holds the blocks of the best chain. Blocks are indexed one at a time, in order of height,
+// and when the chain reorganizes, the blocks that are no longer on the best chain are unwound from the tip
+// of the index before the blocks of the new best chain are indexed.
+//
+// Package Organization:
+// - Interface.go: Defines the Store interface and the types stored in the index
+// - factory/: Factory function creating a store from a URL
+// - mock.go: Provides a mock implementation for testing purposes
+// - sql/: Contains the SQL-based implementation supporting PostgreSQL and SQLite
+package scripthash
+
+import (
+	"context"
+	"crypto/sha256"
+
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+)
+
+// Output is a transaction output locked to a script hash, together with the input spending it, if any.
+type Output struct {
+	// ScriptHash is the script hash of the locking script of the output
+	ScriptHash chainhash.Hash
+
+	// TxID is the hash of the transaction that created the output
+	TxID chainhash.Hash
+
+	// Vout is the index of the output in the transaction
+	Vout uint32
+
+	// Height is the height of the block the transaction was mined in
+	Height uint32
+
+	// TxPos is the position of the transaction in the block
+	TxPos uint32
+
+	// Satoshis is the value of the output
+	Satoshis uint64
+
+	// SpendingTxID is the hash of the transaction spending the output, or nil when the output is unspent
+	SpendingTxID *chainhash.Hash
+
+	// SpendingVin is the index of the input spending the output
+	SpendingVin uint32
+
+	// SpendingHeight is the height of the block the spending transaction was mined in
+	SpendingHeight uint32
+
+	// SpendingTxPos is the position of the spending transaction in the block
+	SpendingTxPos uint32
+}
+
+// Spend is an input of a transaction in an indexed block, spending a previous output.
+type Spend struct {
+	// TxID is the hash of the transaction that created the spent output
+	TxID chainhash.Hash
+
+	// Vout is the index of the spent output
+	Vout uint32
+
+	// SpendingTxID is the hash of the spending transaction
+	SpendingTxID chainhash.Hash
+
+	// SpendingVin is the index of the input in the spending transaction
+	SpendingVin uint32
+
+	// SpendingTxPos is the position of the spending transaction in the block
+	SpendingTxPos uint32
+}
+
+// Block holds the outputs created and the outputs spent by the transactions of a block.
+type Block struct {
+	// Hash is the hash of the block
+	Hash chainhash.Hash
+
+	// Height is the height of the block
+	Height uint32
+
+	// Outputs are the outputs created by the transactions of the block
+	Outputs []*Output
+
+	// Spends are the inputs of the transactions of the block, except for the coinbase
+	Spends []*Spend
+}
+
+// HistoryItem is a transaction that created or spent an output locked to a script hash.
+type HistoryItem struct {
+	// TxID is the hash of the transaction
+	TxID chainhash.Hash
+
+	// Height is the height of the block the transaction was mined in
+	Height uint32
+
+	// TxPos is the position of the transaction in the block
+	TxPos uint32
+}
+
+// Balance is the balance of a script hash.
+type Balance struct {
+	// Confirmed is the sum of the values of the unspent outputs
+	Confirmed uint64
+
+	// Received is the sum of the values of all outputs
+	Received uint64
+
+	// Spent is the sum of the values of the spent outputs
+	Spent uint64
+
+	// UnspentCount is the number of unspent outputs
+	UnspentCount uint64
+}
+
+// Store defines the interface for the script hash index.
+type Store interface {
+	// Health checks the health status of the store.
+	// Parameters:
+	//   - ctx: Context for the operation
+	//   - checkLiveness: Boolean flag to determine if liveness should be checked
+	// Returns: status code, status message, and any error encountered
+	Health(ctx context.Context, checkLiveness bool) (int, string, error)
+
+	// GetTip returns the hash and height of the last indexed block.
+	// Returns a not found error when no block has been indexed.
+	GetTip(ctx context.Context) (*chainhash.Hash, uint32, error)
+
+	// IndexBlock adds the outputs of a block to the index, and marks the outputs spent by the block as spent,
+	// in a single database transaction. The block must be the child of the current tip.
+	IndexBlock(ctx context.Context, block *Block) error
+
+	// UnwindBlock removes the block at the tip of the index, which must have the given hash. The outputs
+	// created by the block are removed, and the outputs spent by the block are marked as unspent again.
+	UnwindBlock(ctx context.Context, blockHash *chainhash.Hash) error
+
+	// GetHistory returns the transactions that created or spent the outputs locked to a script hash, in
+	// blockchain order, or in reverse blockchain order when reverse is set.
+	// Parameters:
+	//   - ctx: Context for the operation
+	//   - scriptHash: The script hash to get the history of
+	//   - skip: Number of transactions to skip
+	//   - limit: Maximum number of transactions to return, 0 for no limit
+	//   - reverse: Return the most recent transactions first
+	GetHistory(ctx context.Context, scriptHash *chainhash.Hash, skip, limit int, reverse bool) ([]*HistoryItem, error)
+
+	// GetUnspent returns the unspent outputs locked to a script hash, in blockchain order.
+	GetUnspent(ctx context.Context, scriptHash *chainhash.Hash) ([]*Output, error)
+
+	// GetBalance returns the balance of a script hash.
+	GetBalance(ctx context.Context, scriptHash *chainhash.Hash) (*Balance, error)
+
+	// Close closes the store.
+	Close() error
+}
+
+// NewScriptHash returns the script hash of a locking script.
+func NewScriptHash(lockingScript []byte) chainhash.Hash {
+	return sha256.Sum256(lockingScript)
+}
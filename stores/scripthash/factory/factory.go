@@ -0,0 +1,40 @@
+// Package factory provides a factory for creating script hash index store implementations.
+package factory
+
+import (
+	"net/url"
+
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/settings"
+	"github.com/bsv-blockchain/teranode/stores/scripthash"
+	"github.com/bsv-blockchain/teranode/stores/scripthash/sql"
+	"github.com/bsv-blockchain/teranode/ulogger"
+)
+
+// NewStore creates a new script hash index store based on the provided URL scheme.
+//
+// Supported schemes are:
+// - postgres: PostgreSQL database backend for production deployments
+// - sqlitememory: In-memory SQLite database for testing and development
+// - sqlite: File-based SQLite database for persistent storage in testing/development
+//
+// Parameters:
+//   - logger: Logger instance for store operations and diagnostics
+//   - storeURL: URL containing the store configuration (connection parameters, credentials)
+//   - tSettings: Teranode settings that control store behavior
+//
+// Returns:
+//   - Store: A new store instance implementing the scripthash.Store interface
+//   - error: Any error encountered during creation
+func NewStore(logger ulogger.Logger, storeURL *url.URL, tSettings *settings.Settings) (scripthash.Store, error) {
+	switch storeURL.Scheme {
+	case "postgres":
+		fallthrough
+	case "sqlitememory":
+		fallthrough
+	case "sqlite":
+		return sql.New(logger, storeURL, tSettings)
+	}
+
+	return nil, errors.NewStorageError("scripthash: unknown scheme: %s", storeURL.Scheme)
+}
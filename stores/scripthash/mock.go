@@ -0,0 +1,88 @@
+// Package scripthash provides mock implementations for testing script hash index functionality.
+// This file contains a mock object that implements the Store interface using testify/mock.
+package scripthash
+
+import (
+	"context"
+
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock provides a mock implementation of the Store interface for testing purposes.
+//
+// Usage:
+//
+//	mockStore := &Mock{}
+//	mockStore.On("GetBalance", mock.Anything, mock.Anything).Return(&Balance{}, nil)
+//	// Use mockStore in place of real Store implementation
+//	mockStore.AssertExpectations(t)
+type Mock struct {
+	mock.Mock
+}
+
+var _ Store = (*Mock)(nil)
+
+// Health mocks the health check functionality of the store.
+func (m *Mock) Health(ctx context.Context, checkLiveness bool) (int, string, error) {
+	args := m.Called(ctx, checkLiveness)
+	return args.Int(0), args.String(1), args.Error(2)
+}
+
+// GetTip mocks the retrieval of the tip of the index.
+func (m *Mock) GetTip(ctx context.Context) (*chainhash.Hash, uint32, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+
+	return args.Get(0).(*chainhash.Hash), args.Get(1).(uint32), args.Error(2)
+}
+
+// IndexBlock mocks the indexing of a block.
+func (m *Mock) IndexBlock(ctx context.Context, block *Block) error {
+	args := m.Called(ctx, block)
+	return args.Error(0)
+}
+
+// UnwindBlock mocks the unwinding of the block at the tip of the index.
+func (m *Mock) UnwindBlock(ctx context.Context, blockHash *chainhash.Hash) error {
+	args := m.Called(ctx, blockHash)
+	return args.Error(0)
+}
+
+// GetHistory mocks the retrieval of the history of a script hash.
+func (m *Mock) GetHistory(ctx context.Context, scriptHash *chainhash.Hash, skip, limit int, reverse bool) ([]*HistoryItem, error) {
+	args := m.Called(ctx, scriptHash, skip, limit, reverse)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]*HistoryItem), args.Error(1)
+}
+
+// GetUnspent mocks the retrieval of the unspent outputs of a script hash.
+func (m *Mock) GetUnspent(ctx context.Context, scriptHash *chainhash.Hash) ([]*Output, error) {
+	args := m.Called(ctx, scriptHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]*Output), args.Error(1)
+}
+
+// GetBalance mocks the retrieval of the balance of a script hash.
+func (m *Mock) GetBalance(ctx context.Context, scriptHash *chainhash.Hash) (*Balance, error) {
+	args := m.Called(ctx, scriptHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*Balance), args.Error(1)
+}
+
+// Close mocks closing the store.
+func (m *Mock) Close() error {
+	args := m.Called()
+	return args.Error(0)
+}
@@ -0,0 +1,447 @@
+// Package sql implements the scripthash.Store interface using SQL database backends, with support for
+// PostgreSQL and SQLite.
+//
+// The index is held in two tables:
+// - scripthash_outputs: One row per indexed output, keyed by outpoint, with the script hash of its locking
+// script and the input spending it, if any
+// - scripthash_blocks: One row per indexed block, keyed by height, used to track the tip of the index
+//
+// The history of a script hash is derived from the outputs locked to it: the transactions that created
+// them and the transactions that spent them.
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/settings"
+	"github.com/bsv-blockchain/teranode/stores/scripthash"
+	"github.com/bsv-blockchain/teranode/ulogger"
+	"github.com/bsv-blockchain/teranode/util"
+	"github.com/bsv-blockchain/teranode/util/usql"
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
+
+// Store implements the scripthash.Store interface using SQL database backends.
+type Store struct {
+	// db is the underlying SQL database connection pool
+	db *usql.DB
+	// engine identifies which SQL engine is being used (PostgreSQL, SQLite, etc.)
+	engine util.SQLEngine
+	// logger provides structured logging capabilities
+	logger ulogger.Logger
+}
+
+// New creates and initializes a new SQL script hash index store.
+// It establishes a database connection based on the provided URL and creates the schema for the
+// selected SQL engine, if it does not exist yet.
+//
+// Parameters:
+//   - logger: Logger instance for recording operational events and errors
+//   - storeURL: URL containing connection parameters and engine selection
+//   - tSettings: Application settings used for the database connection
+//
+// Returns:
+//   - *Store: Initialized SQL store instance
+//   - error: Any error encountered during initialization, wrapped as StorageError
+func New(logger ulogger.Logger, storeURL *url.URL, tSettings *settings.Settings) (*Store, error) {
+	logger = logger.New("shsql")
+
+	db, err := util.InitSQLDB(logger, storeURL, tSettings)
+	if err != nil {
+		return nil, errors.NewStorageError("failed to init sql db", err)
+	}
+
+	switch util.SQLEngine(storeURL.Scheme) {
+	case util.Postgres:
+		if err = createSchema(db, "BYTEA"); err != nil {
+			return nil, errors.NewStorageError("failed to create postgres schema", err)
+		}
+
+	case util.Sqlite, util.SqliteMemory:
+		if err = createSchema(db, "BLOB"); err != nil {
+			return nil, errors.NewStorageError("failed to create sqlite schema", err)
+		}
+
+	default:
+		return nil, errors.NewStorageError("unknown database engine: %s", storeURL.Scheme)
+	}
+
+	return &Store{
+		db:     db,
+		engine: util.SQLEngine(storeURL.Scheme),
+		logger: logger,
+	}, nil
+}
+
+// createSchema creates the tables and indexes of the store. The schema is the same for all engines,
+// except for the type of the binary columns.
+func createSchema(db *usql.DB, bytesType string) error {
+	if _, err := db.Exec(fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS scripthash_outputs (
+		 script_hash      %[1]s NOT NULL
+		,tx_id            %[1]s NOT NULL
+		,vout             BIGINT NOT NULL
+		,height           BIGINT NOT NULL
+		,tx_pos           BIGINT NOT NULL
+		,satoshis         BIGINT NOT NULL
+		,spending_tx_id   %[1]s NULL
+		,spending_vin     BIGINT NULL
+		,spending_height  BIGINT NULL
+		,spending_tx_pos  BIGINT NULL
+		,PRIMARY KEY (tx_id, vout)
+		);
+	`, bytesType)); err != nil {
+		_ = db.Close()
+		return errors.NewStorageError("could not create scripthash_outputs table", err)
+	}
+
+	if _, err := db.Exec(fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS scripthash_blocks (
+		 height  BIGINT PRIMARY KEY
+		,hash    %[1]s NOT NULL
+		);
+	`, bytesType)); err != nil {
+		_ = db.Close()
+		return errors.NewStorageError("could not create scripthash_blocks table", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_scripthash_outputs_script_hash ON scripthash_outputs (script_hash, height, tx_pos);`); err != nil {
+		_ = db.Close()
+		return errors.NewStorageError("could not create idx_scripthash_outputs_script_hash index", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_scripthash_outputs_height ON scripthash_outputs (height);`); err != nil {
+		_ = db.Close()
+		return errors.NewStorageError("could not create idx_scripthash_outputs_height index", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_scripthash_outputs_spending_height ON scripthash_outputs (spending_height) WHERE spending_height IS NOT NULL;`); err != nil {
+		_ = db.Close()
+		return errors.NewStorageError("could not create idx_scripthash_outputs_spending_height index", err)
+	}
+
+	return nil
+}
+
+// Health checks whether the database connection is alive.
+func (s *Store) Health(ctx context.Context, _ bool) (int, string, error) {
+	if err := s.db.PingContext(ctx); err != nil {
+		return http.StatusFailedDependency, "Database connection error", err
+	}
+
+	return http.StatusOK, "OK", nil
+}
+
+// Close closes the database connection.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// GetTip returns the hash and height of the last indexed block.
+// Returns a not found error when no block has been indexed.
+func (s *Store) GetTip(ctx context.Context) (*chainhash.Hash, uint32, error) {
+	q := `
+		SELECT
+		 hash
+		,height
+		FROM scripthash_blocks
+		ORDER BY height DESC
+		LIMIT 1
+	`
+
+	var (
+		hashBytes []byte
+		height    uint32
+	)
+
+	if err := s.db.QueryRowContext(ctx, q).Scan(&hashBytes, &height); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, 0, errors.NewNotFoundError("no block has been indexed")
+		}
+
+		return nil, 0, errors.NewStorageError("failed to get tip of the index", err)
+	}
+
+	hash, err := chainhash.NewHash(hashBytes)
+	if err != nil {
+		return nil, 0, errors.NewProcessingError("invalid block hash in the index", err)
+	}
+
+	return hash, height, nil
+}
+
+// IndexBlock adds the outputs of a block to the index, and marks the outputs spent by the block as spent,
+// in a single database transaction. The block must be the child of the current tip, if any.
+func (s *Store) IndexBlock(ctx context.Context, block *scripthash.Block) error {
+	_, tipHeight, err := s.GetTip(ctx)
+	if err != nil {
+		if !errors.Is(err, errors.ErrNotFound) {
+			return err
+		}
+	} else if block.Height != tipHeight+1 {
+		return errors.NewProcessingError("block %s at height %d is not the child of the tip at height %d", block.Hash, block.Height, tipHeight)
+	}
+
+	txn, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.NewStorageError("failed to begin transaction", err)
+	}
+
+	defer func() {
+		_ = txn.Rollback()
+	}()
+
+	// outputs created more than once (duplicate coinbase transactions) keep the first output
+	insertStmt, err := txn.PrepareContext(ctx, `
+		INSERT INTO scripthash_outputs (
+		 script_hash
+		,tx_id
+		,vout
+		,height
+		,tx_pos
+		,satoshis
+		) VALUES (
+		 $1
+		,$2
+		,$3
+		,$4
+		,$5
+		,$6
+		)
+		ON CONFLICT DO NOTHING
+	`)
+	if err != nil {
+		return errors.NewStorageError("failed to prepare insert statement", err)
+	}
+
+	defer insertStmt.Close()
+
+	for _, output := range block.Outputs {
+		if _, err = insertStmt.ExecContext(ctx, output.ScriptHash[:], output.TxID[:], output.Vout, block.Height, output.TxPos, output.Satoshis); err != nil {
+			return errors.NewStorageError("failed to insert output %s:%d", output.TxID, output.Vout, err)
+		}
+	}
+
+	spendStmt, err := txn.PrepareContext(ctx, `
+		UPDATE scripthash_outputs
+		SET
+		 spending_tx_id = $1
+		,spending_vin = $2
+		,spending_height = $3
+		,spending_tx_pos = $4
+		WHERE tx_id = $5
+		AND vout = $6
+	`)
+	if err != nil {
+		return errors.NewStorageError("failed to prepare spend statement", err)
+	}
+
+	defer spendStmt.Close()
+
+	for _, spend := range block.Spends {
+		if _, err = spendStmt.ExecContext(ctx, spend.SpendingTxID[:], spend.SpendingVin, block.Height, spend.SpendingTxPos, spend.TxID[:], spend.Vout); err != nil {
+			return errors.NewStorageError("failed to mark output %s:%d as spent", spend.TxID, spend.Vout, err)
+		}
+	}
+
+	if _, err = txn.ExecContext(ctx, `INSERT INTO scripthash_blocks (height, hash) VALUES ($1, $2)`, block.Height, block.Hash[:]); err != nil {
+		return errors.NewStorageError("failed to insert block %s", block.Hash, err)
+	}
+
+	if err = txn.Commit(); err != nil {
+		return errors.NewStorageError("failed to commit block %s", block.Hash, err)
+	}
+
+	return nil
+}
+
+// UnwindBlock removes the block at the tip of the index, which must have the given hash. The outputs created
+// by the block are removed, and the outputs spent by the block are marked as unspent again.
+func (s *Store) UnwindBlock(ctx context.Context, blockHash *chainhash.Hash) error {
+	tipHash, tipHeight, err := s.GetTip(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !tipHash.IsEqual(blockHash) {
+		return errors.NewProcessingError("block %s is not the tip of the index, the tip is %s", blockHash, tipHash)
+	}
+
+	txn, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.NewStorageError("failed to begin transaction", err)
+	}
+
+	defer func() {
+		_ = txn.Rollback()
+	}()
+
+	if _, err = txn.ExecContext(ctx, `DELETE FROM scripthash_outputs WHERE height = $1`, tipHeight); err != nil {
+		return errors.NewStorageError("failed to delete outputs of block %s", blockHash, err)
+	}
+
+	if _, err = txn.ExecContext(ctx, `
+		UPDATE scripthash_outputs
+		SET
+		 spending_tx_id = NULL
+		,spending_vin = NULL
+		,spending_height = NULL
+		,spending_tx_pos = NULL
+		WHERE spending_height = $1
+	`, tipHeight); err != nil {
+		return errors.NewStorageError("failed to unspend outputs spent by block %s", blockHash, err)
+	}
+
+	if _, err = txn.ExecContext(ctx, `DELETE FROM scripthash_blocks WHERE height = $1`, tipHeight); err != nil {
+		return errors.NewStorageError("failed to delete block %s", blockHash, err)
+	}
+
+	if err = txn.Commit(); err != nil {
+		return errors.NewStorageError("failed to commit unwinding of block %s", blockHash, err)
+	}
+
+	return nil
+}
+
+// GetHistory returns the transactions that created or spent the outputs locked to a script hash, in
+// blockchain order, or in reverse blockchain order when reverse is set.
+func (s *Store) GetHistory(ctx context.Context, scriptHash *chainhash.Hash, skip, limit int, reverse bool) ([]*scripthash.HistoryItem, error) {
+	order := "ASC"
+	if reverse {
+		order = "DESC"
+	}
+
+	q := fmt.Sprintf(`
+		SELECT tx_id, height, tx_pos
+		FROM scripthash_outputs
+		WHERE script_hash = $1
+		UNION
+		SELECT spending_tx_id, spending_height, spending_tx_pos
+		FROM scripthash_outputs
+		WHERE script_hash = $2
+		AND spending_tx_id IS NOT NULL
+		ORDER BY height %[1]s, tx_pos %[1]s
+	`, order)
+
+	args := []interface{}{scriptHash[:], scriptHash[:]}
+
+	switch {
+	case limit > 0:
+		q += ` LIMIT $3 OFFSET $4`
+
+		args = append(args, limit, skip)
+	case skip > 0:
+		if s.engine == util.Postgres {
+			q += ` OFFSET $3`
+		} else {
+			q += ` LIMIT -1 OFFSET $3`
+		}
+
+		args = append(args, skip)
+	}
+
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, errors.NewStorageError("failed to get history of %s", scriptHash, err)
+	}
+
+	defer rows.Close()
+
+	history := make([]*scripthash.HistoryItem, 0)
+
+	for rows.Next() {
+		var (
+			txIDBytes []byte
+			item      = &scripthash.HistoryItem{}
+		)
+
+		if err = rows.Scan(&txIDBytes, &item.Height, &item.TxPos); err != nil {
+			return nil, errors.NewStorageError("failed to scan history of %s", scriptHash, err)
+		}
+
+		copy(item.TxID[:], txIDBytes)
+
+		history = append(history, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.NewStorageError("failed to read history of %s", scriptHash, err)
+	}
+
+	return history, nil
+}
+
+// GetUnspent returns the unspent outputs locked to a script hash, in blockchain order.
+func (s *Store) GetUnspent(ctx context.Context, scriptHash *chainhash.Hash) ([]*scripthash.Output, error) {
+	q := `
+		SELECT
+		 tx_id
+		,vout
+		,height
+		,tx_pos
+		,satoshis
+		FROM scripthash_outputs
+		WHERE script_hash = $1
+		AND spending_tx_id IS NULL
+		ORDER BY height, tx_pos, vout
+	`
+
+	rows, err := s.db.QueryContext(ctx, q, scriptHash[:])
+	if err != nil {
+		return nil, errors.NewStorageError("failed to get unspent outputs of %s", scriptHash, err)
+	}
+
+	defer rows.Close()
+
+	outputs := make([]*scripthash.Output, 0)
+
+	for rows.Next() {
+		var (
+			txIDBytes []byte
+			output    = &scripthash.Output{ScriptHash: *scriptHash}
+		)
+
+		if err = rows.Scan(&txIDBytes, &output.Vout, &output.Height, &output.TxPos, &output.Satoshis); err != nil {
+			return nil, errors.NewStorageError("failed to scan unspent outputs of %s", scriptHash, err)
+		}
+
+		copy(output.TxID[:], txIDBytes)
+
+		outputs = append(outputs, output)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.NewStorageError("failed to read unspent outputs of %s", scriptHash, err)
+	}
+
+	return outputs, nil
+}
+
+// GetBalance returns the balance of a script hash.
+func (s *Store) GetBalance(ctx context.Context, scriptHash *chainhash.Hash) (*scripthash.Balance, error) {
+	q := `
+		SELECT
+		 COALESCE(SUM(satoshis), 0)
+		,COALESCE(SUM(CASE WHEN spending_tx_id IS NULL THEN satoshis ELSE 0 END), 0)
+		,COUNT(CASE WHEN spending_tx_id IS NULL THEN 1 END)
+		FROM scripthash_outputs
+		WHERE script_hash = $1
+	`
+
+	balance := &scripthash.Balance{}
+
+	if err := s.db.QueryRowContext(ctx, q, scriptHash[:]).Scan(&balance.Received, &balance.Confirmed, &balance.UnspentCount); err != nil {
+		return nil, errors.NewStorageError("failed to get balance of %s", scriptHash, err)
+	}
+
+	balance.Spent = balance.Received - balance.Confirmed
+
+	return balance, nil
+}
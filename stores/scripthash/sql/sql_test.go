@@ -0,0 +1,145 @@
+package sql
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/stores/scripthash"
+	"github.com/bsv-blockchain/teranode/ulogger"
+	"github.com/bsv-blockchain/teranode/util/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(t *testing.T) *Store {
+	tSettings := test.CreateBaseTestSettings(t)
+
+	storeURL, err := url.Parse("sqlitememory:///")
+	require.NoError(t, err)
+
+	s, err := New(ulogger.TestLogger{}, storeURL, tSettings)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = s.Close()
+	})
+
+	return s
+}
+
+func TestGetTip_Empty(t *testing.T) {
+	s := newTestStore(t)
+
+	_, _, err := s.GetTip(context.Background())
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errors.ErrNotFound))
+}
+
+func TestIndexAndUnwindBlock(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	scriptHashA := scripthash.NewScriptHash([]byte("script A"))
+	scriptHashB := scripthash.NewScriptHash([]byte("script B"))
+
+	tx1 := chainhash.DoubleHashH([]byte("tx1"))
+	tx2 := chainhash.DoubleHashH([]byte("tx2"))
+	tx3 := chainhash.DoubleHashH([]byte("tx3"))
+
+	block1 := &scripthash.Block{
+		Hash:   chainhash.DoubleHashH([]byte("block1")),
+		Height: 1,
+		Outputs: []*scripthash.Output{
+			{ScriptHash: scriptHashA, TxID: tx1, Vout: 0, TxPos: 0, Satoshis: 5_000},
+			{ScriptHash: scriptHashA, TxID: tx1, Vout: 1, TxPos: 0, Satoshis: 1_000},
+		},
+	}
+
+	// tx2 spends the first output of tx1 to script B, tx3 spends the output of tx2 back to script A
+	block2 := &scripthash.Block{
+		Hash:   chainhash.DoubleHashH([]byte("block2")),
+		Height: 2,
+		Outputs: []*scripthash.Output{
+			{ScriptHash: scriptHashB, TxID: tx2, Vout: 0, TxPos: 1, Satoshis: 4_900},
+			{ScriptHash: scriptHashA, TxID: tx3, Vout: 0, TxPos: 2, Satoshis: 4_800},
+		},
+		Spends: []*scripthash.Spend{
+			{TxID: tx1, Vout: 0, SpendingTxID: tx2, SpendingVin: 0, SpendingTxPos: 1},
+			{TxID: tx2, Vout: 0, SpendingTxID: tx3, SpendingVin: 0, SpendingTxPos: 2},
+		},
+	}
+
+	require.NoError(t, s.IndexBlock(ctx, block1))
+
+	// a block that is not the child of the tip cannot be indexed
+	err := s.IndexBlock(ctx, &scripthash.Block{Hash: chainhash.DoubleHashH([]byte("block3")), Height: 3})
+	require.Error(t, err)
+
+	require.NoError(t, s.IndexBlock(ctx, block2))
+
+	tipHash, tipHeight, err := s.GetTip(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, block2.Hash, *tipHash)
+	assert.Equal(t, uint32(2), tipHeight)
+
+	history, err := s.GetHistory(ctx, &scriptHashA, 0, 0, false)
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	assert.Equal(t, tx1, history[0].TxID)
+	assert.Equal(t, uint32(1), history[0].Height)
+	assert.Equal(t, tx2, history[1].TxID)
+	assert.Equal(t, uint32(2), history[1].Height)
+	assert.Equal(t, tx3, history[2].TxID)
+
+	history, err = s.GetHistory(ctx, &scriptHashA, 1, 1, true)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, tx2, history[0].TxID)
+
+	history, err = s.GetHistory(ctx, &scriptHashA, 2, 0, false)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, tx3, history[0].TxID)
+
+	unspent, err := s.GetUnspent(ctx, &scriptHashA)
+	require.NoError(t, err)
+	require.Len(t, unspent, 2)
+	assert.Equal(t, tx1, unspent[0].TxID)
+	assert.Equal(t, uint32(1), unspent[0].Vout)
+	assert.Equal(t, tx3, unspent[1].TxID)
+	assert.Equal(t, uint64(4_800), unspent[1].Satoshis)
+
+	balance, err := s.GetBalance(ctx, &scriptHashA)
+	require.NoError(t, err)
+	assert.Equal(t, &scripthash.Balance{Confirmed: 5_800, Received: 10_800, Spent: 5_000, UnspentCount: 2}, balance)
+
+	balance, err = s.GetBalance(ctx, &scriptHashB)
+	require.NoError(t, err)
+	assert.Equal(t, &scripthash.Balance{Confirmed: 0, Received: 4_900, Spent: 4_900, UnspentCount: 0}, balance)
+
+	// only the tip can be unwound
+	require.Error(t, s.UnwindBlock(ctx, &block1.Hash))
+
+	require.NoError(t, s.UnwindBlock(ctx, &block2.Hash))
+
+	tipHash, tipHeight, err = s.GetTip(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, block1.Hash, *tipHash)
+	assert.Equal(t, uint32(1), tipHeight)
+
+	history, err = s.GetHistory(ctx, &scriptHashA, 0, 0, false)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, tx1, history[0].TxID)
+
+	history, err = s.GetHistory(ctx, &scriptHashB, 0, 0, false)
+	require.NoError(t, err)
+	assert.Empty(t, history)
+
+	balance, err = s.GetBalance(ctx, &scriptHashA)
+	require.NoError(t, err)
+	assert.Equal(t, &scripthash.Balance{Confirmed: 6_000, Received: 6_000, Spent: 0, UnspentCount: 2}, balance)
+}
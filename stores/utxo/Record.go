@@ -0,0 +1,120 @@
+// Package utxo provides UTXO (Unspent Transaction Output) management for the Bitcoin SV Teranode implementation.
+//
+// This file defines the Record struct, the complete state of a transaction in a UTXO store, and the
+// RecordExporter and RecordImporter interfaces the stores implement to copy their records to another store.
+package utxo
+
+import (
+	"context"
+
+	"github.com/bsv-blockchain/go-bt/v2"
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/bsv-blockchain/teranode/stores/utxo/spend"
+)
+
+// Record is the complete state of a transaction in a UTXO store, independent of the backend it is stored in.
+// Unlike meta.Data it holds all the state needed to recreate the transaction in another store, bypassing
+// the validation of Create and Spend.
+type Record struct {
+	// TxID is the id of the transaction, which is not the hash of Tx for partial transactions
+	TxID chainhash.Hash
+
+	// Tx is the extended transaction, partial transactions created from a UTXO set have no inputs
+	Tx *bt.Tx
+
+	// Fee is the fee paid by the transaction
+	Fee uint64
+
+	// SizeInBytes is the size of the transaction in bytes
+	SizeInBytes uint64
+
+	// IsCoinbase indicates whether the transaction is a coinbase transaction
+	IsCoinbase bool
+
+	// SpendingHeight is the height from which the outputs of a coinbase transaction can be spent
+	SpendingHeight uint32
+
+	// Frozen indicates whether all the outputs of the transaction are frozen
+	Frozen bool
+
+	// Conflicting indicates whether the transaction is conflicting
+	Conflicting bool
+
+	// Locked indicates whether the outputs of the transaction are locked for spending
+	Locked bool
+
+	// UnminedSince is the height the transaction was first seen at while not mined on the longest chain
+	UnminedSince uint32
+
+	// DeleteAtHeight is the height at which the transaction is deleted, 0 if not set
+	DeleteAtHeight uint32
+
+	// PreserveUntil is the height until which the transaction is preserved from deletion, 0 if not set
+	PreserveUntil uint32
+
+	// CreatedAt is the time the transaction was stored, in milliseconds since the epoch
+	CreatedAt int
+
+	// BlockIDs, BlockHeights and SubtreeIdxs describe the blocks the transaction was mined in, with the
+	// same index in each slice
+	BlockIDs     []uint32
+	BlockHeights []uint32
+	SubtreeIdxs  []int
+
+	// ConflictingChildren are the conflicting transactions spending the outputs of the transaction
+	ConflictingChildren []chainhash.Hash
+
+	// Utxos holds the state of the outputs of the transaction by output index, nil for the outputs that
+	// are not stored as UTXOs
+	Utxos []*RecordUtxo
+}
+
+// RecordUtxo is the state of an output of a transaction in a UTXO store.
+type RecordUtxo struct {
+	// UTXOHash is the unique identifier of the UTXO, which differs from the hash of the output after
+	// the UTXO has been reassigned
+	UTXOHash chainhash.Hash
+
+	// SpendingData identifies the transaction spending the UTXO, nil if the UTXO is unspent
+	SpendingData *spend.SpendingData
+
+	// Frozen indicates whether the UTXO has been frozen by the alert system
+	Frozen bool
+
+	// SpendableIn is the height from which a reassigned UTXO can be spent, 0 if not set
+	SpendableIn uint32
+}
+
+// IsUnmined returns whether the transaction is not mined on the longest chain.
+// A transaction that is in no block is unmined, even when it was stored at height 0.
+func (r *Record) IsUnmined() bool {
+	return r.UnminedSince > 0 || len(r.BlockIDs) == 0
+}
+
+// RecordIterator iterates over the records of a UTXO store.
+type RecordIterator interface {
+	// Next returns the next record, or nil when the iteration is done.
+	Next(ctx context.Context) (*Record, error)
+	// Cursor returns the position of the iterator after the last record returned by Next. Exporting from
+	// the cursor resumes the iteration, possibly returning some of the records before the cursor again.
+	Cursor() string
+	// Close releases the resources held by the iterator.
+	Close() error
+}
+
+// RecordExporter is implemented by the UTXO stores that can export their records, to migrate them to
+// another store.
+type RecordExporter interface {
+	// ExportRecords returns an iterator over all the records of the store, starting at the cursor of a
+	// previous iteration, or at the beginning when the cursor is empty.
+	ExportRecords(ctx context.Context, cursor string) (RecordIterator, error)
+	// ExportRecord returns the record of the transaction, or a TxNotFoundError.
+	ExportRecord(ctx context.Context, hash *chainhash.Hash) (*Record, error)
+}
+
+// RecordImporter is implemented by the UTXO stores that can import records exported from another store.
+type RecordImporter interface {
+	// ImportRecords stores the records, replacing the transactions that already exist, so importing the
+	// same records again has no effect.
+	ImportRecords(ctx context.Context, records []*Record) error
+}
@@ -0,0 +1,658 @@
+package aerospike
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+
+	"github.com/aerospike/aerospike-client-go/v8"
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	safeconversion "github.com/bsv-blockchain/go-safe-conversion"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+	"github.com/bsv-blockchain/teranode/services/utxopersister"
+	"github.com/bsv-blockchain/teranode/stores/utxo"
+	"github.com/bsv-blockchain/teranode/stores/utxo/fields"
+	spendpkg "github.com/bsv-blockchain/teranode/stores/utxo/spend"
+	"github.com/bsv-blockchain/teranode/util"
+	"github.com/bsv-blockchain/teranode/util/uaerospike"
+)
+
+// Ensure Store implements the record export and import interfaces
+var (
+	_ utxo.RecordExporter = (*Store)(nil)
+	_ utxo.RecordImporter = (*Store)(nil)
+)
+
+// exportPartitions is the number of partitions of an Aerospike namespace, the record iterator queries the
+// partitions one at a time
+const exportPartitions = 4096
+
+// lastSpentStateAllSpent is the value of the lastSpentState bin of an extra record of which all the utxos
+// are spent, see teranode.lua
+const lastSpentStateAllSpent = "ALLSPENT"
+
+// recordIterator implements utxo.RecordIterator for Aerospike.
+// It queries the partitions of the namespace one at a time and returns the master records, reading the utxos
+// of the extra records of large transactions. The cursor is the partition being read, so resuming re-reads
+// the records of that partition.
+type recordIterator struct {
+	store     *Store
+	partition int
+	recordset *aerospike.Recordset
+	result    <-chan *aerospike.Result
+	err       error
+	done      bool
+}
+
+// ExportRecords returns an iterator over all the transactions of the store, starting at the partition of the
+// cursor.
+func (s *Store) ExportRecords(_ context.Context, cursor string) (utxo.RecordIterator, error) {
+	it := &recordIterator{
+		store: s,
+	}
+
+	if cursor != "" {
+		partition, err := strconv.Atoi(cursor)
+		if err != nil || partition < 0 || partition > exportPartitions {
+			return nil, errors.NewInvalidArgumentError("invalid export cursor %q", cursor)
+		}
+
+		it.partition = partition
+	}
+
+	return it, nil
+}
+
+func (it *recordIterator) Next(ctx context.Context) (*utxo.Record, error) {
+	for {
+		if it.done || it.err != nil {
+			return nil, it.err
+		}
+
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if it.recordset == nil {
+			if it.partition >= exportPartitions {
+				it.done = true
+				return nil, nil
+			}
+
+			if err := it.queryPartition(); err != nil {
+				it.err = err
+				return nil, err
+			}
+		}
+
+		res, ok := <-it.result
+		if !ok || res == nil {
+			// the partition has been read, continue with the next one
+			_ = it.recordset.Close()
+			it.recordset = nil
+			it.partition++
+
+			continue
+		}
+
+		if res.Err != nil {
+			it.err = errors.NewStorageError("failed to read partition %d", it.partition, res.Err)
+			_ = it.Close()
+
+			return nil, it.err
+		}
+
+		bins := res.Record.Bins
+
+		// only master records have the total extra recs bin, extra records and lock records are skipped
+		if _, ok = bins[fields.TotalExtraRecs.String()].(int); !ok {
+			continue
+		}
+
+		if creating, _ := bins[fields.Creating.String()].(bool); creating {
+			txID, _ := bins[fields.TxID.String()].([]byte)
+			it.store.logger.Warnf("[ExportRecords] skipping transaction %x that is still being created", txID)
+
+			continue
+		}
+
+		record, err := it.store.recordFromBins(ctx, bins)
+		if err != nil {
+			it.err = err
+			_ = it.Close()
+
+			return nil, err
+		}
+
+		return record, nil
+	}
+}
+
+// queryPartition starts the query of the records of the current partition
+func (it *recordIterator) queryPartition() error {
+	stmt := aerospike.NewStatement(it.store.namespace, it.store.setName)
+
+	policy := aerospike.NewQueryPolicy()
+	policy.MaxRetries = 1
+	policy.IncludeBinData = true
+
+	recordset, err := it.store.client.QueryPartitions(policy, stmt, aerospike.NewPartitionFilterById(it.partition))
+	if err != nil {
+		return errors.NewStorageError("failed to query partition %d", it.partition, err)
+	}
+
+	it.recordset = recordset
+	it.result = recordset.Results()
+
+	return nil
+}
+
+func (it *recordIterator) Cursor() string {
+	return strconv.Itoa(it.partition)
+}
+
+func (it *recordIterator) Close() error {
+	it.done = true
+
+	if it.recordset != nil {
+		err := it.recordset.Close()
+		it.recordset = nil
+
+		return err
+	}
+
+	return nil
+}
+
+// ExportRecord returns the record of the transaction.
+func (s *Store) ExportRecord(ctx context.Context, hash *chainhash.Hash) (*utxo.Record, error) {
+	key, err := aerospike.NewKey(s.namespace, s.setName, hash[:])
+	if err != nil {
+		return nil, errors.NewProcessingError("failed to create key for %s", hash, err)
+	}
+
+	rec, aErr := s.client.Get(util.GetAerospikeReadPolicy(s.settings), key)
+	if aErr != nil {
+		if errors.Is(aErr, aerospike.ErrKeyNotFound) {
+			return nil, errors.NewTxNotFoundError("transaction %s not found", hash, aErr)
+		}
+
+		return nil, errors.NewStorageError("failed to read transaction %s", hash, aErr)
+	}
+
+	return s.recordFromBins(ctx, rec.Bins)
+}
+
+// recordFromBins converts the bins of a master record, and the utxos of its extra records, to a record
+func (s *Store) recordFromBins(ctx context.Context, bins aerospike.BinMap) (*utxo.Record, error) {
+	txIDBytes, ok := bins[fields.TxID.String()].([]byte)
+	if !ok || len(txIDBytes) != chainhash.HashSize {
+		return nil, errors.NewProcessingError("record has no valid tx id")
+	}
+
+	record := &utxo.Record{
+		TxID: chainhash.Hash(txIDBytes),
+	}
+
+	var err error
+
+	if external, _ := bins[fields.External.String()].(bool); external {
+		if record.Tx, err = s.getExternalTransaction(ctx, record.TxID); err != nil {
+			return nil, err
+		}
+	} else if record.Tx, err = s.getTxFromBins(bins); err != nil {
+		return nil, errors.NewProcessingError("invalid transaction %s", record.TxID, err)
+	}
+
+	record.Fee = uint64(binInt(bins, fields.Fee))
+	record.SizeInBytes = uint64(binInt(bins, fields.SizeInBytes))
+	record.IsCoinbase, _ = bins[fields.IsCoinbase.String()].(bool)
+	record.SpendingHeight = binUint32(bins, fields.SpendingHeight)
+	record.Conflicting, _ = bins[fields.Conflicting.String()].(bool)
+	record.Locked, _ = bins[fields.Locked.String()].(bool)
+	record.UnminedSince = binUint32(bins, fields.UnminedSince)
+	record.DeleteAtHeight = binUint32(bins, fields.DeleteAtHeight)
+	record.PreserveUntil = binUint32(bins, fields.PreserveUntil)
+	record.CreatedAt, _ = bins[fields.CreatedAt.String()].(int)
+
+	if record.BlockIDs, err = processBlockIDs(bins); err != nil {
+		return nil, errors.NewProcessingError("invalid block ids of %s", record.TxID, err)
+	}
+
+	if len(record.BlockIDs) > 0 {
+		if record.BlockHeights, err = processBlockHeights(bins); err != nil {
+			return nil, errors.NewProcessingError("invalid block heights of %s", record.TxID, err)
+		}
+
+		if record.SubtreeIdxs, err = processSubtreeIdxs(bins); err != nil {
+			return nil, errors.NewProcessingError("invalid subtree idxs of %s", record.TxID, err)
+		}
+	} else {
+		record.BlockIDs = nil
+	}
+
+	if record.ConflictingChildren, err = processConflictingChildren(bins); err != nil {
+		return nil, errors.NewProcessingError("invalid conflicting children of %s", record.TxID, err)
+	}
+
+	totalUtxos, _ := bins[fields.TotalUtxos.String()].(int)
+
+	// the outputs of partial transactions are not padded up to the last output
+	for len(record.Tx.Outputs) < totalUtxos {
+		record.Tx.Outputs = append(record.Tx.Outputs, nil)
+	}
+
+	record.Utxos = make([]*utxo.RecordUtxo, totalUtxos)
+
+	if err = s.addRecordUtxos(record, 0, bins); err != nil {
+		return nil, err
+	}
+
+	totalExtraRecs, _ := bins[fields.TotalExtraRecs.String()].(int)
+
+	for recordNum := 1; recordNum <= totalExtraRecs; recordNum++ {
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		key, aErr := aerospike.NewKey(s.namespace, s.setName, uaerospike.CalculateKeySourceInternal(&record.TxID, uint32(recordNum))) // nolint: gosec
+		if aErr != nil {
+			return nil, errors.NewProcessingError("failed to create key for extra record", aErr)
+		}
+
+		extraRecord, aErr := s.client.Get(util.GetAerospikeReadPolicy(s.settings), key, fields.Utxos.String(), fields.UtxoSpendableIn.String())
+		if aErr != nil {
+			return nil, errors.NewStorageError("failed to read extra record %d of %s", recordNum, record.TxID, aErr)
+		}
+
+		if err = s.addRecordUtxos(record, recordNum*s.utxoBatchSize, extraRecord.Bins); err != nil {
+			return nil, err
+		}
+	}
+
+	return record, nil
+}
+
+// addRecordUtxos adds the utxos of a master or extra record to the record, starting at the output index of
+// the first utxo of the aerospike record
+func (s *Store) addRecordUtxos(record *utxo.Record, baseOffset int, bins aerospike.BinMap) error {
+	utxos, _ := bins[fields.Utxos.String()].([]interface{})
+	spendableIn, _ := bins[fields.UtxoSpendableIn.String()].(map[interface{}]interface{})
+
+	for offset, ui := range utxos {
+		u, ok := ui.([]byte)
+		if !ok {
+			continue
+		}
+
+		idx := baseOffset + offset
+		if idx >= len(record.Utxos) {
+			return errors.NewProcessingError("utxo %d of %s is out of range", idx, record.TxID)
+		}
+
+		if len(u) != 32 && len(u) != 68 {
+			return errors.NewProcessingError("utxo %d of %s has an invalid size", idx, record.TxID)
+		}
+
+		recordUtxo := &utxo.RecordUtxo{
+			UTXOHash: chainhash.Hash(u[:32]),
+		}
+
+		if len(u) == 68 {
+			if bytes.Equal(u[32:], frozenUTXOBytes) {
+				recordUtxo.Frozen = true
+			} else {
+				spendingData, err := spendpkg.NewSpendingDataFromBytes(u[32:])
+				if err != nil {
+					return errors.NewProcessingError("invalid spending data of utxo %d of %s", idx, record.TxID, err)
+				}
+
+				recordUtxo.SpendingData = spendingData
+			}
+		}
+
+		if height, ok := spendableIn[offset].(int); ok && height > 0 {
+			recordUtxo.SpendableIn = uint32(height) // nolint: gosec
+		}
+
+		record.Utxos[idx] = recordUtxo
+	}
+
+	return nil
+}
+
+// ImportRecords stores the records, replacing all the aerospike records of the transactions that already exist.
+// The extra records of large transactions are written before the master records, so an interrupted import
+// never leaves a master record pointing at missing extra records.
+func (s *Store) ImportRecords(ctx context.Context, records []*utxo.Record) error {
+	batchWritePolicy := util.GetAerospikeBatchWritePolicy(s.settings)
+	batchWritePolicy.RecordExistsAction = aerospike.REPLACE
+
+	masterRecords := make([]aerospike.BatchRecordIfc, 0, len(records))
+	extraRecords := make([]aerospike.BatchRecordIfc, 0)
+
+	for _, record := range records {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		binsToStore, err := s.getRecordBinsToStore(ctx, record)
+		if err != nil {
+			return err
+		}
+
+		for idx, bins := range binsToStore {
+			key, err := aerospike.NewKey(s.namespace, s.setName, uaerospike.CalculateKeySourceInternal(&record.TxID, uint32(idx))) // nolint: gosec
+			if err != nil {
+				return errors.NewProcessingError("failed to create key %d for %s", idx, record.TxID, err)
+			}
+
+			putOps := make([]*aerospike.Operation, len(bins))
+			for i, bin := range bins {
+				putOps[i] = aerospike.PutOp(bin)
+			}
+
+			if idx == 0 {
+				masterRecords = append(masterRecords, aerospike.NewBatchWrite(batchWritePolicy, key, putOps...))
+			} else {
+				extraRecords = append(extraRecords, aerospike.NewBatchWrite(batchWritePolicy, key, putOps...))
+			}
+		}
+	}
+
+	for _, batchRecords := range [][]aerospike.BatchRecordIfc{extraRecords, masterRecords} {
+		if len(batchRecords) == 0 {
+			continue
+		}
+
+		if err := s.client.BatchOperate(util.GetAerospikeBatchPolicy(s.settings), batchRecords); err != nil {
+			return errors.NewStorageError("failed to import %d records", len(batchRecords), err)
+		}
+
+		for _, batchRecord := range batchRecords {
+			if err := batchRecord.BatchRec().Err; err != nil {
+				return errors.NewStorageError("failed to import record %v", batchRecord.BatchRec().Key, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// getRecordBinsToStore returns the bins of the master and extra records of the record, writing the
+// transaction to the external store if it is not stored in the master record
+func (s *Store) getRecordBinsToStore(ctx context.Context, record *utxo.Record) ([][]*aerospike.Bin, error) {
+	tx := record.Tx
+	if tx == nil || len(tx.Outputs) == 0 {
+		return nil, errors.NewProcessingError("record of transaction %s has no outputs", record.TxID)
+	}
+
+	external := s.settings.UtxoStore.ExternalizeAllTransactions
+
+	var extendedSize int
+
+	if len(tx.Inputs) == 0 {
+		for _, output := range tx.Outputs {
+			if output != nil {
+				extendedSize += len(output.Bytes())
+			}
+		}
+	} else {
+		extendedSize = len(tx.ExtendedBytes())
+	}
+
+	if extendedSize > MaxTxSizeInStoreInBytes {
+		external = true
+	}
+
+	binsToStore, err := s.GetBinsToStore(tx, record.UnminedSince, record.BlockIDs, record.BlockHeights, record.SubtreeIdxs, external,
+		&record.TxID, record.IsCoinbase, record.Conflicting, record.Locked)
+	if err != nil {
+		return nil, errors.NewProcessingError("could not get bins to store for %s", record.TxID, err)
+	}
+
+	if len(binsToStore) > 1 {
+		external = true
+	}
+
+	spentExtraRecs := 0
+
+	for idx := range binsToStore {
+		bins := binsToStore[idx]
+
+		recordUtxos, spentUtxos, err := s.setRecordUtxoBins(&bins, record, idx*s.utxoBatchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		bins = setBin(bins, fields.Fee.String(), aerospike.NewIntegerValue(int(record.Fee)))                 // nolint: gosec
+		bins = setBin(bins, fields.SizeInBytes.String(), aerospike.NewIntegerValue(int(record.SizeInBytes))) // nolint: gosec
+
+		if record.IsCoinbase {
+			bins = setBin(bins, fields.SpendingHeight.String(), aerospike.NewIntegerValue(int(record.SpendingHeight)))
+		}
+
+		if record.DeleteAtHeight > 0 {
+			bins = setBin(bins, fields.DeleteAtHeight.String(), aerospike.NewIntegerValue(int(record.DeleteAtHeight)))
+		}
+
+		if idx > 0 && recordUtxos == spentUtxos {
+			bins = setBin(bins, "lastSpentState", aerospike.NewStringValue(lastSpentStateAllSpent))
+			spentExtraRecs++
+		}
+
+		binsToStore[idx] = bins
+	}
+
+	master := binsToStore[0]
+	master = removeBin(master, fields.UnminedSince.String())
+
+	if record.IsUnmined() {
+		master = setBin(master, fields.UnminedSince.String(), aerospike.NewIntegerValue(int(record.UnminedSince)))
+	}
+
+	if spentExtraRecs > 0 {
+		master = setBin(master, fields.SpentExtraRecs.String(), aerospike.NewIntegerValue(spentExtraRecs))
+	}
+
+	if record.PreserveUntil > 0 {
+		master = setBin(master, fields.PreserveUntil.String(), aerospike.NewIntegerValue(int(record.PreserveUntil)))
+	}
+
+	if record.CreatedAt > 0 {
+		master = setBin(master, fields.CreatedAt.String(), aerospike.NewIntegerValue(record.CreatedAt))
+	}
+
+	if len(record.ConflictingChildren) > 0 {
+		children := make([]interface{}, len(record.ConflictingChildren))
+		for i := range record.ConflictingChildren {
+			children[i] = record.ConflictingChildren[i][:]
+		}
+
+		master = setBin(master, fields.ConflictingChildren.String(), aerospike.NewListValue(children))
+	}
+
+	binsToStore[0] = master
+
+	if external {
+		if err = s.storeRecordExternally(ctx, record); err != nil {
+			return nil, err
+		}
+	}
+
+	return binsToStore, nil
+}
+
+// setRecordUtxoBins replaces the utxo bins of the aerospike record holding the utxos starting at the output
+// index of baseOffset, returning the number of utxos and spent utxos of the aerospike record
+func (s *Store) setRecordUtxoBins(bins *[]*aerospike.Bin, record *utxo.Record, baseOffset int) (int, int, error) {
+	end := min(baseOffset+s.utxoBatchSize, len(record.Tx.Outputs))
+
+	utxos := make([]interface{}, end-baseOffset)
+	spendableIn := make(map[int]int)
+	recordUtxos := 0
+	spentUtxos := 0
+
+	for idx := baseOffset; idx < end; idx++ {
+		output := record.Tx.Outputs[idx]
+		if output == nil {
+			continue
+		}
+
+		var recordUtxo *utxo.RecordUtxo
+		if idx < len(record.Utxos) {
+			recordUtxo = record.Utxos[idx]
+		}
+
+		if recordUtxo == nil {
+			if !utxo.ShouldStoreOutputAsUTXO(record.IsCoinbase, output, 0) {
+				continue
+			}
+
+			utxoHash, err := util.UTXOHashFromOutput(&record.TxID, output, uint32(idx)) // nolint: gosec
+			if err != nil {
+				return 0, 0, err
+			}
+
+			recordUtxo = &utxo.RecordUtxo{UTXOHash: *utxoHash}
+		}
+
+		frozen := recordUtxo.Frozen || (record.Frozen && recordUtxo.SpendingData == nil)
+
+		// outputs that are not stored as utxos are kept when they have state of their own
+		if !utxo.ShouldStoreOutputAsUTXO(record.IsCoinbase, output, 0) && recordUtxo.SpendingData == nil && !frozen && recordUtxo.SpendableIn == 0 {
+			continue
+		}
+
+		u := make([]byte, 0, 68)
+		u = append(u, recordUtxo.UTXOHash[:]...)
+
+		switch {
+		case recordUtxo.SpendingData != nil:
+			u = append(u, recordUtxo.SpendingData.Bytes()...)
+			spentUtxos++
+		case frozen:
+			u = append(u, frozenUTXOBytes...)
+		}
+
+		utxos[idx-baseOffset] = u
+		recordUtxos++
+
+		if recordUtxo.SpendableIn > 0 {
+			spendableIn[idx-baseOffset] = int(recordUtxo.SpendableIn)
+
+			// reassigned utxos are counted twice so the record is never deleted, see teranode.lua
+			recordUtxos++
+		}
+	}
+
+	*bins = setBin(*bins, fields.Utxos.String(), aerospike.NewListValue(utxos))
+	*bins = setBin(*bins, fields.RecordUtxos.String(), aerospike.NewIntegerValue(recordUtxos))
+	*bins = setBin(*bins, fields.SpentUtxos.String(), aerospike.NewIntegerValue(spentUtxos))
+
+	if len(spendableIn) > 0 {
+		*bins = setBin(*bins, fields.UtxoSpendableIn.String(), spendableIn)
+	}
+
+	return recordUtxos, spentUtxos, nil
+}
+
+// storeRecordExternally writes the transaction of the record to the external store, with the DAH or
+// preservation of the record
+func (s *Store) storeRecordExternally(ctx context.Context, record *utxo.Record) error {
+	tx := record.Tx
+
+	if len(tx.Inputs) == 0 {
+		height := record.UnminedSince
+		if len(record.BlockHeights) > 0 {
+			height = record.BlockHeights[0]
+		}
+
+		wrapper := utxopersister.UTXOWrapper{
+			TxID:     record.TxID,
+			Height:   height,
+			Coinbase: record.IsCoinbase,
+			UTXOs:    make([]*utxopersister.UTXO, 0, len(tx.Outputs)),
+		}
+
+		for i, output := range tx.Outputs {
+			if output == nil {
+				continue
+			}
+
+			iUint32, err := safeconversion.IntToUint32(i)
+			if err != nil {
+				return err
+			}
+
+			wrapper.UTXOs = append(wrapper.UTXOs, &utxopersister.UTXO{
+				Index:  iUint32,
+				Value:  output.Satoshis,
+				Script: *output.LockingScript,
+			})
+		}
+
+		if err := s.externalStore.Set(ctx, record.TxID[:], fileformat.FileTypeOutputs, wrapper.Bytes()); err != nil && !errors.Is(err, errors.ErrBlobAlreadyExists) {
+			return errors.NewStorageError("error writing outputs of %s to external store", record.TxID, err)
+		}
+	} else if err := s.externalStore.Set(ctx, record.TxID[:], fileformat.FileTypeTx, tx.ExtendedBytes()); err != nil && !errors.Is(err, errors.ErrBlobAlreadyExists) {
+		return errors.NewStorageError("error writing transaction %s to external store", record.TxID, err)
+	}
+
+	if record.PreserveUntil > 0 {
+		return s.preserveUntilExternalTransaction(ctx, &record.TxID, record.PreserveUntil)
+	}
+
+	return s.setDAHExternalTransaction(ctx, &record.TxID, record.DeleteAtHeight)
+}
+
+// setBin replaces the bin with the given name, or appends it if the bins do not contain it
+func setBin(bins []*aerospike.Bin, name string, value interface{}) []*aerospike.Bin {
+	bin := aerospike.NewBin(name, value)
+
+	for i := range bins {
+		if bins[i].Name == name {
+			bins[i] = bin
+			return bins
+		}
+	}
+
+	return append(bins, bin)
+}
+
+// removeBin removes the bin with the given name
+func removeBin(bins []*aerospike.Bin, name string) []*aerospike.Bin {
+	for i := range bins {
+		if bins[i].Name == name {
+			return append(bins[:i], bins[i+1:]...)
+		}
+	}
+
+	return bins
+}
+
+// binUint32 returns the value of an integer bin as uint32, 0 if the bin is not set
+func binUint32(bins aerospike.BinMap, field fields.FieldName) uint32 {
+	value, ok := bins[field.String()].(int)
+	if !ok || value < 0 {
+		return 0
+	}
+
+	valueUint32, err := safeconversion.IntToUint32(value)
+	if err != nil {
+		return 0
+	}
+
+	return valueUint32
+}
+
+// binInt returns the value of a non-negative integer bin, 0 if the bin is not set
+func binInt(bins aerospike.BinMap, field fields.FieldName) int {
+	value, ok := bins[field.String()].(int)
+	if !ok || value < 0 {
+		return 0
+	}
+
+	return value
+}
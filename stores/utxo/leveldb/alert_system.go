@@ -0,0 +1,103 @@
+package leveldb
+
+import (
+	"context"
+
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/settings"
+	utxostore "github.com/bsv-blockchain/teranode/stores/utxo"
+	goleveldb "github.com/btcsuite/goleveldb/leveldb"
+)
+
+// FreezeUTXOs marks UTXOs as frozen, preventing them from being spent.
+// Returns an error if any UTXO is already spent or frozen, in which case no UTXO is frozen.
+func (s *Store) FreezeUTXOs(_ context.Context, spends []*utxostore.Spend, _ *settings.Settings) error {
+	return s.updateOutputs(spends, func(spend *utxostore.Spend, o *outputRecord) error {
+		if o.spendingData != nil {
+			return errors.NewUtxoSpentError(*o.spendingData.TxID, spend.Vout, *spend.UTXOHash, o.spendingData)
+		}
+
+		if o.frozen {
+			return errors.NewUtxoFrozenError("transaction %s:%d already frozen", spend.TxID, spend.Vout)
+		}
+
+		o.frozen = true
+
+		return nil
+	})
+}
+
+// UnFreezeUTXOs removes the frozen status from UTXOs.
+// Returns an error if any UTXO is not frozen, in which case no UTXO is unfrozen.
+func (s *Store) UnFreezeUTXOs(_ context.Context, spends []*utxostore.Spend, _ *settings.Settings) error {
+	return s.updateOutputs(spends, func(spend *utxostore.Spend, o *outputRecord) error {
+		if !o.frozen {
+			return errors.NewUtxoFrozenError("transaction %s:%d is not frozen", spend.TxID, spend.Vout)
+		}
+
+		o.frozen = false
+
+		return nil
+	})
+}
+
+// ReAssignUTXO reassigns a frozen UTXO to a new transaction output.
+// The UTXO must be frozen before it can be reassigned.
+// The reassigned UTXO becomes spendable after ReAssignedUtxoSpendableAfterBlocks blocks.
+func (s *Store) ReAssignUTXO(_ context.Context, utxo *utxostore.Spend, newUtxo *utxostore.Spend, _ *settings.Settings) error {
+	spendableIn := s.GetBlockHeight() + utxostore.ReAssignedUtxoSpendableAfterBlocks
+
+	return s.updateOutputs([]*utxostore.Spend{utxo}, func(spend *utxostore.Spend, o *outputRecord) error {
+		if !o.frozen {
+			return errors.NewUtxoFrozenError("transaction %s:%d is not frozen", spend.TxID, spend.Vout)
+		}
+
+		// re-assign the UTXO to the new UTXO
+		o.utxoHash = *newUtxo.UTXOHash
+		o.frozen = false
+		o.spendableIn = spendableIn
+
+		return nil
+	})
+}
+
+// updateOutputs applies the update to the outputs of the spends and writes them in a single batch,
+// nothing is written if the update fails for any of the outputs
+func (s *Store) updateOutputs(spends []*utxostore.Spend, update func(spend *utxostore.Spend, o *outputRecord) error) error {
+	hashes := make([]chainhash.Hash, 0, len(spends))
+	for _, spend := range spends {
+		hashes = append(hashes, *spend.TxID)
+	}
+
+	unlock := s.lockTxs(hashes)
+	defer unlock()
+
+	records, err := s.getRecords(hashes)
+	if err != nil {
+		return err
+	}
+
+	for _, spend := range spends {
+		record := records[*spend.TxID]
+		if record == nil || int(spend.Vout) >= len(record.outputs) || record.outputs[spend.Vout] == nil {
+			return errors.NewNotFoundError("output %s:%d not found", spend.TxID, spend.Vout)
+		}
+
+		if err = update(spend, record.outputs[spend.Vout]); err != nil {
+			return err
+		}
+	}
+
+	batch := new(goleveldb.Batch)
+
+	for hash, record := range records {
+		putRecord(batch, &hash, record)
+	}
+
+	if err = s.write(batch); err != nil {
+		return errors.NewStorageError("[LevelDB] failed to write outputs", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,196 @@
+package leveldb
+
+import (
+	"context"
+	"time"
+
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/stores/cleanup"
+	"github.com/ordishs/gocore"
+)
+
+// Ensure Store implements the cleanup.CleanupProvider interface
+var _ cleanup.CleanupServiceProvider = (*Store)(nil)
+
+// Ensure CleanupService implements the Cleanup Service interface
+var _ cleanup.Service = (*CleanupService)(nil)
+
+const (
+	// DefaultCleanupWorkerCount is the default number of cleanup worker goroutines
+	DefaultCleanupWorkerCount = 1
+
+	// defaultCleanupMaxJobsHistory is the number of cleanup jobs kept in history
+	defaultCleanupMaxJobsHistory = 10
+)
+
+// CleanupService deletes the transactions of the LevelDB UTXO store whose DAH has been reached.
+type CleanupService struct {
+	store              *Store
+	jobManager         *cleanup.JobManager
+	getPersistedHeight func() uint32
+}
+
+// GetCleanupService returns the cleanup service of the store, creating it on the first call.
+// This implements the cleanup.CleanupProvider interface.
+func (s *Store) GetCleanupService() (cleanup.Service, error) {
+	s.cleanupServiceMu.Lock()
+	defer s.cleanupServiceMu.Unlock()
+
+	if s.cleanupService != nil {
+		return s.cleanupService, nil
+	}
+
+	workerCount, _ := gocore.Config().GetInt("leveldb_cleanup_worker_count", DefaultCleanupWorkerCount)
+
+	service := &CleanupService{
+		store: s,
+	}
+
+	jobManager, err := cleanup.NewJobManager(cleanup.JobManagerOptions{
+		Logger:         s.logger,
+		WorkerCount:    workerCount,
+		MaxJobsHistory: defaultCleanupMaxJobsHistory,
+		JobProcessor:   service.processCleanupJob,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	service.jobManager = jobManager
+	s.cleanupService = service
+
+	return service, nil
+}
+
+// Start starts the cleanup service
+func (c *CleanupService) Start(ctx context.Context) {
+	c.store.logger.Infof("[LevelDBCleanupService] starting cleanup service")
+	c.jobManager.Start(ctx)
+}
+
+// UpdateBlockHeight updates the current block height and triggers cleanup if needed
+func (c *CleanupService) UpdateBlockHeight(blockHeight uint32, doneCh ...chan string) error {
+	if blockHeight == 0 {
+		return errors.NewProcessingError("Cannot update block height to 0")
+	}
+
+	return c.jobManager.TriggerCleanup(blockHeight, doneCh...)
+}
+
+// SetPersistedHeightGetter sets the function used to get block persister progress.
+// This allows cleanup to coordinate with block persister to avoid premature deletion.
+func (c *CleanupService) SetPersistedHeightGetter(getter func() uint32) {
+	c.getPersistedHeight = getter
+}
+
+// GetJobs returns a copy of the current jobs list (primarily for testing)
+func (c *CleanupService) GetJobs() []*cleanup.Job {
+	return c.jobManager.GetJobs()
+}
+
+// processCleanupJob executes the cleanup for a specific job
+func (c *CleanupService) processCleanupJob(job *cleanup.Job, workerID int) {
+	logger := c.store.logger
+
+	logger.Debugf("[LevelDBCleanupService %d] running cleanup job for block height %d", workerID, job.BlockHeight)
+
+	job.Started = time.Now()
+
+	// limit the cleanup to the blocks the block persister has processed, see the SQL cleanup service
+	safeCleanupHeight := job.BlockHeight
+
+	if c.getPersistedHeight != nil {
+		// only apply the limitation if block persister has actually processed blocks (height > 0)
+		if persistedHeight := c.getPersistedHeight(); persistedHeight > 0 {
+			retention := c.store.settings.GetUtxoStoreBlockHeightRetention()
+
+			maxSafeHeight := persistedHeight + retention
+			if maxSafeHeight < safeCleanupHeight {
+				logger.Infof("[LevelDBCleanupService %d] Limiting cleanup from height %d to %d (persisted: %d, retention: %d)",
+					workerID, job.BlockHeight, maxSafeHeight, persistedHeight, retention)
+				safeCleanupHeight = maxSafeHeight
+			}
+		}
+	}
+
+	deleted, err := c.store.deleteExpired(job.Context(), safeCleanupHeight)
+
+	if err != nil {
+		job.SetStatus(cleanup.JobStatusFailed)
+		job.Error = err
+		job.Ended = time.Now()
+
+		logger.Errorf("[LevelDBCleanupService %d] cleanup job failed for block height %d: %v", workerID, job.BlockHeight, err)
+
+		if job.DoneCh != nil {
+			job.DoneCh <- cleanup.JobStatusFailed.String()
+			close(job.DoneCh)
+		}
+	} else {
+		job.SetStatus(cleanup.JobStatusCompleted)
+		job.Ended = time.Now()
+
+		logger.Debugf("[LevelDBCleanupService %d] cleanup job completed for block height %d in %v, deleted %d transactions",
+			workerID, job.BlockHeight, time.Since(job.Started), deleted)
+
+		if job.DoneCh != nil {
+			job.DoneCh <- cleanup.JobStatusCompleted.String()
+			close(job.DoneCh)
+		}
+	}
+}
+
+// deleteExpired deletes the transactions with a DAH up to and including the given height, in batches of
+// batchSize transactions, after which the deleted index range is compacted so the tombstones do not slow
+// down later scans. It returns the number of deleted transactions.
+func (s *Store) deleteExpired(ctx context.Context, blockHeight uint32) (int, error) {
+	deleted := 0
+
+	for {
+		txHashes, err := s.scanHeightIndex(prefixDAH, blockHeight, batchSize)
+		if err != nil {
+			return deleted, errors.NewStorageError("[LevelDB] failed to scan DAH index", err)
+		}
+
+		if len(txHashes) == 0 {
+			break
+		}
+
+		batchDeleted := 0
+
+		for i := range txHashes {
+			if err = ctx.Err(); err != nil {
+				return deleted, err
+			}
+
+			// the DAH can have changed since the index was scanned
+			ok, err := s.deleteTx(&txHashes[i], func(record *txRecord) bool {
+				return record.deleteAtHeight > 0 && record.deleteAtHeight <= blockHeight
+			})
+			if err != nil {
+				return deleted, err
+			}
+
+			if ok {
+				batchDeleted++
+			}
+		}
+
+		deleted += batchDeleted
+
+		if batchDeleted == 0 {
+			// the index entries left do not belong to expired transactions
+			break
+		}
+	}
+
+	if deleted > 0 {
+		prometheusUtxoCleanupBatch.Observe(float64(deleted))
+
+		if err := s.db.CompactRange(*heightRange(prefixDAH, blockHeight)); err != nil {
+			s.logger.Warnf("[LevelDB] failed to compact DAH index up to height %d: %v", blockHeight, err)
+		}
+	}
+
+	return deleted, nil
+}
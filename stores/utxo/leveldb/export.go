@@ -0,0 +1,326 @@
+package leveldb
+
+import (
+	"context"
+
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	safeconversion "github.com/bsv-blockchain/go-safe-conversion"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/stores/utxo"
+	"github.com/bsv-blockchain/teranode/util"
+	goleveldb "github.com/btcsuite/goleveldb/leveldb"
+	"github.com/btcsuite/goleveldb/leveldb/iterator"
+	leveldbutil "github.com/btcsuite/goleveldb/leveldb/util"
+)
+
+// Ensure Store implements the record export and import interfaces
+var (
+	_ utxo.RecordExporter = (*Store)(nil)
+	_ utxo.RecordImporter = (*Store)(nil)
+)
+
+// recordIterator implements utxo.RecordIterator for LevelDB.
+// It iterates the transaction records of a snapshot of the database in key order, the cursor is the hash of
+// the last returned transaction.
+type recordIterator struct {
+	store    *Store
+	snapshot *goleveldb.Snapshot
+	iter     iterator.Iterator
+	cursor   string
+	err      error
+	done     bool
+}
+
+// ExportRecords returns an iterator over all the transactions of the store, starting after the transaction
+// of the cursor.
+func (s *Store) ExportRecords(_ context.Context, cursor string) (utxo.RecordIterator, error) {
+	start := []byte{prefixTx}
+
+	if cursor != "" {
+		hash, err := chainhash.NewHashFromStr(cursor)
+		if err != nil {
+			return nil, errors.NewInvalidArgumentError("[LevelDB] invalid export cursor %q", cursor, err)
+		}
+
+		// start right after the key of the cursor
+		start = append(txKey(hash), 0)
+	}
+
+	snapshot, err := s.db.GetSnapshot()
+	if err != nil {
+		return nil, errors.NewStorageError("[LevelDB] failed to get snapshot", err)
+	}
+
+	return &recordIterator{
+		store:    s,
+		snapshot: snapshot,
+		iter:     snapshot.NewIterator(&leveldbutil.Range{Start: start, Limit: []byte{prefixTx + 1}}, nil),
+		cursor:   cursor,
+	}, nil
+}
+
+func (it *recordIterator) Next(ctx context.Context) (*utxo.Record, error) {
+	if it.done || it.err != nil {
+		return nil, it.err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if !it.iter.Next() {
+		if err := it.iter.Error(); err != nil {
+			it.err = errors.NewStorageError("[LevelDB] failed to iterate transactions", err)
+		}
+
+		if err := it.Close(); err != nil {
+			it.store.logger.Warnf("failed to close iterator: %v", err)
+		}
+
+		return nil, it.err
+	}
+
+	txID := chainhash.Hash(it.iter.Key()[1:])
+
+	record, err := getRecordFrom(it.snapshot, &txID)
+	if err != nil {
+		it.err = err
+
+		if err = it.Close(); err != nil {
+			it.store.logger.Warnf("failed to close iterator: %v", err)
+		}
+
+		return nil, it.err
+	}
+
+	it.cursor = txID.String()
+
+	return record.toRecord(&txID), nil
+}
+
+func (it *recordIterator) Cursor() string {
+	return it.cursor
+}
+
+func (it *recordIterator) Close() error {
+	if !it.done {
+		it.done = true
+		it.iter.Release()
+		it.snapshot.Release()
+	}
+
+	return nil
+}
+
+// ExportRecord returns the record of the transaction.
+func (s *Store) ExportRecord(_ context.Context, hash *chainhash.Hash) (*utxo.Record, error) {
+	record, err := s.getRecord(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return record.toRecord(hash), nil
+}
+
+// ImportRecords stores the records, replacing the transactions that already exist together with their
+// index entries.
+func (s *Store) ImportRecords(ctx context.Context, records []*utxo.Record) error {
+	for start := 0; start < len(records); start += batchSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		end := min(start+batchSize, len(records))
+
+		if err := s.importRecords(records[start:end]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) importRecords(records []*utxo.Record) error {
+	hashes := make([]chainhash.Hash, len(records))
+	for i, r := range records {
+		hashes[i] = r.TxID
+	}
+
+	unlock := s.lockTxs(hashes)
+	defer unlock()
+
+	existing, err := s.getRecords(hashes)
+	if err != nil {
+		return err
+	}
+
+	batch := new(goleveldb.Batch)
+
+	for _, r := range records {
+		record, err := newTxRecordFromRecord(r)
+		if err != nil {
+			return err
+		}
+
+		if stored, ok := existing[r.TxID]; ok {
+			// the outputs of the stored transaction can differ from the imported one, replace the record as a whole
+			deleteRecord(batch, &r.TxID, stored)
+		}
+
+		putRecord(batch, &r.TxID, record)
+
+		// a later record with the same hash must replace the keys written by this one
+		existing[r.TxID] = record
+	}
+
+	if err = s.write(batch); err != nil {
+		return errors.NewStorageError("[LevelDB] failed to import %d transactions", len(records), err)
+	}
+
+	return nil
+}
+
+// toRecord converts the record to the backend independent utxo.Record
+func (r *txRecord) toRecord(hash *chainhash.Hash) *utxo.Record {
+	record := &utxo.Record{
+		TxID:           *hash,
+		Tx:             r.tx(),
+		Fee:            r.fee,
+		SizeInBytes:    r.sizeInBytes,
+		IsCoinbase:     r.isCoinbase,
+		SpendingHeight: r.coinbaseSpendingHeight,
+		Frozen:         r.frozen,
+		Conflicting:    r.conflicting,
+		Locked:         r.locked,
+		DeleteAtHeight: r.deleteAtHeight,
+		PreserveUntil:  r.preserveUntil,
+		CreatedAt:      int(r.insertedAt), // nolint:gosec
+	}
+
+	if r.unmined {
+		record.UnminedSince = r.unminedSince
+	}
+
+	for _, b := range r.blocks {
+		record.BlockIDs = append(record.BlockIDs, b.blockID)
+		record.BlockHeights = append(record.BlockHeights, b.blockHeight)
+		record.SubtreeIdxs = append(record.SubtreeIdxs, int(b.subtreeIdx))
+	}
+
+	if len(r.conflictingChildren) > 0 {
+		record.ConflictingChildren = make([]chainhash.Hash, len(r.conflictingChildren))
+		copy(record.ConflictingChildren, r.conflictingChildren)
+	}
+
+	if len(r.outputs) > 0 {
+		record.Utxos = make([]*utxo.RecordUtxo, len(r.outputs))
+
+		for i, o := range r.outputs {
+			if o == nil {
+				continue
+			}
+
+			record.Utxos[i] = &utxo.RecordUtxo{
+				UTXOHash:     o.utxoHash,
+				SpendingData: o.spendingData,
+				Frozen:       o.frozen,
+				SpendableIn:  o.spendableIn,
+			}
+		}
+	}
+
+	return record
+}
+
+// newTxRecordFromRecord converts the backend independent utxo.Record to a record, every output of the
+// transaction is stored, with the hash of the output as utxo hash when the record has no utxo for it
+func newTxRecordFromRecord(r *utxo.Record) (*txRecord, error) {
+	if r.Tx == nil {
+		return nil, errors.NewProcessingError("[LevelDB] record of transaction %s has no transaction", r.TxID)
+	}
+
+	insertedAt, err := safeconversion.IntToUint64(r.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &txRecord{
+		isCoinbase:             r.IsCoinbase,
+		frozen:                 r.Frozen,
+		conflicting:            r.Conflicting,
+		locked:                 r.Locked,
+		unmined:                r.IsUnmined(),
+		version:                r.Tx.Version,
+		lockTime:               r.Tx.LockTime,
+		unminedSince:           r.UnminedSince,
+		deleteAtHeight:         r.DeleteAtHeight,
+		preserveUntil:          r.PreserveUntil,
+		coinbaseSpendingHeight: r.SpendingHeight,
+		fee:                    r.Fee,
+		sizeInBytes:            r.SizeInBytes,
+		insertedAt:             insertedAt,
+		inputs:                 r.Tx.Inputs,
+	}
+
+	if len(r.Tx.Outputs) > 0 {
+		record.outputs = make([]*outputRecord, len(r.Tx.Outputs))
+	}
+
+	for i, output := range r.Tx.Outputs {
+		if output == nil {
+			continue
+		}
+
+		o := &outputRecord{
+			output: output,
+		}
+
+		if i < len(r.Utxos) && r.Utxos[i] != nil {
+			o.utxoHash = r.Utxos[i].UTXOHash
+			o.spendingData = r.Utxos[i].SpendingData
+			o.frozen = r.Utxos[i].Frozen
+			o.spendableIn = r.Utxos[i].SpendableIn
+		} else {
+			iUint32, err := safeconversion.IntToUint32(i)
+			if err != nil {
+				return nil, err
+			}
+
+			utxoHash, err := util.UTXOHashFromOutput(&r.TxID, output, iUint32)
+			if err != nil {
+				return nil, err
+			}
+
+			o.utxoHash = *utxoHash
+		}
+
+		record.outputs[i] = o
+	}
+
+	for i, blockID := range r.BlockIDs {
+		b := blockRecord{
+			blockID: blockID,
+		}
+
+		if i < len(r.BlockHeights) {
+			b.blockHeight = r.BlockHeights[i]
+		}
+
+		if i < len(r.SubtreeIdxs) {
+			subtreeIdx, err := safeconversion.IntToUint32(r.SubtreeIdxs[i])
+			if err != nil {
+				return nil, err
+			}
+
+			b.subtreeIdx = subtreeIdx
+		}
+
+		record.addBlock(b)
+	}
+
+	for _, child := range r.ConflictingChildren {
+		record.addConflictingChild(child)
+	}
+
+	return record, nil
+}
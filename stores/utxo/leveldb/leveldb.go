@@ -0,0 +1,1535 @@
+// Package leveldb provides an embedded implementation of the UTXO store interface, backed by LevelDB.
+//
+// The store is meant for single-box deployments, which do not want to run an Aerospike cluster or a
+// PostgreSQL server next to Teranode, and which outgrow SQLite. All state lives in one LevelDB database
+// in a local directory.
+//
+// # Features
+//
+//   - Full UTXO lifecycle management (create, spend, unspend)
+//   - Conflicting, locked and frozen transactions and outputs
+//   - Support for the alert system (freeze/unfreeze/reassign UTXOs)
+//   - Unmined transaction tracking, with an iterator for block assembly
+//   - Delete-At-Height (DAH) cleanup through the stores/cleanup service, and preservation of transactions
+//   - Prometheus metrics integration
+//
+// # Data Layout
+//
+// Every transaction is stored as a record split over several keys: the transaction itself, which never changes,
+// the meta data, holding its flags, heights, blocks and conflicting children, and the state of each of its
+// outputs. A change only writes the keys of the record that changed, so spending an output does not rewrite the
+// transaction and marking a transaction as mined does not rewrite its outputs. Every change is committed in one
+// atomic batch, together with the secondary index entries of the record:
+//   - t{hash}: the transaction
+//   - m{hash}: the meta data of the transaction
+//   - o{hash}{vout}: the state of an output, its utxo hash, frozen state and spending data
+//   - u{unmined since}{hash}: the unmined transactions, ordered by the height they were first seen at
+//   - d{delete at height}{hash}: the transactions to delete, ordered by their DAH
+//   - p{preserve until}{hash}: the preserved transactions, ordered by the height their preservation ends
+//
+// Heights in the index keys and the vout in the output keys are big-endian, so range scans return the entries in height order.
+//
+// # Usage
+//
+//	store, err := leveldb.New(ctx, logger, settings, &url.URL{
+//	    Scheme:   "leveldb",
+//	    Path:     "/data/utxostore",
+//	    RawQuery: "sync=true",
+//	})
+//
+// # Metrics
+//
+// The following Prometheus metrics are exposed:
+//   - teranode_leveldb_utxo_get: Number of UTXO retrieval operations
+//   - teranode_leveldb_utxo_spend: Number of UTXO spend operations
+//   - teranode_leveldb_utxo_reset: Number of UTXO reset operations
+//   - teranode_leveldb_utxo_delete: Number of UTXO delete operations
+//   - teranode_leveldb_utxo_errors: Number of errors by function and type
+package leveldb
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bsv-blockchain/go-bt/v2"
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	safeconversion "github.com/bsv-blockchain/go-safe-conversion"
+	"github.com/bsv-blockchain/go-subtree"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/settings"
+	"github.com/bsv-blockchain/teranode/stores/utxo"
+	"github.com/bsv-blockchain/teranode/stores/utxo/fields"
+	"github.com/bsv-blockchain/teranode/stores/utxo/meta"
+	spendpkg "github.com/bsv-blockchain/teranode/stores/utxo/spend"
+	"github.com/bsv-blockchain/teranode/ulogger"
+	"github.com/bsv-blockchain/teranode/util"
+	"github.com/bsv-blockchain/teranode/util/tracing"
+	goleveldb "github.com/btcsuite/goleveldb/leveldb"
+	"github.com/btcsuite/goleveldb/leveldb/iterator"
+	"github.com/btcsuite/goleveldb/leveldb/opt"
+	leveldbutil "github.com/btcsuite/goleveldb/leveldb/util"
+)
+
+const (
+	// prefixTx prefixes the keys of the transactions, followed by the tx hash
+	prefixTx = 't'
+	// prefixMeta prefixes the keys of the meta data of the transactions, followed by the tx hash
+	prefixMeta = 'm'
+	// prefixOutput prefixes the keys of the output states, followed by the tx hash and the big endian vout
+	prefixOutput = 'o'
+	// prefixUnmined prefixes the unmined index, followed by the big endian unmined since height and the tx hash
+	prefixUnmined = 'u'
+	// prefixDAH prefixes the DAH index, followed by the big endian DAH and the tx hash
+	prefixDAH = 'd'
+	// prefixPreserve prefixes the preservation index, followed by the big endian preserve until height and the tx hash
+	prefixPreserve = 'p'
+
+	// batchSize is the number of transactions updated in a single batch by the operations on lists of transactions
+	batchSize = 1000
+	// maxOldUnminedTransactions is the maximum number of transactions returned by QueryOldUnminedTransactions
+	maxOldUnminedTransactions = 1000
+	// txLockStripes is the number of mutexes the tx hashes are striped over, to serialize the read-modify-write
+	// of the records
+	txLockStripes = 256
+)
+
+// Store implements the UTXO store interface on an embedded LevelDB database.
+type Store struct {
+	logger          ulogger.Logger
+	settings        *settings.Settings
+	db              *goleveldb.DB
+	writeOptions    *opt.WriteOptions
+	blockHeight     atomic.Uint32
+	medianBlockTime atomic.Uint32
+	closeOnce       sync.Once
+
+	cleanupServiceMu sync.Mutex
+	cleanupService   *CleanupService
+
+	// txLocks serialize the writes of a record, every write reads the record, changes it and writes back the
+	// parts that changed
+	txLocks [txLockStripes]sync.Mutex
+}
+
+// New creates a new LevelDB UTXO store, opening or creating the database in the directory of the URL path.
+// The database is closed when the context is done.
+//
+// URL parameters:
+//   - sync: Sync every write to disk before returning, defaults to false
+//
+// Example URLs:
+//
+//	leveldb:///data/utxostore?sync=true
+//	leveldb://./data/utxostore
+func New(ctx context.Context, logger ulogger.Logger, tSettings *settings.Settings, storeURL *url.URL) (*Store, error) {
+	initPrometheusMetrics()
+
+	if storeURL == nil {
+		return nil, errors.NewConfigurationError("[LevelDB] storeURL is nil")
+	}
+
+	var path string
+	if storeURL.Host == "." {
+		path = storeURL.Path[1:] // relative path
+	} else {
+		path = storeURL.Path // absolute path
+	}
+
+	if len(path) == 0 {
+		return nil, errors.NewConfigurationError("[LevelDB] database path is not set in %s", storeURL)
+	}
+
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, errors.NewStorageError("[LevelDB] failed to create directory", err)
+	}
+
+	db, err := goleveldb.OpenFile(path, &opt.Options{
+		// locking and unlocking scripts do not compress
+		Compression: opt.NoCompression,
+	})
+	if err != nil {
+		return nil, errors.NewStorageError("[LevelDB] failed to open database %s", path, err)
+	}
+
+	s := &Store{
+		logger:       logger,
+		settings:     tSettings,
+		db:           db,
+		writeOptions: &opt.WriteOptions{Sync: storeURL.Query().Get("sync") == "true"},
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		if err := s.Close(); err != nil {
+			s.logger.Errorf("[LevelDB] failed to close utxo store: %v", err)
+		}
+	}()
+
+	return s, nil
+}
+
+// Close closes the database, it is safe to call more than once.
+func (s *Store) Close() error {
+	var err error
+
+	s.closeOnce.Do(func() {
+		if closeErr := s.db.Close(); closeErr != nil {
+			err = errors.NewStorageError("[LevelDB] failed to close database", closeErr)
+		}
+	})
+
+	return err
+}
+
+func (s *Store) SetBlockHeight(blockHeight uint32) error {
+	s.logger.Debugf("setting block height to %d", blockHeight)
+	s.blockHeight.Store(blockHeight)
+
+	return nil
+}
+
+func (s *Store) GetBlockHeight() uint32 {
+	return s.blockHeight.Load()
+}
+
+func (s *Store) SetMedianBlockTime(medianTime uint32) error {
+	s.logger.Debugf("setting median block time to %d", medianTime)
+	s.medianBlockTime.Store(medianTime)
+
+	return nil
+}
+
+func (s *Store) GetMedianBlockTime() uint32 {
+	return s.medianBlockTime.Load()
+}
+
+func (s *Store) GetBlockState() utxo.BlockState {
+	return utxo.BlockState{
+		Height:     s.blockHeight.Load(),
+		MedianTime: s.medianBlockTime.Load(),
+	}
+}
+
+// Health checks that the database can be read.
+func (s *Store) Health(_ context.Context, _ bool) (int, string, error) {
+	if _, err := s.db.Has([]byte{prefixTx}, nil); err != nil {
+		return http.StatusServiceUnavailable, "LevelDB UTXO Store: unable to read database", err
+	}
+
+	return http.StatusOK, "LevelDB UTXO Store", nil
+}
+
+// txKey returns the key of the transaction
+func txKey(hash *chainhash.Hash) []byte {
+	return hashKey(prefixTx, hash)
+}
+
+// metaKey returns the key of the meta data of the transaction
+func metaKey(hash *chainhash.Hash) []byte {
+	return hashKey(prefixMeta, hash)
+}
+
+func hashKey(prefix byte, hash *chainhash.Hash) []byte {
+	key := make([]byte, 1+chainhash.HashSize)
+	key[0] = prefix
+	copy(key[1:], hash[:])
+
+	return key
+}
+
+// outputKey returns the key of the state of the output of the transaction
+func outputKey(hash *chainhash.Hash, vout int) []byte {
+	return binary.BigEndian.AppendUint32(hashKey(prefixOutput, hash), uint32(vout)) // nolint:gosec
+}
+
+// heightKey returns the key of an index entry, the prefix of the index, the big endian height and the tx hash
+func heightKey(prefix byte, height uint32, hash *chainhash.Hash) []byte {
+	key := make([]byte, 5+chainhash.HashSize)
+	key[0] = prefix
+	binary.BigEndian.PutUint32(key[1:5], height)
+	copy(key[5:], hash[:])
+
+	return key
+}
+
+// heightRange returns the range of the index entries with a height up to and including the given height
+func heightRange(prefix byte, height uint32) *leveldbutil.Range {
+	limit := []byte{prefix + 1}
+	if height < 0xffffffff {
+		limit = binary.BigEndian.AppendUint32([]byte{prefix}, height+1)
+	}
+
+	return &leveldbutil.Range{Start: []byte{prefix}, Limit: limit}
+}
+
+// lockStripe returns the stripe of the lock of a transaction
+func lockStripe(hash *chainhash.Hash) int {
+	return int(binary.LittleEndian.Uint32(hash[:4]) % txLockStripes)
+}
+
+// lockTxs locks the records of the transactions, in ascending stripe order so concurrent callers cannot
+// deadlock, and returns the function unlocking them
+func (s *Store) lockTxs(hashes []chainhash.Hash) func() {
+	stripes := make([]int, 0, len(hashes))
+	for i := range hashes {
+		stripes = append(stripes, lockStripe(&hashes[i]))
+	}
+
+	slices.Sort(stripes)
+	stripes = slices.Compact(stripes)
+
+	for _, stripe := range stripes {
+		s.txLocks[stripe].Lock()
+	}
+
+	return func() {
+		for _, stripe := range stripes {
+			s.txLocks[stripe].Unlock()
+		}
+	}
+}
+
+// getRecord reads the record of the transaction, returning a tx not found error if it does not exist
+func (s *Store) getRecord(hash *chainhash.Hash) (*txRecord, error) {
+	return getRecordFrom(s.db, hash)
+}
+
+// reader is implemented by the database and its snapshots
+type reader interface {
+	Get(key []byte, ro *opt.ReadOptions) ([]byte, error)
+	NewIterator(slice *leveldbutil.Range, ro *opt.ReadOptions) iterator.Iterator
+}
+
+// getRecordFrom reads the transaction, its meta data and the states of its outputs
+func getRecordFrom(r reader, hash *chainhash.Hash) (*txRecord, error) {
+	b, err := r.Get(txKey(hash), nil)
+	if err != nil {
+		if errors.Is(err, goleveldb.ErrNotFound) {
+			return nil, errors.NewTxNotFoundError("transaction %s not found", hash)
+		}
+
+		return nil, errors.NewStorageError("[LevelDB] failed to read transaction %s", hash, err)
+	}
+
+	record, err := newTxRecordFromBytes(b)
+	if err != nil {
+		return nil, errors.NewProcessingError("[LevelDB] failed to decode transaction %s", hash, err)
+	}
+
+	if b, err = r.Get(metaKey(hash), nil); err != nil {
+		return nil, errors.NewStorageError("[LevelDB] failed to read meta data of transaction %s", hash, err)
+	}
+
+	if err = record.decodeMeta(b); err != nil {
+		return nil, errors.NewProcessingError("[LevelDB] failed to decode meta data of transaction %s", hash, err)
+	}
+
+	iter := r.NewIterator(leveldbutil.BytesPrefix(hashKey(prefixOutput, hash)), nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		vout := binary.BigEndian.Uint32(iter.Key()[1+chainhash.HashSize:])
+		if int(vout) >= len(record.outputs) || record.outputs[vout] == nil {
+			return nil, errors.NewProcessingError("[LevelDB] state of output %s:%d that was not stored", hash, vout)
+		}
+
+		// the iterator reuses its buffers, the stored state is kept to diff against on the next write
+		if err = record.outputs[vout].decodeState(bytes.Clone(iter.Value())); err != nil {
+			return nil, errors.NewProcessingError("[LevelDB] failed to decode output %s:%d", hash, vout, err)
+		}
+	}
+
+	if err = iter.Error(); err != nil {
+		return nil, errors.NewStorageError("[LevelDB] failed to read outputs of transaction %s", hash, err)
+	}
+
+	for vout, o := range record.outputs {
+		if o != nil && o.stored == nil {
+			return nil, errors.NewProcessingError("[LevelDB] missing state of output %s:%d", hash, vout)
+		}
+	}
+
+	return record, nil
+}
+
+// getRecords reads the records of the transactions, missing transactions are not in the returned map
+func (s *Store) getRecords(hashes []chainhash.Hash) (map[chainhash.Hash]*txRecord, error) {
+	records := make(map[chainhash.Hash]*txRecord, len(hashes))
+
+	for i := range hashes {
+		if _, ok := records[hashes[i]]; ok {
+			continue
+		}
+
+		record, err := s.getRecord(&hashes[i])
+		if err != nil {
+			if errors.Is(err, errors.ErrTxNotFound) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		records[hashes[i]] = record
+	}
+
+	return records, nil
+}
+
+// putRecord adds the writes of the parts of the record that changed to the batch, together with the changes of
+// its index entries
+func putRecord(batch *goleveldb.Batch, hash *chainhash.Hash, record *txRecord) {
+	current := record.indexState()
+	stored := record.indexed
+
+	if stored.unmined && (!current.unmined || stored.unminedSince != current.unminedSince) {
+		batch.Delete(heightKey(prefixUnmined, stored.unminedSince, hash))
+	}
+
+	if current.unmined && (!stored.unmined || stored.unminedSince != current.unminedSince) {
+		batch.Put(heightKey(prefixUnmined, current.unminedSince, hash), nil)
+	}
+
+	updateHeightIndex(batch, prefixDAH, stored.deleteAtHeight, current.deleteAtHeight, hash)
+	updateHeightIndex(batch, prefixPreserve, stored.preserveUntil, current.preserveUntil, hash)
+
+	if record.storedMeta == nil {
+		// the transaction never changes, it is only written together with a new record
+		batch.Put(txKey(hash), record.txBytes())
+	}
+
+	if meta := record.metaBytes(); !bytes.Equal(meta, record.storedMeta) {
+		batch.Put(metaKey(hash), meta)
+		record.storedMeta = meta
+	}
+
+	for vout, o := range record.outputs {
+		if o == nil {
+			continue
+		}
+
+		if state := o.stateBytes(); !bytes.Equal(state, o.stored) {
+			batch.Put(outputKey(hash, vout), state)
+			o.stored = state
+		}
+	}
+
+	record.indexed = current
+}
+
+// updateHeightIndex moves the index entry of the transaction from the stored to the current height, a height
+// of 0 has no index entry
+func updateHeightIndex(batch *goleveldb.Batch, prefix byte, stored, current uint32, hash *chainhash.Hash) {
+	if stored == current {
+		return
+	}
+
+	if stored > 0 {
+		batch.Delete(heightKey(prefix, stored, hash))
+	}
+
+	if current > 0 {
+		batch.Put(heightKey(prefix, current, hash), nil)
+	}
+}
+
+// deleteRecord adds the delete of the record and its index entries to the batch
+func deleteRecord(batch *goleveldb.Batch, hash *chainhash.Hash, record *txRecord) {
+	stored := record.indexed
+
+	if stored.unmined {
+		batch.Delete(heightKey(prefixUnmined, stored.unminedSince, hash))
+	}
+
+	if stored.deleteAtHeight > 0 {
+		batch.Delete(heightKey(prefixDAH, stored.deleteAtHeight, hash))
+	}
+
+	if stored.preserveUntil > 0 {
+		batch.Delete(heightKey(prefixPreserve, stored.preserveUntil, hash))
+	}
+
+	batch.Delete(txKey(hash))
+	batch.Delete(metaKey(hash))
+
+	for vout, o := range record.outputs {
+		if o != nil {
+			batch.Delete(outputKey(hash, vout))
+		}
+	}
+}
+
+// write commits the batch, a no-op for an empty batch
+func (s *Store) write(batch *goleveldb.Batch) error {
+	if batch.Len() == 0 {
+		return nil
+	}
+
+	return s.db.Write(batch, s.writeOptions)
+}
+
+// parentHashes returns the unique hashes of the transactions spent by the inputs
+func parentHashes(inputs []*bt.Input) []chainhash.Hash {
+	hashes := make([]chainhash.Hash, 0, len(inputs))
+
+	for _, input := range inputs {
+		hash := *input.PreviousTxIDChainHash()
+		if !slices.Contains(hashes, hash) {
+			hashes = append(hashes, hash)
+		}
+	}
+
+	return hashes
+}
+
+// setDAH sets the DAH of a transaction that has no unspent outputs left, or that is conflicting, so the cleanup
+// deletes it after the retention period, and clears it otherwise. Preserved transactions are not changed.
+func (s *Store) setDAH(record *txRecord) {
+	retention := s.settings.GetUtxoStoreBlockHeightRetention()
+	if retention == 0 || record.preserveUntil > 0 {
+		return
+	}
+
+	if record.unspentOutputs() == 0 || record.conflicting {
+		record.deleteAtHeight = s.blockHeight.Load() + retention
+	} else {
+		record.deleteAtHeight = 0
+	}
+}
+
+// Create stores a new transaction's outputs as UTXOs.
+// For coinbase transactions, the outputs can only be spent after the coinbase maturity.
+func (s *Store) Create(ctx context.Context, tx *bt.Tx, blockHeight uint32, opts ...utxo.CreateOption) (*meta.Data, error) {
+	options := &utxo.CreateOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	_, _, deferFn := tracing.Tracer("utxo").Start(ctx, "leveldb:Create")
+	defer deferFn()
+
+	txMeta, err := util.TxMetaDataFromTx(tx)
+	if err != nil {
+		return nil, errors.NewProcessingError("failed to get tx meta data", err)
+	}
+
+	if options.Conflicting {
+		txMeta.Conflicting = true
+	}
+
+	if options.Locked {
+		txMeta.Locked = true
+	}
+
+	var txHash *chainhash.Hash
+	if options.TxID != nil {
+		txHash = options.TxID
+	} else {
+		txHash = tx.TxIDChainHash()
+	}
+
+	isCoinbase := tx.IsCoinbase()
+	if options.IsCoinbase != nil {
+		isCoinbase = *options.IsCoinbase
+	}
+
+	insertedAt, err := safeconversion.Int64ToUint64(time.Now().UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+
+	record := &txRecord{
+		isCoinbase:  isCoinbase,
+		frozen:      options.Frozen,
+		conflicting: options.Conflicting,
+		locked:      options.Locked,
+		version:     tx.Version,
+		lockTime:    tx.LockTime,
+		fee:         txMeta.Fee,
+		sizeInBytes: txMeta.SizeInBytes,
+		insertedAt:  insertedAt,
+		inputs:      tx.Inputs,
+	}
+
+	if len(options.MinedBlockInfos) == 0 {
+		// set unmined since only for unmined transactions
+		record.unmined = true
+		record.unminedSince = blockHeight
+	}
+
+	if isCoinbase {
+		record.coinbaseSpendingHeight = blockHeight + uint32(s.settings.ChainCfgParams.CoinbaseMaturity)
+	}
+
+	if len(tx.Outputs) > 0 {
+		record.outputs = make([]*outputRecord, len(tx.Outputs))
+	}
+
+	for i, output := range tx.Outputs {
+		if output == nil {
+			continue
+		}
+
+		iUint32, err := safeconversion.IntToUint32(i)
+		if err != nil {
+			return nil, err
+		}
+
+		utxoHash, err := util.UTXOHashFromOutput(txHash, output, iUint32)
+		if err != nil {
+			return nil, err
+		}
+
+		record.outputs[i] = &outputRecord{
+			output:   output,
+			utxoHash: *utxoHash,
+		}
+	}
+
+	for _, blockMeta := range options.MinedBlockInfos {
+		subtreeIdx, err := safeconversion.IntToUint32(blockMeta.SubtreeIdx)
+		if err != nil {
+			return nil, err
+		}
+
+		record.addBlock(blockRecord{
+			blockID:     blockMeta.BlockID,
+			blockHeight: blockMeta.BlockHeight,
+			subtreeIdx:  subtreeIdx,
+		})
+
+		txMeta.BlockIDs = append(txMeta.BlockIDs, blockMeta.BlockID)
+		txMeta.BlockHeights = append(txMeta.BlockHeights, blockMeta.BlockHeight)
+		txMeta.SubtreeIdxs = append(txMeta.SubtreeIdxs, blockMeta.SubtreeIdx)
+	}
+
+	lockHashes := []chainhash.Hash{*txHash}
+
+	var parents []chainhash.Hash
+
+	if options.Conflicting {
+		parents = parentHashes(tx.Inputs)
+		lockHashes = append(lockHashes, parents...)
+	}
+
+	unlock := s.lockTxs(lockHashes)
+	defer unlock()
+
+	exists, err := s.db.Has(txKey(txHash), nil)
+	if err != nil {
+		return nil, errors.NewStorageError("[LevelDB] failed to check transaction %s", txHash, err)
+	}
+
+	if exists {
+		return nil, errors.NewTxExistsError("Transaction already exists in leveldb store (coinbase=%v)", isCoinbase)
+	}
+
+	batch := new(goleveldb.Batch)
+
+	putRecord(batch, txHash, record)
+
+	if len(parents) > 0 {
+		// add the transaction to the conflicting children of its parents
+		parentRecords, err := s.getRecords(parents)
+		if err != nil {
+			return nil, err
+		}
+
+		for parentHash, parentRecord := range parentRecords {
+			if parentRecord.addConflictingChild(*txHash) {
+				putRecord(batch, &parentHash, parentRecord)
+			}
+		}
+	}
+
+	if err = s.write(batch); err != nil {
+		return nil, errors.NewStorageError("[LevelDB] failed to store transaction %s", txHash, err)
+	}
+
+	return txMeta, nil
+}
+
+// GetMeta retrieves the metadata of a transaction, without the transaction itself.
+func (s *Store) GetMeta(ctx context.Context, hash *chainhash.Hash) (*meta.Data, error) {
+	return s.get(ctx, hash, utxo.MetaFields)
+}
+
+// Get retrieves the requested fields of a transaction, or the metadata and the transaction if no fields are given.
+func (s *Store) Get(ctx context.Context, hash *chainhash.Hash, fields ...fields.FieldName) (*meta.Data, error) {
+	bins := utxo.MetaFieldsWithTx
+	if len(fields) > 0 {
+		bins = fields
+	}
+
+	return s.get(ctx, hash, bins)
+}
+
+func (s *Store) get(_ context.Context, hash *chainhash.Hash, bins []fields.FieldName) (*meta.Data, error) {
+	prometheusUtxoGet.Inc()
+
+	record, err := s.getRecord(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &meta.Data{
+		Fee:         record.fee,
+		SizeInBytes: record.sizeInBytes,
+		IsCoinbase:  record.isCoinbase,
+		LockTime:    record.lockTime,
+		Frozen:      record.frozen,
+		Conflicting: record.conflicting,
+		Locked:      record.locked,
+	}
+
+	if record.unmined {
+		data.UnminedSince = record.unminedSince
+	}
+
+	if slices.Contains(bins, fields.Tx) {
+		data.Tx = record.tx()
+	}
+
+	if slices.Contains(bins, fields.TxInpoints) {
+		data.TxInpoints, err = subtree.NewTxInpointsFromInputs(record.inputs)
+		if err != nil {
+			return nil, errors.NewProcessingError("failed to create tx inpoints from inputs", err)
+		}
+	}
+
+	if slices.Contains(bins, fields.BlockIDs) || slices.Contains(bins, fields.BlockHeights) || slices.Contains(bins, fields.SubtreeIdxs) {
+		for _, block := range record.blocks {
+			data.BlockIDs = append(data.BlockIDs, block.blockID)
+			data.BlockHeights = append(data.BlockHeights, block.blockHeight)
+			data.SubtreeIdxs = append(data.SubtreeIdxs, int(block.subtreeIdx))
+		}
+	}
+
+	if slices.Contains(bins, fields.ConflictingChildren) {
+		data.ConflictingChildren = make([]chainhash.Hash, len(record.conflictingChildren))
+		copy(data.ConflictingChildren, record.conflictingChildren)
+	}
+
+	if slices.Contains(bins, fields.Utxos) {
+		data.SpendingDatas = make([]*spendpkg.SpendingData, len(record.outputs)) // needs to be nullable
+
+		for idx, o := range record.outputs {
+			switch {
+			case o == nil:
+				continue
+			case record.frozen || o.frozen:
+				data.SpendingDatas[idx] = spendpkg.NewSpendingData(&subtree.FrozenBytesTxHash, idx)
+			default:
+				data.SpendingDatas[idx] = o.spendingData
+			}
+		}
+	}
+
+	return data, nil
+}
+
+// Spend marks the outputs spent by the inputs of the transaction as spent.
+// No output is spent if any of them cannot be spent, the error of every spend is set on the returned spends.
+func (s *Store) Spend(ctx context.Context, tx *bt.Tx, blockHeight uint32, ignoreFlags ...utxo.IgnoreFlags) ([]*utxo.Spend, error) {
+	if blockHeight == 0 {
+		return nil, errors.NewProcessingError("blockHeight must be greater than zero")
+	}
+
+	_, _, deferFn := tracing.Tracer("utxo").Start(ctx, "leveldb:Spend")
+	defer deferFn()
+
+	spends, err := utxo.GetSpends(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(spends) == 0 {
+		return nil, errors.NewProcessingError("No spends provided", nil)
+	}
+
+	var useIgnoreConflicting, useIgnoreLocked bool
+
+	if len(ignoreFlags) > 0 {
+		useIgnoreConflicting = ignoreFlags[0].IgnoreConflicting
+		useIgnoreLocked = ignoreFlags[0].IgnoreLocked
+	}
+
+	unlock := s.lockTxs(parentHashes(tx.Inputs))
+	defer unlock()
+
+	records := make(map[chainhash.Hash]*txRecord)
+	errorFound := false
+
+	for _, spend := range spends {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+
+		default:
+		}
+
+		record, ok := records[*spend.TxID]
+		if !ok {
+			record, err = s.getRecord(spend.TxID)
+			if err != nil && !errors.Is(err, errors.ErrTxNotFound) {
+				errorFound = true
+				spend.Err = errors.NewStorageError("[Spend] failed to read %s:%d", spend.TxID, spend.Vout, err)
+
+				continue
+			}
+
+			records[*spend.TxID] = record
+		}
+
+		if record == nil || int(spend.Vout) >= len(record.outputs) || record.outputs[spend.Vout] == nil {
+			errorFound = true
+			spend.Err = errors.NewNotFoundError("output %s:%d not found", spend.TxID, spend.Vout)
+
+			continue
+		}
+
+		o := record.outputs[spend.Vout]
+
+		// If the utxo is frozen, it cannot be spent
+		if record.frozen || o.frozen {
+			errorFound = true
+			spend.Err = errors.NewUtxoFrozenError("[Spend] utxo is frozen for %s:%d", spend.TxID, spend.Vout)
+
+			continue
+		}
+
+		// If the tx is marked as conflicting, it cannot be spent
+		if record.conflicting && !useIgnoreConflicting {
+			errorFound = true
+			spend.Err = errors.NewTxConflictingError("[Spend] tx is conflicting for %s:%d", spend.TxID, spend.Vout)
+
+			continue
+		}
+
+		if record.locked && !useIgnoreLocked {
+			errorFound = true
+			spend.Err = errors.NewTxLockedError("[Spend] utxo is not spendable for %s:%d", spend.TxID, spend.Vout)
+
+			continue
+		}
+
+		if o.spendableIn > 0 && blockHeight < o.spendableIn {
+			errorFound = true
+			spend.Err = errors.NewTxLockedError("[Spend] utxo %s:%d is not spendable until %d", spend.TxID, spend.Vout, o.spendableIn)
+
+			continue
+		}
+
+		// Check if the utxo is already spent
+		if o.spendingData != nil && spend.SpendingData != nil && !bytes.Equal(o.spendingData.Bytes(), spend.SpendingData.Bytes()) {
+			errorFound = true
+			spend.Err = errors.NewUtxoSpentError(*spend.TxID, spend.Vout, *spend.UTXOHash, spend.SpendingData)
+			spend.ConflictingTxID = o.spendingData.TxID
+
+			continue
+		}
+
+		// Check the utxo hash is correct
+		if !o.utxoHash.IsEqual(spend.UTXOHash) {
+			errorFound = true
+			spend.Err = errors.NewStorageError("[Spend] utxo hash mismatch for %s:%d", spend.TxID, spend.Vout)
+
+			continue
+		}
+
+		// If this utxo has a coinbase spending height, check it is time to spend it
+		if record.coinbaseSpendingHeight > 0 && record.coinbaseSpendingHeight > blockHeight {
+			errorFound = true
+			spend.Err = errors.NewStorageError("[Spend] coinbase utxo not ready to spend for %s:%d", spend.TxID, spend.Vout)
+
+			continue
+		}
+
+		o.spendingData = spend.SpendingData
+
+		s.setDAH(record)
+
+		prometheusUtxoSpend.Inc()
+	}
+
+	if errorFound {
+		return spends, errors.NewUtxoError("One or more UTXOs could not be spent")
+	}
+
+	batch := new(goleveldb.Batch)
+
+	for hash, record := range records {
+		putRecord(batch, &hash, record)
+	}
+
+	if err = s.write(batch); err != nil {
+		prometheusUtxoErrors.WithLabelValues("Spend", "write").Inc()
+		return nil, errors.NewStorageError("[Spend] failed to write spends", err)
+	}
+
+	return spends, nil
+}
+
+// Unspend reverses a previous spend operation, marking UTXOs as unspent.
+// Commonly used during blockchain reorganizations.
+func (s *Store) Unspend(ctx context.Context, spends []*utxo.Spend, flagAsLocked ...bool) error {
+	locked := false
+	if len(flagAsLocked) > 0 {
+		locked = flagAsLocked[0]
+	}
+
+	hashes := make([]chainhash.Hash, 0, len(spends))
+
+	for _, spend := range spends {
+		if spend != nil {
+			hashes = append(hashes, *spend.TxID)
+		}
+	}
+
+	unlock := s.lockTxs(hashes)
+	defer unlock()
+
+	records, err := s.getRecords(hashes)
+	if err != nil {
+		return err
+	}
+
+	for _, spend := range spends {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		default:
+		}
+
+		if spend == nil {
+			continue
+		}
+
+		record := records[*spend.TxID]
+		if record == nil || int(spend.Vout) >= len(record.outputs) || record.outputs[spend.Vout] == nil {
+			return errors.NewNotFoundError("output %s:%d not found", spend.TxID, spend.Vout)
+		}
+
+		record.outputs[spend.Vout].spendingData = nil
+		record.locked = locked
+
+		s.setDAH(record)
+
+		prometheusUtxoReset.Inc()
+	}
+
+	batch := new(goleveldb.Batch)
+
+	for hash, record := range records {
+		putRecord(batch, &hash, record)
+	}
+
+	if err = s.write(batch); err != nil {
+		return errors.NewStorageError("[Unspend] failed to write unspends", err)
+	}
+
+	return nil
+}
+
+// Delete removes a transaction and all its associated data.
+// The transaction is also removed from the conflicting children of its parents.
+func (s *Store) Delete(_ context.Context, hash *chainhash.Hash) error {
+	if _, err := s.deleteTx(hash, nil); err != nil {
+		return err
+	}
+
+	prometheusUtxoDelete.Inc()
+
+	return nil
+}
+
+// deleteTx deletes the transaction if it exists and the condition, if given, holds for its record.
+// It returns whether the transaction was deleted.
+func (s *Store) deleteTx(hash *chainhash.Hash, condition func(record *txRecord) bool) (bool, error) {
+	// the parents of a transaction never change, read them before locking them together with the transaction
+	record, err := s.getRecord(hash)
+	if err != nil {
+		if errors.Is(err, errors.ErrTxNotFound) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	parents := parentHashes(record.inputs)
+
+	unlock := s.lockTxs(append([]chainhash.Hash{*hash}, parents...))
+	defer unlock()
+
+	if record, err = s.getRecord(hash); err != nil {
+		if errors.Is(err, errors.ErrTxNotFound) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	if condition != nil && !condition(record) {
+		return false, nil
+	}
+
+	batch := new(goleveldb.Batch)
+
+	deleteRecord(batch, hash, record)
+
+	parentRecords, err := s.getRecords(parents)
+	if err != nil {
+		return false, err
+	}
+
+	for parentHash, parentRecord := range parentRecords {
+		if parentRecord.removeConflictingChild(*hash) {
+			putRecord(batch, &parentHash, parentRecord)
+		}
+	}
+
+	if err = s.write(batch); err != nil {
+		return false, errors.NewStorageError("[LevelDB] failed to delete transaction %s", hash, err)
+	}
+
+	return true, nil
+}
+
+// updateTxs applies the update to the records of the transactions, in batches of batchSize transactions.
+// Missing transactions are passed to the update with a nil record, the update returns whether the record changed.
+func (s *Store) updateTxs(ctx context.Context, hashes []chainhash.Hash, update func(hash *chainhash.Hash, record *txRecord) (bool, error)) error {
+	for start := 0; start < len(hashes); start += batchSize {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		default:
+		}
+
+		end := min(start+batchSize, len(hashes))
+
+		if err := s.updateTxsBatch(hashes[start:end], update); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) updateTxsBatch(hashes []chainhash.Hash, update func(hash *chainhash.Hash, record *txRecord) (bool, error)) error {
+	unlock := s.lockTxs(hashes)
+	defer unlock()
+
+	records, err := s.getRecords(hashes)
+	if err != nil {
+		return err
+	}
+
+	changed := make(map[chainhash.Hash]struct{}, len(records))
+
+	for i := range hashes {
+		record := records[hashes[i]]
+
+		recordChanged, err := update(&hashes[i], record)
+		if err != nil {
+			return err
+		}
+
+		if recordChanged && record != nil {
+			changed[hashes[i]] = struct{}{}
+		}
+	}
+
+	batch := new(goleveldb.Batch)
+
+	for hash := range changed {
+		putRecord(batch, &hash, records[hash])
+	}
+
+	if err = s.write(batch); err != nil {
+		return errors.NewStorageError("[LevelDB] failed to write transactions", err)
+	}
+
+	return nil
+}
+
+// SetMinedMulti adds the block to the blocks the transactions were mined in, or removes it when UnsetMined is set.
+// Transactions that do not exist are skipped. It returns the block ids of every transaction that exists.
+func (s *Store) SetMinedMulti(ctx context.Context, hashes []*chainhash.Hash, minedBlockInfo utxo.MinedBlockInfo) (map[chainhash.Hash][]uint32, error) {
+	_, _, deferFn := tracing.Tracer("utxo").Start(ctx, "leveldb:SetMinedMulti")
+	defer deferFn()
+
+	subtreeIdx, err := safeconversion.IntToUint32(minedBlockInfo.SubtreeIdx)
+	if err != nil {
+		return nil, err
+	}
+
+	txHashes := make([]chainhash.Hash, 0, len(hashes))
+	for _, hash := range hashes {
+		txHashes = append(txHashes, *hash)
+	}
+
+	blockIDsMap := make(map[chainhash.Hash][]uint32, len(hashes))
+
+	err = s.updateTxs(ctx, txHashes, func(hash *chainhash.Hash, record *txRecord) (bool, error) {
+		// skip if the transaction doesn't exist yet (it might be processed later in the same block)
+		if record == nil {
+			return false, nil
+		}
+
+		if minedBlockInfo.UnsetMined {
+			record.removeBlock(minedBlockInfo.BlockID)
+		} else {
+			record.addBlock(blockRecord{
+				blockID:     minedBlockInfo.BlockID,
+				blockHeight: minedBlockInfo.BlockHeight,
+				subtreeIdx:  subtreeIdx,
+			})
+		}
+
+		record.locked = false
+
+		if minedBlockInfo.OnLongestChain {
+			record.unmined = false
+			record.unminedSince = 0
+		}
+
+		blockIDsMap[*hash] = record.blockIDs()
+
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return blockIDsMap, nil
+}
+
+// GetSpend returns the status of an output, and the spending data if it was spent.
+func (s *Store) GetSpend(_ context.Context, spend *utxo.Spend) (*utxo.SpendResponse, error) {
+	record, err := s.getRecord(spend.TxID)
+	if err != nil && !errors.Is(err, errors.ErrTxNotFound) {
+		return nil, err
+	}
+
+	if record == nil || int(spend.Vout) >= len(record.outputs) || record.outputs[spend.Vout] == nil {
+		return nil, errors.NewNotFoundError("utxo not found for %s:%d", spend.TxID, spend.Vout)
+	}
+
+	o := record.outputs[spend.Vout]
+
+	// check utxoHash is the same as expected
+	if !o.utxoHash.IsEqual(spend.UTXOHash) {
+		return nil, errors.NewStorageError("utxo hash mismatch for %s:%d", spend.TxID, spend.Vout)
+	}
+
+	spendingData := o.spendingData
+
+	utxoStatus := utxo.CalculateUtxoStatus(spendingData, record.coinbaseSpendingHeight, s.blockHeight.Load())
+
+	if record.frozen || o.frozen {
+		utxoStatus = utxo.Status_FROZEN
+		// this is needed in for instance conflict resolution where we check the spending data
+		spendingData = spendpkg.NewSpendingData(&subtree.FrozenBytesTxHash, int(spend.Vout))
+	}
+
+	if record.conflicting {
+		utxoStatus = utxo.Status_CONFLICTING
+	}
+
+	if record.locked {
+		utxoStatus = utxo.Status_LOCKED
+	}
+
+	// spendableIn is set by the alert system to indicate when the UTXO can be spent
+	if o.spendableIn > 0 && s.GetBlockHeight() < o.spendableIn {
+		utxoStatus = utxo.Status_IMMATURE
+	}
+
+	return &utxo.SpendResponse{
+		Status:       int(utxoStatus),
+		SpendingData: spendingData,
+		LockTime:     record.coinbaseSpendingHeight,
+	}, nil
+}
+
+// BatchDecorate fetches the metadata of multiple transactions.
+func (s *Store) BatchDecorate(ctx context.Context, unresolvedMetaDataSlice []*utxo.UnresolvedMetaData, fields ...fields.FieldName) error {
+	for _, unresolvedMetaData := range unresolvedMetaDataSlice {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		default:
+			if unresolvedMetaData == nil {
+				continue
+			}
+
+			data, err := s.Get(ctx, &unresolvedMetaData.Hash, fields...)
+			if err != nil {
+				unresolvedMetaData.Err = err
+			} else {
+				unresolvedMetaData.Data = data
+			}
+		}
+	}
+
+	return nil
+}
+
+// PreviousOutputsDecorate fetches output information for transaction inputs.
+func (s *Store) PreviousOutputsDecorate(ctx context.Context, tx *bt.Tx) error {
+	records := make(map[chainhash.Hash]*txRecord)
+
+	var missingInputs []int
+
+	for i, input := range tx.Inputs {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		default:
+		}
+
+		// Skip if already decorated
+		if input == nil || input.PreviousTxScript != nil {
+			continue
+		}
+
+		hash := *input.PreviousTxIDChainHash()
+
+		record, ok := records[hash]
+		if !ok {
+			var err error
+
+			record, err = s.getRecord(&hash)
+			if err != nil && !errors.Is(err, errors.ErrTxNotFound) {
+				return err
+			}
+
+			records[hash] = record
+		}
+
+		if record == nil || int(input.PreviousTxOutIndex) >= len(record.outputs) || record.outputs[input.PreviousTxOutIndex] == nil {
+			// Track missing inputs - they might be in the same block being processed
+			missingInputs = append(missingInputs, i)
+			continue
+		}
+
+		output := record.outputs[input.PreviousTxOutIndex].output
+
+		input.PreviousTxScript = output.LockingScript
+		input.PreviousTxSatoshis = output.Satoshis
+	}
+
+	// If we have missing inputs, return an error indicating they couldn't be found
+	// The caller (ExtendTransaction) will handle this by trying alternative methods
+	if len(missingInputs) > 0 {
+		return errors.NewProcessingError("failed to decorate previous outputs for tx %s", tx.TxIDChainHash())
+	}
+
+	return nil
+}
+
+func (s *Store) GetCounterConflicting(ctx context.Context, hash chainhash.Hash) ([]chainhash.Hash, error) {
+	ctx, _, deferFn := tracing.Tracer("utxo").Start(ctx, "GetCounterConflicting",
+		tracing.WithHistogram(prometheusLevelDBUtxoGetCounterConflicting),
+	)
+
+	defer deferFn()
+
+	return utxo.GetCounterConflictingTxHashes(ctx, s, hash)
+}
+
+// GetConflictingChildren returns a list of conflicting transactions for a given transaction hash.
+func (s *Store) GetConflictingChildren(ctx context.Context, hash chainhash.Hash) ([]chainhash.Hash, error) {
+	ctx, _, deferFn := tracing.Tracer("utxo").Start(ctx, "GetConflicting",
+		tracing.WithHistogram(prometheusLevelDBUtxoGetConflicting),
+	)
+
+	defer deferFn()
+
+	return utxo.GetConflictingChildren(ctx, s, hash)
+}
+
+// SetConflicting marks a list of transactions as conflicting, or clears the flag.
+// It returns the spends of the parent outputs by the transactions, and the transactions spending their outputs.
+func (s *Store) SetConflicting(ctx context.Context, txHashes []chainhash.Hash, setValue bool) ([]*utxo.Spend, []chainhash.Hash, error) {
+	affectedParentSpends := make([]*utxo.Spend, 0, len(txHashes))
+	spendingTxHashes := make([]chainhash.Hash, 0, len(txHashes))
+
+	for i := range txHashes {
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+
+		default:
+		}
+
+		conflictingTxHash := txHashes[i]
+
+		record, err := s.setConflicting(&conflictingTxHash, setValue)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for vin, input := range record.inputs {
+			utxoHash, err := util.UTXOHashFromInput(input)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			affectedParentSpends = append(affectedParentSpends, &utxo.Spend{
+				TxID:         input.PreviousTxIDChainHash(),
+				Vout:         input.PreviousTxOutIndex,
+				UTXOHash:     utxoHash,
+				SpendingData: spendpkg.NewSpendingData(&conflictingTxHash, vin),
+			})
+		}
+
+		for _, o := range record.outputs {
+			if o != nil && !o.frozen && o.spendingData != nil && o.spendingData.TxID != nil {
+				spendingTxHashes = append(spendingTxHashes, *o.spendingData.TxID)
+			}
+		}
+	}
+
+	return affectedParentSpends, spendingTxHashes, nil
+}
+
+// setConflicting sets the conflicting flag of the transaction and adds it to the conflicting children of its parents
+func (s *Store) setConflicting(hash *chainhash.Hash, setValue bool) (*txRecord, error) {
+	// the parents of a transaction never change, read them before locking them together with the transaction
+	record, err := s.getRecord(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	parents := parentHashes(record.inputs)
+
+	unlock := s.lockTxs(append([]chainhash.Hash{*hash}, parents...))
+	defer unlock()
+
+	if record, err = s.getRecord(hash); err != nil {
+		return nil, err
+	}
+
+	record.conflicting = setValue
+
+	// preserved transactions keep their DAH cleared until the preservation expires
+	if record.preserveUntil == 0 {
+		retention := s.settings.GetUtxoStoreBlockHeightRetention()
+
+		if retention > 0 && setValue {
+			record.deleteAtHeight = s.blockHeight.Load() + retention
+		} else {
+			record.deleteAtHeight = 0
+		}
+	}
+
+	batch := new(goleveldb.Batch)
+
+	putRecord(batch, hash, record)
+
+	parentRecords, err := s.getRecords(parents)
+	if err != nil {
+		return nil, err
+	}
+
+	for parentHash, parentRecord := range parentRecords {
+		if parentRecord.addConflictingChild(*hash) {
+			putRecord(batch, &parentHash, parentRecord)
+		}
+	}
+
+	if err = s.write(batch); err != nil {
+		return nil, errors.NewStorageError("failed to set conflicting flag for %s", hash, err)
+	}
+
+	return record, nil
+}
+
+// SetLocked sets or clears the locked flag of the transactions, transactions that do not exist are ignored.
+func (s *Store) SetLocked(ctx context.Context, txHashes []chainhash.Hash, setValue bool) error {
+	return s.updateTxs(ctx, txHashes, func(_ *chainhash.Hash, record *txRecord) (bool, error) {
+		if record == nil {
+			return false, nil
+		}
+
+		record.locked = setValue
+
+		return true, nil
+	})
+}
+
+// MarkTransactionsOnLongestChain clears the unmined since height of transactions that are on the longest chain,
+// and sets it to the current block height for transactions that are not.
+func (s *Store) MarkTransactionsOnLongestChain(ctx context.Context, txHashes []chainhash.Hash, onLongestChain bool) error {
+	missingTxErrors := make([]error, 0, 10)
+	attempted := len(txHashes)
+	errorCount := 0
+
+	currentBlockHeight := s.GetBlockHeight()
+
+	err := s.updateTxs(ctx, txHashes, func(hash *chainhash.Hash, record *txRecord) (bool, error) {
+		if record == nil {
+			errorCount++
+			// Transaction not found - this is FATAL (data corruption)
+			if len(missingTxErrors) < 10 {
+				missingTxErrors = append(missingTxErrors, errors.NewStorageError("MISSING transaction %s", hash))
+			}
+
+			return false, nil
+		}
+
+		if onLongestChain {
+			record.unmined = false
+			record.unminedSince = 0
+		} else {
+			record.unmined = true
+			record.unminedSince = currentBlockHeight
+		}
+
+		return true, nil
+	})
+	if err != nil {
+		s.logger.Errorf("[MarkTransactionsOnLongestChain] error marking transactions, onLongestChain=%t: %v", onLongestChain, err)
+
+		return errors.NewStorageError("failed to mark transactions on longest chain=%t", onLongestChain, err)
+	}
+
+	// Log summary
+	succeeded := attempted - errorCount
+	s.logger.Infof("[MarkTransactionsOnLongestChain] completed: attempted=%d, succeeded=%d, failed=%d, onLongestChain=%t",
+		attempted, succeeded, errorCount, onLongestChain)
+
+	// FATAL if we have missing transactions - this indicates data corruption
+	if len(missingTxErrors) > 0 {
+		s.logger.Fatalf("CRITICAL: %d missing transactions during MarkTransactionsOnLongestChain - data integrity compromised. First errors: %v",
+			len(missingTxErrors), errors.Join(missingTxErrors...))
+	}
+
+	return nil
+}
+
+// QueryOldUnminedTransactions returns transaction hashes for unmined transactions older than the cutoff height.
+// This method is used by the store-agnostic cleanup implementation.
+func (s *Store) QueryOldUnminedTransactions(ctx context.Context, cutoffBlockHeight uint32) ([]chainhash.Hash, error) {
+	iter := s.db.NewIterator(heightRange(prefixUnmined, cutoffBlockHeight), nil)
+	defer iter.Release()
+
+	var txHashes []chainhash.Hash
+
+	for iter.Next() && len(txHashes) < maxOldUnminedTransactions {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		txHashes = append(txHashes, chainhash.Hash(iter.Key()[5:]))
+	}
+
+	if err := iter.Error(); err != nil {
+		return nil, errors.NewStorageError("error iterating unmined transactions", err)
+	}
+
+	return txHashes, nil
+}
+
+// PreserveTransactions marks transactions to be preserved from deletion until a specific block height.
+// This clears any existing DeleteAtHeight and sets PreserveUntil to the specified height.
+// Used to protect parent transactions when cleaning up unmined transactions.
+func (s *Store) PreserveTransactions(ctx context.Context, txIDs []chainhash.Hash, preserveUntilHeight uint32) error {
+	preserved := 0
+
+	err := s.updateTxs(ctx, txIDs, func(_ *chainhash.Hash, record *txRecord) (bool, error) {
+		if record == nil {
+			return false, nil
+		}
+
+		record.preserveUntil = preserveUntilHeight
+		record.deleteAtHeight = 0
+		preserved++
+
+		return true, nil
+	})
+	if err != nil {
+		return errors.NewStorageError("failed to preserve transactions", err)
+	}
+
+	s.logger.Debugf("[PreserveTransactions] Successfully preserved %d out of %d transactions", preserved, len(txIDs))
+
+	return nil
+}
+
+// ProcessExpiredPreservations handles transactions whose preservation period has expired.
+// For each transaction with PreserveUntil <= currentHeight, it sets an appropriate DeleteAtHeight
+// and clears the PreserveUntil field.
+func (s *Store) ProcessExpiredPreservations(ctx context.Context, currentHeight uint32) error {
+	deleteAtHeight := currentHeight + s.settings.GetUtxoStoreBlockHeightRetention()
+	processed := 0
+
+	for {
+		txHashes, err := s.scanHeightIndex(prefixPreserve, currentHeight, batchSize)
+		if err != nil {
+			return errors.NewStorageError("failed to process expired preservations", err)
+		}
+
+		if len(txHashes) == 0 {
+			break
+		}
+
+		processedBefore := processed
+
+		err = s.updateTxs(ctx, txHashes, func(_ *chainhash.Hash, record *txRecord) (bool, error) {
+			if record == nil || record.preserveUntil == 0 || record.preserveUntil > currentHeight {
+				return false, nil
+			}
+
+			record.deleteAtHeight = deleteAtHeight
+			record.preserveUntil = 0
+			processed++
+
+			return true, nil
+		})
+		if err != nil {
+			return errors.NewStorageError("failed to process expired preservations", err)
+		}
+
+		if processed == processedBefore {
+			// the index entries left do not belong to expired preservations
+			break
+		}
+	}
+
+	s.logger.Infof("[ProcessExpiredPreservations] Processed %d expired preservations at height %d", processed, currentHeight)
+
+	return nil
+}
+
+// scanHeightIndex returns up to limit transactions in the index with a height up to and including the given height
+func (s *Store) scanHeightIndex(prefix byte, height uint32, limit int) ([]chainhash.Hash, error) {
+	iter := s.db.NewIterator(heightRange(prefix, height), nil)
+	defer iter.Release()
+
+	txHashes := make([]chainhash.Hash, 0, limit)
+
+	for len(txHashes) < limit && iter.Next() {
+		txHashes = append(txHashes, chainhash.Hash(iter.Key()[5:]))
+	}
+
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+
+	return txHashes, nil
+}
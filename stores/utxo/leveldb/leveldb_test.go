@@ -0,0 +1,543 @@
+package leveldb
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bt/v2"
+	"github.com/bsv-blockchain/go-bt/v2/bscript"
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/settings"
+	"github.com/bsv-blockchain/teranode/stores/cleanup"
+	"github.com/bsv-blockchain/teranode/stores/utxo"
+	"github.com/bsv-blockchain/teranode/stores/utxo/fields"
+	spendpkg "github.com/bsv-blockchain/teranode/stores/utxo/spend"
+	"github.com/bsv-blockchain/teranode/stores/utxo/tests"
+	"github.com/bsv-blockchain/teranode/ulogger"
+	"github.com/bsv-blockchain/teranode/util/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setup(t *testing.T, dir ...string) (*Store, *settings.Settings) {
+	tSettings := test.CreateBaseTestSettings(t)
+
+	path := t.TempDir()
+	if len(dir) > 0 {
+		path = dir[0]
+	}
+
+	store, err := New(context.Background(), ulogger.TestLogger{}, tSettings, &url.URL{Scheme: "leveldb", Path: path})
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = store.Close()
+	})
+
+	return store, tSettings
+}
+
+// newSpendingTx returns a transaction spending the output of the parent to a single output
+func newSpendingTx(t *testing.T, parent *bt.Tx, vout uint32) *bt.Tx {
+	tx := bt.NewTx()
+
+	require.NoError(t, tx.From(parent.TxIDChainHash().String(), vout, parent.Outputs[vout].LockingScript.String(), parent.Outputs[vout].Satoshis))
+	require.NoError(t, tx.PayToAddress("1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", parent.Outputs[vout].Satoshis/2))
+
+	return tx
+}
+
+func Test_SmokeTests(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("leveldb store", func(t *testing.T) {
+		db, _ := setup(t)
+
+		err := db.Delete(ctx, tests.TXHash)
+		require.NoError(t, err)
+
+		tests.Store(t, db)
+	})
+
+	t.Run("leveldb spend", func(t *testing.T) {
+		db, _ := setup(t)
+
+		err := db.Delete(ctx, tests.TXHash)
+		require.NoError(t, err)
+
+		tests.Spend(t, db)
+	})
+
+	t.Run("leveldb reset", func(t *testing.T) {
+		db, _ := setup(t)
+
+		err := db.Delete(ctx, tests.TXHash)
+		require.NoError(t, err)
+
+		tests.Restore(t, db)
+	})
+
+	t.Run("leveldb freeze", func(t *testing.T) {
+		db, _ := setup(t)
+
+		err := db.Delete(ctx, tests.TXHash)
+		require.NoError(t, err)
+
+		tests.Freeze(t, db)
+	})
+
+	t.Run("leveldb reassign", func(t *testing.T) {
+		db, _ := setup(t)
+
+		err := db.Delete(ctx, tests.TXHash)
+		require.NoError(t, err)
+
+		tests.ReAssign(t, db)
+	})
+
+	t.Run("set mined", func(t *testing.T) {
+		db, _ := setup(t)
+
+		err := db.Delete(ctx, tests.TXHash)
+		require.NoError(t, err)
+
+		tests.SetMined(t, db)
+	})
+
+	t.Run("leveldb conflicting tx", func(t *testing.T) {
+		db, _ := setup(t)
+
+		err := db.Delete(ctx, tests.TXHash)
+		require.NoError(t, err)
+
+		tests.Conflicting(t, db)
+	})
+
+	t.Run("leveldb sanity", func(t *testing.T) {
+		db, _ := setup(t)
+
+		tests.Sanity(t, db)
+	})
+}
+
+func TestTxRecord(t *testing.T) {
+	lockingScript := bscript.NewFromBytes([]byte{0x76, 0xa9})
+	emptyScript := bscript.NewFromBytes([]byte{})
+
+	input := &bt.Input{
+		PreviousTxOutIndex: 3,
+		PreviousTxSatoshis: 1000,
+		PreviousTxScript:   lockingScript,
+		UnlockingScript:    emptyScript,
+		SequenceNumber:     0xffffffff,
+	}
+	require.NoError(t, input.PreviousTxIDAdd(tests.TXHash))
+
+	record := &txRecord{
+		isCoinbase:             true,
+		conflicting:            true,
+		unmined:                true,
+		version:                2,
+		lockTime:               500,
+		unminedSince:           0,
+		deleteAtHeight:         110,
+		coinbaseSpendingHeight: 200,
+		fee:                    12,
+		sizeInBytes:            250,
+		insertedAt:             1700000000000,
+		inputs:                 []*bt.Input{input},
+		outputs: []*outputRecord{
+			{
+				output:       &bt.Output{Satoshis: 600, LockingScript: lockingScript},
+				utxoHash:     chainhash.HashH([]byte("utxo0")),
+				spendingData: spendpkg.NewSpendingData(tests.Hash, 1),
+			},
+			nil,
+			{
+				output:      &bt.Output{Satoshis: 400},
+				utxoHash:    chainhash.HashH([]byte("utxo2")),
+				frozen:      true,
+				spendableIn: 1101,
+			},
+		},
+		blocks:              []blockRecord{{blockID: 123, blockHeight: 101, subtreeIdx: 2}},
+		conflictingChildren: []chainhash.Hash{*tests.Hash},
+	}
+
+	b := record.txBytes()
+
+	decoded, err := newTxRecordFromBytes(b)
+	require.NoError(t, err)
+
+	meta := record.metaBytes()
+	require.NoError(t, decoded.decodeMeta(meta))
+
+	for vout, o := range record.outputs {
+		if o != nil {
+			require.NoError(t, decoded.outputs[vout].decodeState(o.stateBytes()))
+		}
+	}
+
+	assert.True(t, decoded.isCoinbase)
+	assert.True(t, decoded.conflicting)
+	assert.True(t, decoded.unmined)
+	assert.False(t, decoded.frozen)
+	assert.False(t, decoded.locked)
+	assert.Equal(t, record.insertedAt, decoded.insertedAt)
+	assert.Equal(t, record.coinbaseSpendingHeight, decoded.coinbaseSpendingHeight)
+	assert.Equal(t, record.deleteAtHeight, decoded.deleteAtHeight)
+	assert.Equal(t, record.indexState(), decoded.indexed)
+	assert.Equal(t, record.blocks, decoded.blocks)
+	assert.Equal(t, record.conflictingChildren, decoded.conflictingChildren)
+
+	require.Len(t, decoded.inputs, 1)
+	assert.Equal(t, tests.TXHash.String(), decoded.inputs[0].PreviousTxIDChainHash().String())
+	assert.Equal(t, lockingScript, decoded.inputs[0].PreviousTxScript)
+	require.NotNil(t, decoded.inputs[0].UnlockingScript)
+	assert.Empty(t, *decoded.inputs[0].UnlockingScript)
+
+	require.Len(t, decoded.outputs, 3)
+	assert.Nil(t, decoded.outputs[1])
+	assert.Equal(t, record.outputs[0].utxoHash, decoded.outputs[0].utxoHash)
+	assert.Equal(t, tests.Hash.String(), decoded.outputs[0].spendingData.TxID.String())
+	assert.Equal(t, 1, decoded.outputs[0].spendingData.Vin)
+	assert.Nil(t, decoded.outputs[2].output.LockingScript)
+	assert.True(t, decoded.outputs[2].frozen)
+	assert.Equal(t, uint32(1101), decoded.outputs[2].spendableIn)
+
+	tx := decoded.tx()
+	require.Len(t, tx.Outputs, 3)
+	assert.Nil(t, tx.Outputs[1])
+
+	// the decoded record serializes to the same bytes
+	assert.Equal(t, b, decoded.txBytes())
+	assert.Equal(t, meta, decoded.metaBytes())
+	assert.Equal(t, record.outputs[0].stateBytes(), decoded.outputs[0].stateBytes())
+
+	// truncated parts are rejected
+	for i := 0; i < len(b); i++ {
+		_, err = newTxRecordFromBytes(b[:i])
+		require.Error(t, err, "transaction truncated to %d bytes", i)
+	}
+
+	for i := 0; i < len(meta); i++ {
+		require.Error(t, (&txRecord{}).decodeMeta(meta[:i]), "meta data truncated to %d bytes", i)
+	}
+
+	state := record.outputs[0].stateBytes()
+	for i := 0; i < len(state); i++ {
+		require.Error(t, (&outputRecord{}).decodeState(state[:i]), "output state truncated to %d bytes", i)
+	}
+
+	_, err = newTxRecordFromBytes(append(b, 0))
+	require.Error(t, err)
+
+	require.Error(t, (&txRecord{}).decodeMeta(append(meta, 0)))
+	require.Error(t, (&outputRecord{}).decodeState(append(state, 0)))
+}
+
+func TestRecordKeys(t *testing.T) {
+	ctx := context.Background()
+
+	store, _ := setup(t)
+
+	parent := tests.Tx
+	parentHash := parent.TxIDChainHash()
+
+	_, err := store.Create(ctx, parent, 100)
+	require.NoError(t, err)
+
+	// the transaction, its meta data and the state of every output are stored under their own keys
+	assert.Equal(t, map[byte]int{prefixTx: 1, prefixMeta: 1, prefixOutput: len(parent.Outputs), prefixUnmined: 1}, keysByPrefix(t, store))
+
+	txBytes, err := store.db.Get(txKey(parentHash), nil)
+	require.NoError(t, err)
+
+	metaBytes, err := store.db.Get(metaKey(parentHash), nil)
+	require.NoError(t, err)
+
+	// a spend only writes the state of the spent output
+	_, err = store.Spend(ctx, newSpendingTx(t, parent, 0), 101)
+	require.NoError(t, err)
+
+	unchanged, err := store.db.Get(txKey(parentHash), nil)
+	require.NoError(t, err)
+	assert.Equal(t, txBytes, unchanged)
+
+	unchanged, err = store.db.Get(metaKey(parentHash), nil)
+	require.NoError(t, err)
+	assert.Equal(t, metaBytes, unchanged)
+
+	spentState, err := store.db.Get(outputKey(parentHash, 0), nil)
+	require.NoError(t, err)
+
+	// marking the transaction as mined only writes its meta data
+	_, err = store.SetMinedMulti(ctx, []*chainhash.Hash{parentHash}, utxo.MinedBlockInfo{BlockID: 1, BlockHeight: 101, OnLongestChain: true})
+	require.NoError(t, err)
+
+	unchanged, err = store.db.Get(txKey(parentHash), nil)
+	require.NoError(t, err)
+	assert.Equal(t, txBytes, unchanged)
+
+	unchanged, err = store.db.Get(outputKey(parentHash, 0), nil)
+	require.NoError(t, err)
+	assert.Equal(t, spentState, unchanged)
+
+	txMeta, err := store.Get(ctx, parentHash, fields.BlockIDs, fields.Utxos)
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{1}, txMeta.BlockIDs)
+	require.NotNil(t, txMeta.SpendingDatas[0])
+	assert.Nil(t, txMeta.SpendingDatas[1])
+
+	require.NoError(t, store.Delete(ctx, parentHash))
+	assert.Empty(t, keysByPrefix(t, store))
+}
+
+// keysByPrefix returns the number of keys in the database by their prefix
+func keysByPrefix(t *testing.T, store *Store) map[byte]int {
+	iter := store.db.NewIterator(nil, nil)
+	defer iter.Release()
+
+	keys := make(map[byte]int)
+
+	for iter.Next() {
+		keys[iter.Key()[0]]++
+	}
+
+	require.NoError(t, iter.Error())
+
+	return keys
+}
+
+func TestDeleteAtHeight(t *testing.T) {
+	ctx := context.Background()
+
+	store, tSettings := setup(t)
+	tSettings.GlobalBlockHeightRetention = 10
+
+	parentTx := newSpendingTx(t, tests.Tx, 0)
+	childTx := newSpendingTx(t, parentTx, 0)
+
+	require.NoError(t, store.SetBlockHeight(100))
+
+	_, err := store.Create(ctx, parentTx, 100)
+	require.NoError(t, err)
+
+	_, err = store.Spend(ctx, childTx, 101)
+	require.NoError(t, err)
+
+	record, err := store.getRecord(parentTx.TxIDChainHash())
+	require.NoError(t, err)
+	assert.Equal(t, uint32(110), record.deleteAtHeight)
+
+	txHashes, err := store.scanHeightIndex(prefixDAH, 110, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []chainhash.Hash{*parentTx.TxIDChainHash()}, txHashes)
+
+	// unspending clears the DAH and its index entry
+	spends, err := utxo.GetSpends(childTx)
+	require.NoError(t, err)
+	require.NoError(t, store.Unspend(ctx, spends))
+
+	record, err = store.getRecord(parentTx.TxIDChainHash())
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), record.deleteAtHeight)
+
+	txHashes, err = store.scanHeightIndex(prefixDAH, 0xffffffff, 10)
+	require.NoError(t, err)
+	assert.Empty(t, txHashes)
+
+	t.Run("preservation", func(t *testing.T) {
+		require.NoError(t, store.PreserveTransactions(ctx, []chainhash.Hash{*parentTx.TxIDChainHash()}, 120))
+
+		// a preserved transaction does not get a DAH when its outputs are spent
+		_, err = store.Spend(ctx, childTx, 101)
+		require.NoError(t, err)
+
+		record, err = store.getRecord(parentTx.TxIDChainHash())
+		require.NoError(t, err)
+		assert.Equal(t, uint32(0), record.deleteAtHeight)
+		assert.Equal(t, uint32(120), record.preserveUntil)
+
+		require.NoError(t, store.ProcessExpiredPreservations(ctx, 119))
+
+		record, err = store.getRecord(parentTx.TxIDChainHash())
+		require.NoError(t, err)
+		assert.Equal(t, uint32(120), record.preserveUntil)
+
+		require.NoError(t, store.ProcessExpiredPreservations(ctx, 120))
+
+		record, err = store.getRecord(parentTx.TxIDChainHash())
+		require.NoError(t, err)
+		assert.Equal(t, uint32(130), record.deleteAtHeight)
+		assert.Equal(t, uint32(0), record.preserveUntil)
+
+		txHashes, err = store.scanHeightIndex(prefixPreserve, 0xffffffff, 10)
+		require.NoError(t, err)
+		assert.Empty(t, txHashes)
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		service, err := store.GetCleanupService()
+		require.NoError(t, err)
+
+		service.Start(ctx)
+
+		// the cleanup is limited by the height persisted by the block persister
+		service.SetPersistedHeightGetter(func() uint32 { return 115 })
+
+		doneCh := make(chan string, 1)
+		require.NoError(t, service.UpdateBlockHeight(140, doneCh))
+		require.Equal(t, cleanup.JobStatusCompleted.String(), <-doneCh)
+
+		_, err = store.Get(ctx, parentTx.TxIDChainHash())
+		require.NoError(t, err)
+
+		service.SetPersistedHeightGetter(func() uint32 { return 0 })
+
+		doneCh = make(chan string, 1)
+		require.NoError(t, service.UpdateBlockHeight(141, doneCh))
+		require.Equal(t, cleanup.JobStatusCompleted.String(), <-doneCh)
+
+		_, err = store.Get(ctx, parentTx.TxIDChainHash())
+		require.ErrorIs(t, err, errors.ErrTxNotFound)
+
+		txHashes, err = store.scanHeightIndex(prefixDAH, 0xffffffff, 10)
+		require.NoError(t, err)
+		assert.Empty(t, txHashes)
+	})
+}
+
+func TestConflictingChildren(t *testing.T) {
+	ctx := context.Background()
+
+	store, tSettings := setup(t)
+	tSettings.GlobalBlockHeightRetention = 10
+
+	require.NoError(t, store.SetBlockHeight(100))
+
+	parentTx := newSpendingTx(t, tests.Tx, 0)
+	childTx := newSpendingTx(t, parentTx, 0)
+
+	_, err := store.Create(ctx, parentTx, 100)
+	require.NoError(t, err)
+
+	_, err = store.Create(ctx, childTx, 100)
+	require.NoError(t, err)
+
+	affectedParentSpends, spendingTxHashes, err := store.SetConflicting(ctx, []chainhash.Hash{*childTx.TxIDChainHash()}, true)
+	require.NoError(t, err)
+
+	require.Len(t, affectedParentSpends, 1)
+	assert.Equal(t, parentTx.TxIDChainHash().String(), affectedParentSpends[0].TxID.String())
+	assert.Equal(t, childTx.TxIDChainHash().String(), affectedParentSpends[0].SpendingData.TxID.String())
+	assert.Empty(t, spendingTxHashes)
+
+	txMeta, err := store.Get(ctx, childTx.TxIDChainHash(), fields.Conflicting)
+	require.NoError(t, err)
+	assert.True(t, txMeta.Conflicting)
+
+	record, err := store.getRecord(childTx.TxIDChainHash())
+	require.NoError(t, err)
+	assert.Equal(t, uint32(110), record.deleteAtHeight)
+
+	children, err := store.GetConflictingChildren(ctx, *parentTx.TxIDChainHash())
+	require.NoError(t, err)
+	assert.Equal(t, []chainhash.Hash{*childTx.TxIDChainHash()}, children)
+
+	// deleting the child removes it from the conflicting children of the parent
+	require.NoError(t, store.Delete(ctx, childTx.TxIDChainHash()))
+
+	txMeta, err = store.Get(ctx, parentTx.TxIDChainHash(), fields.ConflictingChildren)
+	require.NoError(t, err)
+	assert.Empty(t, txMeta.ConflictingChildren)
+}
+
+func TestUnminedTransactions(t *testing.T) {
+	ctx := context.Background()
+
+	store, _ := setup(t)
+
+	require.NoError(t, store.SetBlockHeight(20))
+
+	tx1 := newSpendingTx(t, tests.Tx, 0)
+	tx2 := newSpendingTx(t, tests.Tx, 1)
+	tx3 := newSpendingTx(t, tests.Tx, 2)
+
+	_, err := store.Create(ctx, tx1, 5)
+	require.NoError(t, err)
+
+	_, err = store.Create(ctx, tx2, 15)
+	require.NoError(t, err)
+
+	_, err = store.Create(ctx, tx3, 15, utxo.WithMinedBlockInfo(utxo.MinedBlockInfo{BlockID: 1, BlockHeight: 15}))
+	require.NoError(t, err)
+
+	txHashes, err := store.QueryOldUnminedTransactions(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []chainhash.Hash{*tx1.TxIDChainHash()}, txHashes)
+
+	txHashes, err = store.QueryOldUnminedTransactions(ctx, 20)
+	require.NoError(t, err)
+	assert.Equal(t, []chainhash.Hash{*tx1.TxIDChainHash(), *tx2.TxIDChainHash()}, txHashes)
+
+	// mined on the longest chain, the transaction is no longer unmined
+	_, err = store.SetMinedMulti(ctx, []*chainhash.Hash{tx1.TxIDChainHash()}, utxo.MinedBlockInfo{BlockID: 2, BlockHeight: 20, OnLongestChain: true})
+	require.NoError(t, err)
+
+	txHashes, err = store.QueryOldUnminedTransactions(ctx, 20)
+	require.NoError(t, err)
+	assert.Equal(t, []chainhash.Hash{*tx2.TxIDChainHash()}, txHashes)
+
+	// moved off the longest chain, the transaction is unmined since the current height
+	require.NoError(t, store.MarkTransactionsOnLongestChain(ctx, []chainhash.Hash{*tx3.TxIDChainHash()}, false))
+
+	txMeta, err := store.Get(ctx, tx3.TxIDChainHash(), fields.UnminedSince)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(20), txMeta.UnminedSince)
+
+	_, _, err = store.SetConflicting(ctx, []chainhash.Hash{*tx2.TxIDChainHash()}, true)
+	require.NoError(t, err)
+
+	// conflicting transactions are skipped by the iterator
+	it, err := store.GetUnminedTxIterator(false)
+	require.NoError(t, err)
+
+	unminedTx, err := it.Next(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, unminedTx)
+	assert.Equal(t, tx3.TxIDChainHash().String(), unminedTx.Hash.String())
+	assert.Equal(t, []uint32{1}, unminedTx.BlockIDs)
+	assert.Equal(t, 20, unminedTx.UnminedSince)
+
+	unminedTx, err = it.Next(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, unminedTx)
+
+	require.NoError(t, it.Close())
+}
+
+func TestReopen(t *testing.T) {
+	ctx := context.Background()
+
+	dir := t.TempDir()
+
+	store, _ := setup(t, dir)
+
+	_, err := store.Create(ctx, tests.Tx, 100)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Close())
+
+	store, _ = setup(t, dir)
+
+	txMeta, err := store.Get(ctx, tests.TXHash)
+	require.NoError(t, err)
+	assert.Equal(t, tests.TXHash.String(), txMeta.Tx.TxIDChainHash().String())
+	assert.Equal(t, uint32(100), txMeta.UnminedSince)
+
+	_, err = store.Create(ctx, tests.Tx, 100)
+	require.ErrorIs(t, err, errors.ErrTxExists)
+}
@@ -0,0 +1,102 @@
+package leveldb
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	prometheusUtxoGet    prometheus.Counter
+	prometheusUtxoSpend  prometheus.Counter
+	prometheusUtxoReset  prometheus.Counter
+	prometheusUtxoDelete prometheus.Counter
+	prometheusUtxoErrors *prometheus.CounterVec
+
+	prometheusUtxoCleanupBatch                 prometheus.Histogram
+	prometheusLevelDBUtxoGetCounterConflicting prometheus.Histogram
+	prometheusLevelDBUtxoGetConflicting        prometheus.Histogram
+
+	// only init the metrics once
+	prometheusMetricsInitOnce sync.Once
+)
+
+func initPrometheusMetrics() {
+	prometheusMetricsInitOnce.Do(_initPrometheusMetrics)
+}
+
+func _initPrometheusMetrics() {
+	prometheusUtxoGet = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "teranode",
+			Subsystem: "leveldb",
+			Name:      "utxo_get",
+			Help:      "Number of utxo get calls done to leveldb",
+		},
+	)
+	prometheusUtxoSpend = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "teranode",
+			Subsystem: "leveldb",
+			Name:      "utxo_spend",
+			Help:      "Number of utxo spend calls done to leveldb",
+		},
+	)
+	prometheusUtxoReset = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "teranode",
+			Subsystem: "leveldb",
+			Name:      "utxo_reset",
+			Help:      "Number of utxo reset calls done to leveldb",
+		},
+	)
+	prometheusUtxoDelete = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "teranode",
+			Subsystem: "leveldb",
+			Name:      "utxo_delete",
+			Help:      "Number of utxo delete calls done to leveldb",
+		},
+	)
+	prometheusUtxoErrors = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "teranode",
+			Subsystem: "leveldb",
+			Name:      "utxo_errors",
+			Help:      "Number of utxo errors",
+		},
+		[]string{
+			"function", // function raising the error
+			"error",    // error returned
+		},
+	)
+
+	prometheusUtxoCleanupBatch = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "teranode",
+			Subsystem: "leveldb",
+			Name:      "utxo_cleanup_batch",
+			Help:      "Histogram of the number of transactions deleted by a leveldb cleanup job",
+			Buckets:   prometheus.ExponentialBuckets(1, 4, 10),
+		},
+	)
+
+	prometheusLevelDBUtxoGetCounterConflicting = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "teranode",
+			Subsystem: "leveldb",
+			Name:      "utxo_get_counter_conflicting",
+			Help:      "Histogram of utxo get counter conflicting calls done to leveldb",
+		},
+	)
+
+	prometheusLevelDBUtxoGetConflicting = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "teranode",
+			Subsystem: "leveldb",
+			Name:      "utxo_get_conflicting",
+			Help:      "Histogram of utxo get conflicting calls done to leveldb",
+		},
+	)
+}
@@ -0,0 +1,630 @@
+package leveldb
+
+import (
+	"encoding/binary"
+
+	"github.com/bsv-blockchain/go-bt/v2"
+	"github.com/bsv-blockchain/go-bt/v2/bscript"
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/bsv-blockchain/teranode/errors"
+	spendpkg "github.com/bsv-blockchain/teranode/stores/utxo/spend"
+)
+
+const (
+	// recordVersion is the version of the serialized transaction
+	recordVersion = 2
+
+	flagCoinbase = 1 << 0
+
+	metaFlagFrozen      = 1 << 0
+	metaFlagConflicting = 1 << 1
+	metaFlagLocked      = 1 << 2
+	metaFlagUnmined     = 1 << 3
+
+	outputFlagSpent  = 1 << 0
+	outputFlagFrozen = 1 << 1
+)
+
+// txRecord is the state of a transaction and its outputs. The record is stored under three kinds of keys, so a
+// change only writes the part of the record that changed:
+//   - the transaction, which never changes after it is created
+//   - the meta data, holding the flags, heights, blocks and conflicting children of the transaction
+//   - the state of every stored output, its utxo hash and spending data
+//
+// The serialization formats are as follows, all integers are little-endian.
+//
+// Transaction:
+//   - 1 byte: record version
+//   - 1 byte: flags, coinbase
+//   - 3 x 4 bytes: tx version, lock time and coinbase spending height
+//   - 3 x 8 bytes: fee, size in bytes and the unix milli time the record was inserted at
+//   - varint + inputs: previous tx id, previous index, previous satoshis, previous script, unlocking script, sequence
+//   - varint + outputs: presence byte, satoshis, locking script
+//
+// Meta data:
+//   - 1 byte: flags, frozen, conflicting, locked and unmined
+//   - 3 x 4 bytes: unmined since, delete at height and preserve until
+//   - varint + blocks: block id, block height and subtree index
+//   - varint + conflicting children: tx hash
+//
+// Output state:
+//   - 32 bytes: utxo hash
+//   - 1 byte: flags, spent and frozen
+//   - 4 bytes: spendable in
+//   - 36 bytes: spending data, only for spent outputs
+//
+// Scripts are written as a varint of the length plus one, followed by the script, a zero varint is a nil script.
+type txRecord struct {
+	isCoinbase  bool
+	frozen      bool
+	conflicting bool
+	locked      bool
+	unmined     bool
+
+	version                uint32
+	lockTime               uint32
+	unminedSince           uint32
+	deleteAtHeight         uint32
+	preserveUntil          uint32
+	coinbaseSpendingHeight uint32
+
+	fee         uint64
+	sizeInBytes uint64
+	insertedAt  uint64
+
+	inputs              []*bt.Input
+	outputs             []*outputRecord
+	blocks              []blockRecord
+	conflictingChildren []chainhash.Hash
+
+	// storedMeta holds the meta data as it is stored, nil for a record that was not stored yet, so a write
+	// only touches the meta data when it changed
+	storedMeta []byte
+
+	// indexed holds the index entries of the record as they are stored, so a write only touches the index
+	// entries that changed
+	indexed indexState
+}
+
+// outputRecord is the state of a single output, a nil output record is an output that was not stored
+type outputRecord struct {
+	output       *bt.Output
+	utxoHash     chainhash.Hash
+	frozen       bool
+	spendableIn  uint32
+	spendingData *spendpkg.SpendingData
+
+	// stored holds the state as it is stored, so a write only touches the outputs that changed
+	stored []byte
+}
+
+// blockRecord is a block the transaction was mined in
+type blockRecord struct {
+	blockID     uint32
+	blockHeight uint32
+	subtreeIdx  uint32
+}
+
+// indexState is the part of a record that is indexed under a secondary key
+type indexState struct {
+	unmined        bool
+	unminedSince   uint32
+	deleteAtHeight uint32
+	preserveUntil  uint32
+}
+
+// indexState returns the current index entries of the record
+func (r *txRecord) indexState() indexState {
+	return indexState{
+		unmined:        r.unmined,
+		unminedSince:   r.unminedSince,
+		deleteAtHeight: r.deleteAtHeight,
+		preserveUntil:  r.preserveUntil,
+	}
+}
+
+// tx returns the transaction held by the record, outputs that were not stored are nil
+func (r *txRecord) tx() *bt.Tx {
+	tx := &bt.Tx{
+		Version:  r.version,
+		LockTime: r.lockTime,
+		Inputs:   r.inputs,
+	}
+
+	if len(r.outputs) > 0 {
+		tx.Outputs = make([]*bt.Output, len(r.outputs))
+
+		for i, o := range r.outputs {
+			if o != nil {
+				tx.Outputs[i] = o.output
+			}
+		}
+	}
+
+	return tx
+}
+
+// unspentOutputs returns the number of stored outputs that are not spent
+func (r *txRecord) unspentOutputs() int {
+	unspent := 0
+
+	for _, o := range r.outputs {
+		if o != nil && o.spendingData == nil {
+			unspent++
+		}
+	}
+
+	return unspent
+}
+
+// blockIDs returns the ids of the blocks the transaction was mined in, in ascending order
+func (r *txRecord) blockIDs() []uint32 {
+	blockIDs := make([]uint32, 0, len(r.blocks))
+
+	for _, b := range r.blocks {
+		blockIDs = append(blockIDs, b.blockID)
+	}
+
+	return blockIDs
+}
+
+// addBlock adds the block, keeping the blocks ordered by block id, a block that was already added is ignored
+func (r *txRecord) addBlock(b blockRecord) {
+	for i, existing := range r.blocks {
+		if existing.blockID == b.blockID {
+			return
+		}
+
+		if existing.blockID > b.blockID {
+			r.blocks = append(r.blocks[:i], append([]blockRecord{b}, r.blocks[i:]...)...)
+			return
+		}
+	}
+
+	r.blocks = append(r.blocks, b)
+}
+
+// removeBlock removes the block with the given id
+func (r *txRecord) removeBlock(blockID uint32) {
+	for i, existing := range r.blocks {
+		if existing.blockID == blockID {
+			r.blocks = append(r.blocks[:i], r.blocks[i+1:]...)
+			return
+		}
+	}
+}
+
+// addConflictingChild adds the child, returning whether it was not in the list yet
+func (r *txRecord) addConflictingChild(child chainhash.Hash) bool {
+	for _, existing := range r.conflictingChildren {
+		if existing == child {
+			return false
+		}
+	}
+
+	r.conflictingChildren = append(r.conflictingChildren, child)
+
+	return true
+}
+
+// removeConflictingChild removes the child, returning whether it was in the list
+func (r *txRecord) removeConflictingChild(child chainhash.Hash) bool {
+	for i, existing := range r.conflictingChildren {
+		if existing == child {
+			r.conflictingChildren = append(r.conflictingChildren[:i], r.conflictingChildren[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// txBytes serializes the transaction in the format described on txRecord
+func (r *txRecord) txBytes() []byte {
+	b := make([]byte, 0, 64+len(r.inputs)*180+len(r.outputs)*40)
+
+	var flags byte
+
+	if r.isCoinbase {
+		flags |= flagCoinbase
+	}
+
+	b = append(b, recordVersion, flags)
+	b = binary.LittleEndian.AppendUint32(b, r.version)
+	b = binary.LittleEndian.AppendUint32(b, r.lockTime)
+	b = binary.LittleEndian.AppendUint32(b, r.coinbaseSpendingHeight)
+	b = binary.LittleEndian.AppendUint64(b, r.fee)
+	b = binary.LittleEndian.AppendUint64(b, r.sizeInBytes)
+	b = binary.LittleEndian.AppendUint64(b, r.insertedAt)
+
+	b = append(b, bt.VarInt(uint64(len(r.inputs))).Bytes()...)
+
+	for _, input := range r.inputs {
+		b = append(b, input.PreviousTxIDChainHash()[:]...)
+		b = binary.LittleEndian.AppendUint32(b, input.PreviousTxOutIndex)
+		b = binary.LittleEndian.AppendUint64(b, input.PreviousTxSatoshis)
+		b = appendScript(b, input.PreviousTxScript)
+		b = appendScript(b, input.UnlockingScript)
+		b = binary.LittleEndian.AppendUint32(b, input.SequenceNumber)
+	}
+
+	b = append(b, bt.VarInt(uint64(len(r.outputs))).Bytes()...)
+
+	for _, o := range r.outputs {
+		if o == nil {
+			b = append(b, 0)
+			continue
+		}
+
+		b = append(b, 1)
+		b = binary.LittleEndian.AppendUint64(b, o.output.Satoshis)
+		b = appendScript(b, o.output.LockingScript)
+	}
+
+	return b
+}
+
+// metaBytes serializes the meta data in the format described on txRecord
+func (r *txRecord) metaBytes() []byte {
+	b := make([]byte, 0, 16+len(r.blocks)*12+len(r.conflictingChildren)*chainhash.HashSize)
+
+	var flags byte
+
+	if r.frozen {
+		flags |= metaFlagFrozen
+	}
+
+	if r.conflicting {
+		flags |= metaFlagConflicting
+	}
+
+	if r.locked {
+		flags |= metaFlagLocked
+	}
+
+	if r.unmined {
+		flags |= metaFlagUnmined
+	}
+
+	b = append(b, flags)
+	b = binary.LittleEndian.AppendUint32(b, r.unminedSince)
+	b = binary.LittleEndian.AppendUint32(b, r.deleteAtHeight)
+	b = binary.LittleEndian.AppendUint32(b, r.preserveUntil)
+
+	b = append(b, bt.VarInt(uint64(len(r.blocks))).Bytes()...)
+
+	for _, block := range r.blocks {
+		b = binary.LittleEndian.AppendUint32(b, block.blockID)
+		b = binary.LittleEndian.AppendUint32(b, block.blockHeight)
+		b = binary.LittleEndian.AppendUint32(b, block.subtreeIdx)
+	}
+
+	b = append(b, bt.VarInt(uint64(len(r.conflictingChildren))).Bytes()...)
+
+	for _, child := range r.conflictingChildren {
+		b = append(b, child[:]...)
+	}
+
+	return b
+}
+
+// stateBytes serializes the state of the output in the format described on txRecord
+func (o *outputRecord) stateBytes() []byte {
+	b := make([]byte, 0, chainhash.HashSize+5+36)
+	b = append(b, o.utxoHash[:]...)
+
+	var outputFlags byte
+
+	if o.spendingData != nil {
+		outputFlags |= outputFlagSpent
+	}
+
+	if o.frozen {
+		outputFlags |= outputFlagFrozen
+	}
+
+	b = append(b, outputFlags)
+	b = binary.LittleEndian.AppendUint32(b, o.spendableIn)
+
+	if o.spendingData != nil {
+		b = append(b, o.spendingData.Bytes()...)
+	}
+
+	return b
+}
+
+// appendScript appends the length of the script plus one and the script, or a zero for a nil script
+func appendScript(b []byte, script *bscript.Script) []byte {
+	if script == nil {
+		return append(b, 0)
+	}
+
+	b = append(b, bt.VarInt(uint64(len(*script))+1).Bytes()...)
+
+	return append(b, *script...)
+}
+
+// newTxRecordFromBytes deserializes the transaction in the format described on txRecord, the meta data and the
+// output states are read with decodeMeta and decodeOutputState
+func newTxRecordFromBytes(b []byte) (*txRecord, error) {
+	d := &decoder{b: b}
+
+	if v := d.byte(); v != recordVersion && d.err == nil {
+		return nil, errors.NewProcessingError("[LevelDB] unsupported record version %d", v)
+	}
+
+	flags := d.byte()
+
+	r := &txRecord{
+		isCoinbase:             flags&flagCoinbase != 0,
+		version:                d.uint32(),
+		lockTime:               d.uint32(),
+		coinbaseSpendingHeight: d.uint32(),
+		fee:                    d.uint64(),
+		sizeInBytes:            d.uint64(),
+		insertedAt:             d.uint64(),
+	}
+
+	nInputs := d.count()
+	if nInputs > 0 {
+		r.inputs = make([]*bt.Input, 0, nInputs)
+	}
+
+	for i := 0; i < nInputs && d.err == nil; i++ {
+		previousTxID := d.hash()
+
+		input := &bt.Input{
+			PreviousTxOutIndex: d.uint32(),
+			PreviousTxSatoshis: d.uint64(),
+			PreviousTxScript:   d.script(),
+			UnlockingScript:    d.script(),
+			SequenceNumber:     d.uint32(),
+		}
+
+		if err := input.PreviousTxIDAdd(&previousTxID); err != nil {
+			return nil, errors.NewProcessingError("[LevelDB] invalid previous tx id of input %d", i, err)
+		}
+
+		r.inputs = append(r.inputs, input)
+	}
+
+	nOutputs := d.count()
+	if nOutputs > 0 {
+		r.outputs = make([]*outputRecord, nOutputs)
+	}
+
+	for i := 0; i < nOutputs && d.err == nil; i++ {
+		if d.byte() == 0 {
+			continue
+		}
+
+		o := &outputRecord{
+			output: &bt.Output{
+				Satoshis: d.uint64(),
+			},
+		}
+
+		o.output.LockingScript = d.script()
+
+		r.outputs[i] = o
+	}
+
+	if err := d.done("transaction"); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// decodeMeta sets the meta data of the record from the bytes stored for it
+func (r *txRecord) decodeMeta(b []byte) error {
+	d := &decoder{b: b}
+
+	flags := d.byte()
+	r.frozen = flags&metaFlagFrozen != 0
+	r.conflicting = flags&metaFlagConflicting != 0
+	r.locked = flags&metaFlagLocked != 0
+	r.unmined = flags&metaFlagUnmined != 0
+	r.unminedSince = d.uint32()
+	r.deleteAtHeight = d.uint32()
+	r.preserveUntil = d.uint32()
+
+	nBlocks := d.count()
+	if nBlocks > 0 {
+		r.blocks = make([]blockRecord, 0, nBlocks)
+	}
+
+	for i := 0; i < nBlocks && d.err == nil; i++ {
+		r.blocks = append(r.blocks, blockRecord{
+			blockID:     d.uint32(),
+			blockHeight: d.uint32(),
+			subtreeIdx:  d.uint32(),
+		})
+	}
+
+	nChildren := d.count()
+	if nChildren > 0 {
+		r.conflictingChildren = make([]chainhash.Hash, 0, nChildren)
+	}
+
+	for i := 0; i < nChildren && d.err == nil; i++ {
+		r.conflictingChildren = append(r.conflictingChildren, d.hash())
+	}
+
+	if err := d.done("meta data"); err != nil {
+		return err
+	}
+
+	r.storedMeta = b
+	r.indexed = r.indexState()
+
+	return nil
+}
+
+// decodeState sets the state of the output from the bytes stored for it
+func (o *outputRecord) decodeState(b []byte) error {
+	d := &decoder{b: b}
+
+	o.utxoHash = d.hash()
+
+	outputFlags := d.byte()
+	o.frozen = outputFlags&outputFlagFrozen != 0
+	o.spendableIn = d.uint32()
+	o.spendingData = nil
+
+	if outputFlags&outputFlagSpent != 0 {
+		spendingData, err := spendpkg.NewSpendingDataFromBytes(d.next(36))
+		if err != nil && d.err == nil {
+			return errors.NewProcessingError("[LevelDB] invalid spending data", err)
+		}
+
+		o.spendingData = spendingData
+	}
+
+	if err := d.done("output state"); err != nil {
+		return err
+	}
+
+	o.stored = b
+
+	return nil
+}
+
+// decoder reads the fields of a record, after the first error all reads return zero values
+type decoder struct {
+	b   []byte
+	pos int
+	err error
+}
+
+// done returns the first error of the reads, or an error when not all the bytes were read
+func (d *decoder) done(what string) error {
+	if d.err != nil {
+		return errors.NewProcessingError("[LevelDB] invalid %s", what, d.err)
+	}
+
+	if d.pos != len(d.b) {
+		return errors.NewProcessingError("[LevelDB] invalid %s, %d trailing bytes", what, len(d.b)-d.pos)
+	}
+
+	return nil
+}
+
+func (d *decoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+
+	if n < 0 || len(d.b)-d.pos < n {
+		d.err = errors.NewProcessingError("unexpected end of record at %d, expected %d bytes", d.pos, n)
+		return nil
+	}
+
+	b := d.b[d.pos : d.pos+n]
+	d.pos += n
+
+	return b
+}
+
+func (d *decoder) byte() byte {
+	if b := d.next(1); b != nil {
+		return b[0]
+	}
+
+	return 0
+}
+
+func (d *decoder) uint32() uint32 {
+	if b := d.next(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+
+	return 0
+}
+
+func (d *decoder) uint64() uint64 {
+	if b := d.next(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+
+	return 0
+}
+
+func (d *decoder) hash() chainhash.Hash {
+	var h chainhash.Hash
+
+	copy(h[:], d.next(chainhash.HashSize))
+
+	return h
+}
+
+func (d *decoder) varInt() uint64 {
+	if d.err != nil {
+		return 0
+	}
+
+	if d.pos >= len(d.b) {
+		d.err = errors.NewProcessingError("unexpected end of record at %d, expected a varint", d.pos)
+		return 0
+	}
+
+	size := 1
+
+	switch d.b[d.pos] {
+	case 0xff:
+		size = 9
+	case 0xfe:
+		size = 5
+	case 0xfd:
+		size = 3
+	}
+
+	b := d.next(size)
+	if b == nil {
+		return 0
+	}
+
+	v, _ := bt.NewVarIntFromBytes(b)
+
+	return uint64(v)
+}
+
+// count reads a number of items, which cannot be more than the remaining bytes
+func (d *decoder) count() int {
+	n := d.varInt()
+	if n > uint64(len(d.b)-d.pos) {
+		if d.err == nil {
+			d.err = errors.NewProcessingError("invalid count %d at %d", n, d.pos)
+		}
+
+		return 0
+	}
+
+	return int(n)
+}
+
+func (d *decoder) script() *bscript.Script {
+	// the length of the script is written plus one, so it is at most one more than the remaining bytes
+	n := d.varInt()
+	if n == 0 {
+		return nil
+	}
+
+	if n-1 > uint64(len(d.b)-d.pos) {
+		if d.err == nil {
+			d.err = errors.NewProcessingError("invalid script length %d at %d", n-1, d.pos)
+		}
+
+		return nil
+	}
+
+	b := d.next(int(n - 1))
+	if b == nil {
+		return nil
+	}
+
+	script := make(bscript.Script, len(b))
+	copy(script, b)
+
+	return &script
+}
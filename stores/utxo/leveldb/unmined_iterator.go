@@ -0,0 +1,123 @@
+package leveldb
+
+import (
+	"context"
+
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/bsv-blockchain/go-subtree"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/stores/utxo"
+	goleveldb "github.com/btcsuite/goleveldb/leveldb"
+	"github.com/btcsuite/goleveldb/leveldb/iterator"
+	leveldbutil "github.com/btcsuite/goleveldb/leveldb/util"
+)
+
+// unminedTxIterator implements utxo.UnminedTxIterator for LevelDB.
+// It iterates the unmined index of a snapshot of the database, so the transactions are not affected by
+// concurrent writes.
+type unminedTxIterator struct {
+	store    *Store
+	snapshot *goleveldb.Snapshot
+	iter     iterator.Iterator
+	err      error
+	done     bool
+}
+
+func newUnminedTxIterator(store *Store) (*unminedTxIterator, error) {
+	snapshot, err := store.db.GetSnapshot()
+	if err != nil {
+		return nil, errors.NewStorageError("[LevelDB] failed to get snapshot", err)
+	}
+
+	return &unminedTxIterator{
+		store:    store,
+		snapshot: snapshot,
+		iter:     snapshot.NewIterator(leveldbutil.BytesPrefix([]byte{prefixUnmined}), nil),
+	}, nil
+}
+
+func (it *unminedTxIterator) Next(ctx context.Context) (*utxo.UnminedTransaction, error) {
+	if it.done || it.err != nil {
+		return nil, it.err
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if !it.iter.Next() {
+			if err := it.iter.Error(); err != nil {
+				it.err = errors.NewStorageError("[LevelDB] failed to iterate unmined transactions", err)
+			}
+
+			if err := it.Close(); err != nil {
+				it.store.logger.Warnf("failed to close iterator: %v", err)
+			}
+
+			return nil, it.err
+		}
+
+		txID := chainhash.Hash(it.iter.Key()[5:])
+
+		record, err := getRecordFrom(it.snapshot, &txID)
+		if err != nil {
+			it.err = err
+
+			if err = it.Close(); err != nil {
+				it.store.logger.Warnf("failed to close iterator: %v", err)
+			}
+
+			return nil, it.err
+		}
+
+		// conflicting transactions are not added to block assembly
+		if record.conflicting {
+			continue
+		}
+
+		if record.isCoinbase {
+			return &utxo.UnminedTransaction{
+				Skip: true,
+			}, nil
+		}
+
+		txInpoints, err := subtree.NewTxInpointsFromInputs(record.inputs)
+		if err != nil {
+			if err = it.Close(); err != nil {
+				it.store.logger.Warnf("failed to close iterator: %v", err)
+			}
+
+			return nil, errors.NewProcessingError("failed to create tx inpoints from inputs", err)
+		}
+
+		return &utxo.UnminedTransaction{
+			Hash:         &txID,
+			Fee:          record.fee,
+			Size:         record.sizeInBytes,
+			TxInpoints:   txInpoints,
+			CreatedAt:    int(record.insertedAt), // nolint:gosec
+			Locked:       record.locked,
+			BlockIDs:     record.blockIDs(),
+			UnminedSince: int(record.unminedSince),
+		}, nil
+	}
+}
+
+func (it *unminedTxIterator) Err() error {
+	return it.err
+}
+
+func (it *unminedTxIterator) Close() error {
+	if !it.done {
+		it.done = true
+		it.iter.Release()
+		it.snapshot.Release()
+	}
+
+	return nil
+}
+
+func (s *Store) GetUnminedTxIterator(bool) (utxo.UnminedTxIterator, error) {
+	return newUnminedTxIterator(s)
+}
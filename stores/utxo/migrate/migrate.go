@@ -0,0 +1,604 @@
+// Package migrate copies the records of a UTXO store to a UTXO store of another backend, for instance to move
+// a node from Postgres to Aerospike without re-seeding it from a UTXO set file.
+//
+// The records are streamed from the source store with utxo.RecordExporter and written to the destination with
+// utxo.RecordImporter, so all the state of the transactions is kept: the spending data and frozen state of the
+// utxos, the blocks the transactions were mined in, the unmined since height, the conflicting and locked flags,
+// the conflicting children and the DAH and preservation heights.
+//
+// The position in the source store is written to a checkpoint file after every batch, so an interrupted
+// migration resumes where it stopped. Importing is idempotent, records read again after resuming are simply
+// replaced. A verification pass compares the number of records in both stores and diffs a sample of the records.
+//
+// The migration reads a live store, but the node should be stopped for an exact copy: records changed after they
+// were copied are only updated by running the migration again, and records deleted from the source are not
+// deleted from the destination.
+//
+// The migration is used by the utxo-migrate command of teranode-cli.
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/bsv-blockchain/go-bt/v2"
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/stores/utxo"
+	"github.com/bsv-blockchain/teranode/ulogger"
+)
+
+// DefaultBatchSize is the default number of records imported into the destination store at once
+const DefaultBatchSize = 1000
+
+// progressInterval is the number of migrated records between two progress log messages
+const progressInterval = 100_000
+
+// Options holds the options of a migration
+type Options struct {
+	// Source and Destination identify the stores in the checkpoint file, a checkpoint of a migration between
+	// other stores is not resumed
+	Source      string
+	Destination string
+
+	// BatchSize is the number of records imported into the destination store at once
+	BatchSize int
+
+	// CheckpointFile is the file the position in the source store is written to after every batch, no
+	// checkpoint is written when empty
+	CheckpointFile string
+
+	// VerifySample diffs one in every VerifySample records of the source store with the destination store
+	// during verification, 0 only compares the number of records
+	VerifySample int
+}
+
+// Checkpoint is the progress of a migration, as written to the checkpoint file
+type Checkpoint struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Cursor      string `json:"cursor"`   // the cursor of the source store after the last imported batch
+	Migrated    int64  `json:"migrated"` // the number of records migrated so far
+}
+
+// Result is the result of a migration
+type Result struct {
+	Migrated     int64  `json:"migrated"`     // number of records migrated, including those of a resumed run
+	ResumedAfter int64  `json:"resumedAfter"` // number of records migrated by the run the checkpoint was written by
+	ResumeCursor string `json:"resumeCursor"` // the cursor the migration was resumed from, empty when started from the beginning
+}
+
+// Mismatch is a record that differs between the source and the destination store
+type Mismatch struct {
+	TxID        string   `json:"txID"`
+	Differences []string `json:"differences"`
+}
+
+// VerifyResult is the result of a verification
+type VerifyResult struct {
+	SourceRecords      int64      `json:"sourceRecords"`      // number of records in the source store
+	DestinationRecords int64      `json:"destinationRecords"` // number of records in the destination store
+	Sampled            int64      `json:"sampled"`            // number of records diffed
+	Mismatches         []Mismatch `json:"mismatches"`
+}
+
+// OK returns whether the stores hold the same number of records and no sampled record differs
+func (r *VerifyResult) OK() bool {
+	return r.SourceRecords == r.DestinationRecords && len(r.Mismatches) == 0
+}
+
+// Migrator copies the records of a source store to a destination store
+type Migrator struct {
+	logger      ulogger.Logger
+	source      utxo.RecordExporter
+	destination destinationStore
+	opts        Options
+}
+
+// destinationStore is implemented by the stores records can be migrated to, records are exported from the
+// destination store to verify the migration
+type destinationStore interface {
+	utxo.RecordImporter
+	utxo.RecordExporter
+}
+
+// New creates a migrator from the source to the destination store, returning an error if the source store
+// cannot export its records or the destination store cannot import them.
+func New(logger ulogger.Logger, source, destination utxo.Store, opts Options) (*Migrator, error) {
+	exporter, ok := source.(utxo.RecordExporter)
+	if !ok {
+		return nil, errors.NewInvalidArgumentError("source store %T does not support exporting records", source)
+	}
+
+	importer, ok := destination.(destinationStore)
+	if !ok {
+		return nil, errors.NewInvalidArgumentError("destination store %T does not support importing records", destination)
+	}
+
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+
+	return &Migrator{
+		logger:      logger,
+		source:      exporter,
+		destination: importer,
+		opts:        opts,
+	}, nil
+}
+
+// Migrate copies all the records of the source store to the destination store, resuming from the checkpoint
+// file when it holds the checkpoint of a migration between the same stores. The checkpoint file is removed
+// when the migration completes.
+func (m *Migrator) Migrate(ctx context.Context) (*Result, error) {
+	checkpoint, err := m.readCheckpoint()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		Migrated:     checkpoint.Migrated,
+		ResumedAfter: checkpoint.Migrated,
+		ResumeCursor: checkpoint.Cursor,
+	}
+
+	if checkpoint.Cursor != "" {
+		m.logger.Infof("[UtxoMigrate] resuming migration after %d records from cursor %s", checkpoint.Migrated, checkpoint.Cursor)
+	}
+
+	it, err := m.source.ExportRecords(ctx, checkpoint.Cursor)
+	if err != nil {
+		return result, errors.NewProcessingError("failed to export records from the source store", err)
+	}
+
+	defer func() {
+		_ = it.Close()
+	}()
+
+	batch := make([]*utxo.Record, 0, m.opts.BatchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		if err := m.destination.ImportRecords(ctx, batch); err != nil {
+			return errors.NewProcessingError("failed to import records into the destination store", err)
+		}
+
+		previous := result.Migrated
+		result.Migrated += int64(len(batch))
+		batch = batch[:0]
+
+		checkpoint.Cursor = it.Cursor()
+		checkpoint.Migrated = result.Migrated
+
+		if err := m.writeCheckpoint(checkpoint); err != nil {
+			return err
+		}
+
+		if result.Migrated/progressInterval > previous/progressInterval {
+			m.logger.Infof("[UtxoMigrate] migrated %d records", result.Migrated)
+		}
+
+		return nil
+	}
+
+	for {
+		record, err := it.Next(ctx)
+		if err != nil {
+			return result, errors.NewProcessingError("failed to export records from the source store", err)
+		}
+
+		if record == nil {
+			break
+		}
+
+		batch = append(batch, record)
+
+		if len(batch) >= m.opts.BatchSize {
+			if err = flush(); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	if err = flush(); err != nil {
+		return result, err
+	}
+
+	if m.opts.CheckpointFile != "" {
+		if err = os.Remove(m.opts.CheckpointFile); err != nil && !os.IsNotExist(err) {
+			m.logger.Warnf("[UtxoMigrate] failed to remove checkpoint file %s: %v", m.opts.CheckpointFile, err)
+		}
+	}
+
+	m.logger.Infof("[UtxoMigrate] migration completed, migrated %d records", result.Migrated)
+
+	return result, nil
+}
+
+// Verify counts the records of both stores and diffs one in every VerifySample records of the source store
+// with the record of the transaction in the destination store.
+func (m *Migrator) Verify(ctx context.Context) (*VerifyResult, error) {
+	result := &VerifyResult{
+		Mismatches: make([]Mismatch, 0),
+	}
+
+	err := forEachRecord(ctx, m.source, func(record *utxo.Record) error {
+		result.SourceRecords++
+
+		if m.opts.VerifySample <= 0 || (result.SourceRecords-1)%int64(m.opts.VerifySample) != 0 {
+			return nil
+		}
+
+		result.Sampled++
+
+		destinationRecord, err := m.destination.ExportRecord(ctx, &record.TxID)
+		if err != nil {
+			if errors.Is(err, errors.ErrTxNotFound) {
+				result.Mismatches = append(result.Mismatches, Mismatch{
+					TxID:        record.TxID.String(),
+					Differences: []string{"missing in the destination store"},
+				})
+
+				return nil
+			}
+
+			return errors.NewProcessingError("failed to export %s from the destination store", record.TxID, err)
+		}
+
+		if differences := DiffRecords(record, destinationRecord); len(differences) > 0 {
+			result.Mismatches = append(result.Mismatches, Mismatch{
+				TxID:        record.TxID.String(),
+				Differences: differences,
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return result, errors.NewProcessingError("failed to verify the records of the source store", err)
+	}
+
+	err = forEachRecord(ctx, m.destination, func(*utxo.Record) error {
+		result.DestinationRecords++
+		return nil
+	})
+	if err != nil {
+		return result, errors.NewProcessingError("failed to count the records of the destination store", err)
+	}
+
+	m.logger.Infof("[UtxoMigrate] verified %d source and %d destination records, %d of %d sampled records differ",
+		result.SourceRecords, result.DestinationRecords, len(result.Mismatches), result.Sampled)
+
+	return result, nil
+}
+
+// forEachRecord calls fn for all the records of the store
+func forEachRecord(ctx context.Context, store utxo.RecordExporter, fn func(record *utxo.Record) error) error {
+	it, err := store.ExportRecords(ctx, "")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = it.Close()
+	}()
+
+	for {
+		record, err := it.Next(ctx)
+		if err != nil {
+			return err
+		}
+
+		if record == nil {
+			return nil
+		}
+
+		if err = fn(record); err != nil {
+			return err
+		}
+	}
+}
+
+// readCheckpoint reads the checkpoint file, returning an empty checkpoint when there is no checkpoint file
+func (m *Migrator) readCheckpoint() (*Checkpoint, error) {
+	checkpoint := &Checkpoint{
+		Source:      m.opts.Source,
+		Destination: m.opts.Destination,
+	}
+
+	if m.opts.CheckpointFile == "" {
+		return checkpoint, nil
+	}
+
+	data, err := os.ReadFile(m.opts.CheckpointFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return checkpoint, nil
+		}
+
+		return nil, errors.NewProcessingError("failed to read checkpoint file %s", m.opts.CheckpointFile, err)
+	}
+
+	stored := &Checkpoint{}
+	if err = json.Unmarshal(data, stored); err != nil {
+		return nil, errors.NewProcessingError("failed to parse checkpoint file %s", m.opts.CheckpointFile, err)
+	}
+
+	if stored.Source != m.opts.Source || stored.Destination != m.opts.Destination {
+		return nil, errors.NewInvalidArgumentError("checkpoint file %s is of a migration from %s to %s", m.opts.CheckpointFile, stored.Source, stored.Destination)
+	}
+
+	return stored, nil
+}
+
+// writeCheckpoint writes the checkpoint file, through a temporary file so an interrupted write does not
+// leave a corrupt checkpoint
+func (m *Migrator) writeCheckpoint(checkpoint *Checkpoint) error {
+	if m.opts.CheckpointFile == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return errors.NewProcessingError("failed to encode checkpoint", err)
+	}
+
+	tmpFile := m.opts.CheckpointFile + ".tmp"
+
+	if err = os.WriteFile(tmpFile, data, 0o600); err != nil {
+		return errors.NewProcessingError("failed to write checkpoint file %s", tmpFile, err)
+	}
+
+	if err = os.Rename(tmpFile, m.opts.CheckpointFile); err != nil {
+		return errors.NewProcessingError("failed to write checkpoint file %s", m.opts.CheckpointFile, err)
+	}
+
+	return nil
+}
+
+// DiffRecords returns the differences between the records of a transaction in two stores.
+// The records are compared as the stores represent them: the blocks and conflicting children are compared
+// regardless of their order, the frozen flag of the transaction applies to its unspent utxos, and an output
+// that one store keeps as an untouched utxo while the other does not store it as a utxo is no difference.
+// The creation time is not compared, as not all stores keep it with the same precision.
+func DiffRecords(a, b *utxo.Record) []string {
+	var differences []string
+
+	diff := func(field string, valueA, valueB any) {
+		differences = append(differences, fmt.Sprintf("%s: %v != %v", field, valueA, valueB))
+	}
+
+	if a.TxID != b.TxID {
+		diff("txID", a.TxID, b.TxID)
+	}
+
+	differences = append(differences, diffTx(a.Tx, b.Tx)...)
+
+	if a.Fee != b.Fee {
+		diff("fee", a.Fee, b.Fee)
+	}
+
+	if a.SizeInBytes != b.SizeInBytes {
+		diff("sizeInBytes", a.SizeInBytes, b.SizeInBytes)
+	}
+
+	if a.IsCoinbase != b.IsCoinbase {
+		diff("isCoinbase", a.IsCoinbase, b.IsCoinbase)
+	}
+
+	if a.IsCoinbase && a.SpendingHeight != b.SpendingHeight {
+		diff("spendingHeight", a.SpendingHeight, b.SpendingHeight)
+	}
+
+	if a.Conflicting != b.Conflicting {
+		diff("conflicting", a.Conflicting, b.Conflicting)
+	}
+
+	if a.Locked != b.Locked {
+		diff("locked", a.Locked, b.Locked)
+	}
+
+	if a.IsUnmined() != b.IsUnmined() {
+		diff("unmined", a.IsUnmined(), b.IsUnmined())
+	}
+
+	if a.UnminedSince != b.UnminedSince {
+		diff("unminedSince", a.UnminedSince, b.UnminedSince)
+	}
+
+	if a.DeleteAtHeight != b.DeleteAtHeight {
+		diff("deleteAtHeight", a.DeleteAtHeight, b.DeleteAtHeight)
+	}
+
+	if a.PreserveUntil != b.PreserveUntil {
+		diff("preserveUntil", a.PreserveUntil, b.PreserveUntil)
+	}
+
+	if blocksA, blocksB := sortedBlocks(a), sortedBlocks(b); !slices.Equal(blocksA, blocksB) {
+		diff("blocks", blocksA, blocksB)
+	}
+
+	if childrenA, childrenB := sortedHashes(a.ConflictingChildren), sortedHashes(b.ConflictingChildren); !slices.Equal(childrenA, childrenB) {
+		diff("conflictingChildren", childrenA, childrenB)
+	}
+
+	for idx := 0; idx < max(len(a.Utxos), len(b.Utxos)); idx++ {
+		if difference := diffUtxo(a, b, idx); difference != "" {
+			differences = append(differences, fmt.Sprintf("utxo %d: %s", idx, difference))
+		}
+	}
+
+	return differences
+}
+
+// diffTx returns the differences between the transactions of two records
+func diffTx(a, b *bt.Tx) []string {
+	if a == nil || b == nil {
+		if a != b {
+			return []string{"tx: missing in one of the stores"}
+		}
+
+		return nil
+	}
+
+	var differences []string
+
+	if a.Version != b.Version {
+		differences = append(differences, fmt.Sprintf("version: %d != %d", a.Version, b.Version))
+	}
+
+	if a.LockTime != b.LockTime {
+		differences = append(differences, fmt.Sprintf("lockTime: %d != %d", a.LockTime, b.LockTime))
+	}
+
+	if len(a.Inputs) != len(b.Inputs) {
+		differences = append(differences, fmt.Sprintf("inputs: %d != %d", len(a.Inputs), len(b.Inputs)))
+	} else {
+		for i := range a.Inputs {
+			if !bytes.Equal(a.Inputs[i].ExtendedBytes(false), b.Inputs[i].ExtendedBytes(false)) {
+				differences = append(differences, fmt.Sprintf("input %d differs", i))
+			}
+		}
+	}
+
+	for i := 0; i < max(len(a.Outputs), len(b.Outputs)); i++ {
+		outputA, outputB := outputAt(a, i), outputAt(b, i)
+
+		switch {
+		case outputA == nil && outputB == nil:
+		case outputA == nil || outputB == nil:
+			differences = append(differences, fmt.Sprintf("output %d: missing in one of the stores", i))
+		case !bytes.Equal(outputA.Bytes(), outputB.Bytes()):
+			differences = append(differences, fmt.Sprintf("output %d differs", i))
+		}
+	}
+
+	return differences
+}
+
+// diffUtxo returns the difference between the utxos of the output in two records, an empty string if they
+// do not differ
+func diffUtxo(a, b *utxo.Record, idx int) string {
+	utxoA, utxoB := utxoAt(a, idx), utxoAt(b, idx)
+
+	switch {
+	case utxoA == nil && utxoB == nil:
+		return ""
+	case utxoA == nil:
+		if isUntouched(b, utxoB, idx) {
+			return ""
+		}
+
+		return "missing in the source store"
+	case utxoB == nil:
+		if isUntouched(a, utxoA, idx) {
+			return ""
+		}
+
+		return "missing in the destination store"
+	}
+
+	if utxoA.UTXOHash != utxoB.UTXOHash {
+		return fmt.Sprintf("utxo hash %s != %s", utxoA.UTXOHash, utxoB.UTXOHash)
+	}
+
+	if spendingA, spendingB := spendingDataString(utxoA), spendingDataString(utxoB); spendingA != spendingB {
+		return fmt.Sprintf("spending data %s != %s", spendingA, spendingB)
+	}
+
+	if frozenA, frozenB := isFrozen(a, utxoA), isFrozen(b, utxoB); frozenA != frozenB {
+		return fmt.Sprintf("frozen %t != %t", frozenA, frozenB)
+	}
+
+	if utxoA.SpendableIn != utxoB.SpendableIn {
+		return fmt.Sprintf("spendable in %d != %d", utxoA.SpendableIn, utxoB.SpendableIn)
+	}
+
+	return ""
+}
+
+// isUntouched returns whether the utxo has no state of its own and belongs to an output that is not stored
+// as a utxo by all stores
+func isUntouched(record *utxo.Record, u *utxo.RecordUtxo, idx int) bool {
+	if u.SpendingData != nil || isFrozen(record, u) || u.SpendableIn > 0 {
+		return false
+	}
+
+	output := outputAt(record.Tx, idx)
+
+	return output == nil || !utxo.ShouldStoreOutputAsUTXO(record.IsCoinbase, output, 0)
+}
+
+// isFrozen returns whether the utxo is frozen, by itself or, when unspent, by the frozen flag of the transaction
+func isFrozen(record *utxo.Record, u *utxo.RecordUtxo) bool {
+	return u.SpendingData == nil && (u.Frozen || record.Frozen)
+}
+
+func spendingDataString(u *utxo.RecordUtxo) string {
+	if u.SpendingData == nil {
+		return "<nil>"
+	}
+
+	return u.SpendingData.String()
+}
+
+func utxoAt(record *utxo.Record, idx int) *utxo.RecordUtxo {
+	if idx < len(record.Utxos) {
+		return record.Utxos[idx]
+	}
+
+	return nil
+}
+
+func outputAt(tx *bt.Tx, idx int) *bt.Output {
+	if tx != nil && idx < len(tx.Outputs) {
+		return tx.Outputs[idx]
+	}
+
+	return nil
+}
+
+// sortedBlocks returns the blocks of the record as block id, height and subtree index, ordered by block id
+func sortedBlocks(record *utxo.Record) []string {
+	blocks := make([]string, 0, len(record.BlockIDs))
+
+	for i, blockID := range record.BlockIDs {
+		var (
+			blockHeight uint32
+			subtreeIdx  int
+		)
+
+		if i < len(record.BlockHeights) {
+			blockHeight = record.BlockHeights[i]
+		}
+
+		if i < len(record.SubtreeIdxs) {
+			subtreeIdx = record.SubtreeIdxs[i]
+		}
+
+		blocks = append(blocks, fmt.Sprintf("%010d/%d/%d", blockID, blockHeight, subtreeIdx))
+	}
+
+	slices.Sort(blocks)
+
+	return blocks
+}
+
+func sortedHashes(hashes []chainhash.Hash) []string {
+	sorted := make([]string, 0, len(hashes))
+
+	for _, hash := range hashes {
+		sorted = append(sorted, hash.String())
+	}
+
+	slices.Sort(sorted)
+
+	return slices.Compact(sorted)
+}
@@ -0,0 +1,302 @@
+package migrate
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bt/v2"
+	"github.com/bsv-blockchain/go-bt/v2/bscript"
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/settings"
+	"github.com/bsv-blockchain/teranode/stores/utxo"
+	"github.com/bsv-blockchain/teranode/stores/utxo/fields"
+	"github.com/bsv-blockchain/teranode/stores/utxo/leveldb"
+	"github.com/bsv-blockchain/teranode/stores/utxo/sql"
+	"github.com/bsv-blockchain/teranode/stores/utxo/tests"
+	"github.com/bsv-blockchain/teranode/ulogger"
+	"github.com/bsv-blockchain/teranode/util"
+	"github.com/bsv-blockchain/teranode/util/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSQLStore(t *testing.T, tSettings *settings.Settings, name string) *sql.Store {
+	storeURL, err := url.Parse("sqlitememory:///" + name)
+	require.NoError(t, err)
+
+	store, err := sql.New(context.Background(), ulogger.TestLogger{}, tSettings, storeURL)
+	require.NoError(t, err)
+
+	return store
+}
+
+func newLevelDBStore(t *testing.T, tSettings *settings.Settings) *leveldb.Store {
+	store, err := leveldb.New(context.Background(), ulogger.TestLogger{}, tSettings, &url.URL{Scheme: "leveldb", Path: t.TempDir()})
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = store.Close()
+	})
+
+	return store
+}
+
+// newSpendingTx returns a transaction spending the output of the parent to a single output
+func newSpendingTx(t *testing.T, parent *bt.Tx, vout uint32) *bt.Tx {
+	tx := bt.NewTx()
+
+	require.NoError(t, tx.From(parent.TxIDChainHash().String(), vout, parent.Outputs[vout].LockingScript.String(), parent.Outputs[vout].Satoshis))
+	require.NoError(t, tx.PayToAddress("1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", parent.Outputs[vout].Satoshis/2))
+
+	// the sql store does not store inputs without an unlocking script
+	tx.Inputs[0].UnlockingScript = bscript.NewFromBytes([]byte{})
+
+	return tx
+}
+
+// populate stores a mined parent transaction with a spending child, a conflicting child, a locked child and a
+// frozen utxo, and returns the transactions
+func populate(t *testing.T, store utxo.Store, tSettings *settings.Settings) []*bt.Tx {
+	ctx := context.Background()
+
+	require.NoError(t, store.SetBlockHeight(100))
+
+	parentTx := tests.Tx.Clone()
+	spendingTx := newSpendingTx(t, parentTx, 0)
+	conflictingTx := newSpendingTx(t, parentTx, 1)
+	lockedTx := newSpendingTx(t, parentTx, 2)
+
+	_, err := store.Create(ctx, parentTx, 90, utxo.WithMinedBlockInfo(utxo.MinedBlockInfo{BlockID: 7, BlockHeight: 90, SubtreeIdx: 3}))
+	require.NoError(t, err)
+
+	_, err = store.Create(ctx, spendingTx, 100)
+	require.NoError(t, err)
+
+	_, err = store.Spend(ctx, spendingTx, 100)
+	require.NoError(t, err)
+
+	_, err = store.Create(ctx, conflictingTx, 100, utxo.WithConflicting(true))
+	require.NoError(t, err)
+
+	_, err = store.Create(ctx, lockedTx, 100, utxo.WithLocked(true))
+	require.NoError(t, err)
+
+	utxoHash, err := util.UTXOHashFromOutput(spendingTx.TxIDChainHash(), spendingTx.Outputs[0], 0)
+	require.NoError(t, err)
+
+	require.NoError(t, store.FreezeUTXOs(ctx, []*utxo.Spend{{
+		TxID:     spendingTx.TxIDChainHash(),
+		Vout:     0,
+		UTXOHash: utxoHash,
+	}}, tSettings))
+
+	return []*bt.Tx{parentTx, spendingTx, conflictingTx, lockedTx}
+}
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+	tSettings := test.CreateBaseTestSettings(t)
+
+	source := newSQLStore(t, tSettings, "migrate_source")
+	destination := newLevelDBStore(t, tSettings)
+
+	txs := populate(t, source, tSettings)
+
+	migrator, err := New(ulogger.TestLogger{}, source, destination, Options{
+		BatchSize:      2,
+		CheckpointFile: filepath.Join(t.TempDir(), "checkpoint.json"),
+		VerifySample:   1,
+	})
+	require.NoError(t, err)
+
+	result, err := migrator.Migrate(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(txs)), result.Migrated)
+	assert.Equal(t, int64(0), result.ResumedAfter)
+
+	// the checkpoint is removed when the migration completes
+	_, err = os.Stat(migrator.opts.CheckpointFile)
+	assert.True(t, os.IsNotExist(err))
+
+	verifyResult, err := migrator.Verify(ctx)
+	require.NoError(t, err)
+	assert.True(t, verifyResult.OK(), "mismatches: %v", verifyResult.Mismatches)
+	assert.Equal(t, int64(len(txs)), verifyResult.SourceRecords)
+	assert.Equal(t, int64(len(txs)), verifyResult.DestinationRecords)
+	assert.Equal(t, int64(len(txs)), verifyResult.Sampled)
+
+	parentTx, spendingTx, conflictingTx, lockedTx := txs[0], txs[1], txs[2], txs[3]
+
+	txMeta, err := destination.Get(ctx, parentTx.TxIDChainHash(), fields.BlockIDs, fields.UnminedSince)
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{7}, txMeta.BlockIDs)
+	assert.Equal(t, uint32(0), txMeta.UnminedSince)
+
+	spendResponse, err := destination.GetSpend(ctx, &utxo.Spend{
+		TxID:     parentTx.TxIDChainHash(),
+		Vout:     0,
+		UTXOHash: utxoHashOf(t, parentTx, 0),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, spendResponse.SpendingData)
+	assert.Equal(t, spendingTx.TxIDChainHash().String(), spendResponse.SpendingData.TxID.String())
+
+	spendResponse, err = destination.GetSpend(ctx, &utxo.Spend{
+		TxID:     spendingTx.TxIDChainHash(),
+		Vout:     0,
+		UTXOHash: utxoHashOf(t, spendingTx, 0),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int(utxo.Status_FROZEN), spendResponse.Status)
+
+	txMeta, err = destination.Get(ctx, spendingTx.TxIDChainHash(), fields.UnminedSince)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(100), txMeta.UnminedSince)
+
+	txMeta, err = destination.Get(ctx, conflictingTx.TxIDChainHash(), fields.Conflicting)
+	require.NoError(t, err)
+	assert.True(t, txMeta.Conflicting)
+
+	// the conflicting children are resolved from the spends and the conflicting links of the store
+	sourceChildren, err := source.GetConflictingChildren(ctx, *parentTx.TxIDChainHash())
+	require.NoError(t, err)
+	assert.Contains(t, sourceChildren, *conflictingTx.TxIDChainHash())
+
+	children, err := destination.GetConflictingChildren(ctx, *parentTx.TxIDChainHash())
+	require.NoError(t, err)
+	assert.ElementsMatch(t, sourceChildren, children)
+
+	txMeta, err = destination.Get(ctx, lockedTx.TxIDChainHash(), fields.Locked)
+	require.NoError(t, err)
+	assert.True(t, txMeta.Locked)
+
+	t.Run("back to sql", func(t *testing.T) {
+		back := newSQLStore(t, tSettings, "migrate_back")
+
+		migrator, err := New(ulogger.TestLogger{}, destination, back, Options{VerifySample: 1})
+		require.NoError(t, err)
+
+		_, err = migrator.Migrate(ctx)
+		require.NoError(t, err)
+
+		verifyResult, err := migrator.Verify(ctx)
+		require.NoError(t, err)
+		assert.True(t, verifyResult.OK(), "mismatches: %v", verifyResult.Mismatches)
+		assert.Equal(t, int64(len(txs)), verifyResult.DestinationRecords)
+	})
+}
+
+// failingImporter fails the import of the batch with the given number
+type failingImporter struct {
+	*leveldb.Store
+	failBatch int
+	batches   int
+}
+
+func (f *failingImporter) ImportRecords(ctx context.Context, records []*utxo.Record) error {
+	f.batches++
+	if f.batches == f.failBatch {
+		return errors.NewStorageError("import failed")
+	}
+
+	return f.Store.ImportRecords(ctx, records)
+}
+
+func TestMigrateResume(t *testing.T) {
+	ctx := context.Background()
+	tSettings := test.CreateBaseTestSettings(t)
+
+	source := newSQLStore(t, tSettings, "migrate_resume")
+	destination := &failingImporter{Store: newLevelDBStore(t, tSettings), failBatch: 2}
+
+	txs := populate(t, source, tSettings)
+
+	opts := Options{
+		Source:         "source",
+		Destination:    "destination",
+		BatchSize:      1,
+		CheckpointFile: filepath.Join(t.TempDir(), "checkpoint.json"),
+		VerifySample:   1,
+	}
+
+	migrator, err := New(ulogger.TestLogger{}, source, destination, opts)
+	require.NoError(t, err)
+
+	result, err := migrator.Migrate(ctx)
+	require.Error(t, err)
+	assert.Equal(t, int64(1), result.Migrated)
+
+	// a checkpoint of a migration between other stores is not resumed
+	otherMigrator, err := New(ulogger.TestLogger{}, source, destination, Options{
+		Source:         "other",
+		Destination:    opts.Destination,
+		CheckpointFile: opts.CheckpointFile,
+	})
+	require.NoError(t, err)
+
+	_, err = otherMigrator.Migrate(ctx)
+	require.ErrorIs(t, err, errors.ErrInvalidArgument)
+
+	result, err = migrator.Migrate(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.ResumedAfter)
+	assert.NotEmpty(t, result.ResumeCursor)
+	assert.Equal(t, int64(len(txs)), result.Migrated)
+
+	verifyResult, err := migrator.Verify(ctx)
+	require.NoError(t, err)
+	assert.True(t, verifyResult.OK(), "mismatches: %v", verifyResult.Mismatches)
+}
+
+func TestDiffRecords(t *testing.T) {
+	tx := tests.Tx.Clone()
+
+	record := func() *utxo.Record {
+		return &utxo.Record{
+			TxID:         *tx.TxIDChainHash(),
+			Tx:           tx,
+			Fee:          100,
+			SizeInBytes:  uint64(tx.Size()),
+			BlockIDs:     []uint32{1, 2},
+			BlockHeights: []uint32{10, 11},
+			SubtreeIdxs:  []int{0, 1},
+			Utxos: []*utxo.RecordUtxo{
+				{UTXOHash: *utxoHashOf(t, tx, 0)},
+			},
+		}
+	}
+
+	a, b := record(), record()
+	assert.Empty(t, DiffRecords(a, b))
+
+	// the order of the blocks does not matter
+	b.BlockIDs = []uint32{2, 1}
+	b.BlockHeights = []uint32{11, 10}
+	b.SubtreeIdxs = []int{1, 0}
+	assert.Empty(t, DiffRecords(a, b))
+
+	// the frozen flag of the transaction applies to unspent utxos
+	a.Frozen = true
+	b.Utxos[0].Frozen = true
+	assert.Empty(t, DiffRecords(a, b))
+
+	b = record()
+	b.Fee = 101
+	b.Locked = true
+	assert.Len(t, DiffRecords(a, b), 3)
+
+	a, b = record(), record()
+	b.Utxos = nil
+	assert.Len(t, DiffRecords(a, b), 1)
+}
+
+func utxoHashOf(t *testing.T, tx *bt.Tx, vout uint32) *chainhash.Hash {
+	hash, err := util.UTXOHashFromOutput(tx.TxIDChainHash(), tx.Outputs[vout], vout)
+	require.NoError(t, err)
+
+	return hash
+}
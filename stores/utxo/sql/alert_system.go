@@ -11,6 +11,7 @@
 //   - Optional UTXO expiration with automatic cleanup
 //   - Prometheus metrics integration
 //   - Support for the alert system (freeze/unfreeze/reassign UTXOs)
+//   - Change data capture to Kafka, in the message format of the Aerospike Kafka connector
 //
 // # Usage
 //
@@ -29,6 +30,7 @@
 //   - inputs: Stores transaction inputs with previous output references
 //   - outputs: Stores transaction outputs and UTXO state
 //   - block_ids: Stores which blocks a transaction appears in
+//   - utxo_cdc_outbox: Stores the changes to stream to Kafka, when change data capture is enabled
 //
 // # Metrics
 //
@@ -38,6 +40,7 @@
 //   - teranode_sql_utxo_reset: Number of UTXO reset operations
 //   - teranode_sql_utxo_delete: Number of UTXO delete operations
 //   - teranode_sql_utxo_errors: Number of errors by function and type
+//   - teranode_sql_utxo_cdc_messages: Number of change data capture messages sent to Kafka
 package sql
 
 import (
@@ -89,17 +92,30 @@ func (s *Store) FreezeUTXOs(ctx context.Context, spends []*utxostore.Spend, tSet
 		txHashIDMap[spend.TxID.String()] = id
 	}
 
+	txn, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = txn.Rollback()
+	}()
+
 	// if not, freeze the UTXO
 	for _, spend := range spends {
 		id := txHashIDMap[spend.TxID.String()]
 
 		q := `UPDATE outputs SET frozen = true WHERE transaction_id = $1 AND idx = $2 AND spending_data IS NULL`
-		if _, err := s.db.ExecContext(ctx, q, id, spend.Vout); err != nil {
+		if _, err = txn.ExecContext(ctx, q, id, spend.Vout); err != nil {
+			return err
+		}
+
+		if err = s.addCDCEvent(ctx, txn, cdcEventFreeze, spend.TxID); err != nil {
 			return err
 		}
 	}
 
-	return nil
+	return txn.Commit()
 }
 
 // UnFreezeUTXOs removes the frozen status from UTXOs.
@@ -132,16 +148,29 @@ func (s *Store) UnFreezeUTXOs(ctx context.Context, spends []*utxostore.Spend, tS
 		txHashIDMap[spend.TxID.String()] = id
 	}
 
+	txn, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = txn.Rollback()
+	}()
+
 	for _, spend := range spends {
 		id := txHashIDMap[spend.TxID.String()]
 
 		q := `UPDATE outputs SET frozen = false WHERE transaction_id = $1 AND idx = $2 AND spending_data IS NULL AND frozen = true`
-		if _, err := s.db.ExecContext(ctx, q, id, spend.Vout); err != nil {
+		if _, err = txn.ExecContext(ctx, q, id, spend.Vout); err != nil {
+			return err
+		}
+
+		if err = s.addCDCEvent(ctx, txn, cdcEventUnfreeze, spend.TxID); err != nil {
 			return err
 		}
 	}
 
-	return nil
+	return txn.Commit()
 }
 
 // ReAssignUTXO reassigns a frozen UTXO to a new transaction output.
@@ -180,9 +209,22 @@ func (s *Store) ReAssignUTXO(ctx context.Context, utxo *utxostore.Spend, newUtxo
           AND spending_data IS NULL
           AND frozen = true
     `
-	if _, err := s.db.ExecContext(ctx, q, newUtxo.UTXOHash[:], spendableIn, id, utxo.Vout); err != nil {
+	txn, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = txn.Rollback()
+	}()
+
+	if _, err = txn.ExecContext(ctx, q, newUtxo.UTXOHash[:], spendableIn, id, utxo.Vout); err != nil {
+		return err
+	}
+
+	if err = s.addCDCEvent(ctx, txn, cdcEventReassign, utxo.TxID); err != nil {
 		return err
 	}
 
-	return nil
+	return txn.Commit()
 }
@@ -0,0 +1,512 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/aerospike/aerospike-client-go/v8"
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/bsv-blockchain/go-subtree"
+	"github.com/bsv-blockchain/teranode/errors"
+	modeltime "github.com/bsv-blockchain/teranode/model/time"
+	"github.com/bsv-blockchain/teranode/stores/utxo"
+	"github.com/bsv-blockchain/teranode/stores/utxo/fields"
+	"github.com/bsv-blockchain/teranode/util"
+	"github.com/bsv-blockchain/teranode/util/kafka"
+	pq "github.com/lib/pq"
+)
+
+// The events added to the change data capture outbox, by the store operation changing the transaction
+const (
+	cdcEventCreate              = "create"
+	cdcEventSpend               = "spend"
+	cdcEventUnspend             = "unspend"
+	cdcEventMined               = "mined"
+	cdcEventFreeze              = "freeze"
+	cdcEventUnfreeze            = "unfreeze"
+	cdcEventReassign            = "reassign"
+	cdcEventConflicting         = "conflicting"
+	cdcEventConflictingChild    = "conflicting_child"
+	cdcEventLocked              = "locked"
+	cdcEventLongestChain        = "longest_chain"
+	cdcEventPreserve            = "preserve"
+	cdcEventPreservationExpired = "preservation_expired"
+	cdcEventDelete              = "delete"
+)
+
+// cdcRelayLockID is the postgres advisory lock held while relaying the outbox, so a single store relays the
+// outbox at a time and the messages of a transaction are sent in order
+const cdcRelayLockID = 0x75_74_78_6f_63_64_63 // "utxocdc"
+
+// cdcMessageMetadata is the metadata of a message in the flat JSON format of the Aerospike Kafka connector,
+// see aerospikekafkaconnector.AerospikeKafkaMessage
+type cdcMessageMetadata struct {
+	Msg       string `json:"msg"`
+	Namespace string `json:"namespace"`
+	Set       string `json:"set"`
+	Gen       int64  `json:"gen"`
+	Lut       int64  `json:"lut"`
+	Digest    []byte `json:"digest"`
+	Durable   bool   `json:"durable,omitempty"`
+}
+
+// cdcChange is a transaction changed by one or more events of the outbox
+type cdcChange struct {
+	hash  chainhash.Hash
+	id    int64
+	event string
+	lut   int64
+}
+
+// cdcQueryer is implemented by the database and its transactions
+type cdcQueryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// cdcRelay streams the changes of the store to Kafka, in the message format of the Aerospike Kafka connector.
+//
+// The store operations add an event to the utxo_cdc_outbox table in the database transaction of the change, so
+// the events of committed changes are never lost and rolled back changes have no events. The relay reads the
+// events in order, sends a message with the state of each changed transaction and removes the events once the
+// messages are sent. A transaction that was deleted is sent as a delete message.
+//
+// Messages are sent at least once: when sending fails the events are kept and sent again. The events of a
+// transaction in a batch are sent as a single message with its latest state, like the Aerospike connector
+// shipping a record that was written more than once.
+type cdcRelay struct {
+	store     *Store
+	producer  kafka.KafkaProducerI
+	namespace string
+	set       string
+	batchSize int
+	interval  time.Duration
+}
+
+// newCDCRelay creates the change data capture outbox of the store and the relay sending its events to the
+// topic of the Kafka URL.
+//
+// URL parameters, in addition to the parameters of the Kafka producer:
+//   - namespace: The namespace of the messages (default: utxo-store)
+//   - set: The set of the messages (default: utxo)
+//   - batch_size: The number of outbox events read at a time (default: 1000)
+//   - relay_interval: The interval between relays of the outbox (default: 1s)
+func newCDCRelay(ctx context.Context, s *Store, cdcURL *url.URL) (*cdcRelay, error) {
+	if err := createCDCSchema(ctx, s.db, s.engine); err != nil {
+		return nil, err
+	}
+
+	clusterAdmin, producer, err := kafka.NewKafkaProducer(cdcURL, &s.settings.Kafka)
+	if err != nil {
+		return nil, errors.NewServiceError("failed to create utxo cdc kafka producer", err)
+	}
+
+	// the cluster admin is only needed to create the topic
+	if clusterAdmin != nil {
+		_ = clusterAdmin.Close()
+	}
+
+	return &cdcRelay{
+		store:     s,
+		producer:  producer,
+		namespace: util.GetQueryParam(cdcURL, "namespace", "utxo-store"),
+		set:       util.GetQueryParam(cdcURL, "set", "utxo"),
+		batchSize: util.GetQueryParamInt(cdcURL, "batch_size", 1000),
+		interval:  util.GetQueryParamDuration(cdcURL, "relay_interval", time.Second),
+	}, nil
+}
+
+// createCDCSchema creates the change data capture outbox table
+func createCDCSchema(ctx context.Context, db cdcQueryer, engine string) error {
+	q := `
+      CREATE TABLE IF NOT EXISTS utxo_cdc_outbox (
+         id          BIGSERIAL PRIMARY KEY
+        ,hash        BYTEA NOT NULL
+        ,event       TEXT NOT NULL
+        ,inserted_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+	  );
+	`
+
+	if engine != "postgres" {
+		q = `
+      CREATE TABLE IF NOT EXISTS utxo_cdc_outbox (
+         id          INTEGER PRIMARY KEY AUTOINCREMENT
+        ,hash        BLOB NOT NULL
+        ,event       TEXT NOT NULL
+        ,inserted_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+	  );
+	`
+	}
+
+	if _, err := db.ExecContext(ctx, q); err != nil {
+		return errors.NewStorageError("could not create utxo_cdc_outbox table - [%+v]", err)
+	}
+
+	return nil
+}
+
+// addCDCEvent adds an event of the transactions to the change data capture outbox, in the database transaction
+// of the change. It does nothing when change data capture is disabled.
+func (s *Store) addCDCEvent(ctx context.Context, txn *sql.Tx, event string, hashes ...*chainhash.Hash) error {
+	if s.cdc == nil {
+		return nil
+	}
+
+	for _, hash := range hashes {
+		if _, err := txn.ExecContext(ctx, `INSERT INTO utxo_cdc_outbox (hash, event) VALUES ($1, $2)`, hash[:], event); err != nil {
+			return errors.NewStorageError("failed to add %s event of %s to the cdc outbox", event, hash, err)
+		}
+	}
+
+	return nil
+}
+
+// execWithCDCEvent runs the statement changing the transaction and, when change data capture is enabled, adds the
+// event of the transaction in the same database transaction. No event is added when the statement changed no rows.
+func (s *Store) execWithCDCEvent(ctx context.Context, event string, hash *chainhash.Hash, query string, args ...any) (sql.Result, error) {
+	if s.cdc == nil {
+		return s.db.ExecContext(ctx, query, args...)
+	}
+
+	txn, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = txn.Rollback()
+	}()
+
+	result, err := txn.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected > 0 {
+		if err = s.addCDCEvent(ctx, txn, event, hash); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = txn.Commit(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// start relays the outbox every interval until the context is done
+func (r *cdcRelay) start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(r.interval)
+
+		defer func() {
+			ticker.Stop()
+			_ = r.producer.Close()
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				// relay until the outbox has less than a batch of events left
+				for {
+					relayed, err := r.relayOutbox(ctx)
+					if err != nil {
+						prometheusUtxoErrors.WithLabelValues("CDCRelay", "Failed Relay").Inc()
+						r.store.logger.Errorf("[UTXO CDC] failed to relay the outbox: %v", err)
+
+						break
+					}
+
+					if relayed < r.batchSize {
+						break
+					}
+				}
+			}
+		}
+	}()
+}
+
+// relayOutbox sends the messages of a batch of outbox events and removes the events, returning the number of
+// events relayed. On postgres nothing is relayed while another store holds the relay lock.
+func (r *cdcRelay) relayOutbox(ctx context.Context) (int, error) {
+	var queryer cdcQueryer = r.store.db
+
+	var txn *sql.Tx
+
+	// sqlite is local to the process, only postgres can have stores in other processes relaying the outbox
+	if r.store.engine == "postgres" {
+		var err error
+
+		if txn, err = r.store.db.BeginTx(ctx, nil); err != nil {
+			return 0, errors.NewStorageError("failed to begin cdc relay transaction", err)
+		}
+
+		defer func() {
+			_ = txn.Rollback()
+		}()
+
+		var locked bool
+
+		if err = txn.QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock($1)`, cdcRelayLockID).Scan(&locked); err != nil {
+			return 0, errors.NewStorageError("failed to get cdc relay lock", err)
+		}
+
+		if !locked {
+			return 0, nil
+		}
+
+		queryer = txn
+	}
+
+	changes, ids, err := r.readOutbox(ctx, queryer)
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+
+	for _, change := range changes {
+		message, err := r.message(ctx, change)
+		if err != nil {
+			return 0, err
+		}
+
+		if err = r.producer.Send(change.hash[:], message); err != nil {
+			return 0, errors.NewServiceError("failed to send cdc message of %s", change.hash, err)
+		}
+
+		prometheusUtxoCDCMessages.Inc()
+	}
+
+	if err = r.removeEvents(ctx, txn, ids); err != nil {
+		return 0, err
+	}
+
+	if txn != nil {
+		if err = txn.Commit(); err != nil {
+			return 0, errors.NewStorageError("failed to commit cdc relay transaction", err)
+		}
+	}
+
+	return len(ids), nil
+}
+
+// removeEvents removes the relayed events from the outbox. The events are removed by id, as the ids are taken
+// when an event is added and not when it commits, so an event with a lower id than the relayed ones can still
+// be added after the read.
+func (r *cdcRelay) removeEvents(ctx context.Context, txn *sql.Tx, ids []int64) error {
+	if txn != nil {
+		if _, err := txn.ExecContext(ctx, `DELETE FROM utxo_cdc_outbox WHERE id = ANY($1::bigint[])`, pq.Array(ids)); err != nil {
+			return errors.NewStorageError("failed to remove relayed events from the cdc outbox", err)
+		}
+
+		return nil
+	}
+
+	// sqlite has no arrays, the events are removed one by one in a single transaction
+	sqliteTxn, err := r.store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.NewStorageError("failed to begin cdc outbox transaction", err)
+	}
+
+	defer func() {
+		_ = sqliteTxn.Rollback()
+	}()
+
+	for _, id := range ids {
+		if _, err = sqliteTxn.ExecContext(ctx, `DELETE FROM utxo_cdc_outbox WHERE id = $1`, id); err != nil {
+			return errors.NewStorageError("failed to remove relayed events from the cdc outbox", err)
+		}
+	}
+
+	if err = sqliteTxn.Commit(); err != nil {
+		return errors.NewStorageError("failed to commit cdc outbox transaction", err)
+	}
+
+	return nil
+}
+
+// readOutbox reads a batch of outbox events, returning the changed transactions in the order they were first
+// changed and the ids of the events read
+func (r *cdcRelay) readOutbox(ctx context.Context, queryer cdcQueryer) ([]*cdcChange, []int64, error) {
+	rows, err := queryer.QueryContext(ctx, `
+		SELECT id, hash, event, inserted_at
+		FROM utxo_cdc_outbox
+		ORDER BY id
+		LIMIT $1
+	`, r.batchSize)
+	if err != nil {
+		return nil, nil, errors.NewStorageError("failed to read the cdc outbox", err)
+	}
+
+	defer rows.Close()
+
+	var (
+		changes []*cdcChange
+		ids     []int64
+	)
+
+	byHash := make(map[chainhash.Hash]*cdcChange)
+
+	for rows.Next() {
+		var (
+			id         int64
+			hash       []byte
+			event      string
+			insertedAt modeltime.CustomTime
+		)
+
+		if err = rows.Scan(&id, &hash, &event, &insertedAt); err != nil {
+			return nil, nil, errors.NewStorageError("failed to read the cdc outbox", err)
+		}
+
+		change, ok := byHash[chainhash.Hash(hash)]
+		if !ok {
+			change = &cdcChange{hash: chainhash.Hash(hash)}
+			byHash[change.hash] = change
+			changes = append(changes, change)
+		}
+
+		change.id = id
+		change.event = event
+		change.lut = insertedAt.UnixMilli()
+
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, nil, errors.NewStorageError("failed to read the cdc outbox", err)
+	}
+
+	return changes, ids, nil
+}
+
+// message returns the message with the current state of the changed transaction, the generation of the
+// message is the id of the last event of the transaction, which increases with every change
+func (r *cdcRelay) message(ctx context.Context, change *cdcChange) ([]byte, error) {
+	key, aErr := aerospike.NewKey(r.namespace, r.set, change.hash[:])
+	if aErr != nil {
+		return nil, errors.NewProcessingError("failed to create digest of %s", change.hash, aErr)
+	}
+
+	metadata := cdcMessageMetadata{
+		Msg:       "write",
+		Namespace: r.namespace,
+		Set:       r.set,
+		Gen:       change.id,
+		Lut:       change.lut,
+		Digest:    key.Digest(),
+	}
+
+	record, err := r.store.ExportRecord(ctx, &change.hash)
+	if err != nil {
+		if !errors.Is(err, errors.ErrTxNotFound) {
+			return nil, err
+		}
+
+		metadata.Msg = "delete"
+
+		return json.Marshal(map[string]any{"metadata": metadata})
+	}
+
+	bins := cdcRecordBins(record)
+	bins["metadata"] = metadata
+
+	return json.Marshal(bins)
+}
+
+// cdcRecordBins returns the bins of the Aerospike record of the transaction, without the inputs and outputs.
+// The utxos are encoded like the Aerospike store: the utxo hash, followed by the spending data or the frozen
+// marker, and nil for the outputs that are not stored as utxos.
+func cdcRecordBins(record *utxo.Record) map[string]any {
+	utxos := make([]any, len(record.Tx.Outputs))
+	spendableIn := make(map[string]uint32)
+	totalUtxos := 0
+	spentUtxos := 0
+
+	for idx, output := range record.Tx.Outputs {
+		if output == nil || idx >= len(record.Utxos) || record.Utxos[idx] == nil {
+			continue
+		}
+
+		recordUtxo := record.Utxos[idx]
+		frozen := recordUtxo.Frozen || (record.Frozen && recordUtxo.SpendingData == nil)
+
+		// outputs that are not stored as utxos are kept when they have state of their own
+		if !utxo.ShouldStoreOutputAsUTXO(record.IsCoinbase, output, 0) && recordUtxo.SpendingData == nil && !frozen && recordUtxo.SpendableIn == 0 {
+			continue
+		}
+
+		u := make([]byte, 0, 68)
+		u = append(u, recordUtxo.UTXOHash[:]...)
+
+		switch {
+		case recordUtxo.SpendingData != nil:
+			u = append(u, recordUtxo.SpendingData.Bytes()...)
+			spentUtxos++
+		case frozen:
+			u = append(u, subtree.FrozenBytes[:]...)
+		}
+
+		utxos[idx] = u
+		totalUtxos++
+
+		if recordUtxo.SpendableIn > 0 {
+			spendableIn[strconv.Itoa(idx)] = recordUtxo.SpendableIn
+		}
+	}
+
+	bins := map[string]any{
+		fields.TxID.String():         record.TxID[:],
+		fields.Version.String():      record.Tx.Version,
+		fields.LockTime.String():     record.Tx.LockTime,
+		fields.Fee.String():          record.Fee,
+		fields.SizeInBytes.String():  record.SizeInBytes,
+		fields.IsCoinbase.String():   record.IsCoinbase,
+		fields.Conflicting.String():  record.Conflicting,
+		fields.Locked.String():       record.Locked,
+		fields.CreatedAt.String():    record.CreatedAt,
+		fields.Utxos.String():        utxos,
+		fields.TotalUtxos.String():   totalUtxos,
+		fields.SpentUtxos.String():   spentUtxos,
+		fields.BlockIDs.String():     append([]uint32{}, record.BlockIDs...),
+		fields.BlockHeights.String(): append([]uint32{}, record.BlockHeights...),
+		fields.SubtreeIdxs.String():  append([]int{}, record.SubtreeIdxs...),
+	}
+
+	if record.IsCoinbase {
+		bins[fields.SpendingHeight.String()] = record.SpendingHeight
+	}
+
+	if record.UnminedSince > 0 {
+		bins[fields.UnminedSince.String()] = record.UnminedSince
+	}
+
+	if record.DeleteAtHeight > 0 {
+		bins[fields.DeleteAtHeight.String()] = record.DeleteAtHeight
+	}
+
+	if record.PreserveUntil > 0 {
+		bins[fields.PreserveUntil.String()] = record.PreserveUntil
+	}
+
+	if len(spendableIn) > 0 {
+		bins[fields.UtxoSpendableIn.String()] = spendableIn
+	}
+
+	if len(record.ConflictingChildren) > 0 {
+		children := make([][]byte, 0, len(record.ConflictingChildren))
+		for _, child := range record.ConflictingChildren {
+			children = append(children, child[:])
+		}
+
+		bins[fields.ConflictingChildren.String()] = children
+	}
+
+	return bins
+}
@@ -0,0 +1,350 @@
+package sql
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/aerospike/aerospike-client-go/v8"
+	"github.com/bsv-blockchain/go-bt/v2"
+	"github.com/bsv-blockchain/go-bt/v2/bscript"
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/bsv-blockchain/go-subtree"
+	"github.com/bsv-blockchain/teranode/stores/cleanup"
+	"github.com/bsv-blockchain/teranode/stores/utxo"
+	sqlcleanup "github.com/bsv-blockchain/teranode/stores/utxo/sql/cleanup"
+	utxo2 "github.com/bsv-blockchain/teranode/test/longtest/stores/utxo"
+	"github.com/bsv-blockchain/teranode/ulogger"
+	"github.com/bsv-blockchain/teranode/util"
+	imk "github.com/bsv-blockchain/teranode/util/kafka/in_memory_kafka"
+	"github.com/bsv-blockchain/teranode/util/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cdcTestMessage is the part of a change data capture message checked by the tests
+type cdcTestMessage struct {
+	Metadata            cdcMessageMetadata `json:"metadata"`
+	TxID                []byte             `json:"txID"`
+	Utxos               [][]byte           `json:"utxos"`
+	TotalUtxos          int                `json:"totalUtxos"`
+	SpentUtxos          int                `json:"spentUtxos"`
+	BlockIDs            []uint32           `json:"blockIDs"`
+	Conflicting         bool               `json:"conflicting"`
+	ConflictingChildren [][]byte           `json:"conflictingCs"`
+	Locked              bool               `json:"locked"`
+	UnminedSince        uint32             `json:"unminedSince"`
+	DeleteAtHeight      uint32             `json:"deleteAtHeight"`
+	PreserveUntil       uint32             `json:"preserveUntil"`
+}
+
+func TestCDC(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	initPrometheusMetrics()
+
+	tSettings := test.CreateBaseTestSettings(t)
+	tSettings.UtxoStore.DBTimeout = 30 * time.Second
+
+	// the relay is run by the test, not by the store
+	cdcURL, err := url.Parse("memory://localhost/utxo-cdc-test?relay_interval=1h")
+	require.NoError(t, err)
+
+	tSettings.Kafka.UtxoCDCConfig = cdcURL
+
+	storeURL, err := url.Parse("sqlitememory:///test_cdc")
+	require.NoError(t, err)
+
+	store, err := New(ctx, ulogger.TestLogger{}, tSettings, storeURL)
+	require.NoError(t, err)
+	require.NotNil(t, store.cdc)
+
+	_, tx := setup(ctx, t)
+	txHash := tx.TxIDChainHash()
+
+	// Delete the tx and relay the outbox so the test can run cleanly...
+	require.NoError(t, store.Delete(ctx, txHash))
+
+	_, err = store.cdc.relayOutbox(ctx)
+	require.NoError(t, err)
+
+	// messages are only delivered to the consumers of the topic when they are sent
+	consumer, err := imk.GetSharedBroker().NewInMemoryConsumer("utxo-cdc-test")
+	require.NoError(t, err)
+
+	defer consumer.Close()
+
+	partitionConsumer, err := consumer.ConsumePartition("utxo-cdc-test", 0, 0)
+	require.NoError(t, err)
+
+	messages := partitionConsumer.Messages()
+
+	receive := func(t *testing.T) *cdcTestMessage {
+		select {
+		case msg := <-messages:
+			var message cdcTestMessage
+			require.NoError(t, json.Unmarshal(msg.Value, &message))
+			assert.Equal(t, cdcTestDigest(t, chainhash.Hash(msg.Key)), message.Metadata.Digest)
+
+			return &message
+		case <-time.After(5 * time.Second):
+			t.Fatal("no cdc message received")
+			return nil
+		}
+	}
+
+	spendTx := utxo2.GetSpendingTx(tx, 0)
+
+	var gen int64
+
+	t.Run("create and spend", func(t *testing.T) {
+		_, err = store.Create(ctx, tx, 0)
+		require.NoError(t, err)
+
+		_, err = store.Spend(ctx, spendTx, store.GetBlockHeight()+1)
+		require.NoError(t, err)
+
+		relayed, err := store.cdc.relayOutbox(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, relayed)
+
+		// the create and spend events of the transaction are sent as a single message
+		message := receive(t)
+		assert.Equal(t, "write", message.Metadata.Msg)
+		assert.Equal(t, "utxo-store", message.Metadata.Namespace)
+		assert.Equal(t, "utxo", message.Metadata.Set)
+		assert.Equal(t, txHash[:], message.TxID)
+		assert.Len(t, message.Utxos, len(tx.Outputs))
+		assert.Len(t, message.Utxos[0], 68)
+		assert.Equal(t, spendTx.TxIDChainHash()[:], message.Utxos[0][32:64])
+		assert.Equal(t, 1, message.SpentUtxos)
+		assert.Empty(t, message.BlockIDs)
+
+		gen = message.Metadata.Gen
+	})
+
+	t.Run("rolled back spend", func(t *testing.T) {
+		_, err = store.Spend(ctx, utxo2.GetSpendingTx(tx, 0), store.GetBlockHeight()+1)
+		require.Error(t, err)
+
+		relayed, err := store.cdc.relayOutbox(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, relayed)
+	})
+
+	t.Run("unspend, mined and freeze", func(t *testing.T) {
+		utxoHash0, err := util.UTXOHashFromOutput(txHash, tx.Outputs[0], 0)
+		require.NoError(t, err)
+
+		err = store.Unspend(ctx, []*utxo.Spend{{TxID: txHash, Vout: 0, UTXOHash: utxoHash0}})
+		require.NoError(t, err)
+
+		_, err = store.SetMinedMulti(ctx, []*chainhash.Hash{txHash}, utxo.MinedBlockInfo{
+			BlockID:        1,
+			BlockHeight:    1,
+			SubtreeIdx:     0,
+			OnLongestChain: true,
+		})
+		require.NoError(t, err)
+
+		utxoHash1, err := util.UTXOHashFromOutput(txHash, tx.Outputs[1], 1)
+		require.NoError(t, err)
+
+		err = store.FreezeUTXOs(ctx, []*utxo.Spend{{TxID: txHash, Vout: 1, UTXOHash: utxoHash1}}, tSettings)
+		require.NoError(t, err)
+
+		relayed, err := store.cdc.relayOutbox(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 3, relayed)
+
+		message := receive(t)
+		assert.Equal(t, "write", message.Metadata.Msg)
+		assert.Greater(t, message.Metadata.Gen, gen)
+		assert.Equal(t, utxoHash0[:], message.Utxos[0])
+		assert.Equal(t, subtree.FrozenBytes[:], message.Utxos[1][32:])
+		assert.Equal(t, 0, message.SpentUtxos)
+		assert.Equal(t, []uint32{1}, message.BlockIDs)
+	})
+
+	childTx := bt.NewTx()
+	require.NoError(t, childTx.From(txHash.String(), 0, tx.Outputs[0].LockingScript.String(), tx.Outputs[0].Satoshis))
+	require.NoError(t, childTx.PayToAddress("1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", tx.Outputs[0].Satoshis/2))
+
+	childTx.Inputs[0].UnlockingScript = bscript.NewFromBytes([]byte{})
+
+	childTxHash := childTx.TxIDChainHash()
+
+	t.Run("conflicting", func(t *testing.T) {
+		_, err = store.Create(ctx, childTx, 0)
+		require.NoError(t, err)
+
+		_, _, err = store.SetConflicting(ctx, []chainhash.Hash{*childTxHash}, true)
+		require.NoError(t, err)
+
+		relayed, err := store.cdc.relayOutbox(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 3, relayed)
+
+		message := receive(t)
+		assert.Equal(t, childTxHash[:], message.TxID)
+		assert.True(t, message.Conflicting)
+
+		// the parent is sent with its new conflicting child
+		message = receive(t)
+		assert.Equal(t, txHash[:], message.TxID)
+		assert.Equal(t, [][]byte{childTxHash[:]}, message.ConflictingChildren)
+	})
+
+	t.Run("locked, longest chain and preservation", func(t *testing.T) {
+		require.NoError(t, store.SetBlockHeight(10))
+
+		require.NoError(t, store.SetLocked(ctx, []chainhash.Hash{*txHash}, true))
+		require.NoError(t, store.MarkTransactionsOnLongestChain(ctx, []chainhash.Hash{*txHash}, false))
+		require.NoError(t, store.PreserveTransactions(ctx, []chainhash.Hash{*txHash}, 20))
+
+		relayed, err := store.cdc.relayOutbox(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 3, relayed)
+
+		message := receive(t)
+		assert.Equal(t, txHash[:], message.TxID)
+		assert.True(t, message.Locked)
+		assert.Equal(t, uint32(10), message.UnminedSince)
+		assert.Equal(t, uint32(20), message.PreserveUntil)
+
+		require.NoError(t, store.ProcessExpiredPreservations(ctx, 20))
+
+		relayed, err = store.cdc.relayOutbox(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, relayed)
+
+		message = receive(t)
+		assert.Equal(t, txHash[:], message.TxID)
+		assert.Zero(t, message.PreserveUntil)
+		assert.Equal(t, 20+tSettings.GetUtxoStoreBlockHeightRetention(), message.DeleteAtHeight)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, store.Delete(ctx, txHash))
+
+		relayed, err := store.cdc.relayOutbox(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, relayed)
+
+		message := receive(t)
+		assert.Equal(t, "delete", message.Metadata.Msg)
+		assert.Nil(t, message.TxID)
+		assert.Nil(t, message.Utxos)
+	})
+
+	t.Run("cleanup delete", func(t *testing.T) {
+		_, err = store.db.ExecContext(ctx, "UPDATE transactions SET delete_at_height = 30 WHERE hash = $1", childTxHash[:])
+		require.NoError(t, err)
+
+		cleanupService, err := sqlcleanup.NewService(tSettings, sqlcleanup.Options{
+			Logger:            ulogger.TestLogger{},
+			DB:                store.db,
+			ChangeDataCapture: true,
+		})
+		require.NoError(t, err)
+
+		cleanupService.Start(ctx)
+
+		done := make(chan string, 1)
+		require.NoError(t, cleanupService.UpdateBlockHeight(30, done))
+		assert.Equal(t, cleanup.JobStatusCompleted.String(), <-done)
+
+		relayed, err := store.cdc.relayOutbox(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, relayed)
+
+		message := receive(t)
+		assert.Equal(t, "delete", message.Metadata.Msg)
+		assert.Equal(t, cdcTestDigest(t, *childTxHash), message.Metadata.Digest)
+	})
+
+	var events int
+	require.NoError(t, store.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM utxo_cdc_outbox").Scan(&events))
+	assert.Equal(t, 0, events)
+
+	select {
+	case msg := <-messages:
+		t.Fatalf("unexpected cdc message: %s", msg.Value)
+	default:
+	}
+}
+
+func TestCDCRemoveEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	initPrometheusMetrics()
+
+	tSettings := test.CreateBaseTestSettings(t)
+	tSettings.UtxoStore.DBTimeout = 30 * time.Second
+
+	cdcURL, err := url.Parse("memory://localhost/utxo-cdc-remove-test?relay_interval=1h")
+	require.NoError(t, err)
+
+	tSettings.Kafka.UtxoCDCConfig = cdcURL
+
+	storeURL, err := url.Parse("sqlitememory:///test_cdc_remove")
+	require.NoError(t, err)
+
+	store, err := New(ctx, ulogger.TestLogger{}, tSettings, storeURL)
+	require.NoError(t, err)
+	require.NotNil(t, store.cdc)
+
+	for i := 0; i < 3; i++ {
+		hash := chainhash.HashH([]byte{byte(i)})
+
+		_, err = store.db.ExecContext(ctx, `INSERT INTO utxo_cdc_outbox (hash, event) VALUES ($1, $2)`, hash[:], cdcEventCreate)
+		require.NoError(t, err)
+	}
+
+	// only the events that were relayed are removed, not every event up to the highest relayed id
+	require.NoError(t, store.cdc.removeEvents(ctx, nil, []int64{1, 3}))
+
+	var ids []int64
+
+	rows, err := store.db.QueryContext(ctx, "SELECT id FROM utxo_cdc_outbox")
+	require.NoError(t, err)
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		require.NoError(t, rows.Scan(&id))
+
+		ids = append(ids, id)
+	}
+
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []int64{2}, ids)
+}
+
+func TestCDCDisabled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store, tx := setup(ctx, t)
+	require.Nil(t, store.cdc)
+
+	_, err := store.Create(ctx, tx, 0)
+	require.NoError(t, err)
+
+	// the outbox is only created when change data capture is enabled
+	var name string
+	err = store.db.QueryRowContext(ctx, "SELECT name FROM sqlite_master WHERE type = 'table' AND name = 'utxo_cdc_outbox'").Scan(&name)
+	require.Error(t, err)
+}
+
+func cdcTestDigest(t *testing.T, hash chainhash.Hash) []byte {
+	key, err := aerospike.NewKey("utxo-store", "utxo", hash[:])
+	require.NoError(t, err)
+
+	return key.Digest()
+}
@@ -21,6 +21,9 @@ const (
 
 	// DefaultMaxJobsHistory is the default number of jobs to keep in history
 	DefaultMaxJobsHistory = 1000
+
+	// cdcEventDelete is the change data capture event of a deleted transaction, as added by the SQL store
+	cdcEventDelete = "delete"
 )
 
 // Service implements the utxo.CleanupService interface for SQL-based UTXO stores
@@ -31,6 +34,7 @@ type Service struct {
 	jobManager         *cleanup.JobManager
 	ctx                context.Context
 	getPersistedHeight func() uint32
+	changeDataCapture  bool
 }
 
 // Options contains configuration options for the cleanup service
@@ -49,6 +53,10 @@ type Options struct {
 
 	// Ctx is the context to use to signal shutdown
 	Ctx context.Context
+
+	// ChangeDataCapture adds a delete event of every deleted transaction to the change data capture outbox of
+	// the store
+	ChangeDataCapture bool
 }
 
 // NewService creates a new cleanup service for the SQL store
@@ -76,10 +84,11 @@ func NewService(tSettings *settings.Settings, opts Options) (*Service, error) {
 	}
 
 	service := &Service{
-		logger:   opts.Logger,
-		settings: tSettings,
-		db:       opts.DB,
-		ctx:      opts.Ctx,
+		logger:            opts.Logger,
+		settings:          tSettings,
+		db:                opts.DB,
+		ctx:               opts.Ctx,
+		changeDataCapture: opts.ChangeDataCapture,
 	}
 
 	// Create the job processor function
@@ -179,7 +188,7 @@ func (s *Service) processCleanupJob(job *cleanup.Job, workerID int) {
 	}
 
 	// Execute the cleanup with safe height
-	err := deleteTombstoned(s.db, safeCleanupHeight)
+	err := deleteTombstoned(s.db, safeCleanupHeight, s.changeDataCapture)
 
 	if err != nil {
 		job.SetStatus(cleanup.JobStatusFailed)
@@ -207,12 +216,39 @@ func (s *Service) processCleanupJob(job *cleanup.Job, workerID int) {
 }
 
 // deleteTombstoned removes transactions that have passed their expiration time.
-func deleteTombstoned(db *usql.DB, blockHeight uint32) error {
+// With change data capture, a delete event of every deleted transaction is added in the same database transaction.
+func deleteTombstoned(db *usql.DB, blockHeight uint32, changeDataCapture bool) error {
+	txn, err := db.Begin()
+	if err != nil {
+		return errors.NewStorageError("failed to begin delete transaction", err)
+	}
+
+	defer func() {
+		_ = txn.Rollback()
+	}()
+
+	if changeDataCapture {
+		q := `
+			INSERT INTO utxo_cdc_outbox (hash, event)
+			SELECT hash, $2
+			FROM transactions
+			WHERE delete_at_height <= $1
+		`
+
+		if _, err = txn.Exec(q, blockHeight, cdcEventDelete); err != nil {
+			return errors.NewStorageError("failed to add delete events to the cdc outbox", err)
+		}
+	}
+
 	// Delete transactions that have passed their expiration time
 	// this will cascade to inputs, outputs, block_ids and conflicting_children
-	if _, err := db.Exec("DELETE FROM transactions WHERE delete_at_height <= $1", blockHeight); err != nil {
+	if _, err = txn.Exec("DELETE FROM transactions WHERE delete_at_height <= $1", blockHeight); err != nil {
 		return errors.NewStorageError("failed to delete transactions", err)
 	}
 
+	if err = txn.Commit(); err != nil {
+		return errors.NewStorageError("failed to commit delete transaction", err)
+	}
+
 	return nil
 }
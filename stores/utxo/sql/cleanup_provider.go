@@ -32,9 +32,10 @@ func (s *Store) GetCleanupService() (cleanup.Service, error) {
 
 	// Create a new cleanup service
 	cleanupService, err := sqlcleanup.NewService(s.settings, sqlcleanup.Options{
-		Logger:         s.logger,
-		DB:             s.db,
-		MaxJobsHistory: maxJobHistory,
+		Logger:            s.logger,
+		DB:                s.db,
+		MaxJobsHistory:    maxJobHistory,
+		ChangeDataCapture: s.cdc != nil,
 	})
 	if err != nil {
 		return nil, err
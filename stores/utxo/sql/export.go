@@ -0,0 +1,759 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/bsv-blockchain/go-bt/v2"
+	"github.com/bsv-blockchain/go-bt/v2/bscript"
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	safeconversion "github.com/bsv-blockchain/go-safe-conversion"
+	"github.com/bsv-blockchain/teranode/errors"
+	modeltime "github.com/bsv-blockchain/teranode/model/time"
+	"github.com/bsv-blockchain/teranode/stores/utxo"
+	spendpkg "github.com/bsv-blockchain/teranode/stores/utxo/spend"
+	"github.com/bsv-blockchain/teranode/util"
+)
+
+// Ensure Store implements the record export and import interfaces
+var (
+	_ utxo.RecordExporter = (*Store)(nil)
+	_ utxo.RecordImporter = (*Store)(nil)
+)
+
+// exportPageSize is the number of transaction rows read by a single query of the record iterator
+const exportPageSize = 1000
+
+// exportTransactionColumns are the columns of the transactions table read into a record
+const exportTransactionColumns = `
+	 id
+	,hash
+	,version
+	,lock_time
+	,fee
+	,size_in_bytes
+	,coinbase
+	,frozen
+	,conflicting
+	,locked
+	,delete_at_height
+	,unmined_since
+	,preserve_until
+	,inserted_at
+`
+
+// rowScanner is implemented by sql.Row and sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// recordIterator implements utxo.RecordIterator for SQL.
+// It reads the transactions in pages ordered by their id, the cursor is the id of the last returned
+// transaction. Transactions created while iterating are returned when their id is after the cursor.
+type recordIterator struct {
+	store  *Store
+	lastID int64
+	ids    []int64
+	page   []*utxo.Record
+	done   bool
+}
+
+// ExportRecords returns an iterator over all the transactions of the store, starting after the transaction
+// with the id of the cursor.
+func (s *Store) ExportRecords(_ context.Context, cursor string) (utxo.RecordIterator, error) {
+	it := &recordIterator{
+		store: s,
+	}
+
+	if cursor != "" {
+		lastID, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil {
+			return nil, errors.NewInvalidArgumentError("invalid export cursor %q", cursor, err)
+		}
+
+		it.lastID = lastID
+	}
+
+	return it, nil
+}
+
+func (it *recordIterator) Next(ctx context.Context) (*utxo.Record, error) {
+	if len(it.page) == 0 {
+		if it.done {
+			return nil, nil
+		}
+
+		if err := it.nextPage(ctx); err != nil {
+			return nil, err
+		}
+
+		if len(it.page) == 0 {
+			it.done = true
+			return nil, nil
+		}
+	}
+
+	record := it.page[0]
+	it.lastID = it.ids[0]
+	it.page = it.page[1:]
+	it.ids = it.ids[1:]
+
+	return record, nil
+}
+
+// nextPage reads the transactions of the next page, with their inputs, outputs, blocks and children
+func (it *recordIterator) nextPage(ctx context.Context) error {
+	q := `SELECT ` + exportTransactionColumns + `
+		FROM transactions
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2
+	`
+
+	rows, err := it.store.db.QueryContext(ctx, q, it.lastID, exportPageSize)
+	if err != nil {
+		return errors.NewStorageError("failed to read transactions after id %d", it.lastID, err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		id, record, err := scanRecord(rows)
+		if err != nil {
+			return errors.NewStorageError("failed to read transactions after id %d", it.lastID, err)
+		}
+
+		it.ids = append(it.ids, id)
+		it.page = append(it.page, record)
+	}
+
+	if err = rows.Err(); err != nil {
+		return errors.NewStorageError("failed to read transactions after id %d", it.lastID, err)
+	}
+
+	// the details are read after the rows are closed, so reading them does not need a second connection
+	rows.Close()
+
+	for i, record := range it.page {
+		if err = it.store.readRecordDetails(ctx, it.ids[i], record); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (it *recordIterator) Cursor() string {
+	if it.lastID == 0 {
+		return ""
+	}
+
+	return strconv.FormatInt(it.lastID, 10)
+}
+
+func (it *recordIterator) Close() error {
+	it.done = true
+	it.page = nil
+	it.ids = nil
+
+	return nil
+}
+
+// ExportRecord returns the record of the transaction.
+func (s *Store) ExportRecord(ctx context.Context, hash *chainhash.Hash) (*utxo.Record, error) {
+	q := `SELECT ` + exportTransactionColumns + `
+		FROM transactions
+		WHERE hash = $1
+	`
+
+	id, record, err := scanRecord(s.db.QueryRowContext(ctx, q, hash[:]))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.NewTxNotFoundError("transaction %s not found", hash, err)
+		}
+
+		return nil, errors.NewStorageError("failed to read transaction %s", hash, err)
+	}
+
+	if err = s.readRecordDetails(ctx, id, record); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// scanRecord scans a row of the export transaction columns into a record without inputs and outputs
+func scanRecord(row rowScanner) (int64, *utxo.Record, error) {
+	var (
+		id             int64
+		hash           []byte
+		version        uint32
+		lockTime       uint32
+		deleteAtHeight sql.NullInt64
+		unminedSince   sql.NullInt64
+		preserveUntil  sql.NullInt64
+		insertedAt     modeltime.CustomTime
+	)
+
+	record := &utxo.Record{}
+
+	if err := row.Scan(&id, &hash, &version, &lockTime, &record.Fee, &record.SizeInBytes, &record.IsCoinbase, &record.Frozen,
+		&record.Conflicting, &record.Locked, &deleteAtHeight, &unminedSince, &preserveUntil, &insertedAt); err != nil {
+		return 0, nil, err
+	}
+
+	copy(record.TxID[:], hash)
+
+	record.Tx = &bt.Tx{
+		Version:  version,
+		LockTime: lockTime,
+	}
+
+	record.DeleteAtHeight = nullUint32(deleteAtHeight)
+	record.UnminedSince = nullUint32(unminedSince)
+	record.PreserveUntil = nullUint32(preserveUntil)
+	record.CreatedAt = int(insertedAt.UnixMilli())
+
+	return id, record, nil
+}
+
+// readRecordDetails reads the inputs, outputs, blocks and conflicting children of the transaction into the record
+func (s *Store) readRecordDetails(ctx context.Context, id int64, record *utxo.Record) error {
+	if err := s.readRecordInputs(ctx, id, record); err != nil {
+		return err
+	}
+
+	if err := s.readRecordOutputs(ctx, id, record); err != nil {
+		return err
+	}
+
+	if err := s.readRecordBlocks(ctx, id, record); err != nil {
+		return err
+	}
+
+	return s.readRecordConflictingChildren(ctx, id, record)
+}
+
+func (s *Store) readRecordInputs(ctx context.Context, id int64, record *utxo.Record) error {
+	q := `
+		SELECT
+		 previous_transaction_hash
+		,previous_tx_idx
+		,previous_tx_satoshis
+		,previous_tx_script
+		,unlocking_script
+		,sequence_number
+		FROM inputs
+		WHERE transaction_id = $1
+		ORDER BY idx
+	`
+
+	rows, err := s.db.QueryContext(ctx, q, id)
+	if err != nil {
+		return errors.NewStorageError("failed to read inputs of transaction %s", record.TxID, err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		input := &bt.Input{}
+
+		var previousTxHashBytes []byte
+
+		if err = rows.Scan(&previousTxHashBytes, &input.PreviousTxOutIndex, &input.PreviousTxSatoshis, &input.PreviousTxScript, &input.UnlockingScript, &input.SequenceNumber); err != nil {
+			return errors.NewStorageError("failed to read inputs of transaction %s", record.TxID, err)
+		}
+
+		previousTxHash, err := chainhash.NewHash(previousTxHashBytes)
+		if err != nil {
+			return errors.NewProcessingError("invalid previous transaction hash in transaction %s", record.TxID, err)
+		}
+
+		if err = input.PreviousTxIDAdd(previousTxHash); err != nil {
+			return errors.NewProcessingError("invalid previous transaction hash in transaction %s", record.TxID, err)
+		}
+
+		record.Tx.Inputs = append(record.Tx.Inputs, input)
+	}
+
+	return rows.Err()
+}
+
+func (s *Store) readRecordOutputs(ctx context.Context, id int64, record *utxo.Record) error {
+	q := `
+		SELECT
+		 idx
+		,locking_script
+		,satoshis
+		,coinbase_spending_height
+		,utxo_hash
+		,spending_data
+		,frozen
+		,spendableIn
+		FROM outputs
+		WHERE transaction_id = $1
+		ORDER BY idx
+	`
+
+	rows, err := s.db.QueryContext(ctx, q, id)
+	if err != nil {
+		return errors.NewStorageError("failed to read outputs of transaction %s", record.TxID, err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			idx                    int
+			lockingScript          []byte
+			satoshis               uint64
+			coinbaseSpendingHeight uint32
+			utxoHash               []byte
+			spendingDataBytes      []byte
+			frozen                 sql.NullBool
+			spendableIn            sql.NullInt64
+		)
+
+		if err = rows.Scan(&idx, &lockingScript, &satoshis, &coinbaseSpendingHeight, &utxoHash, &spendingDataBytes, &frozen, &spendableIn); err != nil {
+			return errors.NewStorageError("failed to read outputs of transaction %s", record.TxID, err)
+		}
+
+		// outputs that are not stored leave a gap in the indexes
+		for len(record.Tx.Outputs) <= idx {
+			record.Tx.Outputs = append(record.Tx.Outputs, nil)
+			record.Utxos = append(record.Utxos, nil)
+		}
+
+		record.Tx.Outputs[idx] = &bt.Output{
+			Satoshis:      satoshis,
+			LockingScript: bscript.NewFromBytes(lockingScript),
+		}
+
+		recordUtxo := &utxo.RecordUtxo{
+			Frozen:      frozen.Bool,
+			SpendableIn: nullUint32(spendableIn),
+		}
+
+		copy(recordUtxo.UTXOHash[:], utxoHash)
+
+		if spendingDataBytes != nil {
+			recordUtxo.SpendingData, err = spendpkg.NewSpendingDataFromBytes(spendingDataBytes)
+			if err != nil {
+				return errors.NewProcessingError("invalid spending data of output %s:%d", record.TxID, idx, err)
+			}
+		}
+
+		record.Utxos[idx] = recordUtxo
+
+		if coinbaseSpendingHeight > 0 {
+			record.SpendingHeight = coinbaseSpendingHeight
+		}
+	}
+
+	return rows.Err()
+}
+
+func (s *Store) readRecordBlocks(ctx context.Context, id int64, record *utxo.Record) error {
+	q := `
+		SELECT
+		 block_id
+		,block_height
+		,subtree_idx
+		FROM block_ids
+		WHERE transaction_id = $1
+		ORDER BY block_id
+	`
+
+	rows, err := s.db.QueryContext(ctx, q, id)
+	if err != nil {
+		return errors.NewStorageError("failed to read blocks of transaction %s", record.TxID, err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			blockID     uint32
+			blockHeight uint32
+			subtreeIdx  int
+		)
+
+		if err = rows.Scan(&blockID, &blockHeight, &subtreeIdx); err != nil {
+			return errors.NewStorageError("failed to read blocks of transaction %s", record.TxID, err)
+		}
+
+		record.BlockIDs = append(record.BlockIDs, blockID)
+		record.BlockHeights = append(record.BlockHeights, blockHeight)
+		record.SubtreeIdxs = append(record.SubtreeIdxs, subtreeIdx)
+	}
+
+	return rows.Err()
+}
+
+func (s *Store) readRecordConflictingChildren(ctx context.Context, id int64, record *utxo.Record) error {
+	q := `
+		SELECT child.hash
+		FROM conflicting_children c
+		INNER JOIN transactions child ON c.child_transaction_id = child.id
+		WHERE c.transaction_id = $1
+	`
+
+	rows, err := s.db.QueryContext(ctx, q, id)
+	if err != nil {
+		return errors.NewStorageError("failed to read conflicting children of transaction %s", record.TxID, err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var childHash []byte
+
+		if err = rows.Scan(&childHash); err != nil {
+			return errors.NewStorageError("failed to read conflicting children of transaction %s", record.TxID, err)
+		}
+
+		record.ConflictingChildren = append(record.ConflictingChildren, chainhash.Hash(childHash))
+	}
+
+	return rows.Err()
+}
+
+// ImportRecords stores the records in a single database transaction. A transaction that already exists keeps
+// its id, its row is updated and its inputs, outputs, blocks and conflicting children are replaced.
+func (s *Store) ImportRecords(ctx context.Context, records []*utxo.Record) error {
+	txn, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.NewStorageError("failed to begin import transaction", err)
+	}
+
+	defer func() {
+		_ = txn.Rollback()
+	}()
+
+	for _, record := range records {
+		if err = s.importRecord(ctx, txn, record); err != nil {
+			return err
+		}
+	}
+
+	if err = txn.Commit(); err != nil {
+		return errors.NewStorageError("failed to commit import of %d transactions", len(records), err)
+	}
+
+	return nil
+}
+
+func (s *Store) importRecord(ctx context.Context, txn *sql.Tx, record *utxo.Record) error {
+	if record.Tx == nil {
+		return errors.NewProcessingError("record of transaction %s has no transaction", record.TxID)
+	}
+
+	// unmined_since is NULL only for transactions mined on the longest chain
+	var unminedSince any
+	if record.IsUnmined() {
+		unminedSince = record.UnminedSince
+	}
+
+	insertedAt := s.insertedAtValue(record.CreatedAt)
+
+	var transactionID int64
+
+	err := txn.QueryRowContext(ctx, `SELECT id FROM transactions WHERE hash = $1`, record.TxID[:]).Scan(&transactionID)
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		q := `
+			INSERT INTO transactions (
+			 hash
+			,version
+			,lock_time
+			,fee
+			,size_in_bytes
+			,coinbase
+			,frozen
+			,conflicting
+			,locked
+			,delete_at_height
+			,unmined_since
+			,preserve_until
+			,inserted_at
+			) VALUES (
+			 $1
+			,$2
+			,$3
+			,$4
+			,$5
+			,$6
+			,$7
+			,$8
+			,$9
+			,$10
+			,$11
+			,$12
+			,$13
+			)
+			RETURNING id
+		`
+
+		if err = txn.QueryRowContext(ctx, q, record.TxID[:], record.Tx.Version, record.Tx.LockTime, record.Fee, record.SizeInBytes,
+			record.IsCoinbase, record.Frozen, record.Conflicting, record.Locked, zeroAsNull(record.DeleteAtHeight), unminedSince,
+			zeroAsNull(record.PreserveUntil), insertedAt).Scan(&transactionID); err != nil {
+			return errors.NewStorageError("failed to insert transaction %s", record.TxID, err)
+		}
+
+	case err != nil:
+		return errors.NewStorageError("failed to read transaction %s", record.TxID, err)
+
+	default:
+		q := `
+			UPDATE transactions SET
+			 version = $2
+			,lock_time = $3
+			,fee = $4
+			,size_in_bytes = $5
+			,coinbase = $6
+			,frozen = $7
+			,conflicting = $8
+			,locked = $9
+			,delete_at_height = $10
+			,unmined_since = $11
+			,preserve_until = $12
+			,inserted_at = $13
+			WHERE id = $1
+		`
+
+		if _, err = txn.ExecContext(ctx, q, transactionID, record.Tx.Version, record.Tx.LockTime, record.Fee, record.SizeInBytes,
+			record.IsCoinbase, record.Frozen, record.Conflicting, record.Locked, zeroAsNull(record.DeleteAtHeight), unminedSince,
+			zeroAsNull(record.PreserveUntil), insertedAt); err != nil {
+			return errors.NewStorageError("failed to update transaction %s", record.TxID, err)
+		}
+
+		for _, table := range []string{"inputs", "outputs", "block_ids", "conflicting_children"} {
+			if _, err = txn.ExecContext(ctx, `DELETE FROM `+table+` WHERE transaction_id = $1`, transactionID); err != nil {
+				return errors.NewStorageError("failed to delete %s of transaction %s", table, record.TxID, err)
+			}
+		}
+	}
+
+	if err = importRecordInputs(ctx, txn, transactionID, record); err != nil {
+		return err
+	}
+
+	if err = importRecordOutputs(ctx, txn, transactionID, record); err != nil {
+		return err
+	}
+
+	if err = importRecordBlocks(ctx, txn, transactionID, record); err != nil {
+		return err
+	}
+
+	return importRecordConflictingChildren(ctx, txn, transactionID, record)
+}
+
+func importRecordInputs(ctx context.Context, txn *sql.Tx, transactionID int64, record *utxo.Record) error {
+	q := `
+		INSERT INTO inputs (
+		 transaction_id
+		,idx
+		,previous_transaction_hash
+		,previous_tx_idx
+		,previous_tx_satoshis
+		,previous_tx_script
+		,unlocking_script
+		,sequence_number
+		) VALUES (
+		 $1
+		,$2
+		,$3
+		,$4
+		,$5
+		,$6
+		,$7
+		,$8
+		)
+	`
+
+	for i, input := range record.Tx.Inputs {
+		if _, err := txn.ExecContext(ctx, q, transactionID, i, input.PreviousTxIDChainHash()[:], input.PreviousTxOutIndex,
+			input.PreviousTxSatoshis, input.PreviousTxScript, input.UnlockingScript, input.SequenceNumber); err != nil {
+			return errors.NewStorageError("failed to insert input %d of transaction %s", i, record.TxID, err)
+		}
+	}
+
+	return nil
+}
+
+func importRecordOutputs(ctx context.Context, txn *sql.Tx, transactionID int64, record *utxo.Record) error {
+	q := `
+		INSERT INTO outputs (
+		 transaction_id
+		,idx
+		,locking_script
+		,satoshis
+		,coinbase_spending_height
+		,utxo_hash
+		,spending_data
+		,frozen
+		,spendableIn
+		) VALUES (
+		 $1
+		,$2
+		,$3
+		,$4
+		,$5
+		,$6
+		,$7
+		,$8
+		,$9
+		)
+	`
+
+	for i, output := range record.Tx.Outputs {
+		if output == nil {
+			continue
+		}
+
+		var (
+			utxoHash     chainhash.Hash
+			spendingData []byte
+			frozen       bool
+			spendableIn  any
+		)
+
+		if i < len(record.Utxos) && record.Utxos[i] != nil {
+			utxoHash = record.Utxos[i].UTXOHash
+			frozen = record.Utxos[i].Frozen
+			spendableIn = zeroAsNull(record.Utxos[i].SpendableIn)
+
+			if record.Utxos[i].SpendingData != nil {
+				spendingData = record.Utxos[i].SpendingData.Bytes()
+			}
+		} else {
+			iUint32, err := safeconversion.IntToUint32(i)
+			if err != nil {
+				return err
+			}
+
+			hash, err := util.UTXOHashFromOutput(&record.TxID, output, iUint32)
+			if err != nil {
+				return err
+			}
+
+			utxoHash = *hash
+		}
+
+		if _, err := txn.ExecContext(ctx, q, transactionID, i, output.LockingScript, output.Satoshis, record.SpendingHeight,
+			utxoHash[:], spendingData, frozen, spendableIn); err != nil {
+			return errors.NewStorageError("failed to insert output %d of transaction %s", i, record.TxID, err)
+		}
+	}
+
+	return nil
+}
+
+func importRecordBlocks(ctx context.Context, txn *sql.Tx, transactionID int64, record *utxo.Record) error {
+	q := `
+		INSERT INTO block_ids (
+		 transaction_id
+		,block_id
+		,block_height
+		,subtree_idx
+		) VALUES (
+		 $1
+		,$2
+		,$3
+		,$4
+		)
+	`
+
+	for i, blockID := range record.BlockIDs {
+		var (
+			blockHeight uint32
+			subtreeIdx  int
+		)
+
+		if i < len(record.BlockHeights) {
+			blockHeight = record.BlockHeights[i]
+		}
+
+		if i < len(record.SubtreeIdxs) {
+			subtreeIdx = record.SubtreeIdxs[i]
+		}
+
+		if _, err := txn.ExecContext(ctx, q, transactionID, blockID, blockHeight, subtreeIdx); err != nil {
+			return errors.NewStorageError("failed to insert block %d of transaction %s", blockID, record.TxID, err)
+		}
+	}
+
+	return nil
+}
+
+// importRecordConflictingChildren links the transaction to its conflicting children and, for a conflicting
+// transaction, to its parents. Only transactions that are already stored can be linked, so the links are
+// complete once both sides of a link have been imported, in any order.
+func importRecordConflictingChildren(ctx context.Context, txn *sql.Tx, transactionID int64, record *utxo.Record) error {
+	qChild := `
+		INSERT INTO conflicting_children (transaction_id, child_transaction_id)
+		SELECT $1, id FROM transactions WHERE hash = $2
+		ON CONFLICT DO NOTHING
+	`
+
+	for _, child := range record.ConflictingChildren {
+		if _, err := txn.ExecContext(ctx, qChild, transactionID, child[:]); err != nil {
+			return errors.NewStorageError("failed to insert conflicting child %s of transaction %s", child, record.TxID, err)
+		}
+	}
+
+	if !record.Conflicting {
+		return nil
+	}
+
+	qParent := `
+		INSERT INTO conflicting_children (transaction_id, child_transaction_id)
+		SELECT id, $2 FROM transactions WHERE hash = $1
+		ON CONFLICT DO NOTHING
+	`
+
+	for _, input := range record.Tx.Inputs {
+		if _, err := txn.ExecContext(ctx, qParent, input.PreviousTxIDChainHash()[:], transactionID); err != nil {
+			return errors.NewStorageError("failed to insert conflicting child %s of transaction %s", record.TxID, input.PreviousTxIDChainHash(), err)
+		}
+	}
+
+	return nil
+}
+
+// insertedAtValue returns the value of the inserted_at column for the time in milliseconds since the epoch,
+// or the current time if not set, sqlite stores the time as text in the format of CURRENT_TIMESTAMP
+func (s *Store) insertedAtValue(createdAt int) any {
+	t := time.Now().UTC()
+	if createdAt > 0 {
+		t = time.UnixMilli(int64(createdAt)).UTC()
+	}
+
+	if s.engine == "postgres" {
+		return t
+	}
+
+	return t.Format(modeltime.SQLiteTimestampFormat)
+}
+
+// zeroAsNull returns nil for a height of 0, which is stored as NULL
+func zeroAsNull(height uint32) any {
+	if height == 0 {
+		return nil
+	}
+
+	return height
+}
+
+// nullUint32 returns the value of a nullable height column, 0 for NULL
+func nullUint32(value sql.NullInt64) uint32 {
+	if !value.Valid || value.Int64 < 0 || value.Int64 > 0xFFFFFFFF {
+		return 0
+	}
+
+	return uint32(value.Int64)
+}
@@ -11,6 +11,7 @@
 //   - Optional UTXO expiration with automatic cleanup
 //   - Prometheus metrics integration
 //   - Support for the alert system (freeze/unfreeze/reassign UTXOs)
+//   - Change data capture to Kafka, in the message format of the Aerospike Kafka connector
 //
 // # Usage
 //
@@ -29,6 +30,7 @@
 //   - inputs: Stores transaction inputs with previous output references
 //   - outputs: Stores transaction outputs and UTXO state
 //   - block_ids: Stores which blocks a transaction appears in
+//   - utxo_cdc_outbox: Stores the changes to stream to Kafka, when change data capture is enabled
 //
 // # Metrics
 //
@@ -38,6 +40,7 @@
 //   - teranode_sql_utxo_reset: Number of UTXO reset operations
 //   - teranode_sql_utxo_delete: Number of UTXO delete operations
 //   - teranode_sql_utxo_errors: Number of errors by function and type
+//   - teranode_sql_utxo_cdc_messages: Number of change data capture messages sent to Kafka
 package sql
 
 import (
@@ -15,7 +15,7 @@
 // - Runs cleanup with a specific current block height and retention period
 // - Verifies that only old transactions (stored_at_height <= cutoff) are deleted
 // - Confirms remaining transactions are still accessible and functional
-// - Tests across fully-featured store implementations (SQL stores)
+// - Tests across fully-featured store implementations (SQL and LevelDB stores)
 //
 // Note: Memory store is excluded from full testing because it lacks some features:
 // - GetUnminedTxIterator is not implemented (returns "iterator not implemented")
@@ -36,6 +36,7 @@ import (
 	"github.com/bsv-blockchain/go-bt/v2"
 	"github.com/bsv-blockchain/go-bt/v2/chainhash"
 	"github.com/bsv-blockchain/teranode/stores/utxo"
+	"github.com/bsv-blockchain/teranode/stores/utxo/leveldb"
 	"github.com/bsv-blockchain/teranode/stores/utxo/sql"
 	"github.com/bsv-blockchain/teranode/ulogger"
 	"github.com/bsv-blockchain/teranode/util/test"
@@ -63,6 +64,20 @@ func GetStoreTestCases() []StoreTestCase {
 				return store
 			},
 		},
+		{
+			name: "leveldb_store",
+			createStore: func(t *testing.T, ctx context.Context, logger ulogger.Logger) utxo.Store {
+				settings := test.CreateBaseTestSettings(t)
+				storeURL, err := url.Parse("leveldb://" + t.TempDir())
+				require.NoError(t, err)
+				store, err := leveldb.New(ctx, logger, settings, storeURL)
+				require.NoError(t, err)
+				t.Cleanup(func() {
+					_ = store.Close()
+				})
+				return store
+			},
+		},
 		// Note: Memory store is excluded because:
 		// 1. It doesn't implement GetUnminedTxIterator (returns "iterator not implemented" error)
 		// 2. QueryOldUnminedTransactions returns all unmined transactions regardless of stored_at_height
@@ -28,6 +28,11 @@ func TestUnminedTxIteratorSQLite(t *testing.T) {
 	testUnminedTxIterator(t, utxoStore)
 }
 
+func TestUnminedTxIteratorLevelDB(t *testing.T) {
+	utxoStore := "leveldb://" + t.TempDir()
+	testUnminedTxIterator(t, utxoStore)
+}
+
 func TestUnminedTxIteratorPostgres(t *testing.T) {
 	utxoStore, teardown, err := postgres.SetupTestPostgresContainer()
 	require.NoError(t, err)
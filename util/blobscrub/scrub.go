@@ -0,0 +1,616 @@
+// Package blobscrub verifies the integrity of the blobs in a blob store and repairs them from peers.
+//
+// The scrubber walks the blobs of a store, and for every blob verifies the checksum file written next to it
+// when the store keeps one, that the file format header can be read, and that the blob can be parsed as its
+// file type, for instance that a subtree deserializes to a subtree with the root hash it is stored under.
+// It can also cross-check that the subtrees of the blocks in the blockchain store exist. Problems are
+// collected rather than returned as errors, so a single run reports everything that is wrong with the store.
+//
+// Checksums are only verified when the store itself keeps checksum files, a file store wrapped by another store
+// is not unwrapped. Blobs whose checksum is not verified are counted as unverified in the result.
+//
+// Missing and corrupt subtrees, subtree data and blocks can be repaired by fetching them from the asset
+// HTTP API of peers, the fetched blobs are verified against the hash they are stored under before they
+// replace the blob in the store, with the DAH of the blob they replace.
+//
+// The scrubber is used by the blob-scrub command of teranode-cli.
+package blobscrub
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/bsv-blockchain/go-bt/v2"
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	subtreepkg "github.com/bsv-blockchain/go-subtree"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/model"
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+	"github.com/bsv-blockchain/teranode/services/blockchain"
+	"github.com/bsv-blockchain/teranode/services/utxopersister"
+	"github.com/bsv-blockchain/teranode/stores/blob"
+	"github.com/bsv-blockchain/teranode/stores/blob/options"
+	"github.com/bsv-blockchain/teranode/ulogger"
+	"github.com/bsv-blockchain/teranode/util"
+)
+
+// Check identifies the check a problem was found by
+type Check string
+
+const (
+	CheckChecksum Check = "checksum" // the blob does not match its checksum file
+	CheckRead     Check = "read"     // the blob or its file format header could not be read
+	CheckParse    Check = "parse"    // the blob could not be parsed as its file type, or does not match its key
+	CheckMissing  Check = "missing"  // a blob referenced by a block in the blockchain store is missing
+)
+
+// Problem is a problem found with a blob
+type Problem struct {
+	Key      string              `json:"key"` // the key as displayed, the reversed hex encoded key, or the custom filename
+	FileType fileformat.FileType `json:"fileType"`
+	Check    Check               `json:"check"`
+	Message  string              `json:"message"`
+	Repaired bool                `json:"repaired"` // whether the blob was replaced by a verified copy fetched from a peer
+}
+
+// Options holds the options of a scrub run
+type Options struct {
+	// FileType only scrubs blobs of the file type, FileTypeUnknown scrubs blobs of all file types
+	FileType fileformat.FileType
+
+	// Prefix only scrubs blobs whose reversed hex encoded key starts with the prefix
+	Prefix string
+
+	// CheckBlocks checks that the subtree and subtree data blobs of the blocks in the blockchain store exist
+	CheckBlocks bool
+
+	// Depth is the number of blocks below and including the tip to check, 0 checks the whole chain
+	Depth uint32
+
+	// Repair fetches missing and corrupt subtrees, subtree data and blocks from the peers
+	Repair bool
+
+	// PeerURLs are the base URLs of the asset HTTP API of the peers to repair from, e.g. http://peer:8090/api/v1
+	PeerURLs []string
+}
+
+// Result is the result of a scrub run
+type Result struct {
+	BlobsScrubbed uint64    `json:"blobsScrubbed"` // number of blobs scrubbed
+	Unverified    uint64    `json:"unverified"`    // number of blobs scrubbed without a checksum to verify
+	BlocksChecked uint32    `json:"blocksChecked"` // number of blocks whose subtrees were checked
+	Repaired      int       `json:"repaired"`      // number of problems repaired
+	Problems      []Problem `json:"problems"`
+}
+
+// checksumVerifier is implemented by stores that keep a checksum file next to their blobs
+type checksumVerifier interface {
+	VerifyChecksum(ctx context.Context, key []byte, fileType fileformat.FileType, opts ...options.FileOption) error
+}
+
+// Scrubber verifies and repairs the blobs of a blob store
+type Scrubber struct {
+	logger           ulogger.Logger
+	store            blob.Store
+	blockchainClient blockchain.ClientI
+}
+
+// New creates a scrubber of the store. The blockchain client is only used to check the subtrees of the
+// blocks, and can be nil when Options.CheckBlocks is not set.
+func New(logger ulogger.Logger, store blob.Store, blockchainClient blockchain.ClientI) *Scrubber {
+	return &Scrubber{
+		logger:           logger,
+		store:            store,
+		blockchainClient: blockchainClient,
+	}
+}
+
+// Scrub verifies the blobs of the store, checks the subtrees of the blocks when requested, and repairs the
+// problems found when requested. Errors are only returned when the run cannot continue, problems with
+// blobs are collected in the result.
+func (s *Scrubber) Scrub(ctx context.Context, opts Options) (*Result, error) {
+	if opts.CheckBlocks && s.blockchainClient == nil {
+		return nil, errors.NewConfigurationError("checking the subtrees of the blocks requires a blockchain client")
+	}
+
+	if opts.Repair && len(opts.PeerURLs) == 0 {
+		return nil, errors.NewInvalidArgumentError("repairing blobs requires at least one peer URL")
+	}
+
+	result := &Result{
+		Problems: make([]Problem, 0),
+	}
+
+	listOpts := options.ListOptions{
+		Prefix:   opts.Prefix,
+		FileType: opts.FileType,
+	}
+
+	s.logger.Infof("[BlobScrub] scrubbing blobs with prefix %q and file type %q", opts.Prefix, opts.FileType)
+
+	if _, ok := s.store.(checksumVerifier); !ok {
+		s.logger.Warnf("[BlobScrub] store %T does not keep checksum files, the checksums of the blobs are not verified", s.store)
+	}
+
+	if err := blob.Walk(ctx, s.store, listOpts, func(entry options.ListEntry) error {
+		result.BlobsScrubbed++
+
+		problem, verified := s.scrubBlob(ctx, entry)
+		if !verified {
+			result.Unverified++
+		}
+
+		if problem != nil {
+			s.logger.Warnf("[BlobScrub] %s %s failed %s check: %s", problem.FileType, problem.Key, problem.Check, problem.Message)
+
+			result.Problems = append(result.Problems, *problem)
+		}
+
+		return nil
+	}); err != nil {
+		return result, err
+	}
+
+	if opts.CheckBlocks {
+		if err := s.checkBlocks(ctx, opts.Depth, result); err != nil {
+			return result, err
+		}
+	}
+
+	if opts.Repair {
+		s.repair(ctx, opts.PeerURLs, result)
+	}
+
+	s.logger.Infof("[BlobScrub] scrubbed %d blobs, %d without a checksum, and %d blocks, found %d problems, repaired %d", result.BlobsScrubbed, result.Unverified, result.BlocksChecked, len(result.Problems), result.Repaired)
+
+	return result, nil
+}
+
+// scrubBlob verifies a single blob, it returns nil when no problem was found, and whether the checksum of the
+// blob was verified
+func (s *Scrubber) scrubBlob(ctx context.Context, entry options.ListEntry) (*Problem, bool) {
+	key := entry.Key
+
+	var fileOpts []options.FileOption
+	if key == nil {
+		fileOpts = append(fileOpts, options.WithFilename(entry.Name))
+	}
+
+	newProblem := func(check Check, format string, args ...interface{}) *Problem {
+		return &Problem{Key: entry.Name, FileType: entry.FileType, Check: check, Message: fmt.Sprintf(format, args...)}
+	}
+
+	verified := false
+
+	if verifier, ok := s.store.(checksumVerifier); ok {
+		// a blob without a checksum file is still verified below
+		err := verifier.VerifyChecksum(ctx, key, entry.FileType, fileOpts...)
+		if err != nil && !errors.Is(err, errors.ErrNotFound) {
+			return newProblem(CheckChecksum, "%v", err), true
+		}
+
+		verified = err == nil
+	}
+
+	reader, err := s.store.GetIoReader(ctx, key, entry.FileType, fileOpts...)
+	if err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			// the blob was deleted since it was listed
+			return nil, verified
+		}
+
+		return newProblem(CheckRead, "%v", err), verified
+	}
+
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	if err = s.parse(ctx, key, entry.FileType, reader); err != nil {
+		return newProblem(CheckParse, "%v", err), verified
+	}
+
+	return nil, verified
+}
+
+// parse parses the blob as its file type and checks that it matches the key it is stored under. Blobs of
+// file types that cannot be parsed are read to the end.
+func (s *Scrubber) parse(ctx context.Context, key []byte, fileType fileformat.FileType, r io.Reader) error {
+	var hash *chainhash.Hash
+
+	if len(key) == chainhash.HashSize {
+		hash, _ = chainhash.NewHash(key)
+	}
+
+	switch fileType {
+	case fileformat.FileTypeSubtree, fileformat.FileTypeSubtreeToCheck:
+		subtree, err := subtreepkg.NewSubtreeFromReader(r)
+		if err != nil {
+			return errors.NewProcessingError("failed to deserialize subtree", err)
+		}
+
+		return checkHash(hash, subtree.RootHash(), "subtree root hash")
+
+	case fileformat.FileTypeSubtreeData, fileformat.FileTypeSubtreeMeta:
+		subtree, err := s.getSubtree(ctx, key)
+		if err != nil {
+			return err
+		}
+
+		if subtree == nil {
+			// without the subtree the data can only be read
+			_, err = io.Copy(io.Discard, r)
+			return err
+		}
+
+		if fileType == fileformat.FileTypeSubtreeData {
+			_, err = subtreepkg.NewSubtreeDataFromReader(subtree, r)
+		} else {
+			_, err = subtreepkg.NewSubtreeMetaFromReader(subtree, r)
+		}
+
+		if err != nil {
+			return errors.NewProcessingError("failed to deserialize %s", fileType, err)
+		}
+
+		return nil
+
+	case fileformat.FileTypeBlock:
+		block, err := model.NewBlockFromReader(r)
+		if err != nil {
+			return errors.NewProcessingError("failed to deserialize block", err)
+		}
+
+		return checkHash(hash, block.Hash(), "block hash")
+
+	case fileformat.FileTypeTx:
+		txBytes, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+
+		tx, err := bt.NewTxFromBytes(txBytes)
+		if err != nil {
+			return errors.NewProcessingError("failed to deserialize transaction", err)
+		}
+
+		return checkHash(hash, tx.TxIDChainHash(), "transaction id")
+
+	case fileformat.FileTypeUtxoAdditions, fileformat.FileTypeUtxoDeletions, fileformat.FileTypeUtxoSet:
+		return parseUTXOFile(ctx, hash, fileType, r)
+
+	default:
+		_, err := io.Copy(io.Discard, r)
+		return err
+	}
+}
+
+// getSubtree returns the subtree stored under the key, nil when the store does not hold it
+func (s *Scrubber) getSubtree(ctx context.Context, key []byte) (*subtreepkg.Subtree, error) {
+	subtreeBytes, err := s.store.Get(ctx, key, fileformat.FileTypeSubtree)
+	if err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			return nil, nil
+		}
+
+		return nil, errors.NewStorageError("failed to get the subtree of the blob", err)
+	}
+
+	subtree, err := subtreepkg.NewSubtreeFromBytes(subtreeBytes)
+	if err != nil {
+		return nil, errors.NewProcessingError("failed to deserialize the subtree of the blob", err)
+	}
+
+	return subtree, nil
+}
+
+// parseUTXOFile parses the block hash and height, and the records of a UTXO additions, deletions or set file
+func parseUTXOFile(ctx context.Context, hash *chainhash.Hash, fileType fileformat.FileType, r io.Reader) error {
+	br := bufio.NewReader(r)
+
+	var blockHash chainhash.Hash
+	if _, err := io.ReadFull(br, blockHash[:]); err != nil {
+		return errors.NewProcessingError("failed to read block hash", err)
+	}
+
+	if err := checkHash(hash, &blockHash, "block hash"); err != nil {
+		return err
+	}
+
+	var height [4]byte
+	if _, err := io.ReadFull(br, height[:]); err != nil {
+		return errors.NewProcessingError("failed to read block height", err)
+	}
+
+	if fileType == fileformat.FileTypeUtxoSet {
+		var previousHash chainhash.Hash
+		if _, err := io.ReadFull(br, previousHash[:]); err != nil {
+			return errors.NewProcessingError("failed to read previous block hash", err)
+		}
+	}
+
+	for records := 0; ; records++ {
+		// the records end at the end of the file, or at an EOF marker of 32 zero bytes
+		next, err := br.Peek(chainhash.HashSize)
+		if err != nil {
+			if err == io.EOF && len(next) == 0 {
+				return nil
+			}
+
+			return errors.NewProcessingError("truncated record after %d records", records, err)
+		}
+
+		if bytes.Equal(next, make([]byte, chainhash.HashSize)) {
+			return nil
+		}
+
+		if fileType == fileformat.FileTypeUtxoDeletions {
+			_, err = utxopersister.NewUTXODeletionFromReader(br)
+		} else {
+			_, err = utxopersister.NewUTXOWrapperFromReader(ctx, br)
+		}
+
+		if err != nil {
+			return errors.NewProcessingError("failed to read record %d", records, err)
+		}
+	}
+}
+
+// checkHash returns an error when the hash of the parsed blob is not the hash it is stored under, blobs
+// not stored under a hash are not checked
+func checkHash(want, got *chainhash.Hash, what string) error {
+	if want != nil && !want.IsEqual(got) {
+		return errors.NewProcessingError("%s %s does not match key %s", what, got, want)
+	}
+
+	return nil
+}
+
+// checkBlocks checks that the subtree and subtree data blobs of the blocks from the depth below the tip up
+// to the tip exist in the store
+func (s *Scrubber) checkBlocks(ctx context.Context, depth uint32, result *Result) error {
+	_, tipMeta, err := s.blockchainClient.GetBestBlockHeader(ctx)
+	if err != nil {
+		return errors.NewServiceError("failed to get best block header", err)
+	}
+
+	var startHeight uint32
+	if depth > 0 && depth <= tipMeta.Height {
+		startHeight = tipMeta.Height - depth + 1
+	}
+
+	s.logger.Infof("[BlobScrub] checking the subtrees of blocks %d to %d", startHeight, tipMeta.Height)
+
+	for height := startHeight; height <= tipMeta.Height; height++ {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+
+		block, err := s.blockchainClient.GetBlockByHeight(ctx, height)
+		if err != nil {
+			return errors.NewServiceError("failed to get block at height %d", height, err)
+		}
+
+		result.BlocksChecked++
+
+		for _, subtreeHash := range block.Subtrees {
+			for _, fileType := range []fileformat.FileType{fileformat.FileTypeSubtree, fileformat.FileTypeSubtreeData} {
+				exists, err := s.store.Exists(ctx, subtreeHash[:], fileType)
+				if err != nil {
+					return errors.NewStorageError("failed to check %s %s", fileType, subtreeHash, err)
+				}
+
+				if exists || hasProblem(result.Problems, subtreeHash.String(), fileType) {
+					continue
+				}
+
+				problem := Problem{
+					Key:      subtreeHash.String(),
+					FileType: fileType,
+					Check:    CheckMissing,
+					Message:  fmt.Sprintf("%s of block %s at height %d is missing", fileType, block.Hash(), height),
+				}
+
+				s.logger.Warnf("[BlobScrub] %s", problem.Message)
+
+				result.Problems = append(result.Problems, problem)
+			}
+		}
+	}
+
+	return nil
+}
+
+// hasProblem returns whether a problem was already found with the blob
+func hasProblem(problems []Problem, key string, fileType fileformat.FileType) bool {
+	for _, problem := range problems {
+		if problem.Key == key && problem.FileType == fileType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// repairOrder is the order problems are repaired in, subtree data is verified against the subtree, so
+// subtrees are repaired first
+var repairOrder = map[fileformat.FileType]int{
+	fileformat.FileTypeSubtree:     0,
+	fileformat.FileTypeBlock:       1,
+	fileformat.FileTypeSubtreeData: 2,
+}
+
+// repair replaces the blobs of the problems that can be repaired by verified copies fetched from the peers
+func (s *Scrubber) repair(ctx context.Context, peerURLs []string, result *Result) {
+	indexes := make([]int, 0, len(result.Problems))
+
+	for i, problem := range result.Problems {
+		if _, ok := repairOrder[problem.FileType]; ok {
+			indexes = append(indexes, i)
+		}
+	}
+
+	sort.SliceStable(indexes, func(a, b int) bool {
+		return repairOrder[result.Problems[indexes[a]].FileType] < repairOrder[result.Problems[indexes[b]].FileType]
+	})
+
+	for _, i := range indexes {
+		problem := &result.Problems[i]
+
+		hash, err := chainhash.NewHashFromStr(problem.Key)
+		if err != nil {
+			continue
+		}
+
+		for _, peerURL := range peerURLs {
+			if err = s.repairBlob(ctx, strings.TrimSuffix(peerURL, "/"), hash, problem.FileType); err != nil {
+				s.logger.Warnf("[BlobScrub] failed to repair %s %s from %s: %v", problem.FileType, hash, peerURL, err)
+				continue
+			}
+
+			s.logger.Infof("[BlobScrub] repaired %s %s from %s", problem.FileType, hash, peerURL)
+
+			problem.Repaired = true
+			result.Repaired++
+
+			break
+		}
+	}
+}
+
+// repairBlob fetches the blob from the peer, verifies it against the hash and stores it with the DAH of the blob
+// it replaces
+func (s *Scrubber) repairBlob(ctx context.Context, peerURL string, hash *chainhash.Hash, fileType fileformat.FileType) error {
+	var (
+		data []byte
+		err  error
+	)
+
+	switch fileType {
+	case fileformat.FileTypeSubtree:
+		data, err = fetchSubtree(ctx, peerURL, hash)
+	case fileformat.FileTypeSubtreeData:
+		data, err = s.fetchSubtreeData(ctx, peerURL, hash)
+	case fileformat.FileTypeBlock:
+		data, err = fetchBlock(ctx, peerURL, hash)
+	default:
+		err = errors.NewProcessingError("%s blobs cannot be fetched from peers", fileType)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	fileOpts := []options.FileOption{options.WithAllowOverwrite(true)}
+
+	dah, err := s.store.GetDAH(ctx, hash[:], fileType)
+	if err != nil && !errors.Is(err, errors.ErrNotFound) {
+		return errors.NewStorageError("failed to get the DAH of %s %s", fileType, hash, err)
+	}
+
+	if dah > 0 {
+		fileOpts = append(fileOpts, options.WithDeleteAt(dah))
+	}
+
+	if err = s.store.Set(ctx, hash[:], fileType, data, fileOpts...); err != nil {
+		return errors.NewStorageError("failed to store %s %s", fileType, hash, err)
+	}
+
+	return nil
+}
+
+// fetchSubtree fetches the subtree from the peer and rebuilds it, with the fees and sizes of its nodes and its
+// conflicting nodes. The binary subtree endpoint of the asset API only serves the node hashes, so the subtree is
+// read from the JSON endpoint, which serves the whole subtree.
+func fetchSubtree(ctx context.Context, peerURL string, hash *chainhash.Hash) ([]byte, error) {
+	subtreeJSON, err := util.DoHTTPRequest(ctx, fmt.Sprintf("%s/subtree/%s/json", peerURL, hash))
+	if err != nil {
+		return nil, errors.NewServiceError("failed to get subtree %s", hash, err)
+	}
+
+	var fetched subtreepkg.Subtree
+	if err = json.Unmarshal(subtreeJSON, &fetched); err != nil {
+		return nil, errors.NewProcessingError("failed to decode subtree %s", hash, err)
+	}
+
+	if len(fetched.Nodes) == 0 {
+		return nil, errors.NewProcessingError("subtree %s has no nodes", hash)
+	}
+
+	subtree, err := subtreepkg.NewIncompleteTreeByLeafCount(len(fetched.Nodes))
+	if err != nil {
+		return nil, errors.NewProcessingError("failed to create subtree structure", err)
+	}
+
+	for _, node := range fetched.Nodes {
+		if node.Hash.Equal(subtreepkg.CoinbasePlaceholderHashValue) {
+			err = subtree.AddCoinbaseNode()
+		} else {
+			err = subtree.AddSubtreeNode(node)
+		}
+
+		if err != nil {
+			return nil, errors.NewProcessingError("failed to add node to subtree", err)
+		}
+	}
+
+	for _, conflictingNode := range fetched.ConflictingNodes {
+		if err = subtree.AddConflictingNode(conflictingNode); err != nil {
+			return nil, errors.NewProcessingError("failed to add conflicting node to subtree", err)
+		}
+	}
+
+	if err = checkHash(hash, subtree.RootHash(), "subtree root hash"); err != nil {
+		return nil, err
+	}
+
+	return subtree.Serialize()
+}
+
+// fetchSubtreeData fetches the subtree data from the peer and verifies it against the stored subtree
+func (s *Scrubber) fetchSubtreeData(ctx context.Context, peerURL string, hash *chainhash.Hash) ([]byte, error) {
+	subtree, err := s.getSubtree(ctx, hash[:])
+	if err != nil {
+		return nil, err
+	}
+
+	if subtree == nil {
+		return nil, errors.NewProcessingError("subtree %s is needed to verify its subtree data", hash)
+	}
+
+	data, err := util.DoHTTPRequest(ctx, fmt.Sprintf("%s/subtree_data/%s", peerURL, hash))
+	if err != nil {
+		return nil, errors.NewServiceError("failed to get subtree data %s", hash, err)
+	}
+
+	if _, err = subtreepkg.NewSubtreeDataFromBytes(subtree, data); err != nil {
+		return nil, errors.NewProcessingError("failed to deserialize subtree data %s", hash, err)
+	}
+
+	return data, nil
+}
+
+// fetchBlock fetches the block from the peer and verifies its hash
+func fetchBlock(ctx context.Context, peerURL string, hash *chainhash.Hash) ([]byte, error) {
+	data, err := util.DoHTTPRequest(ctx, fmt.Sprintf("%s/block/%s", peerURL, hash))
+	if err != nil {
+		return nil, errors.NewServiceError("failed to get block %s", hash, err)
+	}
+
+	block, err := model.NewBlockFromBytes(data)
+	if err != nil {
+		return nil, errors.NewProcessingError("failed to deserialize block %s", hash, err)
+	}
+
+	if err = checkHash(hash, block.Hash(), "block hash"); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
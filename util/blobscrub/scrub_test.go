@@ -0,0 +1,169 @@
+package blobscrub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	subtreepkg "github.com/bsv-blockchain/go-subtree"
+	"github.com/bsv-blockchain/teranode/model"
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+	"github.com/bsv-blockchain/teranode/services/blockchain"
+	"github.com/bsv-blockchain/teranode/services/utxopersister"
+	"github.com/bsv-blockchain/teranode/stores/blob/memory"
+	"github.com/bsv-blockchain/teranode/stores/blob/options"
+	"github.com/bsv-blockchain/teranode/ulogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// testSubtree returns a subtree with the coinbase placeholder and three transactions
+func testSubtree(t *testing.T) *subtreepkg.Subtree {
+	subtree, err := subtreepkg.NewTreeByLeafCount(4)
+	require.NoError(t, err)
+	require.NoError(t, subtree.AddCoinbaseNode())
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, subtree.AddNode(chainhash.DoubleHashH([]byte(fmt.Sprintf("tx%d", i))), 1, 1))
+	}
+
+	return subtree
+}
+
+func TestScrub_Blobs(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+
+	subtree := testSubtree(t)
+	subtreeBytes, err := subtree.Serialize()
+	require.NoError(t, err)
+
+	otherHash := chainhash.DoubleHashH([]byte("other"))
+	blockHash := chainhash.DoubleHashH([]byte("block"))
+
+	deletion := &utxopersister.UTXODeletion{TxID: chainhash.DoubleHashH([]byte("tx")), Index: 1}
+	deletions := append(append(append([]byte{}, blockHash[:]...), 1, 0, 0, 0), deletion.DeletionBytes()...)
+
+	require.NoError(t, store.Set(ctx, subtree.RootHash()[:], fileformat.FileTypeSubtree, subtreeBytes))
+	require.NoError(t, store.Set(ctx, otherHash[:], fileformat.FileTypeSubtree, subtreeBytes))
+	require.NoError(t, store.Set(ctx, otherHash[:], fileformat.FileTypeSubtreeToCheck, []byte("not a subtree")))
+	require.NoError(t, store.Set(ctx, blockHash[:], fileformat.FileTypeUtxoDeletions, deletions))
+	require.NoError(t, store.Set(ctx, otherHash[:], fileformat.FileTypeUtxoDeletions, deletions))
+	require.NoError(t, store.Set(ctx, blockHash[:], fileformat.FileTypeUtxoAdditions, append(append([]byte{}, blockHash[:]...), 1, 0, 0, 0, 1)))
+
+	s := New(ulogger.TestLogger{}, store, nil)
+
+	result, err := s.Scrub(ctx, Options{})
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(6), result.BlobsScrubbed)
+
+	// the memory store keeps no checksum files
+	assert.Equal(t, uint64(6), result.Unverified)
+
+	problems := make(map[string]Check, len(result.Problems))
+	for _, problem := range result.Problems {
+		assert.False(t, problem.Repaired)
+
+		problems[problem.Key+"."+problem.FileType.String()] = problem.Check
+	}
+
+	// the subtree and UTXO deletions stored under the wrong hash, the corrupt subtree and the truncated additions
+	assert.Equal(t, map[string]Check{
+		otherHash.String() + ".subtree":        CheckParse,
+		otherHash.String() + ".subtreeToCheck": CheckParse,
+		otherHash.String() + ".utxo-deletions": CheckParse,
+		blockHash.String() + ".utxo-additions": CheckParse,
+	}, problems)
+
+	// only the blobs of the file type are scrubbed
+	result, err = s.Scrub(ctx, Options{FileType: fileformat.FileTypeUtxoDeletions})
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(2), result.BlobsScrubbed)
+	assert.Len(t, result.Problems, 1)
+
+	// repairing and checking blocks need peers and a blockchain client
+	_, err = s.Scrub(ctx, Options{Repair: true})
+	require.Error(t, err)
+
+	_, err = s.Scrub(ctx, Options{CheckBlocks: true})
+	require.Error(t, err)
+}
+
+func TestScrub_CheckBlocksAndRepair(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+
+	subtree := testSubtree(t)
+	require.NoError(t, subtree.AddConflictingNode(subtree.Nodes[2].Hash))
+
+	subtreeHash := subtree.RootHash()
+
+	// the stored subtree is corrupt
+	require.NoError(t, store.Set(ctx, subtreeHash[:], fileformat.FileTypeSubtree, []byte("not a subtree"), options.WithDeleteAt(100)))
+
+	header := &model.BlockHeader{Version: 1, HashPrevBlock: &chainhash.Hash{}, HashMerkleRoot: &chainhash.Hash{}}
+	block := &model.Block{Header: header, Subtrees: []*chainhash.Hash{subtreeHash}, Height: 1}
+
+	mockBlockchainClient := &blockchain.Mock{}
+	mockBlockchainClient.On("GetBestBlockHeader", mock.Anything).Return(header, &model.BlockHeaderMeta{Height: 1}, nil)
+	mockBlockchainClient.On("GetBlockByHeight", mock.Anything, uint32(1)).Return(block, nil)
+
+	// the peer serves the subtree, but not its subtree data
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/subtree/"+subtreeHash.String()+"/json" {
+			http.NotFound(w, r)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(subtree)
+	}))
+	defer server.Close()
+
+	s := New(ulogger.TestLogger{}, store, mockBlockchainClient)
+
+	result, err := s.Scrub(ctx, Options{CheckBlocks: true, Depth: 1, Repair: true, PeerURLs: []string{server.URL}})
+	require.NoError(t, err)
+
+	assert.Equal(t, uint32(1), result.BlocksChecked)
+	assert.Equal(t, 1, result.Repaired)
+	require.Len(t, result.Problems, 2)
+
+	assert.Equal(t, fileformat.FileTypeSubtree, result.Problems[0].FileType)
+	assert.Equal(t, CheckParse, result.Problems[0].Check)
+	assert.True(t, result.Problems[0].Repaired)
+
+	assert.Equal(t, fileformat.FileTypeSubtreeData, result.Problems[1].FileType)
+	assert.Equal(t, CheckMissing, result.Problems[1].Check)
+	assert.False(t, result.Problems[1].Repaired)
+
+	// the repaired subtree is the block subtree, with the fees, sizes and conflicting nodes, and the DAH of the
+	// corrupt subtree
+	subtreeBytes, err := store.Get(ctx, subtreeHash[:], fileformat.FileTypeSubtree)
+	require.NoError(t, err)
+
+	repaired, err := subtreepkg.NewSubtreeFromBytes(subtreeBytes)
+	require.NoError(t, err)
+	assert.Equal(t, subtreeHash, repaired.RootHash())
+	assert.Equal(t, subtree.Nodes, repaired.Nodes)
+	assert.Equal(t, subtree.Fees, repaired.Fees)
+	assert.Equal(t, subtree.ConflictingNodes, repaired.ConflictingNodes)
+
+	dah, err := store.GetDAH(ctx, subtreeHash[:], fileformat.FileTypeSubtree)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(100), dah)
+
+	// a run after the repair only finds the missing subtree data
+	result, err = s.Scrub(ctx, Options{CheckBlocks: true, Depth: 1})
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(1), result.BlobsScrubbed)
+	require.Len(t, result.Problems, 1)
+	assert.Equal(t, fileformat.FileTypeSubtreeData, result.Problems[0].FileType)
+}
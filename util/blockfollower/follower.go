@@ -0,0 +1,241 @@
+// Package blockfollower provides the loop shared by the services that index the blocks of the best chain
+// one at a time, such as the filter index and the script hash index.
+//
+// A Follower subscribes to blockchain notifications and calls the ProcessNextBlockFunc of the service
+// whenever a block is added, on a timer, and again after every processed block, so that the index catches
+// up with the best chain without waiting for notifications. Only one call runs at a time.
+//
+// The package also provides the helpers to read the subtree data written by the Block Persister, from which
+// these services index the transactions of a block.
+package blockfollower
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	subtreepkg "github.com/bsv-blockchain/go-subtree"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/model"
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+	"github.com/bsv-blockchain/teranode/services/blockchain"
+	"github.com/bsv-blockchain/teranode/stores/blob"
+	"github.com/bsv-blockchain/teranode/ulogger"
+)
+
+// timerInterval is the interval at which the next block is processed when no notifications are received
+const timerInterval = 1 * time.Minute
+
+// ProcessNextBlockFunc processes the next block of the best chain. It returns the time to wait before the
+// next block is processed, or a not found error when there is no block to process.
+type ProcessNextBlockFunc func(ctx context.Context) (time.Duration, error)
+
+// Follower calls a ProcessNextBlockFunc until the index of a service has caught up with the best chain.
+type Follower struct {
+	// logger provides logging functionality
+	logger ulogger.Logger
+
+	// name is the prefix of the log messages, e.g. FilterIndex
+	name string
+
+	// subscriber is the name the follower subscribes to blockchain notifications with
+	subscriber string
+
+	// blockchainClient provides the blockchain notifications
+	blockchainClient blockchain.ClientI
+
+	// processNextBlock processes the next block
+	processNextBlock ProcessNextBlockFunc
+
+	// mu provides mutex locking for thread safety
+	mu sync.Mutex
+
+	// running indicates if the follower is currently processing
+	running bool
+
+	// triggerCh is used to trigger processing operations
+	triggerCh chan string
+}
+
+// New creates a new Follower.
+//
+// Parameters:
+// - logger: Logger interface used for recording operational events and errors
+// - name: Prefix of the log messages
+// - subscriber: Name the follower subscribes to blockchain notifications with
+// - blockchainClient: Client interface for subscribing to blockchain notifications
+// - processNextBlock: Function processing the next block
+//
+// Returns a Follower ready to be started with Start.
+func New(logger ulogger.Logger, name string, subscriber string, blockchainClient blockchain.ClientI, processNextBlock ProcessNextBlockFunc) *Follower {
+	return &Follower{
+		logger:           logger,
+		name:             name,
+		subscriber:       subscriber,
+		blockchainClient: blockchainClient,
+		processNextBlock: processNextBlock,
+		triggerCh:        make(chan string, 5),
+	}
+}
+
+// Start waits for the blockchain FSM to leave the IDLE state, subscribes to blockchain notifications and
+// processes blocks as they are received through the notification channel or on a timer, until the context
+// is canceled, the subscription is closed or processing fails.
+//
+// Parameters:
+// - ctx: Context for controlling the follower's lifecycle
+//
+// Returns:
+// - error: Any error encountered during startup or processing
+func (f *Follower) Start(ctx context.Context) error {
+	// Blocks until the FSM transitions from the IDLE state
+	if err := f.blockchainClient.WaitUntilFSMTransitionFromIdleState(ctx); err != nil {
+		f.logger.Errorf("[%s Service] Failed to wait for FSM transition from IDLE state: %s", f.name, err)
+
+		return err
+	}
+
+	ch, err := f.blockchainClient.Subscribe(ctx, f.subscriber)
+	if err != nil {
+		return err
+	}
+
+	// Kick off the first block processing
+	f.Trigger("startup")
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case _, ok := <-ch:
+			if !ok {
+				return errors.NewServiceError("[%s] blockchain subscription closed", f.name)
+			}
+
+			if err := f.trigger(ctx, "blockchain"); err != nil {
+				return err
+			}
+
+		case source := <-f.triggerCh:
+			if err := f.trigger(ctx, source); err != nil {
+				return err
+			}
+
+		case <-time.After(timerInterval):
+			if err := f.trigger(ctx, "timer"); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Trigger requests the processing of the next block, without waiting for it.
+// The request is dropped when the trigger channel is full, as processing has been requested already.
+func (f *Follower) Trigger(source string) {
+	select {
+	case f.triggerCh <- source:
+		// Successfully sent
+	default:
+		f.logger.Debugf("[%s] Dropping %s trigger due to full channel", f.name, source)
+	}
+}
+
+// trigger processes the next block.
+// It ensures only one processing operation runs at a time, and triggers the next iteration when a block
+// has been processed. The delay returned by the processing is waited for before the next iteration, unless
+// the context is canceled.
+func (f *Follower) trigger(ctx context.Context, source string) error {
+	f.mu.Lock()
+
+	if f.running {
+		f.mu.Unlock()
+
+		f.logger.Debugf("[%s] Process already running, skipping trigger from %s", f.name, source)
+
+		return nil // Exit if the process is already running
+	}
+
+	f.running = true
+	f.mu.Unlock()
+
+	defer func() {
+		f.mu.Lock()
+		f.running = false
+		f.mu.Unlock()
+	}()
+
+	f.logger.Debugf("[%s] Trigger from %s to process next block", f.name, source)
+
+	delay, err := f.processNextBlock(ctx)
+	if err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			f.logger.Debugf("[%s] No new block to process", f.name)
+			return nil
+		}
+
+		return err
+	}
+
+	if delay > 0 {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+	}
+
+	// Trigger the next block processing
+	f.Trigger("iteration")
+
+	return nil
+}
+
+// SubtreeDataPersisted returns whether the subtree data of all subtrees of the block has been written.
+func SubtreeDataPersisted(ctx context.Context, subtreeStore blob.Store, block *model.Block) (bool, error) {
+	for _, subtreeHash := range block.Subtrees {
+		exists, err := subtreeStore.Exists(ctx, subtreeHash[:], fileformat.FileTypeSubtreeData)
+		if err != nil {
+			return false, errors.NewStorageError("failed to check subtree data of %s", subtreeHash, err)
+		}
+
+		if !exists {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// ReadSubtreeData reads a subtree and its subtree data from the subtree store.
+func ReadSubtreeData(ctx context.Context, subtreeStore blob.Store, subtreeHash *chainhash.Hash) (*subtreepkg.Data, error) {
+	subtreeReader, err := subtreeStore.GetIoReader(ctx, subtreeHash[:], fileformat.FileTypeSubtree)
+	if err != nil {
+		subtreeReader, err = subtreeStore.GetIoReader(ctx, subtreeHash[:], fileformat.FileTypeSubtreeToCheck)
+		if err != nil {
+			return nil, errors.NewStorageError("failed to get subtree %s from store", subtreeHash, err)
+		}
+	}
+
+	defer subtreeReader.Close()
+
+	subtree := &subtreepkg.Subtree{}
+	if err = subtree.DeserializeFromReader(subtreeReader); err != nil {
+		return nil, errors.NewProcessingError("failed to deserialize subtree %s", subtreeHash, err)
+	}
+
+	subtreeDataReader, err := subtreeStore.GetIoReader(ctx, subtreeHash[:], fileformat.FileTypeSubtreeData)
+	if err != nil {
+		return nil, errors.NewStorageError("failed to get subtree data %s from store", subtreeHash, err)
+	}
+
+	defer subtreeDataReader.Close()
+
+	subtreeData, err := subtreepkg.NewSubtreeDataFromReader(subtree, subtreeDataReader)
+	if err != nil {
+		return nil, errors.NewProcessingError("failed to deserialize subtree data %s", subtreeHash, err)
+	}
+
+	return subtreeData, nil
+}
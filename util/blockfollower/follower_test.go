@@ -0,0 +1,127 @@
+package blockfollower
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/services/blockchain"
+	"github.com/bsv-blockchain/teranode/services/blockchain/blockchain_api"
+	"github.com/bsv-blockchain/teranode/ulogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrigger_AlreadyRunning(t *testing.T) {
+	var calls atomic.Int32
+
+	f := New(ulogger.TestLogger{}, "Test", "test", nil, func(ctx context.Context) (time.Duration, error) {
+		calls.Add(1)
+		return 0, nil
+	})
+	f.running = true
+
+	err := f.trigger(context.Background(), "test")
+
+	assert.NoError(t, err)
+	assert.True(t, f.running)
+	assert.Equal(t, int32(0), calls.Load())
+}
+
+func TestTrigger_NoBlock(t *testing.T) {
+	f := New(ulogger.TestLogger{}, "Test", "test", nil, func(ctx context.Context) (time.Duration, error) {
+		return 0, errors.NewNotFoundError("no block")
+	})
+
+	require.NoError(t, f.trigger(context.Background(), "test"))
+
+	// no next iteration is triggered
+	assert.Empty(t, f.triggerCh)
+}
+
+func TestTrigger_DelayCanceled(t *testing.T) {
+	f := New(ulogger.TestLogger{}, "Test", "test", nil, func(ctx context.Context) (time.Duration, error) {
+		return time.Hour, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- f.trigger(ctx, "test")
+	}()
+
+	cancel()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("trigger did not return when the context was canceled")
+	}
+}
+
+func TestStart_ProcessesUntilCaughtUp(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mockBlockchainClient := &blockchain.Mock{}
+	mockBlockchainClient.On("WaitUntilFSMTransitionFromIdleState", mock.Anything).Return(nil)
+	mockBlockchainClient.On("Subscribe", mock.Anything, "test").Return(make(chan *blockchain_api.Notification), nil)
+
+	var processed atomic.Int32
+
+	f := New(ulogger.TestLogger{}, "Test", "test", mockBlockchainClient, func(ctx context.Context) (time.Duration, error) {
+		if processed.Load() == 3 {
+			return 0, errors.NewNotFoundError("no block")
+		}
+
+		processed.Add(1)
+
+		return 0, nil
+	})
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- f.Start(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		return processed.Load() == 3
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+
+	require.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestStart_SubscriptionClosed(t *testing.T) {
+	ch := make(chan *blockchain_api.Notification)
+	close(ch)
+
+	mockBlockchainClient := &blockchain.Mock{}
+	mockBlockchainClient.On("WaitUntilFSMTransitionFromIdleState", mock.Anything).Return(nil)
+	mockBlockchainClient.On("Subscribe", mock.Anything, "test").Return(ch, nil)
+
+	f := New(ulogger.TestLogger{}, "Test", "test", mockBlockchainClient, func(ctx context.Context) (time.Duration, error) {
+		return 0, errors.NewNotFoundError("no block")
+	})
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- f.Start(context.Background())
+	}()
+
+	select {
+	case err := <-done:
+		require.ErrorIs(t, err, errors.ErrServiceError)
+	case <-time.After(time.Second):
+		t.Fatal("start did not return when the subscription was closed")
+	}
+}
@@ -0,0 +1,346 @@
+// Package chainverifier re-audits the blocks in the blockchain store.
+//
+// The verifier walks the best chain from a given depth below the tip up to the tip, and for every block
+// re-checks the header, and depending on the check level, the difficulty, the presence of the subtree and
+// subtree data blobs, the merkle root computed from the stored subtrees and the validity of the block
+// against the UTXO store. Problems are collected rather than returned as errors, so a single run reports
+// everything that is wrong in the range that was verified.
+//
+// The verifier is used by the verifychain RPC, and by the verify-chain command of teranode-cli, which
+// persists the progress so an interrupted run can be resumed.
+package chainverifier
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	txmap "github.com/bsv-blockchain/go-tx-map"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/model"
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+	"github.com/bsv-blockchain/teranode/services/blockchain"
+	"github.com/bsv-blockchain/teranode/settings"
+	"github.com/bsv-blockchain/teranode/stores/blob"
+	"github.com/bsv-blockchain/teranode/stores/utxo"
+	"github.com/bsv-blockchain/teranode/ulogger"
+)
+
+// Check levels, every level includes the checks of the levels below it
+const (
+	// LevelHeaders checks that every block can be loaded, links to its parent and meets its proof of work target
+	LevelHeaders = iota
+
+	// LevelDifficulty checks that the difficulty of every block is the difficulty required by the chain
+	LevelDifficulty
+
+	// LevelSubtrees checks that the subtree and subtree data blobs of every block exist in the subtree store
+	LevelSubtrees
+
+	// LevelMerkleRoot checks that the merkle root computed from the stored subtrees matches the block header
+	LevelMerkleRoot
+
+	// LevelBlock validates every block against the UTXO store, as the block validation service does
+	LevelBlock
+)
+
+// Check identifies the check a problem was found by
+type Check string
+
+const (
+	CheckLoad        Check = "load"        // the block could not be loaded from the blockchain store
+	CheckHeader      Check = "header"      // the block does not link to its parent
+	CheckProofOfWork Check = "pow"         // the block hash does not meet the target of the block
+	CheckDifficulty  Check = "difficulty"  // the target of the block is not the target required by the chain
+	CheckSubtree     Check = "subtree"     // a subtree of the block is missing from the subtree store
+	CheckSubtreeData Check = "subtreeData" // the subtree data of a subtree of the block is missing
+	CheckMerkleRoot  Check = "merkleRoot"  // the merkle root computed from the subtrees does not match the header
+	CheckBlock       Check = "block"       // the block is not valid against the UTXO store
+)
+
+// Problem is a problem found with a block
+type Problem struct {
+	Height  uint32 `json:"height"`
+	Hash    string `json:"hash,omitempty"` // empty when the block could not be loaded
+	Check   Check  `json:"check"`
+	Message string `json:"message"`
+}
+
+// Options holds the options of a verification run
+type Options struct {
+	// Level is the check level, LevelHeaders to LevelBlock
+	Level int
+
+	// Depth is the number of blocks below and including the tip to verify, 0 verifies the whole chain
+	Depth uint32
+
+	// StartHeight, when set, is the height to start verifying at instead of the height derived from the
+	// depth, used to resume an interrupted run
+	StartHeight *uint32
+
+	// Progress, when set, is called after every block with the height of the block and the problems
+	// found with it. Returning an error stops the run.
+	Progress func(height uint32, problems []Problem) error
+}
+
+// Result is the result of a verification run
+type Result struct {
+	TipHash       string    `json:"tipHash"`       // hash of the tip when the run started
+	TipHeight     uint32    `json:"tipHeight"`     // height of the tip when the run started
+	StartHeight   uint32    `json:"startHeight"`   // height of the first block verified
+	BlocksChecked uint32    `json:"blocksChecked"` // number of blocks verified
+	Problems      []Problem `json:"problems"`
+}
+
+// Verifier verifies the blocks of the best chain
+type Verifier struct {
+	logger           ulogger.Logger
+	settings         *settings.Settings
+	blockchainClient blockchain.ClientI
+	subtreeStore     blob.Store
+	utxoStore        utxo.Store
+}
+
+// New creates a verifier. The subtree store is only used from LevelSubtrees, and the UTXO store only at
+// LevelBlock.
+func New(logger ulogger.Logger, tSettings *settings.Settings, blockchainClient blockchain.ClientI, subtreeStore blob.Store, utxoStore utxo.Store) *Verifier {
+	return &Verifier{
+		logger:           logger,
+		settings:         tSettings,
+		blockchainClient: blockchainClient,
+		subtreeStore:     subtreeStore,
+		utxoStore:        utxoStore,
+	}
+}
+
+// Verify verifies the blocks from the start height up to the tip of the best chain at the time the run
+// started. Errors are only returned when the run cannot continue, problems with blocks are collected in
+// the result.
+func (v *Verifier) Verify(ctx context.Context, opts Options) (*Result, error) {
+	if opts.Level < LevelHeaders || opts.Level > LevelBlock {
+		return nil, errors.NewInvalidArgumentError("check level must be between %d and %d", LevelHeaders, LevelBlock)
+	}
+
+	if opts.Level >= LevelSubtrees && v.subtreeStore == nil {
+		return nil, errors.NewConfigurationError("check level %d requires a subtree store", opts.Level)
+	}
+
+	if opts.Level >= LevelBlock && v.utxoStore == nil {
+		return nil, errors.NewConfigurationError("check level %d requires a UTXO store", opts.Level)
+	}
+
+	tipHeader, tipMeta, err := v.blockchainClient.GetBestBlockHeader(ctx)
+	if err != nil {
+		return nil, errors.NewServiceError("failed to get best block header", err)
+	}
+
+	result := &Result{
+		TipHash:   tipHeader.Hash().String(),
+		TipHeight: tipMeta.Height,
+		Problems:  make([]Problem, 0),
+	}
+
+	if opts.Depth > 0 && opts.Depth <= tipMeta.Height {
+		result.StartHeight = tipMeta.Height - opts.Depth + 1
+	}
+
+	if opts.StartHeight != nil {
+		result.StartHeight = *opts.StartHeight
+	}
+
+	v.logger.Infof("[ChainVerifier] verifying blocks %d to %d at check level %d", result.StartHeight, result.TipHeight, opts.Level)
+
+	var previous *model.BlockHeader
+
+	for height := result.StartHeight; height <= result.TipHeight; height++ {
+		if err = ctx.Err(); err != nil {
+			return result, err
+		}
+
+		var problems []Problem
+
+		previous, problems = v.verifyBlock(ctx, opts.Level, height, previous)
+
+		result.BlocksChecked++
+		result.Problems = append(result.Problems, problems...)
+
+		for _, problem := range problems {
+			v.logger.Warnf("[ChainVerifier] block %s at height %d failed %s check: %s", problem.Hash, problem.Height, problem.Check, problem.Message)
+		}
+
+		if opts.Progress != nil {
+			if err = opts.Progress(height, problems); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	v.logger.Infof("[ChainVerifier] verified %d blocks, found %d problems", result.BlocksChecked, len(result.Problems))
+
+	return result, nil
+}
+
+// verifyBlock runs the checks of the level on the block at the height. The previous header is the header
+// of the block verified before, nil when it is not known, in which case the parent is looked up. It returns
+// the header of the block, nil when the block could not be loaded, and the problems found with it.
+func (v *Verifier) verifyBlock(ctx context.Context, level int, height uint32, previous *model.BlockHeader) (*model.BlockHeader, []Problem) {
+	block, err := v.blockchainClient.GetBlockByHeight(ctx, height)
+	if err != nil {
+		return nil, []Problem{{Height: height, Check: CheckLoad, Message: err.Error()}}
+	}
+
+	problems := make([]Problem, 0)
+
+	addProblem := func(check Check, format string, args ...interface{}) {
+		problems = append(problems, Problem{Height: height, Hash: block.Hash().String(), Check: check, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if height > 0 {
+		if previous == nil {
+			if previous, _, err = v.blockchainClient.GetBlockHeader(ctx, block.Header.HashPrevBlock); err != nil {
+				addProblem(CheckHeader, "parent block %s could not be loaded: %v", block.Header.HashPrevBlock, err)
+			}
+		}
+
+		if previous != nil && !previous.Hash().IsEqual(block.Header.HashPrevBlock) {
+			addProblem(CheckHeader, "block does not link to block %s at height %d", previous.Hash(), height-1)
+		}
+	}
+
+	if ok, _, err := block.Header.HasMetTargetDifficulty(); !ok {
+		if err != nil {
+			addProblem(CheckProofOfWork, "failed to check the target of the block: %v", err)
+		} else {
+			addProblem(CheckProofOfWork, "block hash does not meet the target of the block")
+		}
+	}
+
+	if level >= LevelDifficulty && height > 0 && height > highestCheckpointHeight(v.settings) {
+		expectedNBits, err := v.blockchainClient.GetNextWorkRequired(ctx, block.Header.HashPrevBlock, int64(block.Header.Timestamp))
+
+		switch {
+		case err != nil:
+			addProblem(CheckDifficulty, "failed to calculate the required difficulty: %v", err)
+		case expectedNBits != nil && block.Header.Bits != *expectedNBits:
+			addProblem(CheckDifficulty, "incorrect difficulty bits: got %s, expected %s", block.Header.Bits, *expectedNBits)
+		}
+	}
+
+	if level < LevelSubtrees {
+		return block.Header, problems
+	}
+
+	if !v.checkSubtrees(ctx, block, addProblem) {
+		// the remaining checks need all subtrees of the block
+		return block.Header, problems
+	}
+
+	if level < LevelMerkleRoot {
+		return block.Header, problems
+	}
+
+	if err = block.GetAndValidateSubtrees(ctx, v.logger, v.subtreeStore, v.settings.Block.GetAndValidateSubtreesConcurrency); err != nil {
+		addProblem(CheckSubtree, "failed to load the subtrees: %v", err)
+		return block.Header, problems
+	}
+
+	if err = block.CheckMerkleRoot(ctx); err != nil {
+		addProblem(CheckMerkleRoot, "%v", err)
+		return block.Header, problems
+	}
+
+	if level < LevelBlock {
+		return block.Header, problems
+	}
+
+	if err = v.validateBlock(ctx, block); err != nil {
+		addProblem(CheckBlock, "%v", err)
+	}
+
+	return block.Header, problems
+}
+
+// checkSubtrees checks that the subtree and subtree data blobs of all subtrees of the block exist, and
+// returns whether all of them do
+func (v *Verifier) checkSubtrees(ctx context.Context, block *model.Block, addProblem func(check Check, format string, args ...interface{})) bool {
+	complete := true
+
+	for _, subtreeHash := range block.Subtrees {
+		for _, fileType := range []fileformat.FileType{fileformat.FileTypeSubtree, fileformat.FileTypeSubtreeData} {
+			exists, err := v.subtreeStore.Exists(ctx, subtreeHash[:], fileType)
+
+			switch {
+			case err != nil:
+				addProblem(checkForFileType(fileType), "failed to check %s %s: %v", fileType, subtreeHash, err)
+
+				complete = false
+			case !exists:
+				addProblem(checkForFileType(fileType), "%s %s is missing", fileType, subtreeHash)
+
+				complete = false
+			}
+		}
+	}
+
+	return complete
+}
+
+// validateBlock validates the block against the UTXO store with the headers before it as the current chain,
+// and checks that the parents mined in blocks older than those headers are on the chain of the block
+func (v *Verifier) validateBlock(ctx context.Context, block *model.Block) error {
+	blockHeaders, blockHeadersMeta, err := v.blockchainClient.GetBlockHeaders(ctx, block.Header.HashPrevBlock, v.settings.BlockValidation.PreviousBlockHeaderCount)
+	if err != nil {
+		return errors.NewServiceError("failed to get the block headers before the block", err)
+	}
+
+	blockHeaderIDs := make([]uint32, len(blockHeadersMeta))
+	for i, blockHeaderMeta := range blockHeadersMeta {
+		blockHeaderIDs[i] = blockHeaderMeta.ID
+	}
+
+	oldBlockIDsMap := txmap.NewSyncedMap[chainhash.Hash, []uint32]()
+
+	if ok, err := block.Valid(ctx, v.logger, v.subtreeStore, v.utxoStore, oldBlockIDsMap, nil, blockHeaders, blockHeaderIDs, nil, v.settings); !ok {
+		return errors.NewBlockInvalidError("block is not valid", err)
+	}
+
+	var iterationErr error
+
+	oldBlockIDsMap.Iterate(func(txHash chainhash.Hash, blockIDs []uint32) bool {
+		onChain, err := v.blockchainClient.CheckBlockIsInCurrentChain(ctx, blockIDs)
+		if err != nil {
+			iterationErr = errors.NewServiceError("failed to check the parent blocks of transaction %s", txHash, err)
+			return false
+		}
+
+		if !onChain {
+			iterationErr = errors.NewBlockInvalidError("parent blocks %v of transaction %s are not on the current chain", blockIDs, txHash)
+			return false
+		}
+
+		return true
+	})
+
+	return iterationErr
+}
+
+// checkForFileType returns the check a missing blob of the file type is reported as
+func checkForFileType(fileType fileformat.FileType) Check {
+	if fileType == fileformat.FileTypeSubtreeData {
+		return CheckSubtreeData
+	}
+
+	return CheckSubtree
+}
+
+// highestCheckpointHeight returns the height of the highest checkpoint of the chain. The difficulty of the
+// blocks up to the checkpoint is not checked, as the block validation service does not check it either.
+func highestCheckpointHeight(tSettings *settings.Settings) uint32 {
+	var highest uint32
+
+	for _, checkpoint := range tSettings.ChainCfgParams.Checkpoints {
+		highest = max(highest, uint32(checkpoint.Height)) //nolint:gosec
+	}
+
+	return highest
+}
@@ -0,0 +1,278 @@
+package chainverifier
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/bsv-blockchain/go-chaincfg"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/model"
+	"github.com/bsv-blockchain/teranode/pkg/fileformat"
+	"github.com/bsv-blockchain/teranode/services/blockchain"
+	"github.com/bsv-blockchain/teranode/stores/blob/memory"
+	"github.com/bsv-blockchain/teranode/ulogger"
+	"github.com/bsv-blockchain/teranode/util/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mineBlock sets the nonce of the header to the first nonce meeting the target of the header
+func mineBlock(t *testing.T, header *model.BlockHeader) {
+	for {
+		ok, _, err := header.HasMetTargetDifficulty()
+		require.NoError(t, err)
+
+		if ok {
+			return
+		}
+
+		header.Nonce++
+	}
+}
+
+// testChain returns the regtest genesis block followed by n mined blocks. Every block has the coinbase
+// transaction of the genesis block and no subtrees, so the merkle root is the hash of the coinbase.
+func testChain(t *testing.T, n int) []*model.Block {
+	genesis, err := model.NewBlockFromMsgBlock(chaincfg.RegressionNetParams.GenesisBlock, nil)
+	require.NoError(t, err)
+
+	blocks := []*model.Block{genesis}
+
+	for height := 1; height <= n; height++ {
+		parent := blocks[height-1]
+
+		header := &model.BlockHeader{
+			Version:        1,
+			HashPrevBlock:  parent.Hash(),
+			HashMerkleRoot: genesis.CoinbaseTx.TxIDChainHash(),
+			Timestamp:      parent.Header.Timestamp + 600,
+			Bits:           genesis.Header.Bits,
+		}
+		mineBlock(t, header)
+
+		blocks = append(blocks, newTestBlock(header, genesis, uint32(height))) //nolint:gosec
+	}
+
+	return blocks
+}
+
+// newTestBlock returns a block with the header at the height, with the coinbase transaction of the genesis
+// block. Blocks cache their hash, so a block is replaced rather than modified when its header changes.
+func newTestBlock(header *model.BlockHeader, genesis *model.Block, height uint32) *model.Block {
+	return &model.Block{
+		Header:     header,
+		CoinbaseTx: genesis.CoinbaseTx,
+		Height:     height,
+	}
+}
+
+// newTestVerifier returns a verifier over a mocked blockchain client serving the blocks as the best chain,
+// nil blocks are not served
+func newTestVerifier(t *testing.T, blocks []*model.Block) (*Verifier, *blockchain.Mock, *memory.Memory) {
+	tSettings := test.CreateBaseTestSettings(t)
+	tSettings.ChainCfgParams = &chaincfg.RegressionNetParams
+
+	tip := blocks[len(blocks)-1]
+
+	requiredBits := blocks[0].Header.Bits
+
+	mockBlockchainClient := &blockchain.Mock{}
+	mockBlockchainClient.On("GetBestBlockHeader", mock.Anything).Return(tip.Header, &model.BlockHeaderMeta{Height: tip.Height}, nil)
+	mockBlockchainClient.On("GetNextWorkRequired", mock.Anything, mock.Anything, mock.Anything).Return(&requiredBits, nil)
+
+	for _, block := range blocks {
+		if block == nil {
+			continue
+		}
+
+		mockBlockchainClient.On("GetBlockByHeight", mock.Anything, block.Height).Return(block, nil)
+		mockBlockchainClient.On("GetBlockHeader", mock.Anything, block.Hash()).Return(block.Header, &model.BlockHeaderMeta{Height: block.Height}, nil)
+	}
+
+	subtreeStore := memory.New()
+
+	return New(ulogger.TestLogger{}, tSettings, mockBlockchainClient, subtreeStore, nil), mockBlockchainClient, subtreeStore
+}
+
+func TestVerify_ValidChain(t *testing.T) {
+	blocks := testChain(t, 5)
+	v, _, _ := newTestVerifier(t, blocks)
+
+	result, err := v.Verify(context.Background(), Options{Level: LevelMerkleRoot})
+	require.NoError(t, err)
+
+	assert.Equal(t, blocks[5].Hash().String(), result.TipHash)
+	assert.Equal(t, uint32(5), result.TipHeight)
+	assert.Equal(t, uint32(0), result.StartHeight)
+	assert.Equal(t, uint32(6), result.BlocksChecked)
+	assert.Empty(t, result.Problems)
+}
+
+func TestVerify_Depth(t *testing.T) {
+	blocks := testChain(t, 5)
+	v, _, _ := newTestVerifier(t, blocks)
+
+	result, err := v.Verify(context.Background(), Options{Level: LevelHeaders, Depth: 2})
+	require.NoError(t, err)
+
+	assert.Equal(t, uint32(4), result.StartHeight)
+	assert.Equal(t, uint32(2), result.BlocksChecked)
+	assert.Empty(t, result.Problems)
+
+	// a depth beyond the genesis block verifies the whole chain
+	result, err = v.Verify(context.Background(), Options{Level: LevelHeaders, Depth: 100})
+	require.NoError(t, err)
+
+	assert.Equal(t, uint32(0), result.StartHeight)
+	assert.Equal(t, uint32(6), result.BlocksChecked)
+}
+
+func TestVerify_HeaderProblems(t *testing.T) {
+	blocks := testChain(t, 3)
+
+	// block 2 does not link to block 1
+	otherParent := chainhash.DoubleHashH([]byte("other parent"))
+	header2 := *blocks[2].Header
+	header2.HashPrevBlock = &otherParent
+	mineBlock(t, &header2)
+	blocks[2] = newTestBlock(&header2, blocks[0], 2)
+
+	// block 3 links to block 2, but does not meet its target, which is not the required target either
+	header3 := *blocks[3].Header
+	header3.HashPrevBlock = blocks[2].Hash()
+	header3.Bits = model.NBit{0xff, 0xff, 0x00, 0x1d}
+	blocks[3] = newTestBlock(&header3, blocks[0], 3)
+
+	v, _, _ := newTestVerifier(t, blocks)
+
+	result, err := v.Verify(context.Background(), Options{Level: LevelDifficulty})
+	require.NoError(t, err)
+
+	checks := make([]Check, 0, len(result.Problems))
+	for _, problem := range result.Problems {
+		checks = append(checks, problem.Check)
+	}
+
+	assert.Equal(t, []Check{CheckHeader, CheckProofOfWork, CheckDifficulty}, checks)
+	assert.Equal(t, uint32(2), result.Problems[0].Height)
+	assert.Equal(t, blocks[2].Hash().String(), result.Problems[0].Hash)
+	assert.Equal(t, uint32(3), result.Problems[1].Height)
+}
+
+func TestVerify_LoadProblem(t *testing.T) {
+	blocks := testChain(t, 2)
+	parentHash := blocks[1].Hash()
+	blocks[1] = nil
+
+	v, mockBlockchainClient, _ := newTestVerifier(t, blocks)
+	mockBlockchainClient.On("GetBlockByHeight", mock.Anything, uint32(1)).Return(nil, errors.NewNotFoundError("block not found"))
+	mockBlockchainClient.On("GetBlockHeader", mock.Anything, parentHash).Return(nil, nil, errors.NewNotFoundError("block not found"))
+
+	result, err := v.Verify(context.Background(), Options{Level: LevelHeaders})
+	require.NoError(t, err)
+
+	require.Len(t, result.Problems, 2)
+	assert.Equal(t, uint32(1), result.Problems[0].Height)
+	assert.Equal(t, CheckLoad, result.Problems[0].Check)
+	assert.Empty(t, result.Problems[0].Hash)
+
+	// the parent of block 2 is looked up, as block 1 could not be loaded
+	assert.Equal(t, uint32(2), result.Problems[1].Height)
+	assert.Equal(t, CheckHeader, result.Problems[1].Check)
+	mockBlockchainClient.AssertCalled(t, "GetBlockHeader", mock.Anything, parentHash)
+}
+
+func TestVerify_SubtreeProblems(t *testing.T) {
+	ctx := context.Background()
+	blocks := testChain(t, 1)
+
+	subtreeHash := chainhash.DoubleHashH([]byte("subtree"))
+	blocks[1].Subtrees = []*chainhash.Hash{&subtreeHash}
+
+	v, _, subtreeStore := newTestVerifier(t, blocks)
+	require.NoError(t, subtreeStore.Set(ctx, subtreeHash[:], fileformat.FileTypeSubtree, []byte("subtree")))
+
+	result, err := v.Verify(ctx, Options{Level: LevelMerkleRoot})
+	require.NoError(t, err)
+
+	// the merkle root is not checked when a subtree blob is missing
+	require.Len(t, result.Problems, 1)
+	assert.Equal(t, CheckSubtreeData, result.Problems[0].Check)
+	assert.Equal(t, uint32(1), result.Problems[0].Height)
+	assert.Contains(t, result.Problems[0].Message, subtreeHash.String())
+}
+
+func TestVerify_MerkleRootProblem(t *testing.T) {
+	blocks := testChain(t, 2)
+
+	otherRoot := chainhash.DoubleHashH([]byte("other merkle root"))
+	header := *blocks[2].Header
+	header.HashMerkleRoot = &otherRoot
+	mineBlock(t, &header)
+	blocks[2] = newTestBlock(&header, blocks[0], 2)
+
+	v, _, _ := newTestVerifier(t, blocks)
+
+	result, err := v.Verify(context.Background(), Options{Level: LevelSubtrees})
+	require.NoError(t, err)
+	assert.Empty(t, result.Problems)
+
+	result, err = v.Verify(context.Background(), Options{Level: LevelMerkleRoot})
+	require.NoError(t, err)
+
+	require.Len(t, result.Problems, 1)
+	assert.Equal(t, CheckMerkleRoot, result.Problems[0].Check)
+	assert.Equal(t, uint32(2), result.Problems[0].Height)
+}
+
+func TestVerify_Resume(t *testing.T) {
+	blocks := testChain(t, 5)
+	v, _, _ := newTestVerifier(t, blocks)
+
+	stopAt := uint32(2)
+	stopErr := errors.NewProcessingError("interrupted")
+
+	var verified []uint32
+
+	progress := func(height uint32, problems []Problem) error {
+		verified = append(verified, height)
+
+		if height == stopAt {
+			return stopErr
+		}
+
+		return nil
+	}
+
+	_, err := v.Verify(context.Background(), Options{Level: LevelDifficulty, Progress: progress})
+	require.ErrorIs(t, err, stopErr)
+
+	startHeight := verified[len(verified)-1] + 1
+	stopAt = 0
+
+	result, err := v.Verify(context.Background(), Options{Level: LevelDifficulty, StartHeight: &startHeight, Progress: progress})
+	require.NoError(t, err)
+
+	assert.Equal(t, uint32(3), result.StartHeight)
+	assert.Equal(t, uint32(3), result.BlocksChecked)
+	assert.Equal(t, []uint32{0, 1, 2, 3, 4, 5}, verified)
+}
+
+func TestVerify_InvalidOptions(t *testing.T) {
+	blocks := testChain(t, 1)
+	v, _, _ := newTestVerifier(t, blocks)
+
+	_, err := v.Verify(context.Background(), Options{Level: LevelBlock + 1})
+	require.Error(t, err)
+
+	// the UTXO store is required to validate blocks
+	_, err = v.Verify(context.Background(), Options{Level: LevelBlock})
+	require.Error(t, err)
+
+	v.subtreeStore = nil
+
+	_, err = v.Verify(context.Background(), Options{Level: LevelSubtrees})
+	require.Error(t, err)
+}
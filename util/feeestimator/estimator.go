@@ -0,0 +1,352 @@
+// Package feeestimator estimates the fee rate a transaction needs to be mined within a number of blocks.
+//
+// The estimator records the fee rate of the transactions accepted by the validator, and the number of
+// blocks it took for them to be mined. Transactions are grouped in exponentially spaced fee rate buckets,
+// and for every bucket the estimator keeps the number of transactions that were mined within each target
+// number of blocks. These counts decay with every new block, so recent blocks weigh more than old ones.
+//
+// An estimate for a target is the lowest fee rate for which a sufficient share of the transactions paying
+// at least that fee rate were mined within the target number of blocks.
+//
+// The estimator is fed by wrapping the UTXO store with NewUtxoStore, see utxo_store.go. It is kept in memory
+// and not shared between processes, so it only sees the transactions validated and mined in its own process.
+package feeestimator
+
+import (
+	"math"
+	"sync"
+
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+)
+
+const (
+	// MaxTarget is the highest number of blocks an estimate can be made for
+	MaxTarget = 24
+
+	// minBucketFeeRate is the upper bound of the lowest fee rate bucket, in satoshis per kB
+	minBucketFeeRate = 1.0
+
+	// maxBucketFeeRate is the lower bound of the highest fee rate bucket, in satoshis per kB
+	maxBucketFeeRate = 1e7
+
+	// bucketSpacing is the ratio between the bounds of consecutive fee rate buckets
+	bucketSpacing = 1.25
+
+	// decay is the factor all counts are multiplied with for every new block, a half life of ~350 blocks
+	decay = 0.998
+
+	// sufficientTxs is the minimum (decayed) number of transactions a range of buckets needs for an estimate
+	sufficientTxs = 10.0
+
+	// conservativeThreshold is the share of transactions that must have been mined within the target for a
+	// conservative estimate
+	conservativeThreshold = 0.95
+
+	// economicalThreshold is the share of transactions that must have been mined within the target for an
+	// economical estimate
+	economicalThreshold = 0.85
+)
+
+// SummaryTargets are the targets, in blocks, of the estimates returned by Summary
+var SummaryTargets = []int{1, 2, 3, 6, 12, 24}
+
+// pendingTx is a transaction accepted by the validator that has not been mined yet
+type pendingTx struct {
+	height  uint32  // height of the block the transaction was accepted for
+	bucket  int     // fee rate bucket of the transaction
+	feeRate float64 // fee rate of the transaction in satoshis per kB
+}
+
+// Estimate is the fee rate estimated for a transaction to be mined within a number of blocks
+type Estimate struct {
+	Blocks  int     `json:"blocks"`  // target number of blocks
+	FeeRate float64 `json:"feeRate"` // fee rate in satoshis per kB, -1 when no estimate is available
+}
+
+// Summary holds the estimates for the SummaryTargets
+type Summary struct {
+	Height              uint32     `json:"height"`              // height of the last block seen by the estimator
+	TrackedTransactions int        `json:"trackedTransactions"` // number of transactions waiting to be mined
+	MinFeeRate          float64    `json:"minFeeRate"`          // minimum fee rate of the estimates in satoshis per kB
+	Estimates           []Estimate `json:"estimates"`
+}
+
+// Estimator records the fee rates of accepted transactions and the number of blocks they took to be mined,
+// and estimates fee rates from them. It is safe for concurrent use.
+type Estimator struct {
+	mu         sync.Mutex
+	maxTracked int
+	minFeeRate float64
+
+	// bounds holds the lower bound of every bucket, in satoshis per kB, the first bucket starts at 0
+	bounds []float64
+
+	// totals holds the decayed number of transactions per bucket that were mined, or expired
+	totals []float64
+
+	// feeRates holds the decayed sum of the fee rates of the transactions counted in totals, per bucket
+	feeRates []float64
+
+	// mined holds the decayed number of transactions per bucket mined within target+1 blocks, per target
+	mined [][]float64
+
+	// pending holds the transactions that have not been mined yet
+	pending map[chainhash.Hash]pendingTx
+
+	// height is the height of the last block seen
+	height uint32
+}
+
+// New creates a fee estimator tracking at most maxTracked unmined transactions at a time. Estimates are
+// never lower than minFeeRate, in satoshis per kB, which should be the minimum fee rate of the node.
+func New(maxTracked int, minFeeRate float64) *Estimator {
+	initPrometheusMetrics()
+
+	bounds := []float64{0}
+	for bound := minBucketFeeRate; bound <= maxBucketFeeRate; bound *= bucketSpacing {
+		bounds = append(bounds, bound)
+	}
+
+	mined := make([][]float64, MaxTarget)
+	for i := range mined {
+		mined[i] = make([]float64, len(bounds))
+	}
+
+	return &Estimator{
+		maxTracked: maxTracked,
+		minFeeRate: minFeeRate,
+		bounds:     bounds,
+		totals:     make([]float64, len(bounds)),
+		feeRates:   make([]float64, len(bounds)),
+		mined:      mined,
+		pending:    make(map[chainhash.Hash]pendingTx),
+	}
+}
+
+// AddTransaction records a transaction accepted for the block at the given height, with its fee and size
+// in bytes. Transactions are ignored when the maximum number of tracked transactions is reached.
+func (e *Estimator) AddTransaction(hash chainhash.Hash, fee uint64, size uint64, height uint32) {
+	if size == 0 {
+		return
+	}
+
+	feeRate := float64(fee) * 1000 / float64(size)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, exists := e.pending[hash]; exists {
+		return
+	}
+
+	if len(e.pending) >= e.maxTracked {
+		prometheusFeeEstimatorSkipped.Inc()
+		return
+	}
+
+	e.pending[hash] = pendingTx{
+		height:  height,
+		bucket:  e.bucketIndex(feeRate),
+		feeRate: feeRate,
+	}
+
+	prometheusFeeEstimatorFeeRate.Observe(feeRate)
+	prometheusFeeEstimatorTracked.Set(float64(len(e.pending)))
+}
+
+// ProcessMined records that the transactions were mined in the block at the given height. Unknown
+// transactions are ignored. When the height is higher than the last height seen, the counts are decayed
+// and the transactions that were not mined within MaxTarget blocks are counted as failures.
+func (e *Estimator) ProcessMined(hashes []*chainhash.Hash, height uint32) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if height > e.height {
+		e.processBlock(height)
+	}
+
+	for _, hash := range hashes {
+		tx, exists := e.pending[*hash]
+		if !exists {
+			continue
+		}
+
+		delete(e.pending, *hash)
+
+		// transactions are accepted for the next block, a transaction mined in that block took 1 block
+		blocks := 1
+		if height > tx.height {
+			blocks = int(height-tx.height) + 1
+		}
+
+		e.totals[tx.bucket]++
+		e.feeRates[tx.bucket] += tx.feeRate
+
+		for target := blocks - 1; target < MaxTarget; target++ {
+			e.mined[target][tx.bucket]++
+		}
+
+		prometheusFeeEstimatorBlocksToMined.Observe(float64(blocks))
+	}
+
+	prometheusFeeEstimatorTracked.Set(float64(len(e.pending)))
+}
+
+// processBlock decays the counts for every block since the last height seen, and expires the pending
+// transactions that were not mined within MaxTarget blocks.
+func (e *Estimator) processBlock(height uint32) {
+	if e.height > 0 {
+		factor := math.Pow(decay, float64(height-e.height))
+
+		for i := range e.totals {
+			e.totals[i] *= factor
+			e.feeRates[i] *= factor
+		}
+
+		for _, counts := range e.mined {
+			for i := range counts {
+				counts[i] *= factor
+			}
+		}
+	}
+
+	e.height = height
+
+	for hash, tx := range e.pending {
+		if height >= tx.height && int(height-tx.height)+1 > MaxTarget {
+			delete(e.pending, hash)
+
+			e.totals[tx.bucket]++
+			e.feeRates[tx.bucket] += tx.feeRate
+
+			prometheusFeeEstimatorExpired.Inc()
+		}
+	}
+}
+
+// bucketIndex returns the index of the bucket of a fee rate in satoshis per kB
+func (e *Estimator) bucketIndex(feeRate float64) int {
+	for i := len(e.bounds) - 1; i > 0; i-- {
+		if feeRate >= e.bounds[i] {
+			return i
+		}
+	}
+
+	return 0
+}
+
+// EstimateFee returns the fee rate in satoshis per kB for a transaction to be mined within target blocks.
+// A conservative estimate requires a higher share of transactions to have been mined within the target.
+// It returns false when not enough transactions have been seen for an estimate.
+func (e *Estimator) EstimateFee(target int, conservative bool) (float64, bool) {
+	if target < 1 || target > MaxTarget {
+		return 0, false
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.estimateFee(target, conservative)
+}
+
+// EstimateSmartFee returns the fee rate in satoshis per kB for a transaction to be mined within target
+// blocks. When no estimate is available for the target, higher targets are tried, up to MaxTarget. It
+// returns the target the estimate was made for, and false when no estimate is available at all.
+func (e *Estimator) EstimateSmartFee(target int, conservative bool) (float64, int, bool) {
+	if target < 1 {
+		target = 1
+	}
+
+	if target > MaxTarget {
+		target = MaxTarget
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for ; target <= MaxTarget; target++ {
+		if feeRate, ok := e.estimateFee(target, conservative); ok {
+			return feeRate, target, true
+		}
+	}
+
+	return 0, MaxTarget, false
+}
+
+// Summary returns conservative estimates for the SummaryTargets.
+func (e *Estimator) Summary() *Summary {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	summary := &Summary{
+		Height:              e.height,
+		TrackedTransactions: len(e.pending),
+		MinFeeRate:          e.minFeeRate,
+		Estimates:           make([]Estimate, 0, len(SummaryTargets)),
+	}
+
+	for _, target := range SummaryTargets {
+		feeRate, ok := e.estimateFee(target, true)
+		if !ok {
+			feeRate = -1
+		}
+
+		summary.Estimates = append(summary.Estimates, Estimate{Blocks: target, FeeRate: feeRate})
+	}
+
+	return summary
+}
+
+// estimateFee walks the buckets from the highest fee rate down, grouping buckets until they hold enough
+// transactions. Every group in which the share of transactions mined within the target reaches the
+// threshold passes, and the walk stops at the first group that fails. The estimate is the average fee
+// rate of the lowest passing group. Pending transactions that have waited longer than the target are
+// counted as failures. The caller must hold the lock.
+func (e *Estimator) estimateFee(target int, conservative bool) (float64, bool) {
+	threshold := economicalThreshold
+	if conservative {
+		threshold = conservativeThreshold
+	}
+
+	// pending transactions that already waited longer than the target have failed it
+	failed := make([]float64, len(e.bounds))
+
+	for _, tx := range e.pending {
+		if e.height >= tx.height && int(e.height-tx.height)+1 > target {
+			failed[tx.bucket]++
+		}
+	}
+
+	var (
+		found                     bool
+		bestFeeRate               float64
+		groupTotal, groupMined    float64
+		groupCount, groupFeeRates float64
+	)
+
+	for i := len(e.bounds) - 1; i >= 0; i-- {
+		groupTotal += e.totals[i] + failed[i]
+		groupMined += e.mined[target-1][i]
+		groupCount += e.totals[i]
+		groupFeeRates += e.feeRates[i]
+
+		if groupTotal < sufficientTxs {
+			continue
+		}
+
+		if groupMined/groupTotal < threshold {
+			break
+		}
+
+		found = true
+		bestFeeRate = groupFeeRates / groupCount
+
+		groupTotal, groupMined, groupCount, groupFeeRates = 0, 0, 0, 0
+	}
+
+	if !found {
+		return 0, false
+	}
+
+	return math.Max(bestFeeRate, e.minFeeRate), true
+}
@@ -0,0 +1,201 @@
+package feeestimator
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// txHashes returns n distinct transaction hashes, starting at offset
+func txHashes(offset, n int) []*chainhash.Hash {
+	hashes := make([]*chainhash.Hash, n)
+
+	for i := range hashes {
+		hash := chainhash.Hash{}
+		binary.LittleEndian.PutUint64(hash[:], uint64(offset+i)) //nolint:gosec
+
+		hashes[i] = &hash
+	}
+
+	return hashes
+}
+
+// addTransactions adds the transactions to the estimator with a size of 1 kB and the given fee
+func addTransactions(e *Estimator, hashes []*chainhash.Hash, fee uint64, height uint32) {
+	for _, hash := range hashes {
+		e.AddTransaction(*hash, fee, 1000, height)
+	}
+}
+
+func TestEstimator_NoData(t *testing.T) {
+	e := New(1000, 0)
+
+	_, ok := e.EstimateFee(1, true)
+	assert.False(t, ok)
+
+	_, target, ok := e.EstimateSmartFee(1, true)
+	assert.False(t, ok)
+	assert.Equal(t, MaxTarget, target)
+
+	_, ok = e.EstimateFee(0, true)
+	assert.False(t, ok)
+
+	_, ok = e.EstimateFee(MaxTarget+1, true)
+	assert.False(t, ok)
+}
+
+func TestEstimator_EstimateFee(t *testing.T) {
+	e := New(1000, 0)
+
+	// high fee transactions are mined in the next block, low fee transactions 4 blocks later
+	highFee := txHashes(0, 100)
+	lowFee := txHashes(100, 100)
+
+	addTransactions(e, highFee, 1000, 101)
+	addTransactions(e, lowFee, 10, 101)
+
+	e.ProcessMined(highFee, 101)
+	e.ProcessMined(lowFee, 105)
+
+	feeRate, ok := e.EstimateFee(1, true)
+	require.True(t, ok)
+	assert.InDelta(t, 1000, feeRate, 0.001)
+
+	feeRate, ok = e.EstimateFee(4, true)
+	require.True(t, ok)
+	assert.InDelta(t, 1000, feeRate, 0.001)
+
+	feeRate, ok = e.EstimateFee(5, true)
+	require.True(t, ok)
+	assert.InDelta(t, 10, feeRate, 0.001)
+
+	t.Run("minimum fee rate", func(t *testing.T) {
+		e.minFeeRate = 50
+
+		feeRate, ok := e.EstimateFee(5, true)
+		require.True(t, ok)
+		assert.InDelta(t, 50, feeRate, 0.001)
+	})
+}
+
+func TestEstimator_EstimateSmartFee(t *testing.T) {
+	e := New(1000, 0)
+
+	hashes := txHashes(0, 20)
+	addTransactions(e, hashes, 100, 11)
+	e.ProcessMined(hashes, 13)
+
+	_, ok := e.EstimateFee(1, true)
+	assert.False(t, ok)
+
+	feeRate, target, ok := e.EstimateSmartFee(1, true)
+	require.True(t, ok)
+	assert.Equal(t, 3, target)
+	assert.InDelta(t, 100, feeRate, 0.001)
+
+	feeRate, target, ok = e.EstimateSmartFee(MaxTarget+10, false)
+	require.True(t, ok)
+	assert.Equal(t, MaxTarget, target)
+	assert.InDelta(t, 100, feeRate, 0.001)
+}
+
+func TestEstimator_Thresholds(t *testing.T) {
+	e := New(1000, 0)
+
+	// 90% of the transactions are mined in the next block
+	mined := txHashes(0, 90)
+	late := txHashes(90, 10)
+
+	addTransactions(e, mined, 100, 11)
+	addTransactions(e, late, 100, 11)
+
+	e.ProcessMined(mined, 11)
+	e.ProcessMined(late, 12)
+
+	_, ok := e.EstimateFee(1, true)
+	assert.False(t, ok)
+
+	feeRate, ok := e.EstimateFee(1, false)
+	require.True(t, ok)
+	assert.InDelta(t, 100, feeRate, 0.001)
+}
+
+func TestEstimator_PendingTransactions(t *testing.T) {
+	e := New(1000, 0)
+
+	mined := txHashes(0, 20)
+	stuck := txHashes(20, 20)
+
+	addTransactions(e, mined, 1000, 11)
+	addTransactions(e, stuck, 10, 11)
+
+	e.ProcessMined(mined, 11)
+	e.ProcessMined(nil, 15)
+
+	// the stuck transactions count as failures for targets they already waited longer than
+	feeRate, ok := e.EstimateFee(2, true)
+	require.True(t, ok)
+	assert.InDelta(t, 1000, feeRate, 0.001)
+
+	summary := e.Summary()
+	assert.Equal(t, uint32(15), summary.Height)
+	assert.Equal(t, 20, summary.TrackedTransactions)
+
+	// the stuck transactions expire after MaxTarget blocks
+	e.ProcessMined(nil, 11+MaxTarget)
+
+	summary = e.Summary()
+	assert.Equal(t, 0, summary.TrackedTransactions)
+
+	_, ok = e.EstimateFee(MaxTarget, true)
+	require.True(t, ok)
+}
+
+func TestEstimator_MaxTracked(t *testing.T) {
+	e := New(10, 0)
+
+	addTransactions(e, txHashes(0, 20), 100, 1)
+
+	assert.Equal(t, 10, e.Summary().TrackedTransactions)
+}
+
+func TestEstimator_Summary(t *testing.T) {
+	e := New(1000, 5)
+
+	hashes := txHashes(0, 20)
+	addTransactions(e, hashes, 100, 11)
+	e.ProcessMined(hashes, 12)
+
+	summary := e.Summary()
+
+	assert.Equal(t, uint32(12), summary.Height)
+	assert.Equal(t, 0, summary.TrackedTransactions)
+	assert.InDelta(t, 5, summary.MinFeeRate, 0.001)
+	require.Len(t, summary.Estimates, len(SummaryTargets))
+
+	assert.Equal(t, Estimate{Blocks: 1, FeeRate: -1}, summary.Estimates[0])
+
+	for _, estimate := range summary.Estimates[1:] {
+		assert.InDelta(t, 100, estimate.FeeRate, 0.001)
+	}
+}
+
+func TestEstimator_Decay(t *testing.T) {
+	e := New(1000, 0)
+
+	hashes := txHashes(0, 20)
+	addTransactions(e, hashes, 100, 11)
+	e.ProcessMined(hashes, 11)
+
+	_, ok := e.EstimateFee(1, true)
+	require.True(t, ok)
+
+	// after enough blocks the decayed counts are no longer sufficient for an estimate
+	e.ProcessMined(nil, 11+400)
+
+	_, ok = e.EstimateFee(1, true)
+	assert.False(t, ok)
+}
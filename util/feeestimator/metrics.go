@@ -0,0 +1,83 @@
+package feeestimator
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Prometheus metrics variables for monitoring the fee estimator.
+var (
+	// prometheusFeeEstimatorFeeRate tracks the fee rates of the accepted transactions in satoshis per kB
+	prometheusFeeEstimatorFeeRate prometheus.Histogram
+
+	// prometheusFeeEstimatorBlocksToMined tracks the number of blocks it took for transactions to be mined
+	prometheusFeeEstimatorBlocksToMined prometheus.Histogram
+
+	// prometheusFeeEstimatorTracked tracks the number of transactions waiting to be mined
+	prometheusFeeEstimatorTracked prometheus.Gauge
+
+	// prometheusFeeEstimatorExpired counts the transactions that were not mined within the maximum target
+	prometheusFeeEstimatorExpired prometheus.Counter
+
+	// prometheusFeeEstimatorSkipped counts the transactions not tracked because the limit was reached
+	prometheusFeeEstimatorSkipped prometheus.Counter
+)
+
+var (
+	prometheusMetricsInitOnce sync.Once
+)
+
+func initPrometheusMetrics() {
+	prometheusMetricsInitOnce.Do(_initPrometheusMetrics)
+}
+
+func _initPrometheusMetrics() {
+	prometheusFeeEstimatorFeeRate = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "teranode",
+			Subsystem: "fee_estimator",
+			Name:      "fee_rate",
+			Help:      "Fee rates of the accepted transactions in satoshis per kB",
+			Buckets:   []float64{0, 1, 2, 5, 10, 25, 50, 100, 250, 500, 1000, 5000},
+		},
+	)
+
+	prometheusFeeEstimatorBlocksToMined = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "teranode",
+			Subsystem: "fee_estimator",
+			Name:      "blocks_to_mined",
+			Help:      "Number of blocks it took for accepted transactions to be mined",
+			Buckets:   []float64{1, 2, 3, 4, 6, 8, 12, 16, 24},
+		},
+	)
+
+	prometheusFeeEstimatorTracked = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "teranode",
+			Subsystem: "fee_estimator",
+			Name:      "tracked_transactions",
+			Help:      "Number of accepted transactions waiting to be mined",
+		},
+	)
+
+	prometheusFeeEstimatorExpired = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "teranode",
+			Subsystem: "fee_estimator",
+			Name:      "expired_transactions",
+			Help:      "Number of accepted transactions that were not mined within the maximum target",
+		},
+	)
+
+	prometheusFeeEstimatorSkipped = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "teranode",
+			Subsystem: "fee_estimator",
+			Name:      "skipped_transactions",
+			Help:      "Number of accepted transactions not tracked because the limit was reached",
+		},
+	)
+}
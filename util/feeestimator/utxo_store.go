@@ -0,0 +1,88 @@
+package feeestimator
+
+import (
+	"context"
+
+	"github.com/bsv-blockchain/go-bt/v2"
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/bsv-blockchain/teranode/stores/cleanup"
+	"github.com/bsv-blockchain/teranode/stores/utxo"
+	"github.com/bsv-blockchain/teranode/stores/utxo/meta"
+)
+
+// UtxoStore wraps a utxo.Store and feeds the estimator with the transactions created in the store, and
+// the transactions marked as mined on the longest chain. All other operations are passed through.
+type UtxoStore struct {
+	utxo.Store
+	estimator *Estimator
+}
+
+// NewUtxoStore wraps the UTXO store to feed the estimator.
+func NewUtxoStore(store utxo.Store, estimator *Estimator) *UtxoStore {
+	return &UtxoStore{
+		Store:     store,
+		estimator: estimator,
+	}
+}
+
+// Create creates the transaction in the wrapped store, and adds it to the estimator when it was created
+// unmined. Coinbase and conflicting transactions are not added.
+func (s *UtxoStore) Create(ctx context.Context, tx *bt.Tx, blockHeight uint32, opts ...utxo.CreateOption) (*meta.Data, error) {
+	data, err := s.Store.Create(ctx, tx, blockHeight, opts...)
+	if err != nil {
+		return data, err
+	}
+
+	options := &utxo.CreateOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if data == nil || len(options.MinedBlockInfos) > 0 || options.Conflicting || data.IsCoinbase {
+		return data, nil
+	}
+
+	txHash := options.TxID
+	if txHash == nil {
+		txHash = tx.TxIDChainHash()
+	}
+
+	s.estimator.AddTransaction(*txHash, data.Fee, data.SizeInBytes, blockHeight)
+
+	return data, nil
+}
+
+// SetMinedMulti marks the transactions as mined in the wrapped store, and passes them to the estimator
+// when they were mined on the longest chain.
+func (s *UtxoStore) SetMinedMulti(ctx context.Context, hashes []*chainhash.Hash, minedBlockInfo utxo.MinedBlockInfo) (map[chainhash.Hash][]uint32, error) {
+	blockIDsMap, err := s.Store.SetMinedMulti(ctx, hashes, minedBlockInfo)
+	if err != nil {
+		return blockIDsMap, err
+	}
+
+	if minedBlockInfo.OnLongestChain && !minedBlockInfo.UnsetMined {
+		s.estimator.ProcessMined(hashes, minedBlockInfo.BlockHeight)
+	}
+
+	return blockIDsMap, nil
+}
+
+// GetCleanupService returns the cleanup service of the wrapped store, or nil when it does not provide one.
+func (s *UtxoStore) GetCleanupService() (cleanup.Service, error) {
+	if provider, ok := s.Store.(cleanup.CleanupServiceProvider); ok {
+		return provider.GetCleanupService()
+	}
+
+	return nil, nil
+}
+
+// WaitForIndexReady waits for an index of the wrapped store to be ready, when the store has indexes.
+func (s *UtxoStore) WaitForIndexReady(ctx context.Context, indexName string) error {
+	if waiter, ok := s.Store.(interface {
+		WaitForIndexReady(ctx context.Context, indexName string) error
+	}); ok {
+		return waiter.WaitForIndexReady(ctx, indexName)
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package feeestimator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bt/v2"
+	"github.com/bsv-blockchain/go-bt/v2/chainhash"
+	"github.com/bsv-blockchain/teranode/errors"
+	"github.com/bsv-blockchain/teranode/stores/utxo"
+	"github.com/bsv-blockchain/teranode/stores/utxo/meta"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUtxoStore_Create(t *testing.T) {
+	ctx := context.Background()
+	tx := bt.NewTx()
+
+	tests := []struct {
+		name    string
+		data    *meta.Data
+		opts    []utxo.CreateOption
+		err     error
+		tracked int
+	}{
+		{
+			name:    "unmined transaction",
+			data:    &meta.Data{Fee: 100, SizeInBytes: 250},
+			tracked: 1,
+		},
+		{
+			name: "mined transaction",
+			data: &meta.Data{Fee: 100, SizeInBytes: 250},
+			opts: []utxo.CreateOption{utxo.WithMinedBlockInfo(utxo.MinedBlockInfo{BlockID: 1, BlockHeight: 1})},
+		},
+		{
+			name: "conflicting transaction",
+			data: &meta.Data{Fee: 100, SizeInBytes: 250},
+			opts: []utxo.CreateOption{utxo.WithConflicting(true)},
+		},
+		{
+			name: "coinbase transaction",
+			data: &meta.Data{SizeInBytes: 250, IsCoinbase: true},
+		},
+		{
+			name: "store error",
+			data: &meta.Data{},
+			err:  errors.NewTxExistsError("exists"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &utxo.MockUtxostore{}
+			mockStore.On("Create", ctx, tx, uint32(10), mock.Anything).Return(tt.data, tt.err)
+
+			estimator := New(1000, 0)
+			store := NewUtxoStore(mockStore, estimator)
+
+			data, err := store.Create(ctx, tx, 10, tt.opts...)
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.data, data)
+			}
+
+			assert.Equal(t, tt.tracked, estimator.Summary().TrackedTransactions)
+		})
+	}
+}
+
+func TestUtxoStore_SetMinedMulti(t *testing.T) {
+	ctx := context.Background()
+	hashes := []*chainhash.Hash{bt.NewTx().TxIDChainHash()}
+
+	tests := []struct {
+		name           string
+		minedBlockInfo utxo.MinedBlockInfo
+		tracked        int
+	}{
+		{
+			name:           "mined on the longest chain",
+			minedBlockInfo: utxo.MinedBlockInfo{BlockID: 1, BlockHeight: 10, OnLongestChain: true},
+			tracked:        0,
+		},
+		{
+			name:           "mined on another chain",
+			minedBlockInfo: utxo.MinedBlockInfo{BlockID: 1, BlockHeight: 10},
+			tracked:        1,
+		},
+		{
+			name:           "unset mined",
+			minedBlockInfo: utxo.MinedBlockInfo{BlockID: 1, BlockHeight: 10, OnLongestChain: true, UnsetMined: true},
+			tracked:        1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &utxo.MockUtxostore{}
+			mockStore.On("SetMinedMulti", ctx, hashes, tt.minedBlockInfo).Return(map[chainhash.Hash][]uint32{}, nil)
+
+			estimator := New(1000, 0)
+			estimator.AddTransaction(*hashes[0], 100, 250, 10)
+
+			store := NewUtxoStore(mockStore, estimator)
+
+			_, err := store.SetMinedMulti(ctx, hashes, tt.minedBlockInfo)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.tracked, estimator.Summary().TrackedTransactions)
+			mockStore.AssertExpectations(t)
+		})
+	}
+}
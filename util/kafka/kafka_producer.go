@@ -57,21 +57,25 @@ func (k *SyncKafkaProducer) GetClient() sarama.ConsumerGroup {
 }
 
 // Send publishes a message to Kafka with the specified key and data.
-// The partition is determined by hashing the key.
+// The partition is determined by hashing the key, messages are sent to partition 0 when the producer has
+// no partitions, like the in-memory producer.
 func (k *SyncKafkaProducer) Send(key []byte, data []byte) error {
-	kPartitionsUint32, err := safeconversion.Int32ToUint32(k.Partitions)
-	if err != nil {
-		return err
-	}
+	var partitionInt32 int32
+
+	if k.Partitions > 0 {
+		kPartitionsUint32, err := safeconversion.Int32ToUint32(k.Partitions)
+		if err != nil {
+			return err
+		}
 
-	partition := binary.LittleEndian.Uint32(key) % kPartitionsUint32
+		partition := binary.LittleEndian.Uint32(key) % kPartitionsUint32
 
-	partitionInt32, err := safeconversion.Uint32ToInt32(partition)
-	if err != nil {
-		return err
+		if partitionInt32, err = safeconversion.Uint32ToInt32(partition); err != nil {
+			return err
+		}
 	}
 
-	_, _, err = k.Producer.SendMessage(&sarama.ProducerMessage{
+	_, _, err := k.Producer.SendMessage(&sarama.ProducerMessage{
 		Topic:     k.Topic,
 		Key:       sarama.ByteEncoder(key),
 		Value:     sarama.ByteEncoder(data),
@@ -247,10 +247,9 @@ func TestNewKafkaProducer_MemoryScheme(t *testing.T) {
 	require.True(t, ok)
 	assert.Equal(t, "test-topic", syncProducer.Topic)
 
-	// Note: Memory scheme producer has 0 partitions, so sending messages would cause divide by zero
-	// This is a limitation of the current implementation - the Send method should handle this case
-	// For now, just test that the producer was created correctly
+	// Memory scheme producer has 0 partitions, its messages are sent to partition 0
 	assert.Equal(t, int32(0), syncProducer.Partitions)
+	assert.NoError(t, producer.Send([]byte("test-key"), []byte("test-value")))
 }
 
 func TestNewKafkaProducer_InvalidScheme(t *testing.T) {